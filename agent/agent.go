@@ -52,6 +52,20 @@ const (
 	// command just returning a nonzero exit code, and is chosen as an arbitrary, high number
 	// unlikely to shadow other exit codes, which are typically 1, 2, 3, etc.
 	MagicSessionErrorCode = 229
+
+	// MagicSessionTypeEnvironmentVariable is set by SSH clients to report the
+	// type of a session in the agent stats. Sessions without it are
+	// recognized by the commands IDEs run, and are plain SSH sessions
+	// otherwise.
+	MagicSessionTypeEnvironmentVariable = "CODER_SSH_SESSION_TYPE"
+)
+
+// Session types reported in the agent stats.
+const (
+	SessionTypeSSH             = "ssh"
+	SessionTypeReconnectingPTY = "reconnecting-pty"
+	SessionTypeVSCode          = "vscode"
+	SessionTypeJetBrains       = "jetbrains"
 )
 
 type Options struct {
@@ -70,6 +84,11 @@ type Client interface {
 	AgentReportStats(ctx context.Context, log slog.Logger, stats func() *codersdk.AgentStats) (io.Closer, error)
 	PostWorkspaceAgentAppHealth(ctx context.Context, req codersdk.PostWorkspaceAppHealthsRequest) error
 	PostWorkspaceAgentVersion(ctx context.Context, version string) error
+	PatchStartupLogs(ctx context.Context, req codersdk.PatchStartupLogs) error
+	PostWorkspaceAgentLifecycle(ctx context.Context, req codersdk.PostWorkspaceAgentLifecycleRequest) error
+	WorkspaceAgentAwaitShutdown(ctx context.Context) error
+	PostWorkspaceAgentMetadata(ctx context.Context, key string, req codersdk.PostWorkspaceAgentMetadataRequest) error
+	PostWorkspaceAgentSessionRecording(ctx context.Context, req codersdk.PostWorkspaceAgentSessionRecordingRequest) error
 }
 
 func New(options Options) io.Closer {
@@ -98,7 +117,11 @@ func New(options Options) io.Closer {
 		exchangeToken:          options.ExchangeToken,
 		filesystem:             options.Filesystem,
 		tempDir:                options.TempDir,
+		lifecycleUpdate:        make(chan struct{}, 1),
+		lifecycleReported:      make(chan codersdk.WorkspaceAgentLifecycle, 1),
+		lifecycleState:         codersdk.WorkspaceAgentLifecycleCreated,
 		stats:                  &Stats{},
+		resourceUsage:          newResourceUsageCollector(),
 	}
 	server.init(ctx)
 	return server
@@ -125,8 +148,16 @@ type agent struct {
 	sessionToken atomic.Pointer[string]
 	sshServer    *ssh.Server
 
-	network *tailnet.Conn
-	stats   *Stats
+	lifecycleUpdate   chan struct{}
+	lifecycleReported chan codersdk.WorkspaceAgentLifecycle
+	lifecycleMu       sync.RWMutex // Protects following.
+	lifecycleState    codersdk.WorkspaceAgentLifecycle
+
+	shutdownOnce sync.Once
+
+	network       *tailnet.Conn
+	stats         *Stats
+	resourceUsage *resourceUsageCollector
 }
 
 // runLoop attempts to start the agent in a retry loop.
@@ -179,15 +210,26 @@ func (a *agent) run(ctx context.Context) error {
 
 	// The startup script should only execute on the first run!
 	if oldMetadata == nil {
+		a.setLifecycle(ctx, codersdk.WorkspaceAgentLifecycleStarting)
+
 		go func() {
-			err := a.runStartupScript(ctx, metadata.StartupScript)
+			lifecycleState := codersdk.WorkspaceAgentLifecycleReady
+			err := a.runStartupScript(ctx, metadata.StartupScript, metadata.StartupScriptTimeout)
 			if errors.Is(err, context.Canceled) {
 				return
 			}
 			if err != nil {
 				a.logger.Warn(ctx, "agent script failed", slog.Error(err))
+				lifecycleState = codersdk.WorkspaceAgentLifecycleStartError
+				if errors.Is(err, context.DeadlineExceeded) {
+					lifecycleState = codersdk.WorkspaceAgentLifecycleStartTimeout
+				}
 			}
+			a.setLifecycle(ctx, lifecycleState)
 		}()
+
+		go a.watchShutdown(ctx)
+		go a.reportMetadataLoop(ctx, metadata.Metadata)
 	}
 
 	if metadata.GitAuthConfigs > 0 {
@@ -376,30 +418,202 @@ func (a *agent) runCoordinator(ctx context.Context, network *tailnet.Conn) error
 	}
 }
 
-func (a *agent) runStartupScript(ctx context.Context, script string) error {
+// reportLifecycleLoop reports the current lifecycle state once.
+// Only the latest state is reported, intermediate states may be
+// lost if the agent can't communicate with the API.
+func (a *agent) reportLifecycleLoop(ctx context.Context) {
+	var lastReported codersdk.WorkspaceAgentLifecycle
+	for {
+		select {
+		case <-a.lifecycleUpdate:
+		case <-ctx.Done():
+			return
+		}
+
+		r := retry.New(time.Second, 15*time.Second)
+		for {
+			a.lifecycleMu.RLock()
+			state := a.lifecycleState
+			a.lifecycleMu.RUnlock()
+
+			if state == lastReported {
+				break
+			}
+
+			a.logger.Debug(ctx, "post lifecycle state", slog.F("state", state))
+
+			err := a.client.PostWorkspaceAgentLifecycle(ctx, codersdk.PostWorkspaceAgentLifecycleRequest{
+				State: state,
+			})
+			if err == nil {
+				lastReported = state
+				select {
+				case a.lifecycleReported <- state:
+				case <-a.lifecycleReported:
+					a.lifecycleReported <- state
+				}
+				break
+			}
+			if xerrors.As(err, new(*codersdk.Error)) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+				a.logger.Error(ctx, "failed to report lifecycle state", slog.Error(err))
+				break
+			}
+			// If we encounter a connection error, we want to retry.
+			if !r.Wait(ctx) {
+				return
+			}
+		}
+	}
+}
+
+func (a *agent) setLifecycle(ctx context.Context, state codersdk.WorkspaceAgentLifecycle) {
+	a.lifecycleMu.Lock()
+	if a.lifecycleState.ShuttingDown() && !state.ShuttingDown() {
+		// A startup script finishing after shutdown began must not
+		// revert the state.
+		a.lifecycleMu.Unlock()
+		return
+	}
+	a.lifecycleState = state
+	a.lifecycleMu.Unlock()
+
+	a.logger.Debug(ctx, "set lifecycle state", slog.F("state", state))
+
+	select {
+	case a.lifecycleUpdate <- struct{}{}:
+	default:
+	}
+}
+
+// watchShutdown waits for coderd to report that a build stopping the
+// workspace has started, and runs the shutdown script before the
+// infrastructure is torn down.
+func (a *agent) watchShutdown(ctx context.Context) {
+	r := retry.New(time.Second, 30*time.Second)
+	for {
+		err := a.client.WorkspaceAgentAwaitShutdown(ctx)
+		if err == nil {
+			a.shutdown(ctx)
+			return
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		var sdkErr *codersdk.Error
+		if xerrors.As(err, &sdkErr) && sdkErr.StatusCode() == http.StatusNotFound {
+			a.logger.Debug(ctx, "coderd does not support awaiting shutdown", slog.Error(err))
+			return
+		}
+		a.logger.Warn(ctx, "await shutdown", slog.Error(err))
+		if !r.Wait(ctx) {
+			return
+		}
+	}
+}
+
+// shutdown runs the shutdown script once and reports the outcome.
+func (a *agent) shutdown(ctx context.Context) {
+	a.shutdownOnce.Do(func() {
+		a.setLifecycle(ctx, codersdk.WorkspaceAgentLifecycleShuttingDown)
+
+		lifecycleState := codersdk.WorkspaceAgentLifecycleOff
+		metadata, ok := a.metadata.Load().(codersdk.WorkspaceAgentMetadata)
+		if ok {
+			err := a.runShutdownScript(ctx, metadata.ShutdownScript, metadata.ShutdownScriptTimeout)
+			if err != nil {
+				a.logger.Warn(ctx, "shutdown script failed", slog.Error(err))
+				lifecycleState = codersdk.WorkspaceAgentLifecycleShutdownError
+				if errors.Is(err, context.DeadlineExceeded) {
+					lifecycleState = codersdk.WorkspaceAgentLifecycleShutdownTimeout
+				}
+			}
+		}
+		a.setLifecycle(ctx, lifecycleState)
+	})
+}
+
+func (a *agent) runStartupScript(ctx context.Context, script string, timeout time.Duration) error {
+	return a.runScript(ctx, "startup", script, timeout)
+}
+
+func (a *agent) runShutdownScript(ctx context.Context, script string, timeout time.Duration) error {
+	return a.runScript(ctx, "shutdown", script, timeout)
+}
+
+func (a *agent) runScript(ctx context.Context, lifecycle, script string, timeout time.Duration) error {
+	var logs *startupLogSender
+	if lifecycle == "startup" {
+		// Clients following the startup logs wait for EOF, so it must be
+		// sent even when there's no script or it fails to start.
+		logs = newStartupLogSender(ctx, a.logger, a.client)
+		defer logs.markEOF()
+	}
+	// closeLogs flushes the startup logs when the script output isn't
+	// being copied to them.
+	closeLogs := func() {
+		if logs != nil {
+			logs.markEOF()
+			_ = logs.Close()
+		}
+	}
+
 	if script == "" {
+		closeLogs()
 		return nil
 	}
 
-	a.logger.Info(ctx, "running startup script", slog.F("script", script))
-	writer, err := a.filesystem.OpenFile(filepath.Join(a.tempDir, "coder-startup-script.log"), os.O_CREATE|os.O_RDWR, 0o600)
+	a.logger.Info(ctx, "running script", slog.F("lifecycle", lifecycle), slog.F("script", script))
+	writer, err := a.filesystem.OpenFile(filepath.Join(a.tempDir, fmt.Sprintf("coder-%s-script.log", lifecycle)), os.O_CREATE|os.O_RDWR, 0o600)
 	if err != nil {
-		return xerrors.Errorf("open startup script log file: %w", err)
+		closeLogs()
+		return xerrors.Errorf("open %s script log file: %w", lifecycle, err)
 	}
-	defer func() {
+	// The script output is read through an OS pipe rather than an
+	// io.Writer so that processes backgrounded by the script, which
+	// inherit stdout, don't block the script from completing.
+	outputReader, outputWriter, err := os.Pipe()
+	if err != nil {
 		_ = writer.Close()
+		closeLogs()
+		return xerrors.Errorf("create %s script output pipe: %w", lifecycle, err)
+	}
+	var output io.Writer = writer
+	if logs != nil {
+		output = io.MultiWriter(writer, logs)
+	}
+	go func() {
+		_, _ = io.Copy(output, outputReader)
+		_ = outputReader.Close()
+		_ = writer.Close()
+		if logs != nil {
+			_ = logs.Close()
+		}
 	}()
-	cmd, err := a.createCommand(ctx, script, nil)
+
+	cmdCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		cmdCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	cmd, err := a.createCommand(cmdCtx, script, nil)
 	if err != nil {
+		_ = outputWriter.Close()
 		return xerrors.Errorf("create command: %w", err)
 	}
-	cmd.Stdout = writer
-	cmd.Stderr = writer
-	err = cmd.Run()
+	cmd.Stdout = outputWriter
+	cmd.Stderr = outputWriter
+	err = cmd.Start()
+	// The child process holds its own copy of the pipe.
+	_ = outputWriter.Close()
 	if err != nil {
-		// cmd.Run does not return a context canceled error, it returns "signal: killed".
-		if ctx.Err() != nil {
-			return ctx.Err()
+		return xerrors.Errorf("start: %w", err)
+	}
+	err = cmd.Wait()
+	if err != nil {
+		// cmd.Wait does not return a context canceled error, it returns "signal: killed".
+		if cmdCtx.Err() != nil {
+			return cmdCtx.Err()
 		}
 
 		return xerrors.Errorf("run: %w", err)
@@ -478,6 +692,15 @@ func (a *agent) init(ctx context.Context) {
 			"sftp": func(session ssh.Session) {
 				ctx := session.Context()
 
+				// SFTP transfers files rather than running a terminal, so
+				// it can't be recorded. It's refused instead so file
+				// transfers can't be used to work around recording.
+				if a.sessionRecordingEnabled() {
+					_, _ = fmt.Fprintln(session.Stderr(), "SFTP is disabled because sessions in this workspace are recorded.")
+					_ = session.Exit(1)
+					return
+				}
+
 				// Typically sftp sessions don't request a TTY, but if they do,
 				// we must ensure the gliderlabs/ssh CRLF emulation is disabled.
 				// Otherwise sftp will be broken. This can happen if a user sets
@@ -521,9 +744,12 @@ func (a *agent) init(ctx context.Context) {
 		},
 	}
 
+	go a.reportLifecycleLoop(ctx)
 	go a.runLoop(ctx)
 	cl, err := a.client.AgentReportStats(ctx, a.logger, func() *codersdk.AgentStats {
-		return a.stats.Copy()
+		stats := a.stats.Copy()
+		a.resourceUsage.collect(ctx, a.logger, stats)
+		return stats
 	})
 	if err != nil {
 		a.logger.Error(ctx, "report stats", slog.Error(err))
@@ -537,6 +763,31 @@ func (a *agent) init(ctx context.Context) {
 	}()
 }
 
+// sshSessionType returns the type of an SSH session from the environment
+// variables and the command requested by the client.
+func sshSessionType(env []string, rawCommand string) string {
+	for _, kv := range env {
+		key, value, _ := strings.Cut(kv, "=")
+		if key != MagicSessionTypeEnvironmentVariable {
+			continue
+		}
+		switch value {
+		case SessionTypeVSCode, SessionTypeJetBrains:
+			return value
+		}
+		return SessionTypeSSH
+	}
+	switch {
+	// VS Code Remote SSH installs and runs its server from this directory.
+	case strings.Contains(rawCommand, ".vscode-server"):
+		return SessionTypeVSCode
+	// JetBrains Gateway runs the remote development server of the IDE.
+	case strings.Contains(rawCommand, "remote-dev-server"), strings.Contains(rawCommand, "JetBrains"):
+		return SessionTypeJetBrains
+	}
+	return SessionTypeSSH
+}
+
 // createCommand processes raw command input with OpenSSH-like behavior.
 // If the rawCommand provided is empty, it will default to the users shell.
 // This injects environment variables specified by the user at launch too.
@@ -639,6 +890,8 @@ func (a *agent) createCommand(ctx context.Context, rawCommand string, env []stri
 
 func (a *agent) handleSSHSession(session ssh.Session) (retErr error) {
 	ctx := session.Context()
+	defer a.stats.trackSession(sshSessionType(session.Environ(), session.RawCommand()))()
+
 	cmd, err := a.createCommand(ctx, session.RawCommand(), session.Environ())
 	if err != nil {
 		return err
@@ -670,6 +923,9 @@ func (a *agent) handleSSHSession(session ssh.Session) (retErr error) {
 		if err != nil {
 			return xerrors.Errorf("start command: %w", err)
 		}
+		recording := a.startSessionRecording(ctx, codersdk.SessionRecordingTypeSSH, session.RawCommand(), sshPty.Term, sshPty.Window.Width, sshPty.Window.Height)
+		// Registered before the PTY is closed so the upload runs after.
+		defer a.uploadSessionRecordingAsync(ctx, recording)
 		defer func() {
 			closeErr := ptty.Close()
 			if closeErr != nil {
@@ -685,13 +941,14 @@ func (a *agent) handleSSHSession(session ssh.Session) (retErr error) {
 				if resizeErr != nil {
 					a.logger.Warn(ctx, "failed to resize tty", slog.Error(resizeErr))
 				}
+				recording.resize(win.Width, win.Height)
 			}
 		}()
 		go func() {
-			_, _ = io.Copy(ptty.Input(), session)
+			_, _ = io.Copy(ptty.Input(), recording.teeInput(session))
 		}()
 		go func() {
-			_, _ = io.Copy(session, ptty.Output())
+			_, _ = io.Copy(recording.teeOutput(session), ptty.Output())
 		}()
 		err = process.Wait()
 		var exitErr *exec.ExitError
@@ -703,8 +960,13 @@ func (a *agent) handleSSHSession(session ssh.Session) (retErr error) {
 		return err
 	}
 
-	cmd.Stdout = session
-	cmd.Stderr = session.Stderr()
+	// Commands run without a TTY, such as scp, are recorded too. Their
+	// stdout and stderr are both recorded as output.
+	recording := a.startSessionRecording(ctx, codersdk.SessionRecordingTypeSSH, session.RawCommand(), "", sessionRecordingNoTTYWidth, sessionRecordingNoTTYHeight)
+	// Registered first so the upload runs after the command is waited on.
+	defer a.uploadSessionRecordingAsync(ctx, recording)
+	cmd.Stdout = recording.teeOutput(session)
+	cmd.Stderr = recording.teeOutput(session.Stderr())
 	// This blocks forever until stdin is received if we don't
 	// use StdinPipe. It's unknown what causes this.
 	stdinPipe, err := cmd.StdinPipe()
@@ -712,7 +974,7 @@ func (a *agent) handleSSHSession(session ssh.Session) (retErr error) {
 		return xerrors.Errorf("create stdin pipe: %w", err)
 	}
 	go func() {
-		_, _ = io.Copy(stdinPipe, session)
+		_, _ = io.Copy(stdinPipe, recording.teeInput(session))
 		_ = stdinPipe.Close()
 	}()
 	err = cmd.Start()
@@ -724,6 +986,7 @@ func (a *agent) handleSSHSession(session ssh.Session) (retErr error) {
 
 func (a *agent) handleReconnectingPTY(ctx context.Context, msg codersdk.ReconnectingPTYInit, conn net.Conn) {
 	defer conn.Close()
+	defer a.stats.trackSession(SessionTypeReconnectingPTY)()
 
 	var rpty *reconnectingPTY
 	rawRPTY, ok := a.reconnectingPTYs.Load(msg.ID)
@@ -754,17 +1017,22 @@ func (a *agent) handleReconnectingPTY(ctx context.Context, msg codersdk.Reconnec
 			a.logger.Error(ctx, "start reconnecting pty command", slog.F("id", msg.ID), slog.Error(err))
 			return
 		}
+		recording := a.startSessionRecording(ctx, codersdk.SessionRecordingTypeReconnectingPTY, msg.Command, "xterm-256color", int(msg.Width), int(msg.Height))
 
 		a.closeMutex.Lock()
 		a.connCloseWait.Add(1)
 		a.closeMutex.Unlock()
 		ctx, cancelFunc := context.WithCancel(ctx)
 		rpty = &reconnectingPTY{
+			id:          msg.ID,
+			command:     msg.Command,
+			startedAt:   time.Now(),
 			activeConns: make(map[string]net.Conn),
 			ptty:        ptty,
 			// Timeouts created with an after func can be reset!
 			timeout:        time.AfterFunc(a.reconnectingPTYTimeout, cancelFunc),
 			circularBuffer: circularBuffer,
+			recording:      recording,
 		}
 		a.reconnectingPTYs.Store(msg.ID, rpty)
 		go func() {
@@ -791,6 +1059,7 @@ func (a *agent) handleReconnectingPTY(ctx context.Context, msg codersdk.Reconnec
 					break
 				}
 				part := buffer[:read]
+				rpty.recording.output(part)
 				rpty.circularBufferMutex.Lock()
 				_, err = rpty.circularBuffer.Write(part)
 				rpty.circularBufferMutex.Unlock()
@@ -810,6 +1079,7 @@ func (a *agent) handleReconnectingPTY(ctx context.Context, msg codersdk.Reconnec
 			_ = process.Kill()
 			rpty.Close()
 			a.reconnectingPTYs.Delete(msg.ID)
+			a.uploadSessionRecording(ctx, rpty.recording)
 			a.connCloseWait.Done()
 		}()
 	}
@@ -878,6 +1148,7 @@ func (a *agent) handleReconnectingPTY(ctx context.Context, msg codersdk.Reconnec
 			a.logger.Warn(ctx, "write to reconnecting pty", slog.F("id", msg.ID), slog.Error(err))
 			return
 		}
+		rpty.recording.input([]byte(req.Data))
 		// Check if a resize needs to happen!
 		if req.Height == 0 || req.Width == 0 {
 			continue
@@ -887,6 +1158,7 @@ func (a *agent) handleReconnectingPTY(ctx context.Context, msg codersdk.Reconnec
 			// We can continue after this, it's not fatal!
 			a.logger.Error(ctx, "resize reconnecting pty", slog.F("id", msg.ID), slog.Error(err))
 		}
+		rpty.recording.resize(int(req.Width), int(req.Height))
 	}
 }
 
@@ -906,6 +1178,25 @@ func (a *agent) Close() error {
 	if a.isClosed() {
 		return nil
 	}
+
+	ctx := context.Background()
+	a.shutdown(ctx)
+	// Wait for the lifecycle to be reported, but don't wait forever so
+	// that we don't break user expectations.
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+lifecycleWaitLoop:
+	for {
+		select {
+		case <-ctx.Done():
+			break lifecycleWaitLoop
+		case s := <-a.lifecycleReported:
+			if s.ShuttingDown() && s != codersdk.WorkspaceAgentLifecycleShuttingDown {
+				break lifecycleWaitLoop
+			}
+		}
+	}
+
 	close(a.closed)
 	a.closeCancel()
 	if a.network != nil {
@@ -917,13 +1208,42 @@ func (a *agent) Close() error {
 }
 
 type reconnectingPTY struct {
+	id        string
+	command   string
+	startedAt time.Time
+
 	activeConnsMutex sync.Mutex
 	activeConns      map[string]net.Conn
+	// name is guarded by activeConnsMutex.
+	name string
 
 	circularBuffer      *circbuf.Buffer
 	circularBufferMutex sync.RWMutex
 	timeout             *time.Timer
 	ptty                pty.PTY
+	// recording is nil unless session recording is enabled.
+	recording *sessionRecording
+}
+
+// session returns the reconnecting PTY as it's reported to clients.
+func (r *reconnectingPTY) session() codersdk.ReconnectingPTYSession {
+	r.activeConnsMutex.Lock()
+	defer r.activeConnsMutex.Unlock()
+	return codersdk.ReconnectingPTYSession{
+		ID:          r.id,
+		Name:        r.name,
+		Command:     r.command,
+		StartedAt:   r.startedAt,
+		Connections: len(r.activeConns),
+	}
+}
+
+// setName names the reconnecting PTY so clients can refer to it by
+// something more memorable than its ID.
+func (r *reconnectingPTY) setName(name string) {
+	r.activeConnsMutex.Lock()
+	defer r.activeConnsMutex.Unlock()
+	r.name = name
 }
 
 // Close ends all connections to the reconnecting
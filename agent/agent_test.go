@@ -2,11 +2,13 @@ package agent_test
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net"
+	"net/http"
 	"net/netip"
 	"os"
 	"os/exec"
@@ -40,6 +42,7 @@ import (
 	"cdr.dev/slog"
 	"cdr.dev/slog/sloggers/slogtest"
 	"github.com/coder/coder/agent"
+	"github.com/coder/coder/agent/asciicast"
 	"github.com/coder/coder/codersdk"
 	"github.com/coder/coder/pty/ptytest"
 	"github.com/coder/coder/tailnet"
@@ -61,7 +64,7 @@ func TestAgent(t *testing.T) {
 			ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
 			defer cancel()
 
-			conn, stats, _ := setupAgent(t, codersdk.WorkspaceAgentMetadata{}, 0)
+			conn, _, stats, _ := setupAgent(t, codersdk.WorkspaceAgentMetadata{}, 0)
 
 			sshClient, err := conn.SSHClient(ctx)
 			require.NoError(t, err)
@@ -75,13 +78,51 @@ func TestAgent(t *testing.T) {
 			assert.Greater(t, (<-stats).TxBytes, int64(0))
 		})
 
+		t.Run("SessionCounts", func(t *testing.T) {
+			t.Parallel()
+			ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+			defer cancel()
+
+			conn, _, stats, _ := setupAgent(t, codersdk.WorkspaceAgentMetadata{}, 0)
+
+			sshClient, err := conn.SSHClient(ctx)
+			require.NoError(t, err)
+			defer sshClient.Close()
+			startSession := func(sessionType string) {
+				session, err := sshClient.NewSession()
+				require.NoError(t, err)
+				t.Cleanup(func() {
+					_ = session.Close()
+				})
+				if sessionType != "" {
+					err = session.Setenv(agent.MagicSessionTypeEnvironmentVariable, sessionType)
+					require.NoError(t, err)
+				}
+				err = session.Start("sleep 30")
+				require.NoError(t, err)
+			}
+			startSession("")
+			startSession(agent.SessionTypeVSCode)
+			startSession(agent.SessionTypeVSCode)
+			startSession(agent.SessionTypeJetBrains)
+
+			var s *codersdk.AgentStats
+			require.Eventuallyf(t, func() bool {
+				var ok bool
+				s, ok = <-stats
+				return ok && s.SessionCountSSH == 1 && s.SessionCountVSCode == 2 && s.SessionCountJetBrains == 1
+			}, testutil.WaitLong, testutil.IntervalFast,
+				"never saw session counts: %+v", s,
+			)
+		})
+
 		t.Run("ReconnectingPTY", func(t *testing.T) {
 			t.Parallel()
 
 			ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
 			defer cancel()
 
-			conn, stats, _ := setupAgent(t, codersdk.WorkspaceAgentMetadata{}, 0)
+			conn, _, stats, _ := setupAgent(t, codersdk.WorkspaceAgentMetadata{}, 0)
 
 			ptyConn, err := conn.ReconnectingPTY(ctx, uuid.NewString(), 128, 128, "/bin/bash")
 			require.NoError(t, err)
@@ -98,7 +139,7 @@ func TestAgent(t *testing.T) {
 			require.Eventuallyf(t, func() bool {
 				var ok bool
 				s, ok = (<-stats)
-				return ok && s.NumConns > 0 && s.RxBytes > 0 && s.TxBytes > 0
+				return ok && s.NumConns > 0 && s.RxBytes > 0 && s.TxBytes > 0 && s.SessionCountReconnectingPTY == 1
 			}, testutil.WaitLong, testutil.IntervalFast,
 				"never saw stats: %+v", s,
 			)
@@ -231,7 +272,7 @@ func TestAgent(t *testing.T) {
 		if runtime.GOOS == "windows" {
 			home = "/" + strings.ReplaceAll(home, "\\", "/")
 		}
-		conn, _, _ := setupAgent(t, codersdk.WorkspaceAgentMetadata{}, 0)
+		conn, _, _, _ := setupAgent(t, codersdk.WorkspaceAgentMetadata{}, 0)
 		sshClient, err := conn.SSHClient(ctx)
 		require.NoError(t, err)
 		defer sshClient.Close()
@@ -261,7 +302,7 @@ func TestAgent(t *testing.T) {
 		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
 		defer cancel()
 
-		conn, _, _ := setupAgent(t, codersdk.WorkspaceAgentMetadata{}, 0)
+		conn, _, _, _ := setupAgent(t, codersdk.WorkspaceAgentMetadata{}, 0)
 		sshClient, err := conn.SSHClient(ctx)
 		require.NoError(t, err)
 		defer sshClient.Close()
@@ -364,7 +405,7 @@ func TestAgent(t *testing.T) {
 			t.Skip("This test doesn't work on Windows for some reason...")
 		}
 		content := "output"
-		_, _, fs := setupAgent(t, codersdk.WorkspaceAgentMetadata{
+		_, _, _, fs := setupAgent(t, codersdk.WorkspaceAgentMetadata{
 			StartupScript: "echo " + content,
 		}, 0)
 		var gotContent string
@@ -392,6 +433,291 @@ func TestAgent(t *testing.T) {
 		require.Equal(t, content, strings.TrimSpace(gotContent))
 	})
 
+	t.Run("StartupLogs", func(t *testing.T) {
+		t.Parallel()
+		if runtime.GOOS == "windows" {
+			t.Skip("This test doesn't work on Windows for some reason...")
+		}
+		client := &client{
+			t:       t,
+			agentID: uuid.New(),
+			metadata: codersdk.WorkspaceAgentMetadata{
+				DERPMap:       tailnettest.RunDERPAndSTUN(t),
+				StartupScript: "echo first && echo second",
+			},
+			statsChan:   make(chan *codersdk.AgentStats),
+			coordinator: tailnet.NewCoordinator(),
+		}
+		closer := agent.New(agent.Options{
+			Client:     client,
+			Filesystem: afero.NewMemMapFs(),
+			Logger:     slogtest.Make(t, nil).Leveled(slog.LevelDebug),
+		})
+		t.Cleanup(func() {
+			_ = closer.Close()
+		})
+
+		var logs []codersdk.StartupLog
+		require.Eventually(t, func() bool {
+			var eof bool
+			logs, eof = client.getStartupLogs()
+			return eof
+		}, testutil.WaitShort, testutil.IntervalFast)
+		require.Len(t, logs, 2)
+		require.Equal(t, "first", logs[0].Output)
+		require.Equal(t, "second", logs[1].Output)
+	})
+
+	t.Run("StartupLogsNoScript", func(t *testing.T) {
+		t.Parallel()
+		client := &client{
+			t:       t,
+			agentID: uuid.New(),
+			metadata: codersdk.WorkspaceAgentMetadata{
+				DERPMap: tailnettest.RunDERPAndSTUN(t),
+			},
+			statsChan:   make(chan *codersdk.AgentStats),
+			coordinator: tailnet.NewCoordinator(),
+		}
+		closer := agent.New(agent.Options{
+			Client:     client,
+			Filesystem: afero.NewMemMapFs(),
+			Logger:     slogtest.Make(t, nil).Leveled(slog.LevelDebug),
+		})
+		t.Cleanup(func() {
+			_ = closer.Close()
+		})
+
+		// Followers of the startup logs wait for EOF, so it's sent even
+		// without a startup script.
+		require.Eventually(t, func() bool {
+			logs, eof := client.getStartupLogs()
+			return eof && len(logs) == 0
+		}, testutil.WaitShort, testutil.IntervalFast)
+	})
+
+	t.Run("Lifecycle", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("Ready", func(t *testing.T) {
+			t.Parallel()
+
+			client, closer := setupLifecycleAgent(t, codersdk.WorkspaceAgentMetadata{
+				StartupScript: "true",
+			})
+			defer closer.Close()
+			want := []codersdk.WorkspaceAgentLifecycle{
+				codersdk.WorkspaceAgentLifecycleStarting,
+				codersdk.WorkspaceAgentLifecycleReady,
+			}
+			var got []codersdk.WorkspaceAgentLifecycle
+			assert.Eventually(t, func() bool {
+				got = client.getLifecycleStates()
+				return len(got) > 0 && got[len(got)-1] == want[len(want)-1]
+			}, testutil.WaitShort, testutil.IntervalFast)
+			switch len(got) {
+			case 1:
+				// The reporting loop may skip the starting state.
+				require.Equal(t, want[1:], got)
+			default:
+				require.Equal(t, want, got)
+			}
+		})
+
+		t.Run("StartTimeout", func(t *testing.T) {
+			t.Parallel()
+
+			client, closer := setupLifecycleAgent(t, codersdk.WorkspaceAgentMetadata{
+				StartupScript:        "sleep 5",
+				StartupScriptTimeout: time.Nanosecond,
+			})
+			defer closer.Close()
+			assert.Eventually(t, func() bool {
+				got := client.getLifecycleStates()
+				return len(got) > 0 && got[len(got)-1] == codersdk.WorkspaceAgentLifecycleStartTimeout
+			}, testutil.WaitShort, testutil.IntervalFast)
+		})
+
+		t.Run("StartError", func(t *testing.T) {
+			t.Parallel()
+
+			client, closer := setupLifecycleAgent(t, codersdk.WorkspaceAgentMetadata{
+				StartupScript: "false",
+			})
+			defer closer.Close()
+			assert.Eventually(t, func() bool {
+				got := client.getLifecycleStates()
+				return len(got) > 0 && got[len(got)-1] == codersdk.WorkspaceAgentLifecycleStartError
+			}, testutil.WaitShort, testutil.IntervalFast)
+		})
+
+		t.Run("Off", func(t *testing.T) {
+			t.Parallel()
+
+			client, closer := setupLifecycleAgent(t, codersdk.WorkspaceAgentMetadata{
+				StartupScript: "true",
+			})
+			assert.Eventually(t, func() bool {
+				got := client.getLifecycleStates()
+				return len(got) > 0 && got[len(got)-1] == codersdk.WorkspaceAgentLifecycleReady
+			}, testutil.WaitShort, testutil.IntervalFast)
+
+			// Close reports the final state before returning.
+			require.NoError(t, closer.Close())
+			got := client.getLifecycleStates()
+			require.Equal(t, codersdk.WorkspaceAgentLifecycleOff, got[len(got)-1])
+		})
+
+		t.Run("ShutdownTimeout", func(t *testing.T) {
+			t.Parallel()
+
+			client, closer := setupLifecycleAgent(t, codersdk.WorkspaceAgentMetadata{
+				ShutdownScript:        "sleep 5",
+				ShutdownScriptTimeout: time.Nanosecond,
+			})
+			require.Eventually(t, func() bool {
+				got := client.getLifecycleStates()
+				return len(got) > 0 && got[len(got)-1] == codersdk.WorkspaceAgentLifecycleReady
+			}, testutil.WaitShort, testutil.IntervalFast)
+			require.NoError(t, closer.Close())
+			got := client.getLifecycleStates()
+			require.Equal(t, codersdk.WorkspaceAgentLifecycleShutdownTimeout, got[len(got)-1])
+		})
+
+		t.Run("ShutdownError", func(t *testing.T) {
+			t.Parallel()
+
+			client, closer := setupLifecycleAgent(t, codersdk.WorkspaceAgentMetadata{
+				ShutdownScript: "false",
+			})
+			require.Eventually(t, func() bool {
+				got := client.getLifecycleStates()
+				return len(got) > 0 && got[len(got)-1] == codersdk.WorkspaceAgentLifecycleReady
+			}, testutil.WaitShort, testutil.IntervalFast)
+			require.NoError(t, closer.Close())
+			got := client.getLifecycleStates()
+			require.Equal(t, codersdk.WorkspaceAgentLifecycleShutdownError, got[len(got)-1])
+		})
+	})
+
+	t.Run("ShutdownScript", func(t *testing.T) {
+		t.Parallel()
+		if runtime.GOOS == "windows" {
+			t.Skip("This test doesn't work on Windows for some reason...")
+		}
+		fs := afero.NewMemMapFs()
+		client := &client{
+			t:       t,
+			agentID: uuid.New(),
+			metadata: codersdk.WorkspaceAgentMetadata{
+				DERPMap:        tailnettest.RunDERPAndSTUN(t),
+				ShutdownScript: "echo goodbye",
+			},
+			statsChan:   make(chan *codersdk.AgentStats),
+			coordinator: tailnet.NewCoordinator(),
+			shutdown:    make(chan struct{}),
+		}
+		closer := agent.New(agent.Options{
+			Client:     client,
+			Filesystem: fs,
+			Logger:     slogtest.Make(t, nil).Leveled(slog.LevelDebug),
+		})
+		t.Cleanup(func() {
+			_ = closer.Close()
+		})
+		require.Eventually(t, func() bool {
+			got := client.getLifecycleStates()
+			return len(got) > 0 && got[len(got)-1] == codersdk.WorkspaceAgentLifecycleReady
+		}, testutil.WaitShort, testutil.IntervalFast)
+
+		// The script runs when a stop build starts, while the agent
+		// is still running.
+		close(client.shutdown)
+		require.Eventually(t, func() bool {
+			got := client.getLifecycleStates()
+			return got[len(got)-1] == codersdk.WorkspaceAgentLifecycleOff
+		}, testutil.WaitShort, testutil.IntervalFast)
+		content, err := afero.ReadFile(fs, filepath.Join(os.TempDir(), "coder-shutdown-script.log"))
+		require.NoError(t, err)
+		require.Equal(t, "goodbye", strings.TrimSpace(string(content)))
+	})
+
+	t.Run("Metadata", func(t *testing.T) {
+		t.Parallel()
+		if runtime.GOOS == "windows" {
+			t.Skip("This test doesn't work on Windows for some reason...")
+		}
+		client := &client{
+			t:       t,
+			agentID: uuid.New(),
+			metadata: codersdk.WorkspaceAgentMetadata{
+				DERPMap: tailnettest.RunDERPAndSTUN(t),
+				Metadata: []codersdk.WorkspaceAgentMetadataDescription{{
+					Key:      "greeting",
+					Script:   "echo hello",
+					Interval: 1,
+				}, {
+					Key:    "once",
+					Script: "echo once",
+				}, {
+					Key:    "failure",
+					Script: "echo oops && exit 1",
+				}, {
+					Key:     "timeout",
+					Script:  "sleep 10",
+					Timeout: 1,
+				}, {
+					Key:     "background",
+					Script:  "sleep 60 & echo background",
+					Timeout: 60,
+				}},
+			},
+			statsChan:   make(chan *codersdk.AgentStats),
+			coordinator: tailnet.NewCoordinator(),
+		}
+		closer := agent.New(agent.Options{
+			Client:     client,
+			Filesystem: afero.NewMemMapFs(),
+			Logger:     slogtest.Make(t, nil).Leveled(slog.LevelDebug),
+		})
+		t.Cleanup(func() {
+			_ = closer.Close()
+		})
+
+		// Scripts with an interval are run repeatedly.
+		require.Eventually(t, func() bool {
+			return len(client.getMetadataResults("greeting")) >= 2
+		}, testutil.WaitShort, testutil.IntervalFast)
+		for _, result := range client.getMetadataResults("greeting") {
+			require.Equal(t, "hello", result.Value)
+			require.Empty(t, result.Error)
+		}
+
+		require.Eventually(t, func() bool {
+			return len(client.getMetadataResults("timeout")) > 0
+		}, testutil.WaitShort, testutil.IntervalFast)
+		result := client.getMetadataResults("timeout")[0]
+		require.Contains(t, result.Error, "timed out")
+
+		// Scripts without an interval are only run once.
+		once := client.getMetadataResults("once")
+		require.Len(t, once, 1)
+		require.Equal(t, "once", once[0].Value)
+
+		// Processes left running by the script don't hold up the result.
+		require.Eventually(t, func() bool {
+			return len(client.getMetadataResults("background")) > 0
+		}, testutil.WaitShort, testutil.IntervalFast)
+		background := client.getMetadataResults("background")[0]
+		require.Equal(t, "background", background.Value)
+		require.Empty(t, background.Error)
+
+		failure := client.getMetadataResults("failure")
+		require.Len(t, failure, 1)
+		require.Equal(t, "oops", failure[0].Value)
+		require.Contains(t, failure[0].Error, "exit status 1")
+	})
+
 	t.Run("ReconnectingPTY", func(t *testing.T) {
 		t.Parallel()
 		if runtime.GOOS == "windows" {
@@ -404,7 +730,7 @@ func TestAgent(t *testing.T) {
 		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
 		defer cancel()
 
-		conn, _, _ := setupAgent(t, codersdk.WorkspaceAgentMetadata{}, 0)
+		conn, _, _, _ := setupAgent(t, codersdk.WorkspaceAgentMetadata{}, 0)
 		id := uuid.NewString()
 		netConn, err := conn.ReconnectingPTY(ctx, id, 100, 100, "/bin/bash")
 		require.NoError(t, err)
@@ -453,6 +779,191 @@ func TestAgent(t *testing.T) {
 		expectLine(matchEchoOutput)
 	})
 
+	t.Run("ReconnectingPTYSessions", func(t *testing.T) {
+		t.Parallel()
+		if runtime.GOOS == "windows" {
+			t.Skip("ConPTY appears to be inconsistent on Windows.")
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		conn, _, _, _ := setupAgent(t, codersdk.WorkspaceAgentMetadata{}, 0)
+		res, err := conn.ReconnectingPTYSessions(ctx)
+		require.NoError(t, err)
+		require.Empty(t, res.Sessions)
+
+		id := uuid.NewString()
+		netConn, err := conn.ReconnectingPTY(ctx, id, 100, 100, "/bin/bash")
+		require.NoError(t, err)
+		defer netConn.Close()
+
+		var session codersdk.ReconnectingPTYSession
+		require.Eventually(t, func() bool {
+			res, err = conn.ReconnectingPTYSessions(ctx)
+			if err != nil || len(res.Sessions) != 1 {
+				return false
+			}
+			session = res.Sessions[0]
+			return session.Connections == 1
+		}, testutil.WaitShort, testutil.IntervalFast)
+		require.Equal(t, id, session.ID)
+		require.Equal(t, "/bin/bash", session.Command)
+		require.WithinDuration(t, time.Now(), session.StartedAt, testutil.WaitLong)
+
+		err = conn.CloseReconnectingPTYSession(ctx, id)
+		require.NoError(t, err)
+		// Clients are disconnected when the session is killed.
+		_, _ = io.Copy(io.Discard, netConn)
+		res, err = conn.ReconnectingPTYSessions(ctx)
+		require.NoError(t, err)
+		require.Empty(t, res.Sessions)
+
+		err = conn.CloseReconnectingPTYSession(ctx, id)
+		var sdkErr *codersdk.Error
+		require.ErrorAs(t, err, &sdkErr)
+		require.Equal(t, http.StatusNotFound, sdkErr.StatusCode())
+	})
+
+	t.Run("SessionRecording", func(t *testing.T) {
+		t.Parallel()
+		if runtime.GOOS == "windows" {
+			t.Skip("ConPTY appears to be inconsistent on Windows.")
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		conn, client, _, _ := setupAgent(t, codersdk.WorkspaceAgentMetadata{
+			SessionRecording: true,
+		}, 0)
+		netConn, err := conn.ReconnectingPTY(ctx, uuid.NewString(), 100, 100, "/bin/bash")
+		require.NoError(t, err)
+		defer netConn.Close()
+		bufRead := bufio.NewReader(netConn)
+
+		// Brief pause to reduce the likelihood that we send keystrokes while
+		// the shell is simultaneously sending a prompt.
+		time.Sleep(100 * time.Millisecond)
+
+		for _, input := range []string{"echo recorded\r\n", "exit\r\n"} {
+			data, err := json.Marshal(codersdk.ReconnectingPTYRequest{
+				Data: input,
+			})
+			require.NoError(t, err)
+			_, err = netConn.Write(data)
+			require.NoError(t, err)
+			if input == "exit\r\n" {
+				break
+			}
+			for {
+				line, err := bufRead.ReadString('\n')
+				require.NoError(t, err)
+				if strings.Contains(line, "recorded") && !strings.Contains(line, "echo") {
+					break
+				}
+			}
+		}
+
+		var recordings []codersdk.PostWorkspaceAgentSessionRecordingRequest
+		require.Eventually(t, func() bool {
+			recordings = client.getSessionRecordings()
+			return len(recordings) > 0
+		}, testutil.WaitLong, testutil.IntervalFast)
+		require.Len(t, recordings, 1)
+		recording := recordings[0]
+		require.Equal(t, codersdk.SessionRecordingTypeReconnectingPTY, recording.Type)
+		require.Equal(t, "/bin/bash", recording.Command)
+
+		decoder, header, err := asciicast.NewDecoder(bytes.NewReader(recording.Recording))
+		require.NoError(t, err)
+		require.Equal(t, 100, header.Width)
+		var input, output strings.Builder
+		for {
+			event, err := decoder.Next()
+			if xerrors.Is(err, io.EOF) {
+				break
+			}
+			require.NoError(t, err)
+			switch event.Type {
+			case asciicast.EventTypeInput:
+				input.WriteString(event.Data)
+			case asciicast.EventTypeOutput:
+				output.WriteString(event.Data)
+			}
+		}
+		require.Contains(t, input.String(), "echo recorded")
+		require.Contains(t, output.String(), "recorded")
+	})
+
+	t.Run("SessionRecordingNoTTY", func(t *testing.T) {
+		t.Parallel()
+		if runtime.GOOS == "windows" {
+			t.Skip("The command is a Unix shell command.")
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		conn, client, _, _ := setupAgent(t, codersdk.WorkspaceAgentMetadata{
+			SessionRecording: true,
+		}, 0)
+		sshClient, err := conn.SSHClient(ctx)
+		require.NoError(t, err)
+		defer sshClient.Close()
+		session, err := sshClient.NewSession()
+		require.NoError(t, err)
+		defer session.Close()
+		session.Stdin = strings.NewReader("recorded input")
+		output, err := session.Output("cat && echo recorded output")
+		require.NoError(t, err)
+		require.Equal(t, "recorded inputrecorded output\n", string(output))
+
+		var recordings []codersdk.PostWorkspaceAgentSessionRecordingRequest
+		require.Eventually(t, func() bool {
+			recordings = client.getSessionRecordings()
+			return len(recordings) > 0
+		}, testutil.WaitLong, testutil.IntervalFast)
+		require.Len(t, recordings, 1)
+		recording := recordings[0]
+		require.Equal(t, codersdk.SessionRecordingTypeSSH, recording.Type)
+		require.Equal(t, "cat && echo recorded output", recording.Command)
+
+		decoder, _, err := asciicast.NewDecoder(bytes.NewReader(recording.Recording))
+		require.NoError(t, err)
+		var input, out strings.Builder
+		for {
+			event, err := decoder.Next()
+			if xerrors.Is(err, io.EOF) {
+				break
+			}
+			require.NoError(t, err)
+			switch event.Type {
+			case asciicast.EventTypeInput:
+				input.WriteString(event.Data)
+			case asciicast.EventTypeOutput:
+				out.WriteString(event.Data)
+			}
+		}
+		require.Equal(t, "recorded input", input.String())
+		require.Contains(t, out.String(), "recorded output")
+	})
+
+	t.Run("SessionRecordingRefusesSFTP", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		conn, _, _, _ := setupAgent(t, codersdk.WorkspaceAgentMetadata{
+			SessionRecording: true,
+		}, 0)
+		sshClient, err := conn.SSHClient(ctx)
+		require.NoError(t, err)
+		defer sshClient.Close()
+		_, err = sftp.NewClient(sshClient)
+		require.Error(t, err)
+	})
+
 	t.Run("Dial", func(t *testing.T) {
 		t.Parallel()
 
@@ -501,7 +1012,7 @@ func TestAgent(t *testing.T) {
 					}
 				}()
 
-				conn, _, _ := setupAgent(t, codersdk.WorkspaceAgentMetadata{}, 0)
+				conn, _, _, _ := setupAgent(t, codersdk.WorkspaceAgentMetadata{}, 0)
 				require.True(t, conn.AwaitReachable(context.Background()))
 				conn1, err := conn.DialContext(context.Background(), l.Addr().Network(), l.Addr().String())
 				require.NoError(t, err)
@@ -522,7 +1033,7 @@ func TestAgent(t *testing.T) {
 		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
 		defer cancel()
 		derpMap := tailnettest.RunDERPAndSTUN(t)
-		conn, _, _ := setupAgent(t, codersdk.WorkspaceAgentMetadata{
+		conn, _, _, _ := setupAgent(t, codersdk.WorkspaceAgentMetadata{
 			DERPMap: derpMap,
 		}, 0)
 		defer conn.Close()
@@ -605,7 +1116,7 @@ func TestAgent(t *testing.T) {
 }
 
 func setupSSHCommand(t *testing.T, beforeArgs []string, afterArgs []string) *exec.Cmd {
-	agentConn, _, _ := setupAgent(t, codersdk.WorkspaceAgentMetadata{}, 0)
+	agentConn, _, _, _ := setupAgent(t, codersdk.WorkspaceAgentMetadata{}, 0)
 	listener, err := net.Listen("tcp", "127.0.0.1:0")
 	require.NoError(t, err)
 	waitGroup := sync.WaitGroup{}
@@ -648,7 +1159,7 @@ func setupSSHCommand(t *testing.T, beforeArgs []string, afterArgs []string) *exe
 func setupSSHSession(t *testing.T, options codersdk.WorkspaceAgentMetadata) *ssh.Session {
 	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
 	defer cancel()
-	conn, _, _ := setupAgent(t, options, 0)
+	conn, _, _, _ := setupAgent(t, options, 0)
 	sshClient, err := conn.SSHClient(ctx)
 	require.NoError(t, err)
 	t.Cleanup(func() {
@@ -665,8 +1176,34 @@ func (c closeFunc) Close() error {
 	return c()
 }
 
+func setupLifecycleAgent(t *testing.T, metadata codersdk.WorkspaceAgentMetadata) (*client, io.Closer) {
+	if runtime.GOOS == "windows" {
+		t.Skip("This test doesn't work on Windows for some reason...")
+	}
+	if metadata.DERPMap == nil {
+		metadata.DERPMap = tailnettest.RunDERPAndSTUN(t)
+	}
+	client := &client{
+		t:           t,
+		agentID:     uuid.New(),
+		metadata:    metadata,
+		statsChan:   make(chan *codersdk.AgentStats),
+		coordinator: tailnet.NewCoordinator(),
+	}
+	closer := agent.New(agent.Options{
+		Client:     client,
+		Filesystem: afero.NewMemMapFs(),
+		Logger:     slogtest.Make(t, nil).Leveled(slog.LevelDebug),
+	})
+	t.Cleanup(func() {
+		_ = closer.Close()
+	})
+	return client, closer
+}
+
 func setupAgent(t *testing.T, metadata codersdk.WorkspaceAgentMetadata, ptyTimeout time.Duration) (
 	*codersdk.AgentConn,
+	*client,
 	<-chan *codersdk.AgentStats,
 	afero.Fs,
 ) {
@@ -677,14 +1214,15 @@ func setupAgent(t *testing.T, metadata codersdk.WorkspaceAgentMetadata, ptyTimeo
 	agentID := uuid.New()
 	statsCh := make(chan *codersdk.AgentStats)
 	fs := afero.NewMemMapFs()
+	c := &client{
+		t:           t,
+		agentID:     agentID,
+		metadata:    metadata,
+		statsChan:   statsCh,
+		coordinator: coordinator,
+	}
 	closer := agent.New(agent.Options{
-		Client: &client{
-			t:           t,
-			agentID:     agentID,
-			metadata:    metadata,
-			statsChan:   statsCh,
-			coordinator: coordinator,
-		},
+		Client:                 c,
 		Filesystem:             fs,
 		Logger:                 slogtest.Make(t, nil).Leveled(slog.LevelDebug),
 		ReconnectingPTYTimeout: ptyTimeout,
@@ -711,7 +1249,7 @@ func setupAgent(t *testing.T, metadata codersdk.WorkspaceAgentMetadata, ptyTimeo
 	conn.SetNodeCallback(sendNode)
 	return &codersdk.AgentConn{
 		Conn: conn,
-	}, statsCh, fs
+	}, c, statsCh, fs
 }
 
 var dialTestPayload = []byte("dean-was-here123")
@@ -752,6 +1290,15 @@ type client struct {
 	statsChan          chan *codersdk.AgentStats
 	coordinator        tailnet.Coordinator
 	lastWorkspaceAgent func()
+	// shutdown is closed to request that the agent shuts down.
+	shutdown chan struct{}
+
+	mu              sync.Mutex // Protects following.
+	startupLogs     []codersdk.StartupLog
+	startupEOF      bool
+	lifecycleStates []codersdk.WorkspaceAgentLifecycle
+	metadataResults map[string][]codersdk.PostWorkspaceAgentMetadataRequest
+	recordings      []codersdk.PostWorkspaceAgentSessionRecordingRequest
 }
 
 func (c *client) WorkspaceAgentMetadata(_ context.Context) (codersdk.WorkspaceAgentMetadata, error) {
@@ -814,3 +1361,68 @@ func (*client) PostWorkspaceAgentAppHealth(_ context.Context, _ codersdk.PostWor
 func (*client) PostWorkspaceAgentVersion(_ context.Context, _ string) error {
 	return nil
 }
+
+func (c *client) PatchStartupLogs(_ context.Context, req codersdk.PatchStartupLogs) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.startupLogs = append(c.startupLogs, req.Logs...)
+	c.startupEOF = req.EOF
+	return nil
+}
+
+func (c *client) PostWorkspaceAgentLifecycle(_ context.Context, req codersdk.PostWorkspaceAgentLifecycleRequest) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lifecycleStates = append(c.lifecycleStates, req.State)
+	return nil
+}
+
+func (c *client) WorkspaceAgentAwaitShutdown(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.shutdown:
+		return nil
+	}
+}
+
+func (c *client) PostWorkspaceAgentMetadata(_ context.Context, key string, req codersdk.PostWorkspaceAgentMetadataRequest) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.metadataResults == nil {
+		c.metadataResults = make(map[string][]codersdk.PostWorkspaceAgentMetadataRequest)
+	}
+	c.metadataResults[key] = append(c.metadataResults[key], req)
+	return nil
+}
+
+func (c *client) PostWorkspaceAgentSessionRecording(_ context.Context, req codersdk.PostWorkspaceAgentSessionRecordingRequest) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.recordings = append(c.recordings, req)
+	return nil
+}
+
+func (c *client) getSessionRecordings() []codersdk.PostWorkspaceAgentSessionRecordingRequest {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]codersdk.PostWorkspaceAgentSessionRecordingRequest(nil), c.recordings...)
+}
+
+func (c *client) getMetadataResults(key string) []codersdk.PostWorkspaceAgentMetadataRequest {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]codersdk.PostWorkspaceAgentMetadataRequest(nil), c.metadataResults[key]...)
+}
+
+func (c *client) getLifecycleStates() []codersdk.WorkspaceAgentLifecycle {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]codersdk.WorkspaceAgentLifecycle(nil), c.lifecycleStates...)
+}
+
+func (c *client) getStartupLogs() ([]codersdk.StartupLog, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]codersdk.StartupLog(nil), c.startupLogs...), c.startupEOF
+}
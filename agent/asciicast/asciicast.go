@@ -0,0 +1,338 @@
+// Package asciicast reads and writes recordings of terminal sessions in the
+// asciicast v2 format.
+// See: https://github.com/asciinema/asciinema/blob/develop/doc/asciicast-v2.md
+package asciicast
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/xerrors"
+)
+
+// MimeType is the media type of recordings.
+const MimeType = "application/x-asciicast"
+
+// Header is the first line of a recording.
+type Header struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp,omitempty"`
+	Command   string            `json:"command,omitempty"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+type EventType string
+
+const (
+	EventTypeOutput EventType = "o"
+	EventTypeInput  EventType = "i"
+	// EventTypeResize events have data in the form "{width}x{height}".
+	EventTypeResize EventType = "r"
+)
+
+// Event is written to a recording after the header. Events are encoded as
+// [time, type, data] arrays, where time is in seconds since the start of the
+// recording.
+type Event struct {
+	Time time.Duration
+	Type EventType
+	Data string
+}
+
+func (e Event) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]any{
+		json.Number(strconv.FormatFloat(e.Time.Seconds(), 'f', 6, 64)),
+		e.Type,
+		e.Data,
+	})
+}
+
+func (e *Event) UnmarshalJSON(data []byte) error {
+	var raw []json.RawMessage
+	err := json.Unmarshal(data, &raw)
+	if err != nil {
+		return err
+	}
+	if len(raw) != 3 {
+		return xerrors.Errorf("expected 3 elements in event, got %d", len(raw))
+	}
+	var seconds float64
+	err = json.Unmarshal(raw[0], &seconds)
+	if err != nil {
+		return xerrors.Errorf("decode time: %w", err)
+	}
+	err = json.Unmarshal(raw[1], &e.Type)
+	if err != nil {
+		return xerrors.Errorf("decode type: %w", err)
+	}
+	err = json.Unmarshal(raw[2], &e.Data)
+	if err != nil {
+		return xerrors.Errorf("decode data: %w", err)
+	}
+	e.Time = time.Duration(seconds * float64(time.Second))
+	return nil
+}
+
+// Recorder writes a recording of a session. It is safe for concurrent use.
+type Recorder struct {
+	mu        sync.Mutex
+	w         io.Writer
+	start     time.Time
+	written   int64
+	limit     int64
+	truncated bool
+	closed    bool
+	err       error
+	// Incomplete UTF-8 sequences at the end of writes are held until the
+	// rest of the sequence is written, so events only contain valid text.
+	pending map[EventType][]byte
+}
+
+// NewRecorder writes the header of a recording to w and returns a recorder
+// for its events. Events are dropped once more than limit bytes have been
+// written. A limit of zero means there is no limit.
+func NewRecorder(w io.Writer, header Header, limit int64) (*Recorder, error) {
+	start := time.Now()
+	header.Version = 2
+	if header.Timestamp == 0 {
+		header.Timestamp = start.Unix()
+	}
+	data, err := json.Marshal(header)
+	if err != nil {
+		return nil, xerrors.Errorf("marshal header: %w", err)
+	}
+	n, err := w.Write(append(data, '\n'))
+	if err != nil {
+		return nil, xerrors.Errorf("write header: %w", err)
+	}
+	return &Recorder{
+		w:       w,
+		start:   start,
+		written: int64(n),
+		limit:   limit,
+		pending: map[EventType][]byte{},
+	}, nil
+}
+
+// Output records data written to the terminal.
+func (r *Recorder) Output(p []byte) {
+	r.text(EventTypeOutput, p)
+}
+
+// Input records data typed into the terminal.
+func (r *Recorder) Input(p []byte) {
+	r.text(EventTypeInput, p)
+}
+
+// Resize records a change to the size of the terminal.
+func (r *Recorder) Resize(width, height int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.write(EventTypeResize, fmt.Sprintf("%dx%d", width, height))
+}
+
+// Truncated returns whether events were dropped because the limit was
+// reached.
+func (r *Recorder) Truncated() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.truncated
+}
+
+// Err returns the first error writing events.
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// Close stops recording. Events recorded after Close are dropped, so the
+// underlying writer can be read once Close returns.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	return r.err
+}
+
+// OutputWriter returns a writer that records output.
+func (r *Recorder) OutputWriter() io.Writer {
+	return recorderWriter(r.Output)
+}
+
+// InputWriter returns a writer that records input.
+func (r *Recorder) InputWriter() io.Writer {
+	return recorderWriter(r.Input)
+}
+
+type recorderWriter func(p []byte)
+
+func (w recorderWriter) Write(p []byte) (int, error) {
+	w(p)
+	return len(p), nil
+}
+
+func (r *Recorder) text(eventType EventType, p []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	data := append(r.pending[eventType], p...)
+	complete, rest := splitIncomplete(data)
+	r.pending[eventType] = append([]byte(nil), rest...)
+	if len(complete) == 0 {
+		return
+	}
+	r.write(eventType, string(complete))
+}
+
+func (r *Recorder) write(eventType EventType, data string) {
+	if r.closed || r.truncated || r.err != nil {
+		return
+	}
+	line, err := json.Marshal(Event{
+		Time: time.Since(r.start),
+		Type: eventType,
+		Data: data,
+	})
+	if err != nil {
+		r.err = err
+		return
+	}
+	line = append(line, '\n')
+	if r.limit > 0 && r.written+int64(len(line)) > r.limit {
+		r.truncated = true
+		return
+	}
+	n, err := r.w.Write(line)
+	r.written += int64(n)
+	if err != nil {
+		r.err = err
+	}
+}
+
+// splitIncomplete splits an incomplete UTF-8 sequence from the end of p.
+func splitIncomplete(p []byte) (complete, rest []byte) {
+	for i := 1; i < utf8.UTFMax && i <= len(p); i++ {
+		if !utf8.RuneStart(p[len(p)-i]) {
+			continue
+		}
+		if !utf8.FullRune(p[len(p)-i:]) {
+			return p[:len(p)-i], p[len(p)-i:]
+		}
+		break
+	}
+	return p, nil
+}
+
+// Decoder reads a recording.
+type Decoder struct {
+	scanner *bufio.Scanner
+}
+
+// NewDecoder reads the header of a recording from r and returns a decoder
+// for its events.
+func NewDecoder(r io.Reader) (*Decoder, Header, error) {
+	scanner := bufio.NewScanner(r)
+	// Events can contain large amounts of output.
+	scanner.Buffer(make([]byte, 0, 64<<10), 16<<20)
+	if !scanner.Scan() {
+		err := scanner.Err()
+		if err == nil {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, Header{}, xerrors.Errorf("read header: %w", err)
+	}
+	var header Header
+	err := json.Unmarshal(scanner.Bytes(), &header)
+	if err != nil {
+		return nil, Header{}, xerrors.Errorf("decode header: %w", err)
+	}
+	if header.Version != 2 {
+		return nil, Header{}, xerrors.Errorf("unsupported asciicast version %d", header.Version)
+	}
+	return &Decoder{scanner: scanner}, header, nil
+}
+
+// Next returns the next event. It returns io.EOF when there are no more
+// events.
+func (d *Decoder) Next() (Event, error) {
+	for d.scanner.Scan() {
+		line := d.scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var event Event
+		err := json.Unmarshal(line, &event)
+		if err != nil {
+			return Event{}, xerrors.Errorf("decode event: %w", err)
+		}
+		return event, nil
+	}
+	if err := d.scanner.Err(); err != nil {
+		return Event{}, err
+	}
+	return Event{}, io.EOF
+}
+
+// PlayOptions configure the replay of a recording.
+type PlayOptions struct {
+	// Speed multiplies the playback speed. It defaults to 1.
+	Speed float64
+	// IdleTimeLimit caps the time between events. Zero means there is no
+	// limit.
+	IdleTimeLimit time.Duration
+}
+
+// Play writes the output of a recording to w with its original timing.
+func Play(ctx context.Context, r io.Reader, w io.Writer, opts PlayOptions) error {
+	if opts.Speed <= 0 {
+		opts.Speed = 1
+	}
+	decoder, _, err := NewDecoder(r)
+	if err != nil {
+		return err
+	}
+	var last time.Duration
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	<-timer.C
+	for {
+		event, err := decoder.Next()
+		if xerrors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if event.Type != EventTypeOutput {
+			continue
+		}
+		wait := event.Time - last
+		last = event.Time
+		if opts.IdleTimeLimit > 0 && wait > opts.IdleTimeLimit {
+			wait = opts.IdleTimeLimit
+		}
+		wait = time.Duration(float64(wait) / opts.Speed)
+		if wait > 0 {
+			timer.Reset(wait)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+		_, err = io.WriteString(w, event.Data)
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package asciicast_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/agent/asciicast"
+	"github.com/coder/coder/testutil"
+)
+
+func TestRecorder(t *testing.T) {
+	t.Parallel()
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		t.Parallel()
+		var buf bytes.Buffer
+		recorder, err := asciicast.NewRecorder(&buf, asciicast.Header{
+			Width:  80,
+			Height: 24,
+			Env:    map[string]string{"TERM": "xterm-256color"},
+		}, 0)
+		require.NoError(t, err)
+		recorder.Input([]byte("ls\r"))
+		recorder.Output([]byte("file.txt\r\n"))
+		recorder.Resize(120, 40)
+		require.NoError(t, recorder.Err())
+
+		decoder, header, err := asciicast.NewDecoder(&buf)
+		require.NoError(t, err)
+		require.Equal(t, 2, header.Version)
+		require.Equal(t, 80, header.Width)
+		require.NotZero(t, header.Timestamp)
+		require.Equal(t, "xterm-256color", header.Env["TERM"])
+
+		for _, expected := range []asciicast.Event{
+			{Type: asciicast.EventTypeInput, Data: "ls\r"},
+			{Type: asciicast.EventTypeOutput, Data: "file.txt\r\n"},
+			{Type: asciicast.EventTypeResize, Data: "120x40"},
+		} {
+			event, err := decoder.Next()
+			require.NoError(t, err)
+			require.Equal(t, expected.Type, event.Type)
+			require.Equal(t, expected.Data, event.Data)
+		}
+		_, err = decoder.Next()
+		require.ErrorIs(t, err, io.EOF)
+	})
+
+	t.Run("SplitRune", func(t *testing.T) {
+		t.Parallel()
+		var buf bytes.Buffer
+		recorder, err := asciicast.NewRecorder(&buf, asciicast.Header{}, 0)
+		require.NoError(t, err)
+		// "é" is encoded as two bytes.
+		encoded := []byte("é")
+		recorder.Output([]byte{'a', encoded[0]})
+		recorder.Output([]byte{encoded[1], 'b'})
+
+		decoder, _, err := asciicast.NewDecoder(&buf)
+		require.NoError(t, err)
+		event, err := decoder.Next()
+		require.NoError(t, err)
+		require.Equal(t, "a", event.Data)
+		event, err = decoder.Next()
+		require.NoError(t, err)
+		require.Equal(t, "éb", event.Data)
+	})
+
+	t.Run("Limit", func(t *testing.T) {
+		t.Parallel()
+		var buf bytes.Buffer
+		recorder, err := asciicast.NewRecorder(&buf, asciicast.Header{}, 128)
+		require.NoError(t, err)
+		recorder.Output([]byte("small"))
+		recorder.Output(bytes.Repeat([]byte("x"), 256))
+		// Events after the limit is reached are dropped.
+		recorder.Output([]byte("small"))
+		require.True(t, recorder.Truncated())
+		require.LessOrEqual(t, buf.Len(), 128)
+	})
+
+	t.Run("Close", func(t *testing.T) {
+		t.Parallel()
+		var buf bytes.Buffer
+		recorder, err := asciicast.NewRecorder(&buf, asciicast.Header{}, 0)
+		require.NoError(t, err)
+		recorder.Output([]byte("before"))
+		require.NoError(t, recorder.Close())
+		recorder.Output([]byte("after"))
+
+		decoder, _, err := asciicast.NewDecoder(&buf)
+		require.NoError(t, err)
+		event, err := decoder.Next()
+		require.NoError(t, err)
+		require.Equal(t, "before", event.Data)
+		_, err = decoder.Next()
+		require.ErrorIs(t, err, io.EOF)
+	})
+}
+
+func TestPlay(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitShort)
+	defer cancel()
+
+	var recording bytes.Buffer
+	recording.WriteString(`{"version": 2, "width": 80, "height": 24}` + "\n")
+	recording.WriteString(`[0.1, "o", "hello "]` + "\n")
+	recording.WriteString(`[0.2, "i", "typed"]` + "\n")
+	// A long pause is capped by the idle time limit.
+	recording.WriteString(`[3600.0, "o", "world"]` + "\n")
+
+	var out bytes.Buffer
+	start := time.Now()
+	err := asciicast.Play(ctx, &recording, &out, asciicast.PlayOptions{
+		Speed:         10,
+		IdleTimeLimit: time.Second,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "hello world", out.String())
+	require.Less(t, time.Since(start), testutil.WaitShort)
+}
@@ -0,0 +1,168 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"github.com/coder/coder/codersdk"
+)
+
+const (
+	// metadataDefaultTimeout is used for metadata scripts that don't
+	// declare a timeout.
+	metadataDefaultTimeout = 5 * time.Second
+	// metadataMaxLength is the maximum number of bytes of output reported
+	// for a metadata script. Longer output is truncated.
+	metadataMaxLength = 10 << 10
+	// metadataOutputDrainTimeout is how long to wait for the output of a
+	// metadata script after it exits.
+	metadataOutputDrainTimeout = time.Second
+)
+
+// reportMetadataLoop runs every metadata script on its interval and reports
+// the results to coderd until the context is canceled.
+func (a *agent) reportMetadataLoop(ctx context.Context, descriptions []codersdk.WorkspaceAgentMetadataDescription) {
+	var wg sync.WaitGroup
+	for _, description := range descriptions {
+		description := description
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a.reportMetadata(ctx, description)
+		}()
+	}
+	wg.Wait()
+}
+
+// reportMetadata runs a single metadata script on its interval. Scripts
+// without an interval are run once.
+func (a *agent) reportMetadata(ctx context.Context, description codersdk.WorkspaceAgentMetadataDescription) {
+	logger := a.logger.With(slog.F("key", description.Key))
+	interval := time.Duration(description.Interval) * time.Second
+	for {
+		result := a.collectMetadata(ctx, description)
+		if ctx.Err() != nil {
+			return
+		}
+		err := a.client.PostWorkspaceAgentMetadata(ctx, description.Key, result)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			// The next run reports a fresh value, so there's no need to
+			// retry this one.
+			logger.Warn(ctx, "report metadata", slog.Error(err))
+		}
+		if interval <= 0 {
+			return
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// collectMetadata runs the metadata script and returns its trimmed output.
+// Failures are reported in the result rather than returned.
+func (a *agent) collectMetadata(ctx context.Context, description codersdk.WorkspaceAgentMetadataDescription) codersdk.PostWorkspaceAgentMetadataRequest {
+	timeout := time.Duration(description.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = metadataDefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var result codersdk.PostWorkspaceAgentMetadataRequest
+	cmd, err := a.createCommand(ctx, description.Script, nil)
+	if err != nil {
+		result.Error = xerrors.Errorf("create command: %w", err).Error()
+		return result
+	}
+	// The output is read through an OS pipe rather than an io.Writer so
+	// that processes backgrounded by the script, which inherit stdout,
+	// don't block the script from completing.
+	outputReader, outputWriter, err := os.Pipe()
+	if err != nil {
+		result.Error = xerrors.Errorf("create output pipe: %w", err).Error()
+		return result
+	}
+	defer outputReader.Close()
+	output := &limitedBuffer{limit: metadataMaxLength}
+	copyDone := make(chan struct{})
+	go func() {
+		defer close(copyDone)
+		_, _ = io.Copy(output, outputReader)
+	}()
+	cmd.Stdout = outputWriter
+	cmd.Stderr = outputWriter
+	err = cmd.Start()
+	// The child process holds its own copy of the pipe.
+	_ = outputWriter.Close()
+	if err == nil {
+		err = cmd.Wait()
+		// Give the copy a moment to drain the pipe. It only blocks past
+		// this when a background process still holds the pipe open.
+		timer := time.NewTimer(metadataOutputDrainTimeout)
+		select {
+		case <-copyDone:
+		case <-timer.C:
+		case <-ctx.Done():
+		}
+		timer.Stop()
+	}
+	// Truncation may split a multi-byte character.
+	result.Value = strings.ToValidUTF8(strings.TrimSpace(output.String()), "")
+	if err != nil {
+		// cmd.Wait does not return a context error, it returns "signal: killed".
+		switch {
+		case errors.Is(ctx.Err(), context.DeadlineExceeded):
+			result.Error = xerrors.Errorf("script timed out after %s", timeout).Error()
+		case ctx.Err() != nil:
+			result.Error = ctx.Err().Error()
+		default:
+			result.Error = xerrors.Errorf("run: %w", err).Error()
+		}
+	}
+	return result
+}
+
+// limitedBuffer is an io.Writer that keeps the first limit bytes written
+// to it and discards the rest.
+type limitedBuffer struct {
+	mu    sync.Mutex
+	buf   bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if remaining := b.limit - b.buf.Len(); remaining > 0 {
+		if len(p) > remaining {
+			_, _ = b.buf.Write(p[:remaining])
+		} else {
+			_, _ = b.buf.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+func (b *limitedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
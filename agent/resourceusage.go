@@ -0,0 +1,77 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	"cdr.dev/slog"
+
+	"github.com/coder/coder/codersdk"
+)
+
+// resourceUsage is a sample of the resources used by the workspace.
+type resourceUsage struct {
+	// cpuTime is the CPU time used since an arbitrary point, so usage is
+	// measured as the difference between samples.
+	cpuTime          time.Duration
+	cpuTotalCores    float64
+	memoryUsedBytes  int64
+	memoryTotalBytes int64
+	diskUsedBytes    int64
+	diskTotalBytes   int64
+}
+
+// resourceUsageCollector samples the CPU, memory and disk usage of the
+// workspace for the agent stats. Inside a container, usage and limits are read
+// from the cgroup of the container rather than the host.
+type resourceUsageCollector struct {
+	// root is prepended to the paths of the cgroup and proc files read.
+	root string
+	// diskPath is a path on the filesystem whose usage is reported.
+	diskPath string
+
+	mu          sync.Mutex // Protects following.
+	lastCPUTime time.Duration
+	lastSample  time.Time
+}
+
+func newResourceUsageCollector() *resourceUsageCollector {
+	diskPath, err := os.UserHomeDir()
+	if err != nil {
+		diskPath = "/"
+	}
+	return &resourceUsageCollector{
+		root:     "/",
+		diskPath: diskPath,
+	}
+}
+
+// collect sets the resource usage of the stats. CPU usage is averaged over the
+// time since the previous call, so the first call reports none.
+func (c *resourceUsageCollector) collect(ctx context.Context, logger slog.Logger, stats *codersdk.AgentStats) {
+	usage, err := c.sample()
+	if err != nil {
+		logger.Debug(ctx, "sample resource usage", slog.Error(err))
+		return
+	}
+	now := time.Now()
+
+	c.mu.Lock()
+	if !c.lastSample.IsZero() && usage.cpuTime >= c.lastCPUTime {
+		elapsed := now.Sub(c.lastSample)
+		if elapsed > 0 {
+			stats.CPUUsedCores = float64(usage.cpuTime-c.lastCPUTime) / float64(elapsed)
+		}
+	}
+	c.lastCPUTime = usage.cpuTime
+	c.lastSample = now
+	c.mu.Unlock()
+
+	stats.CPUTotalCores = usage.cpuTotalCores
+	stats.MemoryUsedBytes = usage.memoryUsedBytes
+	stats.MemoryTotalBytes = usage.memoryTotalBytes
+	stats.DiskUsedBytes = usage.diskUsedBytes
+	stats.DiskTotalBytes = usage.diskTotalBytes
+}
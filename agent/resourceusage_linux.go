@@ -0,0 +1,204 @@
+//go:build linux
+
+package agent
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// clockTicksPerSecond is the unit of the CPU times in /proc/stat, which is
+// 100 on every architecture Linux supports.
+const clockTicksPerSecond = 100
+
+func (c *resourceUsageCollector) sample() (resourceUsage, error) {
+	var (
+		usage resourceUsage
+		err   error
+	)
+	usage.cpuTime, err = c.cpuTime()
+	if err != nil {
+		return resourceUsage{}, xerrors.Errorf("read cpu time: %w", err)
+	}
+	usage.cpuTotalCores = c.cpuTotalCores()
+	usage.memoryUsedBytes, usage.memoryTotalBytes, err = c.memory()
+	if err != nil {
+		return resourceUsage{}, xerrors.Errorf("read memory usage: %w", err)
+	}
+	var stat syscall.Statfs_t
+	err = syscall.Statfs(c.diskPath, &stat)
+	if err != nil {
+		return resourceUsage{}, xerrors.Errorf("statfs %q: %w", c.diskPath, err)
+	}
+	//nolint:unconvert // The field types differ between architectures.
+	blockSize := int64(stat.Bsize)
+	usage.diskTotalBytes = int64(stat.Blocks) * blockSize
+	usage.diskUsedBytes = int64(stat.Blocks-stat.Bfree) * blockSize
+	return usage, nil
+}
+
+func (c *resourceUsageCollector) path(elem ...string) string {
+	return filepath.Join(append([]string{c.root}, elem...)...)
+}
+
+// cgroupV2 returns whether the unified cgroup hierarchy is mounted.
+func (c *resourceUsageCollector) cgroupV2() bool {
+	_, err := os.Stat(c.path("sys/fs/cgroup/cgroup.controllers"))
+	return err == nil
+}
+
+// cpuTime returns the CPU time used by the cgroup, or by the host if cgroup
+// accounting isn't available.
+func (c *resourceUsageCollector) cpuTime() (time.Duration, error) {
+	if c.cgroupV2() {
+		stat, err := readKeyValueFile(c.path("sys/fs/cgroup/cpu.stat"))
+		if err == nil {
+			if usec, ok := stat["usage_usec"]; ok {
+				return time.Duration(usec) * time.Microsecond, nil
+			}
+		}
+	} else {
+		nsec, err := readIntFile(c.path("sys/fs/cgroup/cpuacct/cpuacct.usage"))
+		if err == nil {
+			return time.Duration(nsec), nil
+		}
+	}
+
+	data, err := os.ReadFile(c.path("proc/stat"))
+	if err != nil {
+		return 0, err
+	}
+	line, _, _ := bytes.Cut(data, []byte("\n"))
+	fields := strings.Fields(string(line))
+	if len(fields) < 5 || fields[0] != "cpu" {
+		return 0, xerrors.Errorf("unexpected /proc/stat line %q", line)
+	}
+	var ticks int64
+	for i, field := range fields[1:] {
+		// Skip the idle and iowait times.
+		if i == 3 || i == 4 {
+			continue
+		}
+		value, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			return 0, xerrors.Errorf("parse /proc/stat: %w", err)
+		}
+		ticks += value
+	}
+	return time.Duration(ticks) * time.Second / clockTicksPerSecond, nil
+}
+
+// cpuTotalCores returns the CPU quota of the cgroup in cores, or the number of
+// cores of the host if there is no quota.
+func (c *resourceUsageCollector) cpuTotalCores() float64 {
+	hostCores := float64(runtime.NumCPU())
+	var quota, period int64
+	if c.cgroupV2() {
+		data, err := os.ReadFile(c.path("sys/fs/cgroup/cpu.max"))
+		if err != nil {
+			return hostCores
+		}
+		fields := strings.Fields(string(data))
+		if len(fields) != 2 || fields[0] == "max" {
+			return hostCores
+		}
+		quota, _ = strconv.ParseInt(fields[0], 10, 64)
+		period, _ = strconv.ParseInt(fields[1], 10, 64)
+	} else {
+		quota, _ = readIntFile(c.path("sys/fs/cgroup/cpu/cpu.cfs_quota_us"))
+		period, _ = readIntFile(c.path("sys/fs/cgroup/cpu/cpu.cfs_period_us"))
+	}
+	if quota <= 0 || period <= 0 {
+		return hostCores
+	}
+	cores := float64(quota) / float64(period)
+	if cores > hostCores {
+		return hostCores
+	}
+	return cores
+}
+
+// memory returns the memory used by the cgroup and its limit. Inactive file
+// cache is excluded from the usage, as the kernel reclaims it before reaching
+// the limit. The host's memory is returned if cgroup accounting isn't
+// available.
+func (c *resourceUsageCollector) memory() (used int64, total int64, err error) {
+	meminfo, err := readKeyValueFile(c.path("proc/meminfo"))
+	if err != nil {
+		return 0, 0, err
+	}
+	// /proc/meminfo is in kibibytes.
+	hostTotal := meminfo["MemTotal"] * 1024
+	hostUsed := hostTotal - meminfo["MemAvailable"]*1024
+
+	var (
+		usageFile, limitFile, inactiveKey string
+		limit                             int64
+	)
+	if c.cgroupV2() {
+		usageFile = c.path("sys/fs/cgroup/memory.current")
+		limitFile = c.path("sys/fs/cgroup/memory.max")
+		inactiveKey = "inactive_file"
+	} else {
+		usageFile = c.path("sys/fs/cgroup/memory/memory.usage_in_bytes")
+		limitFile = c.path("sys/fs/cgroup/memory/memory.limit_in_bytes")
+		inactiveKey = "total_inactive_file"
+	}
+	used, err = readIntFile(usageFile)
+	if err != nil {
+		return hostUsed, hostTotal, nil
+	}
+	stat, err := readKeyValueFile(filepath.Join(filepath.Dir(usageFile), "memory.stat"))
+	if err == nil && stat[inactiveKey] < used {
+		used -= stat[inactiveKey]
+	}
+	// The limit is "max", or a huge number in cgroup v1, if there is none.
+	limit, err = readIntFile(limitFile)
+	if err != nil || limit <= 0 || limit > hostTotal {
+		limit = hostTotal
+	}
+	return used, limit, nil
+}
+
+func readIntFile(name string) (int64, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+}
+
+// readKeyValueFile reads a file of "key value" lines, like cpu.stat and
+// memory.stat. Keys may end with a colon and values with a unit, like in
+// /proc/meminfo. Lines with values that aren't integers are skipped.
+func readKeyValueFile(name string) (map[string]int64, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	values := map[string]int64{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		value, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		values[strings.TrimSuffix(fields[0], ":")] = value
+	}
+	return values, scanner.Err()
+}
@@ -0,0 +1,124 @@
+//go:build linux
+
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"cdr.dev/slog/sloggers/slogtest"
+
+	"github.com/coder/coder/codersdk"
+)
+
+func TestResourceUsageCollector(t *testing.T) {
+	t.Parallel()
+
+	writeFiles := func(t *testing.T, root string, files map[string]string) {
+		t.Helper()
+		for name, content := range files {
+			name = filepath.Join(root, name)
+			require.NoError(t, os.MkdirAll(filepath.Dir(name), 0o755))
+			require.NoError(t, os.WriteFile(name, []byte(content), 0o600))
+		}
+	}
+	const meminfo = "MemTotal:       16384000 kB\nMemFree:         1000000 kB\nMemAvailable:    8192000 kB\n"
+
+	t.Run("CgroupV2", func(t *testing.T) {
+		t.Parallel()
+		root := t.TempDir()
+		writeFiles(t, root, map[string]string{
+			"proc/meminfo":                     meminfo,
+			"sys/fs/cgroup/cgroup.controllers": "cpu memory\n",
+			"sys/fs/cgroup/cpu.stat":           "usage_usec 1000000\nuser_usec 800000\n",
+			"sys/fs/cgroup/cpu.max":            "50000 100000\n",
+			"sys/fs/cgroup/memory.current":     "3000000000\n",
+			"sys/fs/cgroup/memory.stat":        "anon 1000000000\ninactive_file 1000000000\n",
+			"sys/fs/cgroup/memory.max":         "4000000000\n",
+		})
+		c := &resourceUsageCollector{root: root, diskPath: root}
+		usage, err := c.sample()
+		require.NoError(t, err)
+		assert.Equal(t, time.Second, usage.cpuTime)
+		assert.Equal(t, 0.5, usage.cpuTotalCores)
+		assert.EqualValues(t, 2000000000, usage.memoryUsedBytes)
+		assert.EqualValues(t, 4000000000, usage.memoryTotalBytes)
+		assert.Greater(t, usage.diskTotalBytes, int64(0))
+		assert.LessOrEqual(t, usage.diskUsedBytes, usage.diskTotalBytes)
+	})
+
+	t.Run("CgroupV1Unlimited", func(t *testing.T) {
+		t.Parallel()
+		root := t.TempDir()
+		writeFiles(t, root, map[string]string{
+			"proc/meminfo":                               meminfo,
+			"sys/fs/cgroup/cpuacct/cpuacct.usage":        "2500000000\n",
+			"sys/fs/cgroup/cpu/cpu.cfs_quota_us":         "-1\n",
+			"sys/fs/cgroup/cpu/cpu.cfs_period_us":        "100000\n",
+			"sys/fs/cgroup/memory/memory.usage_in_bytes": "1000000000\n",
+			"sys/fs/cgroup/memory/memory.stat":           "total_inactive_file 200000000\n",
+			"sys/fs/cgroup/memory/memory.limit_in_bytes": "9223372036854771712\n",
+		})
+		c := &resourceUsageCollector{root: root, diskPath: root}
+		usage, err := c.sample()
+		require.NoError(t, err)
+		assert.Equal(t, 2500*time.Millisecond, usage.cpuTime)
+		assert.Equal(t, float64(runtime.NumCPU()), usage.cpuTotalCores)
+		assert.EqualValues(t, 800000000, usage.memoryUsedBytes)
+		// The limit is capped to the host's memory.
+		assert.EqualValues(t, 16384000*1024, usage.memoryTotalBytes)
+	})
+
+	t.Run("Host", func(t *testing.T) {
+		t.Parallel()
+		root := t.TempDir()
+		writeFiles(t, root, map[string]string{
+			"proc/meminfo": meminfo,
+			// user nice system idle iowait irq softirq
+			"proc/stat": "cpu  100 0 50 1000 20 5 5\ncpu0 100 0 50 1000 20 5 5\n",
+		})
+		c := &resourceUsageCollector{root: root, diskPath: root}
+		usage, err := c.sample()
+		require.NoError(t, err)
+		assert.Equal(t, 1600*time.Millisecond, usage.cpuTime)
+		assert.EqualValues(t, (16384000-8192000)*1024, usage.memoryUsedBytes)
+		assert.EqualValues(t, 16384000*1024, usage.memoryTotalBytes)
+	})
+
+	t.Run("CPUUsedCores", func(t *testing.T) {
+		t.Parallel()
+		root := t.TempDir()
+		files := map[string]string{
+			"proc/meminfo":                     meminfo,
+			"sys/fs/cgroup/cgroup.controllers": "cpu memory\n",
+			"sys/fs/cgroup/cpu.stat":           "usage_usec 1000000\n",
+		}
+		writeFiles(t, root, files)
+		c := &resourceUsageCollector{root: root, diskPath: root}
+		ctx := context.Background()
+		logger := slogtest.Make(t, nil)
+
+		var stats codersdk.AgentStats
+		c.collect(ctx, logger, &stats)
+		assert.Zero(t, stats.CPUUsedCores, "the first sample has nothing to compare to")
+		assert.Greater(t, stats.MemoryTotalBytes, int64(0))
+
+		// Pretend the previous sample was a second ago, and the workspace
+		// used two seconds of CPU time since.
+		c.mu.Lock()
+		c.lastSample = time.Now().Add(-time.Second)
+		c.mu.Unlock()
+		files["sys/fs/cgroup/cpu.stat"] = "usage_usec 3000000\n"
+		writeFiles(t, root, files)
+		stats = codersdk.AgentStats{}
+		c.collect(ctx, logger, &stats)
+		assert.InDelta(t, 2, stats.CPUUsedCores, 0.1)
+	})
+}
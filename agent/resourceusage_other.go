@@ -0,0 +1,9 @@
+//go:build !linux
+
+package agent
+
+import "golang.org/x/xerrors"
+
+func (*resourceUsageCollector) sample() (resourceUsage, error) {
+	return resourceUsage{}, xerrors.New("resource usage is only sampled on Linux")
+}
@@ -0,0 +1,158 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"time"
+
+	"cdr.dev/slog"
+
+	"github.com/coder/coder/agent/asciicast"
+	"github.com/coder/coder/codersdk"
+)
+
+const (
+	// maxSessionRecordingSize limits the memory used by a recording. Output
+	// after the limit is reached is not recorded.
+	maxSessionRecordingSize = 10 << 20
+	// sessionRecordingUploadTimeout bounds how long closing the agent waits
+	// for recordings to be uploaded.
+	sessionRecordingUploadTimeout = 30 * time.Second
+	// sessionRecordingNoTTYWidth and sessionRecordingNoTTYHeight are the
+	// size recorded for sessions without a TTY, since players require one.
+	sessionRecordingNoTTYWidth  = 80
+	sessionRecordingNoTTYHeight = 24
+)
+
+// sessionRecording records a terminal session. All methods are safe to call
+// on a nil recording, which is returned when recording is disabled.
+type sessionRecording struct {
+	recordingType codersdk.SessionRecordingType
+	command       string
+	startedAt     time.Time
+	buf           bytes.Buffer
+	recorder      *asciicast.Recorder
+}
+
+// sessionRecordingEnabled returns whether sessions in the workspace are
+// recorded.
+func (a *agent) sessionRecordingEnabled() bool {
+	metadata, ok := a.metadata.Load().(codersdk.WorkspaceAgentMetadata)
+	return ok && metadata.SessionRecording
+}
+
+// startSessionRecording returns a recording for a new terminal session, or
+// nil if session recording is disabled for the workspace. The term is empty
+// for sessions without a TTY.
+func (a *agent) startSessionRecording(ctx context.Context, recordingType codersdk.SessionRecordingType, command, term string, width, height int) *sessionRecording {
+	if !a.sessionRecordingEnabled() {
+		return nil
+	}
+	r := &sessionRecording{
+		recordingType: recordingType,
+		command:       command,
+		startedAt:     time.Now(),
+	}
+	header := asciicast.Header{
+		Width:   width,
+		Height:  height,
+		Command: command,
+	}
+	if term != "" {
+		header.Env = map[string]string{"TERM": term}
+	}
+	var err error
+	r.recorder, err = asciicast.NewRecorder(&r.buf, header, maxSessionRecordingSize)
+	if err != nil {
+		a.logger.Warn(ctx, "start session recording", slog.Error(err))
+		return nil
+	}
+	return r
+}
+
+// teeOutput returns a writer that writes to w and records the output.
+func (r *sessionRecording) teeOutput(w io.Writer) io.Writer {
+	if r == nil {
+		return w
+	}
+	return io.MultiWriter(w, r.recorder.OutputWriter())
+}
+
+// teeInput returns a reader that reads from rd and records the input.
+func (r *sessionRecording) teeInput(rd io.Reader) io.Reader {
+	if r == nil {
+		return rd
+	}
+	return io.TeeReader(rd, r.recorder.InputWriter())
+}
+
+func (r *sessionRecording) output(p []byte) {
+	if r == nil {
+		return
+	}
+	r.recorder.Output(p)
+}
+
+func (r *sessionRecording) input(p []byte) {
+	if r == nil {
+		return
+	}
+	r.recorder.Input(p)
+}
+
+func (r *sessionRecording) resize(width, height int) {
+	if r == nil {
+		return
+	}
+	r.recorder.Resize(width, height)
+}
+
+// uploadSessionRecording stops the recording and uploads it.
+func (a *agent) uploadSessionRecording(ctx context.Context, r *sessionRecording) {
+	if r == nil {
+		return
+	}
+	err := r.recorder.Close()
+	if err != nil {
+		a.logger.Warn(ctx, "session recording failed", slog.Error(err))
+		return
+	}
+	if r.recorder.Truncated() {
+		a.logger.Warn(ctx, "session recording was truncated", slog.F("limit", maxSessionRecordingSize))
+	}
+	// The session context is canceled when the session ends, so the upload
+	// uses its own.
+	uploadCtx, cancel := context.WithTimeout(context.Background(), sessionRecordingUploadTimeout)
+	defer cancel()
+	err = a.client.PostWorkspaceAgentSessionRecording(uploadCtx, codersdk.PostWorkspaceAgentSessionRecordingRequest{
+		Type:      r.recordingType,
+		Command:   r.command,
+		StartedAt: r.startedAt,
+		EndedAt:   time.Now(),
+		Recording: r.buf.Bytes(),
+	})
+	if err != nil {
+		a.logger.Warn(ctx, "upload session recording", slog.Error(err))
+	}
+}
+
+// uploadSessionRecordingAsync uploads the recording in the background so the
+// session can end without waiting for the upload.
+func (a *agent) uploadSessionRecordingAsync(ctx context.Context, r *sessionRecording) {
+	if r == nil {
+		return
+	}
+	a.closeMutex.Lock()
+	if a.isClosed() {
+		a.closeMutex.Unlock()
+		a.logger.Warn(ctx, "agent closed before session recording was uploaded")
+		return
+	}
+	a.connCloseWait.Add(1)
+	a.closeMutex.Unlock()
+	go func() {
+		defer a.connCloseWait.Done()
+		a.uploadSessionRecording(ctx, r)
+	}()
+}
@@ -0,0 +1,194 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"github.com/coder/coder/codersdk"
+)
+
+const (
+	// startupLogsFlushInterval is how often buffered startup script output
+	// is sent to coderd.
+	startupLogsFlushInterval = 250 * time.Millisecond
+	// startupLogsBatchSize is the maximum number of lines sent in a single
+	// request.
+	startupLogsBatchSize = 1000
+	// startupLogsMaxQueued is the maximum number of lines buffered while
+	// coderd is unreachable. Older lines are dropped beyond this limit.
+	startupLogsMaxQueued = 10000
+)
+
+// startupLogSender is an io.Writer that splits startup script output into
+// lines and streams them to coderd in batches.
+type startupLogSender struct {
+	ctx    context.Context
+	logger slog.Logger
+	client Client
+
+	mu      sync.Mutex
+	partial []byte
+	queue   []codersdk.StartupLog
+	eof     bool
+	closed  bool
+	// overflowed is set once coderd rejects logs for exceeding the
+	// maximum length. Further output is discarded.
+	overflowed bool
+
+	flush chan struct{}
+	done  chan struct{}
+}
+
+func newStartupLogSender(ctx context.Context, logger slog.Logger, client Client) *startupLogSender {
+	s := &startupLogSender{
+		ctx:    ctx,
+		logger: logger,
+		client: client,
+		flush:  make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+func (s *startupLogSender) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.partial = append(s.partial, p...)
+	for {
+		index := bytes.IndexByte(s.partial, '\n')
+		if index < 0 {
+			break
+		}
+		s.enqueue(string(s.partial[:index]))
+		s.partial = s.partial[index+1:]
+	}
+	return len(p), nil
+}
+
+// enqueue must be called with the mutex held.
+func (s *startupLogSender) enqueue(line string) {
+	if s.overflowed {
+		return
+	}
+	s.queue = append(s.queue, codersdk.StartupLog{
+		CreatedAt: time.Now(),
+		Output:    strings.TrimSuffix(line, "\r"),
+	})
+	if len(s.queue) > startupLogsMaxQueued {
+		s.queue = s.queue[len(s.queue)-startupLogsMaxQueued:]
+	}
+}
+
+// markEOF indicates the startup script has exited. Output written after
+// this by processes the script left running is still sent.
+func (s *startupLogSender) markEOF() {
+	s.mu.Lock()
+	s.eof = true
+	s.mu.Unlock()
+	s.triggerFlush()
+}
+
+// Close flushes any trailing partial line and waits for all queued
+// output to be sent, or for the context to be canceled.
+func (s *startupLogSender) Close() error {
+	s.mu.Lock()
+	if len(s.partial) > 0 {
+		s.enqueue(string(s.partial))
+		s.partial = nil
+	}
+	s.closed = true
+	s.mu.Unlock()
+	s.triggerFlush()
+	<-s.done
+	return nil
+}
+
+func (s *startupLogSender) triggerFlush() {
+	select {
+	case s.flush <- struct{}{}:
+	default:
+	}
+}
+
+func (s *startupLogSender) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(startupLogsFlushInterval)
+	defer ticker.Stop()
+
+	sentEOF := false
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.flush:
+		}
+
+		s.mu.Lock()
+		logs := s.queue
+		s.queue = nil
+		eof := s.eof
+		closed := s.closed
+		s.mu.Unlock()
+
+		for len(logs) > 0 || (eof && !sentEOF) {
+			batch := logs
+			if len(batch) > startupLogsBatchSize {
+				batch = batch[:startupLogsBatchSize]
+			}
+			err := s.client.PatchStartupLogs(s.ctx, codersdk.PatchStartupLogs{
+				Logs: batch,
+				EOF:  eof && len(batch) == len(logs),
+			})
+			var sdkErr *codersdk.Error
+			if xerrors.As(err, &sdkErr) && sdkErr.StatusCode() == http.StatusRequestEntityTooLarge {
+				// coderd stores a limited amount of output, so the rest
+				// is discarded. EOF is still sent on its own.
+				s.logger.Warn(s.ctx, "startup logs too large, dropping remaining logs", slog.Error(err))
+				s.mu.Lock()
+				s.overflowed = true
+				s.queue = nil
+				s.mu.Unlock()
+				logs = nil
+				continue
+			}
+			if err != nil {
+				if s.ctx.Err() != nil {
+					return
+				}
+				s.logger.Warn(s.ctx, "send startup logs", slog.Error(err))
+				// Retry on the next tick.
+				s.mu.Lock()
+				s.queue = append(logs, s.queue...)
+				if len(s.queue) > startupLogsMaxQueued {
+					s.queue = s.queue[len(s.queue)-startupLogsMaxQueued:]
+				}
+				s.mu.Unlock()
+				break
+			}
+			logs = logs[len(batch):]
+			if eof && len(logs) == 0 {
+				sentEOF = true
+			}
+		}
+
+		if closed && sentEOF {
+			s.mu.Lock()
+			empty := len(s.queue) == 0
+			s.mu.Unlock()
+			if empty {
+				return
+			}
+		}
+	}
+}
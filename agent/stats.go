@@ -36,13 +36,60 @@ type Stats struct {
 	NumConns int64 `json:"num_comms"`
 	RxBytes  int64 `json:"rx_bytes"`
 	TxBytes  int64 `json:"tx_bytes"`
+
+	// The number of open sessions of each type.
+	SessionCountSSH             int64 `json:"session_count_ssh"`
+	SessionCountReconnectingPTY int64 `json:"session_count_reconnecting_pty"`
+	SessionCountVSCode          int64 `json:"session_count_vscode"`
+	SessionCountJetBrains       int64 `json:"session_count_jetbrains"`
+
+	// The number of sessions of each type opened since the agent started.
+	ConnectionCountSSH             int64 `json:"connection_count_ssh"`
+	ConnectionCountReconnectingPTY int64 `json:"connection_count_reconnecting_pty"`
+	ConnectionCountVSCode          int64 `json:"connection_count_vscode"`
+	ConnectionCountJetBrains       int64 `json:"connection_count_jetbrains"`
 }
 
 func (s *Stats) Copy() *codersdk.AgentStats {
 	return &codersdk.AgentStats{
-		NumConns: atomic.LoadInt64(&s.NumConns),
-		RxBytes:  atomic.LoadInt64(&s.RxBytes),
-		TxBytes:  atomic.LoadInt64(&s.TxBytes),
+		NumConns:                    atomic.LoadInt64(&s.NumConns),
+		RxBytes:                     atomic.LoadInt64(&s.RxBytes),
+		TxBytes:                     atomic.LoadInt64(&s.TxBytes),
+		SessionCountSSH:             atomic.LoadInt64(&s.SessionCountSSH),
+		SessionCountReconnectingPTY: atomic.LoadInt64(&s.SessionCountReconnectingPTY),
+		SessionCountVSCode:          atomic.LoadInt64(&s.SessionCountVSCode),
+		SessionCountJetBrains:       atomic.LoadInt64(&s.SessionCountJetBrains),
+
+		ConnectionCountSSH:             atomic.LoadInt64(&s.ConnectionCountSSH),
+		ConnectionCountReconnectingPTY: atomic.LoadInt64(&s.ConnectionCountReconnectingPTY),
+		ConnectionCountVSCode:          atomic.LoadInt64(&s.ConnectionCountVSCode),
+		ConnectionCountJetBrains:       atomic.LoadInt64(&s.ConnectionCountJetBrains),
+	}
+}
+
+// sessionCounters returns the open session count and the connection count of
+// a session type.
+func (s *Stats) sessionCounters(sessionType string) (open *int64, total *int64) {
+	switch sessionType {
+	case SessionTypeVSCode:
+		return &s.SessionCountVSCode, &s.ConnectionCountVSCode
+	case SessionTypeJetBrains:
+		return &s.SessionCountJetBrains, &s.ConnectionCountJetBrains
+	case SessionTypeReconnectingPTY:
+		return &s.SessionCountReconnectingPTY, &s.ConnectionCountReconnectingPTY
+	default:
+		return &s.SessionCountSSH, &s.ConnectionCountSSH
+	}
+}
+
+// trackSession counts a session as open until the returned function is
+// called.
+func (s *Stats) trackSession(sessionType string) func() {
+	open, total := s.sessionCounters(sessionType)
+	atomic.AddInt64(total, 1)
+	atomic.AddInt64(open, 1)
+	return func() {
+		atomic.AddInt64(open, -1)
 	}
 }
 
@@ -1,7 +1,10 @@
 package agent
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"sort"
 	"sync"
 	"time"
 
@@ -11,7 +14,7 @@ import (
 	"github.com/coder/coder/codersdk"
 )
 
-func (*agent) statisticsHandler() http.Handler {
+func (a *agent) statisticsHandler() http.Handler {
 	r := chi.NewRouter()
 	r.Get("/", func(rw http.ResponseWriter, r *http.Request) {
 		httpapi.Write(r.Context(), rw, http.StatusOK, codersdk.Response{
@@ -21,6 +24,9 @@ func (*agent) statisticsHandler() http.Handler {
 
 	lp := &listeningPortsHandler{}
 	r.Get("/api/v0/listening-ports", lp.handler)
+	r.Get("/api/v0/reconnecting-ptys", a.reconnectingPTYsHandler)
+	r.Patch("/api/v0/reconnecting-ptys/{id}", a.updateReconnectingPTYHandler)
+	r.Delete("/api/v0/reconnecting-ptys/{id}", a.closeReconnectingPTYHandler)
 
 	return r
 }
@@ -47,3 +53,74 @@ func (lp *listeningPortsHandler) handler(rw http.ResponseWriter, r *http.Request
 		Ports: ports,
 	})
 }
+
+// reconnectingPTYsHandler returns the reconnecting PTYs that are running,
+// oldest first.
+func (a *agent) reconnectingPTYsHandler(rw http.ResponseWriter, r *http.Request) {
+	sessions := make([]codersdk.ReconnectingPTYSession, 0)
+	a.reconnectingPTYs.Range(func(_, value interface{}) bool {
+		rpty, ok := value.(*reconnectingPTY)
+		if ok {
+			sessions = append(sessions, rpty.session())
+		}
+		return true
+	})
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].StartedAt.Before(sessions[j].StartedAt)
+	})
+
+	httpapi.Write(r.Context(), rw, http.StatusOK, codersdk.ReconnectingPTYSessionsResponse{
+		Sessions: sessions,
+	})
+}
+
+// closeReconnectingPTYHandler kills the process of a reconnecting PTY and
+// disconnects its clients.
+func (a *agent) closeReconnectingPTYHandler(rw http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	rawRPTY, ok := a.reconnectingPTYs.LoadAndDelete(id)
+	if !ok {
+		httpapi.Write(r.Context(), rw, http.StatusNotFound, codersdk.Response{
+			Message: fmt.Sprintf("Reconnecting PTY %q not found.", id),
+		})
+		return
+	}
+	rpty, ok := rawRPTY.(*reconnectingPTY)
+	if ok {
+		// Closing the PTY ends the output loop, which kills the process.
+		rpty.Close()
+	}
+
+	httpapi.Write(r.Context(), rw, http.StatusNoContent, nil)
+}
+
+// updateReconnectingPTYHandler renames a reconnecting PTY.
+func (a *agent) updateReconnectingPTYHandler(rw http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	var req codersdk.UpdateReconnectingPTYSessionRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		httpapi.Write(r.Context(), rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Invalid request body.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	rawRPTY, ok := a.reconnectingPTYs.Load(id)
+	if !ok {
+		httpapi.Write(r.Context(), rw, http.StatusNotFound, codersdk.Response{
+			Message: fmt.Sprintf("Reconnecting PTY %q not found.", id),
+		})
+		return
+	}
+	rpty, ok := rawRPTY.(*reconnectingPTY)
+	if !ok {
+		httpapi.Write(r.Context(), rw, http.StatusInternalServerError, codersdk.Response{
+			Message: fmt.Sprintf("Reconnecting PTY %q has an unexpected type %T.", id, rawRPTY),
+		})
+		return
+	}
+	rpty.setName(req.Name)
+
+	httpapi.Write(r.Context(), rw, http.StatusOK, rpty.session())
+}
@@ -20,6 +20,10 @@ type AgentOptions struct {
 	Fetch         func(context.Context) (codersdk.WorkspaceAgent, error)
 	FetchInterval time.Duration
 	WarnInterval  time.Duration
+	// Wait waits for the agent to finish running its startup script
+	// after it has connected. Otherwise a notice is displayed if the
+	// startup script is still running.
+	Wait bool
 }
 
 // Agent displays a spinning indicator that waits for a workspace agent to connect.
@@ -36,14 +40,15 @@ func Agent(ctx context.Context, writer io.Writer, opts AgentOptions) error {
 		return xerrors.Errorf("fetch: %w", err)
 	}
 
-	if agent.Status == codersdk.WorkspaceAgentConnected {
+	if agentReady(agent, opts.Wait) {
+		showLifecycleNotice(writer, agent)
 		return nil
 	}
 
 	spin := spinner.New(spinner.CharSets[78], 100*time.Millisecond, spinner.WithColor("fgHiGreen"))
 	spin.Writer = writer
 	spin.ForceOutput = true
-	spin.Suffix = " Waiting for connection from " + Styles.Field.Render(agent.Name) + "..."
+	spin.Suffix = waitingSuffix(agent)
 	spin.Start()
 	defer spin.Stop()
 
@@ -119,15 +124,60 @@ func Agent(ctx context.Context, writer io.Writer, opts AgentOptions) error {
 			return xerrors.Errorf("fetch: %w", err)
 		}
 		resourceMutex.Unlock()
+		if agentReady(agent, opts.Wait) {
+			spin.Stop()
+			showLifecycleNotice(writer, agent)
+			return nil
+		}
 		switch agent.Status {
 		case codersdk.WorkspaceAgentConnected:
-			return nil
+			spin.Lock()
+			spin.Suffix = waitingSuffix(agent)
+			spin.Unlock()
 		case codersdk.WorkspaceAgentTimeout, codersdk.WorkspaceAgentDisconnected:
 			showMessage()
 		}
 	}
 }
 
+// agentReady returns whether the agent is connected and, if waiting,
+// whether it has finished running its startup script.
+func agentReady(agent codersdk.WorkspaceAgent, wait bool) bool {
+	if agent.Status != codersdk.WorkspaceAgentConnected {
+		return false
+	}
+	return !wait || !agent.LifecycleState.Starting()
+}
+
+func waitingSuffix(agent codersdk.WorkspaceAgent) string {
+	if agent.Status == codersdk.WorkspaceAgentConnected {
+		return " Waiting for the startup script of " + Styles.Field.Render(agent.Name) + " to finish..."
+	}
+	return " Waiting for connection from " + Styles.Field.Render(agent.Name) + "..."
+}
+
+// showLifecycleNotice warns when a connected agent isn't ready for use.
+func showLifecycleNotice(writer io.Writer, agent codersdk.WorkspaceAgent) {
+	var m string
+	switch agent.LifecycleState {
+	case codersdk.WorkspaceAgentLifecycleStarting:
+		m = "The startup script is still running and your workspace may be incomplete."
+	case codersdk.WorkspaceAgentLifecycleStartTimeout:
+		m = "The startup script timed out and your workspace may be incomplete."
+	case codersdk.WorkspaceAgentLifecycleStartError:
+		m = "The startup script exited with an error and your workspace may be incomplete."
+	default:
+		if !agent.LifecycleState.ShuttingDown() {
+			return
+		}
+		m = "The workspace agent is shutting down."
+	}
+	if agent.TroubleshootingURL != "" {
+		m = fmt.Sprintf("%s See troubleshooting instructions at: %s", m, agent.TroubleshootingURL)
+	}
+	_, _ = fmt.Fprintf(writer, "%s\n", Styles.Warn.Render("Notice: "+m))
+}
+
 func waitingMessage(agent codersdk.WorkspaceAgent) string {
 	var m string
 	switch agent.Status {
@@ -97,3 +97,78 @@ func TestAgentTimeoutWithTroubleshootingURL(t *testing.T) {
 	connected.Store(true)
 	<-done
 }
+
+func TestAgentWaitForStartupScript(t *testing.T) {
+	t.Parallel()
+
+	ctx, _ := testutil.Context(t)
+
+	var ready atomic.Bool
+	cmd := &cobra.Command{
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := cliui.Agent(cmd.Context(), cmd.OutOrStdout(), cliui.AgentOptions{
+				WorkspaceName: "example",
+				Fetch: func(ctx context.Context) (codersdk.WorkspaceAgent, error) {
+					agent := codersdk.WorkspaceAgent{
+						Name:           "main",
+						Status:         codersdk.WorkspaceAgentConnected,
+						LifecycleState: codersdk.WorkspaceAgentLifecycleStarting,
+					}
+					if ready.Load() {
+						agent.LifecycleState = codersdk.WorkspaceAgentLifecycleReady
+					}
+					return agent, nil
+				},
+				FetchInterval: time.Millisecond,
+				Wait:          true,
+			})
+			return err
+		},
+	}
+	ptty := ptytest.New(t)
+	cmd.SetOutput(ptty.Output())
+	cmd.SetIn(ptty.Input())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		err := cmd.ExecuteContext(ctx)
+		assert.NoError(t, err)
+	}()
+	ptty.ExpectMatch("to finish")
+	ready.Store(true)
+	<-done
+}
+
+func TestAgentLifecycleNotice(t *testing.T) {
+	t.Parallel()
+
+	ctx, _ := testutil.Context(t)
+
+	cmd := &cobra.Command{
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := cliui.Agent(cmd.Context(), cmd.OutOrStdout(), cliui.AgentOptions{
+				WorkspaceName: "example",
+				Fetch: func(ctx context.Context) (codersdk.WorkspaceAgent, error) {
+					return codersdk.WorkspaceAgent{
+						Status:         codersdk.WorkspaceAgentConnected,
+						LifecycleState: codersdk.WorkspaceAgentLifecycleStartError,
+					}, nil
+				},
+				FetchInterval: time.Millisecond,
+				Wait:          true,
+			})
+			return err
+		},
+	}
+	ptty := ptytest.New(t)
+	cmd.SetOutput(ptty.Output())
+	cmd.SetIn(ptty.Input())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		err := cmd.ExecuteContext(ctx)
+		assert.NoError(t, err)
+	}()
+	ptty.ExpectMatch("exited with an error")
+	<-done
+}
@@ -60,3 +60,90 @@ func ParameterSchema(cmd *cobra.Command, parameterSchema codersdk.ParameterSchem
 
 	return value, nil
 }
+
+// RichParameter prompts for the value of a rich parameter declared by a
+// template version. Values are validated before they are returned.
+func RichParameter(cmd *cobra.Command, templateVersionParameter codersdk.TemplateVersionParameter) (string, error) {
+	_, _ = fmt.Fprintln(cmd.OutOrStdout(), Styles.Bold.Render(templateVersionParameter.Name))
+	if templateVersionParameter.Description != "" {
+		_, _ = fmt.Fprintln(cmd.OutOrStdout(), "  "+strings.TrimSpace(strings.Join(strings.Split(templateVersionParameter.Description, "\n"), "\n  "))+"\n")
+	}
+
+	var (
+		value string
+		err   error
+	)
+	options := richParameterOptions(templateVersionParameter)
+	if len(options) > 0 && templateVersionParameter.Type != codersdk.RichParameterTypeListString {
+		names := make([]string, 0, len(options))
+		var defaultName string
+		for _, option := range options {
+			names = append(names, option.Name)
+			if option.Value == templateVersionParameter.DefaultValue {
+				defaultName = option.Name
+			}
+		}
+		// Move the cursor up a single line for nicer display!
+		_, _ = fmt.Fprint(cmd.OutOrStdout(), "\033[1A")
+		var name string
+		name, err = Select(cmd, SelectOptions{
+			Options:    names,
+			Default:    defaultName,
+			HideSearch: true,
+		})
+		if err != nil {
+			return "", err
+		}
+		for _, option := range options {
+			if option.Name == name {
+				value = option.Value
+				break
+			}
+		}
+		_, _ = fmt.Fprintln(cmd.OutOrStdout())
+		_, _ = fmt.Fprintln(cmd.OutOrStdout(), "  "+Styles.Prompt.String()+Styles.Field.Render(name))
+	} else {
+		text := "Enter a value"
+		if templateVersionParameter.DefaultValue != "" {
+			text += fmt.Sprintf(" (default: %q)", templateVersionParameter.DefaultValue)
+		}
+		text += ":"
+
+		value, err = Prompt(cmd, PromptOptions{
+			Text: Styles.Bold.Render(text),
+			Validate: func(value string) error {
+				value = strings.TrimSpace(value)
+				if value == "" {
+					value = templateVersionParameter.DefaultValue
+				}
+				return codersdk.ValidateWorkspaceBuildParameter(templateVersionParameter, value)
+			},
+		})
+		if err != nil {
+			return "", err
+		}
+		value = strings.TrimSpace(value)
+		// If they didn't specify anything, use the default value.
+		if value == "" {
+			value = templateVersionParameter.DefaultValue
+		}
+	}
+
+	err = codersdk.ValidateWorkspaceBuildParameter(templateVersionParameter, value)
+	if err != nil {
+		return "", err
+	}
+	return value, nil
+}
+
+// richParameterOptions returns the options to select from for a parameter.
+// Booleans can only be "true" or "false".
+func richParameterOptions(templateVersionParameter codersdk.TemplateVersionParameter) []codersdk.TemplateVersionParameterOption {
+	if len(templateVersionParameter.Options) > 0 || templateVersionParameter.Type != codersdk.RichParameterTypeBool {
+		return templateVersionParameter.Options
+	}
+	return []codersdk.TemplateVersionParameterOption{
+		{Name: "true", Value: "true"},
+		{Name: "false", Value: "false"},
+	}
+}
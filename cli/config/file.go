@@ -26,6 +26,8 @@ func (r Root) URL() File {
 	return File(filepath.Join(string(r), "url"))
 }
 
+// Organization holds the ID of the organization selected with
+// "coder organizations switch".
 func (r Root) Organization() File {
 	return File(filepath.Join(string(r), "organization"))
 }
@@ -29,6 +29,7 @@ import (
 
 const (
 	sshDefaultConfigFileName = "~/.ssh/config"
+	sshDefaultHostPrefix     = "coder."
 	sshStartToken            = "# ------------START-CODER-----------"
 	sshEndToken              = "# ------------END-CODER------------"
 	sshConfigSectionHeader   = "# This section is managed by coder. DO NOT EDIT."
@@ -46,6 +47,10 @@ const (
 // from the coder config in ~/.ssh/coder.
 type sshConfigOptions struct {
 	sshOptions []string
+	// wildcard writes a single "Host coder.*" entry that resolves the
+	// workspace and agent when connecting, instead of one entry per
+	// workspace agent.
+	wildcard bool
 }
 
 func (o sshConfigOptions) equal(other sshConfigOptions) bool {
@@ -64,6 +69,28 @@ func (o sshConfigOptions) asList() (list []string) {
 	return list
 }
 
+// sshHostOptions returns the options of a host entry, excluding the Host
+// line itself. HostName is omitted when hostName is empty.
+func (o sshConfigOptions) sshHostOptions(hostName string) []string {
+	var configOptions []string
+	for _, option := range o.sshOptions {
+		configOptions = append(configOptions, "\t"+option)
+	}
+	if hostName != "" {
+		configOptions = append(configOptions, "\tHostName "+hostName)
+	}
+	return append(configOptions,
+		"\tConnectTimeout=0",
+		"\tStrictHostKeyChecking=no",
+		// Without this, the "REMOTE HOST IDENTITY CHANGED"
+		// message will appear.
+		"\tUserKnownHostsFile=/dev/null",
+		// This disables the "Warning: Permanently added 'hostname' (RSA) to the list of known hosts."
+		// message from appearing on every SSH. This happens because we ignore the known hosts.
+		"\tLogLevel ERROR",
+	)
+}
+
 type sshWorkspaceConfig struct {
 	Name  string
 	Hosts []string
@@ -139,6 +166,7 @@ func configSSH() *cobra.Command {
 		usePreviousOpts  bool
 		dryRun           bool
 		skipProxyCommand bool
+		wildcard         bool
 	)
 	cmd := &cobra.Command{
 		Annotations: workspaceCommand,
@@ -153,6 +181,10 @@ func configSSH() *cobra.Command {
 				Description: "You can use --dry-run (or -n) to see the changes that would be made",
 				Command:     "coder config-ssh --dry-run",
 			},
+			example{
+				Description: "You can use --wildcard so new workspaces are reachable without running config-ssh again",
+				Command:     "coder config-ssh --wildcard",
+			},
 		),
 		Args: cobra.ExactArgs(0),
 		RunE: func(cmd *cobra.Command, _ []string) error {
@@ -246,6 +278,20 @@ func configSSH() *cobra.Command {
 				}
 			}
 
+			// Once enabled, wildcard mode is kept until it's explicitly
+			// disabled so the config doesn't go stale again.
+			sshConfigOpts.wildcard = wildcard
+			if !cmd.Flags().Changed("wildcard") && lastConfig != nil {
+				sshConfigOpts.wildcard = lastConfig.wildcard
+			}
+			if lastConfig != nil && lastConfig.wildcard != sshConfigOpts.wildcard {
+				if sshConfigOpts.wildcard {
+					changes = append(changes, "Replace the workspace host entries with a wildcard host entry")
+				} else {
+					changes = append(changes, "Replace the wildcard host entry with workspace host entries")
+				}
+			}
+
 			configModified := configRaw
 
 			buf := &bytes.Buffer{}
@@ -266,39 +312,45 @@ func configSSH() *cobra.Command {
 			slices.SortFunc(workspaceConfigs, func(a, b sshWorkspaceConfig) bool {
 				return a.Name < b.Name
 			})
-			for _, wc := range workspaceConfigs {
-				sort.Strings(wc.Hosts)
-				// Write agent configuration.
-				for _, hostname := range wc.Hosts {
-					configOptions := []string{
-						"Host coder." + hostname,
-					}
-					for _, option := range sshConfigOpts.sshOptions {
-						configOptions = append(configOptions, "\t"+option)
-					}
-					configOptions = append(configOptions,
-						"\tHostName coder."+hostname,
-						"\tConnectTimeout=0",
-						"\tStrictHostKeyChecking=no",
-						// Without this, the "REMOTE HOST IDENTITY CHANGED"
-						// message will appear.
-						"\tUserKnownHostsFile=/dev/null",
-						// This disables the "Warning: Permanently added 'hostname' (RSA) to the list of known hosts."
-						// message from appearing on every SSH. This happens because we ignore the known hosts.
-						"\tLogLevel ERROR",
+			if sshConfigOpts.wildcard {
+				// The workspace and agent are resolved from the host name
+				// when connecting, so new workspaces and agents are
+				// reachable without rewriting the config.
+				configOptions := append([]string{"Host " + sshDefaultHostPrefix + "*"}, sshConfigOpts.sshHostOptions("")...)
+				if !skipProxyCommand {
+					configOptions = append(
+						configOptions,
+						fmt.Sprintf(
+							"\tProxyCommand %s --global-config %s ssh --stdio --ssh-host-prefix %s %%h",
+							escapedCoderBinary, escapedGlobalConfig, sshDefaultHostPrefix,
+						),
 					)
-					if !skipProxyCommand {
-						configOptions = append(
-							configOptions,
-							fmt.Sprintf(
-								"\tProxyCommand %s --global-config %s ssh --stdio %s",
-								escapedCoderBinary, escapedGlobalConfig, hostname,
-							),
+				}
+
+				_, _ = buf.WriteString(strings.Join(configOptions, "\n"))
+				_ = buf.WriteByte('\n')
+			} else {
+				for _, wc := range workspaceConfigs {
+					sort.Strings(wc.Hosts)
+					// Write agent configuration.
+					for _, hostname := range wc.Hosts {
+						configOptions := append(
+							[]string{"Host " + sshDefaultHostPrefix + hostname},
+							sshConfigOpts.sshHostOptions(sshDefaultHostPrefix+hostname)...,
 						)
+						if !skipProxyCommand {
+							configOptions = append(
+								configOptions,
+								fmt.Sprintf(
+									"\tProxyCommand %s --global-config %s ssh --stdio %s",
+									escapedCoderBinary, escapedGlobalConfig, hostname,
+								),
+							)
+						}
+
+						_, _ = buf.WriteString(strings.Join(configOptions, "\n"))
+						_ = buf.WriteByte('\n')
 					}
-
-					_, _ = buf.WriteString(strings.Join(configOptions, "\n"))
-					_ = buf.WriteByte('\n')
 				}
 			}
 
@@ -366,6 +418,7 @@ func configSSH() *cobra.Command {
 	cliflag.StringVarP(cmd.Flags(), &sshConfigFile, "ssh-config-file", "", "CODER_SSH_CONFIG_FILE", sshDefaultConfigFileName, "Specifies the path to an SSH config.")
 	cmd.Flags().StringArrayVarP(&sshConfigOpts.sshOptions, "ssh-option", "o", []string{}, "Specifies additional SSH options to embed in each host stanza.")
 	cmd.Flags().BoolVarP(&dryRun, "dry-run", "n", false, "Perform a trial run with no changes made, showing a diff at the end.")
+	cliflag.BoolVarP(cmd.Flags(), &wildcard, "wildcard", "", "CODER_SSH_CONFIG_WILDCARD", false, "Specifies whether to write a single wildcard host entry that resolves workspaces when connecting, instead of an entry per workspace. Once enabled, it is kept until disabled with --wildcard=false.")
 	cmd.Flags().BoolVarP(&skipProxyCommand, "skip-proxy-command", "", false, "Specifies whether the ProxyCommand option should be skipped. Useful for testing.")
 	_ = cmd.Flags().MarkHidden("skip-proxy-command")
 	cliflag.BoolVarP(cmd.Flags(), &usePreviousOpts, "use-previous-options", "", "CODER_SSH_USE_PREVIOUS_OPTIONS", false, "Specifies whether or not to keep options from previous run of config-ssh.")
@@ -383,11 +436,14 @@ func sshConfigWriteSectionHeader(w io.Writer, addNewline bool, o sshConfigOption
 	_, _ = fmt.Fprint(w, nl+sshStartToken+"\n")
 	_, _ = fmt.Fprint(w, sshConfigSectionHeader)
 	_, _ = fmt.Fprint(w, sshConfigDocsHeader)
-	if len(o.sshOptions) > 0 {
+	if len(o.sshOptions) > 0 || o.wildcard {
 		_, _ = fmt.Fprint(w, sshConfigOptionsHeader)
 		for _, opt := range o.sshOptions {
 			_, _ = fmt.Fprintf(w, "# :%s=%s\n", "ssh-option", opt)
 		}
+		if o.wildcard {
+			_, _ = fmt.Fprintf(w, "# :%s=%t\n", "wildcard", o.wildcard)
+		}
 	}
 	_, _ = fmt.Fprint(w, "#\n")
 }
@@ -406,6 +462,8 @@ func sshConfigParseLastOptions(r io.Reader) (o sshConfigOptions) {
 			switch parts[0] {
 			case "ssh-option":
 				o.sshOptions = append(o.sshOptions, parts[1])
+			case "wildcard":
+				o.wildcard = parts[1] == "true"
 			default:
 				// Unknown option, ignore.
 			}
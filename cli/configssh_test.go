@@ -213,6 +213,19 @@ func TestConfigSSH_FileWriteAndOptionsFlow(t *testing.T) {
 		headerEnd,
 	}, "\n")
 
+	wildcardSection := strings.Join([]string{
+		headerStart,
+		"# Last config-ssh options:",
+		"# :wildcard=true",
+		"#",
+		"Host coder.*",
+		"	ConnectTimeout=0",
+		"	StrictHostKeyChecking=no",
+		"	UserKnownHostsFile=/dev/null",
+		"	LogLevel ERROR",
+		headerEnd,
+	}, "\n")
+
 	type writeConfig struct {
 		ssh string
 	}
@@ -509,6 +522,68 @@ func TestConfigSSH_FileWriteAndOptionsFlow(t *testing.T) {
 				"--yes",
 			},
 		},
+		{
+			name: "Wildcard replaces workspace entries",
+			writeConfig: writeConfig{
+				ssh: strings.Join([]string{
+					headerStart,
+					"Host coder.removed",
+					"	HostName coder.removed",
+					headerEnd,
+					"",
+				}, "\n"),
+			},
+			wantConfig: wantConfig{
+				ssh: strings.Join([]string{
+					wildcardSection,
+					"",
+				}, "\n"),
+			},
+			args: []string{
+				"--wildcard",
+				"--skip-proxy-command",
+				"--yes",
+			},
+		},
+		{
+			name: "Wildcard is kept on re-run",
+			writeConfig: writeConfig{
+				ssh: strings.Join([]string{
+					wildcardSection,
+					"",
+				}, "\n"),
+			},
+			wantConfig: wantConfig{
+				ssh: strings.Join([]string{
+					wildcardSection,
+					"",
+				}, "\n"),
+			},
+			args: []string{
+				"--skip-proxy-command",
+				"--yes",
+			},
+		},
+		{
+			name: "Wildcard can be disabled",
+			writeConfig: writeConfig{
+				ssh: strings.Join([]string{
+					wildcardSection,
+					"",
+				}, "\n"),
+			},
+			wantConfig: wantConfig{
+				ssh: strings.Join([]string{
+					baseHeader,
+					"",
+				}, "\n"),
+			},
+			args: []string{
+				"--wildcard=false",
+				"--skip-proxy-command",
+				"--yes",
+			},
+		},
 		{
 			name: "Do not overwrite config when using --dry-run",
 			writeConfig: writeConfig{
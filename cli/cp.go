@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/cli/cliflag"
+)
+
+func copyFiles() *cobra.Command {
+	var (
+		recursive   bool
+		ignoreFiles []string
+		excludes    []string
+	)
+	cmd := &cobra.Command{
+		Annotations: workspaceCommand,
+		Use:         "cp <source> <destination>",
+		Short:       "Copy files between your machine and a workspace",
+		Long: "Paths in a workspace are written as <workspace>:<path>, like scp. Relative paths in a workspace are relative to " +
+			"the home directory. File modes and modification times are kept, and symbolic links are skipped.",
+		Args: cobra.ExactArgs(2),
+		Example: formatExamples(
+			example{
+				Description: "Copy a file into the home directory of a workspace",
+				Command:     "coder cp ./notes.txt my-workspace:",
+			},
+			example{
+				Description: "Copy a directory out of a workspace, skipping build output",
+				Command:     "coder cp -r --exclude 'build/' my-workspace:project ./project",
+			},
+		),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			src := parseTransferLocation(args[0])
+			dst := parseTransferLocation(args[1])
+			if (src.workspace == "") == (dst.workspace == "") {
+				return xerrors.New("exactly one of the paths must be in a workspace, written as <workspace>:<path>")
+			}
+
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return xerrors.Errorf("create codersdk client: %w", err)
+			}
+			workspaceName := src.workspace + dst.workspace
+			sftpClient, closeSFTP, err := dialWorkspaceSFTP(ctx, cmd, client, workspaceName)
+			if err != nil {
+				return err
+			}
+			defer closeSFTP()
+
+			var srcFS, dstFS transferFS = localFS{}, sftpFS{client: sftpClient}
+			if src.workspace != "" {
+				srcFS, dstFS = dstFS, srcFS
+			}
+			info, err := srcFS.Stat(src.path)
+			if err != nil {
+				return xerrors.Errorf("stat %q: %w", args[0], err)
+			}
+			var ignore *ignoreMatcher
+			if info.IsDir() {
+				if !recursive {
+					return xerrors.Errorf("%q is a directory, use --recursive to copy it", args[0])
+				}
+				ignore, err = readIgnoreFiles(srcFS, src.path, ignoreFiles, excludes)
+				if err != nil {
+					return err
+				}
+			}
+			// Like cp, copy into destinations that are existing directories.
+			dstPath := dst.path
+			if dstInfo, err := dstFS.Stat(dstPath); err == nil && dstInfo.IsDir() {
+				dstPath = dstFS.Join(dstPath, info.Name())
+			}
+
+			progress := newTransferProgress(cmd)
+			copier := &fileCopier{
+				src:      srcFS,
+				dst:      dstFS,
+				ignore:   ignore,
+				progress: progress,
+			}
+			err = copier.copyPath(src.path, dstPath, "", info)
+			progress.clear()
+			if err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintln(cmd.ErrOrStderr(), progress.summary("Copied"))
+			return nil
+		},
+	}
+	cmd.Flags().BoolVarP(&recursive, "recursive", "r", false, "Copy directories and their contents.")
+	cliflag.StringArrayVarP(cmd.Flags(), &ignoreFiles, "ignore-file", "", "CODER_CP_IGNORE_FILE", defaultIgnoreFiles, "Ignore files in the root of a copied directory. Paths matching their patterns are skipped.")
+	cliflag.StringArrayVarP(cmd.Flags(), &excludes, "exclude", "", "CODER_CP_EXCLUDE", nil, "Skip paths matching a pattern, in the .gitignore format.")
+	return cmd
+}
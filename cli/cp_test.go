@@ -0,0 +1,133 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"cdr.dev/slog/sloggers/slogtest"
+
+	"github.com/coder/coder/agent"
+	"github.com/coder/coder/cli/clitest"
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/testutil"
+)
+
+func TestCp(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("The workspace paths are POSIX paths.")
+	}
+
+	client, workspace, agentToken := setupWorkspaceForAgent(t, nil)
+	agentClient := codersdk.New(client.URL)
+	agentClient.SetSessionToken(agentToken)
+	agentCloser := agent.New(agent.Options{
+		Client: agentClient,
+		Logger: slogtest.Make(t, nil).Named("agent"),
+	})
+	t.Cleanup(func() {
+		_ = agentCloser.Close()
+	})
+	coderdtest.AwaitWorkspaceAgents(t, client, workspace.ID)
+
+	// The agent runs on this machine, so workspace paths are local paths.
+	run := func(t *testing.T, args ...string) (string, error) {
+		t.Helper()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		cmd, root := clitest.New(t, append([]string{"cp"}, args...)...)
+		clitest.SetupConfig(t, client, root)
+		var stderr bytes.Buffer
+		cmd.SetErr(&stderr)
+		err := cmd.ExecuteContext(ctx)
+		return stderr.String(), err
+	}
+
+	t.Run("UploadDirectory", func(t *testing.T) {
+		t.Parallel()
+
+		src := t.TempDir()
+		writeFiles(t, src, map[string]string{
+			"main.go":          "package main",
+			"lib/lib.go":       "package lib",
+			"build/output":     "binary",
+			"lib/.coderignore": "",
+			".coderignore":     "build/\n*.log",
+			"debug.log":        "log",
+		})
+		mtime := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+		require.NoError(t, os.Chtimes(filepath.Join(src, "main.go"), mtime, mtime))
+		dst := t.TempDir()
+
+		stderr, err := run(t, "-r", src, workspace.Name+":"+dst)
+		require.NoError(t, err)
+		require.Contains(t, stderr, "Copied 4 files")
+
+		// The destination exists, so the directory is copied into it.
+		copied := filepath.Join(dst, filepath.Base(src))
+		requireFile(t, filepath.Join(copied, "main.go"), "package main")
+		requireFile(t, filepath.Join(copied, "lib", "lib.go"), "package lib")
+		require.NoFileExists(t, filepath.Join(copied, "build", "output"))
+		require.NoFileExists(t, filepath.Join(copied, "debug.log"))
+		info, err := os.Stat(filepath.Join(copied, "main.go"))
+		require.NoError(t, err)
+		require.True(t, info.ModTime().Equal(mtime), info.ModTime())
+	})
+
+	t.Run("DownloadFile", func(t *testing.T) {
+		t.Parallel()
+
+		src := t.TempDir()
+		writeFiles(t, src, map[string]string{
+			"script.sh": "echo hello",
+		})
+		require.NoError(t, os.Chmod(filepath.Join(src, "script.sh"), 0o755))
+		dst := filepath.Join(t.TempDir(), "downloaded.sh")
+
+		_, err := run(t, workspace.Name+":"+filepath.Join(src, "script.sh"), dst)
+		require.NoError(t, err)
+		requireFile(t, dst, "echo hello")
+		info, err := os.Stat(dst)
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0o755), info.Mode().Perm())
+	})
+
+	t.Run("DirectoryRequiresRecursive", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := run(t, t.TempDir(), workspace.Name+":"+t.TempDir())
+		require.ErrorContains(t, err, "use --recursive")
+	})
+
+	t.Run("BothLocal", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := run(t, "./a", "./b")
+		require.ErrorContains(t, err, "exactly one of the paths must be in a workspace")
+	})
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		name = filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(name), 0o755))
+		require.NoError(t, os.WriteFile(name, []byte(content), 0o600))
+	}
+}
+
+func requireFile(t *testing.T, name, content string) {
+	t.Helper()
+	data, err := os.ReadFile(name)
+	require.NoError(t, err)
+	require.Equal(t, content, string(data))
+}
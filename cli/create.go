@@ -121,7 +121,7 @@ func create() *cobra.Command {
 				schedSpec = ptr.Ref(sched.String())
 			}
 
-			parameters, err := prepWorkspaceBuild(cmd, client, prepWorkspaceBuildArgs{
+			buildParams, err := prepWorkspaceBuild(cmd, client, prepWorkspaceBuildArgs{
 				Template:         template,
 				ExistingParams:   []codersdk.Parameter{},
 				ParameterFile:    parameterFile,
@@ -140,11 +140,12 @@ func create() *cobra.Command {
 			}
 
 			workspace, err := client.CreateWorkspace(cmd.Context(), organization.ID, codersdk.Me, codersdk.CreateWorkspaceRequest{
-				TemplateID:        template.ID,
-				Name:              workspaceName,
-				AutostartSchedule: schedSpec,
-				TTLMillis:         ptr.Ref(stopAfter.Milliseconds()),
-				ParameterValues:   parameters,
+				TemplateID:          template.ID,
+				Name:                workspaceName,
+				AutostartSchedule:   schedSpec,
+				TTLMillis:           ptr.Ref(stopAfter.Milliseconds()),
+				ParameterValues:     buildParams.parameters,
+				RichParameterValues: buildParams.richParameters,
 			})
 			if err != nil {
 				return err
@@ -169,15 +170,23 @@ func create() *cobra.Command {
 }
 
 type prepWorkspaceBuildArgs struct {
-	Template         codersdk.Template
-	ExistingParams   []codersdk.Parameter
-	ParameterFile    string
-	NewWorkspaceName string
+	Template           codersdk.Template
+	ExistingParams     []codersdk.Parameter
+	ExistingRichParams []codersdk.WorkspaceBuildParameter
+	ParameterFile      string
+	NewWorkspaceName   string
+}
+
+type buildParameters struct {
+	// Parameters are legacy parameters compatible with parameter schemas.
+	parameters []codersdk.CreateParameterRequest
+	// Rich parameters are values for the template version's rich parameters.
+	richParameters []codersdk.WorkspaceBuildParameter
 }
 
 // prepWorkspaceBuild will ensure a workspace build will succeed on the latest template version.
 // Any missing params will be prompted to the user.
-func prepWorkspaceBuild(cmd *cobra.Command, client *codersdk.Client, args prepWorkspaceBuildArgs) ([]codersdk.CreateParameterRequest, error) {
+func prepWorkspaceBuild(cmd *cobra.Command, client *codersdk.Client, args prepWorkspaceBuildArgs) (*buildParameters, error) {
 	ctx := cmd.Context()
 	templateVersion, err := client.TemplateVersion(ctx, args.Template.ActiveVersionID)
 	if err != nil {
@@ -187,6 +196,10 @@ func prepWorkspaceBuild(cmd *cobra.Command, client *codersdk.Client, args prepWo
 	if err != nil {
 		return nil, err
 	}
+	templateVersionParameters, err := client.TemplateVersionRichParameters(ctx, templateVersion.ID)
+	if err != nil {
+		return nil, err
+	}
 
 	// parameterMapFromFile can be nil if parameter file is not specified
 	var parameterMapFromFile map[string]string
@@ -234,12 +247,42 @@ PromptParamLoop:
 			DestinationScheme: parameterSchema.DefaultDestinationScheme,
 		})
 	}
+	richParameters := make([]codersdk.WorkspaceBuildParameter, 0)
+RichParamLoop:
+	for _, templateVersionParameter := range templateVersionParameters {
+		if !disclaimerPrinted {
+			_, _ = fmt.Fprintln(cmd.OutOrStdout(), cliui.Styles.Paragraph.Render("This template has customizable parameters. Values can be changed after create, but may have unintended side effects (like data loss).")+"\r\n")
+			disclaimerPrinted = true
+		}
+
+		// Param file is all or nothing
+		if !useParamFile {
+			for _, e := range args.ExistingRichParams {
+				if e.Name == templateVersionParameter.Name {
+					// If the param already exists, we do not need to prompt it again.
+					// The value from the previous build is reused.
+					continue RichParamLoop
+				}
+			}
+		}
+
+		parameterValue, err := getWorkspaceBuildParameterValueFromMapOrInput(cmd, parameterMapFromFile, templateVersionParameter)
+		if err != nil {
+			return nil, err
+		}
+
+		richParameters = append(richParameters, codersdk.WorkspaceBuildParameter{
+			Name:  templateVersionParameter.Name,
+			Value: parameterValue,
+		})
+	}
 	_, _ = fmt.Fprintln(cmd.OutOrStdout())
 
 	// Run a dry-run with the given parameters to check correctness
 	dryRun, err := client.CreateTemplateVersionDryRun(cmd.Context(), templateVersion.ID, codersdk.CreateTemplateVersionDryRunRequest{
-		WorkspaceName:   args.NewWorkspaceName,
-		ParameterValues: parameters,
+		WorkspaceName:       args.NewWorkspaceName,
+		ParameterValues:     parameters,
+		RichParameterValues: append(append([]codersdk.WorkspaceBuildParameter{}, args.ExistingRichParams...), richParameters...),
 	})
 	if err != nil {
 		return nil, xerrors.Errorf("begin workspace dry-run: %w", err)
@@ -279,5 +322,8 @@ PromptParamLoop:
 		return nil, err
 	}
 
-	return parameters, nil
+	return &buildParameters{
+		parameters:     parameters,
+		richParameters: richParameters,
+	}, nil
 }
@@ -231,6 +231,34 @@ func newConfig() *codersdk.DeploymentConfig {
 				Flag:    "oidc-scopes",
 				Default: []string{oidc.ScopeOpenID, "profile", "email"},
 			},
+			GroupField: &codersdk.DeploymentConfigField[string]{
+				Name:       "OIDC Group Field",
+				Usage:      "The claim with the groups of users. When set, users are added to and removed from Coder groups with the same names each time they log in with OIDC.",
+				Flag:       "oidc-group-field",
+				Enterprise: true,
+			},
+			GroupMapping: &codersdk.DeploymentConfigField[[]string]{
+				Name:       "OIDC Group Mapping",
+				Usage:      "Maps a group in the OIDC group claim to a Coder group, formatted as <oidc group>=<coder group>. Groups without a mapping keep their name.",
+				Flag:       "oidc-group-mapping",
+				Enterprise: true,
+			},
+			CreateMissingGroups: &codersdk.DeploymentConfigField[bool]{
+				Name:       "OIDC Create Missing Groups",
+				Usage:      "Whether to create the Coder groups in the OIDC group claim that don't exist. Otherwise, they're ignored.",
+				Flag:       "oidc-create-missing-groups",
+				Enterprise: true,
+			},
+			RoleField: &codersdk.DeploymentConfigField[string]{
+				Name:  "OIDC Role Field",
+				Usage: "The claim with the site roles of users. When set, the roles in --oidc-role-mapping are synced from the claim each time users log in with OIDC.",
+				Flag:  "oidc-role-field",
+			},
+			RoleMapping: &codersdk.DeploymentConfigField[[]string]{
+				Name:  "OIDC Role Mapping",
+				Usage: "Maps a role in the OIDC role claim to a Coder site role, formatted as <oidc role>=<coder role>. Only mapped roles are synced, and roles in the claim without a mapping are ignored.",
+				Flag:  "oidc-role-mapping",
+			},
 		},
 
 		Telemetry: &codersdk.TelemetryConfig{
@@ -388,12 +416,70 @@ func newConfig() *codersdk.DeploymentConfig {
 				Default: 10 * time.Minute,
 			},
 		},
+		FileStorage: &codersdk.FileStorageConfig{
+			Backend: &codersdk.DeploymentConfigField[string]{
+				Name:    "File Storage Backend",
+				Usage:   "Where to store the contents of uploaded files and template archives. Accepted values are \"database\", \"local\" and \"s3\". Files saved in the database remain readable after changing this.",
+				Flag:    "file-storage-backend",
+				Default: "database",
+			},
+			LocalPath: &codersdk.DeploymentConfigField[string]{
+				Name:  "File Storage Local Path",
+				Usage: "Directory to store file contents in when using the \"local\" backend. It must be shared by all replicas.",
+				Flag:  "file-storage-local-path",
+			},
+			S3: &codersdk.FileStorageS3Config{
+				Endpoint: &codersdk.DeploymentConfigField[string]{
+					Name:  "File Storage S3 Endpoint",
+					Usage: "URL of the S3-compatible service when using the \"s3\" backend. Defaults to AWS S3 in the configured region.",
+					Flag:  "file-storage-s3-endpoint",
+				},
+				Region: &codersdk.DeploymentConfigField[string]{
+					Name:    "File Storage S3 Region",
+					Usage:   "Region of the bucket when using the \"s3\" backend.",
+					Flag:    "file-storage-s3-region",
+					Default: "us-east-1",
+				},
+				Bucket: &codersdk.DeploymentConfigField[string]{
+					Name:  "File Storage S3 Bucket",
+					Usage: "Bucket to store file contents in when using the \"s3\" backend.",
+					Flag:  "file-storage-s3-bucket",
+				},
+				Prefix: &codersdk.DeploymentConfigField[string]{
+					Name:  "File Storage S3 Prefix",
+					Usage: "Prefix of the keys of objects when using the \"s3\" backend.",
+					Flag:  "file-storage-s3-prefix",
+				},
+				AccessKeyID: &codersdk.DeploymentConfigField[string]{
+					Name:  "File Storage S3 Access Key ID",
+					Usage: "Access key ID used to sign requests when using the \"s3\" backend.",
+					Flag:  "file-storage-s3-access-key-id",
+				},
+				SecretAccessKey: &codersdk.DeploymentConfigField[string]{
+					Name:   "File Storage S3 Secret Access Key",
+					Usage:  "Secret access key used to sign requests when using the \"s3\" backend.",
+					Flag:   "file-storage-s3-secret-access-key",
+					Secret: true,
+				},
+				UsePathStyle: &codersdk.DeploymentConfigField[bool]{
+					Name:  "File Storage S3 Use Path Style",
+					Usage: "Address the bucket in the path of requests instead of the hostname. Most self-hosted S3-compatible services, such as MinIO, require this.",
+					Flag:  "file-storage-s3-use-path-style",
+				},
+			},
+		},
 		APIRateLimit: &codersdk.DeploymentConfigField[int]{
 			Name:    "API Rate Limit",
 			Usage:   "Maximum number of requests per minute allowed to the API per user, or per IP address for unauthenticated users. Negative values mean no rate limit. Some API endpoints are always rate limited regardless of this value to prevent denial-of-service attacks.",
 			Flag:    "api-rate-limit",
 			Default: 512,
 		},
+		MaxTokenLifetime: &codersdk.DeploymentConfigField[time.Duration]{
+			Name:    "Max Token Lifetime",
+			Usage:   "The maximum lifetime of tokens users can create. Tokens last 30 days by default, or this long if it's shorter.",
+			Flag:    "max-token-lifetime",
+			Default: 876600 * time.Hour,
+		},
 		Experimental: &codersdk.DeploymentConfigField[bool]{
 			Name:  "Experimental",
 			Usage: "Enable experimental features. Experimental features are not ready for production.",
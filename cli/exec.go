@@ -0,0 +1,315 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/agent"
+	"github.com/coder/coder/cli/cliflag"
+	"github.com/coder/coder/cli/cliui"
+	"github.com/coder/coder/codersdk"
+)
+
+func execCommand() *cobra.Command {
+	var (
+		searchQuery string
+		env         []string
+		workdir     string
+		parallel    int
+	)
+	cmd := &cobra.Command{
+		Annotations: workspaceCommand,
+		Use:         "exec [workspace] -- <command> [args...]",
+		Short:       "Run a command in a workspace without a terminal",
+		Long: "Runs a command in a workspace and exits with its exit code. The output of the command is written to stdout and stderr " +
+			"as it was written in the workspace. Like ssh, the arguments are joined with spaces and run by the user's shell.",
+		Example: formatExamples(
+			example{
+				Description: "Run tests in a workspace",
+				Command:     "coder exec my-workspace -- make test",
+			},
+			example{
+				Description: "Run a command in a directory with extra environment variables",
+				Command:     "coder exec my-workspace --workdir ~/project --env GOFLAGS=-v -- go build ./...",
+			},
+			example{
+				Description: "Check the disk usage of all of your running workspaces built from a template",
+				Command:     `coder exec --search "owner:me template:docker" -- df -h /`,
+			},
+		),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			// Arguments after "--" are the command, so it can have flags of
+			// its own.
+			command := args
+			if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+				args, command = args[:dash], args[dash:]
+			} else if searchQuery == "" && len(args) > 0 {
+				args, command = args[:1], args[1:]
+			} else {
+				args = nil
+			}
+			var workspaceName string
+			if searchQuery == "" {
+				if len(args) != 1 {
+					return xerrors.New("a workspace is required")
+				}
+				workspaceName = args[0]
+			} else if len(args) > 0 {
+				return xerrors.New("a workspace can't be specified with --search")
+			}
+			if len(command) == 0 {
+				return xerrors.New("a command is required")
+			}
+			for _, kv := range env {
+				if !strings.Contains(kv, "=") {
+					return xerrors.Errorf("environment variable %q must be in the KEY=VALUE format", kv)
+				}
+			}
+			rawCommand := strings.Join(command, " ")
+			if workdir != "" {
+				rawCommand = fmt.Sprintf("cd %s && %s", quoteShellPath(workdir), rawCommand)
+			}
+
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return xerrors.Errorf("create codersdk client: %w", err)
+			}
+
+			if searchQuery != "" {
+				return execInWorkspaces(ctx, cmd, client, searchQuery, parallel, rawCommand, env)
+			}
+
+			workspace, workspaceAgent, err := getWorkspaceAndAgent(ctx, cmd, client, codersdk.Me, workspaceName, false)
+			if err != nil {
+				return err
+			}
+			err = cliui.Agent(ctx, cmd.ErrOrStderr(), cliui.AgentOptions{
+				WorkspaceName: workspace.Name,
+				Fetch: func(ctx context.Context) (codersdk.WorkspaceAgent, error) {
+					return client.WorkspaceAgent(ctx, workspaceAgent.ID)
+				},
+			})
+			if err != nil {
+				return xerrors.Errorf("await agent: %w", err)
+			}
+			exitCode, err := execInWorkspace(ctx, client, workspaceAgent.ID, rawCommand, env, cmd.InOrStdin(), cmd.OutOrStdout(), cmd.ErrOrStderr())
+			if err != nil {
+				return err
+			}
+			if exitCode != 0 {
+				return &ExitError{Code: exitCode}
+			}
+			return nil
+		},
+	}
+	cliflag.StringVarP(cmd.Flags(), &searchQuery, "search", "", "CODER_EXEC_SEARCH", "", "Run the command in every running workspace matching the search query instead of a single workspace. Output lines are prefixed with the workspace name.")
+	cliflag.StringArrayVarP(cmd.Flags(), &env, "env", "e", "CODER_EXEC_ENV", nil, "Set environment variables for the command, in the KEY=VALUE format.")
+	cliflag.StringVarP(cmd.Flags(), &workdir, "workdir", "w", "CODER_EXEC_WORKDIR", "", "The directory to run the command in. Defaults to the directory of the workspace agent.")
+	cliflag.IntVarP(cmd.Flags(), &parallel, "parallel", "", "CODER_EXEC_PARALLEL", 10, "The number of workspaces to run the command in at once with --search.")
+	return cmd
+}
+
+// execInWorkspaces runs the command in every running workspace matching the
+// search query. It fails if the command fails in any of them.
+func execInWorkspaces(ctx context.Context, cmd *cobra.Command, client *codersdk.Client, searchQuery string, parallel int, command string, env []string) error {
+	if parallel < 1 {
+		return xerrors.New("--parallel must be at least 1")
+	}
+	res, err := client.Workspaces(ctx, codersdk.WorkspaceFilter{
+		FilterQuery: searchQuery,
+	})
+	if err != nil {
+		return xerrors.Errorf("search workspaces: %w", err)
+	}
+
+	var (
+		stdoutMutex sync.Mutex
+		stderrMutex sync.Mutex
+		failedMutex sync.Mutex
+		failed      []string
+		eg          errgroup.Group
+	)
+	eg.SetLimit(parallel)
+	fail := func(name, reason string) {
+		failedMutex.Lock()
+		defer failedMutex.Unlock()
+		failed = append(failed, name)
+		stderrMutex.Lock()
+		defer stderrMutex.Unlock()
+		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "%s: %s\n", name, reason)
+	}
+	ran := 0
+	for _, workspace := range res.Workspaces {
+		workspace := workspace
+		name := workspace.OwnerName + "/" + workspace.Name
+		if workspace.LatestBuild.Transition != codersdk.WorkspaceTransitionStart ||
+			workspace.LatestBuild.Job.Status != codersdk.ProvisionerJobSucceeded {
+			continue
+		}
+		ran++
+		eg.Go(func() error {
+			workspaceAgent, err := connectedWorkspaceAgent(workspace)
+			if err != nil {
+				fail(name, err.Error())
+				return nil
+			}
+			stdout := &prefixWriter{mutex: &stdoutMutex, w: cmd.OutOrStdout(), prefix: name + ": "}
+			stderr := &prefixWriter{mutex: &stderrMutex, w: cmd.ErrOrStderr(), prefix: name + ": "}
+			exitCode, err := execInWorkspace(ctx, client, workspaceAgent.ID, command, env, nil, stdout, stderr)
+			stdout.Flush()
+			stderr.Flush()
+			if err != nil {
+				fail(name, err.Error())
+				return nil
+			}
+			if exitCode != 0 {
+				fail(name, fmt.Sprintf("exited with status %d", exitCode))
+			}
+			return nil
+		})
+	}
+	_ = eg.Wait()
+
+	if ran == 0 {
+		return xerrors.Errorf("no running workspaces match %q", searchQuery)
+	}
+	if len(failed) > 0 {
+		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "The command failed in %d of %d workspaces.\n", len(failed), ran)
+		return &ExitError{Code: 1}
+	}
+	return nil
+}
+
+// connectedWorkspaceAgent returns the agent of a workspace with a single
+// agent, if it's connected.
+func connectedWorkspaceAgent(workspace codersdk.Workspace) (codersdk.WorkspaceAgent, error) {
+	agents := make([]codersdk.WorkspaceAgent, 0)
+	for _, resource := range workspace.LatestBuild.Resources {
+		agents = append(agents, resource.Agents...)
+	}
+	switch len(agents) {
+	case 0:
+		return codersdk.WorkspaceAgent{}, xerrors.New("workspace has no agents")
+	case 1:
+	default:
+		return codersdk.WorkspaceAgent{}, xerrors.New("workspace has multiple agents, run the command in it with the name of an agent instead")
+	}
+	if agents[0].Status != codersdk.WorkspaceAgentConnected {
+		return codersdk.WorkspaceAgent{}, xerrors.Errorf("agent is %s", agents[0].Status)
+	}
+	return agents[0], nil
+}
+
+// execInWorkspace runs a command over SSH in the workspace agent and returns
+// its exit code.
+func execInWorkspace(ctx context.Context, client *codersdk.Client, agentID uuid.UUID, command string, env []string, stdin io.Reader, stdout, stderr io.Writer) (int, error) {
+	conn, err := client.DialWorkspaceAgent(ctx, agentID, &codersdk.DialWorkspaceAgentOptions{})
+	if err != nil {
+		return 0, xerrors.Errorf("dial workspace agent: %w", err)
+	}
+	defer conn.Close()
+	if !conn.AwaitReachable(ctx) {
+		return 0, xerrors.New("workspace agent is unreachable")
+	}
+	sshClient, err := conn.SSHClient(ctx)
+	if err != nil {
+		return 0, xerrors.Errorf("ssh client: %w", err)
+	}
+	defer sshClient.Close()
+	sshSession, err := sshClient.NewSession()
+	if err != nil {
+		return 0, xerrors.Errorf("ssh session: %w", err)
+	}
+	defer sshSession.Close()
+
+	for _, kv := range env {
+		key, value, _ := strings.Cut(kv, "=")
+		err = sshSession.Setenv(key, value)
+		if err != nil {
+			return 0, xerrors.Errorf("set environment variable %q: %w", key, err)
+		}
+	}
+	sshSession.Stdin = stdin
+	sshSession.Stdout = stdout
+	sshSession.Stderr = stderr
+
+	err = sshSession.Run(command)
+	var exitErr *gossh.ExitError
+	if xerrors.As(err, &exitErr) {
+		if exitErr.ExitStatus() == agent.MagicSessionErrorCode {
+			return 0, xerrors.New("the workspace agent failed to start the command, see the agent logs for details")
+		}
+		return exitErr.ExitStatus(), nil
+	}
+	var exitMissingErr *gossh.ExitMissingError
+	if xerrors.As(err, &exitMissingErr) {
+		return 0, xerrors.New("the connection to the workspace ended before the command exited")
+	}
+	if err != nil {
+		return 0, xerrors.Errorf("run command: %w", err)
+	}
+	return 0, nil
+}
+
+// quoteShellPath quotes a path for a POSIX shell, leaving a leading "~/"
+// unquoted so it's expanded to the home directory.
+func quoteShellPath(path string) string {
+	if path == "~" {
+		return path
+	}
+	home := ""
+	if strings.HasPrefix(path, "~/") {
+		home, path = "~/", strings.TrimPrefix(path, "~/")
+	}
+	return home + "'" + strings.ReplaceAll(path, "'", `'"'"'`) + "'"
+}
+
+// prefixWriter prefixes each line written to w. Whole lines are written while
+// holding mutex, so lines of writers sharing it aren't interleaved.
+type prefixWriter struct {
+	mutex  *sync.Mutex
+	w      io.Writer
+	prefix string
+	buf    []byte
+}
+
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	p.buf = append(p.buf, b...)
+	for {
+		i := bytes.IndexByte(p.buf, '\n')
+		if i < 0 {
+			break
+		}
+		p.writeLine(p.buf[:i+1])
+		p.buf = p.buf[i+1:]
+	}
+	return len(b), nil
+}
+
+// Flush writes the last line if it didn't end with a newline.
+func (p *prefixWriter) Flush() {
+	if len(p.buf) == 0 {
+		return
+	}
+	p.writeLine(append(p.buf, '\n'))
+	p.buf = nil
+}
+
+func (p *prefixWriter) writeLine(line []byte) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	_, _ = io.WriteString(p.w, p.prefix)
+	_, _ = p.w.Write(line)
+}
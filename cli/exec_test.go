@@ -0,0 +1,89 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"cdr.dev/slog/sloggers/slogtest"
+
+	"github.com/coder/coder/agent"
+	"github.com/coder/coder/cli"
+	"github.com/coder/coder/cli/clitest"
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/testutil"
+)
+
+func TestExec(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("The commands are run by a POSIX shell.")
+	}
+
+	client, workspace, agentToken := setupWorkspaceForAgent(t, nil)
+	agentClient := codersdk.New(client.URL)
+	agentClient.SetSessionToken(agentToken)
+	agentCloser := agent.New(agent.Options{
+		Client: agentClient,
+		Logger: slogtest.Make(t, nil).Named("agent"),
+	})
+	t.Cleanup(func() {
+		_ = agentCloser.Close()
+	})
+	coderdtest.AwaitWorkspaceAgents(t, client, workspace.ID)
+
+	run := func(t *testing.T, args ...string) (string, string, error) {
+		t.Helper()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		cmd, root := clitest.New(t, append([]string{"exec"}, args...)...)
+		clitest.SetupConfig(t, client, root)
+		var stdout, stderr bytes.Buffer
+		cmd.SetOut(&stdout)
+		cmd.SetErr(&stderr)
+		err := cmd.ExecuteContext(ctx)
+		return stdout.String(), stderr.String(), err
+	}
+
+	t.Run("ExitCode", func(t *testing.T) {
+		t.Parallel()
+
+		stdout, stderr, err := run(t, workspace.Name, "--", "echo out; echo err >&2; exit 3")
+		var exitErr *cli.ExitError
+		require.ErrorAs(t, err, &exitErr)
+		require.Equal(t, 3, exitErr.Code, stderr)
+		require.Equal(t, "out\n", stdout)
+		require.Contains(t, stderr, "err\n")
+		require.NotContains(t, stderr, "out")
+	})
+
+	t.Run("EnvAndWorkdir", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		stdout, _, err := run(t, workspace.Name, "--env", "EXEC_TEST=hello", "--workdir", dir, "--", "echo", "$EXEC_TEST", "&&", "pwd")
+		require.NoError(t, err)
+		require.Equal(t, "hello\n"+dir+"\n", stdout)
+	})
+
+	t.Run("Search", func(t *testing.T) {
+		t.Parallel()
+
+		stdout, stderr, err := run(t, "--search", "owner:me", "--", "echo one; echo two")
+		require.NoError(t, err, stderr)
+		prefix := workspace.OwnerName + "/" + workspace.Name + ": "
+		require.Equal(t, prefix+"one\n"+prefix+"two\n", stdout)
+	})
+
+	t.Run("SearchNoMatches", func(t *testing.T) {
+		t.Parallel()
+
+		_, _, err := run(t, "--search", "name:doesnotexist", "--", "true")
+		require.ErrorContains(t, err, "no running workspaces")
+	})
+}
@@ -0,0 +1,432 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/pkg/sftp"
+	"github.com/spf13/cobra"
+	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/cli/cliui"
+	"github.com/coder/coder/codersdk"
+)
+
+// defaultIgnoreFiles are read from the root of the source directory of
+// transfers.
+var defaultIgnoreFiles = []string{".gitignore", ".coderignore"}
+
+// transferLocation is a path on the local machine, or in a workspace if
+// workspace is set. Paths in workspaces are written as <workspace>:<path>,
+// like scp.
+type transferLocation struct {
+	workspace string
+	path      string
+}
+
+func parseTransferLocation(arg string) transferLocation {
+	i := strings.Index(arg, ":")
+	// Colons after a path separator, and after Windows drive letters, are
+	// part of local paths.
+	if i <= 0 || strings.ContainsAny(arg[:i], `/\`) || (runtime.GOOS == "windows" && i == 1) {
+		return transferLocation{path: arg}
+	}
+	location := transferLocation{
+		workspace: arg[:i],
+		path:      arg[i+1:],
+	}
+	// The SFTP server resolves relative paths from the home directory.
+	switch {
+	case location.path == "" || location.path == "~":
+		location.path = "."
+	case strings.HasPrefix(location.path, "~/"):
+		location.path = strings.TrimPrefix(location.path, "~/")
+	}
+	return location
+}
+
+// transferFS is the filesystem on one side of a transfer.
+type transferFS interface {
+	Stat(name string) (fs.FileInfo, error)
+	Lstat(name string) (fs.FileInfo, error)
+	ReadDir(name string) ([]fs.FileInfo, error)
+	Open(name string) (io.ReadCloser, error)
+	Create(name string, mode fs.FileMode) (io.WriteCloser, error)
+	MkdirAll(name string) error
+	Chtimes(name string, mtime time.Time) error
+	// Remove removes a file or an empty directory.
+	Remove(name string) error
+	Join(elem ...string) string
+}
+
+type localFS struct{}
+
+func (localFS) Stat(name string) (fs.FileInfo, error)  { return os.Stat(name) }
+func (localFS) Lstat(name string) (fs.FileInfo, error) { return os.Lstat(name) }
+func (localFS) Open(name string) (io.ReadCloser, error) {
+	return os.Open(name)
+}
+func (localFS) MkdirAll(name string) error { return os.MkdirAll(name, 0o755) }
+func (localFS) Remove(name string) error   { return os.Remove(name) }
+func (localFS) Join(elem ...string) string { return joinLocal(elem...) }
+
+func (localFS) ReadDir(name string) ([]fs.FileInfo, error) {
+	entries, err := os.ReadDir(name)
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]fs.FileInfo, 0, len(entries))
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			// The file was removed after the directory was read.
+			continue
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+func (localFS) Create(name string, mode fs.FileMode) (io.WriteCloser, error) {
+	return os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode.Perm())
+}
+
+func (localFS) Chtimes(name string, mtime time.Time) error {
+	return os.Chtimes(name, mtime, mtime)
+}
+
+func joinLocal(elem ...string) string {
+	return filepath.Join(elem...)
+}
+
+type sftpFS struct {
+	client *sftp.Client
+}
+
+func (s sftpFS) Stat(name string) (fs.FileInfo, error)      { return s.client.Stat(name) }
+func (s sftpFS) Lstat(name string) (fs.FileInfo, error)     { return s.client.Lstat(name) }
+func (s sftpFS) ReadDir(name string) ([]fs.FileInfo, error) { return s.client.ReadDir(name) }
+func (s sftpFS) MkdirAll(name string) error                 { return s.client.MkdirAll(name) }
+func (s sftpFS) Remove(name string) error                   { return s.client.Remove(name) }
+func (s sftpFS) Join(elem ...string) string                 { return path.Join(elem...) }
+
+func (s sftpFS) Open(name string) (io.ReadCloser, error) {
+	return s.client.Open(name)
+}
+
+func (s sftpFS) Create(name string, mode fs.FileMode) (io.WriteCloser, error) {
+	f, err := s.client.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return nil, err
+	}
+	err = f.Chmod(mode.Perm())
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+func (s sftpFS) Chtimes(name string, mtime time.Time) error {
+	return s.client.Chtimes(name, mtime, mtime)
+}
+
+// dialWorkspaceSFTP connects to the SFTP server of the workspace agent.
+func dialWorkspaceSFTP(ctx context.Context, cmd *cobra.Command, client *codersdk.Client, workspaceName string) (*sftp.Client, func(), error) {
+	workspace, workspaceAgent, err := getWorkspaceAndAgent(ctx, cmd, client, codersdk.Me, workspaceName, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = cliui.Agent(ctx, cmd.ErrOrStderr(), cliui.AgentOptions{
+		WorkspaceName: workspace.Name,
+		Fetch: func(ctx context.Context) (codersdk.WorkspaceAgent, error) {
+			return client.WorkspaceAgent(ctx, workspaceAgent.ID)
+		},
+	})
+	if err != nil {
+		return nil, nil, xerrors.Errorf("await agent: %w", err)
+	}
+	conn, err := client.DialWorkspaceAgent(ctx, workspaceAgent.ID, &codersdk.DialWorkspaceAgentOptions{})
+	if err != nil {
+		return nil, nil, xerrors.Errorf("dial workspace agent: %w", err)
+	}
+	if !conn.AwaitReachable(ctx) {
+		_ = conn.Close()
+		return nil, nil, xerrors.New("workspace agent is unreachable")
+	}
+	sshClient, err := conn.SSHClient(ctx)
+	if err != nil {
+		_ = conn.Close()
+		return nil, nil, xerrors.Errorf("ssh client: %w", err)
+	}
+	closeSSH := func() {
+		_ = sshClient.Close()
+		_ = conn.Close()
+	}
+	sftpClient, err := newSFTPClient(sshClient)
+	if err != nil {
+		closeSSH()
+		return nil, nil, err
+	}
+	return sftpClient, func() {
+		_ = sftpClient.Close()
+		closeSSH()
+	}, nil
+}
+
+// newSFTPClient starts the sftp subsystem on a new session. If the agent
+// refuses it, for example because sessions in the workspace are recorded, the
+// reason the agent wrote to stderr is included in the error.
+func newSFTPClient(sshClient *gossh.Client) (*sftp.Client, error) {
+	session, err := sshClient.NewSession()
+	if err != nil {
+		return nil, xerrors.Errorf("ssh session: %w", err)
+	}
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		_ = session.Close()
+		return nil, xerrors.Errorf("stdin pipe: %w", err)
+	}
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		_ = session.Close()
+		return nil, xerrors.Errorf("stdout pipe: %w", err)
+	}
+	stderr, err := session.StderrPipe()
+	if err != nil {
+		_ = session.Close()
+		return nil, xerrors.Errorf("stderr pipe: %w", err)
+	}
+	err = session.RequestSubsystem("sftp")
+	if err != nil {
+		_ = session.Close()
+		return nil, xerrors.Errorf("request sftp subsystem: %w", err)
+	}
+
+	// The stderr of the session has to be drained so it can't block the
+	// channel, and is kept in case the agent refuses SFTP.
+	var stderrBuf bytes.Buffer
+	stderrDone := make(chan struct{})
+	go func() {
+		defer close(stderrDone)
+		_, _ = io.Copy(&stderrBuf, io.LimitReader(stderr, 4096))
+		_, _ = io.Copy(io.Discard, stderr)
+	}()
+
+	sftpClient, err := sftp.NewClientPipe(stdout, stdin)
+	if err != nil {
+		_ = session.Close()
+		// The agent closes the session right after explaining why it refused,
+		// so stderr ends shortly after. Anything else, like a lost connection,
+		// is reported as is.
+		select {
+		case <-stderrDone:
+			if reason := strings.TrimSpace(stderrBuf.String()); reason != "" {
+				return nil, xerrors.Errorf("sftp client: %s: %w", reason, err)
+			}
+		case <-time.After(time.Second):
+		}
+		return nil, xerrors.Errorf("sftp client: %w", err)
+	}
+	return sftpClient, nil
+}
+
+// readIgnoreFiles reads the ignore files that exist in the root directory,
+// and adds the exclude patterns after them.
+func readIgnoreFiles(fsys transferFS, root string, names []string, excludes []string) (*ignoreMatcher, error) {
+	m := &ignoreMatcher{}
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		f, err := fsys.Open(fsys.Join(root, name))
+		if xerrors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, xerrors.Errorf("open ignore file %q: %w", name, err)
+		}
+		data, err := io.ReadAll(f)
+		_ = f.Close()
+		if err != nil {
+			return nil, xerrors.Errorf("read ignore file %q: %w", name, err)
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			m.add(line)
+		}
+	}
+	for _, exclude := range excludes {
+		m.add(exclude)
+	}
+	return m, nil
+}
+
+// fileCopier copies files between filesystems.
+type fileCopier struct {
+	src, dst transferFS
+	ignore   *ignoreMatcher
+	progress *transferProgress
+}
+
+// copyPath copies the file or directory at srcPath to dstPath. rel is the
+// slash-separated path from the root of the transfer, which ignore patterns
+// are matched against. Symbolic links and special files are skipped.
+func (c *fileCopier) copyPath(srcPath, dstPath, rel string, info fs.FileInfo) error {
+	switch {
+	case info.IsDir():
+		err := c.dst.MkdirAll(dstPath)
+		if err != nil {
+			return xerrors.Errorf("create directory %q: %w", dstPath, err)
+		}
+		entries, err := c.src.ReadDir(srcPath)
+		if err != nil {
+			return xerrors.Errorf("read directory %q: %w", srcPath, err)
+		}
+		for _, entry := range entries {
+			entryRel := path.Join(rel, entry.Name())
+			if c.ignore.Ignored(entryRel, entry.IsDir()) {
+				continue
+			}
+			err = c.copyPath(c.src.Join(srcPath, entry.Name()), c.dst.Join(dstPath, entry.Name()), entryRel, entry)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case info.Mode().IsRegular():
+		return c.copyFile(srcPath, dstPath, info)
+	default:
+		return nil
+	}
+}
+
+func (c *fileCopier) copyFile(srcPath, dstPath string, info fs.FileInfo) error {
+	src, err := c.src.Open(srcPath)
+	if err != nil {
+		return xerrors.Errorf("open %q: %w", srcPath, err)
+	}
+	defer src.Close()
+	dst, err := c.dst.Create(dstPath, info.Mode())
+	if err != nil {
+		return xerrors.Errorf("create %q: %w", dstPath, err)
+	}
+	// Keep the SFTP side of the copy unwrapped, so its concurrent
+	// implementation of io.ReaderFrom or io.WriterTo is used.
+	if _, ok := c.dst.(sftpFS); ok {
+		_, err = io.Copy(dst, io.TeeReader(src, c.progress))
+	} else {
+		_, err = io.Copy(io.MultiWriter(dst, c.progress), src)
+	}
+	closeErr := dst.Close()
+	if err != nil {
+		return xerrors.Errorf("copy %q: %w", srcPath, err)
+	}
+	if closeErr != nil {
+		return xerrors.Errorf("close %q: %w", dstPath, closeErr)
+	}
+	err = c.dst.Chtimes(dstPath, info.ModTime())
+	if err != nil {
+		return xerrors.Errorf("set modification time of %q: %w", dstPath, err)
+	}
+	c.progress.files++
+	return nil
+}
+
+// removeAll removes a file or a directory and its contents.
+func removeAll(fsys transferFS, name string) error {
+	info, err := fsys.Lstat(name)
+	if xerrors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		entries, err := fsys.ReadDir(name)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			err = removeAll(fsys, fsys.Join(name, entry.Name()))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return fsys.Remove(name)
+}
+
+// transferProgress counts the files and bytes transferred, and draws a
+// status line to out while copying if it's set.
+type transferProgress struct {
+	out      io.Writer
+	files    int
+	bytes    int64
+	start    time.Time
+	lastDraw time.Time
+}
+
+func newTransferProgress(cmd *cobra.Command) *transferProgress {
+	p := &transferProgress{
+		start: time.Now(),
+	}
+	if isTTYErr(cmd) {
+		p.out = cmd.ErrOrStderr()
+	}
+	return p
+}
+
+// Write counts bytes as they're copied.
+func (p *transferProgress) Write(b []byte) (int, error) {
+	p.bytes += int64(len(b))
+	if p.out != nil && time.Since(p.lastDraw) > 100*time.Millisecond {
+		p.lastDraw = time.Now()
+		_, _ = fmt.Fprintf(p.out, "\r\033[K%s", p.status())
+	}
+	return len(b), nil
+}
+
+// clear removes the status line.
+func (p *transferProgress) clear() {
+	if p.out != nil && !p.lastDraw.IsZero() {
+		_, _ = fmt.Fprint(p.out, "\r\033[K")
+		p.lastDraw = time.Time{}
+	}
+}
+
+func (p *transferProgress) status() string {
+	files := "files"
+	if p.files == 1 {
+		files = "file"
+	}
+	return fmt.Sprintf("%d %s (%s)", p.files, files, formatBytes(p.bytes))
+}
+
+// summary returns the totals since the transfer started.
+func (p *transferProgress) summary(verb string) string {
+	return fmt.Sprintf("%s %s in %s.", verb, p.status(), time.Since(p.start).Round(time.Millisecond))
+}
+
+// formatBytes formats a size with binary units, e.g. 1.5 MiB.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTransferLocation(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		arg      string
+		expected transferLocation
+	}{
+		{arg: "file.txt", expected: transferLocation{path: "file.txt"}},
+		{arg: "./dir:name", expected: transferLocation{path: "./dir:name"}},
+		{arg: ":file", expected: transferLocation{path: ":file"}},
+		{arg: "ws:", expected: transferLocation{workspace: "ws", path: "."}},
+		{arg: "ws:~", expected: transferLocation{workspace: "ws", path: "."}},
+		{arg: "ws:~/project", expected: transferLocation{workspace: "ws", path: "project"}},
+		{arg: "ws.main:/tmp/x", expected: transferLocation{workspace: "ws.main", path: "/tmp/x"}},
+	} {
+		require.Equal(t, tc.expected, parseTransferLocation(tc.arg), tc.arg)
+	}
+}
+
+func TestIgnoreMatcher(t *testing.T) {
+	t.Parallel()
+
+	m := &ignoreMatcher{}
+	for _, line := range []string{
+		"# comment",
+		"*.log",
+		"!keep.log",
+		"node_modules/",
+		"/build",
+		"docs/**/*.tmp",
+	} {
+		m.add(line)
+	}
+	for _, tc := range []struct {
+		rel     string
+		isDir   bool
+		ignored bool
+	}{
+		{rel: "debug.log", ignored: true},
+		{rel: "sub/debug.log", ignored: true},
+		{rel: "keep.log"},
+		{rel: "node_modules", isDir: true, ignored: true},
+		{rel: "web/node_modules/pkg/index.js", ignored: true},
+		{rel: "node_modules"},
+		{rel: "build", isDir: true, ignored: true},
+		{rel: "build/output", ignored: true},
+		{rel: "src/build", isDir: true},
+		{rel: "docs/a.tmp", ignored: true},
+		{rel: "docs/a/b/c.tmp", ignored: true},
+		{rel: "a.tmp"},
+		{rel: "main.go"},
+	} {
+		require.Equal(t, tc.ignored, m.Ignored(tc.rel, tc.isDir), tc.rel)
+	}
+
+	var nilMatcher *ignoreMatcher
+	require.False(t, nilMatcher.Ignored("debug.log", false))
+}
+
+func TestFormatBytes(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "512 B", formatBytes(512))
+	require.Equal(t, "1.5 KiB", formatBytes(1536))
+	require.Equal(t, "2.0 MiB", formatBytes(2<<20))
+}
@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"path"
+	"strings"
+)
+
+// ignoreMatcher matches slash-separated paths relative to the root of a
+// transfer against patterns in the format of .gitignore files.
+type ignoreMatcher struct {
+	patterns []ignorePattern
+}
+
+type ignorePattern struct {
+	glob    string
+	negate  bool
+	dirOnly bool
+	// anchored patterns match the whole path rather than any name in it.
+	anchored bool
+}
+
+// add parses a line of an ignore file.
+func (m *ignoreMatcher) add(line string) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return
+	}
+	var p ignorePattern
+	if strings.HasPrefix(line, "!") {
+		p.negate = true
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+	if strings.Contains(line, "/") {
+		p.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+	if line == "" {
+		return
+	}
+	p.glob = line
+	m.patterns = append(m.patterns, p)
+}
+
+// Ignored returns whether the path or any of its parent directories are
+// ignored.
+func (m *ignoreMatcher) Ignored(rel string, isDir bool) bool {
+	if m == nil || len(m.patterns) == 0 {
+		return false
+	}
+	parts := strings.Split(rel, "/")
+	for i := 1; i < len(parts); i++ {
+		if m.match(strings.Join(parts[:i], "/"), true) {
+			return true
+		}
+	}
+	return m.match(rel, isDir)
+}
+
+// match returns whether the last pattern matching the path ignores it.
+func (m *ignoreMatcher) match(rel string, isDir bool) bool {
+	ignored := false
+	for _, p := range m.patterns {
+		if p.dirOnly && !isDir {
+			continue
+		}
+		var ok bool
+		if p.anchored {
+			ok = matchGlobPath(strings.Split(p.glob, "/"), strings.Split(rel, "/"))
+		} else {
+			ok, _ = path.Match(p.glob, path.Base(rel))
+		}
+		if ok {
+			ignored = !p.negate
+		}
+	}
+	return ignored
+}
+
+// matchGlobPath matches path segments against pattern segments, where "**"
+// matches any number of segments.
+func matchGlobPath(pattern, parts []string) bool {
+	if len(pattern) == 0 {
+		return len(parts) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(parts); i++ {
+			if matchGlobPath(pattern[1:], parts[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(parts) == 0 {
+		return false
+	}
+	ok, _ := path.Match(pattern[0], parts[0])
+	return ok && matchGlobPath(pattern[1:], parts[1:])
+}
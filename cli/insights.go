@@ -0,0 +1,266 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/cli/cliui"
+	"github.com/coder/coder/codersdk"
+)
+
+func insights() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "insights",
+		Short: "Report the usage of templates",
+		Long: "Usage is rolled up every few minutes by the hour, from the connection stats of workspace agents and the " +
+			"daily cost of workspace builds. Reports include the templates you can edit.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	cmd.AddCommand(
+		insightsTemplates(),
+		insightsUsers(),
+		insightsApps(),
+	)
+	return cmd
+}
+
+// insightsOptions are the flags shared by the insights reports.
+type insightsOptions struct {
+	start     string
+	end       string
+	templates []string
+	csv       bool
+	columns   []string
+}
+
+func (o *insightsOptions) attach(cmd *cobra.Command, columns string) {
+	cmd.Flags().StringVar(&o.start, "start", "", "The first day of the report, as YYYY-MM-DD in local time. Defaults to 6 days before --end.")
+	cmd.Flags().StringVar(&o.end, "end", "", "The last day of the report, as YYYY-MM-DD in local time. Defaults to today.")
+	cmd.Flags().StringArrayVarP(&o.templates, "template", "t", nil, "Only report on the template with this name. Can be specified multiple times.")
+	cmd.Flags().BoolVar(&o.csv, "csv", false, "Print the report as CSV.")
+	cmd.Flags().StringArrayVarP(&o.columns, "column", "c", nil, "Specify a column to filter in the table. Available columns are: "+columns+".")
+}
+
+// request returns the request of the report. Days are in local time, and both
+// the first and last day are included.
+func (o *insightsOptions) request(cmd *cobra.Command, client *codersdk.Client) (codersdk.InsightsRequest, error) {
+	const layout = "2006-01-02"
+	now := time.Now()
+	endDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	if o.end != "" {
+		day, err := time.ParseInLocation(layout, o.end, time.Local)
+		if err != nil {
+			return codersdk.InsightsRequest{}, xerrors.Errorf("parse --end %q: must be formatted as YYYY-MM-DD", o.end)
+		}
+		endDay = day
+	}
+	startDay := endDay.AddDate(0, 0, -6)
+	if o.start != "" {
+		day, err := time.ParseInLocation(layout, o.start, time.Local)
+		if err != nil {
+			return codersdk.InsightsRequest{}, xerrors.Errorf("parse --start %q: must be formatted as YYYY-MM-DD", o.start)
+		}
+		startDay = day
+	}
+	if endDay.Before(startDay) {
+		return codersdk.InsightsRequest{}, xerrors.New("--end must not be before --start")
+	}
+
+	req := codersdk.InsightsRequest{
+		StartTime: startDay,
+		EndTime:   endDay.AddDate(0, 0, 1),
+	}
+	if len(o.templates) > 0 {
+		organization, err := CurrentOrganization(cmd, client)
+		if err != nil {
+			return codersdk.InsightsRequest{}, err
+		}
+		req.TemplateIDs = make([]uuid.UUID, 0, len(o.templates))
+		for _, name := range o.templates {
+			template, err := client.TemplateByName(cmd.Context(), organization.ID, name)
+			if err != nil {
+				return codersdk.InsightsRequest{}, xerrors.Errorf("get template %q: %w", name, err)
+			}
+			req.TemplateIDs = append(req.TemplateIDs, template.ID)
+		}
+	}
+	return req, nil
+}
+
+// run prints the report as CSV if requested, or as a table of the rows
+// returned by table.
+func (o *insightsOptions) run(cmd *cobra.Command, report codersdk.InsightsReport, table func(*codersdk.Client, codersdk.InsightsRequest) (interface{}, error)) error {
+	client, err := CreateClient(cmd)
+	if err != nil {
+		return err
+	}
+	req, err := o.request(cmd, client)
+	if err != nil {
+		return err
+	}
+	if o.csv {
+		data, err := client.InsightsCSV(cmd.Context(), report, req)
+		if err != nil {
+			return err
+		}
+		_, err = cmd.OutOrStdout().Write(data)
+		return err
+	}
+	rows, err := table(client, req)
+	if err != nil {
+		return err
+	}
+	out, err := cliui.DisplayTable(rows, "", o.columns)
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintln(cmd.OutOrStdout(), out)
+	return nil
+}
+
+type templateInsightRow struct {
+	Template    string `table:"template"`
+	ActiveUsers int64  `table:"active users"`
+	ActiveHours int64  `table:"active hours"`
+	Connections int64  `table:"connections"`
+	SSH         int64  `table:"ssh"`
+	WebTerminal int64  `table:"web terminal"`
+	VSCode      int64  `table:"vs code"`
+	JetBrains   int64  `table:"jetbrains"`
+	Cost        string `table:"cost"`
+}
+
+func insightsTemplates() *cobra.Command {
+	var opts insightsOptions
+	cmd := &cobra.Command{
+		Use:   "templates",
+		Short: "Report the active users, connections and cost of templates",
+		Args:  cobra.NoArgs,
+		Example: formatExamples(
+			example{
+				Description: "Report the usage of every template over the last 7 days",
+				Command:     "coder insights templates",
+			},
+			example{
+				Description: "Export the usage of a template in January as CSV",
+				Command:     "coder insights templates --template docker --start 2023-01-01 --end 2023-01-31 --csv > usage.csv",
+			},
+		),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return opts.run(cmd, codersdk.InsightsReportTemplates, func(client *codersdk.Client, req codersdk.InsightsRequest) (interface{}, error) {
+				res, err := client.TemplateInsights(cmd.Context(), req)
+				if err != nil {
+					return nil, err
+				}
+				rows := make([]templateInsightRow, 0, len(res.Templates))
+				for _, insight := range res.Templates {
+					rows = append(rows, templateInsightRow{
+						Template:    insight.TemplateName,
+						ActiveUsers: insight.ActiveUsers,
+						ActiveHours: insight.ActiveHours,
+						Connections: insight.ConnectionCount,
+						SSH:         insight.SSHConnectionCount,
+						WebTerminal: insight.ReconnectingPTYConnectionCount,
+						VSCode:      insight.VSCodeConnectionCount,
+						JetBrains:   insight.JetBrainsConnectionCount,
+						Cost:        fmt.Sprintf("%.2f", insight.Cost),
+					})
+				}
+				return rows, nil
+			})
+		},
+	}
+	opts.attach(cmd, "template, active_users, active_hours, connections, ssh, web_terminal, vs_code, jetbrains, cost")
+	return cmd
+}
+
+type userInsightRow struct {
+	Template    string `table:"template"`
+	User        string `table:"user"`
+	ActiveHours int64  `table:"active hours"`
+	Connections int64  `table:"connections"`
+	SSH         int64  `table:"ssh"`
+	WebTerminal int64  `table:"web terminal"`
+	VSCode      int64  `table:"vs code"`
+	JetBrains   int64  `table:"jetbrains"`
+	Cost        string `table:"cost"`
+}
+
+func insightsUsers() *cobra.Command {
+	var opts insightsOptions
+	cmd := &cobra.Command{
+		Use:   "users",
+		Short: "Report the active hours, connections and cost of each user of templates",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return opts.run(cmd, codersdk.InsightsReportUsers, func(client *codersdk.Client, req codersdk.InsightsRequest) (interface{}, error) {
+				res, err := client.UserInsights(cmd.Context(), req)
+				if err != nil {
+					return nil, err
+				}
+				rows := make([]userInsightRow, 0, len(res.Users))
+				for _, insight := range res.Users {
+					rows = append(rows, userInsightRow{
+						Template:    insight.TemplateName,
+						User:        insight.Username,
+						ActiveHours: insight.ActiveHours,
+						Connections: insight.ConnectionCount,
+						SSH:         insight.SSHConnectionCount,
+						WebTerminal: insight.ReconnectingPTYConnectionCount,
+						VSCode:      insight.VSCodeConnectionCount,
+						JetBrains:   insight.JetBrainsConnectionCount,
+						Cost:        fmt.Sprintf("%.2f", insight.Cost),
+					})
+				}
+				return rows, nil
+			})
+		},
+	}
+	opts.attach(cmd, "template, user, active_hours, connections, ssh, web_terminal, vs_code, jetbrains, cost")
+	return cmd
+}
+
+type appInsightRow struct {
+	Template   string `table:"template"`
+	App        string `table:"app"`
+	Users      int64  `table:"users"`
+	UsageHours int64  `table:"usage hours"`
+	Requests   int64  `table:"requests"`
+}
+
+func insightsApps() *cobra.Command {
+	var opts insightsOptions
+	cmd := &cobra.Command{
+		Use:   "apps",
+		Short: "Report the users and requests of the apps of templates",
+		Long:  "Apps are grouped by slug. Only requests made by signed in users are counted.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return opts.run(cmd, codersdk.InsightsReportApps, func(client *codersdk.Client, req codersdk.InsightsRequest) (interface{}, error) {
+				res, err := client.AppInsights(cmd.Context(), req)
+				if err != nil {
+					return nil, err
+				}
+				rows := make([]appInsightRow, 0, len(res.Apps))
+				for _, insight := range res.Apps {
+					rows = append(rows, appInsightRow{
+						Template:   insight.TemplateName,
+						App:        insight.Slug,
+						Users:      insight.Users,
+						UsageHours: insight.UsageHours,
+						Requests:   insight.Requests,
+					})
+				}
+				return rows, nil
+			})
+		},
+	}
+	opts.attach(cmd, "template, app, users, usage_hours, requests")
+	return cmd
+}
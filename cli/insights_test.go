@@ -0,0 +1,93 @@
+package cli_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/cli/clitest"
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/testutil"
+)
+
+func TestInsights(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testutil.Context(t)
+	defer cancel()
+
+	client, _, api := coderdtest.NewWithAPI(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+	user := coderdtest.CreateFirstUser(t, client)
+	version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+	coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+	template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+
+	hour := database.Now().Truncate(time.Hour)
+	err := api.Database.UpsertTemplateUsageStats(ctx, database.UpsertTemplateUsageStatsParams{
+		StartTime:          hour,
+		TemplateID:         template.ID,
+		UserID:             user.UserID,
+		Active:             true,
+		ConnectionCount:    3,
+		SshConnectionCount: 3,
+		Cost:               1.5,
+	})
+	require.NoError(t, err)
+	err = api.Database.InsertTemplateAppUsageStats(ctx, database.InsertTemplateAppUsageStatsParams{
+		StartTime:  hour,
+		TemplateID: template.ID,
+		UserID:     user.UserID,
+		Slug:       "code-server",
+		Requests:   7,
+	})
+	require.NoError(t, err)
+
+	run := func(t *testing.T, args ...string) (string, error) {
+		ctx, cancel := testutil.Context(t)
+		defer cancel()
+
+		cmd, root := clitest.New(t, args...)
+		clitest.SetupConfig(t, client, root)
+		var out bytes.Buffer
+		cmd.SetOut(&out)
+		err := cmd.ExecuteContext(ctx)
+		return out.String(), err
+	}
+
+	t.Run("Templates", func(t *testing.T) {
+		t.Parallel()
+
+		out, err := run(t, "insights", "templates", "--template", template.Name)
+		require.NoError(t, err)
+		require.Contains(t, out, template.Name)
+		require.Contains(t, out, "1.50")
+	})
+
+	t.Run("Apps", func(t *testing.T) {
+		t.Parallel()
+
+		out, err := run(t, "insights", "apps", "--column", "app", "--column", "requests")
+		require.NoError(t, err)
+		require.Contains(t, out, "code-server")
+		require.Contains(t, out, "7")
+	})
+
+	t.Run("CSV", func(t *testing.T) {
+		t.Parallel()
+
+		out, err := run(t, "insights", "users", "--csv")
+		require.NoError(t, err)
+		require.Contains(t, out, "template_id,template_name,user_id,username,active_hours")
+		require.Contains(t, out, user.UserID.String())
+	})
+
+	t.Run("InvalidDate", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := run(t, "insights", "templates", "--start", "yesterday")
+		require.ErrorContains(t, err, "YYYY-MM-DD")
+	})
+}
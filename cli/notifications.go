@@ -0,0 +1,293 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/cli/cliui"
+	"github.com/coder/coder/codersdk"
+)
+
+func notifications() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "notifications",
+		Short:   "Manage webhooks notified about workspace and template events",
+		Aliases: []string{"notification"},
+		Example: formatExamples(
+			example{
+				Description: "Notify an endpoint when workspace builds fail",
+				Command:     "coder notifications create build-failures --endpoint https://example.com/hook --event workspace_build.failed",
+			},
+			example{
+				Description: "List failed deliveries of a webhook",
+				Command:     "coder notifications deliveries build-failures --status failed",
+			},
+		),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	cmd.AddCommand(
+		createNotification(),
+		listNotifications(),
+		removeNotification(),
+		notificationDeliveries(),
+	)
+	return cmd
+}
+
+func createNotification() *cobra.Command {
+	var (
+		endpoint string
+		events   []string
+	)
+	cmd := &cobra.Command{
+		Use:   "create <name>",
+		Short: "Create a webhook",
+		Long: "Create a webhook that is sent a signed JSON payload for every event it is subscribed to. " +
+			"Events: " + strings.Join(webhookEventNames(), ", ") + ".",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return xerrors.Errorf("create codersdk client: %w", err)
+			}
+			organization, err := CurrentOrganization(cmd, client)
+			if err != nil {
+				return xerrors.Errorf("get current organization: %w", err)
+			}
+
+			eventTypes := make([]codersdk.WebhookEventType, 0, len(events))
+			for _, event := range events {
+				eventTypes = append(eventTypes, codersdk.WebhookEventType(event))
+			}
+			webhook, err := client.CreateWebhook(cmd.Context(), organization.ID, codersdk.CreateWebhookRequest{
+				Name:   args[0],
+				URL:    endpoint,
+				Events: eventTypes,
+			})
+			if err != nil {
+				return xerrors.Errorf("create webhook: %w", err)
+			}
+
+			cmd.Println(cliui.Styles.Wrap.Render(
+				fmt.Sprintf("Webhook %s has been created. Deliveries are signed with this secret:", cliui.Styles.Keyword.Render(webhook.Name)),
+			))
+			cmd.Println()
+			cmd.Println(cliui.Styles.Code.Render(webhook.Secret))
+			cmd.Println()
+			cmd.Println(cliui.Styles.Wrap.Render(
+				"It will not be shown again.",
+			))
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&endpoint, "endpoint", "", "The URL deliveries are sent to.")
+	cmd.Flags().StringArrayVar(&events, "event", nil, "An event to subscribe to. Can be specified multiple times. Subscribes to all events if unset.")
+	_ = cmd.MarkFlagRequired("endpoint")
+	return cmd
+}
+
+type notificationRow struct {
+	Name      string    `table:"Name"`
+	ID        string    `table:"ID"`
+	URL       string    `table:"URL"`
+	Events    string    `table:"Events"`
+	CreatedAt time.Time `table:"Created At"`
+}
+
+func listNotifications() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List webhooks",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return xerrors.Errorf("create codersdk client: %w", err)
+			}
+			organization, err := CurrentOrganization(cmd, client)
+			if err != nil {
+				return xerrors.Errorf("get current organization: %w", err)
+			}
+
+			webhooks, err := client.WebhooksByOrganization(cmd.Context(), organization.ID)
+			if err != nil {
+				return xerrors.Errorf("list webhooks: %w", err)
+			}
+			if len(webhooks) == 0 {
+				cmd.Println(cliui.Styles.Wrap.Render(
+					"No webhooks found.",
+				))
+				return nil
+			}
+
+			rows := make([]notificationRow, 0, len(webhooks))
+			for _, webhook := range webhooks {
+				events := "all"
+				if len(webhook.Events) > 0 {
+					names := make([]string, 0, len(webhook.Events))
+					for _, event := range webhook.Events {
+						names = append(names, string(event))
+					}
+					events = strings.Join(names, ", ")
+				}
+				rows = append(rows, notificationRow{
+					Name:      webhook.Name,
+					ID:        webhook.ID.String(),
+					URL:       webhook.URL,
+					Events:    events,
+					CreatedAt: webhook.CreatedAt,
+				})
+			}
+
+			out, err := cliui.DisplayTable(rows, "", nil)
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintln(cmd.OutOrStdout(), out)
+			return err
+		},
+	}
+	return cmd
+}
+
+func removeNotification() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "remove <name|id>",
+		Aliases: []string{"rm"},
+		Short:   "Delete a webhook",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return xerrors.Errorf("create codersdk client: %w", err)
+			}
+			webhook, err := namedWebhook(cmd, client, args[0])
+			if err != nil {
+				return err
+			}
+
+			err = client.DeleteWebhook(cmd.Context(), webhook.ID)
+			if err != nil {
+				return xerrors.Errorf("delete webhook: %w", err)
+			}
+
+			cmd.Println(cliui.Styles.Wrap.Render(
+				fmt.Sprintf("Webhook %s has been deleted.", cliui.Styles.Keyword.Render(webhook.Name)),
+			))
+			return nil
+		},
+	}
+	return cmd
+}
+
+type notificationDeliveryRow struct {
+	ID         string    `table:"ID"`
+	Event      string    `table:"Event"`
+	Status     string    `table:"Status"`
+	Attempts   int32     `table:"Attempts"`
+	StatusCode int32     `table:"Last Status Code"`
+	Error      string    `table:"Last Error"`
+	CreatedAt  time.Time `table:"Created At"`
+}
+
+func notificationDeliveries() *cobra.Command {
+	var (
+		status string
+		limit  int
+	)
+	cmd := &cobra.Command{
+		Use:   "deliveries <name|id>",
+		Short: "List the deliveries of a webhook, newest first",
+		Long:  "List the deliveries of a webhook, newest first. Deliveries with the failed status have exhausted their retries.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return xerrors.Errorf("create codersdk client: %w", err)
+			}
+			webhook, err := namedWebhook(cmd, client, args[0])
+			if err != nil {
+				return err
+			}
+
+			deliveries, err := client.WebhookDeliveries(cmd.Context(), webhook.ID, codersdk.WebhookDeliveriesRequest{
+				Status: codersdk.WebhookDeliveryStatus(status),
+				Limit:  limit,
+			})
+			if err != nil {
+				return xerrors.Errorf("list webhook deliveries: %w", err)
+			}
+			if len(deliveries) == 0 {
+				cmd.Println(cliui.Styles.Wrap.Render(
+					"No deliveries found.",
+				))
+				return nil
+			}
+
+			rows := make([]notificationDeliveryRow, 0, len(deliveries))
+			for _, delivery := range deliveries {
+				rows = append(rows, notificationDeliveryRow{
+					ID:         delivery.ID.String(),
+					Event:      string(delivery.EventType),
+					Status:     string(delivery.Status),
+					Attempts:   delivery.Attempts,
+					StatusCode: delivery.LastStatusCode,
+					Error:      delivery.LastError,
+					CreatedAt:  delivery.CreatedAt,
+				})
+			}
+
+			out, err := cliui.DisplayTable(rows, "", nil)
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintln(cmd.OutOrStdout(), out)
+			return err
+		},
+	}
+	cmd.Flags().StringVar(&status, "status", "", "Only show deliveries with this status: pending, delivered or failed.")
+	cmd.Flags().IntVar(&limit, "limit", 25, "The maximum number of deliveries to show.")
+	return cmd
+}
+
+// namedWebhook returns the webhook with the ID or name in the current
+// organization.
+func namedWebhook(cmd *cobra.Command, client *codersdk.Client, identifier string) (codersdk.Webhook, error) {
+	if id, err := uuid.Parse(identifier); err == nil {
+		webhook, err := client.Webhook(cmd.Context(), id)
+		if err != nil {
+			return codersdk.Webhook{}, xerrors.Errorf("get webhook: %w", err)
+		}
+		return webhook, nil
+	}
+
+	organization, err := CurrentOrganization(cmd, client)
+	if err != nil {
+		return codersdk.Webhook{}, xerrors.Errorf("get current organization: %w", err)
+	}
+	webhooks, err := client.WebhooksByOrganization(cmd.Context(), organization.ID)
+	if err != nil {
+		return codersdk.Webhook{}, xerrors.Errorf("list webhooks: %w", err)
+	}
+	for _, webhook := range webhooks {
+		if webhook.Name == identifier {
+			return webhook, nil
+		}
+	}
+	return codersdk.Webhook{}, xerrors.Errorf("webhook %q not found", identifier)
+}
+
+func webhookEventNames() []string {
+	names := make([]string, 0, len(codersdk.WebhookEventTypes))
+	for _, event := range codersdk.WebhookEventTypes {
+		names = append(names, string(event))
+	}
+	return names
+}
@@ -0,0 +1,77 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/cli/clitest"
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/testutil"
+)
+
+func TestNotifications(t *testing.T) {
+	t.Parallel()
+	client := coderdtest.New(t, nil)
+	user := coderdtest.CreateFirstUser(t, client)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	cmd, root := clitest.New(t, "notifications", "ls")
+	clitest.SetupConfig(t, client, root)
+	buf := new(bytes.Buffer)
+	cmd.SetOut(buf)
+	err := cmd.Execute()
+	require.NoError(t, err)
+	require.Contains(t, buf.String(), "No webhooks found")
+
+	cmd, root = clitest.New(t, "notifications", "create", "builds",
+		"--endpoint", "https://example.com/hook",
+		"--event", string(codersdk.WebhookEventWorkspaceBuildFailed),
+	)
+	clitest.SetupConfig(t, client, root)
+	buf = new(bytes.Buffer)
+	cmd.SetOut(buf)
+	err = cmd.Execute()
+	require.NoError(t, err)
+	require.Contains(t, buf.String(), "signed with this secret")
+
+	webhooks, err := client.WebhooksByOrganization(ctx, user.OrganizationID)
+	require.NoError(t, err)
+	require.Len(t, webhooks, 1)
+	require.Equal(t, []codersdk.WebhookEventType{codersdk.WebhookEventWorkspaceBuildFailed}, webhooks[0].Events)
+
+	cmd, root = clitest.New(t, "notifications", "ls")
+	clitest.SetupConfig(t, client, root)
+	buf = new(bytes.Buffer)
+	cmd.SetOut(buf)
+	err = cmd.Execute()
+	require.NoError(t, err)
+	require.Contains(t, buf.String(), "builds")
+	require.Contains(t, buf.String(), "https://example.com/hook")
+	require.Contains(t, buf.String(), webhooks[0].ID.String())
+
+	cmd, root = clitest.New(t, "notifications", "deliveries", "builds")
+	clitest.SetupConfig(t, client, root)
+	buf = new(bytes.Buffer)
+	cmd.SetOut(buf)
+	err = cmd.Execute()
+	require.NoError(t, err)
+	require.Contains(t, buf.String(), "No deliveries found")
+
+	cmd, root = clitest.New(t, "notifications", "rm", "builds")
+	clitest.SetupConfig(t, client, root)
+	buf = new(bytes.Buffer)
+	cmd.SetOut(buf)
+	err = cmd.Execute()
+	require.NoError(t, err)
+	require.Contains(t, buf.String(), "has been deleted")
+
+	webhooks, err = client.WebhooksByOrganization(ctx, user.OrganizationID)
+	require.NoError(t, err)
+	require.Empty(t, webhooks)
+}
@@ -0,0 +1,156 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/cli/cliui"
+)
+
+func organizationMembers() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "members",
+		Short:   "Manage the members of the current organization",
+		Aliases: []string{"member"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	cmd.AddCommand(
+		listOrganizationMembers(),
+		addOrganizationMember(),
+		removeOrganizationMember(),
+	)
+
+	return cmd
+}
+
+type organizationMemberRow struct {
+	Username string `table:"Username"`
+	Email    string `table:"Email"`
+	Roles    string `table:"Roles"`
+}
+
+func listOrganizationMembers() *cobra.Command {
+	var outputFormat string
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List the members of the current organization",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return xerrors.Errorf("create codersdk client: %w", err)
+			}
+			org, err := CurrentOrganization(cmd, client)
+			if err != nil {
+				return xerrors.Errorf("get current organization: %w", err)
+			}
+
+			members, err := client.OrganizationMembers(cmd.Context(), org.ID)
+			if err != nil {
+				return xerrors.Errorf("list organization members: %w", err)
+			}
+
+			out := ""
+			switch outputFormat {
+			case "table", "":
+				rows := make([]organizationMemberRow, 0, len(members))
+				for _, member := range members {
+					roles := make([]string, 0, len(member.Roles))
+					for _, role := range member.Roles {
+						roles = append(roles, role.DisplayName)
+					}
+					rows = append(rows, organizationMemberRow{
+						Username: member.Username,
+						Email:    member.Email,
+						Roles:    strings.Join(roles, ", "),
+					})
+				}
+				out, err = cliui.DisplayTable(rows, "Username", nil)
+				if err != nil {
+					return xerrors.Errorf("render table: %w", err)
+				}
+			case "json":
+				outBytes, err := json.Marshal(members)
+				if err != nil {
+					return xerrors.Errorf("marshal members to JSON: %w", err)
+				}
+				out = string(outBytes)
+			default:
+				return xerrors.Errorf(`unknown output format %q, only "table" and "json" are supported`, outputFormat)
+			}
+
+			_, err = fmt.Fprintln(cmd.OutOrStdout(), out)
+			return err
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "table", "Output format. Available formats are: table, json.")
+	return cmd
+}
+
+func addOrganizationMember() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "add <username|user_id>",
+		Short: "Add an existing user to the current organization",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return xerrors.Errorf("create codersdk client: %w", err)
+			}
+			org, err := CurrentOrganization(cmd, client)
+			if err != nil {
+				return xerrors.Errorf("get current organization: %w", err)
+			}
+
+			_, err = client.AddOrganizationMember(cmd.Context(), org.ID, args[0])
+			if err != nil {
+				return xerrors.Errorf("add organization member: %w", err)
+			}
+
+			_, err = fmt.Fprintf(cmd.OutOrStdout(), "Added %s to organization %s!\n",
+				cliui.Styles.Keyword.Render(args[0]), cliui.Styles.Keyword.Render(org.Name))
+			return err
+		},
+	}
+
+	return cmd
+}
+
+func removeOrganizationMember() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "remove <username|user_id>",
+		Aliases: []string{"rm"},
+		Short:   "Remove a user from the current organization",
+		Long:    "Users are also removed from the groups of the organization. Users that own workspaces in the organization can't be removed.",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return xerrors.Errorf("create codersdk client: %w", err)
+			}
+			org, err := CurrentOrganization(cmd, client)
+			if err != nil {
+				return xerrors.Errorf("get current organization: %w", err)
+			}
+
+			err = client.RemoveOrganizationMember(cmd.Context(), org.ID, args[0])
+			if err != nil {
+				return xerrors.Errorf("remove organization member: %w", err)
+			}
+
+			_, err = fmt.Fprintf(cmd.OutOrStdout(), "Removed %s from organization %s!\n",
+				cliui.Styles.Keyword.Render(args[0]), cliui.Styles.Keyword.Render(org.Name))
+			return err
+		},
+	}
+
+	return cmd
+}
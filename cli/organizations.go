@@ -0,0 +1,213 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/cli/cliui"
+	"github.com/coder/coder/codersdk"
+)
+
+func organizations() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "organizations",
+		Short:   "Manage organizations",
+		Long:    "Organizations isolate templates, workspaces and provisioners of teams sharing a deployment.",
+		Aliases: []string{"organization", "orgs", "org"},
+		Example: formatExamples(
+			example{
+				Description: "Create an organization",
+				Command:     "coder organizations create data-science",
+			},
+			example{
+				Description: "Use an organization for all following commands",
+				Command:     "coder organizations switch data-science",
+			},
+			example{
+				Description: "Add a user to the current organization",
+				Command:     "coder organizations members add alice",
+			},
+		),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	cmd.AddCommand(
+		createOrganization(),
+		listOrganizations(),
+		editOrganization(),
+		switchOrganization(),
+		organizationMembers(),
+	)
+
+	return cmd
+}
+
+func createOrganization() *cobra.Command {
+	var description string
+	cmd := &cobra.Command{
+		Use:   "create <name>",
+		Short: "Create an organization",
+		Long:  "You become the admin of the organizations you create.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return xerrors.Errorf("create codersdk client: %w", err)
+			}
+
+			org, err := client.CreateOrganization(cmd.Context(), codersdk.CreateOrganizationRequest{
+				Name:        args[0],
+				Description: description,
+			})
+			if err != nil {
+				return xerrors.Errorf("create organization: %w", err)
+			}
+
+			_, err = fmt.Fprintf(cmd.OutOrStdout(), "Created organization %s! Run %s to use it.\n",
+				cliui.Styles.Keyword.Render(org.Name),
+				cliui.Styles.Code.Render("coder organizations switch "+org.Name))
+			return err
+		},
+	}
+
+	cmd.Flags().StringVar(&description, "description", "", "Specify a description for the organization.")
+	return cmd
+}
+
+type organizationRow struct {
+	Name        string `table:"Name"`
+	ID          string `table:"ID"`
+	Description string `table:"Description"`
+	Current     bool   `table:"Current"`
+}
+
+func listOrganizations() *cobra.Command {
+	var outputFormat string
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List the organizations you're a member of",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return xerrors.Errorf("create codersdk client: %w", err)
+			}
+
+			orgs, err := client.OrganizationsByUser(cmd.Context(), codersdk.Me)
+			if err != nil {
+				return xerrors.Errorf("list organizations: %w", err)
+			}
+
+			out := ""
+			switch outputFormat {
+			case "table", "":
+				// An unknown selection only fails commands that use it,
+				// so it's still possible to list organizations to fix it.
+				current, _ := CurrentOrganization(cmd, client)
+				rows := make([]organizationRow, 0, len(orgs))
+				for _, org := range orgs {
+					rows = append(rows, organizationRow{
+						Name:        org.Name,
+						ID:          org.ID.String(),
+						Description: org.Description,
+						Current:     org.ID == current.ID,
+					})
+				}
+				out, err = cliui.DisplayTable(rows, "Name", nil)
+				if err != nil {
+					return xerrors.Errorf("render table: %w", err)
+				}
+			case "json":
+				outBytes, err := json.Marshal(orgs)
+				if err != nil {
+					return xerrors.Errorf("marshal organizations to JSON: %w", err)
+				}
+				out = string(outBytes)
+			default:
+				return xerrors.Errorf(`unknown output format %q, only "table" and "json" are supported`, outputFormat)
+			}
+
+			_, err = fmt.Fprintln(cmd.OutOrStdout(), out)
+			return err
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "table", "Output format. Available formats are: table, json.")
+	return cmd
+}
+
+func editOrganization() *cobra.Command {
+	var (
+		name        string
+		description string
+	)
+	cmd := &cobra.Command{
+		Use:   "edit <name>",
+		Short: "Edit the settings of an organization",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return xerrors.Errorf("create codersdk client: %w", err)
+			}
+
+			org, err := client.OrganizationByName(cmd.Context(), codersdk.Me, args[0])
+			if err != nil {
+				return xerrors.Errorf("get organization %q: %w", args[0], err)
+			}
+
+			req := codersdk.UpdateOrganizationRequest{
+				Name: name,
+			}
+			if cmd.Flags().Changed("description") {
+				req.Description = &description
+			}
+			org, err = client.UpdateOrganization(cmd.Context(), org.ID, req)
+			if err != nil {
+				return xerrors.Errorf("update organization: %w", err)
+			}
+
+			_, err = fmt.Fprintf(cmd.OutOrStdout(), "Updated organization %s!\n", cliui.Styles.Keyword.Render(org.Name))
+			return err
+		},
+	}
+
+	cmd.Flags().StringVar(&name, "name", "", "Rename the organization.")
+	cmd.Flags().StringVar(&description, "description", "", "Specify a description for the organization.")
+	return cmd
+}
+
+func switchOrganization() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "switch <name>",
+		Short: "Select the organization used by other commands",
+		Long:  "The selected organization is stored in the CLI configuration, and is reset when you log out.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return xerrors.Errorf("create codersdk client: %w", err)
+			}
+
+			org, err := client.OrganizationByName(cmd.Context(), codersdk.Me, args[0])
+			if err != nil {
+				return xerrors.Errorf("get organization %q: %w", args[0], err)
+			}
+
+			err = createConfig(cmd).Organization().Write(org.ID.String())
+			if err != nil {
+				return xerrors.Errorf("write organization: %w", err)
+			}
+
+			_, err = fmt.Fprintf(cmd.OutOrStdout(), "Switched to organization %s!\n", cliui.Styles.Keyword.Render(org.Name))
+			return err
+		},
+	}
+
+	return cmd
+}
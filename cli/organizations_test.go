@@ -0,0 +1,125 @@
+package cli_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/cli/clitest"
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/testutil"
+)
+
+func TestOrganizations(t *testing.T) {
+	t.Parallel()
+
+	t.Run("CreateAndSwitch", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		_ = coderdtest.CreateFirstUser(t, client)
+
+		cmd, root := clitest.New(t, "organizations", "create", "data-science", "--description", "Notebooks")
+		clitest.SetupConfig(t, client, root)
+		buf := new(bytes.Buffer)
+		cmd.SetOut(buf)
+		err := cmd.Execute()
+		require.NoError(t, err)
+		require.Contains(t, buf.String(), "data-science")
+
+		ctx, cancel := testutil.Context(t)
+		defer cancel()
+		org, err := client.OrganizationByName(ctx, codersdk.Me, "data-science")
+		require.NoError(t, err)
+		require.Equal(t, "Notebooks", org.Description)
+
+		cmd, root = clitest.New(t, "organizations", "switch", "data-science")
+		clitest.SetupConfig(t, client, root)
+		err = cmd.Execute()
+		require.NoError(t, err)
+
+		selected, err := root.Organization().Read()
+		require.NoError(t, err)
+		require.Equal(t, org.ID.String(), selected)
+
+		cmd, root = clitest.New(t, "organizations", "ls", "-o", "json")
+		clitest.SetupConfig(t, client, root)
+		buf = new(bytes.Buffer)
+		cmd.SetOut(buf)
+		err = cmd.Execute()
+		require.NoError(t, err)
+		require.Contains(t, buf.String(), org.ID.String())
+
+		cmd, root = clitest.New(t, "organizations", "edit", "data-science", "--name", "research")
+		clitest.SetupConfig(t, client, root)
+		err = cmd.Execute()
+		require.NoError(t, err)
+
+		org, err = client.Organization(ctx, org.ID)
+		require.NoError(t, err)
+		require.Equal(t, "research", org.Name)
+	})
+
+	t.Run("Members", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		first := coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := testutil.Context(t)
+		defer cancel()
+		org, err := client.CreateOrganization(ctx, codersdk.CreateOrganizationRequest{
+			Name: "data-science",
+		})
+		require.NoError(t, err)
+		_, err = client.CreateUser(ctx, codersdk.CreateUserRequest{
+			Email:          "alice@coder.com",
+			Username:       "alice",
+			Password:       "SomeSecurePassword!",
+			OrganizationID: first.OrganizationID,
+		})
+		require.NoError(t, err)
+
+		// Members are managed in the selected organization.
+		cmd, root := clitest.New(t, "organizations", "members", "add", "alice")
+		clitest.SetupConfig(t, client, root)
+		err = root.Organization().Write(org.ID.String())
+		require.NoError(t, err)
+		err = cmd.Execute()
+		require.NoError(t, err)
+
+		cmd, root = clitest.New(t, "organizations", "members", "ls")
+		clitest.SetupConfig(t, client, root)
+		err = root.Organization().Write(org.ID.String())
+		require.NoError(t, err)
+		buf := new(bytes.Buffer)
+		cmd.SetOut(buf)
+		err = cmd.Execute()
+		require.NoError(t, err)
+		require.Contains(t, buf.String(), "alice@coder.com")
+
+		cmd, root = clitest.New(t, "organizations", "members", "rm", "alice")
+		clitest.SetupConfig(t, client, root)
+		err = root.Organization().Write(org.ID.String())
+		require.NoError(t, err)
+		err = cmd.Execute()
+		require.NoError(t, err)
+
+		members, err := client.OrganizationMembers(ctx, org.ID)
+		require.NoError(t, err)
+		require.Len(t, members, 1)
+	})
+
+	t.Run("NotAMember", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		_ = coderdtest.CreateFirstUser(t, client)
+
+		cmd, root := clitest.New(t, "organizations", "members", "ls")
+		clitest.SetupConfig(t, client, root)
+		err := root.Organization().Write("00000000-0000-0000-0000-000000000000")
+		require.NoError(t, err)
+		err = cmd.Execute()
+		require.ErrorContains(t, err, "coder organizations switch")
+	})
+}
@@ -55,3 +55,20 @@ func getParameterValueFromMapOrInput(cmd *cobra.Command, parameterMap map[string
 	}
 	return parameterValue, nil
 }
+
+// Returns a rich parameter value from a given map, if the map exists, else takes input from the user.
+// Parameters absent from the map use their default value.
+func getWorkspaceBuildParameterValueFromMapOrInput(cmd *cobra.Command, parameterMap map[string]string, templateVersionParameter codersdk.TemplateVersionParameter) (string, error) {
+	if parameterMap == nil {
+		return cliui.RichParameter(cmd, templateVersionParameter)
+	}
+	parameterValue, ok := parameterMap[templateVersionParameter.Name]
+	if !ok {
+		parameterValue = templateVersionParameter.DefaultValue
+	}
+	err := codersdk.ValidateWorkspaceBuildParameter(templateVersionParameter, parameterValue)
+	if err != nil {
+		return "", xerrors.Errorf("Invalid value for parameter %q: %w", templateVersionParameter.Name, err)
+	}
+	return parameterValue, nil
+}
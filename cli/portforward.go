@@ -1,18 +1,25 @@
 package cli
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
+	"math"
 	"net"
 	"os"
 	"os/signal"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"syscall"
+	"time"
 
+	"github.com/charmbracelet/lipgloss"
 	"github.com/pion/udp"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 	"golang.org/x/xerrors"
 
 	"github.com/coder/coder/agent"
@@ -23,8 +30,12 @@ import (
 
 func portForward() *cobra.Command {
 	var (
-		tcpForwards []string // <port>:<port>
-		udpForwards []string // <port>:<port>
+		tcpForwards  []string // <port>:<port>
+		udpForwards  []string // <port>:<port>
+		auto         bool
+		autoInclude  []string // <port>-<port>
+		autoExclude  []string // <port>-<port>
+		autoInterval time.Duration
 	)
 	cmd := &cobra.Command{
 		Use:     "port-forward <workspace>",
@@ -48,6 +59,10 @@ func portForward() *cobra.Command {
 				Description: "Port forward multiple ports (TCP or UDP) in condensed syntax",
 				Command:     "coder port-forward <workspace> --tcp 8080,9000:3000,9090-9092,10000-10002:10010-10012",
 			},
+			example{
+				Description: "Forward ports as they're opened in the workspace, except for ports 5432 and 6379",
+				Command:     "coder port-forward <workspace> --auto --auto-exclude 5432,6379",
+			},
 		),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx, cancel := context.WithCancel(cmd.Context())
@@ -57,7 +72,21 @@ func portForward() *cobra.Command {
 			if err != nil {
 				return xerrors.Errorf("parse port-forward specs: %w", err)
 			}
-			if len(specs) == 0 {
+			include, err := parsePortRanges(autoInclude)
+			if err != nil {
+				return xerrors.Errorf("parse --auto-include: %w", err)
+			}
+			exclude, err := parsePortRanges(autoExclude)
+			if err != nil {
+				return xerrors.Errorf("parse --auto-exclude: %w", err)
+			}
+			if !auto && (len(include) > 0 || len(exclude) > 0) {
+				return xerrors.New("--auto-include and --auto-exclude require --auto")
+			}
+			if auto && autoInterval <= 0 {
+				return xerrors.New("--auto-interval must be positive")
+			}
+			if len(specs) == 0 && !auto {
 				err = cmd.Help()
 				if err != nil {
 					return xerrors.Errorf("generate help output: %w", err)
@@ -100,6 +129,11 @@ func portForward() *cobra.Command {
 			}
 			defer conn.Close()
 
+			// Everything is written through out, so the table of automatic
+			// forwards is only redrawn in place while it's the last output.
+			out := &lineCountingWriter{w: cmd.OutOrStderr()}
+			tty := isTTYWriter(cmd, cmd.OutOrStderr)
+
 			// Start all listeners.
 			var (
 				wg                = new(sync.WaitGroup)
@@ -116,7 +150,7 @@ func portForward() *cobra.Command {
 			defer closeAllListeners()
 
 			for i, spec := range specs {
-				l, err := listenAndPortForward(ctx, cmd, conn, wg, spec)
+				l, err := listenAndPortForward(ctx, out, conn, wg, spec)
 				if err != nil {
 					return err
 				}
@@ -137,7 +171,7 @@ func portForward() *cobra.Command {
 				case <-ctx.Done():
 					closeErr = ctx.Err()
 				case <-sigs:
-					_, _ = fmt.Fprintln(cmd.OutOrStderr(), "\nReceived signal, closing all listeners and active connections")
+					_, _ = fmt.Fprintln(out, "\nReceived signal, closing all listeners and active connections")
 				}
 
 				cancel()
@@ -145,7 +179,15 @@ func portForward() *cobra.Command {
 			}()
 
 			conn.AwaitReachable(ctx)
-			_, _ = fmt.Fprintln(cmd.OutOrStderr(), "Ready!")
+			_, _ = fmt.Fprintln(out, "Ready!")
+			if auto {
+				forwarder := newAutoPortForwarder(out, tty, conn, wg, include, exclude, specs)
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					forwarder.run(ctx, autoInterval)
+				}()
+			}
 			wg.Wait()
 			return closeErr
 		},
@@ -153,11 +195,15 @@ func portForward() *cobra.Command {
 
 	cliflag.StringArrayVarP(cmd.Flags(), &tcpForwards, "tcp", "p", "CODER_PORT_FORWARD_TCP", nil, "Forward TCP port(s) from the workspace to the local machine")
 	cliflag.StringArrayVarP(cmd.Flags(), &udpForwards, "udp", "", "CODER_PORT_FORWARD_UDP", nil, "Forward UDP port(s) from the workspace to the local machine. The UDP connection has TCP-like semantics to support stateful UDP protocols")
+	cliflag.BoolVarP(cmd.Flags(), &auto, "auto", "", "CODER_PORT_FORWARD_AUTO", false, "Forward TCP ports as processes in the workspace start listening on them, to the same local port or the next free one. Forwards are closed when the ports are closed")
+	cliflag.StringArrayVarP(cmd.Flags(), &autoInclude, "auto-include", "", "CODER_PORT_FORWARD_AUTO_INCLUDE", nil, "Only forward workspace ports in these ports or ranges with --auto, e.g. 3000-3999,8080")
+	cliflag.StringArrayVarP(cmd.Flags(), &autoExclude, "auto-exclude", "", "CODER_PORT_FORWARD_AUTO_EXCLUDE", nil, "Don't forward workspace ports in these ports or ranges with --auto")
+	cliflag.DurationVarP(cmd.Flags(), &autoInterval, "auto-interval", "", "CODER_PORT_FORWARD_AUTO_INTERVAL", 2*time.Second, "How often to check for opened and closed ports in the workspace with --auto")
 	return cmd
 }
 
-func listenAndPortForward(ctx context.Context, cmd *cobra.Command, conn *codersdk.AgentConn, wg *sync.WaitGroup, spec portForwardSpec) (net.Listener, error) {
-	_, _ = fmt.Fprintf(cmd.OutOrStderr(), "Forwarding '%v://%v' locally to '%v://%v' in the workspace\n", spec.listenNetwork, spec.listenAddress, spec.dialNetwork, spec.dialAddress)
+func listenAndPortForward(ctx context.Context, out io.Writer, conn *codersdk.AgentConn, wg *sync.WaitGroup, spec portForwardSpec) (net.Listener, error) {
+	_, _ = fmt.Fprintf(out, "Forwarding '%v://%v' locally to '%v://%v' in the workspace\n", spec.listenNetwork, spec.listenAddress, spec.dialNetwork, spec.dialAddress)
 
 	var (
 		l   net.Listener
@@ -189,7 +235,13 @@ func listenAndPortForward(ctx context.Context, cmd *cobra.Command, conn *codersd
 	if err != nil {
 		return nil, xerrors.Errorf("listen '%v://%v': %w", spec.listenNetwork, spec.listenAddress, err)
 	}
+	forwardConnections(ctx, out, conn, wg, l, spec)
+	return l, nil
+}
 
+// forwardConnections accepts connections on the listener and forwards them to
+// the workspace until the listener is closed.
+func forwardConnections(ctx context.Context, out io.Writer, conn *codersdk.AgentConn, wg *sync.WaitGroup, l net.Listener, spec portForwardSpec) {
 	wg.Add(1)
 	go func(spec portForwardSpec) {
 		defer wg.Done()
@@ -200,8 +252,8 @@ func listenAndPortForward(ctx context.Context, cmd *cobra.Command, conn *codersd
 				if xerrors.Is(err, net.ErrClosed) {
 					return
 				}
-				_, _ = fmt.Fprintf(cmd.OutOrStderr(), "Error accepting connection from '%v://%v': %v\n", spec.listenNetwork, spec.listenAddress, err)
-				_, _ = fmt.Fprintln(cmd.OutOrStderr(), "Killing listener")
+				_, _ = fmt.Fprintf(out, "Error accepting connection from '%v://%v': %v\n", spec.listenNetwork, spec.listenAddress, err)
+				_, _ = fmt.Fprintln(out, "Killing listener")
 				return
 			}
 
@@ -209,7 +261,7 @@ func listenAndPortForward(ctx context.Context, cmd *cobra.Command, conn *codersd
 				defer netConn.Close()
 				remoteConn, err := conn.DialContext(ctx, spec.dialNetwork, spec.dialAddress)
 				if err != nil {
-					_, _ = fmt.Fprintf(cmd.OutOrStderr(), "Failed to dial '%v://%v' in workspace: %s\n", spec.dialNetwork, spec.dialAddress, err)
+					_, _ = fmt.Fprintf(out, "Failed to dial '%v://%v' in workspace: %s\n", spec.dialNetwork, spec.dialAddress, err)
 					return
 				}
 				defer remoteConn.Close()
@@ -218,8 +270,6 @@ func listenAndPortForward(ctx context.Context, cmd *cobra.Command, conn *codersd
 			}(netConn)
 		}
 	}(spec)
-
-	return l, nil
 }
 
 type portForwardSpec struct {
@@ -363,3 +413,297 @@ func parsePortRange(in string) ([]uint16, error) {
 	}
 	return ports, nil
 }
+
+type portRange struct {
+	start, end uint16
+}
+
+func (r portRange) contains(port uint16) bool {
+	return port >= r.start && port <= r.end
+}
+
+// parsePortRanges parses comma-separated ports and port ranges, e.g.
+// "3000-3999,8080".
+func parsePortRanges(specs []string) ([]portRange, error) {
+	var ranges []portRange
+	for _, specEntry := range specs {
+		for _, spec := range strings.Split(specEntry, ",") {
+			startStr, endStr, isRange := strings.Cut(spec, "-")
+			start, err := parsePort(startStr)
+			if err != nil {
+				return nil, xerrors.Errorf("parse port range %q: %w", spec, err)
+			}
+			end := start
+			if isRange {
+				end, err = parsePort(endStr)
+				if err != nil {
+					return nil, xerrors.Errorf("parse port range %q: %w", spec, err)
+				}
+				if end < start {
+					return nil, xerrors.Errorf("range end port %v is less than start port %v", end, start)
+				}
+			}
+			ranges = append(ranges, portRange{start: start, end: end})
+		}
+	}
+	return ranges, nil
+}
+
+// autoPortForwarder forwards workspace ports as processes start listening on
+// them, and stops forwarding them when they're closed.
+type autoPortForwarder struct {
+	out *lineCountingWriter
+	// tty is whether out is a terminal, so the table can be redrawn in place.
+	tty  bool
+	conn *codersdk.AgentConn
+	wg   *sync.WaitGroup
+	// include and exclude filter the ports that are forwarded. All ports are
+	// included if include is empty.
+	include []portRange
+	exclude []portRange
+	// skip contains the workspace ports that are forwarded explicitly.
+	skip     map[uint16]struct{}
+	forwards map[uint16]*autoPortForward
+	// failed contains the ports that couldn't be forwarded, so the error is
+	// only printed once while they're open.
+	failed map[uint16]struct{}
+	// tableLines is the height of the last table drawn, and tableEnd is the
+	// number of lines written to out after it was drawn. The table is only
+	// redrawn in place when nothing was written below it.
+	tableLines int
+	tableEnd   int
+}
+
+type autoPortForward struct {
+	listener      net.Listener
+	LocalAddress  string `table:"local address"`
+	WorkspacePort uint16 `table:"workspace port"`
+	Process       string `table:"process"`
+}
+
+func newAutoPortForwarder(out *lineCountingWriter, tty bool, conn *codersdk.AgentConn, wg *sync.WaitGroup, include, exclude []portRange, specs []portForwardSpec) *autoPortForwarder {
+	skip := map[uint16]struct{}{}
+	for _, spec := range specs {
+		if spec.dialNetwork != "tcp" {
+			continue
+		}
+		_, port, err := net.SplitHostPort(spec.dialAddress)
+		if err != nil {
+			continue
+		}
+		if p, err := parsePort(port); err == nil {
+			skip[p] = struct{}{}
+		}
+	}
+	return &autoPortForwarder{
+		out:      out,
+		tty:      tty,
+		conn:     conn,
+		wg:       wg,
+		include:  include,
+		exclude:  exclude,
+		skip:     skip,
+		forwards: map[uint16]*autoPortForward{},
+		failed:   map[uint16]struct{}{},
+	}
+}
+
+// run polls the listening ports in the workspace until the context is
+// canceled, then closes all of its listeners.
+func (f *autoPortForwarder) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	defer func() {
+		for _, forward := range f.forwards {
+			_ = forward.listener.Close()
+		}
+	}()
+
+	f.draw()
+	for {
+		if f.poll(ctx) {
+			f.draw()
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll starts and stops forwards to match the listening ports in the
+// workspace. It returns whether the forwards changed.
+func (f *autoPortForwarder) poll(ctx context.Context) bool {
+	res, err := f.conn.ListeningPorts(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			_, _ = fmt.Fprintf(f.out, "Failed to list listening ports in the workspace: %s\n", err)
+		}
+		return false
+	}
+	listening := map[uint16]codersdk.ListeningPort{}
+	for _, port := range res.Ports {
+		if port.Network != codersdk.ListeningPortNetworkTCP || !f.shouldForward(port.Port) {
+			continue
+		}
+		listening[port.Port] = port
+	}
+
+	changed := false
+	for port, forward := range f.forwards {
+		if _, ok := listening[port]; ok {
+			continue
+		}
+		_ = forward.listener.Close()
+		delete(f.forwards, port)
+		changed = true
+	}
+	for port := range f.failed {
+		if _, ok := listening[port]; !ok {
+			delete(f.failed, port)
+		}
+	}
+	for port, listeningPort := range listening {
+		if _, ok := f.forwards[port]; ok {
+			continue
+		}
+		if _, ok := f.failed[port]; ok {
+			continue
+		}
+		l, err := listenNextFreePort(port)
+		if err != nil {
+			f.failed[port] = struct{}{}
+			_, _ = fmt.Fprintf(f.out, "Failed to forward port %d in the workspace: %s\n", port, err)
+			continue
+		}
+		forwardConnections(ctx, f.out, f.conn, f.wg, l, portForwardSpec{
+			listenNetwork: "tcp",
+			listenAddress: l.Addr().String(),
+			dialNetwork:   "tcp",
+			dialAddress:   fmt.Sprintf("127.0.0.1:%d", port),
+		})
+		f.forwards[port] = &autoPortForward{
+			listener:      l,
+			LocalAddress:  l.Addr().String(),
+			WorkspacePort: port,
+			Process:       listeningPort.ProcessName,
+		}
+		changed = true
+	}
+	return changed
+}
+
+func (f *autoPortForwarder) shouldForward(port uint16) bool {
+	if _, ok := f.skip[port]; ok {
+		return false
+	}
+	included := len(f.include) == 0
+	for _, r := range f.include {
+		if r.contains(port) {
+			included = true
+			break
+		}
+	}
+	if !included {
+		return false
+	}
+	for _, r := range f.exclude {
+		if r.contains(port) {
+			return false
+		}
+	}
+	return true
+}
+
+// draw prints the table of active forwards. On a terminal, the previous
+// table is replaced.
+func (f *autoPortForwarder) draw() {
+	rows := make([]autoPortForward, 0, len(f.forwards))
+	for _, forward := range f.forwards {
+		rows = append(rows, *forward)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].WorkspacePort < rows[j].WorkspacePort
+	})
+	text := "Waiting for ports to be opened in the workspace..."
+	if len(rows) > 0 {
+		table, err := cliui.DisplayTable(rows, "", nil)
+		if err != nil {
+			_, _ = fmt.Fprintf(f.out, "Failed to display forwarded ports: %s\n", err)
+			return
+		}
+		text = table
+	}
+
+	f.out.mut.Lock()
+	defer f.out.mut.Unlock()
+	if f.tty && f.tableLines > 0 && f.out.lines == f.tableEnd {
+		// Move the cursor to the start of the previous table and clear it.
+		_, _ = fmt.Fprintf(f.out.w, "\033[%dA\033[J", f.tableLines)
+		f.out.lines -= f.tableLines
+	}
+	_, _ = fmt.Fprintln(f.out.w, text)
+	f.tableLines = terminalLines(text, f.out.width())
+	f.out.lines += f.tableLines
+	f.tableEnd = f.out.lines
+}
+
+// lineCountingWriter counts the lines written to it.
+type lineCountingWriter struct {
+	mut   sync.Mutex
+	w     io.Writer
+	lines int
+}
+
+func (w *lineCountingWriter) Write(p []byte) (int, error) {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+	n, err := w.w.Write(p)
+	w.lines += bytes.Count(p[:n], []byte("\n"))
+	return n, err
+}
+
+// width returns the width of the terminal written to, or zero if it's not a
+// terminal.
+func (w *lineCountingWriter) width() int {
+	file, ok := w.w.(*os.File)
+	if !ok {
+		return 0
+	}
+	width, _, err := term.GetSize(int(file.Fd()))
+	if err != nil {
+		return 0
+	}
+	return width
+}
+
+// terminalLines returns the number of lines text takes up when printed with
+// a trailing newline on a terminal of the given width. Lines longer than the
+// width wrap, unless the width is zero.
+func terminalLines(text string, width int) int {
+	lines := 0
+	for _, line := range strings.Split(text, "\n") {
+		lineWidth := lipgloss.Width(line)
+		if width <= 0 || lineWidth <= width {
+			lines++
+			continue
+		}
+		lines += (lineWidth + width - 1) / width
+	}
+	return lines
+}
+
+// listenNextFreePort listens on the port on localhost, or the next free port
+// after it.
+func listenNextFreePort(port uint16) (net.Listener, error) {
+	var err error
+	for p := int(port); p <= math.MaxUint16 && p < int(port)+100; p++ {
+		var l net.Listener
+		l, err = net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", p))
+		if err == nil {
+			return l, nil
+		}
+	}
+	return nil, xerrors.Errorf("no free local port found: %w", err)
+}
@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"bytes"
 	"fmt"
 	"strings"
 	"testing"
@@ -88,3 +89,69 @@ func Test_parsePortForwards(t *testing.T) {
 		})
 	}
 }
+
+func Test_autoPortForwarderShouldForward(t *testing.T) {
+	t.Parallel()
+
+	include, err := parsePortRanges([]string{"3000-3999,8080"})
+	require.NoError(t, err)
+	exclude, err := parsePortRanges([]string{"3306"})
+	require.NoError(t, err)
+	specs, err := parsePortForwards([]string{"9000:3001"}, nil)
+	require.NoError(t, err)
+	f := newAutoPortForwarder(nil, false, nil, nil, include, exclude, specs)
+
+	for port, want := range map[uint16]bool{
+		3000: true,
+		3999: true,
+		8080: true,
+		// Forwarded explicitly.
+		3001: false,
+		3306: false,
+		4000: false,
+		8081: false,
+	} {
+		require.Equal(t, want, f.shouldForward(port), port)
+	}
+
+	_, err = parsePortRanges([]string{"4000-3000"})
+	require.Error(t, err)
+	_, err = parsePortRanges([]string{"http"})
+	require.Error(t, err)
+}
+
+func Test_autoPortForwarderDraw(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	out := &lineCountingWriter{w: &buf}
+	f := newAutoPortForwarder(out, true, nil, nil, nil, nil, nil)
+	clearTable := fmt.Sprintf("\033[%dA\033[J", 1)
+
+	f.draw()
+	require.Equal(t, 1, out.lines)
+	f.draw()
+	// The previous table is replaced.
+	require.Equal(t, 1, strings.Count(buf.String(), clearTable))
+	require.Equal(t, 1, out.lines)
+
+	// Output below the table isn't cleared, the table is drawn again below.
+	_, _ = fmt.Fprintln(out, "Failed to dial")
+	f.draw()
+	require.Equal(t, 1, strings.Count(buf.String(), clearTable))
+	require.Equal(t, 3, out.lines)
+	f.draw()
+	require.Equal(t, 2, strings.Count(buf.String(), clearTable))
+	require.Equal(t, 3, out.lines)
+}
+
+func Test_terminalLines(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, 1, terminalLines("abc", 0))
+	require.Equal(t, 2, terminalLines("abc\n", 80))
+	require.Equal(t, 2, terminalLines("abcdef", 3))
+	require.Equal(t, 4, terminalLines("abcdefg\nab", 3))
+	// Styles don't take up space.
+	require.Equal(t, 1, terminalLines("\033[1mabc\033[0m", 3))
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"regexp"
 	"sync"
 	"testing"
 
@@ -13,6 +14,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"cdr.dev/slog/sloggers/slogtest"
+
+	"github.com/coder/coder/agent"
 	"github.com/coder/coder/cli/clitest"
 	"github.com/coder/coder/coderd/coderdtest"
 	"github.com/coder/coder/codersdk"
@@ -418,3 +422,65 @@ type addr struct {
 	network string
 	addr    string
 }
+
+func TestPortForwardAuto(t *testing.T) {
+	t.Parallel()
+
+	client, workspace, agentToken := setupWorkspaceForAgent(t, nil)
+	agentClient := codersdk.New(client.URL)
+	agentClient.SetSessionToken(agentToken)
+	agentCloser := agent.New(agent.Options{
+		Client: agentClient,
+		Logger: slogtest.Make(t, nil).Named("agent"),
+	})
+	t.Cleanup(func() {
+		_ = agentCloser.Close()
+	})
+	coderdtest.AwaitWorkspaceAgents(t, client, workspace.ID)
+
+	// The agent runs on this machine, so this port is listening in the
+	// workspace and taken locally.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	remotePort := setupTestListener(t, l)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+	cmd, root := clitest.New(t, "port-forward", workspace.Name, "--auto", "--auto-include", remotePort, "--auto-interval", "100ms")
+	clitest.SetupConfig(t, client, root)
+	pty := ptytest.New(t)
+	cmd.SetIn(pty.Input())
+	cmd.SetOut(pty.Output())
+	cmd.SetErr(pty.Output())
+	cmdDone := tGo(t, func() {
+		err := cmd.ExecuteContext(ctx)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+	pty.ExpectMatch("Waiting for ports")
+
+	// The port is forwarded to the next free local port.
+	out := pty.ExpectMatch(remotePort)
+	matches := regexp.MustCompile(`127\.0\.0\.1:(\d+)`).FindAllStringSubmatch(out, -1)
+	require.NotEmpty(t, matches, out)
+	localAddress := matches[len(matches)-1][0]
+	require.NotEqual(t, "127.0.0.1:"+remotePort, localAddress)
+
+	c, err := net.Dial("tcp", localAddress)
+	require.NoError(t, err)
+	testDial(t, c)
+	_ = c.Close()
+
+	// The forward is closed with the port.
+	_ = l.Close()
+	pty.ExpectMatch("Waiting for ports")
+	require.Eventually(t, func() bool {
+		c, err := net.Dial("tcp", localAddress)
+		if err == nil {
+			_ = c.Close()
+		}
+		return err != nil
+	}, testutil.WaitShort, testutil.IntervalFast)
+
+	cancel()
+	<-cmdDone
+}
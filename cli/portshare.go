@@ -0,0 +1,377 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/pion/udp"
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/agent"
+	"github.com/coder/coder/cli/cliui"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/codersdk"
+)
+
+func portShare() *cobra.Command {
+	cmd := &cobra.Command{
+		Annotations: workspaceCommand,
+		Use:         "port",
+		Short:       "Share ports in your workspaces with other users",
+		Long: "Shared TCP ports are reached through the subdomain application proxy, so the deployment must have a wildcard " +
+			"access URL, and only HTTP services can be shared. Shared UDP ports are forwarded with \"coder port forward-udp\". " +
+			"Templates set the highest level ports may be shared with.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	cmd.AddCommand(
+		portShareCreate(),
+		portShareDelete(),
+		portShareList(),
+		portShareForwardUDP(),
+	)
+	return cmd
+}
+
+func portShareCreate() *cobra.Command {
+	var (
+		sharingLevel string
+		shareUDP     bool
+	)
+	cmd := &cobra.Command{
+		Use:   "share <workspace>[.<agent>] <port>",
+		Short: "Share a port with other users, or change the level it is shared with",
+		Args:  cobra.ExactArgs(2),
+		Example: formatExamples(
+			example{
+				Description: "Share port 8080 with every user signed in to the deployment",
+				Command:     "coder port share my-workspace 8080",
+			},
+			example{
+				Description: "Share port 3000 of the frontend agent with anyone who has the URL",
+				Command:     "coder port share my-workspace.frontend 3000 --level public",
+			},
+			example{
+				Description: "Share UDP port 9000 with every user signed in to the deployment",
+				Command:     "coder port share my-workspace 9000 --udp",
+			},
+		),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return err
+			}
+			port, err := parsePort(args[1])
+			if err != nil {
+				return err
+			}
+			workspace, agentName, err := workspaceAndAgentName(cmd, client, args[0])
+			if err != nil {
+				return err
+			}
+			share, err := client.UpsertWorkspaceAgentPortShare(ctx, workspace.ID, codersdk.UpsertWorkspaceAgentPortShareRequest{
+				AgentName:    agentName,
+				Port:         int32(port),
+				Protocol:     portShareProtocol(shareUDP),
+				SharingLevel: codersdk.WorkspaceAppSharingLevel(sharingLevel),
+			})
+			if err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Shared %s port %d of %s with the %s sharing level.\n", strings.ToUpper(string(share.Protocol)), port, cliui.Styles.Keyword.Render(workspace.Name+"."+agentName), share.SharingLevel)
+			if share.Protocol == codersdk.WorkspaceAgentPortShareProtocolUDP {
+				_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Forward it with: coder port forward-udp %s/%s.%s %d\n", workspace.OwnerName, workspace.Name, agentName, port)
+				return nil
+			}
+			portURL, err := sharedPortURL(cmd, client, workspace, share)
+			if err != nil {
+				return err
+			}
+			if portURL != "" {
+				_, _ = fmt.Fprintln(cmd.OutOrStdout(), portURL)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&sharingLevel, "level", "l", string(codersdk.WorkspaceAppSharingLevelAuthenticated),
+		"The users to share the port with: \"authenticated\" for every user signed in to the deployment, or \"public\" for anyone with the URL. \"owner\" makes it private again.")
+	cmd.Flags().BoolVar(&shareUDP, "udp", false, "Share a UDP port instead of a TCP port.")
+	return cmd
+}
+
+func portShareDelete() *cobra.Command {
+	var unshareUDP bool
+	cmd := &cobra.Command{
+		Use:     "unshare <workspace>[.<agent>] <port>",
+		Short:   "Stop sharing a port with other users",
+		Args:    cobra.ExactArgs(2),
+		Aliases: []string{"rm"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return err
+			}
+			port, err := parsePort(args[1])
+			if err != nil {
+				return err
+			}
+			workspace, agentName, err := workspaceAndAgentName(cmd, client, args[0])
+			if err != nil {
+				return err
+			}
+			protocol := portShareProtocol(unshareUDP)
+			err = client.DeleteWorkspaceAgentPortShare(ctx, workspace.ID, agentName, int32(port), protocol)
+			if err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "%s port %d of %s is no longer shared.\n", strings.ToUpper(string(protocol)), port, cliui.Styles.Keyword.Render(workspace.Name+"."+agentName))
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&unshareUDP, "udp", false, "Stop sharing a UDP port instead of a TCP port.")
+	return cmd
+}
+
+type portShareRow struct {
+	Agent        string `table:"agent"`
+	Port         int32  `table:"port"`
+	Protocol     string `table:"protocol"`
+	SharingLevel string `table:"sharing level"`
+	URL          string `table:"url"`
+}
+
+func portShareList() *cobra.Command {
+	var columns []string
+	cmd := &cobra.Command{
+		Use:     "ls <workspace>",
+		Short:   "List the shared ports of a workspace",
+		Args:    cobra.ExactArgs(1),
+		Aliases: []string{"list"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return err
+			}
+			workspace, err := namedWorkspace(cmd, client, args[0])
+			if err != nil {
+				return err
+			}
+			res, err := client.WorkspaceAgentPortShares(ctx, workspace.ID)
+			if err != nil {
+				return err
+			}
+			if len(res.Shares) == 0 {
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "No ports of %s are shared. Share one with \"coder port share\".\n", workspace.Name)
+				return nil
+			}
+
+			rows := make([]portShareRow, 0, len(res.Shares))
+			capped := false
+			for _, share := range res.Shares {
+				portURL, err := sharedPortURL(cmd, client, workspace, share)
+				if err != nil {
+					return err
+				}
+				level := string(share.SharingLevel)
+				if sharingLevelExceeds(share.SharingLevel, res.MaxSharingLevel) {
+					level = fmt.Sprintf("%s (%s)", share.SharingLevel, res.MaxSharingLevel)
+					capped = true
+				}
+				rows = append(rows, portShareRow{
+					Agent:        share.AgentName,
+					Port:         share.Port,
+					Protocol:     string(share.Protocol),
+					SharingLevel: level,
+					URL:          portURL,
+				})
+			}
+			out, err := cliui.DisplayTable(rows, "", columns)
+			if err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintln(cmd.OutOrStdout(), out)
+			if capped {
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "The template only allows ports to be shared with the %s sharing level, so ports shared above it are treated as %s.\n", res.MaxSharingLevel, res.MaxSharingLevel)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringArrayVarP(&columns, "column", "c", nil, "Specify a column to filter in the table. Available columns are: agent, port, protocol, sharing_level, url.")
+	return cmd
+}
+
+// workspaceAndAgentName returns the workspace and agent of a
+// <workspace>[.<agent>] argument. The agent may be omitted if the workspace
+// has a single agent.
+func workspaceAndAgentName(cmd *cobra.Command, client *codersdk.Client, in string) (codersdk.Workspace, string, error) {
+	workspaceName, agentName, _ := strings.Cut(in, ".")
+	workspace, err := namedWorkspace(cmd, client, workspaceName)
+	if err != nil {
+		return codersdk.Workspace{}, "", err
+	}
+	if agentName != "" {
+		return workspace, agentName, nil
+	}
+	agentNames := make([]string, 0)
+	for _, resource := range workspace.LatestBuild.Resources {
+		for _, agent := range resource.Agents {
+			agentNames = append(agentNames, agent.Name)
+		}
+	}
+	if len(agentNames) != 1 {
+		return codersdk.Workspace{}, "", xerrors.Errorf("specify the agent as %s.<agent>, the workspace has %d agents", workspace.Name, len(agentNames))
+	}
+	return workspace, agentNames[0], nil
+}
+
+// sharedPortURL returns the URL of a shared port in the subdomain proxy, or
+// an empty string if the deployment has no wildcard access URL or the port
+// isn't a TCP port.
+func sharedPortURL(cmd *cobra.Command, client *codersdk.Client, workspace codersdk.Workspace, share codersdk.WorkspaceAgentPortShare) (string, error) {
+	if share.Protocol == codersdk.WorkspaceAgentPortShareProtocolUDP {
+		return "", nil
+	}
+	appHost, err := client.GetAppHost(cmd.Context())
+	if err != nil {
+		return "", xerrors.Errorf("get app host: %w", err)
+	}
+	if appHost.Host == "" {
+		return "", nil
+	}
+	subdomain := httpapi.ApplicationURL{
+		Port:          uint16(share.Port),
+		AgentName:     share.AgentName,
+		WorkspaceName: workspace.Name,
+		Username:      workspace.OwnerName,
+	}.String()
+	return (&url.URL{
+		Scheme: client.URL.Scheme,
+		Host:   strings.Replace(appHost.Host, "*", subdomain, 1),
+		Path:   "/",
+	}).String(), nil
+}
+
+// sharingLevelExceeds returns whether a sharing level is more permissive than
+// another.
+func sharingLevelExceeds(level, other codersdk.WorkspaceAppSharingLevel) bool {
+	ranks := map[codersdk.WorkspaceAppSharingLevel]int{
+		codersdk.WorkspaceAppSharingLevelOwner:         0,
+		codersdk.WorkspaceAppSharingLevelAuthenticated: 1,
+		codersdk.WorkspaceAppSharingLevelPublic:        2,
+	}
+	return ranks[level] > ranks[other]
+}
+
+func portShareProtocol(udp bool) codersdk.WorkspaceAgentPortShareProtocol {
+	if udp {
+		return codersdk.WorkspaceAgentPortShareProtocolUDP
+	}
+	return codersdk.WorkspaceAgentPortShareProtocolTCP
+}
+
+func portShareForwardUDP() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "forward-udp [<owner>/]<workspace>[.<agent>] [<local-port>:]<port>",
+		Short: "Forward a UDP port shared with you to your local machine",
+		Args:  cobra.ExactArgs(2),
+		Example: formatExamples(
+			example{
+				Description: "Forward UDP port 9000 shared by alice to port 9000 on your local machine",
+				Command:     "coder port forward-udp alice/dev 9000",
+			},
+		),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := context.WithCancel(cmd.Context())
+			defer cancel()
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return err
+			}
+			owner, workspaceAndAgent, ok := strings.Cut(args[0], "/")
+			if !ok {
+				owner, workspaceAndAgent = codersdk.Me, args[0]
+			}
+			specs, err := parsePortForwards(nil, []string{args[1]})
+			if err != nil {
+				return xerrors.Errorf("parse port: %w", err)
+			}
+			if len(specs) != 1 {
+				return xerrors.New("forward one port at a time")
+			}
+			spec := specs[0]
+			_, rawPort, err := net.SplitHostPort(spec.dialAddress)
+			if err != nil {
+				return xerrors.Errorf("split %q: %w", spec.dialAddress, err)
+			}
+			port, err := parsePort(rawPort)
+			if err != nil {
+				return err
+			}
+
+			// Dial once up front, so access errors are reported before
+			// listening. The first local client uses this connection.
+			first, err := client.DialWorkspaceAgentPortShareUDP(ctx, owner, workspaceAndAgent, port)
+			if err != nil {
+				return xerrors.Errorf("dial shared port: %w", err)
+			}
+			defer func() {
+				if first != nil {
+					_ = first.Close()
+				}
+			}()
+
+			localAddr, err := net.ResolveUDPAddr("udp", spec.listenAddress)
+			if err != nil {
+				return xerrors.Errorf("resolve %q: %w", spec.listenAddress, err)
+			}
+			l, err := udp.Listen("udp", localAddr)
+			if err != nil {
+				return xerrors.Errorf("listen 'udp://%s': %w", spec.listenAddress, err)
+			}
+			go func() {
+				<-ctx.Done()
+				_ = l.Close()
+			}()
+			_, _ = fmt.Fprintf(cmd.OutOrStderr(), "Forwarding 'udp://%s' locally to UDP port %d of %s/%s\n", l.Addr(), port, owner, workspaceAndAgent)
+
+			var wg sync.WaitGroup
+			defer wg.Wait()
+			for {
+				netConn, err := l.Accept()
+				if err != nil {
+					if ctx.Err() != nil || xerrors.Is(err, net.ErrClosed) {
+						return nil
+					}
+					return xerrors.Errorf("accept: %w", err)
+				}
+				remote := first
+				first = nil
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					defer netConn.Close()
+					if remote == nil {
+						var err error
+						remote, err = client.DialWorkspaceAgentPortShareUDP(ctx, owner, workspaceAndAgent, port)
+						if err != nil {
+							_, _ = fmt.Fprintf(cmd.OutOrStderr(), "Failed to dial shared port: %s\n", err)
+							return
+						}
+					}
+					defer remote.Close()
+					agent.Bicopy(ctx, netConn, remote)
+				}()
+			}
+		},
+	}
+	return cmd
+}
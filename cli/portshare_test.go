@@ -0,0 +1,174 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"cdr.dev/slog/sloggers/slogtest"
+
+	"github.com/coder/coder/agent"
+	"github.com/coder/coder/cli/clitest"
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/util/ptr"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/provisionersdk/proto"
+	"github.com/coder/coder/pty/ptytest"
+	"github.com/coder/coder/testutil"
+)
+
+func TestPortShare(t *testing.T) {
+	t.Parallel()
+
+	client, workspace, _ := setupWorkspaceForAgent(t, func(agents []*proto.Agent) []*proto.Agent {
+		agents[0].Name = "dev"
+		return agents
+	})
+	ctx, _ := testutil.Context(t)
+	_, err := client.UpdateTemplateMeta(ctx, workspace.TemplateID, codersdk.UpdateTemplateMeta{
+		MaxPortSharingLevel: ptr.Ref(codersdk.WorkspaceAppSharingLevelAuthenticated),
+	})
+	require.NoError(t, err)
+
+	run := func(args ...string) (string, error) {
+		cmd, root := clitest.New(t, args...)
+		clitest.SetupConfig(t, client, root)
+		var out bytes.Buffer
+		cmd.SetOut(&out)
+		cmd.SetErr(&out)
+		err := cmd.ExecuteContext(ctx)
+		return out.String(), err
+	}
+
+	out, err := run("port", "share", workspace.Name, "8080")
+	require.NoError(t, err)
+	require.Contains(t, out, "Shared TCP port 8080")
+
+	shares, err := client.WorkspaceAgentPortShares(ctx, workspace.ID)
+	require.NoError(t, err)
+	require.Len(t, shares.Shares, 1)
+	require.Equal(t, "dev", shares.Shares[0].AgentName)
+	require.Equal(t, codersdk.WorkspaceAppSharingLevelAuthenticated, shares.Shares[0].SharingLevel)
+
+	// The template doesn't allow public ports.
+	_, err = run("port", "share", workspace.Name+".dev", "8080", "--level", "public")
+	require.Error(t, err)
+
+	out, err = run("port", "ls", workspace.Name)
+	require.NoError(t, err)
+	require.Contains(t, out, "8080")
+	require.Contains(t, out, "authenticated")
+
+	out, err = run("port", "share", workspace.Name, "9000", "--udp")
+	require.NoError(t, err)
+	require.Contains(t, out, "Shared UDP port 9000")
+	require.Contains(t, out, "coder port forward-udp")
+	out, err = run("port", "ls", workspace.Name)
+	require.NoError(t, err)
+	require.Contains(t, out, "udp")
+
+	_, err = run("port", "unshare", workspace.Name, "8080")
+	require.NoError(t, err)
+	// The UDP port is unshared separately.
+	_, err = run("port", "unshare", workspace.Name, "9000")
+	require.Error(t, err)
+	_, err = run("port", "unshare", workspace.Name, "9000", "--udp")
+	require.NoError(t, err)
+	out, err = run("port", "ls", workspace.Name)
+	require.NoError(t, err)
+	require.Contains(t, out, "No ports")
+
+	_, err = run("port", "unshare", workspace.Name, "8080")
+	require.Error(t, err)
+}
+
+func TestPortShareForwardUDP(t *testing.T) {
+	t.Parallel()
+
+	client, workspace, agentToken := setupWorkspaceForAgent(t, func(agents []*proto.Agent) []*proto.Agent {
+		agents[0].Name = "dev"
+		return agents
+	})
+	agentClient := codersdk.New(client.URL)
+	agentClient.SetSessionToken(agentToken)
+	agentCloser := agent.New(agent.Options{
+		Client: agentClient,
+		Logger: slogtest.Make(t, nil).Named("agent"),
+	})
+	t.Cleanup(func() {
+		_ = agentCloser.Close()
+	})
+	resources := coderdtest.AwaitWorkspaceAgents(t, client, workspace.ID)
+	agentName := resources[0].Agents[0].Name
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	// The agent runs on this machine, so it can reach the echo server.
+	echo, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = echo.Close()
+	})
+	go func() {
+		buf := make([]byte, 1024)
+		for {
+			n, addr, err := echo.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			_, _ = echo.WriteTo(buf[:n], addr)
+		}
+	}()
+	udpAddr, ok := echo.LocalAddr().(*net.UDPAddr)
+	require.True(t, ok)
+
+	_, err = client.UpdateTemplateMeta(ctx, workspace.TemplateID, codersdk.UpdateTemplateMeta{
+		MaxPortSharingLevel: ptr.Ref(codersdk.WorkspaceAppSharingLevelAuthenticated),
+	})
+	require.NoError(t, err)
+	_, err = client.UpsertWorkspaceAgentPortShare(ctx, workspace.ID, codersdk.UpsertWorkspaceAgentPortShareRequest{
+		AgentName:    agentName,
+		Port:         int32(udpAddr.Port),
+		Protocol:     codersdk.WorkspaceAgentPortShareProtocolUDP,
+		SharingLevel: codersdk.WorkspaceAppSharingLevelAuthenticated,
+	})
+	require.NoError(t, err)
+
+	// Find a free local port.
+	local, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	localPort := local.LocalAddr().(*net.UDPAddr).Port
+	require.NoError(t, local.Close())
+	cmd, root := clitest.New(t, "port", "forward-udp", workspace.OwnerName+"/"+workspace.Name, fmt.Sprintf("%d:%d", localPort, udpAddr.Port))
+	clitest.SetupConfig(t, client, root)
+	pty := ptytest.New(t)
+	cmd.SetOut(pty.Output())
+	cmd.SetErr(pty.Output())
+	cmdCtx, stop := context.WithCancel(ctx)
+	cmdDone := tGo(t, func() {
+		err := cmd.ExecuteContext(cmdCtx)
+		assert.NoError(t, err)
+	})
+	pty.ExpectMatch("Forwarding")
+
+	conn, err := net.Dial("udp", fmt.Sprintf("127.0.0.1:%d", localPort))
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("hello"))
+	require.NoError(t, err)
+	buf := make([]byte, 1024)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(testutil.WaitShort)))
+	n, err := conn.Read(buf)
+	require.NoError(t, err)
+	require.Equal(t, "hello", string(buf[:n]))
+
+	stop()
+	<-cmdDone
+}
@@ -0,0 +1,327 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/cli/cliui"
+	"github.com/coder/coder/codersdk"
+)
+
+func roles() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "roles",
+		Short:   "Manage custom roles",
+		Long:    "Custom roles grant permissions on top of the builtin roles, and are assigned to users like any other role.",
+		Aliases: []string{"role"},
+		Example: formatExamples(
+			example{
+				Description: "Create a site wide role that can read all templates and workspaces",
+				Command:     "coder roles create reviewer --site-permission template:read --site-permission workspace:read",
+			},
+			example{
+				Description: "Create a role in your current organization that can manage templates",
+				Command:     "coder roles create template-author --org --org-permission 'template:*'",
+			},
+			example{
+				Description: "List all custom roles",
+				Command:     "coder roles ls",
+			},
+			example{
+				Description: "Delete a role",
+				Command:     "coder roles rm reviewer",
+			},
+		),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	cmd.AddCommand(
+		createRole(),
+		listRoles(),
+		editRole(),
+		deleteRole(),
+	)
+
+	return cmd
+}
+
+type rolePermissionFlags struct {
+	site []string
+	org  []string
+	user []string
+}
+
+func (f *rolePermissionFlags) register(cmd *cobra.Command) {
+	const format = "formatted as \"<resource>:<action>\", prefix with \"!\" to deny. Can be specified multiple times."
+	cmd.Flags().StringArrayVar(&f.site, "site-permission", nil, "Grant a permission on all resources of the deployment, "+format)
+	cmd.Flags().StringArrayVar(&f.org, "org-permission", nil, "Grant a permission on the resources of the organization, "+format)
+	cmd.Flags().StringArrayVar(&f.user, "user-permission", nil, "Grant a permission on resources owned by the user, "+format)
+}
+
+func createRole() *cobra.Command {
+	var (
+		displayName string
+		inOrg       bool
+		permissions rolePermissionFlags
+	)
+	cmd := &cobra.Command{
+		Use:   "create <name>",
+		Short: "Create a custom role",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return xerrors.Errorf("create codersdk client: %w", err)
+			}
+
+			req := codersdk.CreateCustomRoleRequest{
+				Name:        args[0],
+				DisplayName: displayName,
+			}
+			if inOrg {
+				organization, err := CurrentOrganization(cmd, client)
+				if err != nil {
+					return xerrors.Errorf("get current organization: %w", err)
+				}
+				req.OrganizationID = &organization.ID
+			}
+			req.SitePermissions, err = parsePermissions(permissions.site)
+			if err != nil {
+				return err
+			}
+			req.OrgPermissions, err = parsePermissions(permissions.org)
+			if err != nil {
+				return err
+			}
+			req.UserPermissions, err = parsePermissions(permissions.user)
+			if err != nil {
+				return err
+			}
+
+			role, err := client.CreateCustomRole(cmd.Context(), req)
+			if err != nil {
+				return xerrors.Errorf("create role: %w", err)
+			}
+
+			_, err = fmt.Fprintf(cmd.OutOrStdout(), "Created role %s!\n", cliui.Styles.Keyword.Render(role.Name))
+			return err
+		},
+	}
+
+	cmd.Flags().StringVar(&displayName, "display-name", "", "Specify a human readable name for the role. Defaults to the name.")
+	cmd.Flags().BoolVar(&inOrg, "org", false, "Create the role in the current organization instead of site wide.")
+	permissions.register(cmd)
+	return cmd
+}
+
+type roleRow struct {
+	Name            string `table:"Name"`
+	DisplayName     string `table:"Display Name"`
+	SitePermissions string `table:"Site Permissions"`
+	OrgPermissions  string `table:"Org Permissions"`
+	UserPermissions string `table:"User Permissions"`
+}
+
+func listRoles() *cobra.Command {
+	var outputFormat string
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List custom roles",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return xerrors.Errorf("create codersdk client: %w", err)
+			}
+
+			customRoles, err := client.CustomRoles(cmd.Context())
+			if err != nil {
+				return xerrors.Errorf("list roles: %w", err)
+			}
+
+			out := ""
+			switch outputFormat {
+			case "table", "":
+				if len(customRoles) == 0 {
+					cmd.Println(cliui.Styles.Wrap.Render(
+						"No custom roles found.",
+					))
+					return nil
+				}
+
+				rows := make([]roleRow, 0, len(customRoles))
+				for _, role := range customRoles {
+					rows = append(rows, roleRow{
+						Name:            role.Name,
+						DisplayName:     role.DisplayName,
+						SitePermissions: formatPermissions(role.SitePermissions),
+						OrgPermissions:  formatPermissions(role.OrgPermissions),
+						UserPermissions: formatPermissions(role.UserPermissions),
+					})
+				}
+				out, err = cliui.DisplayTable(rows, "", nil)
+				if err != nil {
+					return xerrors.Errorf("render table: %w", err)
+				}
+			case "json":
+				outBytes, err := json.Marshal(customRoles)
+				if err != nil {
+					return xerrors.Errorf("marshal roles to JSON: %w", err)
+				}
+				out = string(outBytes)
+			default:
+				return xerrors.Errorf(`unknown output format %q, only "table" and "json" are supported`, outputFormat)
+			}
+
+			_, err = fmt.Fprintln(cmd.OutOrStdout(), out)
+			return err
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "table", "Output format. Available formats are: table, json.")
+	return cmd
+}
+
+func editRole() *cobra.Command {
+	var (
+		displayName string
+		inOrg       bool
+		permissions rolePermissionFlags
+	)
+	cmd := &cobra.Command{
+		Use:   "edit <name>",
+		Short: "Edit a custom role",
+		Long:  "Permission flags replace all existing permissions of the same kind. Kinds that aren't specified are left untouched.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return xerrors.Errorf("create codersdk client: %w", err)
+			}
+
+			name, err := roleName(cmd, client, args[0], inOrg)
+			if err != nil {
+				return err
+			}
+
+			var req codersdk.UpdateCustomRoleRequest
+			if cmd.Flags().Changed("display-name") {
+				req.DisplayName = &displayName
+			}
+			for _, kind := range []struct {
+				flag  string
+				raw   []string
+				field **[]codersdk.Permission
+			}{
+				{flag: "site-permission", raw: permissions.site, field: &req.SitePermissions},
+				{flag: "org-permission", raw: permissions.org, field: &req.OrgPermissions},
+				{flag: "user-permission", raw: permissions.user, field: &req.UserPermissions},
+			} {
+				if !cmd.Flags().Changed(kind.flag) {
+					continue
+				}
+				parsed, err := parsePermissions(kind.raw)
+				if err != nil {
+					return err
+				}
+				*kind.field = &parsed
+			}
+
+			role, err := client.UpdateCustomRole(cmd.Context(), name, req)
+			if err != nil {
+				return xerrors.Errorf("update role: %w", err)
+			}
+
+			_, err = fmt.Fprintf(cmd.OutOrStdout(), "Updated role %s!\n", cliui.Styles.Keyword.Render(role.Name))
+			return err
+		},
+	}
+
+	cmd.Flags().StringVar(&displayName, "display-name", "", "Specify a human readable name for the role.")
+	cmd.Flags().BoolVar(&inOrg, "org", false, "Edit the role of the same name in the current organization.")
+	permissions.register(cmd)
+	return cmd
+}
+
+func deleteRole() *cobra.Command {
+	var inOrg bool
+	cmd := &cobra.Command{
+		Use:     "delete <name>",
+		Aliases: []string{"rm"},
+		Short:   "Delete a custom role",
+		Long:    "Deleting a role removes it from every user it's assigned to.",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return xerrors.Errorf("create codersdk client: %w", err)
+			}
+
+			name, err := roleName(cmd, client, args[0], inOrg)
+			if err != nil {
+				return err
+			}
+
+			err = client.DeleteCustomRole(cmd.Context(), name)
+			if err != nil {
+				return xerrors.Errorf("delete role: %w", err)
+			}
+
+			_, err = fmt.Fprintf(cmd.OutOrStdout(), "Deleted role %s!\n", cliui.Styles.Keyword.Render(name))
+			return err
+		},
+	}
+
+	cmd.Flags().BoolVar(&inOrg, "org", false, "Delete the role of the same name in the current organization.")
+	return cmd
+}
+
+// roleName returns the name a role is assigned with. Organization roles are
+// suffixed with the ID of the current organization.
+func roleName(cmd *cobra.Command, client *codersdk.Client, name string, inOrg bool) (string, error) {
+	if !inOrg || strings.Contains(name, ":") {
+		return name, nil
+	}
+	organization, err := CurrentOrganization(cmd, client)
+	if err != nil {
+		return "", xerrors.Errorf("get current organization: %w", err)
+	}
+	return name + ":" + organization.ID.String(), nil
+}
+
+// parsePermissions parses permissions formatted as "<resource>:<action>",
+// optionally prefixed with "!" to negate them.
+func parsePermissions(raw []string) ([]codersdk.Permission, error) {
+	permissions := make([]codersdk.Permission, 0, len(raw))
+	for _, s := range raw {
+		negate := strings.HasPrefix(s, "!")
+		resource, action, ok := strings.Cut(strings.TrimPrefix(s, "!"), ":")
+		if !ok || resource == "" || action == "" {
+			return nil, xerrors.Errorf("invalid permission %q, must be formatted as \"<resource>:<action>\"", s)
+		}
+		permissions = append(permissions, codersdk.Permission{
+			Negate:       negate,
+			ResourceType: resource,
+			Action:       action,
+		})
+	}
+	return permissions, nil
+}
+
+func formatPermissions(permissions []codersdk.Permission) string {
+	formatted := make([]string, 0, len(permissions))
+	for _, permission := range permissions {
+		s := permission.ResourceType + ":" + permission.Action
+		if permission.Negate {
+			s = "!" + s
+		}
+		formatted = append(formatted, s)
+	}
+	return strings.Join(formatted, ", ")
+}
@@ -0,0 +1,111 @@
+package cli_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/cli/clitest"
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/testutil"
+)
+
+func TestRoles(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Site", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		_ = coderdtest.CreateFirstUser(t, client)
+
+		cmd, root := clitest.New(t, "roles", "ls")
+		clitest.SetupConfig(t, client, root)
+		buf := new(bytes.Buffer)
+		cmd.SetOut(buf)
+		err := cmd.Execute()
+		require.NoError(t, err)
+		require.Contains(t, buf.String(), "No custom roles found")
+
+		cmd, root = clitest.New(t, "roles", "create", "reviewer",
+			"--display-name", "Reviewer",
+			"--site-permission", "template:read",
+			"--site-permission", "!workspace:read",
+		)
+		clitest.SetupConfig(t, client, root)
+		buf = new(bytes.Buffer)
+		cmd.SetOut(buf)
+		err = cmd.Execute()
+		require.NoError(t, err)
+		require.Contains(t, buf.String(), "reviewer")
+
+		cmd, root = clitest.New(t, "roles", "ls")
+		clitest.SetupConfig(t, client, root)
+		buf = new(bytes.Buffer)
+		cmd.SetOut(buf)
+		err = cmd.Execute()
+		require.NoError(t, err)
+		require.Contains(t, buf.String(), "Reviewer")
+		require.Contains(t, buf.String(), "template:read, !workspace:read")
+
+		cmd, root = clitest.New(t, "roles", "edit", "reviewer", "--site-permission", "workspace:read")
+		clitest.SetupConfig(t, client, root)
+		err = cmd.Execute()
+		require.NoError(t, err)
+
+		ctx, cancel := testutil.Context(t)
+		defer cancel()
+		role, err := client.CustomRole(ctx, "reviewer")
+		require.NoError(t, err)
+		require.Equal(t, "Reviewer", role.DisplayName)
+		require.Equal(t, []codersdk.Permission{{ResourceType: "workspace", Action: "read"}}, role.SitePermissions)
+
+		cmd, root = clitest.New(t, "roles", "rm", "reviewer")
+		clitest.SetupConfig(t, client, root)
+		err = cmd.Execute()
+		require.NoError(t, err)
+
+		roles, err := client.CustomRoles(ctx)
+		require.NoError(t, err)
+		require.Empty(t, roles)
+	})
+
+	t.Run("Organization", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		owner := coderdtest.CreateFirstUser(t, client)
+
+		cmd, root := clitest.New(t, "roles", "create", "template-author", "--org", "--org-permission", "template:*")
+		clitest.SetupConfig(t, client, root)
+		err := cmd.Execute()
+		require.NoError(t, err)
+
+		ctx, cancel := testutil.Context(t)
+		defer cancel()
+		name := "template-author:" + owner.OrganizationID.String()
+		role, err := client.CustomRole(ctx, name)
+		require.NoError(t, err)
+		require.NotNil(t, role.OrganizationID)
+		require.Equal(t, owner.OrganizationID, *role.OrganizationID)
+
+		cmd, root = clitest.New(t, "roles", "rm", "template-author", "--org")
+		clitest.SetupConfig(t, client, root)
+		err = cmd.Execute()
+		require.NoError(t, err)
+
+		_, err = client.CustomRole(ctx, name)
+		require.Error(t, err)
+	})
+
+	t.Run("InvalidPermission", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		_ = coderdtest.CreateFirstUser(t, client)
+
+		cmd, root := clitest.New(t, "roles", "create", "broken", "--site-permission", "template")
+		clitest.SetupConfig(t, client, root)
+		err := cmd.Execute()
+		require.ErrorContains(t, err, "invalid permission")
+	})
+}
@@ -71,26 +71,35 @@ func Core() []*cobra.Command {
 	// Please re-sort this list alphabetically if you change it!
 	return []*cobra.Command{
 		configSSH(),
+		copyFiles(),
 		create(),
 		deleteWorkspace(),
 		dotfiles(),
+		execCommand(),
 		gitssh(),
+		insights(),
 		list(),
 		loadtest(),
 		login(),
 		logout(),
+		notifications(),
+		organizations(),
 		parameters(),
+		portShare(),
 		portForward(),
 		publickey(),
 		rename(),
 		resetPassword(),
+		roles(),
 		schedules(),
+		sessions(),
 		show(),
 		speedtest(),
 		ssh(),
 		start(),
 		state(),
 		stop(),
+		syncFiles(),
 		templates(),
 		tokens(),
 		update(),
@@ -102,7 +111,10 @@ func Core() []*cobra.Command {
 
 func AGPL() []*cobra.Command {
 	all := append(Core(), Server(deployment.NewViper(), func(_ context.Context, o *coderd.Options) (*coderd.API, io.Closer, error) {
-		api := coderd.New(o)
+		api, err := coderd.New(o)
+		if err != nil {
+			return nil, nil, err
+		}
 		return api, api, nil
 	}))
 	return all
@@ -352,14 +364,31 @@ func createAgentClient(cmd *cobra.Command) (*codersdk.Client, error) {
 }
 
 // CurrentOrganization returns the currently active organization for the authenticated user.
+// This is the organization selected with "coder organizations switch", or the
+// first organization of the user when none is selected.
 func CurrentOrganization(cmd *cobra.Command, client *codersdk.Client) (codersdk.Organization, error) {
 	orgs, err := client.OrganizationsByUser(cmd.Context(), codersdk.Me)
 	if err != nil {
-		return codersdk.Organization{}, nil
+		return codersdk.Organization{}, xerrors.Errorf("get organizations: %w", err)
+	}
+	if len(orgs) == 0 {
+		return codersdk.Organization{}, xerrors.New("You aren't a member of any organization.")
+	}
+
+	selected, err := createConfig(cmd).Organization().Read()
+	if err != nil && !os.IsNotExist(err) {
+		return codersdk.Organization{}, xerrors.Errorf("read selected organization: %w", err)
+	}
+	selected = strings.TrimSpace(selected)
+	if selected == "" {
+		return orgs[0], nil
 	}
-	// For now, we won't use the config to set this.
-	// Eventually, we will support changing using "coder switch <org>"
-	return orgs[0], nil
+	for _, org := range orgs {
+		if org.ID.String() == selected {
+			return org, nil
+		}
+	}
+	return codersdk.Organization{}, xerrors.Errorf("You aren't a member of the selected organization %q. Run \"coder organizations switch\" to select another one.", selected)
 }
 
 // namedWorkspace fetches and returns a workspace by an identifier, which may be either
@@ -555,6 +584,16 @@ func formatExamples(examples ...example) string {
 	return sb.String()
 }
 
+// ExitError exits the CLI with Code without printing an error. It's returned
+// by commands that propagate the exit code of a command they ran.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
 // FormatCobraError colorizes and adds "--help" docs to cobra commands.
 func FormatCobraError(err error, cmd *cobra.Command) string {
 	helpErrMsg := fmt.Sprintf("Run '%s --help' for usage.", cmd.CommandPath())
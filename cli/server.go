@@ -56,6 +56,7 @@ import (
 	"github.com/coder/coder/coderd/database/databasefake"
 	"github.com/coder/coder/coderd/database/migrations"
 	"github.com/coder/coder/coderd/devtunnel"
+	"github.com/coder/coder/coderd/filestore"
 	"github.com/coder/coder/coderd/gitauth"
 	"github.com/coder/coder/coderd/gitsshkey"
 	"github.com/coder/coder/coderd/httpapi"
@@ -406,6 +407,14 @@ func Server(vip *viper.Viper, newAPI func(context.Context, *coderd.Options) (*co
 				if err != nil {
 					return xerrors.Errorf("parse oidc oauth callback url: %w", err)
 				}
+				groupMapping, err := parseOIDCMapping(cfg.OIDC.GroupMapping.Value)
+				if err != nil {
+					return xerrors.Errorf("parse oidc group mapping: %w", err)
+				}
+				roleMapping, err := parseOIDCMapping(cfg.OIDC.RoleMapping.Value)
+				if err != nil {
+					return xerrors.Errorf("parse oidc role mapping: %w", err)
+				}
 				options.OIDCConfig = &coderd.OIDCConfig{
 					OAuth2Config: &oauth2.Config{
 						ClientID:     cfg.OIDC.ClientID.Value,
@@ -417,8 +426,13 @@ func Server(vip *viper.Viper, newAPI func(context.Context, *coderd.Options) (*co
 					Verifier: oidcProvider.Verifier(&oidc.Config{
 						ClientID: cfg.OIDC.ClientID.Value,
 					}),
-					EmailDomain:  cfg.OIDC.EmailDomain.Value,
-					AllowSignups: cfg.OIDC.AllowSignups.Value,
+					EmailDomain:         cfg.OIDC.EmailDomain.Value,
+					AllowSignups:        cfg.OIDC.AllowSignups.Value,
+					GroupField:          cfg.OIDC.GroupField.Value,
+					GroupMapping:        groupMapping,
+					CreateMissingGroups: cfg.OIDC.CreateMissingGroups.Value,
+					RoleField:           cfg.OIDC.RoleField.Value,
+					RoleMapping:         roleMapping,
 				}
 			}
 
@@ -486,6 +500,11 @@ func Server(vip *viper.Viper, newAPI func(context.Context, *coderd.Options) (*co
 				defer options.Pubsub.Close()
 			}
 
+			options.FileStore, err = configureFileStore(options.Database, cfg.FileStorage)
+			if err != nil {
+				return xerrors.Errorf("configure file storage: %w", err)
+			}
+
 			deploymentID, err := options.Database.GetDeploymentID(ctx)
 			if errors.Is(err, sql.ErrNoRows) {
 				err = nil
@@ -563,6 +582,18 @@ func Server(vip *viper.Viper, newAPI func(context.Context, *coderd.Options) (*co
 				}
 				defer closeWorkspacesFunc()
 
+				// Agents report stats when they change, at most once per
+				// refresh interval, so allow for a missed report.
+				agentStatsMaxAge := 2 * options.AgentStatsRefreshInterval
+				if agentStatsMaxAge == 0 {
+					agentStatsMaxAge = 20 * time.Minute
+				}
+				closeAgentStatsFunc, err := prometheusmetrics.AgentStats(ctx, logger.Named("agent_stats_metrics"), options.PrometheusRegistry, options.Database, 0, agentStatsMaxAge)
+				if err != nil {
+					return xerrors.Errorf("register agent stats prometheus metric: %w", err)
+				}
+				defer closeAgentStatsFunc()
+
 				//nolint:revive
 				defer serveHandler(ctx, logger, promhttp.InstrumentMetricHandler(
 					options.PrometheusRegistry, promhttp.HandlerFor(options.PrometheusRegistry, promhttp.HandlerOpts{}),
@@ -673,7 +704,7 @@ func Server(vip *viper.Viper, newAPI func(context.Context, *coderd.Options) (*co
 
 			autobuildPoller := time.NewTicker(cfg.AutobuildPollInterval.Value)
 			defer autobuildPoller.Stop()
-			autobuildExecutor := executor.New(ctx, options.Database, logger, autobuildPoller.C)
+			autobuildExecutor := executor.New(ctx, options.Database, logger, autobuildPoller.C).WithAuditor(&coderAPI.Auditor)
 			autobuildExecutor.Run()
 
 			// This is helpful for tests, but can be silently ignored.
@@ -1064,6 +1095,35 @@ func configureTLS(tlsMinVersion, tlsClientAuth string, tlsCertFiles, tlsKeyFiles
 }
 
 //nolint:revive // Ignore flag-parameter: parameter 'allowEveryone' seems to be a control flag, avoid control coupling (revive)
+func configureFileStore(db database.Store, cfg *codersdk.FileStorageConfig) (filestore.Store, error) {
+	switch cfg.Backend.Value {
+	case "", string(database.FileStorageDatabase):
+		return filestore.NewDatabase(db), nil
+	case string(database.FileStorageLocal):
+		return filestore.NewLocal(cfg.LocalPath.Value)
+	case string(database.FileStorageS3):
+		var endpoint *url.URL
+		if cfg.S3.Endpoint.Value != "" {
+			var err error
+			endpoint, err = url.Parse(cfg.S3.Endpoint.Value)
+			if err != nil {
+				return nil, xerrors.Errorf("parse s3 endpoint: %w", err)
+			}
+		}
+		return filestore.NewS3(filestore.S3Options{
+			Endpoint:        endpoint,
+			Region:          cfg.S3.Region.Value,
+			Bucket:          cfg.S3.Bucket.Value,
+			Prefix:          cfg.S3.Prefix.Value,
+			AccessKeyID:     cfg.S3.AccessKeyID.Value,
+			SecretAccessKey: cfg.S3.SecretAccessKey.Value,
+			UsePathStyle:    cfg.S3.UsePathStyle.Value,
+		})
+	default:
+		return nil, xerrors.Errorf("unknown backend %q: must be \"database\", \"local\" or \"s3\"", cfg.Backend.Value)
+	}
+}
+
 func configureGithubOAuth2(accessURL *url.URL, clientID, clientSecret string, allowSignups, allowEveryone bool, allowOrgs []string, rawTeams []string, enterpriseBaseURL string) (*coderd.GithubOAuth2Config, error) {
 	redirectURL, err := accessURL.Parse("/api/v2/users/oauth2/github/callback")
 	if err != nil {
@@ -1172,6 +1232,19 @@ func configureGithubOAuth2(accessURL *url.URL, clientID, clientSecret string, al
 	}, nil
 }
 
+// parseOIDCMapping parses values formatted as <oidc value>=<coder name>.
+func parseOIDCMapping(values []string) (map[string]string, error) {
+	mapping := make(map[string]string, len(values))
+	for _, value := range values {
+		from, to, ok := strings.Cut(value, "=")
+		if !ok || from == "" || to == "" {
+			return nil, xerrors.Errorf("mapping is formatted incorrectly. got %s; wanted <oidc value>=<coder name>", value)
+		}
+		mapping[from] = to
+	}
+	return mapping, nil
+}
+
 func serveHandler(ctx context.Context, logger slog.Logger, handler http.Handler, addr, name string) (closeFunc func()) {
 	logger.Debug(ctx, "http server listening", slog.F("addr", addr), slog.F("name", name))
 
@@ -0,0 +1,478 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mattn/go-isatty"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/agent/asciicast"
+	"github.com/coder/coder/cli/cliui"
+	"github.com/coder/coder/codersdk"
+)
+
+func sessions() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "sessions",
+		Short:   "Manage terminal sessions running in workspaces and play back recorded sessions",
+		Long:    "Terminal sessions opened from the dashboard keep running in the workspace after the browser disconnects, and can be reattached to from any machine. Sessions are recorded for templates with session recording enabled. Recordings can be watched by admins and auditors.",
+		Aliases: []string{"session"},
+		Example: formatExamples(
+			example{
+				Description: "List the terminal sessions running in a workspace",
+				Command:     "coder sessions ls my-workspace",
+			},
+			example{
+				Description: "Name a terminal session",
+				Command:     "coder sessions rename my-workspace 2b9a0c5e-1f4c-4c1e-8d4a-6f0e7c3b9d21 build",
+			},
+			example{
+				Description: "Reattach to a terminal session by ID or name",
+				Command:     "coder sessions attach my-workspace build",
+			},
+			example{
+				Description: "List recorded sessions in a workspace",
+				Command:     "coder sessions list --workspace alice/dev",
+			},
+			example{
+				Description: "Play back a session at twice the speed",
+				Command:     "coder sessions play 5c6bbc7e-4b7f-4b3c-9d3d-1b2d8c0f5e4a --speed 2",
+			},
+		),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	cmd.AddCommand(
+		listSessions(),
+		attachSession(),
+		renameSession(),
+		killSession(),
+		listSessionRecordings(),
+		playSession(),
+	)
+	return cmd
+}
+
+// sessionDetachKey detaches from a terminal session without ending it. It's
+// Ctrl+], which telnet uses to the same effect.
+const sessionDetachKey = 0x1d
+
+type sessionRow struct {
+	ID          string    `table:"ID"`
+	Name        string    `table:"Name"`
+	Command     string    `table:"Command"`
+	StartedAt   time.Time `table:"Started At"`
+	Connections int       `table:"Attached Clients"`
+}
+
+func listSessions() *cobra.Command {
+	cmd := &cobra.Command{
+		Annotations: workspaceCommand,
+		Use:         "ls <workspace>",
+		Short:       "List the terminal sessions running in a workspace, oldest first",
+		Args:        cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return xerrors.Errorf("create codersdk client: %w", err)
+			}
+			workspace, workspaceAgent, err := getWorkspaceAndAgent(cmd.Context(), cmd, client, codersdk.Me, args[0], false)
+			if err != nil {
+				return err
+			}
+			res, err := client.WorkspaceAgentReconnectingPTYSessions(cmd.Context(), workspaceAgent.ID)
+			if err != nil {
+				return xerrors.Errorf("list terminal sessions: %w", err)
+			}
+			if len(res.Sessions) == 0 {
+				cmd.Println(cliui.Styles.Wrap.Render(
+					fmt.Sprintf("No terminal sessions are running in %s.", workspace.Name),
+				))
+				return nil
+			}
+
+			rows := make([]sessionRow, 0, len(res.Sessions))
+			for _, session := range res.Sessions {
+				command := session.Command
+				if command == "" {
+					command = "(shell)"
+				}
+				rows = append(rows, sessionRow{
+					ID:          session.ID,
+					Name:        session.Name,
+					Command:     command,
+					StartedAt:   session.StartedAt,
+					Connections: session.Connections,
+				})
+			}
+
+			out, err := cliui.DisplayTable(rows, "", nil)
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintln(cmd.OutOrStdout(), out)
+			return err
+		},
+	}
+	return cmd
+}
+
+func attachSession() *cobra.Command {
+	cmd := &cobra.Command{
+		Annotations: workspaceCommand,
+		Use:         "attach <workspace> <id|name>",
+		Short:       "Attach to a terminal session running in a workspace. Press Ctrl+] to detach without ending the session",
+		Args:        cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := context.WithCancel(cmd.Context())
+			defer cancel()
+
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return xerrors.Errorf("create codersdk client: %w", err)
+			}
+			workspace, workspaceAgent, err := getWorkspaceAndAgent(ctx, cmd, client, codersdk.Me, args[0], false)
+			if err != nil {
+				return err
+			}
+			// Attaching to an unknown ID would start a new session instead.
+			session, err := findSession(ctx, client, workspace, workspaceAgent, args[0], args[1])
+			if err != nil {
+				return err
+			}
+			id := session.ID
+
+			conn, err := client.DialWorkspaceAgent(ctx, workspaceAgent.ID, &codersdk.DialWorkspaceAgentOptions{})
+			if err != nil {
+				return xerrors.Errorf("dial workspace agent: %w", err)
+			}
+			defer conn.Close()
+			if !conn.AwaitReachable(ctx) {
+				return xerrors.New("workspace agent is unreachable")
+			}
+
+			width, height := 80, 24
+			stdoutFile, validOut := cmd.OutOrStdout().(*os.File)
+			stdinFile, validIn := cmd.InOrStdin().(*os.File)
+			if validOut {
+				w, h, err := term.GetSize(int(stdoutFile.Fd()))
+				if err == nil {
+					width, height = w, h
+				}
+			}
+			ptyConn, err := conn.ReconnectingPTY(ctx, id, uint16(height), uint16(width), "")
+			if err != nil {
+				return xerrors.Errorf("attach to terminal session: %w", err)
+			}
+			defer ptyConn.Close()
+
+			var sendMutex sync.Mutex
+			send := func(req codersdk.ReconnectingPTYRequest) error {
+				data, err := json.Marshal(req)
+				if err != nil {
+					return err
+				}
+				sendMutex.Lock()
+				defer sendMutex.Unlock()
+				_, err = ptyConn.Write(data)
+				return err
+			}
+
+			if validOut && validIn && isatty.IsTerminal(stdoutFile.Fd()) {
+				state, err := term.MakeRaw(int(stdinFile.Fd()))
+				if err != nil {
+					return err
+				}
+				defer func() {
+					_ = term.Restore(int(stdinFile.Fd()), state)
+				}()
+
+				windowChange := listenWindowSize(ctx)
+				go func() {
+					for {
+						select {
+						case <-ctx.Done():
+							return
+						case <-windowChange:
+						}
+						width, height, err := term.GetSize(int(stdoutFile.Fd()))
+						if err != nil {
+							continue
+						}
+						_ = send(codersdk.ReconnectingPTYRequest{
+							Height: uint16(height),
+							Width:  uint16(width),
+						})
+					}
+				}()
+			}
+
+			detached := make(chan struct{})
+			go func() {
+				buffer := make([]byte, 1024)
+				for {
+					read, err := cmd.InOrStdin().Read(buffer)
+					if err != nil {
+						return
+					}
+					part := buffer[:read]
+					detach := bytes.IndexByte(part, sessionDetachKey)
+					if detach >= 0 {
+						part = part[:detach]
+					}
+					if len(part) > 0 {
+						err = send(codersdk.ReconnectingPTYRequest{
+							Data: string(part),
+						})
+						if err != nil {
+							return
+						}
+					}
+					if detach >= 0 {
+						close(detached)
+						_ = ptyConn.Close()
+						return
+					}
+				}
+			}()
+
+			// The agent closes the connection when the session ends.
+			_, _ = io.Copy(cmd.OutOrStdout(), ptyConn)
+			select {
+			case <-detached:
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "\r\nDetached from terminal session %s.\r\n", id)
+			default:
+			}
+			return nil
+		},
+	}
+	return cmd
+}
+
+// findSession returns the terminal session running in the workspace agent
+// with the given ID or name. IDs take precedence over names.
+func findSession(ctx context.Context, client *codersdk.Client, workspace codersdk.Workspace, workspaceAgent codersdk.WorkspaceAgent, workspaceArg, idOrName string) (codersdk.ReconnectingPTYSession, error) {
+	res, err := client.WorkspaceAgentReconnectingPTYSessions(ctx, workspaceAgent.ID)
+	if err != nil {
+		return codersdk.ReconnectingPTYSession{}, xerrors.Errorf("list terminal sessions: %w", err)
+	}
+	var named []codersdk.ReconnectingPTYSession
+	for _, session := range res.Sessions {
+		if session.ID == idOrName {
+			return session, nil
+		}
+		if session.Name == idOrName {
+			named = append(named, session)
+		}
+	}
+	switch len(named) {
+	case 0:
+		return codersdk.ReconnectingPTYSession{}, xerrors.Errorf("no terminal session %q is running in %s, see %q for running sessions", idOrName, workspace.Name, "coder sessions ls "+workspaceArg)
+	case 1:
+		return named[0], nil
+	default:
+		return codersdk.ReconnectingPTYSession{}, xerrors.Errorf("%d terminal sessions in %s are named %q, use the session ID instead", len(named), workspace.Name, idOrName)
+	}
+}
+
+func renameSession() *cobra.Command {
+	cmd := &cobra.Command{
+		Annotations: workspaceCommand,
+		Use:         "rename <workspace> <id|name> <new-name>",
+		Short:       "Name a terminal session running in a workspace, so it can be attached to by name. An empty name clears it",
+		Args:        cobra.ExactArgs(3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return xerrors.Errorf("create codersdk client: %w", err)
+			}
+			workspace, workspaceAgent, err := getWorkspaceAndAgent(cmd.Context(), cmd, client, codersdk.Me, args[0], false)
+			if err != nil {
+				return err
+			}
+			session, err := findSession(cmd.Context(), client, workspace, workspaceAgent, args[0], args[1])
+			if err != nil {
+				return err
+			}
+			_, err = client.UpdateWorkspaceAgentReconnectingPTYSession(cmd.Context(), workspaceAgent.ID, session.ID, codersdk.UpdateReconnectingPTYSessionRequest{
+				Name: args[2],
+			})
+			if err != nil {
+				return xerrors.Errorf("rename terminal session: %w", err)
+			}
+			if args[2] == "" {
+				_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Cleared the name of terminal session %s in %s.\n", session.ID, workspace.Name)
+				return nil
+			}
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Renamed terminal session %s in %s to %q.\n", session.ID, workspace.Name, args[2])
+			return nil
+		},
+	}
+	return cmd
+}
+
+func killSession() *cobra.Command {
+	cmd := &cobra.Command{
+		Annotations: workspaceCommand,
+		Use:         "kill <workspace> <id|name>",
+		Short:       "End a terminal session running in a workspace and disconnect its clients",
+		Args:        cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return xerrors.Errorf("create codersdk client: %w", err)
+			}
+			workspace, workspaceAgent, err := getWorkspaceAndAgent(cmd.Context(), cmd, client, codersdk.Me, args[0], false)
+			if err != nil {
+				return err
+			}
+			session, err := findSession(cmd.Context(), client, workspace, workspaceAgent, args[0], args[1])
+			if err != nil {
+				return err
+			}
+			err = client.DeleteWorkspaceAgentReconnectingPTYSession(cmd.Context(), workspaceAgent.ID, session.ID)
+			if err != nil {
+				return xerrors.Errorf("kill terminal session: %w", err)
+			}
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Killed terminal session %s in %s.\n", session.ID, workspace.Name)
+			return nil
+		},
+	}
+	return cmd
+}
+
+type sessionRecordingRow struct {
+	ID        string        `table:"ID"`
+	Workspace string        `table:"Workspace"`
+	Type      string        `table:"Type"`
+	Command   string        `table:"Command"`
+	StartedAt time.Time     `table:"Started At"`
+	Duration  time.Duration `table:"Duration"`
+}
+
+func listSessionRecordings() *cobra.Command {
+	var (
+		workspaceName string
+		username      string
+		limit         int
+	)
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"recordings"},
+		Short:   "List recorded sessions, newest first",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return xerrors.Errorf("create codersdk client: %w", err)
+			}
+
+			req := codersdk.WorkspaceSessionRecordingsRequest{
+				Pagination: codersdk.Pagination{Limit: limit},
+			}
+			if workspaceName != "" {
+				workspace, err := namedWorkspace(cmd, client, workspaceName)
+				if err != nil {
+					return xerrors.Errorf("get workspace: %w", err)
+				}
+				req.WorkspaceID = workspace.ID
+			}
+			if username != "" {
+				user, err := client.User(cmd.Context(), username)
+				if err != nil {
+					return xerrors.Errorf("get user: %w", err)
+				}
+				req.OwnerID = user.ID
+			}
+
+			recordings, err := client.WorkspaceSessionRecordings(cmd.Context(), req)
+			if err != nil {
+				return xerrors.Errorf("list session recordings: %w", err)
+			}
+			if len(recordings) == 0 {
+				cmd.Println(cliui.Styles.Wrap.Render(
+					"No recorded sessions found.",
+				))
+				return nil
+			}
+
+			rows := make([]sessionRecordingRow, 0, len(recordings))
+			for _, recording := range recordings {
+				command := recording.Command
+				if command == "" {
+					command = "(shell)"
+				}
+				rows = append(rows, sessionRecordingRow{
+					ID:        recording.ID.String(),
+					Workspace: recording.OwnerName + "/" + recording.WorkspaceName,
+					Type:      string(recording.Type),
+					Command:   command,
+					StartedAt: recording.StartedAt,
+					Duration:  recording.EndedAt.Sub(recording.StartedAt).Round(time.Second),
+				})
+			}
+
+			out, err := cliui.DisplayTable(rows, "", nil)
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintln(cmd.OutOrStdout(), out)
+			return err
+		},
+	}
+	cmd.Flags().StringVar(&workspaceName, "workspace", "", "Only list sessions in this workspace, as <workspace> or <owner>/<workspace>.")
+	cmd.Flags().StringVar(&username, "user", "", "Only list sessions in workspaces owned by this user.")
+	cmd.Flags().IntVar(&limit, "limit", 25, "The maximum number of sessions to list. Zero lists all sessions.")
+	return cmd
+}
+
+func playSession() *cobra.Command {
+	var (
+		speed         float64
+		idleTimeLimit time.Duration
+	)
+	cmd := &cobra.Command{
+		Use:   "play <id>",
+		Short: "Play back a recorded session in the terminal",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := uuid.Parse(args[0])
+			if err != nil {
+				return xerrors.Errorf("invalid session id %q: %w", args[0], err)
+			}
+			if speed <= 0 {
+				return xerrors.New("--speed must be positive")
+			}
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return xerrors.Errorf("create codersdk client: %w", err)
+			}
+
+			content, err := client.WorkspaceSessionRecordingContent(cmd.Context(), id)
+			if err != nil {
+				return xerrors.Errorf("get session recording: %w", err)
+			}
+			err = asciicast.Play(cmd.Context(), bytes.NewReader(content), cmd.OutOrStdout(), asciicast.PlayOptions{
+				Speed:         speed,
+				IdleTimeLimit: idleTimeLimit,
+			})
+			if err != nil {
+				return xerrors.Errorf("play session recording: %w", err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().Float64Var(&speed, "speed", 1, "Multiplies the playback speed.")
+	cmd.Flags().DurationVar(&idleTimeLimit, "idle-time-limit", 2*time.Second, "Caps the pause between output. Zero plays pauses at their original length.")
+	return cmd
+}
@@ -0,0 +1,192 @@
+package cli_test
+
+import (
+	"context"
+	"encoding/json"
+	"runtime"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"cdr.dev/slog/sloggers/slogtest"
+
+	"github.com/coder/coder/agent"
+	"github.com/coder/coder/cli/clitest"
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/pty/ptytest"
+	"github.com/coder/coder/testutil"
+)
+
+func TestSessions(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("ConPTY appears to be inconsistent on Windows.")
+	}
+
+	t.Run("List", func(t *testing.T) {
+		t.Parallel()
+		client, workspace, _, id := setupSession(t)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		cmd, root := clitest.New(t, "sessions", "ls", workspace.Name)
+		clitest.SetupConfig(t, client, root)
+		pty := ptytest.New(t)
+		cmd.SetOut(pty.Output())
+
+		cmdDone := tGo(t, func() {
+			err := cmd.ExecuteContext(ctx)
+			assert.NoError(t, err)
+		})
+		pty.ExpectMatch(id.String())
+		pty.ExpectMatch("/bin/bash")
+		<-cmdDone
+	})
+
+	t.Run("AttachAndDetach", func(t *testing.T) {
+		t.Parallel()
+		client, workspace, _, id := setupSession(t)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		cmd, root := clitest.New(t, "sessions", "attach", workspace.Name, id.String())
+		clitest.SetupConfig(t, client, root)
+		pty := ptytest.New(t)
+		cmd.SetIn(pty.Input())
+		cmd.SetOut(pty.Output())
+		cmd.SetErr(pty.Output())
+
+		cmdDone := tGo(t, func() {
+			err := cmd.ExecuteContext(ctx)
+			assert.NoError(t, err)
+		})
+		// Output from before attaching is replayed.
+		pty.ExpectMatch("hello-from-the-dashboard")
+		pty.WriteLine("\x1d")
+		pty.ExpectMatch("Detached")
+		<-cmdDone
+	})
+
+	t.Run("AttachUnknown", func(t *testing.T) {
+		t.Parallel()
+		client, workspace, _, _ := setupSession(t)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		cmd, root := clitest.New(t, "sessions", "attach", workspace.Name, "unknown")
+		clitest.SetupConfig(t, client, root)
+		err := cmd.ExecuteContext(ctx)
+		require.ErrorContains(t, err, "no terminal session")
+	})
+
+	t.Run("RenameAndKillByName", func(t *testing.T) {
+		t.Parallel()
+		client, workspace, agentID, id := setupSession(t)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		cmd, root := clitest.New(t, "sessions", "rename", workspace.Name, id.String(), "build")
+		clitest.SetupConfig(t, client, root)
+		err := cmd.ExecuteContext(ctx)
+		require.NoError(t, err)
+
+		res, err := client.WorkspaceAgentReconnectingPTYSessions(ctx, agentID)
+		require.NoError(t, err)
+		require.Len(t, res.Sessions, 1)
+		require.Equal(t, "build", res.Sessions[0].Name)
+
+		cmd, root = clitest.New(t, "sessions", "kill", workspace.Name, "build")
+		clitest.SetupConfig(t, client, root)
+		err = cmd.ExecuteContext(ctx)
+		require.NoError(t, err)
+
+		res, err = client.WorkspaceAgentReconnectingPTYSessions(ctx, agentID)
+		require.NoError(t, err)
+		require.Empty(t, res.Sessions)
+	})
+
+	t.Run("RenameInvalid", func(t *testing.T) {
+		t.Parallel()
+		client, workspace, _, id := setupSession(t)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		cmd, root := clitest.New(t, "sessions", "rename", workspace.Name, id.String(), "not a name")
+		clitest.SetupConfig(t, client, root)
+		err := cmd.ExecuteContext(ctx)
+		require.ErrorContains(t, err, "Invalid terminal session name")
+	})
+}
+
+func TestSessionsKill(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("ConPTY appears to be inconsistent on Windows.")
+	}
+
+	client, workspace, agentID, id := setupSession(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	cmd, root := clitest.New(t, "sessions", "kill", workspace.Name, id.String())
+	clitest.SetupConfig(t, client, root)
+	err := cmd.ExecuteContext(ctx)
+	require.NoError(t, err)
+
+	res, err := client.WorkspaceAgentReconnectingPTYSessions(ctx, agentID)
+	require.NoError(t, err)
+	require.Empty(t, res.Sessions)
+}
+
+// setupSession starts a workspace agent running a terminal session that was
+// opened the way the web terminal opens them.
+func setupSession(t *testing.T) (*codersdk.Client, codersdk.Workspace, uuid.UUID, uuid.UUID) {
+	t.Helper()
+
+	client, workspace, agentToken := setupWorkspaceForAgent(t, nil)
+	agentClient := codersdk.New(client.URL)
+	agentClient.SetSessionToken(agentToken)
+	agentCloser := agent.New(agent.Options{
+		Client: agentClient,
+		Logger: slogtest.Make(t, nil).Named("agent"),
+	})
+	t.Cleanup(func() {
+		_ = agentCloser.Close()
+	})
+	resources := coderdtest.AwaitWorkspaceAgents(t, client, workspace.ID)
+	agentID := resources[0].Agents[0].ID
+
+	// The connection is closed when its context is, so it must outlive the
+	// helper.
+	connCtx, connCancel := context.WithCancel(context.Background())
+	t.Cleanup(connCancel)
+	id := uuid.New()
+	conn, err := client.WorkspaceAgentReconnectingPTY(connCtx, agentID, id, 80, 80, "/bin/bash")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+	data, err := json.Marshal(codersdk.ReconnectingPTYRequest{
+		Data: "echo hello-from-the-dashboard\r\n",
+	})
+	require.NoError(t, err)
+	_, err = conn.Write(data)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+	require.Eventually(t, func() bool {
+		res, err := client.WorkspaceAgentReconnectingPTYSessions(ctx, agentID)
+		return err == nil && len(res.Sessions) == 1
+	}, testutil.WaitShort, testutil.IntervalFast)
+	return client, workspace, agentID, id
+}
@@ -1,14 +1,21 @@
 package cli
 
 import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/google/uuid"
 	"github.com/spf13/cobra"
 	"golang.org/x/xerrors"
 
 	"github.com/coder/coder/cli/cliui"
+	"github.com/coder/coder/codersdk"
 )
 
 func show() *cobra.Command {
-	return &cobra.Command{
+	var startupLogs bool
+	cmd := &cobra.Command{
 		Annotations: workspaceCommand,
 		Use:         "show <workspace>",
 		Short:       "Display details of a workspace's resources and agents",
@@ -26,10 +33,42 @@ func show() *cobra.Command {
 			if err != nil {
 				return xerrors.Errorf("get workspace: %w", err)
 			}
-			return cliui.WorkspaceResources(cmd.OutOrStdout(), workspace.LatestBuild.Resources, cliui.WorkspaceResourcesOptions{
+			err = cliui.WorkspaceResources(cmd.OutOrStdout(), workspace.LatestBuild.Resources, cliui.WorkspaceResourcesOptions{
 				WorkspaceName: workspace.Name,
 				ServerVersion: buildInfo.Version,
 			})
+			if err != nil {
+				return err
+			}
+			if !startupLogs {
+				return nil
+			}
+			for _, resource := range workspace.LatestBuild.Resources {
+				for _, agent := range resource.Agents {
+					_, _ = fmt.Fprintf(cmd.OutOrStdout(), "\n%s\n", cliui.Styles.Bold.Render(fmt.Sprintf("Startup logs for %s.%s:", resource.Name, agent.Name)))
+					err = followStartupLogs(cmd.Context(), cmd.OutOrStdout(), client, agent.ID)
+					if err != nil {
+						return err
+					}
+				}
+			}
+			return nil
 		},
 	}
+	cmd.Flags().BoolVar(&startupLogs, "startup-logs", false, "Follow the startup script logs of each agent until the script exits.")
+	return cmd
+}
+
+// followStartupLogs writes the startup script logs of the agent to the
+// writer until the agent reports the script has exited.
+func followStartupLogs(ctx context.Context, writer io.Writer, client *codersdk.Client, agentID uuid.UUID) error {
+	logs, closer, err := client.WorkspaceAgentStartupLogsAfter(ctx, agentID, 0)
+	if err != nil {
+		return xerrors.Errorf("follow startup logs: %w", err)
+	}
+	defer closer.Close()
+	for log := range logs {
+		_, _ = fmt.Fprintln(writer, log.Output)
+	}
+	return ctx.Err()
 }
@@ -41,6 +41,9 @@ func ssh() *cobra.Command {
 		forwardAgent   bool
 		identityAgent  string
 		wsPollInterval time.Duration
+		startupLogs    bool
+		wait           bool
+		hostPrefix     string
 	)
 	cmd := &cobra.Command{
 		Annotations: workspaceCommand,
@@ -68,7 +71,11 @@ func ssh() *cobra.Command {
 				}
 			}
 
-			workspace, workspaceAgent, err := getWorkspaceAndAgent(ctx, cmd, client, codersdk.Me, args[0], shuffle)
+			var workspaceName string
+			if !shuffle {
+				workspaceName = strings.TrimPrefix(args[0], hostPrefix)
+			}
+			workspace, workspaceAgent, err := getWorkspaceAndAgent(ctx, cmd, client, codersdk.Me, workspaceName, shuffle)
 			if err != nil {
 				return err
 			}
@@ -85,10 +92,17 @@ func ssh() *cobra.Command {
 				Fetch: func(ctx context.Context) (codersdk.WorkspaceAgent, error) {
 					return client.WorkspaceAgent(ctx, workspaceAgent.ID)
 				},
+				Wait: wait,
 			})
 			if err != nil {
 				return xerrors.Errorf("await agent: %w", err)
 			}
+			if startupLogs {
+				err = followStartupLogs(ctx, cmd.ErrOrStderr(), client, workspaceAgent.ID)
+				if err != nil {
+					return err
+				}
+			}
 
 			conn, err := client.DialWorkspaceAgent(ctx, workspaceAgent.ID, &codersdk.DialWorkspaceAgentOptions{})
 			if err != nil {
@@ -218,6 +232,9 @@ func ssh() *cobra.Command {
 	cliflag.BoolVarP(cmd.Flags(), &forwardAgent, "forward-agent", "A", "CODER_SSH_FORWARD_AGENT", false, "Specifies whether to forward the SSH agent specified in $SSH_AUTH_SOCK")
 	cliflag.StringVarP(cmd.Flags(), &identityAgent, "identity-agent", "", "CODER_SSH_IDENTITY_AGENT", "", "Specifies which identity agent to use (overrides $SSH_AUTH_SOCK), forward agent must also be enabled")
 	cliflag.DurationVarP(cmd.Flags(), &wsPollInterval, "workspace-poll-interval", "", "CODER_WORKSPACE_POLL_INTERVAL", workspacePollInterval, "Specifies how often to poll for workspace automated shutdown.")
+	cliflag.BoolVarP(cmd.Flags(), &wait, "wait", "", "CODER_SSH_WAIT", false, "Specifies whether to wait for the agent startup script to finish before connecting.")
+	cliflag.StringVarP(cmd.Flags(), &hostPrefix, "ssh-host-prefix", "", "CODER_SSH_HOST_PREFIX", "", "Strip this prefix from the provided hostname to determine the workspace name. This is useful when used as part of an OpenSSH proxy command.")
+	cliflag.BoolVarP(cmd.Flags(), &startupLogs, "startup-logs", "", "CODER_SSH_STARTUP_LOGS", false, "Specifies whether to stream the agent startup script logs to stderr until the script exits before connecting.")
 	return cmd
 }
 
@@ -253,7 +270,10 @@ func getWorkspaceAndAgent(ctx context.Context, cmd *cobra.Command, client *coder
 	}
 
 	if workspace.LatestBuild.Transition != codersdk.WorkspaceTransitionStart {
-		return codersdk.Workspace{}, codersdk.WorkspaceAgent{}, xerrors.New("workspace must be in start transition to ssh")
+		workspace, err = autostartWorkspace(ctx, cmd, client, workspace)
+		if err != nil {
+			return codersdk.Workspace{}, codersdk.WorkspaceAgent{}, err
+		}
 	}
 	if workspace.LatestBuild.Job.CompletedAt == nil {
 		err := cliui.WorkspaceBuild(ctx, cmd.ErrOrStderr(), client, workspace.LatestBuild.ID)
@@ -304,6 +324,61 @@ func getWorkspaceAndAgent(ctx context.Context, cmd *cobra.Command, client *coder
 	return workspace, workspaceAgent, nil
 }
 
+// autostartWorkspace starts a stopped workspace if starting on connect is
+// enabled by its template or owner, and waits for the build to complete.
+func autostartWorkspace(ctx context.Context, cmd *cobra.Command, client *codersdk.Client, workspace codersdk.Workspace) (codersdk.Workspace, error) {
+	if workspace.LatestBuild.Transition != codersdk.WorkspaceTransitionStop {
+		return codersdk.Workspace{}, xerrors.New("workspace must be in start transition to ssh")
+	}
+	template, err := client.Template(ctx, workspace.TemplateID)
+	if err != nil {
+		return codersdk.Workspace{}, xerrors.Errorf("get template: %w", err)
+	}
+	enabled := template.AutoStartOnConnect
+	if !enabled {
+		owner, err := client.User(ctx, workspace.OwnerID.String())
+		if err != nil {
+			return codersdk.Workspace{}, xerrors.Errorf("get workspace owner: %w", err)
+		}
+		enabled = owner.AutoStartOnConnect
+	}
+	if !enabled {
+		return codersdk.Workspace{}, xerrors.Errorf("workspace must be in start transition to ssh, start it with \"coder start %s\"", workspace.Name)
+	}
+
+	// Wait for the workspace to finish stopping before starting it.
+	if workspace.LatestBuild.Job.CompletedAt == nil {
+		err := cliui.WorkspaceBuild(ctx, cmd.ErrOrStderr(), client, workspace.LatestBuild.ID)
+		if err != nil {
+			return codersdk.Workspace{}, err
+		}
+	}
+
+	_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Starting workspace %s...\n", cliui.Styles.Keyword.Render(workspace.Name))
+	build, err := client.CreateWorkspaceBuild(ctx, workspace.ID, codersdk.CreateWorkspaceBuildRequest{
+		Transition: codersdk.WorkspaceTransitionStart,
+	})
+	if err != nil {
+		return codersdk.Workspace{}, xerrors.Errorf("start workspace: %w", err)
+	}
+	err = cliui.ProvisionerJob(ctx, cmd.ErrOrStderr(), cliui.ProvisionerJobOptions{
+		Fetch: func() (codersdk.ProvisionerJob, error) {
+			build, err := client.WorkspaceBuild(ctx, build.ID)
+			return build.Job, err
+		},
+		Cancel: func() error {
+			return client.CancelWorkspaceBuild(ctx, build.ID)
+		},
+		Logs: func() (<-chan codersdk.ProvisionerJobLog, io.Closer, error) {
+			return client.WorkspaceBuildLogsAfter(ctx, build.ID, 0)
+		},
+	})
+	if err != nil {
+		return codersdk.Workspace{}, xerrors.Errorf("start workspace: %w", err)
+	}
+	return client.Workspace(ctx, workspace.ID)
+}
+
 // Attempt to poll workspace autostop. We write a per-workspace lockfile to
 // avoid spamming the user with notifications in case of multiple instances
 // of the CLI running simultaneously.
@@ -0,0 +1,358 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/cli/cliflag"
+)
+
+// syncDebounce is how long sync waits for changes to settle before uploading
+// them, so editors saving a file in several steps cause a single upload.
+const syncDebounce = 200 * time.Millisecond
+
+func syncFiles() *cobra.Command {
+	var (
+		ignoreFiles []string
+		excludes    []string
+		deleteFiles bool
+		once        bool
+	)
+	cmd := &cobra.Command{
+		Annotations: workspaceCommand,
+		Use:         "sync <local-directory> <workspace>:<directory>",
+		Short:       "Mirror a local directory into a workspace as it changes",
+		Long: "Uploads files that differ in size or modification time, then watches the local directory and uploads changes " +
+			"as they're made. Ignore files are read once at the start. Symbolic links are skipped.",
+		Args: cobra.ExactArgs(2),
+		Example: formatExamples(
+			example{
+				Description: "Mirror the current directory into ~/project in a workspace",
+				Command:     "coder sync . my-workspace:project",
+			},
+			example{
+				Description: "Make the workspace directory match once, removing files that don't exist locally",
+				Command:     "coder sync --delete --once . my-workspace:project",
+			},
+		),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			local := parseTransferLocation(args[0])
+			remote := parseTransferLocation(args[1])
+			if local.workspace != "" || remote.workspace == "" {
+				return xerrors.New("sync mirrors a local directory into a workspace, written as <workspace>:<directory>")
+			}
+			info, err := os.Stat(local.path)
+			if err != nil {
+				return xerrors.Errorf("stat %q: %w", local.path, err)
+			}
+			if !info.IsDir() {
+				return xerrors.Errorf("%q is not a directory", local.path)
+			}
+			ignore, err := readIgnoreFiles(localFS{}, local.path, ignoreFiles, excludes)
+			if err != nil {
+				return err
+			}
+
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return xerrors.Errorf("create codersdk client: %w", err)
+			}
+			sftpClient, closeSFTP, err := dialWorkspaceSFTP(ctx, cmd, client, remote.workspace)
+			if err != nil {
+				return err
+			}
+			defer closeSFTP()
+
+			progress := newTransferProgress(cmd)
+			s := &directorySync{
+				localRoot:  local.path,
+				remoteRoot: remote.path,
+				delete:     deleteFiles,
+				copier: &fileCopier{
+					src:      localFS{},
+					dst:      sftpFS{client: sftpClient},
+					ignore:   ignore,
+					progress: progress,
+				},
+			}
+			err = s.syncDir("")
+			progress.clear()
+			if err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintln(cmd.ErrOrStderr(), s.summary())
+			if once {
+				return nil
+			}
+
+			watcher, err := fsnotify.NewWatcher()
+			if err != nil {
+				return xerrors.Errorf("create watcher: %w", err)
+			}
+			defer watcher.Close()
+			s.watcher = watcher
+			err = s.watchDir("")
+			if err != nil {
+				return err
+			}
+			// Log each change rather than drawing progress while watching.
+			progress.out = nil
+			s.log = cmd.ErrOrStderr()
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Watching %s for changes. Press Ctrl+C to stop.\n", local.path)
+
+			ctx, stop := signal.NotifyContext(ctx, InterruptSignals...)
+			defer stop()
+
+			pending := map[string]struct{}{}
+			debounce := time.NewTimer(syncDebounce)
+			debounce.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					_, _ = fmt.Fprintln(cmd.ErrOrStderr(), s.summary())
+					return nil
+				case err := <-watcher.Errors:
+					// Errors like a full event queue don't stop the watcher.
+					_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Watch error: %s\n", err)
+				case event := <-watcher.Events:
+					rel, err := filepath.Rel(local.path, event.Name)
+					if err != nil {
+						continue
+					}
+					pending[filepath.ToSlash(rel)] = struct{}{}
+					debounce.Reset(syncDebounce)
+				case <-debounce.C:
+					rels := make([]string, 0, len(pending))
+					for rel := range pending {
+						rels = append(rels, rel)
+					}
+					sort.Strings(rels)
+					pending = map[string]struct{}{}
+					// A path that fails to sync is retried when it changes
+					// again, so it doesn't stop the rest from syncing.
+					for _, rel := range rels {
+						err = s.syncPath(rel)
+						if err != nil {
+							s.failed++
+							s.logf("Failed to sync %s: %s", rel, err)
+						}
+					}
+				}
+			}
+		},
+	}
+	cliflag.StringArrayVarP(cmd.Flags(), &ignoreFiles, "ignore-file", "", "CODER_SYNC_IGNORE_FILE", defaultIgnoreFiles, "Ignore files in the root of the local directory. Paths matching their patterns aren't synced.")
+	cliflag.StringArrayVarP(cmd.Flags(), &excludes, "exclude", "", "CODER_SYNC_EXCLUDE", nil, "Skip paths matching a pattern, in the .gitignore format.")
+	cliflag.BoolVarP(cmd.Flags(), &deleteFiles, "delete", "", "CODER_SYNC_DELETE", false, "Remove files in the workspace directory that don't exist in the local directory. Ignored paths aren't removed.")
+	cliflag.BoolVarP(cmd.Flags(), &once, "once", "", "CODER_SYNC_ONCE", false, "Exit after the first sync instead of watching for changes.")
+	return cmd
+}
+
+// directorySync mirrors a local directory into a workspace. Paths passed to
+// its methods are slash-separated and relative to the roots.
+type directorySync struct {
+	localRoot  string
+	remoteRoot string
+	delete     bool
+	copier     *fileCopier
+	// watcher is set once the initial sync completes, and new directories are
+	// watched as they're synced.
+	watcher *fsnotify.Watcher
+	// log is written a line for every change if set.
+	log     io.Writer
+	deleted int
+	failed  int
+}
+
+func (s *directorySync) localPath(rel string) string {
+	return joinLocal(s.localRoot, filepath.FromSlash(rel))
+}
+
+func (s *directorySync) remotePath(rel string) string {
+	return path.Join(s.remoteRoot, rel)
+}
+
+// syncDir uploads the files in a directory that changed, and removes files
+// in the remote directory that don't exist locally if delete is set.
+func (s *directorySync) syncDir(rel string) error {
+	remoteDir := s.remotePath(rel)
+	err := s.copier.dst.MkdirAll(remoteDir)
+	if err != nil {
+		return xerrors.Errorf("create directory %q: %w", remoteDir, err)
+	}
+	entries, err := s.copier.src.ReadDir(s.localPath(rel))
+	if err != nil {
+		return xerrors.Errorf("read directory %q: %w", s.localPath(rel), err)
+	}
+	remoteEntries, err := s.copier.dst.ReadDir(remoteDir)
+	if err != nil {
+		return xerrors.Errorf("read directory %q: %w", remoteDir, err)
+	}
+	remoteInfos := make(map[string]fs.FileInfo, len(remoteEntries))
+	for _, remoteInfo := range remoteEntries {
+		remoteInfos[remoteInfo.Name()] = remoteInfo
+	}
+
+	localNames := make(map[string]struct{}, len(entries))
+	for _, entry := range entries {
+		localNames[entry.Name()] = struct{}{}
+		entryRel := path.Join(rel, entry.Name())
+		if s.copier.ignore.Ignored(entryRel, entry.IsDir()) {
+			continue
+		}
+		switch {
+		case entry.IsDir():
+			if remoteInfo, ok := remoteInfos[entry.Name()]; ok && !remoteInfo.IsDir() {
+				err = s.remove(entryRel)
+				if err != nil {
+					return err
+				}
+			}
+			err = s.syncDir(entryRel)
+		case entry.Mode().IsRegular():
+			err = s.syncFile(entryRel, entry, remoteInfos[entry.Name()])
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if !s.delete {
+		return nil
+	}
+	for name, remoteInfo := range remoteInfos {
+		if _, ok := localNames[name]; ok {
+			continue
+		}
+		entryRel := path.Join(rel, name)
+		if s.copier.ignore.Ignored(entryRel, remoteInfo.IsDir()) {
+			continue
+		}
+		err = s.remove(entryRel)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// syncFile uploads a file unless the remote file has the same size and
+// modification time.
+func (s *directorySync) syncFile(rel string, info, remoteInfo fs.FileInfo) error {
+	if remoteInfo != nil && remoteInfo.Mode().IsRegular() &&
+		remoteInfo.Size() == info.Size() && remoteInfo.ModTime().Unix() == info.ModTime().Unix() {
+		return nil
+	}
+	if remoteInfo != nil && remoteInfo.IsDir() {
+		err := removeAll(s.copier.dst, s.remotePath(rel))
+		if err != nil {
+			return xerrors.Errorf("remove %q: %w", s.remotePath(rel), err)
+		}
+	}
+	err := s.copier.copyFile(s.localPath(rel), s.remotePath(rel), info)
+	if err != nil {
+		return err
+	}
+	s.logf("Uploaded %s (%s)", rel, formatBytes(info.Size()))
+	return nil
+}
+
+// syncPath syncs a path that changed while watching.
+func (s *directorySync) syncPath(rel string) error {
+	info, err := os.Lstat(s.localPath(rel))
+	if xerrors.Is(err, fs.ErrNotExist) {
+		if !s.delete || s.copier.ignore.Ignored(rel, false) {
+			return nil
+		}
+		return s.remove(rel)
+	}
+	if err != nil {
+		return xerrors.Errorf("stat %q: %w", s.localPath(rel), err)
+	}
+	if s.copier.ignore.Ignored(rel, info.IsDir()) {
+		return nil
+	}
+	switch {
+	case info.IsDir():
+		err = s.watchDir(rel)
+		if err != nil {
+			return err
+		}
+		return s.syncDir(rel)
+	case info.Mode().IsRegular():
+		remoteInfo, err := s.copier.dst.Lstat(s.remotePath(rel))
+		if err != nil && !xerrors.Is(err, fs.ErrNotExist) {
+			return xerrors.Errorf("stat %q: %w", s.remotePath(rel), err)
+		}
+		return s.syncFile(rel, info, remoteInfo)
+	default:
+		return nil
+	}
+}
+
+func (s *directorySync) remove(rel string) error {
+	if _, err := s.copier.dst.Lstat(s.remotePath(rel)); xerrors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	err := removeAll(s.copier.dst, s.remotePath(rel))
+	if err != nil {
+		return xerrors.Errorf("remove %q: %w", s.remotePath(rel), err)
+	}
+	s.deleted++
+	s.logf("Removed %s", rel)
+	return nil
+}
+
+// watchDir watches a directory and its subdirectories that aren't ignored.
+func (s *directorySync) watchDir(rel string) error {
+	if s.watcher == nil {
+		return nil
+	}
+	err := s.watcher.Add(s.localPath(rel))
+	if err != nil {
+		return xerrors.Errorf("watch %q: %w", s.localPath(rel), err)
+	}
+	entries, err := os.ReadDir(s.localPath(rel))
+	if err != nil {
+		return xerrors.Errorf("read directory %q: %w", s.localPath(rel), err)
+	}
+	for _, entry := range entries {
+		entryRel := path.Join(rel, entry.Name())
+		if !entry.IsDir() || s.copier.ignore.Ignored(entryRel, true) {
+			continue
+		}
+		err = s.watchDir(entryRel)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *directorySync) logf(format string, args ...interface{}) {
+	if s.log != nil {
+		_, _ = fmt.Fprintf(s.log, format+"\n", args...)
+	}
+}
+
+func (s *directorySync) summary() string {
+	summary := s.copier.progress.summary("Uploaded")
+	if s.deleted > 0 {
+		summary = fmt.Sprintf("%s Removed %d.", summary, s.deleted)
+	}
+	if s.failed > 0 {
+		summary = fmt.Sprintf("%s Failed to sync %d.", summary, s.failed)
+	}
+	return summary
+}
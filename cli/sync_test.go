@@ -0,0 +1,157 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"cdr.dev/slog/sloggers/slogtest"
+
+	"github.com/coder/coder/agent"
+	"github.com/coder/coder/cli/clitest"
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/pty/ptytest"
+	"github.com/coder/coder/testutil"
+)
+
+func TestSync(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("The workspace paths are POSIX paths.")
+	}
+
+	client, workspace, agentToken := setupWorkspaceForAgent(t, nil)
+	agentClient := codersdk.New(client.URL)
+	agentClient.SetSessionToken(agentToken)
+	agentCloser := agent.New(agent.Options{
+		Client: agentClient,
+		Logger: slogtest.Make(t, nil).Named("agent"),
+	})
+	t.Cleanup(func() {
+		_ = agentCloser.Close()
+	})
+	coderdtest.AwaitWorkspaceAgents(t, client, workspace.ID)
+
+	t.Run("Once", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		src := t.TempDir()
+		writeFiles(t, src, map[string]string{
+			"a.txt":          "a",
+			"dir/b.txt":      "b",
+			"node_modules/x": "x",
+			".gitignore":     "node_modules/",
+		})
+		// The agent runs on this machine, so workspace paths are local paths.
+		dst := t.TempDir()
+		writeFiles(t, dst, map[string]string{
+			"stale.txt":      "stale",
+			"node_modules/y": "y",
+		})
+
+		cmd, root := clitest.New(t, "sync", "--once", "--delete", src, workspace.Name+":"+dst)
+		clitest.SetupConfig(t, client, root)
+		var stderr bytes.Buffer
+		cmd.SetErr(&stderr)
+		err := cmd.ExecuteContext(ctx)
+		require.NoError(t, err)
+		require.Contains(t, stderr.String(), "Uploaded 3 files")
+		require.Contains(t, stderr.String(), "Removed 1.")
+
+		requireFile(t, filepath.Join(dst, "a.txt"), "a")
+		requireFile(t, filepath.Join(dst, "dir", "b.txt"), "b")
+		require.NoFileExists(t, filepath.Join(dst, "stale.txt"))
+		require.NoFileExists(t, filepath.Join(dst, "node_modules", "x"))
+		// Ignored paths aren't removed.
+		require.FileExists(t, filepath.Join(dst, "node_modules", "y"))
+
+		// Unchanged files aren't uploaded again.
+		cmd, root = clitest.New(t, "sync", "--once", src, workspace.Name+":"+dst)
+		clitest.SetupConfig(t, client, root)
+		stderr.Reset()
+		cmd.SetErr(&stderr)
+		err = cmd.ExecuteContext(ctx)
+		require.NoError(t, err)
+		require.Contains(t, stderr.String(), "Uploaded 0 files")
+	})
+
+	t.Run("Watch", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		src := t.TempDir()
+		writeFiles(t, src, map[string]string{
+			"a.txt": "a",
+		})
+		dst := t.TempDir()
+
+		cmd, root := clitest.New(t, "sync", "--delete", src, workspace.Name+":"+dst)
+		clitest.SetupConfig(t, client, root)
+		pty := ptytest.New(t)
+		cmd.SetErr(pty.Output())
+		cmdCtx, stop := context.WithCancel(ctx)
+		cmdDone := tGo(t, func() {
+			err := cmd.ExecuteContext(cmdCtx)
+			assert.NoError(t, err)
+		})
+		pty.ExpectMatch("Watching")
+
+		writeFiles(t, src, map[string]string{
+			"new/b.txt": "b",
+		})
+		pty.ExpectMatch("Uploaded new/b.txt")
+		requireFile(t, filepath.Join(dst, "new", "b.txt"), "b")
+
+		require.NoError(t, os.Remove(filepath.Join(src, "a.txt")))
+		pty.ExpectMatch("Removed a.txt")
+		require.NoFileExists(t, filepath.Join(dst, "a.txt"))
+
+		stop()
+		<-cmdDone
+	})
+	t.Run("WatchContinuesAfterFailure", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		src := t.TempDir()
+		dst := t.TempDir()
+		// A file where a directory is synced to can't be replaced by it.
+		writeFiles(t, dst, map[string]string{
+			"blocked": "file",
+		})
+
+		cmd, root := clitest.New(t, "sync", src, workspace.Name+":"+dst)
+		clitest.SetupConfig(t, client, root)
+		pty := ptytest.New(t)
+		cmd.SetErr(pty.Output())
+		cmdCtx, stop := context.WithCancel(ctx)
+		cmdDone := tGo(t, func() {
+			err := cmd.ExecuteContext(cmdCtx)
+			assert.NoError(t, err)
+		})
+		pty.ExpectMatch("Watching")
+
+		require.NoError(t, os.Mkdir(filepath.Join(src, "blocked"), 0o755))
+		pty.ExpectMatch("Failed to sync blocked")
+
+		writeFiles(t, src, map[string]string{
+			"a.txt": "a",
+		})
+		pty.ExpectMatch("Uploaded a.txt")
+		requireFile(t, filepath.Join(dst, "a.txt"), "a")
+
+		stop()
+		<-cmdDone
+	})
+}
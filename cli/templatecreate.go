@@ -29,6 +29,9 @@ func templateCreate() *cobra.Command {
 		provisionerTags []string
 		parameterFile   string
 		defaultTTL      time.Duration
+		inactivityTTL   time.Duration
+		stoppedTTL      time.Duration
+		warningTTL      time.Duration
 	)
 	cmd := &cobra.Command{
 		Use:   "create [name]",
@@ -114,9 +117,12 @@ func templateCreate() *cobra.Command {
 			}
 
 			createReq := codersdk.CreateTemplateRequest{
-				Name:             templateName,
-				VersionID:        job.ID,
-				DefaultTTLMillis: ptr.Ref(defaultTTL.Milliseconds()),
+				Name:                       templateName,
+				VersionID:                  job.ID,
+				DefaultTTLMillis:           ptr.Ref(defaultTTL.Milliseconds()),
+				InactivityTTLMillis:        ptr.Ref(inactivityTTL.Milliseconds()),
+				StoppedTTLMillis:           ptr.Ref(stoppedTTL.Milliseconds()),
+				AutodeleteWarningTTLMillis: ptr.Ref(warningTTL.Milliseconds()),
 			}
 
 			_, err = client.CreateTemplate(cmd.Context(), organization.ID, createReq)
@@ -140,6 +146,9 @@ func templateCreate() *cobra.Command {
 	cmd.Flags().StringVarP(&parameterFile, "parameter-file", "", "", "Specify a file path with parameter values.")
 	cmd.Flags().StringArrayVarP(&provisionerTags, "provisioner-tag", "", []string{}, "Specify a set of tags to target provisioner daemons.")
 	cmd.Flags().DurationVarP(&defaultTTL, "default-ttl", "", 24*time.Hour, "Specify a default TTL for workspaces created from this template.")
+	cmd.Flags().DurationVarP(&inactivityTTL, "inactivity-ttl", "", 0, "Specify how long workspaces created from this template may go unused before they are deleted. 0 disables it.")
+	cmd.Flags().DurationVarP(&stoppedTTL, "stopped-ttl", "", 0, "Specify how long workspaces created from this template may remain stopped before they are deleted. 0 disables it.")
+	cmd.Flags().DurationVarP(&warningTTL, "autodelete-warning-ttl", "", 24*time.Hour, "Specify how long dormant workspaces created from this template are marked for deletion before they are deleted.")
 	// This is for testing!
 	err := cmd.Flags().MarkHidden("test.provisioner")
 	if err != nil {
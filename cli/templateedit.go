@@ -8,16 +8,32 @@ import (
 	"golang.org/x/xerrors"
 
 	"github.com/coder/coder/cli/cliui"
+	"github.com/coder/coder/coderd/util/ptr"
 	"github.com/coder/coder/codersdk"
 )
 
 func templateEdit() *cobra.Command {
 	var (
-		name        string
-		displayName string
-		description string
-		icon        string
-		defaultTTL  time.Duration
+		name          string
+		displayName   string
+		description   string
+		icon          string
+		defaultTTL    time.Duration
+		inactivityTTL time.Duration
+		stoppedTTL    time.Duration
+		warningTTL    time.Duration
+		maxTTL        time.Duration
+
+		autostartDays      []string
+		autostartStartHour int
+		autostartEndHour   int
+
+		quietHoursSchedule string
+		quietHoursDuration time.Duration
+
+		sessionRecording    bool
+		autoStartOnConnect  bool
+		maxPortSharingLevel string
 	)
 
 	cmd := &cobra.Command{
@@ -46,6 +62,55 @@ func templateEdit() *cobra.Command {
 				Icon:             icon,
 				DefaultTTLMillis: defaultTTL.Milliseconds(),
 			}
+			// Autodelete TTLs are left unchanged unless their flag is set,
+			// since zero disables them.
+			if cmd.Flags().Changed("inactivity-ttl") {
+				req.InactivityTTLMillis = ptr.Ref(inactivityTTL.Milliseconds())
+			}
+			if cmd.Flags().Changed("stopped-ttl") {
+				req.StoppedTTLMillis = ptr.Ref(stoppedTTL.Milliseconds())
+			}
+			if cmd.Flags().Changed("autodelete-warning-ttl") {
+				req.AutodeleteWarningTTLMillis = ptr.Ref(warningTTL.Milliseconds())
+			}
+			// The schedule policy is also left unchanged unless a flag is
+			// set. Unset fields of a partially changed policy keep their
+			// current values.
+			if cmd.Flags().Changed("max-ttl") {
+				req.MaxTTLMillis = ptr.Ref(maxTTL.Milliseconds())
+			}
+			if cmd.Flags().Changed("autostart-days") || cmd.Flags().Changed("autostart-start-hour") || cmd.Flags().Changed("autostart-end-hour") {
+				autostart := template.AutostartRequirement
+				if cmd.Flags().Changed("autostart-days") {
+					autostart.DaysOfWeek = autostartDays
+				}
+				if cmd.Flags().Changed("autostart-start-hour") {
+					autostart.StartHour = autostartStartHour
+				}
+				if cmd.Flags().Changed("autostart-end-hour") {
+					autostart.EndHour = autostartEndHour
+				}
+				req.AutostartRequirement = &autostart
+			}
+			if cmd.Flags().Changed("quiet-hours") || cmd.Flags().Changed("quiet-hours-duration") {
+				quietHours := template.QuietHours
+				if cmd.Flags().Changed("quiet-hours") {
+					quietHours.Schedule = quietHoursSchedule
+				}
+				if cmd.Flags().Changed("quiet-hours-duration") {
+					quietHours.DurationMillis = quietHoursDuration.Milliseconds()
+				}
+				req.QuietHours = &quietHours
+			}
+			if cmd.Flags().Changed("session-recording") {
+				req.SessionRecording = &sessionRecording
+			}
+			if cmd.Flags().Changed("auto-start-on-connect") {
+				req.AutoStartOnConnect = &autoStartOnConnect
+			}
+			if cmd.Flags().Changed("max-port-sharing-level") {
+				req.MaxPortSharingLevel = ptr.Ref(codersdk.WorkspaceAppSharingLevel(maxPortSharingLevel))
+			}
 
 			_, err = client.UpdateTemplateMeta(cmd.Context(), template.ID, req)
 			if err != nil {
@@ -61,6 +126,18 @@ func templateEdit() *cobra.Command {
 	cmd.Flags().StringVarP(&description, "description", "", "", "Edit the template description")
 	cmd.Flags().StringVarP(&icon, "icon", "", "", "Edit the template icon path")
 	cmd.Flags().DurationVarP(&defaultTTL, "default-ttl", "", 0, "Edit the template default time before shutdown - workspaces created from this template to this value.")
+	cmd.Flags().DurationVarP(&inactivityTTL, "inactivity-ttl", "", 0, "Edit how long workspaces created from this template may go unused before they are deleted. 0 disables it.")
+	cmd.Flags().DurationVarP(&stoppedTTL, "stopped-ttl", "", 0, "Edit how long workspaces created from this template may remain stopped before they are deleted. 0 disables it.")
+	cmd.Flags().DurationVarP(&warningTTL, "autodelete-warning-ttl", "", 0, "Edit how long dormant workspaces created from this template are marked for deletion before they are deleted.")
+	cmd.Flags().DurationVarP(&maxTTL, "max-ttl", "", 0, "Edit the maximum time workspaces created from this template may run for after they are started. 0 disables it.")
+	cmd.Flags().StringSliceVarP(&autostartDays, "autostart-days", "", nil, "Edit the days of the week workspaces created from this template may be autostarted on, e.g. monday,tuesday.")
+	cmd.Flags().IntVarP(&autostartStartHour, "autostart-start-hour", "", 0, "Edit the hour of the day from which workspaces created from this template may be autostarted.")
+	cmd.Flags().IntVarP(&autostartEndHour, "autostart-end-hour", "", 24, "Edit the hour of the day until which workspaces created from this template may be autostarted, exclusive.")
+	cmd.Flags().StringVarP(&quietHoursSchedule, "quiet-hours", "", "", "Edit the cron schedule quiet hours start at, e.g. \"CRON_TZ=US/Central 0 22 * * *\". Workspaces are stopped and not autostarted during quiet hours. Empty disables it.")
+	cmd.Flags().DurationVarP(&quietHoursDuration, "quiet-hours-duration", "", 0, "Edit how long quiet hours last.")
+	cmd.Flags().BoolVarP(&sessionRecording, "session-recording", "", false, "Edit whether terminal sessions in workspaces created from this template are recorded.")
+	cmd.Flags().BoolVarP(&autoStartOnConnect, "auto-start-on-connect", "", false, "Edit whether stopped workspaces created from this template are started by \"coder ssh\", \"coder port-forward\", \"coder speedtest\" and app access.")
+	cmd.Flags().StringVarP(&maxPortSharingLevel, "max-port-sharing-level", "", "", "Edit the highest level ports in workspaces created from this template may be shared with by \"coder port share\": owner, authenticated or public.")
 	cliui.AllowSkipPrompt(cmd)
 
 	return cmd
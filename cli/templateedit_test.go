@@ -10,6 +10,7 @@ import (
 
 	"github.com/coder/coder/cli/clitest"
 	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/util/ptr"
 	"github.com/coder/coder/codersdk"
 	"github.com/coder/coder/testutil"
 )
@@ -58,6 +59,103 @@ func TestTemplateEdit(t *testing.T) {
 		assert.Equal(t, icon, updated.Icon)
 		assert.Equal(t, defaultTTL.Milliseconds(), updated.DefaultTTLMillis)
 	})
+	t.Run("MaxPortSharingLevel", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		_ = coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		require.Equal(t, codersdk.WorkspaceAppSharingLevelOwner, template.MaxPortSharingLevel)
+
+		cmd, root := clitest.New(t, "templates", "edit", template.Name, "--max-port-sharing-level", "public")
+		clitest.SetupConfig(t, client, root)
+
+		ctx, _ := testutil.Context(t)
+		err := cmd.ExecuteContext(ctx)
+		require.NoError(t, err)
+
+		updated, err := client.Template(ctx, template.ID)
+		require.NoError(t, err)
+		assert.Equal(t, codersdk.WorkspaceAppSharingLevelPublic, updated.MaxPortSharingLevel)
+
+		cmd, root = clitest.New(t, "templates", "edit", template.Name, "--max-port-sharing-level", "everyone")
+		clitest.SetupConfig(t, client, root)
+		err = cmd.ExecuteContext(ctx)
+		require.Error(t, err)
+	})
+	t.Run("AutodeleteTTLs", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		_ = coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID, func(ctr *codersdk.CreateTemplateRequest) {
+			ctr.StoppedTTLMillis = ptr.Ref(time.Hour.Milliseconds())
+		})
+
+		// Test the cli command.
+		inactivityTTL := 30 * 24 * time.Hour
+		cmdArgs := []string{
+			"templates",
+			"edit",
+			template.Name,
+			"--inactivity-ttl", inactivityTTL.String(),
+		}
+		cmd, root := clitest.New(t, cmdArgs...)
+		clitest.SetupConfig(t, client, root)
+
+		ctx, _ := testutil.Context(t)
+		err := cmd.ExecuteContext(ctx)
+
+		require.NoError(t, err)
+
+		// Assert that only the inactivity TTL changed.
+		updated, err := client.Template(context.Background(), template.ID)
+		require.NoError(t, err)
+		assert.Equal(t, inactivityTTL.Milliseconds(), updated.InactivityTTLMillis)
+		assert.Equal(t, time.Hour.Milliseconds(), updated.StoppedTTLMillis)
+		assert.Equal(t, (24 * time.Hour).Milliseconds(), updated.AutodeleteWarningTTLMillis)
+	})
+	t.Run("SchedulePolicy", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		_ = coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+
+		// Test the cli command.
+		cmdArgs := []string{
+			"templates",
+			"edit",
+			template.Name,
+			"--max-ttl", "8h",
+			"--autostart-days", "monday,friday",
+			"--autostart-start-hour", "6",
+			"--quiet-hours", "CRON_TZ=US/Central 0 22 * * *",
+			"--quiet-hours-duration", "8h",
+			"--session-recording",
+		}
+		cmd, root := clitest.New(t, cmdArgs...)
+		clitest.SetupConfig(t, client, root)
+
+		ctx, _ := testutil.Context(t)
+		err := cmd.ExecuteContext(ctx)
+
+		require.NoError(t, err)
+
+		// Assert that the policy changed and unset fields were kept.
+		updated, err := client.Template(context.Background(), template.ID)
+		require.NoError(t, err)
+		assert.Equal(t, (8 * time.Hour).Milliseconds(), updated.MaxTTLMillis)
+		assert.Equal(t, []string{"monday", "friday"}, updated.AutostartRequirement.DaysOfWeek)
+		assert.Equal(t, 6, updated.AutostartRequirement.StartHour)
+		assert.Equal(t, 24, updated.AutostartRequirement.EndHour)
+		assert.Equal(t, "CRON_TZ=US/Central 0 22 * * *", updated.QuietHours.Schedule)
+		assert.Equal(t, (8 * time.Hour).Milliseconds(), updated.QuietHours.DurationMillis)
+		assert.True(t, updated.SessionRecording)
+	})
 	t.Run("FirstEmptyThenNotModified", func(t *testing.T) {
 		t.Parallel()
 		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
@@ -1,16 +1,457 @@
 package cli
 
 import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+
+	"github.com/google/uuid"
 	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/cli/cliui"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/provisionersdk"
+)
+
+const (
+	templatePlanActionAdd    = "add"
+	templatePlanActionChange = "change"
+	templatePlanActionRemove = "remove"
+
+	templatePlanKindResource  = "resource"
+	templatePlanKindAgent     = "agent"
+	templatePlanKindApp       = "app"
+	templatePlanKindParameter = "parameter"
 )
 
+// templatePlanChange is a single difference between the active template
+// version and the planned one.
+type templatePlanChange struct {
+	Action string `json:"action"`
+	Kind   string `json:"kind"`
+	// Name is a slash separated path to the changed item, e.g.
+	// "docker_container.workspace/main/code-server" for an app.
+	Name string `json:"name"`
+	// Fields lists the attributes that differ for "change" actions.
+	Fields []string `json:"fields,omitempty"`
+}
+
+type templatePlanResult struct {
+	TemplateID       uuid.UUID            `json:"template_id"`
+	ActiveVersionID  uuid.UUID            `json:"active_version_id"`
+	PlannedVersionID uuid.UUID            `json:"planned_version_id"`
+	Changes          []templatePlanChange `json:"changes"`
+}
+
 func templatePlan() *cobra.Command {
-	return &cobra.Command{
-		Use:   "plan <directory>",
-		Args:  cobra.MinimumNArgs(1),
-		Short: "Plan a template push from the current directory",
+	var (
+		directory       string
+		versionName     string
+		provisioner     string
+		parameterFile   string
+		outputFormat    string
+		exitCode        bool
+		provisionerTags []string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "plan [template]",
+		Args:  cobra.MaximumNArgs(1),
+		Short: "Plan a template push from the current directory or as specified by flag",
+		Long:  "Uploads the template source as a new, inactive template version and shows how the resources, agents, apps and parameters it provisions differ from the template's active version.",
+		Example: formatExamples(
+			example{
+				Description: "Show the changes a push of the current directory would make",
+				Command:     "coder templates plan my-template",
+			},
+			example{
+				Description: "Fail a CI job with machine readable output when the template has pending changes",
+				Command:     "coder templates plan my-template --directory ./template --output json --exit-code",
+			},
+		),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if outputFormat != "text" && outputFormat != "json" {
+				return xerrors.Errorf(`unknown output format %q, only "text" and "json" are supported`, outputFormat)
+			}
+
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return err
+			}
+			organization, err := CurrentOrganization(cmd, client)
+			if err != nil {
+				return err
+			}
+
+			name := filepath.Base(directory)
+			if len(args) > 0 {
+				name = args[0]
+			}
+
+			template, err := client.TemplateByName(cmd.Context(), organization.ID, name)
+			if err != nil {
+				return err
+			}
+
+			// Job logs and previews are only useful to humans. Keep stdout
+			// reserved for the plan itself when emitting JSON.
+			out := cmd.OutOrStdout()
+			if outputFormat == "json" {
+				cmd.SetOut(cmd.ErrOrStderr())
+				defer cmd.SetOut(out)
+			}
+
+			var parameterMap map[string]string
+			if parameterFile != "" {
+				parameterMap, err = createParameterMapFromFile(parameterFile)
+				if err != nil {
+					return err
+				}
+			}
+
+			content, err := provisionersdk.Tar(directory, provisionersdk.TemplateArchiveLimit)
+			if err != nil {
+				return err
+			}
+			resp, err := client.Upload(cmd.Context(), codersdk.ContentTypeTar, content)
+			if err != nil {
+				return err
+			}
+
+			tags, err := ParseProvisionerTags(provisionerTags)
+			if err != nil {
+				return err
+			}
+
+			version, _, err := createValidTemplateVersion(cmd, createValidTemplateVersionArgs{
+				Name:            versionName,
+				Client:          client,
+				Organization:    organization,
+				Provisioner:     database.ProvisionerType(provisioner),
+				FileID:          resp.ID,
+				ParameterFile:   parameterFile,
+				Template:        &template,
+				ReuseParameters: true,
+				ProvisionerTags: tags,
+			})
+			if err != nil {
+				return err
+			}
+
+			activeSchemas, err := client.TemplateVersionSchema(cmd.Context(), template.ActiveVersionID)
+			if err != nil {
+				return xerrors.Errorf("get active version parameter schemas: %w", err)
+			}
+			plannedSchemas, err := client.TemplateVersionSchema(cmd.Context(), version.ID)
+			if err != nil {
+				return xerrors.Errorf("get planned version parameter schemas: %w", err)
+			}
+
+			_, _ = fmt.Fprintln(cmd.OutOrStdout(), "Planning active template version...")
+			activeResources, err := templatePlanDryRun(cmd, client, template.ActiveVersionID, activeSchemas, parameterMap)
+			if err != nil {
+				return xerrors.Errorf("dry-run active version: %w", err)
+			}
+			_, _ = fmt.Fprintln(cmd.OutOrStdout(), "Planning new template version...")
+			plannedResources, err := templatePlanDryRun(cmd, client, version.ID, plannedSchemas, parameterMap)
+			if err != nil {
+				return xerrors.Errorf("dry-run new version: %w", err)
+			}
+
+			result := templatePlanResult{
+				TemplateID:       template.ID,
+				ActiveVersionID:  template.ActiveVersionID,
+				PlannedVersionID: version.ID,
+				Changes:          diffTemplateResources(activeResources, plannedResources),
+			}
+			result.Changes = append(result.Changes, diffTemplateParameterSchemas(activeSchemas, plannedSchemas)...)
+
+			switch outputFormat {
+			case "json":
+				data, err := json.MarshalIndent(result, "", "  ")
+				if err != nil {
+					return xerrors.Errorf("marshal plan to JSON: %w", err)
+				}
+				_, _ = fmt.Fprintln(out, string(data))
+			default:
+				_, _ = fmt.Fprintln(out)
+				displayTemplatePlan(out, result.Changes)
+			}
+
+			if exitCode && len(result.Changes) > 0 {
+				return xerrors.Errorf("template %q has %d pending change(s)", template.Name, len(result.Changes))
+			}
 			return nil
 		},
 	}
+
+	currentDirectory, _ := os.Getwd()
+	cmd.Flags().StringVarP(&directory, "directory", "d", currentDirectory, "Specify the directory to plan from")
+	cmd.Flags().StringVarP(&provisioner, "test.provisioner", "", "terraform", "Customize the provisioner backend")
+	cmd.Flags().StringVarP(&parameterFile, "parameter-file", "", "", "Specify a file path with parameter values.")
+	cmd.Flags().StringVarP(&versionName, "name", "", "", "Specify a name for the planned template version. It will be automatically generated if not provided.")
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "text", "Output format. Available formats are: text, json.")
+	cmd.Flags().BoolVar(&exitCode, "exit-code", false, "Exit with a non-zero status when the plan contains changes")
+	cmd.Flags().StringArrayVarP(&provisionerTags, "provisioner-tag", "", []string{}, "Specify a set of tags to target provisioner daemons.")
+	// This is for testing!
+	err := cmd.Flags().MarkHidden("test.provisioner")
+	if err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+// templatePlanDryRun runs a workspace dry-run against a template version
+// and returns the resources it would provision on start. Values from the
+// parameter file override workspace-scoped parameter defaults.
+func templatePlanDryRun(cmd *cobra.Command, client *codersdk.Client, versionID uuid.UUID, schemas []codersdk.ParameterSchema, parameterMap map[string]string) ([]codersdk.WorkspaceResource, error) {
+	parameters := make([]codersdk.CreateParameterRequest, 0)
+	for _, schema := range schemas {
+		if !schema.AllowOverrideSource {
+			continue
+		}
+		value, ok := parameterMap[schema.Name]
+		if !ok {
+			continue
+		}
+		parameters = append(parameters, codersdk.CreateParameterRequest{
+			Name:              schema.Name,
+			SourceValue:       value,
+			SourceScheme:      codersdk.ParameterSourceSchemeData,
+			DestinationScheme: schema.DefaultDestinationScheme,
+		})
+	}
+
+	dryRun, err := client.CreateTemplateVersionDryRun(cmd.Context(), versionID, codersdk.CreateTemplateVersionDryRunRequest{
+		WorkspaceName:   "plan",
+		ParameterValues: parameters,
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("begin dry-run: %w", err)
+	}
+	err = cliui.ProvisionerJob(cmd.Context(), cmd.OutOrStdout(), cliui.ProvisionerJobOptions{
+		Fetch: func() (codersdk.ProvisionerJob, error) {
+			return client.TemplateVersionDryRun(cmd.Context(), versionID, dryRun.ID)
+		},
+		Cancel: func() error {
+			return client.CancelTemplateVersionDryRun(cmd.Context(), versionID, dryRun.ID)
+		},
+		Logs: func() (<-chan codersdk.ProvisionerJobLog, io.Closer, error) {
+			return client.TemplateVersionDryRunLogsAfter(cmd.Context(), versionID, dryRun.ID, 0)
+		},
+		// Don't show log output for the dry-run unless there's an error.
+		Silent: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return client.TemplateVersionDryRunResources(cmd.Context(), versionID, dryRun.ID)
+}
+
+// diffTemplateResources compares the resources, agents and apps of two
+// dry-runs. Resources are matched by type and name, agents by name and
+// apps by slug.
+func diffTemplateResources(active, planned []codersdk.WorkspaceResource) []templatePlanChange {
+	activeByName := make(map[string]codersdk.WorkspaceResource, len(active))
+	for _, resource := range active {
+		activeByName[resource.Type+"."+resource.Name] = resource
+	}
+	plannedByName := make(map[string]codersdk.WorkspaceResource, len(planned))
+	for _, resource := range planned {
+		plannedByName[resource.Type+"."+resource.Name] = resource
+	}
+
+	changes := make([]templatePlanChange, 0)
+	for _, name := range sortedKeys(activeByName, plannedByName) {
+		oldResource, inActive := activeByName[name]
+		newResource, inPlanned := plannedByName[name]
+		switch {
+		case !inActive:
+			changes = append(changes, templatePlanChange{Action: templatePlanActionAdd, Kind: templatePlanKindResource, Name: name})
+		case !inPlanned:
+			changes = append(changes, templatePlanChange{Action: templatePlanActionRemove, Kind: templatePlanKindResource, Name: name})
+		default:
+			var fields planFields
+			fields.compare("hide", oldResource.Hide, newResource.Hide)
+			fields.compare("icon", oldResource.Icon, newResource.Icon)
+			fields.compare("daily_cost", oldResource.DailyCost, newResource.DailyCost)
+			fields.compare("metadata", oldResource.Metadata, newResource.Metadata)
+			if len(fields) > 0 {
+				changes = append(changes, templatePlanChange{Action: templatePlanActionChange, Kind: templatePlanKindResource, Name: name, Fields: fields})
+			}
+		}
+		changes = append(changes, diffTemplateAgents(name, oldResource.Agents, newResource.Agents)...)
+	}
+	return changes
+}
+
+func diffTemplateAgents(resourceName string, active, planned []codersdk.WorkspaceAgent) []templatePlanChange {
+	activeByName := make(map[string]codersdk.WorkspaceAgent, len(active))
+	for _, agent := range active {
+		activeByName[agent.Name] = agent
+	}
+	plannedByName := make(map[string]codersdk.WorkspaceAgent, len(planned))
+	for _, agent := range planned {
+		plannedByName[agent.Name] = agent
+	}
+
+	changes := make([]templatePlanChange, 0)
+	for _, name := range sortedKeys(activeByName, plannedByName) {
+		oldAgent, inActive := activeByName[name]
+		newAgent, inPlanned := plannedByName[name]
+		path := resourceName + "/" + name
+		switch {
+		case !inActive:
+			changes = append(changes, templatePlanChange{Action: templatePlanActionAdd, Kind: templatePlanKindAgent, Name: path})
+		case !inPlanned:
+			changes = append(changes, templatePlanChange{Action: templatePlanActionRemove, Kind: templatePlanKindAgent, Name: path})
+		default:
+			var fields planFields
+			fields.compare("operating_system", oldAgent.OperatingSystem, newAgent.OperatingSystem)
+			fields.compare("architecture", oldAgent.Architecture, newAgent.Architecture)
+			fields.compare("environment_variables", oldAgent.EnvironmentVariables, newAgent.EnvironmentVariables)
+			fields.compare("startup_script", oldAgent.StartupScript, newAgent.StartupScript)
+			fields.compare("directory", oldAgent.Directory, newAgent.Directory)
+			fields.compare("connection_timeout_seconds", oldAgent.ConnectionTimeoutSeconds, newAgent.ConnectionTimeoutSeconds)
+			fields.compare("troubleshooting_url", oldAgent.TroubleshootingURL, newAgent.TroubleshootingURL)
+			if len(fields) > 0 {
+				changes = append(changes, templatePlanChange{Action: templatePlanActionChange, Kind: templatePlanKindAgent, Name: path, Fields: fields})
+			}
+		}
+		changes = append(changes, diffTemplateApps(path, oldAgent.Apps, newAgent.Apps)...)
+	}
+	return changes
+}
+
+func diffTemplateApps(agentPath string, active, planned []codersdk.WorkspaceApp) []templatePlanChange {
+	activeBySlug := make(map[string]codersdk.WorkspaceApp, len(active))
+	for _, app := range active {
+		activeBySlug[app.Slug] = app
+	}
+	plannedBySlug := make(map[string]codersdk.WorkspaceApp, len(planned))
+	for _, app := range planned {
+		plannedBySlug[app.Slug] = app
+	}
+
+	changes := make([]templatePlanChange, 0)
+	for _, slug := range sortedKeys(activeBySlug, plannedBySlug) {
+		oldApp, inActive := activeBySlug[slug]
+		newApp, inPlanned := plannedBySlug[slug]
+		path := agentPath + "/" + slug
+		switch {
+		case !inActive:
+			changes = append(changes, templatePlanChange{Action: templatePlanActionAdd, Kind: templatePlanKindApp, Name: path})
+		case !inPlanned:
+			changes = append(changes, templatePlanChange{Action: templatePlanActionRemove, Kind: templatePlanKindApp, Name: path})
+		default:
+			var fields planFields
+			fields.compare("display_name", oldApp.DisplayName, newApp.DisplayName)
+			fields.compare("command", oldApp.Command, newApp.Command)
+			fields.compare("icon", oldApp.Icon, newApp.Icon)
+			fields.compare("subdomain", oldApp.Subdomain, newApp.Subdomain)
+			fields.compare("sharing_level", oldApp.SharingLevel, newApp.SharingLevel)
+			fields.compare("healthcheck", oldApp.Healthcheck, newApp.Healthcheck)
+			if len(fields) > 0 {
+				changes = append(changes, templatePlanChange{Action: templatePlanActionChange, Kind: templatePlanKindApp, Name: path, Fields: fields})
+			}
+		}
+	}
+	return changes
+}
+
+func diffTemplateParameterSchemas(active, planned []codersdk.ParameterSchema) []templatePlanChange {
+	activeByName := make(map[string]codersdk.ParameterSchema, len(active))
+	for _, schema := range active {
+		activeByName[schema.Name] = schema
+	}
+	plannedByName := make(map[string]codersdk.ParameterSchema, len(planned))
+	for _, schema := range planned {
+		plannedByName[schema.Name] = schema
+	}
+
+	changes := make([]templatePlanChange, 0)
+	for _, name := range sortedKeys(activeByName, plannedByName) {
+		oldSchema, inActive := activeByName[name]
+		newSchema, inPlanned := plannedByName[name]
+		switch {
+		case !inActive:
+			changes = append(changes, templatePlanChange{Action: templatePlanActionAdd, Kind: templatePlanKindParameter, Name: name})
+		case !inPlanned:
+			changes = append(changes, templatePlanChange{Action: templatePlanActionRemove, Kind: templatePlanKindParameter, Name: name})
+		default:
+			var fields planFields
+			fields.compare("description", oldSchema.Description, newSchema.Description)
+			fields.compare("default_source_scheme", oldSchema.DefaultSourceScheme, newSchema.DefaultSourceScheme)
+			fields.compare("default_source_value", oldSchema.DefaultSourceValue, newSchema.DefaultSourceValue)
+			fields.compare("allow_override_source", oldSchema.AllowOverrideSource, newSchema.AllowOverrideSource)
+			fields.compare("default_destination_scheme", oldSchema.DefaultDestinationScheme, newSchema.DefaultDestinationScheme)
+			fields.compare("allow_override_destination", oldSchema.AllowOverrideDestination, newSchema.AllowOverrideDestination)
+			fields.compare("redisplay_value", oldSchema.RedisplayValue, newSchema.RedisplayValue)
+			fields.compare("validation_condition", oldSchema.ValidationCondition, newSchema.ValidationCondition)
+			fields.compare("validation_error", oldSchema.ValidationError, newSchema.ValidationError)
+			fields.compare("validation_type_system", oldSchema.ValidationTypeSystem, newSchema.ValidationTypeSystem)
+			fields.compare("validation_value_type", oldSchema.ValidationValueType, newSchema.ValidationValueType)
+			if len(fields) > 0 {
+				changes = append(changes, templatePlanChange{Action: templatePlanActionChange, Kind: templatePlanKindParameter, Name: name, Fields: fields})
+			}
+		}
+	}
+	return changes
+}
+
+// planFields collects the names of attributes that differ.
+type planFields []string
+
+func (f *planFields) compare(name string, oldVal, newVal interface{}) {
+	if !reflect.DeepEqual(oldVal, newVal) {
+		*f = append(*f, name)
+	}
+}
+
+// sortedKeys returns the union of the keys in both maps in sorted order.
+func sortedKeys[T any](a, b map[string]T) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for key := range a {
+		keys = append(keys, key)
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func displayTemplatePlan(writer io.Writer, changes []templatePlanChange) {
+	if len(changes) == 0 {
+		_, _ = fmt.Fprintln(writer, cliui.Styles.Paragraph.Render("No changes. The template source matches the active version."))
+		return
+	}
+
+	var added, changed, removed int
+	for _, change := range changes {
+		var line string
+		switch change.Action {
+		case templatePlanActionAdd:
+			added++
+			line = cliui.Styles.Keyword.Render("+ " + change.Kind + " " + change.Name)
+		case templatePlanActionRemove:
+			removed++
+			line = cliui.Styles.Error.Render("- " + change.Kind + " " + change.Name)
+		default:
+			changed++
+			line = cliui.Styles.Warn.Render("~ "+change.Kind+" "+change.Name) + " " +
+				cliui.Styles.Placeholder.Render(fmt.Sprintf("%v", change.Fields))
+		}
+		_, _ = fmt.Fprintln(writer, line)
+	}
+	_, _ = fmt.Fprintf(writer, "\nPlan: %d to add, %d to change, %d to remove.\n", added, changed, removed)
 }
@@ -0,0 +1,105 @@
+package cli_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/cli/clitest"
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/provisioner/echo"
+	"github.com/coder/coder/provisionersdk/proto"
+)
+
+func TestTemplatePlan(t *testing.T) {
+	t.Parallel()
+	t.Run("NoChanges", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		_ = coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+
+		source := clitest.CreateTemplateVersionSource(t, &echo.Responses{
+			Parse:          echo.ParseComplete,
+			ProvisionApply: echo.ProvisionComplete,
+		})
+		cmd, root := clitest.New(t, "templates", "plan", template.Name, "--directory", source, "--test.provisioner", string(database.ProvisionerTypeEcho), "--exit-code")
+		clitest.SetupConfig(t, client, root)
+		var out bytes.Buffer
+		cmd.SetOut(&out)
+		require.NoError(t, cmd.Execute())
+		require.Contains(t, out.String(), "No changes")
+
+		// Planning must not promote the uploaded version.
+		template, err := client.Template(cmd.Context(), template.ID)
+		require.NoError(t, err)
+		require.Equal(t, version.ID, template.ActiveVersionID)
+	})
+
+	t.Run("Changes", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		_ = coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+
+		source := clitest.CreateTemplateVersionSource(t, &echo.Responses{
+			Parse: createTestParseResponseWithDefault("us-east"),
+			ProvisionApply: []*proto.Provision_Response{{
+				Type: &proto.Provision_Response_Complete{
+					Complete: &proto.Provision_Complete{
+						Resources: []*proto.Resource{{
+							Name: "dev",
+							Type: "google_compute_instance",
+							Agents: []*proto.Agent{{
+								Name: "main",
+								Auth: &proto.Agent_Token{},
+								Apps: []*proto.App{{
+									Slug:        "code-server",
+									DisplayName: "code-server",
+									Url:         "http://localhost:8080",
+								}},
+							}},
+						}},
+					},
+				},
+			}},
+		})
+		cmd, root := clitest.New(t, "templates", "plan", template.Name, "--directory", source, "--test.provisioner", string(database.ProvisionerTypeEcho), "--output", "json", "--exit-code")
+		clitest.SetupConfig(t, client, root)
+		var out bytes.Buffer
+		cmd.SetOut(&out)
+		cmd.SetErr(io.Discard)
+		require.Error(t, cmd.Execute())
+
+		var plan struct {
+			ActiveVersionID string `json:"active_version_id"`
+			Changes         []struct {
+				Action string `json:"action"`
+				Kind   string `json:"kind"`
+				Name   string `json:"name"`
+			} `json:"changes"`
+		}
+		require.NoError(t, json.Unmarshal(out.Bytes(), &plan))
+		require.Equal(t, version.ID.String(), plan.ActiveVersionID)
+
+		got := map[string]string{}
+		for _, change := range plan.Changes {
+			got[change.Kind+" "+change.Name] = change.Action
+		}
+		require.Equal(t, map[string]string{
+			"resource google_compute_instance.dev":             "add",
+			"agent google_compute_instance.dev/main":           "add",
+			"app google_compute_instance.dev/main/code-server": "add",
+			"parameter region":                                 "add",
+			"parameter username":                               "add",
+		}, got)
+	})
+}
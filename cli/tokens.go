@@ -23,13 +23,17 @@ func tokens() *cobra.Command {
 				Description: "Create a token for automation",
 				Command:     "coder tokens create",
 			},
+			example{
+				Description: "Create a token that can only start and stop workspaces for 30 days",
+				Command:     "coder tokens create --name nightly --scope workspace_start_stop --lifetime 720h",
+			},
 			example{
 				Description: "List your tokens",
 				Command:     "coder tokens ls",
 			},
 			example{
-				Description: "Remove a token by ID",
-				Command:     "coder tokens rm WuoWs4ZsMX",
+				Description: "Remove a token by name or ID",
+				Command:     "coder tokens rm nightly",
 			},
 		),
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -46,16 +50,28 @@ func tokens() *cobra.Command {
 }
 
 func createToken() *cobra.Command {
+	var (
+		name      string
+		lifetime  time.Duration
+		scope     string
+		allowList []string
+	)
 	cmd := &cobra.Command{
 		Use:   "create",
-		Short: "Create a tokens",
+		Short: "Create a token",
+		Args:  cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			client, err := CreateClient(cmd)
 			if err != nil {
 				return xerrors.Errorf("create codersdk client: %w", err)
 			}
 
-			res, err := client.CreateToken(cmd.Context(), codersdk.Me, codersdk.CreateTokenRequest{})
+			res, err := client.CreateToken(cmd.Context(), codersdk.Me, codersdk.CreateTokenRequest{
+				TokenName: name,
+				Lifetime:  lifetime,
+				Scope:     codersdk.APIKeyScope(scope),
+				AllowList: allowList,
+			})
 			if err != nil {
 				return xerrors.Errorf("create tokens: %w", err)
 			}
@@ -74,11 +90,17 @@ func createToken() *cobra.Command {
 		},
 	}
 
+	cmd.Flags().StringVarP(&name, "name", "n", "", "Specify a name for the token. Defaults to a random name.")
+	cmd.Flags().DurationVar(&lifetime, "lifetime", 0, "Specify how long the token lasts. Defaults to 30 days, or the maximum token lifetime of the deployment if it's shorter.")
+	cmd.Flags().StringVar(&scope, "scope", string(codersdk.APIKeyScopeAll), "Limit what the token can do. Available scopes are: all, read, workspace_start_stop, template_push, application_connect.")
+	cmd.Flags().StringArrayVar(&allowList, "allow", nil, "Limit the scope to a workspace or template, formatted as \"workspace:<id>\" or \"template:<id>\". Can be specified multiple times.")
 	return cmd
 }
 
 type tokenRow struct {
 	ID        string    `table:"ID"`
+	Name      string    `table:"Name"`
+	Scope     string    `table:"Scope"`
 	LastUsed  time.Time `table:"Last Used"`
 	ExpiresAt time.Time `table:"Expires At"`
 	CreatedAt time.Time `table:"Created At"`
@@ -97,7 +119,7 @@ func listTokens() *cobra.Command {
 
 			keys, err := client.GetTokens(cmd.Context(), codersdk.Me)
 			if err != nil {
-				return xerrors.Errorf("create tokens: %w", err)
+				return xerrors.Errorf("list tokens: %w", err)
 			}
 
 			if len(keys) == 0 {
@@ -108,8 +130,14 @@ func listTokens() *cobra.Command {
 
 			var rows []tokenRow
 			for _, key := range keys {
+				scope := string(key.Scope)
+				if len(key.AllowList) > 0 {
+					scope += " (" + strings.Join(key.AllowList, ", ") + ")"
+				}
 				rows = append(rows, tokenRow{
 					ID:        key.ID,
+					Name:      key.TokenName,
+					Scope:     scope,
 					LastUsed:  key.LastUsed,
 					ExpiresAt: key.ExpiresAt,
 					CreatedAt: key.CreatedAt,
@@ -131,7 +159,7 @@ func listTokens() *cobra.Command {
 
 func removeToken() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:     "remove [id]",
+		Use:     "remove <name|id>",
 		Aliases: []string{"rm"},
 		Short:   "Delete a token",
 		Args:    cobra.ExactArgs(1),
@@ -141,7 +169,15 @@ func removeToken() *cobra.Command {
 				return xerrors.Errorf("create codersdk client: %w", err)
 			}
 
-			err = client.DeleteAPIKey(cmd.Context(), codersdk.Me, args[0])
+			// Tokens are looked up by name first, and fall back to the
+			// argument being an ID.
+			id := args[0]
+			token, err := client.APIKeyByName(cmd.Context(), codersdk.Me, args[0])
+			if err == nil {
+				id = token.ID
+			}
+
+			err = client.DeleteAPIKey(cmd.Context(), codersdk.Me, id)
 			if err != nil {
 				return xerrors.Errorf("delete api key: %w", err)
 			}
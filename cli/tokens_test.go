@@ -63,4 +63,36 @@ func TestTokens(t *testing.T) {
 	res = buf.String()
 	require.NotEmpty(t, res)
 	require.Contains(t, res, "deleted")
+
+	cmd, root = clitest.New(t, "tokens", "create", "--name", "ci", "--scope", "read", "--lifetime", "24h")
+	clitest.SetupConfig(t, client, root)
+	buf = new(bytes.Buffer)
+	cmd.SetOut(buf)
+	err = cmd.Execute()
+	require.NoError(t, err)
+	require.Regexp(t, r, buf.String())
+
+	cmd, root = clitest.New(t, "tokens", "ls")
+	clitest.SetupConfig(t, client, root)
+	buf = new(bytes.Buffer)
+	cmd.SetOut(buf)
+	err = cmd.Execute()
+	require.NoError(t, err)
+	res = buf.String()
+	require.Contains(t, res, "ci")
+	require.Contains(t, res, "read")
+
+	// Names are unique.
+	cmd, root = clitest.New(t, "tokens", "create", "--name", "ci")
+	clitest.SetupConfig(t, client, root)
+	err = cmd.Execute()
+	require.ErrorContains(t, err, "already exists")
+
+	cmd, root = clitest.New(t, "tokens", "rm", "ci")
+	clitest.SetupConfig(t, client, root)
+	buf = new(bytes.Buffer)
+	cmd.SetOut(buf)
+	err = cmd.Execute()
+	require.NoError(t, err)
+	require.Contains(t, buf.String(), "deleted")
 }
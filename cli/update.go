@@ -39,27 +39,34 @@ func update() *cobra.Command {
 			}
 
 			var existingParams []codersdk.Parameter
+			var existingRichParams []codersdk.WorkspaceBuildParameter
 			if !alwaysPrompt {
 				existingParams, err = client.Parameters(cmd.Context(), codersdk.ParameterWorkspace, workspace.ID)
 				if err != nil {
 					return nil
 				}
+				existingRichParams, err = client.WorkspaceBuildParameters(cmd.Context(), workspace.LatestBuild.ID)
+				if err != nil {
+					return err
+				}
 			}
 
-			parameters, err := prepWorkspaceBuild(cmd, client, prepWorkspaceBuildArgs{
-				Template:         template,
-				ExistingParams:   existingParams,
-				ParameterFile:    parameterFile,
-				NewWorkspaceName: workspace.Name,
+			buildParams, err := prepWorkspaceBuild(cmd, client, prepWorkspaceBuildArgs{
+				Template:           template,
+				ExistingParams:     existingParams,
+				ExistingRichParams: existingRichParams,
+				ParameterFile:      parameterFile,
+				NewWorkspaceName:   workspace.Name,
 			})
 			if err != nil {
 				return nil
 			}
 
 			build, err := client.CreateWorkspaceBuild(cmd.Context(), workspace.ID, codersdk.CreateWorkspaceBuildRequest{
-				TemplateVersionID: template.ActiveVersionID,
-				Transition:        workspace.LatestBuild.Transition,
-				ParameterValues:   parameters,
+				TemplateVersionID:   template.ActiveVersionID,
+				Transition:          workspace.LatestBuild.Transition,
+				ParameterValues:     buildParams.parameters,
+				RichParameterValues: buildParams.richParameters,
 			})
 			if err != nil {
 				return err
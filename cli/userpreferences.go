@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/codersdk"
+)
+
+func userPreferences() *cobra.Command {
+	var autoStartOnConnect bool
+	cmd := &cobra.Command{
+		Use:   "preferences [username|user_id]",
+		Short: "Show or edit the preferences of a user. Defaults to your own preferences",
+		Args:  cobra.MaximumNArgs(1),
+		Example: formatExamples(
+			example{
+				Description: "Start your stopped workspaces when connecting to them with \"coder ssh\"",
+				Command:     "coder users preferences --auto-start-on-connect",
+			},
+		),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := CreateClient(cmd)
+			if err != nil {
+				return err
+			}
+			identifier := codersdk.Me
+			if len(args) > 0 {
+				identifier = args[0]
+			}
+
+			user, err := client.User(cmd.Context(), identifier)
+			if err != nil {
+				return xerrors.Errorf("fetch user: %w", err)
+			}
+			if cmd.Flags().Changed("auto-start-on-connect") {
+				user, err = client.UpdateUserPreferences(cmd.Context(), user.ID.String(), codersdk.UpdateUserPreferencesRequest{
+					AutoStartOnConnect: autoStartOnConnect,
+				})
+				if err != nil {
+					return xerrors.Errorf("update user preferences: %w", err)
+				}
+			}
+
+			_, err = fmt.Fprintf(cmd.OutOrStdout(), "Auto start on connect: %t\n", user.AutoStartOnConnect)
+			return err
+		},
+	}
+	cmd.Flags().BoolVar(&autoStartOnConnect, "auto-start-on-connect", false, "Specifies whether stopped workspaces are started by \"coder ssh\", \"coder port-forward\", \"coder speedtest\" and app access.")
+	return cmd
+}
@@ -19,6 +19,7 @@ func users() *cobra.Command {
 		userCreate(),
 		userList(),
 		userSingle(),
+		userPreferences(),
 		createUserStatusCommand(codersdk.UserStatusActive),
 		createUserStatusCommand(codersdk.UserStatusSuspended),
 	)
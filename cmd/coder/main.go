@@ -20,6 +20,10 @@ func main() {
 		if errors.Is(err, cliui.Canceled) {
 			os.Exit(1)
 		}
+		var exitErr *cli.ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.Code)
+		}
 		cobraErr := cli.FormatCobraError(err, cmd)
 		_, _ = fmt.Fprintln(os.Stderr, cobraErr)
 		os.Exit(1)
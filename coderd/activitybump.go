@@ -9,6 +9,7 @@ import (
 	"golang.org/x/xerrors"
 
 	"cdr.dev/slog"
+	"github.com/coder/coder/coderd/autobuild/schedule"
 	"github.com/coder/coder/coderd/database"
 )
 
@@ -52,7 +53,21 @@ func activityBumpWorkspace(log slog.Logger, db database.Store, workspace databas
 			return nil
 		}
 
-		newDeadline := database.Now().Add(bumpAmount)
+		template, err := s.GetTemplateByID(ctx, workspace.TemplateID)
+		if err != nil {
+			return xerrors.Errorf("get template: %w", err)
+		}
+		policy, err := schedule.NewTemplatePolicy(template)
+		if err != nil {
+			return xerrors.Errorf("parse template schedule policy: %w", err)
+		}
+
+		// The bump may not extend the deadline past the maximum allowed by the
+		// template.
+		newDeadline := policy.Deadline(job.CompletedAt.Time, database.Now().Add(bumpAmount))
+		if !newDeadline.After(build.Deadline) {
+			return nil
+		}
 
 		if _, err := s.UpdateWorkspaceBuildByID(ctx, database.UpdateWorkspaceBuildByIDParams{
 			ID:               build.ID,
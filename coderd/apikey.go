@@ -8,27 +8,48 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	"github.com/moby/moby/pkg/namesgenerator"
 	"github.com/tabbed/pqtype"
 	"golang.org/x/xerrors"
 
+	"github.com/coder/coder/coderd/audit"
 	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/coderd/httpapi"
 	"github.com/coder/coder/coderd/httpmw"
 	"github.com/coder/coder/coderd/rbac"
 	"github.com/coder/coder/coderd/telemetry"
+	"github.com/coder/coder/coderd/util/slice"
 	"github.com/coder/coder/codersdk"
 	"github.com/coder/coder/cryptorand"
 )
 
-// Creates a new token API key that effectively doesn't expire.
+// defaultTokenLifetime is the lifetime of tokens created without one, unless
+// the maximum token lifetime of the deployment is shorter.
+const defaultTokenLifetime = 30 * 24 * time.Hour
+
+// Creates a new token API key with a name, scope and lifetime.
 func (api *API) postToken(rw http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-	user := httpmw.UserParam(r)
+	var (
+		ctx               = r.Context()
+		user              = httpmw.UserParam(r)
+		auditor           = api.Auditor.Load()
+		aReq, commitAudit = audit.InitRequest[database.APIKey](rw, &audit.RequestParams{
+			Audit:   *auditor,
+			Log:     api.Logger,
+			Request: r,
+			Action:  database.AuditActionCreate,
+		})
+	)
+	defer commitAudit()
 
+	if !unrestrictedAPIKey(rw, r) {
+		return
+	}
 	if !api.Authorize(r, rbac.ActionCreate, rbac.ResourceAPIKey.WithOwner(user.ID.String())) {
 		httpapi.ResourceNotFound(rw)
 		return
@@ -40,19 +61,102 @@ func (api *API) postToken(rw http.ResponseWriter, r *http.Request) {
 	}
 
 	scope := database.APIKeyScopeAll
-	if scope != "" {
+	if createToken.Scope != "" {
 		scope = database.APIKeyScope(createToken.Scope)
 	}
+	if !validAPIKeyScope(scope) {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: fmt.Sprintf("Invalid scope %q.", scope),
+			Validations: []codersdk.ValidationError{
+				{Field: "scope", Detail: "must be one of all, application_connect, read, workspace_start_stop or template_push"},
+			},
+		})
+		return
+	}
+
+	maxLifetime := api.DeploymentConfig.MaxTokenLifetime.Value
+	lifetime := createToken.Lifetime
+	if lifetime == 0 {
+		lifetime = defaultTokenLifetime
+		if lifetime > maxLifetime {
+			lifetime = maxLifetime
+		}
+	}
+	if lifetime < 0 || lifetime > maxLifetime {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: fmt.Sprintf("Lifetime must be positive and at most %s.", maxLifetime),
+			Validations: []codersdk.ValidationError{
+				{Field: "lifetime", Detail: fmt.Sprintf("must be at most the maximum token lifetime of %s", maxLifetime)},
+			},
+		})
+		return
+	}
+
+	tokenName := createToken.TokenName
+	if tokenName == "" {
+		tokenName = strings.ReplaceAll(namesgenerator.GetRandomName(1), "_", "-")
+	}
+	if err := httpapi.NameValid(tokenName); err != nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: fmt.Sprintf("Invalid token name %q.", tokenName),
+			Validations: []codersdk.ValidationError{
+				{Field: "token_name", Detail: err.Error()},
+			},
+		})
+		return
+	}
+
+	allowList := make([]string, 0, len(createToken.AllowList))
+	for _, element := range createToken.AllowList {
+		if err := validAllowListElement(element); err != nil {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: fmt.Sprintf("Invalid allow list element %q.", element),
+				Validations: []codersdk.ValidationError{
+					{Field: "allow_list", Detail: err.Error()},
+				},
+			})
+			return
+		}
+		allowList = append(allowList, element)
+	}
 
-	// tokens last 100 years
-	lifeTime := time.Hour * 876000
-	cookie, err := api.createAPIKey(ctx, createAPIKeyParams{
+	_, err := api.Database.GetAPIKeyByName(ctx, database.GetAPIKeyByNameParams{
+		UserID:    user.ID,
+		TokenName: tokenName,
+	})
+	if err == nil {
+		httpapi.Write(ctx, rw, http.StatusConflict, codersdk.Response{
+			Message: fmt.Sprintf("A token named %q already exists.", tokenName),
+			Validations: []codersdk.ValidationError{
+				{Field: "token_name", Detail: "must be unique"},
+			},
+		})
+		return
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching API key.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	cookie, key, err := api.createAPIKey(ctx, createAPIKeyParams{
 		UserID:          user.ID,
+		RemoteAddr:      r.RemoteAddr,
 		LoginType:       database.LoginTypeToken,
-		ExpiresAt:       database.Now().Add(lifeTime),
+		ExpiresAt:       database.Now().Add(lifetime),
 		Scope:           scope,
-		LifetimeSeconds: int64(lifeTime.Seconds()),
+		LifetimeSeconds: int64(lifetime.Seconds()),
+		TokenName:       tokenName,
+		AllowList:       allowList,
 	})
+	if database.IsUniqueViolation(err) {
+		httpapi.Write(ctx, rw, http.StatusConflict, codersdk.Response{
+			Message: fmt.Sprintf("A token named %q already exists.", tokenName),
+		})
+		return
+	}
 	if err != nil {
 		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
 			Message: "Failed to create API key.",
@@ -60,6 +164,7 @@ func (api *API) postToken(rw http.ResponseWriter, r *http.Request) {
 		})
 		return
 	}
+	aReq.New = *key
 
 	httpapi.Write(ctx, rw, http.StatusCreated, codersdk.GenerateAPIKeyResponse{Key: cookie.Value})
 }
@@ -70,13 +175,16 @@ func (api *API) postAPIKey(rw http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	user := httpmw.UserParam(r)
 
+	if !unrestrictedAPIKey(rw, r) {
+		return
+	}
 	if !api.Authorize(r, rbac.ActionCreate, rbac.ResourceAPIKey.WithOwner(user.ID.String())) {
 		httpapi.ResourceNotFound(rw)
 		return
 	}
 
 	lifeTime := time.Hour * 24 * 7
-	cookie, err := api.createAPIKey(ctx, createAPIKeyParams{
+	cookie, _, err := api.createAPIKey(ctx, createAPIKeyParams{
 		UserID:     user.ID,
 		LoginType:  database.LoginTypePassword,
 		RemoteAddr: r.RemoteAddr,
@@ -100,6 +208,21 @@ func (api *API) postAPIKey(rw http.ResponseWriter, r *http.Request) {
 	httpapi.Write(ctx, rw, http.StatusCreated, codersdk.GenerateAPIKeyResponse{Key: cookie.Value})
 }
 
+// unrestrictedAPIKey returns whether the API key of the request has the all
+// scope and no allow list. Only those keys can create API keys, so a scoped
+// token can't be used to create a broader one. A response has been written if
+// it returns false.
+func unrestrictedAPIKey(rw http.ResponseWriter, r *http.Request) bool {
+	key := httpmw.APIKey(r)
+	if key.Scope == database.APIKeyScopeAll && len(key.AllowList) == 0 {
+		return true
+	}
+	httpapi.Write(r.Context(), rw, http.StatusForbidden, codersdk.Response{
+		Message: "API keys can only be created with an API key that has the all scope and no allow list.",
+	})
+	return false
+}
+
 func (api *API) apiKey(rw http.ResponseWriter, r *http.Request) {
 	var (
 		ctx  = r.Context()
@@ -113,7 +236,7 @@ func (api *API) apiKey(rw http.ResponseWriter, r *http.Request) {
 
 	keyID := chi.URLParam(r, "keyid")
 	key, err := api.Database.GetAPIKeyByID(ctx, keyID)
-	if errors.Is(err, sql.ErrNoRows) {
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && key.UserID != user.ID) {
 		httpapi.ResourceNotFound(rw)
 		return
 	}
@@ -128,7 +251,7 @@ func (api *API) apiKey(rw http.ResponseWriter, r *http.Request) {
 	httpapi.Write(ctx, rw, http.StatusOK, convertAPIKey(key))
 }
 
-func (api *API) tokens(rw http.ResponseWriter, r *http.Request) {
+func (api *API) apiKeyByName(rw http.ResponseWriter, r *http.Request) {
 	var (
 		ctx  = r.Context()
 		user = httpmw.UserParam(r)
@@ -139,11 +262,40 @@ func (api *API) tokens(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	keys, err := api.Database.GetAPIKeysByLoginType(ctx, database.LoginTypeToken)
+	key, err := api.Database.GetAPIKeyByName(ctx, database.GetAPIKeyByNameParams{
+		UserID:    user.ID,
+		TokenName: chi.URLParam(r, "keyname"),
+	})
 	if errors.Is(err, sql.ErrNoRows) {
-		httpapi.Write(ctx, rw, http.StatusOK, []codersdk.APIKey{})
+		httpapi.ResourceNotFound(rw)
 		return
 	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching API key.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, convertAPIKey(key))
+}
+
+func (api *API) tokens(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx  = r.Context()
+		user = httpmw.UserParam(r)
+	)
+
+	if !api.Authorize(r, rbac.ActionRead, rbac.ResourceAPIKey.WithOwner(user.ID.String())) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	keys, err := api.Database.GetAPIKeysByUserID(ctx, database.GetAPIKeysByUserIDParams{
+		LoginType: database.LoginTypeToken,
+		UserID:    user.ID,
+	})
 	if err != nil {
 		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
 			Message: "Internal error fetching API keys.",
@@ -152,7 +304,7 @@ func (api *API) tokens(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var apiKeys []codersdk.APIKey
+	apiKeys := make([]codersdk.APIKey, 0, len(keys))
 	for _, key := range keys {
 		apiKeys = append(apiKeys, convertAPIKey(key))
 	}
@@ -162,9 +314,17 @@ func (api *API) tokens(rw http.ResponseWriter, r *http.Request) {
 
 func (api *API) deleteAPIKey(rw http.ResponseWriter, r *http.Request) {
 	var (
-		ctx  = r.Context()
-		user = httpmw.UserParam(r)
+		ctx               = r.Context()
+		user              = httpmw.UserParam(r)
+		auditor           = api.Auditor.Load()
+		aReq, commitAudit = audit.InitRequest[database.APIKey](rw, &audit.RequestParams{
+			Audit:   *auditor,
+			Log:     api.Logger,
+			Request: r,
+			Action:  database.AuditActionDelete,
+		})
 	)
+	defer commitAudit()
 
 	if !api.Authorize(r, rbac.ActionDelete, rbac.ResourceAPIKey.WithOwner(user.ID.String())) {
 		httpapi.ResourceNotFound(rw)
@@ -172,7 +332,21 @@ func (api *API) deleteAPIKey(rw http.ResponseWriter, r *http.Request) {
 	}
 
 	keyID := chi.URLParam(r, "keyid")
-	err := api.Database.DeleteAPIKeyByID(ctx, keyID)
+	key, err := api.Database.GetAPIKeyByID(ctx, keyID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && key.UserID != user.ID) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching API key.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	aReq.Old = key
+
+	err = api.Database.DeleteAPIKeyByID(ctx, keyID)
 	if errors.Is(err, sql.ErrNoRows) {
 		httpapi.ResourceNotFound(rw)
 		return
@@ -188,6 +362,16 @@ func (api *API) deleteAPIKey(rw http.ResponseWriter, r *http.Request) {
 	httpapi.Write(ctx, rw, http.StatusNoContent, nil)
 }
 
+// AuditTokenUse records the use of a token in the audit log. The API key
+// middleware calls it at most once an hour for each token.
+func (api *API) AuditTokenUse(r *http.Request, key database.APIKey) {
+	audit.RecordUse(r.Context(), &audit.RequestParams{
+		Audit:   *api.Auditor.Load(),
+		Log:     api.Logger,
+		Request: r,
+	}, key.UserID, key)
+}
+
 // Generates a new ID and secret for an API key.
 func generateAPIKeyIDSecret() (id string, secret string, err error) {
 	// Length of an API Key ID.
@@ -212,12 +396,45 @@ type createAPIKeyParams struct {
 	ExpiresAt       time.Time
 	LifetimeSeconds int64
 	Scope           database.APIKeyScope
+	TokenName       string
+	AllowList       []string
 }
 
-func (api *API) createAPIKey(ctx context.Context, params createAPIKeyParams) (*http.Cookie, error) {
+// validAPIKeyScope returns whether the scope is known.
+func validAPIKeyScope(scope database.APIKeyScope) bool {
+	switch scope {
+	case database.APIKeyScopeAll, database.APIKeyScopeApplicationConnect,
+		database.APIKeyScopeRead, database.APIKeyScopeWorkspaceStartStop,
+		database.APIKeyScopeTemplatePush:
+		return true
+	default:
+		return false
+	}
+}
+
+// validAllowListElement returns an error if the element isn't a supported
+// resource type and an ID or the wildcard, formatted as "type:id".
+func validAllowListElement(element string) error {
+	resourceType, id, ok := strings.Cut(element, ":")
+	if !ok {
+		return xerrors.New(`must be formatted as "type:id"`)
+	}
+	if !slice.Contains(rbac.AllowListTypes, resourceType) {
+		return xerrors.Errorf("type must be one of %s", strings.Join(rbac.AllowListTypes, ", "))
+	}
+	if id == rbac.WildcardSymbol {
+		return nil
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return xerrors.Errorf("id must be a UUID or %q", rbac.WildcardSymbol)
+	}
+	return nil
+}
+
+func (api *API) createAPIKey(ctx context.Context, params createAPIKeyParams) (*http.Cookie, *database.APIKey, error) {
 	keyID, keySecret, err := generateAPIKeyIDSecret()
 	if err != nil {
-		return nil, xerrors.Errorf("generate API key: %w", err)
+		return nil, nil, xerrors.Errorf("generate API key: %w", err)
 	}
 	hashed := sha256.Sum256([]byte(keySecret))
 
@@ -240,10 +457,12 @@ func (api *API) createAPIKey(ctx context.Context, params createAPIKeyParams) (*h
 	if params.Scope != "" {
 		scope = params.Scope
 	}
-	switch scope {
-	case database.APIKeyScopeAll, database.APIKeyScopeApplicationConnect:
-	default:
-		return nil, xerrors.Errorf("invalid API key scope: %q", scope)
+	if !validAPIKeyScope(scope) {
+		return nil, nil, xerrors.Errorf("invalid API key scope: %q", scope)
+	}
+	allowList := params.AllowList
+	if allowList == nil {
+		allowList = []string{}
 	}
 
 	key, err := api.Database.InsertAPIKey(ctx, database.InsertAPIKeyParams{
@@ -264,9 +483,11 @@ func (api *API) createAPIKey(ctx context.Context, params createAPIKeyParams) (*h
 		HashedSecret: hashed[:],
 		LoginType:    params.LoginType,
 		Scope:        scope,
+		TokenName:    params.TokenName,
+		AllowList:    allowList,
 	})
 	if err != nil {
-		return nil, xerrors.Errorf("insert API key: %w", err)
+		return nil, nil, xerrors.Errorf("insert API key: %w", err)
 	}
 
 	api.Telemetry.Report(&telemetry.Snapshot{
@@ -282,5 +503,5 @@ func (api *API) createAPIKey(ctx context.Context, params createAPIKeyParams) (*h
 		HttpOnly: true,
 		SameSite: http.SameSiteLaxMode,
 		Secure:   api.SecureAuthCookie,
-	}, nil
+	}, &key, nil
 }
@@ -2,12 +2,16 @@ package coderd_test
 
 import (
 	"context"
+	"net/http"
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 
+	"github.com/coder/coder/coderd/audit"
 	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/codersdk"
 	"github.com/coder/coder/testutil"
 )
@@ -34,9 +38,11 @@ func TestTokens(t *testing.T) {
 		require.NoError(t, err)
 		require.EqualValues(t, len(keys), 1)
 		require.Contains(t, res.Key, keys[0].ID)
-		// expires_at must be greater than 50 years
-		require.Greater(t, keys[0].ExpiresAt, time.Now().Add(time.Hour*438300))
+		// Tokens last 30 days by default.
+		require.WithinDuration(t, time.Now().Add(30*24*time.Hour), keys[0].ExpiresAt, time.Minute)
 		require.Equal(t, codersdk.APIKeyScopeAll, keys[0].Scope)
+		// A name is generated if it's not provided.
+		require.NotEmpty(t, keys[0].TokenName)
 
 		// no update
 
@@ -65,10 +71,222 @@ func TestTokens(t *testing.T) {
 		require.NoError(t, err)
 		require.EqualValues(t, len(keys), 1)
 		require.Contains(t, res.Key, keys[0].ID)
-		// expires_at must be greater than 50 years
-		require.Greater(t, keys[0].ExpiresAt, time.Now().Add(time.Hour*438300))
 		require.Equal(t, keys[0].Scope, codersdk.APIKeyScopeApplicationConnect)
 	})
+
+	t.Run("Named", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+
+		res, err := client.CreateToken(ctx, codersdk.Me, codersdk.CreateTokenRequest{
+			TokenName: "ci",
+		})
+		require.NoError(t, err)
+
+		key, err := client.APIKeyByName(ctx, codersdk.Me, "ci")
+		require.NoError(t, err)
+		require.Contains(t, res.Key, key.ID)
+		require.Equal(t, "ci", key.TokenName)
+
+		_, err = client.CreateToken(ctx, codersdk.Me, codersdk.CreateTokenRequest{
+			TokenName: "ci",
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusConflict, apiErr.StatusCode())
+
+		// Names are unique per user, and users only list their own tokens.
+		member := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+		_, err = member.CreateToken(ctx, codersdk.Me, codersdk.CreateTokenRequest{
+			TokenName: "ci",
+		})
+		require.NoError(t, err)
+		keys, err := member.GetTokens(ctx, codersdk.Me)
+		require.NoError(t, err)
+		require.Len(t, keys, 1)
+		require.NotEqual(t, key.ID, keys[0].ID)
+
+		// Members can't see or delete the tokens of other users.
+		err = member.DeleteAPIKey(ctx, codersdk.Me, key.ID)
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+	})
+
+	t.Run("Lifetime", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		dc := coderdtest.DeploymentConfig(t)
+		dc.MaxTokenLifetime.Value = 7 * 24 * time.Hour
+		client := coderdtest.New(t, &coderdtest.Options{DeploymentConfig: dc})
+		_ = coderdtest.CreateFirstUser(t, client)
+
+		// The default lifetime is limited by the maximum.
+		_, err := client.CreateToken(ctx, codersdk.Me, codersdk.CreateTokenRequest{
+			TokenName: "default",
+		})
+		require.NoError(t, err)
+		key, err := client.APIKeyByName(ctx, codersdk.Me, "default")
+		require.NoError(t, err)
+		require.WithinDuration(t, time.Now().Add(7*24*time.Hour), key.ExpiresAt, time.Minute)
+
+		_, err = client.CreateToken(ctx, codersdk.Me, codersdk.CreateTokenRequest{
+			TokenName: "short",
+			Lifetime:  time.Hour,
+		})
+		require.NoError(t, err)
+		key, err = client.APIKeyByName(ctx, codersdk.Me, "short")
+		require.NoError(t, err)
+		require.WithinDuration(t, time.Now().Add(time.Hour), key.ExpiresAt, time.Minute)
+
+		_, err = client.CreateToken(ctx, codersdk.Me, codersdk.CreateTokenRequest{
+			TokenName: "long",
+			Lifetime:  8 * 24 * time.Hour,
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+	})
+
+	t.Run("ReadScope", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		client := coderdtest.New(t, nil)
+		_ = coderdtest.CreateFirstUser(t, client)
+
+		res, err := client.CreateToken(ctx, codersdk.Me, codersdk.CreateTokenRequest{
+			Scope: codersdk.APIKeyScopeRead,
+		})
+		require.NoError(t, err)
+		scoped := codersdk.New(client.URL)
+		scoped.SetSessionToken(res.Key)
+
+		_, err = scoped.User(ctx, codersdk.Me)
+		require.NoError(t, err)
+		_, err = scoped.CreateToken(ctx, codersdk.Me, codersdk.CreateTokenRequest{})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusForbidden, apiErr.StatusCode())
+	})
+
+	t.Run("NoEscalation", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		client := coderdtest.New(t, nil)
+		_ = coderdtest.CreateFirstUser(t, client)
+
+		// Tokens with a scope or an allow list can't create tokens, which
+		// would have the all scope and no allow list.
+		for _, req := range []codersdk.CreateTokenRequest{
+			{Scope: codersdk.APIKeyScopeWorkspaceStartStop},
+			{Scope: codersdk.APIKeyScopeTemplatePush},
+			{AllowList: []string{"workspace:" + uuid.NewString()}},
+		} {
+			res, err := client.CreateToken(ctx, codersdk.Me, req)
+			require.NoError(t, err)
+			scoped := codersdk.New(client.URL)
+			scoped.SetSessionToken(res.Key)
+
+			_, err = scoped.CreateToken(ctx, codersdk.Me, codersdk.CreateTokenRequest{})
+			var apiErr *codersdk.Error
+			require.ErrorAs(t, err, &apiErr)
+			require.Equal(t, http.StatusForbidden, apiErr.StatusCode())
+			_, err = scoped.CreateAPIKey(ctx, codersdk.Me)
+			require.ErrorAs(t, err, &apiErr)
+			require.Equal(t, http.StatusForbidden, apiErr.StatusCode())
+		}
+	})
+
+	t.Run("AllowList", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		allowed := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+		denied := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+
+		_, err := client.CreateToken(ctx, codersdk.Me, codersdk.CreateTokenRequest{
+			Scope:     codersdk.APIKeyScopeWorkspaceStartStop,
+			AllowList: []string{"workspace:not-a-uuid"},
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+
+		res, err := client.CreateToken(ctx, codersdk.Me, codersdk.CreateTokenRequest{
+			Scope:     codersdk.APIKeyScopeWorkspaceStartStop,
+			AllowList: []string{"workspace:" + allowed.ID.String()},
+		})
+		require.NoError(t, err)
+		scoped := codersdk.New(client.URL)
+		scoped.SetSessionToken(res.Key)
+
+		_, err = scoped.Workspace(ctx, allowed.ID)
+		require.NoError(t, err)
+		_, err = scoped.Workspace(ctx, denied.ID)
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+
+		workspaces, err := scoped.Workspaces(ctx, codersdk.WorkspaceFilter{})
+		require.NoError(t, err)
+		require.Len(t, workspaces.Workspaces, 1)
+		require.Equal(t, allowed.ID, workspaces.Workspaces[0].ID)
+
+		// Templates aren't in the allow list, so they aren't limited.
+		_, err = scoped.Template(ctx, template.ID)
+		require.NoError(t, err)
+	})
+
+	t.Run("Audit", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		auditor := audit.NewMock()
+		client := coderdtest.New(t, &coderdtest.Options{Auditor: auditor})
+		_ = coderdtest.CreateFirstUser(t, client)
+
+		res, err := client.CreateToken(ctx, codersdk.Me, codersdk.CreateTokenRequest{
+			TokenName: "audited",
+		})
+		require.NoError(t, err)
+		scoped := codersdk.New(client.URL)
+		scoped.SetSessionToken(res.Key)
+		_, err = scoped.User(ctx, codersdk.Me)
+		require.NoError(t, err)
+		key, err := client.APIKeyByName(ctx, codersdk.Me, "audited")
+		require.NoError(t, err)
+		err = client.DeleteAPIKey(ctx, codersdk.Me, key.ID)
+		require.NoError(t, err)
+
+		var actions []database.AuditAction
+		for _, alog := range auditor.AuditLogs {
+			if alog.ResourceType != database.ResourceTypeApiKey {
+				continue
+			}
+			require.Equal(t, "audited", alog.ResourceTarget)
+			actions = append(actions, alog.Action)
+		}
+		require.Equal(t, []database.AuditAction{
+			database.AuditActionCreate,
+			database.AuditActionUse,
+			database.AuditActionDelete,
+		}, actions)
+	})
 }
 
 func TestAPIKey(t *testing.T) {
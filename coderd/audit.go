@@ -59,7 +59,7 @@ func (api *API) auditLogs(rw http.ResponseWriter, r *http.Request) {
 	}
 
 	httpapi.Write(ctx, rw, http.StatusOK, codersdk.AuditLogResponse{
-		AuditLogs: convertAuditLogs(dblogs),
+		AuditLogs: convertAuditLogs(dblogs, api.CustomRoles),
 	})
 }
 
@@ -172,17 +172,17 @@ func (api *API) generateFakeAuditLog(rw http.ResponseWriter, r *http.Request) {
 	rw.WriteHeader(http.StatusNoContent)
 }
 
-func convertAuditLogs(dblogs []database.GetAuditLogsOffsetRow) []codersdk.AuditLog {
+func convertAuditLogs(dblogs []database.GetAuditLogsOffsetRow, customRoles *rbac.CustomRoles) []codersdk.AuditLog {
 	alogs := make([]codersdk.AuditLog, 0, len(dblogs))
 
 	for _, dblog := range dblogs {
-		alogs = append(alogs, convertAuditLog(dblog))
+		alogs = append(alogs, convertAuditLog(dblog, customRoles))
 	}
 
 	return alogs
 }
 
-func convertAuditLog(dblog database.GetAuditLogsOffsetRow) codersdk.AuditLog {
+func convertAuditLog(dblog database.GetAuditLogsOffsetRow, customRoles *rbac.CustomRoles) codersdk.AuditLog {
 	ip, _ := netip.AddrFromSlice(dblog.Ip.IPNet.IP)
 
 	diff := codersdk.AuditDiff{}
@@ -201,8 +201,7 @@ func convertAuditLog(dblog database.GetAuditLogsOffsetRow) codersdk.AuditLog {
 		}
 
 		for _, roleName := range dblog.UserRoles {
-			rbacRole, _ := rbac.RoleByName(roleName)
-			user.Roles = append(user.Roles, convertRole(rbacRole))
+			user.Roles = append(user.Roles, convertRoleName(customRoles, roleName))
 		}
 	}
 
@@ -330,6 +329,12 @@ func resourceTypeFromString(resourceTypeString string) string {
 		return resourceTypeString
 	case codersdk.ResourceTypeAPIKey:
 		return resourceTypeString
+	case codersdk.ResourceTypeCustomRole:
+		return resourceTypeString
+	case codersdk.ResourceTypeOrganizationMember:
+		return resourceTypeString
+	case codersdk.ResourceTypeWebhook:
+		return resourceTypeString
 	}
 	return ""
 }
@@ -342,6 +347,8 @@ func actionFromString(actionString string) string {
 		return actionString
 	case codersdk.AuditActionDelete:
 		return actionString
+	case codersdk.AuditActionUse:
+		return actionString
 	default:
 	}
 	return ""
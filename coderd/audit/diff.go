@@ -17,7 +17,10 @@ type Auditable interface {
 		database.Workspace |
 		database.GitSSHKey |
 		database.Group |
-		database.WorkspaceBuild
+		database.WorkspaceBuild |
+		database.WorkspaceSessionRecording |
+		database.CustomRole |
+		database.Webhook
 }
 
 // Map is a map of changed fields in an audited resource. It maps field names to
@@ -34,6 +34,8 @@ type Request[T Auditable] struct {
 
 func ResourceTarget[T Auditable](tgt T) string {
 	switch typed := any(tgt).(type) {
+	case database.APIKey:
+		return typed.TokenName
 	case database.Organization:
 		return typed.Name
 	case database.Template:
@@ -51,6 +53,14 @@ func ResourceTarget[T Auditable](tgt T) string {
 		return typed.PublicKey
 	case database.Group:
 		return typed.Name
+	case database.WorkspaceSessionRecording:
+		return string(typed.Type)
+	case database.CustomRole:
+		return typed.RoleName()
+	case database.OrganizationMember:
+		return typed.UserID.String()
+	case database.Webhook:
+		return typed.Name
 	default:
 		panic(fmt.Sprintf("unknown resource %T", tgt))
 	}
@@ -58,6 +68,9 @@ func ResourceTarget[T Auditable](tgt T) string {
 
 func ResourceID[T Auditable](tgt T) uuid.UUID {
 	switch typed := any(tgt).(type) {
+	case database.APIKey:
+		// API key IDs aren't UUIDs, so keys are identified by their user.
+		return typed.UserID
 	case database.Organization:
 		return typed.ID
 	case database.Template:
@@ -74,6 +87,16 @@ func ResourceID[T Auditable](tgt T) uuid.UUID {
 		return typed.UserID
 	case database.Group:
 		return typed.ID
+	case database.WorkspaceSessionRecording:
+		return typed.ID
+	case database.CustomRole:
+		return typed.ID
+	case database.OrganizationMember:
+		// Members don't have IDs of their own, so they're identified by
+		// their user.
+		return typed.UserID
+	case database.Webhook:
+		return typed.ID
 	default:
 		panic(fmt.Sprintf("unknown resource %T", tgt))
 	}
@@ -81,6 +104,8 @@ func ResourceID[T Auditable](tgt T) uuid.UUID {
 
 func ResourceType[T Auditable](tgt T) database.ResourceType {
 	switch any(tgt).(type) {
+	case database.APIKey:
+		return database.ResourceTypeApiKey
 	case database.Organization:
 		return database.ResourceTypeOrganization
 	case database.Template:
@@ -97,6 +122,14 @@ func ResourceType[T Auditable](tgt T) database.ResourceType {
 		return database.ResourceTypeGitSshKey
 	case database.Group:
 		return database.ResourceTypeGroup
+	case database.WorkspaceSessionRecording:
+		return database.ResourceTypeWorkspaceSessionRecording
+	case database.CustomRole:
+		return database.ResourceTypeCustomRole
+	case database.OrganizationMember:
+		return database.ResourceTypeOrganizationMember
+	case database.Webhook:
+		return database.ResourceTypeWebhook
 	default:
 		panic(fmt.Sprintf("unknown resource %T", tgt))
 	}
@@ -164,6 +197,99 @@ func InitRequest[T Auditable](w http.ResponseWriter, p *RequestParams) (*Request
 	}
 }
 
+// RecordUse commits an audit log for a resource used by a request, such as
+// the token authenticating it. Unlike InitRequest, it doesn't wait for the
+// request to be handled, so it can be called from middleware that hasn't
+// authenticated the request yet.
+func RecordUse[T Auditable](ctx context.Context, p *RequestParams, userID uuid.UUID, resource T) {
+	if p.AdditionalFields == nil {
+		p.AdditionalFields = json.RawMessage("{}")
+	}
+
+	err := p.Audit.Export(ctx, database.AuditLog{
+		ID:               uuid.New(),
+		Time:             database.Now(),
+		UserID:           userID,
+		Ip:               parseIP(p.Request.RemoteAddr),
+		UserAgent:        p.Request.UserAgent(),
+		ResourceType:     ResourceType(resource),
+		ResourceID:       ResourceID(resource),
+		ResourceTarget:   ResourceTarget(resource),
+		Action:           database.AuditActionUse,
+		Diff:             []byte("{}"),
+		StatusCode:       http.StatusOK,
+		RequestID:        httpmw.RequestID(p.Request),
+		AdditionalFields: p.AdditionalFields,
+	})
+	if err != nil {
+		p.Log.Error(ctx, "export audit log", slog.Error(err))
+	}
+}
+
+// BackgroundAuditParams describes an audit log for an action that was not
+// triggered by an HTTP request, such as a workspace transition performed by the
+// lifecycle executor.
+type BackgroundAuditParams[T Auditable] struct {
+	Audit Auditor
+	Log   slog.Logger
+
+	UserID           uuid.UUID
+	OrganizationID   uuid.UUID
+	Status           int
+	Action           database.AuditAction
+	AdditionalFields json.RawMessage
+
+	Old T
+	New T
+}
+
+// BackgroundAudit commits an audit log for an action performed by coderd
+// itself. The audit log is attributed to the loopback address since there is
+// no client.
+func BackgroundAudit[T Auditable](ctx context.Context, p *BackgroundAuditParams[T]) {
+	// If no resources were provided, there's nothing we can audit.
+	if ResourceID(p.Old) == uuid.Nil && ResourceID(p.New) == uuid.Nil {
+		return
+	}
+
+	var diffRaw = []byte("{}")
+	if p.Status < 400 {
+		diff := Diff(p.Audit, p.Old, p.New)
+
+		var err error
+		diffRaw, err = json.Marshal(diff)
+		if err != nil {
+			p.Log.Warn(ctx, "marshal diff", slog.Error(err))
+			diffRaw = []byte("{}")
+		}
+	}
+
+	if p.AdditionalFields == nil {
+		p.AdditionalFields = json.RawMessage("{}")
+	}
+
+	err := p.Audit.Export(ctx, database.AuditLog{
+		ID:               uuid.New(),
+		Time:             database.Now(),
+		UserID:           p.UserID,
+		OrganizationID:   p.OrganizationID,
+		Ip:               parseIP("127.0.0.1"),
+		UserAgent:        "coderd",
+		ResourceType:     either(p.Old, p.New, ResourceType[T]),
+		ResourceID:       either(p.Old, p.New, ResourceID[T]),
+		ResourceTarget:   either(p.Old, p.New, ResourceTarget[T]),
+		Action:           p.Action,
+		Diff:             diffRaw,
+		StatusCode:       int32(p.Status),
+		RequestID:        uuid.New(),
+		AdditionalFields: p.AdditionalFields,
+	})
+	if err != nil {
+		p.Log.Error(ctx, "export audit log", slog.Error(err))
+		return
+	}
+}
+
 func either[T Auditable, R any](old, new T, fn func(T) R) R {
 	if ResourceID(new) != uuid.Nil {
 		return fn(new)
@@ -2,7 +2,9 @@ package executor
 
 import (
 	"context"
-	"encoding/json"
+	"database/sql"
+	"net/http"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -10,17 +12,20 @@ import (
 	"golang.org/x/xerrors"
 
 	"cdr.dev/slog"
+	"github.com/coder/coder/coderd/audit"
 	"github.com/coder/coder/coderd/autobuild/schedule"
 	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/wsbuilder"
 )
 
-// Executor automatically starts or stops workspaces.
+// Executor automatically starts, stops or deletes workspaces.
 type Executor struct {
 	ctx     context.Context
 	db      database.Store
 	log     slog.Logger
 	tick    <-chan time.Time
 	statsCh chan<- Stats
+	auditor *atomic.Pointer[audit.Auditor]
 }
 
 // Stats contains information about one run of Executor.
@@ -48,7 +53,14 @@ func (e *Executor) WithStatsChannel(ch chan<- Stats) *Executor {
 	return e
 }
 
-// Run will cause executor to start or stop workspaces on every
+// WithAuditor will cause Executor to audit workspaces it marks for or
+// unmarks from deletion, and workspaces it deletes.
+func (e *Executor) WithAuditor(auditor *atomic.Pointer[audit.Auditor]) *Executor {
+	e.auditor = auditor
+	return e
+}
+
+// Run will cause executor to start, stop or delete workspaces on every
 // tick from its channel. It will stop when its context is Done, or when
 // its channel is closed.
 func (e *Executor) Run() {
@@ -108,9 +120,20 @@ func (e *Executor) runOnce(t time.Time) Stats {
 	}
 	workspaces := database.ConvertWorkspaceRows(workspaceRows)
 
+	templateRows, err := e.db.GetTemplates(e.ctx)
+	if err != nil {
+		e.log.Error(e.ctx, "get templates for autodelete", slog.Error(err))
+		return stats
+	}
+	templates := make(map[uuid.UUID]database.Template, len(templateRows))
+	for _, template := range templateRows {
+		templates[template.ID] = template
+	}
+
 	var eligibleWorkspaceIDs []uuid.UUID
 	for _, ws := range workspaces {
-		if isEligibleForAutoStartStop(ws) {
+		template := templates[ws.TemplateID]
+		if isEligibleForAutoStartStop(ws, template) || isEligibleForAutodelete(ws, template) {
 			eligibleWorkspaceIDs = append(eligibleWorkspaceIDs, ws.ID)
 		}
 	}
@@ -126,7 +149,10 @@ func (e *Executor) runOnce(t time.Time) Stats {
 		log := e.log.With(slog.F("workspace_id", wsID))
 
 		eg.Go(func() error {
+			// Audit logs are only committed once the transaction succeeds.
+			var auditLog *workspaceAudit
 			err := e.db.InTx(func(db database.Store) error {
+				auditLog = nil
 				// Re-check eligibility since the first check was outside the
 				// transaction and the workspace settings may have changed.
 				ws, err := db.GetWorkspaceByID(e.ctx, wsID)
@@ -134,7 +160,18 @@ func (e *Executor) runOnce(t time.Time) Stats {
 					log.Error(e.ctx, "get workspace autostart failed", slog.Error(err))
 					return nil
 				}
-				if !isEligibleForAutoStartStop(ws) {
+				template, err := db.GetTemplateByID(e.ctx, ws.TemplateID)
+				if err != nil {
+					log.Warn(e.ctx, "get workspace template", slog.Error(err))
+					return nil
+				}
+				autodelete := isEligibleForAutodelete(ws, template)
+				if !isEligibleForAutoStartStop(ws, template) && !autodelete {
+					return nil
+				}
+				policy, err := schedule.NewTemplatePolicy(template)
+				if err != nil {
+					log.Warn(e.ctx, "parse template schedule policy", slog.Error(err))
 					return nil
 				}
 
@@ -151,7 +188,29 @@ func (e *Executor) runOnce(t time.Time) Stats {
 					return nil
 				}
 
-				validTransition, nextTransition, err := getNextTransition(ws, priorHistory, priorJob)
+				if autodelete {
+					var deleted bool
+					deleted, auditLog, err = e.autodelete(db, log, t, ws, template, priorHistory, priorJob)
+					if err != nil {
+						return err
+					}
+					if deleted {
+						stats.Transitions[ws.ID] = database.WorkspaceTransitionDelete
+						return nil
+					}
+					if !isEligibleForAutoStartStop(ws, template) {
+						return nil
+					}
+				}
+
+				// The template policy may have changed since the workspace was
+				// started, so the deadline of a running workspace is clamped.
+				priorHistory, err = clampDeadline(e.ctx, db, policy, priorHistory, priorJob)
+				if err != nil {
+					return err
+				}
+
+				validTransition, nextTransition, err := getNextTransition(ws, policy, priorHistory, priorJob)
 				if err != nil {
 					log.Debug(e.ctx, "skipping workspace", slog.Error(err))
 					return nil
@@ -169,7 +228,7 @@ func (e *Executor) runOnce(t time.Time) Stats {
 				log.Info(e.ctx, "scheduling workspace transition", slog.F("transition", validTransition))
 
 				stats.Transitions[ws.ID] = validTransition
-				if err := build(e.ctx, db, ws, validTransition, priorHistory, priorJob); err != nil {
+				if err := build(e.ctx, db, ws, validTransition); err != nil {
 					log.Error(e.ctx, "unable to transition workspace",
 						slog.F("transition", validTransition),
 						slog.Error(err),
@@ -181,6 +240,10 @@ func (e *Executor) runOnce(t time.Time) Stats {
 			}, nil)
 			if err != nil {
 				log.Error(e.ctx, "workspace scheduling failed", slog.Error(err))
+				return nil
+			}
+			if auditLog != nil {
+				e.audit(*auditLog)
 			}
 			return nil
 		})
@@ -195,12 +258,165 @@ func (e *Executor) runOnce(t time.Time) Stats {
 	return stats
 }
 
-func isEligibleForAutoStartStop(ws database.Workspace) bool {
-	return !ws.Deleted && (ws.AutostartSchedule.String != "" || ws.Ttl.Int64 > 0)
+// isEligibleForAutoStartStop returns true if the workspace has an autostart
+// schedule or TTL, or if its template enforces a maximum TTL or quiet hours.
+func isEligibleForAutoStartStop(ws database.Workspace, template database.Template) bool {
+	return !ws.Deleted && (ws.AutostartSchedule.String != "" || ws.Ttl.Int64 > 0 ||
+		template.MaxTTL > 0 || template.QuietHoursSchedule != "")
+}
+
+// clampDeadline updates the deadline of a running workspace if it exceeds the
+// maximum allowed by the template policy, and returns the updated build.
+func clampDeadline(
+	ctx context.Context,
+	db database.Store,
+	policy schedule.TemplatePolicy,
+	priorHistory database.WorkspaceBuild,
+	priorJob database.ProvisionerJob,
+) (database.WorkspaceBuild, error) {
+	if priorHistory.Transition != database.WorkspaceTransitionStart ||
+		!priorJob.CompletedAt.Valid || priorJob.Error.String != "" {
+		return priorHistory, nil
+	}
+	deadline := policy.Deadline(priorJob.CompletedAt.Time, priorHistory.Deadline)
+	if deadline.Equal(priorHistory.Deadline) {
+		return priorHistory, nil
+	}
+	updated, err := db.UpdateWorkspaceBuildByID(ctx, database.UpdateWorkspaceBuildByIDParams{
+		ID:               priorHistory.ID,
+		UpdatedAt:        database.Now(),
+		ProvisionerState: priorHistory.ProvisionerState,
+		Deadline:         deadline,
+	})
+	if err != nil {
+		return priorHistory, xerrors.Errorf("clamp workspace deadline: %w", err)
+	}
+	return updated, nil
+}
+
+// isEligibleForAutodelete returns true if the workspace's template has an
+// autodelete policy, or if the workspace has been marked for deletion and may
+// need to be unmarked.
+func isEligibleForAutodelete(ws database.Workspace, template database.Template) bool {
+	return !ws.Deleted && (template.InactivityTTL > 0 || template.StoppedTTL > 0 || ws.DeletingAt.Valid)
+}
+
+// workspaceAudit is an audit log for a workspace the executor has changed.
+type workspaceAudit struct {
+	action database.AuditAction
+	old    database.Workspace
+	new    database.Workspace
+}
+
+// autodelete marks dormant workspaces for deletion for the template's warning
+// period, unmarks workspaces that are no longer dormant and deletes workspaces
+// whose warning period has elapsed. Using the workspace during the warning
+// period cancels the deletion. It returns true if a delete build was created.
+func (e *Executor) autodelete(
+	db database.Store,
+	log slog.Logger,
+	now time.Time,
+	ws database.Workspace,
+	template database.Template,
+	priorHistory database.WorkspaceBuild,
+	priorJob database.ProvisionerJob,
+) (bool, *workspaceAudit, error) {
+	dormant, err := isDormant(now, ws, template, priorHistory, priorJob)
+	if err != nil {
+		log.Debug(e.ctx, "skipping workspace autodelete", slog.Error(err))
+		return false, nil, nil
+	}
+
+	switch {
+	case dormant && !ws.DeletingAt.Valid:
+		deletingAt := now.Add(time.Duration(template.AutodeleteWarningTTL))
+		updated, err := db.UpdateWorkspaceDeletingAtByID(e.ctx, database.UpdateWorkspaceDeletingAtByIDParams{
+			ID:         ws.ID,
+			DeletingAt: sql.NullTime{Time: deletingAt, Valid: true},
+		})
+		if err != nil {
+			return false, nil, xerrors.Errorf("mark workspace for deletion: %w", err)
+		}
+		log.Info(e.ctx, "marked dormant workspace for deletion", slog.F("deleting_at", deletingAt))
+		return false, &workspaceAudit{action: database.AuditActionWrite, old: ws, new: updated}, nil
+	case !dormant && ws.DeletingAt.Valid:
+		updated, err := db.UpdateWorkspaceDeletingAtByID(e.ctx, database.UpdateWorkspaceDeletingAtByIDParams{
+			ID: ws.ID,
+		})
+		if err != nil {
+			return false, nil, xerrors.Errorf("unmark workspace for deletion: %w", err)
+		}
+		log.Info(e.ctx, "workspace is no longer dormant, cancelled deletion")
+		return false, &workspaceAudit{action: database.AuditActionWrite, old: ws, new: updated}, nil
+	case dormant && !now.Before(ws.DeletingAt.Time):
+		log.Info(e.ctx, "scheduling workspace transition", slog.F("transition", database.WorkspaceTransitionDelete))
+		if err := build(e.ctx, db, ws, database.WorkspaceTransitionDelete); err != nil {
+			return false, nil, xerrors.Errorf("delete dormant workspace: %w", err)
+		}
+		return true, &workspaceAudit{action: database.AuditActionDelete, old: ws}, nil
+	default:
+		return false, nil, nil
+	}
+}
+
+// isDormant returns true if the workspace has been unused for longer than the
+// template's inactivity TTL, or stopped for longer than its stopped TTL.
+// Activity is measured from the later of the last use and the latest build.
+func isDormant(
+	now time.Time,
+	ws database.Workspace,
+	template database.Template,
+	priorHistory database.WorkspaceBuild,
+	priorJob database.ProvisionerJob,
+) (bool, error) {
+	if !priorJob.CompletedAt.Valid {
+		return false, xerrors.Errorf("latest workspace build is in progress")
+	}
+	if priorHistory.Transition == database.WorkspaceTransitionDelete {
+		return false, xerrors.Errorf("workspace is already being deleted")
+	}
+
+	if template.InactivityTTL > 0 {
+		lastActive := ws.LastUsedAt
+		if priorHistory.CreatedAt.After(lastActive) {
+			lastActive = priorHistory.CreatedAt
+		}
+		if now.Sub(lastActive) >= time.Duration(template.InactivityTTL) {
+			return true, nil
+		}
+	}
+	if template.StoppedTTL > 0 &&
+		priorHistory.Transition == database.WorkspaceTransitionStop &&
+		priorJob.Error.String == "" &&
+		now.Sub(priorJob.CompletedAt.Time) >= time.Duration(template.StoppedTTL) {
+		return true, nil
+	}
+	return false, nil
+}
+
+func (e *Executor) audit(log workspaceAudit) {
+	if e.auditor == nil {
+		return
+	}
+	auditor := e.auditor.Load()
+	if auditor == nil {
+		return
+	}
+	audit.BackgroundAudit(e.ctx, &audit.BackgroundAuditParams[database.Workspace]{
+		Audit:          *auditor,
+		Log:            e.log,
+		UserID:         log.old.OwnerID,
+		OrganizationID: log.old.OrganizationID,
+		Status:         http.StatusOK,
+		Action:         log.action,
+		Old:            log.old,
+		New:            log.new,
+	})
 }
 
 func getNextTransition(
 	ws database.Workspace,
+	policy schedule.TemplatePolicy,
 	priorHistory database.WorkspaceBuild,
 	priorJob database.ProvisionerJob,
 ) (
@@ -225,80 +441,44 @@ func getNextTransition(
 		if err != nil {
 			return "", time.Time{}, xerrors.Errorf("workspace has invalid autostart schedule: %w", err)
 		}
-		// Round down to the nearest minute, as this is the finest granularity cron supports.
-		// Truncate is probably not necessary here, but doing it anyway to be sure.
-		nextTransition = sched.Next(priorHistory.CreatedAt).Truncate(time.Minute)
-		return database.WorkspaceTransitionStart, nextTransition, nil
+		// Skip triggers that are not allowed by the template policy. Every
+		// trigger of a weekly schedule happens within a week.
+		weekLater := priorHistory.CreatedAt.Add(7 * 24 * time.Hour)
+		for next := sched.Next(priorHistory.CreatedAt); !next.After(weekLater); next = sched.Next(next) {
+			if policy.AllowsAutostart(sched, next) {
+				// Round down to the nearest minute, as this is the finest granularity cron supports.
+				// Truncate is probably not necessary here, but doing it anyway to be sure.
+				return database.WorkspaceTransitionStart, next.Truncate(time.Minute), nil
+			}
+		}
+		return "", time.Time{}, xerrors.Errorf("autostart schedule is not allowed by template policy")
 	default:
 		return "", time.Time{}, xerrors.Errorf("last transition not valid for autostart or autostop")
 	}
 }
 
-// TODO(cian): this function duplicates most of api.postWorkspaceBuilds. Refactor.
-// See: https://github.com/coder/coder/issues/1401
-func build(ctx context.Context, store database.Store, workspace database.Workspace, trans database.WorkspaceTransition, priorHistory database.WorkspaceBuild, priorJob database.ProvisionerJob) error {
-	template, err := store.GetTemplateByID(ctx, workspace.TemplateID)
-	if err != nil {
-		return xerrors.Errorf("get workspace template: %w", err)
-	}
-
-	priorBuildNumber := priorHistory.BuildNumber
-
-	// This must happen in a transaction to ensure history can be inserted, and
-	// the prior history can update it's "after" column to point at the new.
-	workspaceBuildID := uuid.New()
-	input, err := json.Marshal(struct {
-		WorkspaceBuildID string `json:"workspace_build_id"`
-	}{
-		WorkspaceBuildID: workspaceBuildID.String(),
-	})
-	if err != nil {
-		return xerrors.Errorf("marshal provision job: %w", err)
-	}
-	provisionerJobID := uuid.New()
-	now := database.Now()
-
+// build creates a workspace build with the template version and parameters
+// of the prior build.
+func build(ctx context.Context, store database.Store, workspace database.Workspace, trans database.WorkspaceTransition) error {
 	var buildReason database.BuildReason
 	switch trans {
 	case database.WorkspaceTransitionStart:
 		buildReason = database.BuildReasonAutostart
 	case database.WorkspaceTransitionStop:
 		buildReason = database.BuildReasonAutostop
+	case database.WorkspaceTransitionDelete:
+		buildReason = database.BuildReasonAutodelete
 	default:
 		return xerrors.Errorf("Unsupported transition: %q", trans)
 	}
 
-	newProvisionerJob, err := store.InsertProvisionerJob(ctx, database.InsertProvisionerJobParams{
-		ID:             provisionerJobID,
-		CreatedAt:      now,
-		UpdatedAt:      now,
-		InitiatorID:    workspace.OwnerID,
-		OrganizationID: template.OrganizationID,
-		Provisioner:    template.Provisioner,
-		Type:           database.ProvisionerJobTypeWorkspaceBuild,
-		StorageMethod:  priorJob.StorageMethod,
-		FileID:         priorJob.FileID,
-		Tags:           priorJob.Tags,
-		Input:          input,
-	})
-	if err != nil {
-		return xerrors.Errorf("insert provisioner job: %w", err)
-	}
-	_, err = store.InsertWorkspaceBuild(ctx, database.InsertWorkspaceBuildParams{
-		ID:                workspaceBuildID,
-		CreatedAt:         now,
-		UpdatedAt:         now,
-		WorkspaceID:       workspace.ID,
-		TemplateVersionID: priorHistory.TemplateVersionID,
-		BuildNumber:       priorBuildNumber + 1,
-		ProvisionerState:  priorHistory.ProvisionerState,
-		InitiatorID:       workspace.OwnerID,
-		Transition:        trans,
-		JobID:             newProvisionerJob.ID,
-		Reason:            buildReason,
+	_, _, err := wsbuilder.Build(ctx, store, workspace, wsbuilder.Options{
+		Transition:  trans,
+		Reason:      buildReason,
+		InitiatorID: workspace.OwnerID,
 	})
 	if err != nil {
-		return xerrors.Errorf("insert workspace build: %w", err)
+		return xerrors.Errorf("build workspace: %w", err)
 	}
 	return nil
 }
@@ -3,11 +3,13 @@ package executor_test
 import (
 	"context"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
 	"go.uber.org/goleak"
 
+	"github.com/coder/coder/coderd/audit"
 	"github.com/coder/coder/coderd/autobuild/executor"
 	"github.com/coder/coder/coderd/autobuild/schedule"
 	"github.com/coder/coder/coderd/coderdtest"
@@ -540,6 +542,256 @@ func TestExecutorAutostartMultipleOK(t *testing.T) {
 	assert.Len(t, stats2.Transitions, 0)
 }
 
+func TestExecutorAutodeleteInactive(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx     = context.Background()
+		auditor = audit.NewMock()
+		tickCh  = make(chan time.Time)
+		statsCh = make(chan executor.Stats)
+		client  = coderdtest.New(t, &coderdtest.Options{
+			AutobuildTicker:          tickCh,
+			IncludeProvisionerDaemon: true,
+			AutobuildStats:           statsCh,
+			Auditor:                  auditor,
+		})
+		// Given: we have a user with a workspace
+		workspace = mustProvisionWorkspace(t, client)
+	)
+	// Given: the template deletes workspaces that are unused for a minute,
+	// two hours after marking them for deletion
+	_, err := client.UpdateTemplateMeta(ctx, workspace.TemplateID, codersdk.UpdateTemplateMeta{
+		InactivityTTLMillis:        ptr.Ref(time.Minute.Milliseconds()),
+		AutodeleteWarningTTLMillis: ptr.Ref((2 * time.Hour).Milliseconds()),
+	})
+	require.NoError(t, err)
+
+	// When: the autobuild executor ticks after the workspace became dormant
+	tick := time.Now().Add(2 * time.Minute)
+	tickCh <- tick
+
+	// Then: the workspace should be marked for deletion, but not deleted
+	stats := <-statsCh
+	assert.NoError(t, stats.Error)
+	assert.Len(t, stats.Transitions, 0)
+	workspace = coderdtest.MustWorkspace(t, client, workspace.ID)
+	require.NotNil(t, workspace.DeletingAt)
+	assert.WithinDuration(t, tick.Add(2*time.Hour), *workspace.DeletingAt, time.Second)
+	require.NotEmpty(t, auditor.AuditLogs)
+	auditLog := auditor.AuditLogs[len(auditor.AuditLogs)-1]
+	assert.Equal(t, workspace.ID, auditLog.ResourceID)
+	assert.Equal(t, database.AuditActionWrite, auditLog.Action)
+
+	// When: the autobuild executor ticks after the warning period
+	go func() {
+		tickCh <- workspace.DeletingAt.Add(time.Minute)
+		close(tickCh)
+	}()
+
+	// Then: the workspace should be deleted
+	stats = <-statsCh
+	assert.NoError(t, stats.Error)
+	assert.Len(t, stats.Transitions, 1)
+	assert.Equal(t, database.WorkspaceTransitionDelete, stats.Transitions[workspace.ID])
+
+	workspace = coderdtest.MustWorkspace(t, client, workspace.ID)
+	assert.Equal(t, codersdk.WorkspaceTransitionDelete, workspace.LatestBuild.Transition)
+	assert.Equal(t, codersdk.BuildReasonAutodelete, workspace.LatestBuild.Reason)
+}
+
+func TestExecutorAutodeleteActiveAgain(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx     = context.Background()
+		tickCh  = make(chan time.Time)
+		statsCh = make(chan executor.Stats)
+		client  = coderdtest.New(t, &coderdtest.Options{
+			AutobuildTicker:          tickCh,
+			IncludeProvisionerDaemon: true,
+			AutobuildStats:           statsCh,
+		})
+		// Given: we have a user with a workspace
+		workspace = mustProvisionWorkspace(t, client)
+	)
+	// Given: the template deletes workspaces that are unused for a minute
+	_, err := client.UpdateTemplateMeta(ctx, workspace.TemplateID, codersdk.UpdateTemplateMeta{
+		InactivityTTLMillis: ptr.Ref(time.Minute.Milliseconds()),
+	})
+	require.NoError(t, err)
+
+	// Given: the workspace has been marked for deletion
+	tickCh <- time.Now().Add(2 * time.Minute)
+	stats := <-statsCh
+	assert.NoError(t, stats.Error)
+	workspace = coderdtest.MustWorkspace(t, client, workspace.ID)
+	require.NotNil(t, workspace.DeletingAt)
+
+	// When: the autobuild executor ticks while the workspace is no longer dormant
+	go func() {
+		tickCh <- time.Now()
+		close(tickCh)
+	}()
+
+	// Then: the workspace should no longer be marked for deletion
+	stats = <-statsCh
+	assert.NoError(t, stats.Error)
+	assert.Len(t, stats.Transitions, 0)
+	workspace = coderdtest.MustWorkspace(t, client, workspace.ID)
+	assert.Nil(t, workspace.DeletingAt)
+}
+
+func TestExecutorAutodeleteStopped(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx     = context.Background()
+		tickCh  = make(chan time.Time)
+		statsCh = make(chan executor.Stats)
+		client  = coderdtest.New(t, &coderdtest.Options{
+			AutobuildTicker:          tickCh,
+			IncludeProvisionerDaemon: true,
+			AutobuildStats:           statsCh,
+		})
+		// Given: we have a user with a workspace
+		workspace = mustProvisionWorkspace(t, client)
+	)
+	// Given: the template deletes workspaces that are stopped for a minute
+	_, err := client.UpdateTemplateMeta(ctx, workspace.TemplateID, codersdk.UpdateTemplateMeta{
+		StoppedTTLMillis: ptr.Ref(time.Minute.Milliseconds()),
+	})
+	require.NoError(t, err)
+
+	// When: the autobuild executor ticks while the workspace is running
+	tickCh <- time.Now().Add(2 * time.Minute)
+
+	// Then: the workspace should not be marked for deletion
+	stats := <-statsCh
+	assert.NoError(t, stats.Error)
+	assert.Len(t, stats.Transitions, 0)
+	workspace = coderdtest.MustWorkspace(t, client, workspace.ID)
+	assert.Nil(t, workspace.DeletingAt)
+
+	// When: the autobuild executor ticks after the workspace has been stopped
+	workspace = coderdtest.MustTransitionWorkspace(t, client, workspace.ID, database.WorkspaceTransitionStart, database.WorkspaceTransitionStop)
+	go func() {
+		tickCh <- time.Now().Add(2 * time.Minute)
+		close(tickCh)
+	}()
+
+	// Then: the workspace should be marked for deletion
+	stats = <-statsCh
+	assert.NoError(t, stats.Error)
+	assert.Len(t, stats.Transitions, 0)
+	workspace = coderdtest.MustWorkspace(t, client, workspace.ID)
+	assert.NotNil(t, workspace.DeletingAt)
+}
+
+func TestExecutorAutostopTemplateMaxTTL(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx     = context.Background()
+		tickCh  = make(chan time.Time)
+		statsCh = make(chan executor.Stats)
+		client  = coderdtest.New(t, &coderdtest.Options{
+			AutobuildTicker:          tickCh,
+			IncludeProvisionerDaemon: true,
+			AutobuildStats:           statsCh,
+		})
+		// Given: we have a user with a workspace that has autostop disabled
+		workspace = mustProvisionWorkspace(t, client, func(cwr *codersdk.CreateWorkspaceRequest) {
+			cwr.AutostartSchedule = nil
+			cwr.TTLMillis = nil
+		})
+	)
+	// Given: workspace is running without a deadline
+	require.Equal(t, codersdk.WorkspaceTransitionStart, workspace.LatestBuild.Transition)
+	require.Zero(t, workspace.LatestBuild.Deadline)
+
+	// Given: the template limits workspaces to running for an hour
+	_, err := client.UpdateTemplateMeta(ctx, workspace.TemplateID, codersdk.UpdateTemplateMeta{
+		MaxTTLMillis: ptr.Ref(time.Hour.Milliseconds()),
+	})
+	require.NoError(t, err)
+
+	// When: the autobuild executor ticks after the maximum TTL
+	go func() {
+		tickCh <- time.Now().Add(2 * time.Hour)
+		close(tickCh)
+	}()
+
+	// Then: the workspace should be stopped
+	stats := <-statsCh
+	assert.NoError(t, stats.Error)
+	assert.Len(t, stats.Transitions, 1)
+	assert.Equal(t, database.WorkspaceTransitionStop, stats.Transitions[workspace.ID])
+
+	workspace = coderdtest.MustWorkspace(t, client, workspace.ID)
+	assert.Equal(t, codersdk.BuildReasonAutostop, workspace.LatestBuild.Reason)
+}
+
+func TestExecutorAutostartTemplatePolicy(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx     = context.Background()
+		sched   = mustSchedule(t, "CRON_TZ=UTC 0 * * * *")
+		tickCh  = make(chan time.Time)
+		statsCh = make(chan executor.Stats)
+		client  = coderdtest.New(t, &coderdtest.Options{
+			AutobuildTicker:          tickCh,
+			IncludeProvisionerDaemon: true,
+			AutobuildStats:           statsCh,
+		})
+		// Given: we have a user with a workspace that has autostart enabled
+		workspace = mustProvisionWorkspace(t, client, func(cwr *codersdk.CreateWorkspaceRequest) {
+			cwr.AutostartSchedule = ptr.Ref(sched.String())
+		})
+	)
+	// Given: workspace is stopped
+	workspace = coderdtest.MustTransitionWorkspace(t, client, workspace.ID, database.WorkspaceTransitionStart, database.WorkspaceTransitionStop)
+	next := sched.Next(workspace.LatestBuild.CreatedAt)
+
+	// Given: the template does not allow autostart on the day of the next
+	// scheduled start
+	var days []string
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if day != next.Weekday() {
+			days = append(days, strings.ToLower(day.String()))
+		}
+	}
+	_, err := client.UpdateTemplateMeta(ctx, workspace.TemplateID, codersdk.UpdateTemplateMeta{
+		AutostartRequirement: &codersdk.TemplateAutostartRequirement{
+			DaysOfWeek: days,
+			StartHour:  0,
+			EndHour:    24,
+		},
+	})
+	require.NoError(t, err)
+
+	// When: the autobuild executor ticks at the scheduled time
+	tickCh <- next
+
+	// Then: the workspace should not be started
+	stats := <-statsCh
+	assert.NoError(t, stats.Error)
+	assert.Len(t, stats.Transitions, 0)
+
+	// When: the autobuild executor ticks at the start of the next allowed day
+	go func() {
+		tickCh <- time.Date(next.Year(), next.Month(), next.Day()+1, 0, 0, 0, 0, time.UTC)
+		close(tickCh)
+	}()
+
+	// Then: the workspace should be started
+	stats = <-statsCh
+	assert.NoError(t, stats.Error)
+	assert.Len(t, stats.Transitions, 1)
+	assert.Equal(t, database.WorkspaceTransitionStart, stats.Transitions[workspace.ID])
+}
+
 func mustProvisionWorkspace(t *testing.T, client *codersdk.Client, mut ...func(*codersdk.CreateWorkspaceRequest)) codersdk.Workspace {
 	t.Helper()
 	user := coderdtest.CreateFirstUser(t, client)
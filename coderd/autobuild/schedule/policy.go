@@ -0,0 +1,185 @@
+package schedule
+
+import (
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/coderd/database"
+)
+
+// AllDaysOfWeek is the bitmask allowing autostart on every day of the week.
+const AllDaysOfWeek uint8 = 0b1111111
+
+// TemplatePolicy constrains the autostart schedules and autostop deadlines of
+// workspaces created from a template.
+type TemplatePolicy struct {
+	// MaxTTL is the maximum duration a workspace may run for after it is
+	// started. Zero means there is no maximum.
+	MaxTTL time.Duration
+	// AutostartDaysOfWeek is a bitmask of the days autostart is allowed on,
+	// with Sunday as the least significant bit.
+	AutostartDaysOfWeek uint8
+	// AutostartStartHour and AutostartEndHour bound the hours of the day
+	// autostart is allowed in, in the timezone of the autostart schedule.
+	// AutostartEndHour is exclusive.
+	AutostartStartHour int
+	AutostartEndHour   int
+	// QuietHours is when quiet hours start. Workspaces are stopped when quiet
+	// hours start and are not autostarted until QuietHoursDuration has
+	// elapsed. Nil disables quiet hours.
+	QuietHours         *Schedule
+	QuietHoursDuration time.Duration
+}
+
+// DefaultTemplatePolicy allows any autostart schedule and autostop deadline.
+func DefaultTemplatePolicy() TemplatePolicy {
+	return TemplatePolicy{
+		AutostartDaysOfWeek: AllDaysOfWeek,
+		AutostartStartHour:  0,
+		AutostartEndHour:    24,
+	}
+}
+
+// NewTemplatePolicy returns the policy of a template.
+func NewTemplatePolicy(template database.Template) (TemplatePolicy, error) {
+	policy := TemplatePolicy{
+		MaxTTL:              time.Duration(template.MaxTTL),
+		AutostartDaysOfWeek: uint8(template.AutostartDaysOfWeek),
+		AutostartStartHour:  int(template.AutostartStartHour),
+		AutostartEndHour:    int(template.AutostartEndHour),
+		QuietHoursDuration:  time.Duration(template.QuietHoursDuration),
+	}
+	if template.QuietHoursSchedule != "" {
+		sched, err := Weekly(template.QuietHoursSchedule)
+		if err != nil {
+			return TemplatePolicy{}, xerrors.Errorf("parse quiet hours schedule: %w", err)
+		}
+		policy.QuietHours = sched
+	}
+	return policy, nil
+}
+
+// ValidateAutostart returns an error if the autostart schedule triggers on a
+// day or at a time that is not allowed by the policy.
+func (p TemplatePolicy) ValidateAutostart(s *Schedule) error {
+	// Every trigger of a weekly schedule happens within a week.
+	for next := s.Next(t0); next.Before(tMax); next = s.Next(next) {
+		if err := p.checkAutostart(next.In(s.Location())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AllowsAutostart returns true if the policy allows a workspace with the
+// autostart schedule to be started at t.
+func (p TemplatePolicy) AllowsAutostart(s *Schedule, t time.Time) bool {
+	return p.checkAutostart(t.In(s.Location())) == nil
+}
+
+func (p TemplatePolicy) checkAutostart(t time.Time) error {
+	if p.AutostartDaysOfWeek&(1<<uint(t.Weekday())) == 0 {
+		return xerrors.Errorf("autostart is not allowed on %s, allowed days are %s", t.Weekday(), p.allowedDays())
+	}
+	if t.Hour() < p.AutostartStartHour || t.Hour() >= p.AutostartEndHour {
+		return xerrors.Errorf("autostart is only allowed from %02d:00 to %02d:00", p.AutostartStartHour, p.AutostartEndHour)
+	}
+	if p.InQuietHours(t) {
+		return xerrors.Errorf("autostart at %s is during quiet hours", t.Format(time.Kitchen))
+	}
+	return nil
+}
+
+// ValidateTTL returns an error if the workspace TTL exceeds the maximum.
+// A nil TTL disables autostop, which is not allowed with a maximum.
+func (p TemplatePolicy) ValidateTTL(ttl *time.Duration) error {
+	if p.MaxTTL == 0 {
+		return nil
+	}
+	if ttl == nil {
+		return xerrors.Errorf("autostop is required by the template, time until shutdown must be at most %s", p.MaxTTL)
+	}
+	if *ttl > p.MaxTTL {
+		return xerrors.Errorf("time until shutdown must be at most %s", p.MaxTTL)
+	}
+	return nil
+}
+
+// InQuietHours returns true if t is during quiet hours.
+func (p TemplatePolicy) InQuietHours(t time.Time) bool {
+	if p.QuietHours == nil {
+		return false
+	}
+	// The first quiet hours start after t - duration is the latest one that
+	// could still be in progress.
+	start := p.QuietHours.Next(t.Add(-p.QuietHoursDuration))
+	return !start.After(t)
+}
+
+// Deadline returns the autostop deadline of a workspace started at startedAt,
+// clamped to the maximum TTL and the start of quiet hours. A zero deadline
+// means the workspace has no autostop and is returned as is if the policy has
+// no maximum and no quiet hours.
+func (p TemplatePolicy) Deadline(startedAt, deadline time.Time) time.Time {
+	if p.MaxTTL > 0 {
+		maxDeadline := startedAt.Add(p.MaxTTL)
+		if deadline.IsZero() || deadline.After(maxDeadline) {
+			deadline = maxDeadline
+		}
+	}
+	if p.QuietHours != nil {
+		quietHours := p.QuietHours.Next(startedAt)
+		if deadline.IsZero() || deadline.After(quietHours) {
+			deadline = quietHours
+		}
+	}
+	return deadline
+}
+
+// allowedDays returns a humanized list of the days autostart is allowed on.
+func (p TemplatePolicy) allowedDays() string {
+	var days []string
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if p.AutostartDaysOfWeek&(1<<uint(day)) != 0 {
+			days = append(days, day.String()[:3])
+		}
+	}
+	if len(days) == 0 {
+		return "none"
+	}
+	return strings.Join(days, ", ")
+}
+
+// DaysOfWeekMask converts lowercase weekday names to a bitmask.
+func DaysOfWeekMask(days []string) (uint8, error) {
+	var mask uint8
+	for _, day := range days {
+		weekday, ok := weekdays[strings.ToLower(day)]
+		if !ok {
+			return 0, xerrors.Errorf("invalid day of week %q", day)
+		}
+		mask |= 1 << uint(weekday)
+	}
+	return mask, nil
+}
+
+// DaysOfWeek converts a bitmask to lowercase weekday names.
+func DaysOfWeek(mask uint8) []string {
+	days := make([]string, 0, 7)
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if mask&(1<<uint(day)) != 0 {
+			days = append(days, strings.ToLower(day.String()))
+		}
+	}
+	return days
+}
+
+var weekdays = func() map[string]time.Weekday {
+	m := make(map[string]time.Weekday, 7)
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		m[strings.ToLower(day.String())] = day
+	}
+	return m
+}()
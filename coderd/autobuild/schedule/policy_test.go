@@ -0,0 +1,183 @@
+package schedule_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/autobuild/schedule"
+	"github.com/coder/coder/coderd/util/ptr"
+)
+
+func Test_TemplatePolicy_ValidateAutostart(t *testing.T) {
+	t.Parallel()
+	weekdays, err := schedule.DaysOfWeekMask([]string{"monday", "tuesday", "wednesday", "thursday", "friday"})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		policy        schedule.TemplatePolicy
+		spec          string
+		expectedError string
+	}{
+		{
+			name:   "default policy",
+			policy: schedule.DefaultTemplatePolicy(),
+			spec:   "CRON_TZ=US/Central 30 9 * * *",
+		},
+		{
+			name: "weekdays only",
+			policy: schedule.TemplatePolicy{
+				AutostartDaysOfWeek: weekdays,
+				AutostartEndHour:    24,
+			},
+			spec: "CRON_TZ=US/Central 30 9 * * 1-5",
+		},
+		{
+			name: "weekend not allowed",
+			policy: schedule.TemplatePolicy{
+				AutostartDaysOfWeek: weekdays,
+				AutostartEndHour:    24,
+			},
+			spec:          "CRON_TZ=US/Central 30 9 * * *",
+			expectedError: "autostart is not allowed on Saturday, allowed days are Mon, Tue, Wed, Thu, Fri",
+		},
+		{
+			name: "outside hours",
+			policy: schedule.TemplatePolicy{
+				AutostartDaysOfWeek: schedule.AllDaysOfWeek,
+				AutostartStartHour:  6,
+				AutostartEndHour:    9,
+			},
+			spec:          "CRON_TZ=US/Central 30 9 * * *",
+			expectedError: "autostart is only allowed from 06:00 to 09:00",
+		},
+		{
+			name: "during quiet hours",
+			policy: schedule.TemplatePolicy{
+				AutostartDaysOfWeek: schedule.AllDaysOfWeek,
+				AutostartEndHour:    24,
+				QuietHours:          mustWeekly(t, "CRON_TZ=US/Central 0 22 * * *"),
+				QuietHoursDuration:  10 * time.Hour,
+			},
+			spec:          "CRON_TZ=US/Central 30 6 * * *",
+			expectedError: "autostart at 6:30AM is during quiet hours",
+		},
+		{
+			name: "after quiet hours",
+			policy: schedule.TemplatePolicy{
+				AutostartDaysOfWeek: schedule.AllDaysOfWeek,
+				AutostartEndHour:    24,
+				QuietHours:          mustWeekly(t, "CRON_TZ=US/Central 0 22 * * *"),
+				QuietHoursDuration:  10 * time.Hour,
+			},
+			spec: "CRON_TZ=US/Central 30 8 * * *",
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			err := testCase.policy.ValidateAutostart(mustWeekly(t, testCase.spec))
+			if testCase.expectedError == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, testCase.expectedError)
+			}
+		})
+	}
+}
+
+func Test_TemplatePolicy_ValidateTTL(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, schedule.DefaultTemplatePolicy().ValidateTTL(nil))
+
+	policy := schedule.DefaultTemplatePolicy()
+	policy.MaxTTL = 8 * time.Hour
+	require.NoError(t, policy.ValidateTTL(ptr.Ref(8*time.Hour)))
+	require.Error(t, policy.ValidateTTL(ptr.Ref(9*time.Hour)))
+	require.Error(t, policy.ValidateTTL(nil))
+}
+
+func Test_TemplatePolicy_Deadline(t *testing.T) {
+	t.Parallel()
+	startedAt := time.Date(2022, 4, 1, 14, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name     string
+		policy   schedule.TemplatePolicy
+		deadline time.Time
+		expected time.Time
+	}{
+		{
+			name:     "no policy",
+			policy:   schedule.DefaultTemplatePolicy(),
+			deadline: startedAt.Add(12 * time.Hour),
+			expected: startedAt.Add(12 * time.Hour),
+		},
+		{
+			name:     "no policy without deadline",
+			policy:   schedule.DefaultTemplatePolicy(),
+			deadline: time.Time{},
+			expected: time.Time{},
+		},
+		{
+			name:     "max ttl",
+			policy:   schedule.TemplatePolicy{MaxTTL: 8 * time.Hour},
+			deadline: startedAt.Add(12 * time.Hour),
+			expected: startedAt.Add(8 * time.Hour),
+		},
+		{
+			name:     "max ttl without deadline",
+			policy:   schedule.TemplatePolicy{MaxTTL: 8 * time.Hour},
+			deadline: time.Time{},
+			expected: startedAt.Add(8 * time.Hour),
+		},
+		{
+			name:     "max ttl after deadline",
+			policy:   schedule.TemplatePolicy{MaxTTL: 8 * time.Hour},
+			deadline: startedAt.Add(4 * time.Hour),
+			expected: startedAt.Add(4 * time.Hour),
+		},
+		{
+			name: "quiet hours",
+			policy: schedule.TemplatePolicy{
+				MaxTTL:             24 * time.Hour,
+				QuietHours:         mustWeekly(t, "CRON_TZ=UTC 0 22 * * *"),
+				QuietHoursDuration: 8 * time.Hour,
+			},
+			deadline: startedAt.Add(12 * time.Hour),
+			expected: time.Date(2022, 4, 1, 22, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, testCase.expected, testCase.policy.Deadline(startedAt, testCase.deadline))
+		})
+	}
+}
+
+func Test_DaysOfWeekMask(t *testing.T) {
+	t.Parallel()
+
+	mask, err := schedule.DaysOfWeekMask([]string{"Sunday", "saturday"})
+	require.NoError(t, err)
+	require.Equal(t, uint8(0b1000001), mask)
+	require.Equal(t, []string{"sunday", "saturday"}, schedule.DaysOfWeek(mask))
+
+	_, err = schedule.DaysOfWeekMask([]string{"someday"})
+	require.Error(t, err)
+}
+
+func mustWeekly(t *testing.T, spec string) *schedule.Schedule {
+	t.Helper()
+	sched, err := schedule.Weekly(spec)
+	require.NoError(t, err)
+	return sched
+}
@@ -38,11 +38,14 @@ import (
 	"github.com/coder/coder/coderd/awsidentity"
 	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/coderd/database/dbtype"
+	"github.com/coder/coder/coderd/filestore"
 	"github.com/coder/coder/coderd/gitauth"
 	"github.com/coder/coder/coderd/gitsshkey"
 	"github.com/coder/coder/coderd/httpapi"
 	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/coderd/insights"
 	"github.com/coder/coder/coderd/metricscache"
+	"github.com/coder/coder/coderd/notifications"
 	"github.com/coder/coder/coderd/provisionerdserver"
 	"github.com/coder/coder/coderd/rbac"
 	"github.com/coder/coder/coderd/telemetry"
@@ -72,6 +75,9 @@ type Options struct {
 
 	// CacheDir is used for caching files served by the API.
 	CacheDir string
+	// FileStore stores the contents of uploaded files and template
+	// archives. Defaults to the database.
+	FileStore filestore.Store
 
 	Auditor                        audit.Auditor
 	AgentConnectionUpdateFrequency time.Duration
@@ -103,12 +109,17 @@ type Options struct {
 
 	MetricsCacheRefreshInterval time.Duration
 	AgentStatsRefreshInterval   time.Duration
-	Experimental                bool
-	DeploymentConfig            *codersdk.DeploymentConfig
+	// InsightsRefreshInterval is how often usage is rolled up for insights.
+	InsightsRefreshInterval time.Duration
+	// WebhookRetryInterval is the delay before a failed webhook delivery
+	// is first retried. It doubles after every attempt.
+	WebhookRetryInterval time.Duration
+	Experimental         bool
+	DeploymentConfig     *codersdk.DeploymentConfig
 }
 
 // New constructs a Coder API handler.
-func New(options *Options) *API {
+func New(options *Options) (*API, error) {
 	if options == nil {
 		options = &Options{}
 	}
@@ -137,8 +148,9 @@ func New(options *Options) *API {
 	if options.MetricsCacheRefreshInterval == 0 {
 		options.MetricsCacheRefreshInterval = time.Hour
 	}
+	customRoles := rbac.NewCustomRoles()
 	if options.Authorizer == nil {
-		options.Authorizer = rbac.NewAuthorizer()
+		options.Authorizer = rbac.NewAuthorizerWithCustomRoles(customRoles)
 	}
 	if options.PrometheusRegistry == nil {
 		options.PrometheusRegistry = prometheus.NewRegistry()
@@ -152,6 +164,9 @@ func New(options *Options) *API {
 	if options.Auditor == nil {
 		options.Auditor = audit.NewNop()
 	}
+	if options.FileStore == nil {
+		options.FileStore = filestore.NewDatabase(options.Database)
+	}
 
 	siteCacheDir := options.CacheDir
 	if siteCacheDir != "" {
@@ -159,7 +174,17 @@ func New(options *Options) *API {
 	}
 	binFS, err := site.ExtractOrReadBinFS(siteCacheDir, site.FS())
 	if err != nil {
-		panic(xerrors.Errorf("read site bin failed: %w", err))
+		return nil, xerrors.Errorf("read site bin failed: %w", err)
+	}
+
+	notifier, err := notifications.New(notifications.Options{
+		Database:      options.Database,
+		Pubsub:        options.Pubsub,
+		Logger:        options.Logger.Named("notifications"),
+		RetryInterval: options.WebhookRetryInterval,
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("start notifications: %w", err)
 	}
 
 	metricsCache := metricscache.New(
@@ -167,6 +192,17 @@ func New(options *Options) *API {
 		options.Logger.Named("metrics_cache"),
 		options.MetricsCacheRefreshInterval,
 	)
+	insightsRollup := insights.New(
+		options.Database,
+		options.Logger.Named("insights"),
+		options.InsightsRefreshInterval,
+	)
+	fileCollector := filestore.NewCollector(
+		options.Database,
+		options.FileStore,
+		options.Logger.Named("file_collector"),
+		time.Hour,
+	)
 
 	r := chi.NewRouter()
 	api := &API{
@@ -178,10 +214,33 @@ func New(options *Options) *API {
 			Authorizer: options.Authorizer,
 			Logger:     options.Logger,
 		},
-		metricsCache: metricsCache,
-		Auditor:      atomic.Pointer[audit.Auditor]{},
+		metricsCache:   metricsCache,
+		insightsRollup: insightsRollup,
+		fileCollector:  fileCollector,
+		notifier:       notifier,
+		Auditor:        atomic.Pointer[audit.Auditor]{},
+		CustomRoles:    customRoles,
 	}
 	api.Auditor.Store(&options.Auditor)
+	err = api.reloadCustomRoles(context.Background())
+	if err != nil {
+		// Users with custom roles fail authorization until the roles are
+		// reloaded, but everyone else can still use the API.
+		api.Logger.Error(context.Background(), "load custom roles", slog.Error(err))
+	}
+	api.closeCustomRolesSubscription, err = api.Pubsub.Subscribe(customRolesPubsubEvent, func(ctx context.Context, _ []byte) {
+		err := api.reloadCustomRoles(ctx)
+		if err != nil {
+			api.Logger.Error(ctx, "reload custom roles", slog.Error(err))
+		}
+	})
+	if err != nil {
+		metricsCache.Close()
+		_ = insightsRollup.Close()
+		_ = fileCollector.Close()
+		_ = notifier.Close()
+		return nil, xerrors.Errorf("subscribe to custom roles: %w", err)
+	}
 	api.workspaceAgentCache = wsconncache.New(api.dialWorkspaceAgentTailnet, 0)
 	api.TailnetCoordinator.Store(&options.TailnetCoordinator)
 	oauthConfigs := &httpmw.OAuth2Configs{
@@ -194,6 +253,7 @@ func New(options *Options) *API {
 		OAuth2Configs:   oauthConfigs,
 		RedirectToLogin: false,
 		Optional:        false,
+		TokenUsed:       api.AuditTokenUse,
 	})
 	// Same as above but it redirects to the login page.
 	apiKeyMiddlewareRedirect := httpmw.ExtractAPIKey(httpmw.ExtractAPIKeyConfig{
@@ -201,6 +261,7 @@ func New(options *Options) *API {
 		OAuth2Configs:   oauthConfigs,
 		RedirectToLogin: true,
 		Optional:        false,
+		TokenUsed:       api.AuditTokenUse,
 	})
 
 	r.Use(
@@ -209,6 +270,14 @@ func New(options *Options) *API {
 		httpmw.ExtractRealIP(api.RealIPConfig),
 		httpmw.Logger(api.Logger),
 		httpmw.Prometheus(options.PrometheusRegistry),
+		// Custom roles are attached to the request context so authorizers
+		// that weren't created with the set, like the ones tests inject,
+		// expand them too.
+		func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				next.ServeHTTP(w, r.WithContext(rbac.WithCustomRoles(r.Context(), api.CustomRoles)))
+			})
+		},
 		// handleSubdomainApplications checks if the first subdomain is a valid
 		// app URL. If it is, it will serve that application.
 		api.handleSubdomainApplications(
@@ -237,25 +306,26 @@ func New(options *Options) *API {
 
 	r.Get("/healthz", func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write([]byte("OK")) })
 
+	appMiddlewares := []func(http.Handler) http.Handler{
+		tracing.Middleware(api.TracerProvider),
+		httpmw.RateLimit(options.APIRateLimit, time.Minute),
+		httpmw.ExtractAPIKey(httpmw.ExtractAPIKeyConfig{
+			DB:            options.Database,
+			OAuth2Configs: oauthConfigs,
+			// Optional is true to allow for public apps. If an
+			// authorization check fails and the user is not authenticated,
+			// they will be redirected to the login page by the app handler.
+			RedirectToLogin: false,
+			Optional:        true,
+		}),
+		// Redirect to the login page if the user tries to open an app with
+		// "me" as the username and they are not logged in.
+		httpmw.ExtractUserParam(api.Database, true),
+		// Extracts the <workspace.agent> from the url
+		httpmw.ExtractWorkspaceAndAgentParam(api.Database),
+	}
 	apps := func(r chi.Router) {
-		r.Use(
-			tracing.Middleware(api.TracerProvider),
-			httpmw.RateLimit(options.APIRateLimit, time.Minute),
-			httpmw.ExtractAPIKey(httpmw.ExtractAPIKeyConfig{
-				DB:            options.Database,
-				OAuth2Configs: oauthConfigs,
-				// Optional is true to allow for public apps. If an
-				// authorization check fails and the user is not authenticated,
-				// they will be redirected to the login page by the app handler.
-				RedirectToLogin: false,
-				Optional:        true,
-			}),
-			// Redirect to the login page if the user tries to open an app with
-			// "me" as the username and they are not logged in.
-			httpmw.ExtractUserParam(api.Database, true),
-			// Extracts the <workspace.agent> from the url
-			httpmw.ExtractWorkspaceAndAgentParam(api.Database),
-		)
+		r.Use(appMiddlewares...)
 		r.HandleFunc("/*", api.workspaceAppsProxyPath)
 	}
 	// %40 is the encoded character of the @ symbol. VS Code Web does
@@ -263,6 +333,14 @@ func New(options *Options) *API {
 	// other applications might not as well.
 	r.Route("/%40{user}/{workspace_and_agent}/apps/{workspaceapp}", apps)
 	r.Route("/@{user}/{workspace_and_agent}/apps/{workspaceapp}", apps)
+	// Shared UDP ports are relayed over a websocket, with the same
+	// authentication as apps.
+	udpPorts := func(r chi.Router) {
+		r.Use(appMiddlewares...)
+		r.Get("/", api.workspaceAgentPortShareUDP)
+	}
+	r.Route("/%40{user}/{workspace_and_agent}/udp/{port}", udpPorts)
+	r.Route("/@{user}/{workspace_and_agent}/udp/{port}", udpPorts)
 	r.Route("/derp", func(r chi.Router) {
 		r.Get("/", derphttp.Handler(api.DERPServer).ServeHTTP)
 		// This is used when UDP is blocked, and latency must be checked via HTTP(s).
@@ -321,6 +399,15 @@ func New(options *Options) *API {
 			r.Get("/count", api.auditLogCount)
 			r.Post("/testgenerate", api.generateFakeAuditLog)
 		})
+		r.Route("/insights", func(r chi.Router) {
+			r.Use(
+				apiKeyMiddleware,
+			)
+
+			r.Get("/templates", api.insightsTemplates)
+			r.Get("/users", api.insightsUsers)
+			r.Get("/apps", api.insightsApps)
+		})
 		r.Route("/files", func(r chi.Router) {
 			r.Use(
 				apiKeyMiddleware,
@@ -341,6 +428,7 @@ func New(options *Options) *API {
 					httpmw.ExtractOrganizationParam(options.Database),
 				)
 				r.Get("/", api.organization)
+				r.Patch("/", api.patchOrganization)
 				r.Route("/templateversions", func(r chi.Router) {
 					r.Post("/", api.postTemplateVersionsByOrganization)
 					r.Get("/{templateversionname}", api.templateVersionByOrganizationAndName)
@@ -350,15 +438,26 @@ func New(options *Options) *API {
 					r.Get("/", api.templatesByOrganization)
 					r.Get("/{templatename}", api.templateByOrganizationAndName)
 				})
+				r.Route("/webhooks", func(r chi.Router) {
+					r.Post("/", api.postWebhookByOrganization)
+					r.Get("/", api.webhooksByOrganization)
+				})
 				r.Route("/members", func(r chi.Router) {
+					r.Get("/", api.organizationMembers)
 					r.Get("/roles", api.assignableOrgRoles)
 					r.Route("/{user}", func(r chi.Router) {
 						r.Use(
 							httpmw.ExtractUserParam(options.Database, false),
-							httpmw.ExtractOrganizationMemberParam(options.Database),
 						)
-						r.Put("/roles", api.putMemberRoles)
-						r.Post("/workspaces", api.postWorkspacesByOrganization)
+						r.Post("/", api.postOrganizationMember)
+						r.Group(func(r chi.Router) {
+							r.Use(
+								httpmw.ExtractOrganizationMemberParam(options.Database),
+							)
+							r.Delete("/", api.deleteOrganizationMember)
+							r.Put("/roles", api.putMemberRoles)
+							r.Post("/workspaces", api.postWorkspacesByOrganization)
+						})
 					})
 				})
 			})
@@ -396,6 +495,7 @@ func New(options *Options) *API {
 			r.Patch("/cancel", api.patchCancelTemplateVersion)
 			r.Get("/schema", api.templateVersionSchema)
 			r.Get("/parameters", api.templateVersionParameters)
+			r.Get("/rich-parameters", api.templateVersionRichParameters)
 			r.Get("/resources", api.templateVersionResources)
 			r.Get("/logs", api.templateVersionLogs)
 			r.Route("/dry-run", func(r chi.Router) {
@@ -406,6 +506,17 @@ func New(options *Options) *API {
 				r.Patch("/{jobID}/cancel", api.patchTemplateVersionDryRunCancel)
 			})
 		})
+		r.Route("/roles", func(r chi.Router) {
+			r.Use(apiKeyMiddleware)
+			r.Get("/", api.customRoles)
+			r.Post("/", api.postCustomRole)
+			r.Route("/{role}", func(r chi.Router) {
+				r.Use(httpmw.ExtractCustomRoleParam(options.Database))
+				r.Get("/", api.customRole)
+				r.Patch("/", api.patchCustomRole)
+				r.Delete("/", api.deleteCustomRole)
+			})
+		})
 		r.Route("/users", func(r chi.Router) {
 			r.Get("/first", api.firstUser)
 			r.Post("/first", api.postFirstUser)
@@ -445,6 +556,7 @@ func New(options *Options) *API {
 					r.Delete("/", api.deleteUser)
 					r.Get("/", api.userByName)
 					r.Put("/profile", api.putUserProfile)
+					r.Put("/preferences", api.putUserPreferences)
 					r.Route("/status", func(r chi.Router) {
 						r.Put("/suspend", api.putUserStatus(database.UserStatusSuspended))
 						r.Put("/activate", api.putUserStatus(database.UserStatusActive))
@@ -461,6 +573,7 @@ func New(options *Options) *API {
 						r.Route("/tokens", func(r chi.Router) {
 							r.Post("/", api.postToken)
 							r.Get("/", api.tokens)
+							r.Get("/{keyname}", api.apiKeyByName)
 						})
 						r.Route("/{keyid}", func(r chi.Router) {
 							r.Get("/", api.apiKey)
@@ -481,6 +594,25 @@ func New(options *Options) *API {
 				})
 			})
 		})
+		r.Route("/webhooks/{webhook}", func(r chi.Router) {
+			r.Use(
+				apiKeyMiddleware,
+				httpmw.ExtractWebhookParam(options.Database),
+			)
+			r.Get("/", api.webhook)
+			r.Patch("/", api.patchWebhook)
+			r.Delete("/", api.deleteWebhook)
+			r.Get("/deliveries", api.webhookDeliveries)
+		})
+		r.Route("/session-recordings", func(r chi.Router) {
+			r.Use(apiKeyMiddleware)
+			r.Get("/", api.workspaceSessionRecordings)
+			r.Route("/{sessionrecording}", func(r chi.Router) {
+				r.Use(httpmw.ExtractWorkspaceSessionRecordingParam(options.Database))
+				r.Get("/", api.workspaceSessionRecording)
+				r.Get("/content", api.workspaceSessionRecordingContent)
+			})
+		})
 		r.Route("/workspaceagents", func(r chi.Router) {
 			r.Post("/azure-instance-identity", api.postWorkspaceAuthAzureInstanceIdentity)
 			r.Post("/aws-instance-identity", api.postWorkspaceAuthAWSInstanceIdentity)
@@ -490,10 +622,15 @@ func New(options *Options) *API {
 				r.Get("/metadata", api.workspaceAgentMetadata)
 				r.Post("/version", api.postWorkspaceAgentVersion)
 				r.Post("/app-health", api.postWorkspaceAppHealth)
+				r.Patch("/startup-logs", api.patchWorkspaceAgentStartupLogs)
+				r.Post("/report-lifecycle", api.workspaceAgentReportLifecycle)
+				r.Get("/await-shutdown", api.workspaceAgentAwaitShutdown)
+				r.Post("/metadata/{key}", api.workspaceAgentPostMetadata)
 				r.Get("/gitauth", api.workspaceAgentsGitAuth)
 				r.Get("/gitsshkey", api.agentGitSSHKey)
 				r.Get("/coordinate", api.workspaceAgentCoordinate)
 				r.Get("/report-stats", api.workspaceAgentReportStats)
+				r.Post("/session-recordings", api.postWorkspaceAgentSessionRecording)
 			})
 			r.Route("/{workspaceagent}", func(r chi.Router) {
 				r.Use(
@@ -504,6 +641,11 @@ func New(options *Options) *API {
 				r.Get("/", api.workspaceAgent)
 				r.Get("/pty", api.workspaceAgentPTY)
 				r.Get("/listening-ports", api.workspaceAgentListeningPorts)
+				r.Get("/pty-sessions", api.workspaceAgentReconnectingPTYSessions)
+				r.Patch("/pty-sessions/{ptysession}", api.patchWorkspaceAgentReconnectingPTYSession)
+				r.Delete("/pty-sessions/{ptysession}", api.deleteWorkspaceAgentReconnectingPTYSession)
+				r.Get("/startup-logs", api.workspaceAgentStartupLogs)
+				r.Get("/watch-metadata", api.watchWorkspaceAgentMetadata)
 				r.Get("/connection", api.workspaceAgentConnection)
 				r.Get("/coordinate", api.workspaceAgentClientCoordinate)
 				// TODO: This can be removed in October. It allows for a friendly
@@ -539,6 +681,12 @@ func New(options *Options) *API {
 				})
 				r.Get("/watch", api.watchWorkspace)
 				r.Put("/extend", api.putExtendWorkspace)
+				r.Get("/stats", api.workspaceStats)
+				r.Route("/port-shares", func(r chi.Router) {
+					r.Get("/", api.workspaceAgentPortShares)
+					r.Post("/", api.postWorkspaceAgentPortShare)
+					r.Delete("/{agentname}/{port}", api.deleteWorkspaceAgentPortShare)
+				})
 			})
 		})
 		r.Route("/workspacebuilds/{workspacebuild}", func(r chi.Router) {
@@ -552,6 +700,7 @@ func New(options *Options) *API {
 			r.Get("/logs", api.workspaceBuildLogs)
 			r.Get("/resources", api.workspaceBuildResources)
 			r.Get("/state", api.workspaceBuildState)
+			r.Get("/parameters", api.workspaceBuildParameters)
 		})
 		r.Route("/authcheck", func(r chi.Router) {
 			r.Use(apiKeyMiddleware)
@@ -575,7 +724,7 @@ func New(options *Options) *API {
 	})
 
 	r.NotFound(compressHandler(http.HandlerFunc(api.siteHandler.ServeHTTP)).ServeHTTP)
-	return api
+	return api, nil
 }
 
 type API struct {
@@ -589,15 +738,27 @@ type API struct {
 	WorkspaceClientCoordinateOverride atomic.Pointer[func(rw http.ResponseWriter) bool]
 	TailnetCoordinator                atomic.Pointer[tailnet.Coordinator]
 	QuotaCommitter                    atomic.Pointer[proto.QuotaCommitter]
-	HTTPAuth                          *HTTPAuthorizer
+	// GroupSync sets the groups of a user to the named groups when they log
+	// in with an identity provider that has a group claim. Groups are an
+	// enterprise feature, so it's only set when they're licensed.
+	GroupSync atomic.Pointer[func(ctx context.Context, tx database.Store, userID uuid.UUID, groupNames []string) error]
+	HTTPAuth  *HTTPAuthorizer
+	// CustomRoles are the roles defined by admins. They're kept in sync with
+	// the database, including changes made by other replicas.
+	CustomRoles *rbac.CustomRoles
 
 	// APIHandler serves "/api/v2"
 	APIHandler chi.Router
 	// RootHandler serves "/"
 	RootHandler chi.Router
 
-	metricsCache *metricscache.Cache
-	siteHandler  http.Handler
+	metricsCache   *metricscache.Cache
+	insightsRollup *insights.Rollup
+	fileCollector  *filestore.Collector
+	notifier       *notifications.Notifier
+	siteHandler    http.Handler
+
+	closeCustomRolesSubscription func()
 
 	WebsocketWaitMutex sync.Mutex
 	WebsocketWaitGroup sync.WaitGroup
@@ -611,7 +772,11 @@ func (api *API) Close() error {
 	api.WebsocketWaitGroup.Wait()
 	api.WebsocketWaitMutex.Unlock()
 
+	api.closeCustomRolesSubscription()
 	api.metricsCache.Close()
+	_ = api.insightsRollup.Close()
+	_ = api.fileCollector.Close()
+	_ = api.notifier.Close()
 	coordinator := api.TailnetCoordinator.Load()
 	if coordinator != nil {
 		_ = (*coordinator).Close()
@@ -674,6 +839,7 @@ func (api *API) CreateInMemoryProvisionerDaemon(ctx context.Context, debounce ti
 		AccessURL:          api.AccessURL,
 		ID:                 daemon.ID,
 		Database:           api.Database,
+		FileStore:          api.FileStore,
 		Pubsub:             api.Pubsub,
 		Provisioners:       daemon.Provisioners,
 		Telemetry:          api.Telemetry,
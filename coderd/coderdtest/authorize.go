@@ -10,11 +10,13 @@ import (
 	"testing"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/xerrors"
 
 	"github.com/coder/coder/coderd"
+	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/coderd/rbac"
 	"github.com/coder/coder/codersdk"
 	"github.com/coder/coder/provisioner/echo"
@@ -64,6 +66,11 @@ func AGPLRoutes(a *AuthTester) (map[string]string, map[string]RouteCheck) {
 		"POST:/api/v2/workspaceagents/me/version":               {NoAuthorize: true},
 		"POST:/api/v2/workspaceagents/me/app-health":            {NoAuthorize: true},
 		"GET:/api/v2/workspaceagents/me/report-stats":           {NoAuthorize: true},
+		"PATCH:/api/v2/workspaceagents/me/startup-logs":         {NoAuthorize: true},
+		"POST:/api/v2/workspaceagents/me/report-lifecycle":      {NoAuthorize: true},
+		"GET:/api/v2/workspaceagents/me/await-shutdown":         {NoAuthorize: true},
+		"POST:/api/v2/workspaceagents/me/metadata/{key}":        {NoAuthorize: true},
+		"POST:/api/v2/workspaceagents/me/session-recordings":    {NoAuthorize: true},
 
 		// These endpoints have more assertions. This is good, add more endpoints to assert if you can!
 		"GET:/api/v2/organizations/{organization}": {AssertObject: rbac.ResourceOrganization.InOrg(a.Admin.OrganizationID)},
@@ -116,14 +123,49 @@ func AGPLRoutes(a *AuthTester) (map[string]string, map[string]RouteCheck) {
 			AssertAction: rbac.ActionRead,
 			AssertObject: workspaceRBACObj,
 		},
+		"GET:/api/v2/workspaceagents/{workspaceagent}/startup-logs": {
+			AssertAction: rbac.ActionRead,
+			AssertObject: workspaceRBACObj,
+		},
+		"GET:/api/v2/workspaceagents/{workspaceagent}/watch-metadata": {
+			AssertAction: rbac.ActionRead,
+			AssertObject: workspaceRBACObj,
+		},
 		"GET:/api/v2/workspaceagents/{workspaceagent}/pty": {
 			AssertAction: rbac.ActionCreate,
 			AssertObject: workspaceExecObj,
 		},
+		"GET:/api/v2/workspaceagents/{workspaceagent}/pty-sessions": {
+			AssertAction: rbac.ActionCreate,
+			AssertObject: workspaceExecObj,
+		},
+		"PATCH:/api/v2/workspaceagents/{workspaceagent}/pty-sessions/{ptysession}": {
+			AssertAction: rbac.ActionCreate,
+			AssertObject: workspaceExecObj,
+		},
+		"DELETE:/api/v2/workspaceagents/{workspaceagent}/pty-sessions/{ptysession}": {
+			AssertAction: rbac.ActionCreate,
+			AssertObject: workspaceExecObj,
+		},
 		"GET:/api/v2/workspaceagents/{workspaceagent}/coordinate": {
 			AssertAction: rbac.ActionCreate,
 			AssertObject: workspaceExecObj,
 		},
+		"GET:/api/v2/insights/templates": {
+			StatusCode:   http.StatusOK,
+			AssertAction: rbac.ActionUpdate,
+			AssertObject: rbac.ResourceTemplate.InOrg(a.Template.OrganizationID),
+		},
+		"GET:/api/v2/insights/users": {
+			StatusCode:   http.StatusOK,
+			AssertAction: rbac.ActionUpdate,
+			AssertObject: rbac.ResourceTemplate.InOrg(a.Template.OrganizationID),
+		},
+		"GET:/api/v2/insights/apps": {
+			StatusCode:   http.StatusOK,
+			AssertAction: rbac.ActionUpdate,
+			AssertObject: rbac.ResourceTemplate.InOrg(a.Template.OrganizationID),
+		},
 		"GET:/api/v2/organizations/{organization}/templates": {
 			StatusCode:   http.StatusOK,
 			AssertAction: rbac.ActionRead,
@@ -141,6 +183,60 @@ func AGPLRoutes(a *AuthTester) (map[string]string, map[string]RouteCheck) {
 			AssertAction: rbac.ActionRead,
 			AssertObject: rbac.ResourceTemplate.InOrg(a.Template.OrganizationID),
 		},
+		"GET:/api/v2/organizations/{organization}/webhooks": {
+			AssertAction: rbac.ActionRead,
+			AssertObject: rbac.ResourceWebhook.InOrg(a.Organization.ID),
+		},
+		"POST:/api/v2/organizations/{organization}/webhooks": {
+			AssertAction: rbac.ActionCreate,
+			AssertObject: rbac.ResourceWebhook.InOrg(a.Organization.ID),
+		},
+		"GET:/api/v2/webhooks/{webhook}": {
+			AssertAction: rbac.ActionRead,
+			AssertObject: rbac.ResourceWebhook.InOrg(a.Webhook.OrganizationID),
+		},
+		"PATCH:/api/v2/webhooks/{webhook}": {
+			AssertAction: rbac.ActionUpdate,
+			AssertObject: rbac.ResourceWebhook.InOrg(a.Webhook.OrganizationID),
+		},
+		"DELETE:/api/v2/webhooks/{webhook}": {
+			AssertAction: rbac.ActionDelete,
+			AssertObject: rbac.ResourceWebhook.InOrg(a.Webhook.OrganizationID),
+		},
+		"GET:/api/v2/webhooks/{webhook}/deliveries": {
+			AssertAction: rbac.ActionRead,
+			AssertObject: rbac.ResourceWebhook.InOrg(a.Webhook.OrganizationID),
+		},
+		"GET:/api/v2/session-recordings": {
+			StatusCode:   http.StatusOK,
+			AssertAction: rbac.ActionRead,
+			AssertObject: rbac.ResourceWorkspaceSessionRecording.InOrg(a.Organization.ID),
+		},
+		"GET:/api/v2/session-recordings/{sessionrecording}": {
+			AssertAction: rbac.ActionRead,
+			AssertObject: rbac.ResourceWorkspaceSessionRecording.InOrg(a.SessionRecording.OrganizationID),
+		},
+		"GET:/api/v2/session-recordings/{sessionrecording}/content": {
+			AssertAction: rbac.ActionRead,
+			AssertObject: rbac.ResourceWorkspaceSessionRecording.InOrg(a.SessionRecording.OrganizationID),
+		},
+		"GET:/api/v2/roles": {
+			StatusCode:   http.StatusOK,
+			AssertAction: rbac.ActionRead,
+			AssertObject: rbac.ResourceCustomRole,
+		},
+		"GET:/api/v2/roles/{role}": {
+			AssertAction: rbac.ActionRead,
+			AssertObject: rbac.ResourceCustomRole,
+		},
+		"PATCH:/api/v2/roles/{role}": {
+			AssertAction: rbac.ActionUpdate,
+			AssertObject: rbac.ResourceCustomRole,
+		},
+		"DELETE:/api/v2/roles/{role}": {
+			AssertAction: rbac.ActionDelete,
+			AssertObject: rbac.ResourceCustomRole,
+		},
 		"POST:/api/v2/files": {AssertAction: rbac.ActionCreate, AssertObject: rbac.ResourceFile},
 		"GET:/api/v2/files/{fileID}": {
 			AssertAction: rbac.ActionRead,
@@ -228,7 +324,28 @@ func AGPLRoutes(a *AuthTester) (map[string]string, map[string]RouteCheck) {
 			AssertAction: rbac.ActionRead,
 			AssertObject: workspaceRBACObj,
 		},
-		"GET:/api/v2/users":                      {StatusCode: http.StatusOK, AssertObject: rbac.ResourceUser},
+		"GET:/api/v2/workspaces/{workspace}/stats": {
+			AssertAction: rbac.ActionRead,
+			AssertObject: workspaceRBACObj,
+		},
+		"GET:/api/v2/workspaces/{workspace}/port-shares": {
+			AssertAction: rbac.ActionRead,
+			AssertObject: workspaceRBACObj,
+		},
+		"POST:/api/v2/workspaces/{workspace}/port-shares": {
+			AssertAction: rbac.ActionUpdate,
+			AssertObject: workspaceRBACObj,
+		},
+		"DELETE:/api/v2/workspaces/{workspace}/port-shares/{agentname}/{port}": {
+			AssertAction: rbac.ActionUpdate,
+			AssertObject: workspaceRBACObj,
+		},
+		"GET:/api/v2/users": {StatusCode: http.StatusOK, AssertObject: rbac.ResourceUser},
+		"GET:/api/v2/organizations/{organization}/members": {
+			StatusCode:   http.StatusOK,
+			AssertAction: rbac.ActionRead,
+			AssertObject: rbac.ResourceOrganizationMember.InOrg(a.Admin.OrganizationID),
+		},
 		"GET:/api/v2/applications/auth-redirect": {AssertAction: rbac.ActionCreate, AssertObject: rbac.ResourceAPIKey},
 
 		// These endpoints need payloads to get to the auth part. Payloads will be required
@@ -236,6 +353,7 @@ func AGPLRoutes(a *AuthTester) (map[string]string, map[string]RouteCheck) {
 		"PUT:/api/v2/organizations/{organization}/members/{user}/roles":                   {NoAuthorize: true},
 		"POST:/api/v2/workspaces/{workspace}/builds":                                      {StatusCode: http.StatusBadRequest, NoAuthorize: true},
 		"POST:/api/v2/organizations/{organization}/templateversions":                      {StatusCode: http.StatusBadRequest, NoAuthorize: true},
+		"POST:/api/v2/roles":                                                              {StatusCode: http.StatusBadRequest, NoAuthorize: true},
 		"GET:/api/v2/organizations/{organization}/templateversions/{templateversionname}": {StatusCode: http.StatusBadRequest, NoAuthorize: true},
 
 		// Endpoints that use the SQLQuery filter.
@@ -263,6 +381,14 @@ func AGPLRoutes(a *AuthTester) (map[string]string, map[string]RouteCheck) {
 		AssertAction: rbac.ActionCreate,
 		AssertObject: applicationConnectObj,
 	})
+	assertRoute["GET:/%40{user}/{workspace_and_agent}/udp/{port}"] = RouteCheck{
+		AssertAction: rbac.ActionCreate,
+		AssertObject: applicationConnectObj,
+	}
+	assertRoute["GET:/@{user}/{workspace_and_agent}/udp/{port}"] = RouteCheck{
+		AssertAction: rbac.ActionCreate,
+		AssertObject: applicationConnectObj,
+	}
 
 	return skipRoutes, assertRoute
 }
@@ -289,6 +415,9 @@ type AuthTester struct {
 	File                  codersdk.UploadResponse
 	TemplateVersionDryRun codersdk.ProvisionerJob
 	TemplateParam         codersdk.Parameter
+	Webhook               codersdk.Webhook
+	SessionRecording      database.WorkspaceSessionRecording
+	CustomRole            codersdk.CustomRole
 	URLParams             map[string]string
 }
 
@@ -346,6 +475,35 @@ func NewAuthTester(ctx context.Context, t *testing.T, client *codersdk.Client, a
 		DestinationScheme: codersdk.ParameterDestinationSchemeProvisionerVariable,
 	})
 	require.NoError(t, err, "create template param")
+	webhook, err := client.CreateWebhook(ctx, admin.OrganizationID, codersdk.CreateWebhookRequest{
+		Name: "test-webhook",
+		URL:  "https://example.com/hook",
+		// Subscribe to an event the tests never trigger so nothing is sent.
+		Events: []codersdk.WebhookEventType{codersdk.WebhookEventWorkspaceAutostop},
+	})
+	require.NoError(t, err, "create webhook")
+	// Session recordings are only uploaded by agents, so insert one directly.
+	sessionRecording, err := api.Database.InsertWorkspaceSessionRecording(ctx, database.InsertWorkspaceSessionRecordingParams{
+		ID:             uuid.New(),
+		CreatedAt:      database.Now(),
+		OrganizationID: admin.OrganizationID,
+		WorkspaceID:    workspace.ID,
+		AgentID:        workspace.LatestBuild.Resources[0].Agents[0].ID,
+		OwnerID:        workspace.OwnerID,
+		Type:           database.SessionRecordingTypeSSH,
+		StartedAt:      database.Now(),
+		EndedAt:        database.Now(),
+		FileID:         file.ID,
+	})
+	require.NoError(t, err, "insert session recording")
+	customRole, err := client.CreateCustomRole(ctx, codersdk.CreateCustomRoleRequest{
+		Name: "test-role",
+		SitePermissions: []codersdk.Permission{{
+			ResourceType: rbac.ResourceTemplate.Type,
+			Action:       rbac.ActionRead,
+		}},
+	})
+	require.NoError(t, err, "create custom role")
 	urlParameters := map[string]string{
 		"{organization}":        admin.OrganizationID.String(),
 		"{user}":                admin.UserID.String(),
@@ -362,7 +520,12 @@ func NewAuthTester(ctx context.Context, t *testing.T, client *codersdk.Client, a
 		"{templateversion}":     version.ID.String(),
 		"{jobID}":               templateVersionDryRun.ID.String(),
 		"{templatename}":        template.Name,
+		"{webhook}":             webhook.ID.String(),
+		"{sessionrecording}":    sessionRecording.ID.String(),
+		"{role}":                customRole.Name,
 		"{workspace_and_agent}": workspace.Name + "." + workspace.LatestBuild.Resources[0].Agents[0].Name,
+		"{agentname}":           workspace.LatestBuild.Resources[0].Agents[0].Name,
+		"{port}":                "8080",
 		// Only checking template scoped params here
 		"parameters/{scope}/{id}": fmt.Sprintf("parameters/%s/%s",
 			string(templateParam.Scope), templateParam.ScopeID.String()),
@@ -382,6 +545,9 @@ func NewAuthTester(ctx context.Context, t *testing.T, client *codersdk.Client, a
 		File:                  file,
 		TemplateVersionDryRun: templateVersionDryRun,
 		TemplateParam:         templateParam,
+		Webhook:               webhook,
+		SessionRecording:      sessionRecording,
+		CustomRole:            customRole,
 		URLParams:             urlParameters,
 	}
 }
@@ -487,7 +653,7 @@ type authCall struct {
 	SubjectID string
 	Roles     []string
 	Groups    []string
-	Scope     rbac.Scope
+	Scope     rbac.ExpandableScope
 	Action    rbac.Action
 	Object    rbac.Object
 }
@@ -501,11 +667,11 @@ var _ rbac.Authorizer = (*RecordingAuthorizer)(nil)
 
 // ByRoleNameSQL does not record the call. This matches the postgres behavior
 // of not calling Authorize()
-func (r *RecordingAuthorizer) ByRoleNameSQL(_ context.Context, _ string, _ []string, _ rbac.Scope, _ []string, _ rbac.Action, _ rbac.Object) error {
+func (r *RecordingAuthorizer) ByRoleNameSQL(_ context.Context, _ string, _ []string, _ rbac.ExpandableScope, _ []string, _ rbac.Action, _ rbac.Object) error {
 	return r.AlwaysReturn
 }
 
-func (r *RecordingAuthorizer) ByRoleName(_ context.Context, subjectID string, roleNames []string, scope rbac.Scope, groups []string, action rbac.Action, object rbac.Object) error {
+func (r *RecordingAuthorizer) ByRoleName(_ context.Context, subjectID string, roleNames []string, scope rbac.ExpandableScope, groups []string, action rbac.Action, object rbac.Object) error {
 	r.Called = &authCall{
 		SubjectID: subjectID,
 		Roles:     roleNames,
@@ -517,7 +683,7 @@ func (r *RecordingAuthorizer) ByRoleName(_ context.Context, subjectID string, ro
 	return r.AlwaysReturn
 }
 
-func (r *RecordingAuthorizer) PrepareByRoleName(_ context.Context, subjectID string, roles []string, scope rbac.Scope, groups []string, action rbac.Action, _ string) (rbac.PreparedAuthorized, error) {
+func (r *RecordingAuthorizer) PrepareByRoleName(_ context.Context, subjectID string, roles []string, scope rbac.ExpandableScope, groups []string, action rbac.Action, _ string) (rbac.PreparedAuthorized, error) {
 	return &fakePreparedAuthorizer{
 		Original:           r,
 		SubjectID:          subjectID,
@@ -537,7 +703,7 @@ type fakePreparedAuthorizer struct {
 	Original            *RecordingAuthorizer
 	SubjectID           string
 	Roles               []string
-	Scope               rbac.Scope
+	Scope               rbac.ExpandableScope
 	Action              rbac.Action
 	Groups              []string
 	HardCodedSQLString  string
@@ -25,6 +25,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -58,6 +59,7 @@ import (
 	"github.com/coder/coder/coderd/awsidentity"
 	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/coderd/database/dbtestutil"
+	"github.com/coder/coder/coderd/filestore"
 	"github.com/coder/coder/coderd/gitauth"
 	"github.com/coder/coder/coderd/gitsshkey"
 	"github.com/coder/coder/coderd/httpapi"
@@ -95,11 +97,14 @@ type Options struct {
 	TLSCertificates      []tls.Certificate
 	GitAuthConfigs       []*gitauth.Config
 	TrialGenerator       func(context.Context, string) error
+	FileStore            filestore.Store
 
 	// IncludeProvisionerDaemon when true means to start an in-memory provisionerD
 	IncludeProvisionerDaemon    bool
 	MetricsCacheRefreshInterval time.Duration
 	AgentStatsRefreshInterval   time.Duration
+	InsightsRefreshInterval     time.Duration
+	WebhookRetryInterval        time.Duration
 	DeploymentConfig            *codersdk.DeploymentConfig
 
 	// Overriding the database is heavily discouraged.
@@ -168,13 +173,18 @@ func NewOptions(t *testing.T, options *Options) (func(http.Handler), context.Can
 		options.DeploymentConfig = DeploymentConfig(t)
 	}
 
+	var auditor atomic.Pointer[audit.Auditor]
+	if options.Auditor != nil {
+		auditor.Store(&options.Auditor)
+	}
+
 	ctx, cancelFunc := context.WithCancel(context.Background())
 	lifecycleExecutor := executor.New(
 		ctx,
 		options.Database,
 		slogtest.Make(t, nil).Named("autobuild.executor").Leveled(slog.LevelDebug),
 		options.AutobuildTicker,
-	).WithStatsChannel(options.AutobuildStats)
+	).WithStatsChannel(options.AutobuildStats).WithAuditor(&auditor)
 	lifecycleExecutor.Run()
 
 	var mutex sync.RWMutex
@@ -242,6 +252,7 @@ func NewOptions(t *testing.T, options *Options) (func(http.Handler), context.Can
 			AppHostnameRegex:               appHostnameRegex,
 			Logger:                         slogtest.Make(t, nil).Leveled(slog.LevelDebug),
 			CacheDir:                       t.TempDir(),
+			FileStore:                      options.FileStore,
 			Database:                       options.Database,
 			Pubsub:                         options.Pubsub,
 			GitAuthConfigs:                 options.GitAuthConfigs,
@@ -282,6 +293,8 @@ func NewOptions(t *testing.T, options *Options) (func(http.Handler), context.Can
 			AutoImportTemplates:         options.AutoImportTemplates,
 			MetricsCacheRefreshInterval: options.MetricsCacheRefreshInterval,
 			AgentStatsRefreshInterval:   options.AgentStatsRefreshInterval,
+			InsightsRefreshInterval:     options.InsightsRefreshInterval,
+			WebhookRetryInterval:        options.WebhookRetryInterval,
 			DeploymentConfig:            options.DeploymentConfig,
 		}
 }
@@ -295,7 +308,8 @@ func NewWithAPI(t *testing.T, options *Options) (*codersdk.Client, io.Closer, *c
 	}
 	setHandler, cancelFunc, newOptions := NewOptions(t, options)
 	// We set the handler after server creation for the access URL.
-	coderAPI := coderd.New(newOptions)
+	coderAPI, err := coderd.New(newOptions)
+	require.NoError(t, err)
 	setHandler(coderAPI.RootHandler)
 	var provisionerCloser io.Closer = nopcloser{}
 	if options.IncludeProvisionerDaemon {
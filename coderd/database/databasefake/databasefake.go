@@ -40,6 +40,7 @@ func New() database.Store {
 			groupMembers:                   make([]database.GroupMember, 0),
 			auditLogs:                      make([]database.AuditLog, 0),
 			files:                          make([]database.File, 0),
+			fileData:                       make([]database.FileData, 0),
 			gitSSHKey:                      make([]database.GitSSHKey, 0),
 			parameterSchemas:               make([]database.ParameterSchema, 0),
 			parameterValues:                make([]database.ParameterValue, 0),
@@ -50,8 +51,13 @@ func New() database.Store {
 			provisionerJobResourceMetadata: make([]database.WorkspaceResourceMetadatum, 0),
 			provisionerJobs:                make([]database.ProvisionerJob, 0),
 			templateVersions:               make([]database.TemplateVersion, 0),
+			templateVersionParameters:      make([]database.TemplateVersionParameter, 0),
 			templates:                      make([]database.Template, 0),
+			webhooks:                       make([]database.Webhook, 0),
+			webhookDeliveries:              make([]database.WebhookDelivery, 0),
 			workspaceBuilds:                make([]database.WorkspaceBuild, 0),
+			workspaceBuildParameters:       make([]database.WorkspaceBuildParameter, 0),
+			workspaceAgentStartupLogs:      make([]database.WorkspaceAgentStartupLog, 0),
 			workspaceApps:                  make([]database.WorkspaceApp, 0),
 			workspaces:                     make([]database.Workspace, 0),
 			licenses:                       make([]database.License, 0),
@@ -91,7 +97,9 @@ type data struct {
 	// New tables
 	agentStats                     []database.AgentStat
 	auditLogs                      []database.AuditLog
+	customRoles                    []database.CustomRole
 	files                          []database.File
+	fileData                       []database.FileData
 	gitAuthLinks                   []database.GitAuthLink
 	gitSSHKey                      []database.GitSSHKey
 	groups                         []database.Group
@@ -105,16 +113,27 @@ type data struct {
 	provisionerJobResourceMetadata []database.WorkspaceResourceMetadatum
 	provisionerJobs                []database.ProvisionerJob
 	templateVersions               []database.TemplateVersion
+	templateVersionParameters      []database.TemplateVersionParameter
 	templates                      []database.Template
+	templateUsageStats             []database.TemplateUsageStat
+	templateAppUsageStats          []database.TemplateAppUsageStat
+	webhooks                       []database.Webhook
+	webhookDeliveries              []database.WebhookDelivery
+	workspaceAgentMetadata         []database.WorkspaceAgentMetadatum
+	workspaceAgentStartupLogs      []database.WorkspaceAgentStartupLog
 	workspaceBuilds                []database.WorkspaceBuild
+	workspaceBuildParameters       []database.WorkspaceBuildParameter
 	workspaceApps                  []database.WorkspaceApp
+	workspaceSessionRecordings     []database.WorkspaceSessionRecording
+	workspaceAgentPortShares       []database.WorkspaceAgentPortShare
 	workspaces                     []database.Workspace
 	licenses                       []database.License
 	replicas                       []database.Replica
 
-	deploymentID  string
-	derpMeshKey   string
-	lastLicenseID int32
+	deploymentID            string
+	derpMeshKey             string
+	lastAutostopNoticeCheck string
+	lastLicenseID           int32
 }
 
 func (*fakeQuerier) Ping(_ context.Context) (time.Duration, error) {
@@ -128,6 +147,18 @@ func (q *fakeQuerier) InTx(fn func(database.Store) error, _ *sql.TxOptions) erro
 	return fn(&fakeQuerier{mutex: inTxMutex{}, data: q.data})
 }
 
+// AcquireLock is a no-op because transactions of the fake database are
+// already serialized.
+func (*fakeQuerier) AcquireLock(_ context.Context, _ int64) error {
+	return nil
+}
+
+// TryAcquireLock always succeeds because transactions of the fake database
+// are already serialized.
+func (*fakeQuerier) TryAcquireLock(_ context.Context, _ int64) (bool, error) {
+	return true, nil
+}
+
 func (q *fakeQuerier) AcquireProvisionerJob(_ context.Context, arg database.AcquireProvisionerJobParams) (database.ProvisionerJob, error) {
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
@@ -136,6 +167,9 @@ func (q *fakeQuerier) AcquireProvisionerJob(_ context.Context, arg database.Acqu
 		if provisionerJob.StartedAt.Valid {
 			continue
 		}
+		if arg.OrganizationID != uuid.Nil && provisionerJob.OrganizationID != arg.OrganizationID {
+			continue
+		}
 		found := false
 		for _, provisionerType := range arg.Types {
 			if provisionerJob.Provisioner != provisionerType {
@@ -189,13 +223,14 @@ func (q *fakeQuerier) InsertAgentStat(_ context.Context, p database.InsertAgentS
 	defer q.mutex.Unlock()
 
 	stat := database.AgentStat{
-		ID:          p.ID,
-		CreatedAt:   p.CreatedAt,
-		WorkspaceID: p.WorkspaceID,
-		AgentID:     p.AgentID,
-		UserID:      p.UserID,
-		Payload:     p.Payload,
-		TemplateID:  p.TemplateID,
+		ID:                 p.ID,
+		CreatedAt:          p.CreatedAt,
+		WorkspaceID:        p.WorkspaceID,
+		AgentID:            p.AgentID,
+		UserID:             p.UserID,
+		Payload:            p.Payload,
+		TemplateID:         p.TemplateID,
+		ConnectionActivity: p.ConnectionActivity,
 	}
 	q.agentStats = append(q.agentStats, stat)
 	return stat, nil
@@ -228,6 +263,60 @@ func (q *fakeQuerier) GetLatestAgentStat(_ context.Context, agentID uuid.UUID) (
 	return latest, nil
 }
 
+func (q *fakeQuerier) GetLatestAgentStats(_ context.Context, createdAfter time.Time) ([]database.GetLatestAgentStatsRow, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	latest := map[uuid.UUID]database.AgentStat{}
+	for _, agentStat := range q.agentStats {
+		if !agentStat.CreatedAt.After(createdAfter) {
+			continue
+		}
+		if current, ok := latest[agentStat.AgentID]; ok && !agentStat.CreatedAt.After(current.CreatedAt) {
+			continue
+		}
+		latest[agentStat.AgentID] = agentStat
+	}
+
+	rows := make([]database.GetLatestAgentStatsRow, 0, len(latest))
+	for _, agentStat := range latest {
+		row := database.GetLatestAgentStatsRow{
+			AgentID:   agentStat.AgentID,
+			CreatedAt: agentStat.CreatedAt,
+			Payload:   agentStat.Payload,
+		}
+		var foundUser, foundWorkspace, foundAgent bool
+		for _, user := range q.users {
+			if user.ID == agentStat.UserID {
+				row.Username = user.Username
+				foundUser = true
+				break
+			}
+		}
+		for _, workspace := range q.workspaces {
+			if workspace.ID == agentStat.WorkspaceID && !workspace.Deleted {
+				row.WorkspaceName = workspace.Name
+				foundWorkspace = true
+				break
+			}
+		}
+		for _, agent := range q.provisionerJobAgents {
+			if agent.ID == agentStat.AgentID {
+				row.AgentName = agent.Name
+				foundAgent = true
+				break
+			}
+		}
+		if foundUser && foundWorkspace && foundAgent {
+			rows = append(rows, row)
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].AgentID.String() < rows[j].AgentID.String()
+	})
+	return rows, nil
+}
+
 func (q *fakeQuerier) GetTemplateDAUs(_ context.Context, templateID uuid.UUID) ([]database.GetTemplateDAUsRow, error) {
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
@@ -235,7 +324,7 @@ func (q *fakeQuerier) GetTemplateDAUs(_ context.Context, templateID uuid.UUID) (
 	seens := make(map[time.Time]map[uuid.UUID]struct{})
 
 	for _, as := range q.agentStats {
-		if as.TemplateID != templateID {
+		if as.TemplateID != templateID || !as.ConnectionActivity {
 			continue
 		}
 
@@ -380,6 +469,31 @@ func (q *fakeQuerier) GetAPIKeysByLoginType(_ context.Context, t database.LoginT
 	return apiKeys, nil
 }
 
+func (q *fakeQuerier) GetAPIKeysByUserID(_ context.Context, arg database.GetAPIKeysByUserIDParams) ([]database.APIKey, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	apiKeys := make([]database.APIKey, 0)
+	for _, key := range q.apiKeys {
+		if key.LoginType == arg.LoginType && key.UserID == arg.UserID {
+			apiKeys = append(apiKeys, key)
+		}
+	}
+	return apiKeys, nil
+}
+
+func (q *fakeQuerier) GetAPIKeyByName(_ context.Context, arg database.GetAPIKeyByNameParams) (database.APIKey, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	for _, key := range q.apiKeys {
+		if key.LoginType == database.LoginTypeToken && key.UserID == arg.UserID && key.TokenName == arg.TokenName {
+			return key, nil
+		}
+	}
+	return database.APIKey{}, sql.ErrNoRows
+}
+
 func (q *fakeQuerier) DeleteAPIKeyByID(_ context.Context, id string) error {
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
@@ -664,19 +778,20 @@ func convertUsers(users []database.User, count int64) []database.GetUsersRow {
 	rows := make([]database.GetUsersRow, len(users))
 	for i, u := range users {
 		rows[i] = database.GetUsersRow{
-			ID:             u.ID,
-			Email:          u.Email,
-			Username:       u.Username,
-			HashedPassword: u.HashedPassword,
-			CreatedAt:      u.CreatedAt,
-			UpdatedAt:      u.UpdatedAt,
-			Status:         u.Status,
-			RBACRoles:      u.RBACRoles,
-			LoginType:      u.LoginType,
-			AvatarURL:      u.AvatarURL,
-			Deleted:        u.Deleted,
-			LastSeenAt:     u.LastSeenAt,
-			Count:          count,
+			ID:                 u.ID,
+			Email:              u.Email,
+			Username:           u.Username,
+			HashedPassword:     u.HashedPassword,
+			CreatedAt:          u.CreatedAt,
+			UpdatedAt:          u.UpdatedAt,
+			Status:             u.Status,
+			RBACRoles:          u.RBACRoles,
+			LoginType:          u.LoginType,
+			AvatarURL:          u.AvatarURL,
+			Deleted:            u.Deleted,
+			LastSeenAt:         u.LastSeenAt,
+			AutoStartOnConnect: u.AutoStartOnConnect,
+			Count:              count,
 		}
 	}
 
@@ -923,6 +1038,7 @@ func convertToWorkspaceRows(workspaces []database.Workspace, count int64) []data
 			AutostartSchedule: w.AutostartSchedule,
 			Ttl:               w.Ttl,
 			LastUsedAt:        w.LastUsedAt,
+			DeletingAt:        w.DeletingAt,
 			Count:             count,
 		}
 	}
@@ -1107,6 +1223,27 @@ func (q *fakeQuerier) GetLatestWorkspaceBuildByWorkspaceID(_ context.Context, wo
 	return row, nil
 }
 
+func (q *fakeQuerier) GetLatestWorkspaceBuildByWorkspaceIDAndTransition(_ context.Context, arg database.GetLatestWorkspaceBuildByWorkspaceIDAndTransitionParams) (database.WorkspaceBuild, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	var row database.WorkspaceBuild
+	var buildNum int32 = -1
+	for _, workspaceBuild := range q.workspaceBuilds {
+		if workspaceBuild.WorkspaceID != arg.WorkspaceID || workspaceBuild.Transition != arg.Transition {
+			continue
+		}
+		if workspaceBuild.BuildNumber > buildNum {
+			row = workspaceBuild
+			buildNum = workspaceBuild.BuildNumber
+		}
+	}
+	if buildNum == -1 {
+		return database.WorkspaceBuild{}, sql.ErrNoRows
+	}
+	return row, nil
+}
+
 func (q *fakeQuerier) GetLatestWorkspaceBuilds(_ context.Context) ([]database.WorkspaceBuild, error) {
 	q.mutex.RLock()
 	defer q.mutex.RUnlock()
@@ -1160,6 +1297,40 @@ func (q *fakeQuerier) GetLatestWorkspaceBuildsByWorkspaceIDs(_ context.Context,
 	return returnBuilds, nil
 }
 
+func (q *fakeQuerier) GetUpcomingWorkspaceAutostops(_ context.Context, arg database.GetUpcomingWorkspaceAutostopsParams) ([]database.GetUpcomingWorkspaceAutostopsRow, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	latest := make(map[uuid.UUID]database.WorkspaceBuild)
+	for _, build := range q.workspaceBuilds {
+		if build.BuildNumber > latest[build.WorkspaceID].BuildNumber {
+			latest[build.WorkspaceID] = build
+		}
+	}
+	rows := make([]database.GetUpcomingWorkspaceAutostopsRow, 0)
+	for _, workspace := range q.workspaces {
+		if workspace.Deleted || !workspace.Ttl.Valid {
+			continue
+		}
+		build, ok := latest[workspace.ID]
+		if !ok || build.Transition != database.WorkspaceTransitionStart {
+			continue
+		}
+		if !build.Deadline.After(arg.DeadlineAfter) || build.Deadline.After(arg.DeadlineBefore) {
+			continue
+		}
+		rows = append(rows, database.GetUpcomingWorkspaceAutostopsRow{
+			WorkspaceID:    workspace.ID,
+			WorkspaceName:  workspace.Name,
+			OwnerID:        workspace.OwnerID,
+			OrganizationID: workspace.OrganizationID,
+			BuildID:        build.ID,
+			Deadline:       build.Deadline,
+		})
+	}
+	return rows, nil
+}
+
 func (q *fakeQuerier) GetWorkspaceBuildsByWorkspaceID(_ context.Context,
 	params database.GetWorkspaceBuildsByWorkspaceIDParams,
 ) ([]database.WorkspaceBuild, error) {
@@ -1255,7 +1426,12 @@ func (q *fakeQuerier) GetOrganizations(_ context.Context) ([]database.Organizati
 	if len(q.organizations) == 0 {
 		return nil, sql.ErrNoRows
 	}
-	return q.organizations, nil
+	organizations := make([]database.Organization, len(q.organizations))
+	copy(organizations, q.organizations)
+	sort.SliceStable(organizations, func(i, j int) bool {
+		return organizations[i].CreatedAt.Before(organizations[j].CreatedAt)
+	})
+	return organizations, nil
 }
 
 func (q *fakeQuerier) GetOrganizationByID(_ context.Context, id uuid.UUID) (database.Organization, error) {
@@ -1379,6 +1555,18 @@ func (q *fakeQuerier) UpdateTemplateMetaByID(_ context.Context, arg database.Upd
 		tpl.Description = arg.Description
 		tpl.Icon = arg.Icon
 		tpl.DefaultTTL = arg.DefaultTTL
+		tpl.InactivityTTL = arg.InactivityTTL
+		tpl.StoppedTTL = arg.StoppedTTL
+		tpl.MaxTTL = arg.MaxTTL
+		tpl.AutostartDaysOfWeek = arg.AutostartDaysOfWeek
+		tpl.AutostartStartHour = arg.AutostartStartHour
+		tpl.AutostartEndHour = arg.AutostartEndHour
+		tpl.QuietHoursSchedule = arg.QuietHoursSchedule
+		tpl.QuietHoursDuration = arg.QuietHoursDuration
+		tpl.SessionRecording = arg.SessionRecording
+		tpl.AutoStartOnConnect = arg.AutoStartOnConnect
+		tpl.MaxPortSharingLevel = arg.MaxPortSharingLevel
+		tpl.AutodeleteWarningTTL = arg.AutodeleteWarningTTL
 		q.templates[idx] = tpl
 		return tpl, nil
 	}
@@ -1560,6 +1748,40 @@ func (q *fakeQuerier) GetTemplateVersionByJobID(_ context.Context, jobID uuid.UU
 	return database.TemplateVersion{}, sql.ErrNoRows
 }
 
+func (q *fakeQuerier) GetTemplateVersionParameters(_ context.Context, templateVersionID uuid.UUID) ([]database.TemplateVersionParameter, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	parameters := make([]database.TemplateVersionParameter, 0)
+	for _, param := range q.templateVersionParameters {
+		if param.TemplateVersionID != templateVersionID {
+			continue
+		}
+		parameters = append(parameters, param)
+	}
+	sort.Slice(parameters, func(i, j int) bool {
+		return parameters[i].Index < parameters[j].Index
+	})
+	return parameters, nil
+}
+
+func (q *fakeQuerier) GetWorkspaceBuildParameters(_ context.Context, workspaceBuildID uuid.UUID) ([]database.WorkspaceBuildParameter, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	params := make([]database.WorkspaceBuildParameter, 0)
+	for _, param := range q.workspaceBuildParameters {
+		if param.WorkspaceBuildID != workspaceBuildID {
+			continue
+		}
+		params = append(params, param)
+	}
+	sort.Slice(params, func(i, j int) bool {
+		return params[i].Name < params[j].Name
+	})
+	return params, nil
+}
+
 func (q *fakeQuerier) GetParameterSchemasByJobID(_ context.Context, jobID uuid.UUID) ([]database.ParameterSchema, error) {
 	q.mutex.RLock()
 	defer q.mutex.RUnlock()
@@ -1744,6 +1966,60 @@ func (q *fakeQuerier) GetOrganizationIDsByMemberIDs(_ context.Context, ids []uui
 	return getOrganizationIDsByMemberIDRows, nil
 }
 
+func (q *fakeQuerier) GetOrganizationMembers(_ context.Context, organizationID uuid.UUID) ([]database.OrganizationMember, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	members := make([]database.OrganizationMember, 0)
+	for _, member := range q.organizationMembers {
+		if member.OrganizationID != organizationID {
+			continue
+		}
+		members = append(members, member)
+	}
+	sort.SliceStable(members, func(i, j int) bool {
+		return members[i].CreatedAt.Before(members[j].CreatedAt)
+	})
+	return members, nil
+}
+
+func (q *fakeQuerier) DeleteOrganizationMember(_ context.Context, arg database.DeleteOrganizationMemberParams) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for index, member := range q.organizationMembers {
+		if member.OrganizationID != arg.OrganizationID || member.UserID != arg.UserID {
+			continue
+		}
+		q.organizationMembers[index] = q.organizationMembers[len(q.organizationMembers)-1]
+		q.organizationMembers = q.organizationMembers[:len(q.organizationMembers)-1]
+		return nil
+	}
+	return nil
+}
+
+func (q *fakeQuerier) UpdateOrganization(_ context.Context, arg database.UpdateOrganizationParams) (database.Organization, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for _, organization := range q.organizations {
+		if organization.ID != arg.ID && strings.EqualFold(organization.Name, arg.Name) {
+			return database.Organization{}, errDuplicateKey
+		}
+	}
+	for index, organization := range q.organizations {
+		if organization.ID != arg.ID {
+			continue
+		}
+		organization.Name = arg.Name
+		organization.Description = arg.Description
+		organization.UpdatedAt = arg.UpdatedAt
+		q.organizations[index] = organization
+		return organization, nil
+	}
+	return database.Organization{}, sql.ErrNoRows
+}
+
 func (q *fakeQuerier) GetOrganizationMembershipsByUserID(_ context.Context, userID uuid.UUID) ([]database.OrganizationMember, error) {
 	q.mutex.RLock()
 	defer q.mutex.RUnlock()
@@ -1853,6 +2129,39 @@ func (q *fakeQuerier) GetWorkspaceAgentsByResourceIDs(_ context.Context, resourc
 	return workspaceAgents, nil
 }
 
+func (q *fakeQuerier) GetWorkspaceAgentMetadataByAgentIDs(_ context.Context, ids []uuid.UUID) ([]database.WorkspaceAgentMetadatum, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	metadata := make([]database.WorkspaceAgentMetadatum, 0)
+	for _, m := range q.workspaceAgentMetadata {
+		if slices.Contains(ids, m.WorkspaceAgentID) {
+			metadata = append(metadata, m)
+		}
+	}
+	sort.Slice(metadata, func(i, j int) bool {
+		return metadata[i].Key < metadata[j].Key
+	})
+	return metadata, nil
+}
+
+func (q *fakeQuerier) GetWorkspaceAgentStartupLogsAfter(_ context.Context, arg database.GetWorkspaceAgentStartupLogsAfterParams) ([]database.WorkspaceAgentStartupLog, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	logs := make([]database.WorkspaceAgentStartupLog, 0)
+	for _, log := range q.workspaceAgentStartupLogs {
+		if log.AgentID != arg.AgentID {
+			continue
+		}
+		if log.ID <= arg.CreatedAfter {
+			continue
+		}
+		logs = append(logs, log)
+	}
+	return logs, nil
+}
+
 func (q *fakeQuerier) GetWorkspaceAgentsCreatedAfter(_ context.Context, after time.Time) ([]database.WorkspaceAgent, error) {
 	q.mutex.RLock()
 	defer q.mutex.RUnlock()
@@ -2076,6 +2385,14 @@ func (q *fakeQuerier) InsertAPIKey(_ context.Context, arg database.InsertAPIKeyP
 		arg.LifetimeSeconds = 86400
 	}
 
+	if arg.LoginType == database.LoginTypeToken {
+		for _, key := range q.apiKeys {
+			if key.LoginType == database.LoginTypeToken && key.UserID == arg.UserID && key.TokenName == arg.TokenName {
+				return database.APIKey{}, errDuplicateKey
+			}
+		}
+	}
+
 	//nolint:gosimple
 	key := database.APIKey{
 		ID:              arg.ID,
@@ -2089,6 +2406,8 @@ func (q *fakeQuerier) InsertAPIKey(_ context.Context, arg database.InsertAPIKeyP
 		LastUsed:        arg.LastUsed,
 		LoginType:       arg.LoginType,
 		Scope:           arg.Scope,
+		TokenName:       arg.TokenName,
+		AllowList:       arg.AllowList,
 	}
 	q.apiKeys = append(q.apiKeys, key)
 	return key, nil
@@ -2105,21 +2424,141 @@ func (q *fakeQuerier) InsertFile(_ context.Context, arg database.InsertFileParam
 		CreatedAt: arg.CreatedAt,
 		CreatedBy: arg.CreatedBy,
 		Mimetype:  arg.Mimetype,
-		Data:      arg.Data,
+		Storage:   arg.Storage,
 	}
 	q.files = append(q.files, file)
 	return file, nil
 }
 
+func (q *fakeQuerier) UpdateFileCreatedAtByID(_ context.Context, arg database.UpdateFileCreatedAtByIDParams) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for index, file := range q.files {
+		if file.ID != arg.ID {
+			continue
+		}
+		file.CreatedAt = arg.CreatedAt
+		q.files[index] = file
+		return nil
+	}
+	return sql.ErrNoRows
+}
+
+func (q *fakeQuerier) DeleteUnreferencedFileByID(_ context.Context, arg database.DeleteUnreferencedFileByIDParams) (database.File, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for index, file := range q.files {
+		if file.ID != arg.ID {
+			continue
+		}
+		if !file.CreatedAt.Before(arg.CreatedBefore) || q.isFileReferencedNoLock(file.ID) {
+			return database.File{}, sql.ErrNoRows
+		}
+		q.files[index] = q.files[len(q.files)-1]
+		q.files = q.files[:len(q.files)-1]
+		return file, nil
+	}
+	return database.File{}, sql.ErrNoRows
+}
+
+// isFileReferencedNoLock returns whether a provisioner job or session
+// recording references the file.
+func (q *fakeQuerier) isFileReferencedNoLock(id uuid.UUID) bool {
+	for _, job := range q.provisionerJobs {
+		if job.FileID == id {
+			return true
+		}
+	}
+	for _, recording := range q.workspaceSessionRecordings {
+		if recording.FileID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (q *fakeQuerier) GetFileCountByHashAndStorage(_ context.Context, arg database.GetFileCountByHashAndStorageParams) (int64, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	var count int64
+	for _, file := range q.files {
+		if file.Hash == arg.Hash && file.Storage == arg.Storage {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (q *fakeQuerier) GetUnreferencedFilesCreatedBefore(_ context.Context, createdAt time.Time) ([]database.File, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	files := make([]database.File, 0)
+	for _, file := range q.files {
+		if !file.CreatedAt.Before(createdAt) || q.isFileReferencedNoLock(file.ID) {
+			continue
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+func (q *fakeQuerier) InsertFileData(_ context.Context, arg database.InsertFileDataParams) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for _, data := range q.fileData {
+		if data.Hash == arg.Hash {
+			return nil
+		}
+	}
+	q.fileData = append(q.fileData, database.FileData{
+		Hash: arg.Hash,
+		Data: arg.Data,
+	})
+	return nil
+}
+
+func (q *fakeQuerier) GetFileDataByHash(_ context.Context, hash string) ([]byte, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	for _, data := range q.fileData {
+		if data.Hash == hash {
+			return data.Data, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (q *fakeQuerier) DeleteFileDataByHash(_ context.Context, hash string) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for index, data := range q.fileData {
+		if data.Hash != hash {
+			continue
+		}
+		q.fileData[index] = q.fileData[len(q.fileData)-1]
+		q.fileData = q.fileData[:len(q.fileData)-1]
+		return nil
+	}
+	return nil
+}
+
 func (q *fakeQuerier) InsertOrganization(_ context.Context, arg database.InsertOrganizationParams) (database.Organization, error) {
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
 
 	organization := database.Organization{
-		ID:        arg.ID,
-		Name:      arg.Name,
-		CreatedAt: arg.CreatedAt,
-		UpdatedAt: arg.UpdatedAt,
+		ID:          arg.ID,
+		Name:        arg.Name,
+		Description: arg.Description,
+		CreatedAt:   arg.CreatedAt,
+		UpdatedAt:   arg.UpdatedAt,
 	}
 	q.organizations = append(q.organizations, organization)
 	return organization, nil
@@ -2167,23 +2606,35 @@ func (q *fakeQuerier) InsertTemplate(_ context.Context, arg database.InsertTempl
 
 	//nolint:gosimple
 	template := database.Template{
-		ID:              arg.ID,
-		CreatedAt:       arg.CreatedAt,
-		UpdatedAt:       arg.UpdatedAt,
-		OrganizationID:  arg.OrganizationID,
-		Name:            arg.Name,
-		Provisioner:     arg.Provisioner,
-		ActiveVersionID: arg.ActiveVersionID,
-		Description:     arg.Description,
-		DefaultTTL:      arg.DefaultTTL,
-		CreatedBy:       arg.CreatedBy,
-		UserACL:         arg.UserACL,
-		GroupACL:        arg.GroupACL,
-		DisplayName:     arg.DisplayName,
-		Icon:            arg.Icon,
-	}
-	q.templates = append(q.templates, template)
-	return template, nil
+		ID:                   arg.ID,
+		CreatedAt:            arg.CreatedAt,
+		UpdatedAt:            arg.UpdatedAt,
+		OrganizationID:       arg.OrganizationID,
+		Name:                 arg.Name,
+		Provisioner:          arg.Provisioner,
+		ActiveVersionID:      arg.ActiveVersionID,
+		Description:          arg.Description,
+		DefaultTTL:           arg.DefaultTTL,
+		CreatedBy:            arg.CreatedBy,
+		UserACL:              arg.UserACL,
+		GroupACL:             arg.GroupACL,
+		DisplayName:          arg.DisplayName,
+		Icon:                 arg.Icon,
+		InactivityTTL:        arg.InactivityTTL,
+		StoppedTTL:           arg.StoppedTTL,
+		MaxTTL:               arg.MaxTTL,
+		AutostartDaysOfWeek:  arg.AutostartDaysOfWeek,
+		AutostartStartHour:   arg.AutostartStartHour,
+		AutostartEndHour:     arg.AutostartEndHour,
+		QuietHoursSchedule:   arg.QuietHoursSchedule,
+		QuietHoursDuration:   arg.QuietHoursDuration,
+		SessionRecording:     arg.SessionRecording,
+		AutoStartOnConnect:   arg.AutoStartOnConnect,
+		MaxPortSharingLevel:  database.AppSharingLevelOwner,
+		AutodeleteWarningTTL: arg.AutodeleteWarningTTL,
+	}
+	q.templates = append(q.templates, template)
+	return template, nil
 }
 
 func (q *fakeQuerier) InsertTemplateVersion(_ context.Context, arg database.InsertTemplateVersionParams) (database.TemplateVersion, error) {
@@ -2206,6 +2657,58 @@ func (q *fakeQuerier) InsertTemplateVersion(_ context.Context, arg database.Inse
 	return version, nil
 }
 
+func (q *fakeQuerier) InsertTemplateVersionParameter(_ context.Context, arg database.InsertTemplateVersionParameterParams) (database.TemplateVersionParameter, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for _, param := range q.templateVersionParameters {
+		if param.TemplateVersionID == arg.TemplateVersionID && param.Name == arg.Name {
+			return database.TemplateVersionParameter{}, errDuplicateKey
+		}
+	}
+
+	//nolint:gosimple
+	param := database.TemplateVersionParameter{
+		TemplateVersionID: arg.TemplateVersionID,
+		Name:              arg.Name,
+		Description:       arg.Description,
+		Type:              arg.Type,
+		Mutable:           arg.Mutable,
+		DefaultValue:      arg.DefaultValue,
+		Icon:              arg.Icon,
+		Options:           arg.Options,
+		ValidationRegex:   arg.ValidationRegex,
+		ValidationMin:     arg.ValidationMin,
+		ValidationMax:     arg.ValidationMax,
+		ValidationError:   arg.ValidationError,
+		Index:             arg.Index,
+	}
+	q.templateVersionParameters = append(q.templateVersionParameters, param)
+	return param, nil
+}
+
+func (q *fakeQuerier) InsertWorkspaceBuildParameters(_ context.Context, arg database.InsertWorkspaceBuildParametersParams) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if len(arg.Name) != len(arg.Value) {
+		return xerrors.Errorf("got %d names and %d values", len(arg.Name), len(arg.Value))
+	}
+	for index, name := range arg.Name {
+		for _, param := range q.workspaceBuildParameters {
+			if param.WorkspaceBuildID == arg.WorkspaceBuildID && param.Name == name {
+				return errDuplicateKey
+			}
+		}
+		q.workspaceBuildParameters = append(q.workspaceBuildParameters, database.WorkspaceBuildParameter{
+			WorkspaceBuildID: arg.WorkspaceBuildID,
+			Name:             name,
+			Value:            arg.Value[index],
+		})
+	}
+	return nil
+}
+
 func (q *fakeQuerier) InsertProvisionerJobLogs(_ context.Context, arg database.InsertProvisionerJobLogsParams) ([]database.ProvisionerJobLog, error) {
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
@@ -2264,11 +2767,12 @@ func (q *fakeQuerier) InsertProvisionerDaemon(_ context.Context, arg database.In
 	defer q.mutex.Unlock()
 
 	daemon := database.ProvisionerDaemon{
-		ID:           arg.ID,
-		CreatedAt:    arg.CreatedAt,
-		Name:         arg.Name,
-		Provisioners: arg.Provisioners,
-		Tags:         arg.Tags,
+		ID:             arg.ID,
+		CreatedAt:      arg.CreatedAt,
+		Name:           arg.Name,
+		Provisioners:   arg.Provisioners,
+		Tags:           arg.Tags,
+		OrganizationID: arg.OrganizationID,
 	}
 	q.provisionerDaemons = append(q.provisionerDaemons, daemon)
 	return daemon, nil
@@ -2300,22 +2804,26 @@ func (q *fakeQuerier) InsertWorkspaceAgent(_ context.Context, arg database.Inser
 	defer q.mutex.Unlock()
 
 	agent := database.WorkspaceAgent{
-		ID:                       arg.ID,
-		CreatedAt:                arg.CreatedAt,
-		UpdatedAt:                arg.UpdatedAt,
-		ResourceID:               arg.ResourceID,
-		AuthToken:                arg.AuthToken,
-		AuthInstanceID:           arg.AuthInstanceID,
-		EnvironmentVariables:     arg.EnvironmentVariables,
-		Name:                     arg.Name,
-		Architecture:             arg.Architecture,
-		OperatingSystem:          arg.OperatingSystem,
-		Directory:                arg.Directory,
-		StartupScript:            arg.StartupScript,
-		InstanceMetadata:         arg.InstanceMetadata,
-		ResourceMetadata:         arg.ResourceMetadata,
-		ConnectionTimeoutSeconds: arg.ConnectionTimeoutSeconds,
-		TroubleshootingURL:       arg.TroubleshootingURL,
+		ID:                           arg.ID,
+		CreatedAt:                    arg.CreatedAt,
+		UpdatedAt:                    arg.UpdatedAt,
+		ResourceID:                   arg.ResourceID,
+		AuthToken:                    arg.AuthToken,
+		AuthInstanceID:               arg.AuthInstanceID,
+		EnvironmentVariables:         arg.EnvironmentVariables,
+		Name:                         arg.Name,
+		Architecture:                 arg.Architecture,
+		OperatingSystem:              arg.OperatingSystem,
+		Directory:                    arg.Directory,
+		StartupScript:                arg.StartupScript,
+		InstanceMetadata:             arg.InstanceMetadata,
+		ResourceMetadata:             arg.ResourceMetadata,
+		ConnectionTimeoutSeconds:     arg.ConnectionTimeoutSeconds,
+		TroubleshootingURL:           arg.TroubleshootingURL,
+		StartupScriptTimeoutSeconds:  arg.StartupScriptTimeoutSeconds,
+		ShutdownScript:               arg.ShutdownScript,
+		ShutdownScriptTimeoutSeconds: arg.ShutdownScriptTimeoutSeconds,
+		LifecycleState:               database.WorkspaceAgentLifecycleStateCreated,
 	}
 
 	q.provisionerJobAgents = append(q.provisionerJobAgents, agent)
@@ -2429,6 +2937,22 @@ func (q *fakeQuerier) UpdateUserProfile(_ context.Context, arg database.UpdateUs
 	return database.User{}, sql.ErrNoRows
 }
 
+func (q *fakeQuerier) UpdateUserPreferences(_ context.Context, arg database.UpdateUserPreferencesParams) (database.User, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for index, user := range q.users {
+		if user.ID != arg.ID {
+			continue
+		}
+		user.AutoStartOnConnect = arg.AutoStartOnConnect
+		user.UpdatedAt = arg.UpdatedAt
+		q.users[index] = user
+		return user, nil
+	}
+	return database.User{}, sql.ErrNoRows
+}
+
 func (q *fakeQuerier) UpdateUserStatus(_ context.Context, arg database.UpdateUserStatusParams) (database.User, error) {
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
@@ -2518,6 +3042,67 @@ func (q *fakeQuerier) InsertWorkspaceBuild(_ context.Context, arg database.Inser
 	return workspaceBuild, nil
 }
 
+func (q *fakeQuerier) InsertWorkspaceAgentMetadata(_ context.Context, arg database.InsertWorkspaceAgentMetadataParams) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for _, m := range q.workspaceAgentMetadata {
+		if m.WorkspaceAgentID == arg.WorkspaceAgentID && m.Key == arg.Key {
+			return errDuplicateKey
+		}
+	}
+
+	metadatum := database.WorkspaceAgentMetadatum{
+		WorkspaceAgentID: arg.WorkspaceAgentID,
+		DisplayName:      arg.DisplayName,
+		Key:              arg.Key,
+		Script:           arg.Script,
+		Timeout:          arg.Timeout,
+		Interval:         arg.Interval,
+	}
+	q.workspaceAgentMetadata = append(q.workspaceAgentMetadata, metadatum)
+	return nil
+}
+
+func (q *fakeQuerier) InsertWorkspaceAgentStartupLogs(_ context.Context, arg database.InsertWorkspaceAgentStartupLogsParams) ([]database.WorkspaceAgentStartupLog, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	logs := make([]database.WorkspaceAgentStartupLog, 0)
+	id := int64(0)
+	if len(q.workspaceAgentStartupLogs) > 0 {
+		id = q.workspaceAgentStartupLogs[len(q.workspaceAgentStartupLogs)-1].ID
+	}
+	outputLength := int32(0)
+	for index, output := range arg.Output {
+		id++
+		outputLength += int32(len(output))
+		logs = append(logs, database.WorkspaceAgentStartupLog{
+			ID:        id,
+			AgentID:   arg.AgentID,
+			CreatedAt: arg.CreatedAt[index],
+			Output:    output,
+		})
+	}
+	for index, agent := range q.provisionerJobAgents {
+		if agent.ID != arg.AgentID {
+			continue
+		}
+		// Greater than 1MB, same as the PostgreSQL constraint!
+		if agent.StartupLogsLength+outputLength > (1 << 20) {
+			return nil, &pq.Error{
+				Constraint: "max_startup_logs_length",
+				Table:      "workspace_agents",
+			}
+		}
+		agent.StartupLogsLength += outputLength
+		q.provisionerJobAgents[index] = agent
+		break
+	}
+	q.workspaceAgentStartupLogs = append(q.workspaceAgentStartupLogs, logs...)
+	return logs, nil
+}
+
 func (q *fakeQuerier) InsertWorkspaceApp(_ context.Context, arg database.InsertWorkspaceAppParams) (database.WorkspaceApp, error) {
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
@@ -2710,6 +3295,72 @@ func (q *fakeQuerier) UpdateWorkspaceAgentVersionByID(_ context.Context, arg dat
 	return sql.ErrNoRows
 }
 
+func (q *fakeQuerier) UpdateWorkspaceAgentLifecycleStateByID(_ context.Context, arg database.UpdateWorkspaceAgentLifecycleStateByIDParams) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for index, agent := range q.provisionerJobAgents {
+		if agent.ID != arg.ID {
+			continue
+		}
+
+		agent.LifecycleState = arg.LifecycleState
+		q.provisionerJobAgents[index] = agent
+		return nil
+	}
+	return sql.ErrNoRows
+}
+
+func (q *fakeQuerier) UpdateWorkspaceAgentMetadata(_ context.Context, arg database.UpdateWorkspaceAgentMetadataParams) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for index, m := range q.workspaceAgentMetadata {
+		if m.WorkspaceAgentID != arg.WorkspaceAgentID || m.Key != arg.Key {
+			continue
+		}
+
+		m.Value = arg.Value
+		m.Error = arg.Error
+		m.CollectedAt = arg.CollectedAt
+		q.workspaceAgentMetadata[index] = m
+		return nil
+	}
+	return sql.ErrNoRows
+}
+
+func (q *fakeQuerier) UpdateWorkspaceAgentStartupLogsEOFByID(_ context.Context, arg database.UpdateWorkspaceAgentStartupLogsEOFByIDParams) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for index, agent := range q.provisionerJobAgents {
+		if agent.ID != arg.ID {
+			continue
+		}
+
+		agent.StartupLogsEOF = arg.StartupLogsEOF
+		q.provisionerJobAgents[index] = agent
+		return nil
+	}
+	return sql.ErrNoRows
+}
+
+func (q *fakeQuerier) UpdateWorkspaceAgentStartupLogOverflowByID(_ context.Context, arg database.UpdateWorkspaceAgentStartupLogOverflowByIDParams) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for index, agent := range q.provisionerJobAgents {
+		if agent.ID != arg.ID {
+			continue
+		}
+
+		agent.StartupLogsOverflowed = arg.StartupLogsOverflowed
+		q.provisionerJobAgents[index] = agent
+		return nil
+	}
+	return sql.ErrNoRows
+}
+
 func (q *fakeQuerier) UpdateProvisionerJobByID(_ context.Context, arg database.UpdateProvisionerJobByIDParams) error {
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
@@ -2816,6 +3467,40 @@ func (q *fakeQuerier) UpdateWorkspaceTTL(_ context.Context, arg database.UpdateW
 	return sql.ErrNoRows
 }
 
+func (q *fakeQuerier) UpdateWorkspaceDeletingAtByID(_ context.Context, arg database.UpdateWorkspaceDeletingAtByIDParams) (database.Workspace, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for index, workspace := range q.workspaces {
+		if workspace.ID != arg.ID {
+			continue
+		}
+		workspace.DeletingAt = arg.DeletingAt
+		q.workspaces[index] = workspace
+		return workspace, nil
+	}
+
+	return database.Workspace{}, sql.ErrNoRows
+}
+
+func (q *fakeQuerier) UpdateWorkspacesTTLByTemplateID(_ context.Context, arg database.UpdateWorkspacesTTLByTemplateIDParams) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for index, workspace := range q.workspaces {
+		if workspace.TemplateID != arg.TemplateID || workspace.Deleted {
+			continue
+		}
+		if workspace.Ttl.Valid && workspace.Ttl.Int64 <= arg.MaxTTL {
+			continue
+		}
+		workspace.Ttl = sql.NullInt64{Int64: arg.MaxTTL, Valid: true}
+		q.workspaces[index] = workspace
+	}
+
+	return nil
+}
+
 func (q *fakeQuerier) UpdateWorkspaceLastUsedAt(_ context.Context, arg database.UpdateWorkspaceLastUsedAtParams) error {
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
@@ -2955,6 +3640,19 @@ func (q *fakeQuerier) DeleteGroupMember(_ context.Context, userID uuid.UUID) err
 	return nil
 }
 
+func (q *fakeQuerier) DeleteGroupMemberFromGroup(_ context.Context, arg database.DeleteGroupMemberFromGroupParams) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, member := range q.groupMembers {
+		if member.UserID == arg.UserID && member.GroupID == arg.GroupID {
+			q.groupMembers = append(q.groupMembers[:i], q.groupMembers[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
 func (q *fakeQuerier) UpdateGroupByID(_ context.Context, arg database.UpdateGroupByIDParams) (database.Group, error) {
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
@@ -3152,6 +3850,21 @@ func (q *fakeQuerier) GetDERPMeshKey(_ context.Context) (string, error) {
 	return q.derpMeshKey, nil
 }
 
+func (q *fakeQuerier) UpsertLastAutostopNoticeCheck(_ context.Context, value string) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.lastAutostopNoticeCheck = value
+	return nil
+}
+
+func (q *fakeQuerier) GetLastAutostopNoticeCheck(_ context.Context) (string, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	return q.lastAutostopNoticeCheck, nil
+}
+
 func (q *fakeQuerier) InsertLicense(
 	_ context.Context, arg database.InsertLicenseParams,
 ) (database.License, error) {
@@ -3316,6 +4029,7 @@ func (q *fakeQuerier) InsertAllUsersGroup(ctx context.Context, orgID uuid.UUID)
 		ID:             orgID,
 		Name:           database.AllUsersGroup,
 		OrganizationID: orgID,
+		Source:         database.GroupSourceUser,
 	})
 }
 
@@ -3337,6 +4051,7 @@ func (q *fakeQuerier) InsertGroup(_ context.Context, arg database.InsertGroupPar
 		OrganizationID: arg.OrganizationID,
 		AvatarURL:      arg.AvatarURL,
 		QuotaAllowance: arg.QuotaAllowance,
+		Source:         arg.Source,
 	}
 
 	q.groups = append(q.groups, group)
@@ -3344,8 +4059,35 @@ func (q *fakeQuerier) InsertGroup(_ context.Context, arg database.InsertGroupPar
 	return group, nil
 }
 
-func (*fakeQuerier) GetUserGroups(_ context.Context, _ uuid.UUID) ([]database.Group, error) {
-	panic("not implemented")
+func (q *fakeQuerier) GetUserGroups(_ context.Context, userID uuid.UUID) ([]database.Group, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	groups := make([]database.Group, 0)
+	for _, member := range q.groupMembers {
+		if member.UserID != userID {
+			continue
+		}
+		for _, group := range q.groups {
+			if group.ID == member.GroupID {
+				groups = append(groups, group)
+			}
+		}
+	}
+	return groups, nil
+}
+
+func (q *fakeQuerier) GetGroupMemberIDs(_ context.Context, groupID uuid.UUID) ([]uuid.UUID, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	ids := make([]uuid.UUID, 0)
+	for _, member := range q.groupMembers {
+		if member.GroupID == groupID {
+			ids = append(ids, member.UserID)
+		}
+	}
+	return ids, nil
 }
 
 func (q *fakeQuerier) GetGroupMembers(_ context.Context, groupID uuid.UUID) ([]database.User, error) {
@@ -3538,15 +4280,18 @@ func (q *fakeQuerier) UpdateGitAuthLink(_ context.Context, arg database.UpdateGi
 	return nil
 }
 
-func (q *fakeQuerier) GetQuotaAllowanceForUser(_ context.Context, userID uuid.UUID) (int64, error) {
+func (q *fakeQuerier) GetQuotaAllowanceForUser(_ context.Context, arg database.GetQuotaAllowanceForUserParams) (int64, error) {
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
 	var sum int64
 	for _, member := range q.groupMembers {
-		if member.UserID != userID {
+		if member.UserID != arg.UserID {
 			continue
 		}
 		for _, group := range q.groups {
+			if arg.OrganizationID != uuid.Nil && group.OrganizationID != arg.OrganizationID {
+				continue
+			}
 			if group.ID == member.GroupID {
 				sum += int64(group.QuotaAllowance)
 			}
@@ -3555,12 +4300,15 @@ func (q *fakeQuerier) GetQuotaAllowanceForUser(_ context.Context, userID uuid.UU
 	return sum, nil
 }
 
-func (q *fakeQuerier) GetQuotaConsumedForUser(_ context.Context, userID uuid.UUID) (int64, error) {
+func (q *fakeQuerier) GetQuotaConsumedForUser(_ context.Context, arg database.GetQuotaConsumedForUserParams) (int64, error) {
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
 	var sum int64
 	for _, workspace := range q.workspaces {
-		if workspace.OwnerID != userID {
+		if workspace.OwnerID != arg.OwnerID {
+			continue
+		}
+		if arg.OrganizationID != uuid.Nil && workspace.OrganizationID != arg.OrganizationID {
 			continue
 		}
 		if workspace.Deleted {
@@ -3580,3 +4328,751 @@ func (q *fakeQuerier) GetQuotaConsumedForUser(_ context.Context, userID uuid.UUI
 	}
 	return sum, nil
 }
+
+func (q *fakeQuerier) GetWebhookByID(_ context.Context, id uuid.UUID) (database.Webhook, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	for _, webhook := range q.webhooks {
+		if webhook.ID == id {
+			return webhook, nil
+		}
+	}
+	return database.Webhook{}, sql.ErrNoRows
+}
+
+func (q *fakeQuerier) GetWebhooksByOrganizationID(_ context.Context, organizationID uuid.UUID) ([]database.Webhook, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	webhooks := make([]database.Webhook, 0)
+	for _, webhook := range q.webhooks {
+		if webhook.OrganizationID == organizationID {
+			webhooks = append(webhooks, webhook)
+		}
+	}
+	slices.SortFunc(webhooks, func(a, b database.Webhook) bool {
+		return a.Name < b.Name
+	})
+	return webhooks, nil
+}
+
+func (q *fakeQuerier) InsertWebhook(_ context.Context, arg database.InsertWebhookParams) (database.Webhook, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for _, webhook := range q.webhooks {
+		if webhook.OrganizationID == arg.OrganizationID && webhook.Name == arg.Name {
+			return database.Webhook{}, errDuplicateKey
+		}
+	}
+
+	webhook := database.Webhook{
+		ID:             arg.ID,
+		OrganizationID: arg.OrganizationID,
+		Name:           arg.Name,
+		Url:            arg.Url,
+		Secret:         arg.Secret,
+		Events:         arg.Events,
+		CreatedAt:      arg.CreatedAt,
+		UpdatedAt:      arg.UpdatedAt,
+	}
+	q.webhooks = append(q.webhooks, webhook)
+	return webhook, nil
+}
+
+func (q *fakeQuerier) UpdateWebhookByID(_ context.Context, arg database.UpdateWebhookByIDParams) (database.Webhook, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for index, webhook := range q.webhooks {
+		if webhook.ID != arg.ID {
+			continue
+		}
+		for _, other := range q.webhooks {
+			if other.ID != webhook.ID && other.OrganizationID == webhook.OrganizationID && other.Name == arg.Name {
+				return database.Webhook{}, errDuplicateKey
+			}
+		}
+		webhook.Name = arg.Name
+		webhook.Url = arg.Url
+		webhook.Events = arg.Events
+		webhook.UpdatedAt = arg.UpdatedAt
+		q.webhooks[index] = webhook
+		return webhook, nil
+	}
+	return database.Webhook{}, sql.ErrNoRows
+}
+
+func (q *fakeQuerier) DeleteWebhookByID(_ context.Context, id uuid.UUID) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for index, webhook := range q.webhooks {
+		if webhook.ID != id {
+			continue
+		}
+		q.webhooks = append(q.webhooks[:index], q.webhooks[index+1:]...)
+		deliveries := make([]database.WebhookDelivery, 0, len(q.webhookDeliveries))
+		for _, delivery := range q.webhookDeliveries {
+			if delivery.WebhookID != id {
+				deliveries = append(deliveries, delivery)
+			}
+		}
+		q.webhookDeliveries = deliveries
+		return nil
+	}
+	return sql.ErrNoRows
+}
+
+func (q *fakeQuerier) InsertWebhookDelivery(_ context.Context, arg database.InsertWebhookDeliveryParams) (database.WebhookDelivery, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for _, delivery := range q.webhookDeliveries {
+		if delivery.WebhookID == arg.WebhookID && delivery.EventID == arg.EventID {
+			// Emulates ON CONFLICT DO NOTHING.
+			return database.WebhookDelivery{}, sql.ErrNoRows
+		}
+	}
+
+	delivery := database.WebhookDelivery{
+		ID:        arg.ID,
+		WebhookID: arg.WebhookID,
+		EventID:   arg.EventID,
+		EventType: arg.EventType,
+		Payload:   arg.Payload,
+		Status:    database.WebhookDeliveryStatusPending,
+		CreatedAt: arg.CreatedAt,
+		UpdatedAt: arg.UpdatedAt,
+	}
+	q.webhookDeliveries = append(q.webhookDeliveries, delivery)
+	return delivery, nil
+}
+
+func (q *fakeQuerier) UpdateWebhookDeliveryByID(_ context.Context, arg database.UpdateWebhookDeliveryByIDParams) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for index, delivery := range q.webhookDeliveries {
+		if delivery.ID != arg.ID {
+			continue
+		}
+		delivery.Status = arg.Status
+		delivery.Attempts = arg.Attempts
+		delivery.LastStatusCode = arg.LastStatusCode
+		delivery.LastError = arg.LastError
+		delivery.UpdatedAt = arg.UpdatedAt
+		q.webhookDeliveries[index] = delivery
+		return nil
+	}
+	return sql.ErrNoRows
+}
+
+func (q *fakeQuerier) AcquireStaleWebhookDeliveries(_ context.Context, arg database.AcquireStaleWebhookDeliveriesParams) ([]database.WebhookDelivery, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	deliveries := make([]database.WebhookDelivery, 0)
+	for index, delivery := range q.webhookDeliveries {
+		if delivery.Status != database.WebhookDeliveryStatusPending || !delivery.UpdatedAt.Before(arg.UpdatedBefore) {
+			continue
+		}
+		delivery.UpdatedAt = arg.Now
+		q.webhookDeliveries[index] = delivery
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, nil
+}
+
+func (q *fakeQuerier) GetWebhookDeliveriesByWebhookID(_ context.Context, arg database.GetWebhookDeliveriesByWebhookIDParams) ([]database.WebhookDelivery, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	deliveries := make([]database.WebhookDelivery, 0)
+	for _, delivery := range q.webhookDeliveries {
+		if delivery.WebhookID != arg.WebhookID {
+			continue
+		}
+		if arg.Status != "" && string(delivery.Status) != arg.Status {
+			continue
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	slices.SortFunc(deliveries, func(a, b database.WebhookDelivery) bool {
+		return a.CreatedAt.After(b.CreatedAt)
+	})
+	if arg.LimitOpt > 0 && len(deliveries) > int(arg.LimitOpt) {
+		deliveries = deliveries[:arg.LimitOpt]
+	}
+	return deliveries, nil
+}
+
+func (q *fakeQuerier) InsertWorkspaceSessionRecording(_ context.Context, arg database.InsertWorkspaceSessionRecordingParams) (database.WorkspaceSessionRecording, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	//nolint:gosimple
+	recording := database.WorkspaceSessionRecording{
+		ID:             arg.ID,
+		CreatedAt:      arg.CreatedAt,
+		OrganizationID: arg.OrganizationID,
+		WorkspaceID:    arg.WorkspaceID,
+		AgentID:        arg.AgentID,
+		OwnerID:        arg.OwnerID,
+		Type:           arg.Type,
+		Command:        arg.Command,
+		StartedAt:      arg.StartedAt,
+		EndedAt:        arg.EndedAt,
+		FileID:         arg.FileID,
+	}
+	q.workspaceSessionRecordings = append(q.workspaceSessionRecordings, recording)
+	return recording, nil
+}
+
+func (q *fakeQuerier) GetWorkspaceSessionRecordingByID(_ context.Context, id uuid.UUID) (database.WorkspaceSessionRecording, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	for _, recording := range q.workspaceSessionRecordings {
+		if recording.ID == id {
+			return recording, nil
+		}
+	}
+	return database.WorkspaceSessionRecording{}, sql.ErrNoRows
+}
+
+func (q *fakeQuerier) GetWorkspaceSessionRecordings(_ context.Context, arg database.GetWorkspaceSessionRecordingsParams) ([]database.WorkspaceSessionRecording, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	recordings := make([]database.WorkspaceSessionRecording, 0)
+	for _, recording := range q.workspaceSessionRecordings {
+		if arg.WorkspaceID != uuid.Nil && recording.WorkspaceID != arg.WorkspaceID {
+			continue
+		}
+		if arg.OwnerID != uuid.Nil && recording.OwnerID != arg.OwnerID {
+			continue
+		}
+		recordings = append(recordings, recording)
+	}
+	slices.SortFunc(recordings, func(a, b database.WorkspaceSessionRecording) bool {
+		if a.StartedAt.Equal(b.StartedAt) {
+			return a.ID.String() > b.ID.String()
+		}
+		return a.StartedAt.After(b.StartedAt)
+	})
+	if arg.OffsetOpt > 0 {
+		if int(arg.OffsetOpt) > len(recordings) {
+			return []database.WorkspaceSessionRecording{}, nil
+		}
+		recordings = recordings[arg.OffsetOpt:]
+	}
+	if arg.LimitOpt > 0 && len(recordings) > int(arg.LimitOpt) {
+		recordings = recordings[:arg.LimitOpt]
+	}
+	return recordings, nil
+}
+
+func (q *fakeQuerier) GetWorkspaceAgentPortShare(_ context.Context, arg database.GetWorkspaceAgentPortShareParams) (database.WorkspaceAgentPortShare, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	for _, share := range q.workspaceAgentPortShares {
+		if share.WorkspaceID == arg.WorkspaceID && share.AgentName == arg.AgentName && share.Port == arg.Port && share.Protocol == arg.Protocol {
+			return share, nil
+		}
+	}
+	return database.WorkspaceAgentPortShare{}, sql.ErrNoRows
+}
+
+func (q *fakeQuerier) GetWorkspaceAgentPortSharesByWorkspaceID(_ context.Context, workspaceID uuid.UUID) ([]database.WorkspaceAgentPortShare, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	shares := make([]database.WorkspaceAgentPortShare, 0)
+	for _, share := range q.workspaceAgentPortShares {
+		if share.WorkspaceID == workspaceID {
+			shares = append(shares, share)
+		}
+	}
+	slices.SortFunc(shares, func(a, b database.WorkspaceAgentPortShare) bool {
+		if a.AgentName != b.AgentName {
+			return a.AgentName < b.AgentName
+		}
+		if a.Port != b.Port {
+			return a.Port < b.Port
+		}
+		return a.Protocol < b.Protocol
+	})
+	return shares, nil
+}
+
+func (q *fakeQuerier) UpsertWorkspaceAgentPortShare(_ context.Context, arg database.UpsertWorkspaceAgentPortShareParams) (database.WorkspaceAgentPortShare, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, share := range q.workspaceAgentPortShares {
+		if share.WorkspaceID == arg.WorkspaceID && share.AgentName == arg.AgentName && share.Port == arg.Port && share.Protocol == arg.Protocol {
+			share.SharingLevel = arg.SharingLevel
+			share.UpdatedAt = arg.CreatedAt
+			q.workspaceAgentPortShares[i] = share
+			return share, nil
+		}
+	}
+	share := database.WorkspaceAgentPortShare{
+		WorkspaceID:  arg.WorkspaceID,
+		AgentName:    arg.AgentName,
+		Port:         arg.Port,
+		Protocol:     arg.Protocol,
+		SharingLevel: arg.SharingLevel,
+		CreatedAt:    arg.CreatedAt,
+		UpdatedAt:    arg.CreatedAt,
+	}
+	q.workspaceAgentPortShares = append(q.workspaceAgentPortShares, share)
+	return share, nil
+}
+
+func (q *fakeQuerier) DeleteWorkspaceAgentPortShare(_ context.Context, arg database.DeleteWorkspaceAgentPortShareParams) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, share := range q.workspaceAgentPortShares {
+		if share.WorkspaceID == arg.WorkspaceID && share.AgentName == arg.AgentName && share.Port == arg.Port && share.Protocol == arg.Protocol {
+			q.workspaceAgentPortShares = append(q.workspaceAgentPortShares[:i], q.workspaceAgentPortShares[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (q *fakeQuerier) RollupTemplateUsageStats(_ context.Context, arg database.RollupTemplateUsageStatsParams) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	type usageKey struct {
+		startTime  time.Time
+		templateID uuid.UUID
+		userID     uuid.UUID
+	}
+	usage := make(map[usageKey]*database.TemplateUsageStat)
+	get := func(key usageKey) *database.TemplateUsageStat {
+		stat, ok := usage[key]
+		if !ok {
+			stat = &database.TemplateUsageStat{
+				StartTime:  key.startTime,
+				TemplateID: key.templateID,
+				UserID:     key.userID,
+			}
+			usage[key] = stat
+		}
+		return stat
+	}
+	counterDelta := func(previous, current int64) int64 {
+		if current < previous {
+			return current
+		}
+		return current - previous
+	}
+
+	agentStats := make([]database.AgentStat, 0)
+	for _, agentStat := range q.agentStats {
+		if agentStat.CreatedAt.Before(arg.EndTime) {
+			agentStats = append(agentStats, agentStat)
+		}
+	}
+	sort.Slice(agentStats, func(i, j int) bool {
+		return agentStats[i].CreatedAt.Before(agentStats[j].CreatedAt)
+	})
+	// The connection counters in agent stats payloads.
+	type counters struct {
+		NumConns                       int64 `json:"num_comms"`
+		ConnectionCountSSH             int64 `json:"connection_count_ssh"`
+		ConnectionCountReconnectingPTY int64 `json:"connection_count_reconnecting_pty"`
+		ConnectionCountVSCode          int64 `json:"connection_count_vscode"`
+		ConnectionCountJetBrains       int64 `json:"connection_count_jetbrains"`
+	}
+	previous := make(map[uuid.UUID]counters)
+	for _, agentStat := range agentStats {
+		var current counters
+		err := json.Unmarshal(agentStat.Payload, &current)
+		if err != nil {
+			return err
+		}
+		last := previous[agentStat.AgentID]
+		previous[agentStat.AgentID] = current
+		if agentStat.CreatedAt.Before(arg.StartTime) {
+			continue
+		}
+		stat := get(usageKey{
+			startTime:  agentStat.CreatedAt.Truncate(time.Hour),
+			templateID: agentStat.TemplateID,
+			userID:     agentStat.UserID,
+		})
+		stat.Active = stat.Active || agentStat.ConnectionActivity
+		stat.ConnectionCount += counterDelta(last.NumConns, current.NumConns)
+		stat.SshConnectionCount += counterDelta(last.ConnectionCountSSH, current.ConnectionCountSSH)
+		stat.ReconnectingPtyConnectionCount += counterDelta(last.ConnectionCountReconnectingPTY, current.ConnectionCountReconnectingPTY)
+		stat.VscodeConnectionCount += counterDelta(last.ConnectionCountVSCode, current.ConnectionCountVSCode)
+		stat.JetbrainsConnectionCount += counterDelta(last.ConnectionCountJetBrains, current.ConnectionCountJetBrains)
+	}
+
+	builds := make([]database.WorkspaceBuild, 0)
+	for _, build := range q.workspaceBuilds {
+		if build.CreatedAt.Before(arg.EndTime) {
+			builds = append(builds, build)
+		}
+	}
+	sort.Slice(builds, func(i, j int) bool {
+		return builds[i].CreatedAt.Before(builds[j].CreatedAt)
+	})
+	for i, build := range builds {
+		if build.DailyCost == 0 {
+			continue
+		}
+		// A build's cost applies until the next build of the workspace.
+		costStart := build.CreatedAt
+		costEnd := arg.Now
+		for _, next := range builds[i+1:] {
+			if next.WorkspaceID == build.WorkspaceID {
+				costEnd = next.CreatedAt
+				break
+			}
+		}
+		if costStart.Before(arg.StartTime) {
+			costStart = arg.StartTime
+		}
+		if costEnd.After(arg.EndTime) {
+			costEnd = arg.EndTime
+		}
+		var workspace database.Workspace
+		for _, w := range q.workspaces {
+			if w.ID == build.WorkspaceID {
+				workspace = w
+				break
+			}
+		}
+		for hour := costStart.Truncate(time.Hour); hour.Before(costEnd); hour = hour.Add(time.Hour) {
+			overlap := hour.Add(time.Hour).Sub(hour)
+			if costEnd.Before(hour.Add(time.Hour)) {
+				overlap -= hour.Add(time.Hour).Sub(costEnd)
+			}
+			if costStart.After(hour) {
+				overlap -= costStart.Sub(hour)
+			}
+			if overlap <= 0 {
+				continue
+			}
+			stat := get(usageKey{
+				startTime:  hour,
+				templateID: workspace.TemplateID,
+				userID:     workspace.OwnerID,
+			})
+			stat.Cost += float64(build.DailyCost) * overlap.Hours() / 24
+		}
+	}
+
+	for _, stat := range usage {
+		replaced := false
+		for i, existing := range q.templateUsageStats {
+			if existing.StartTime.Equal(stat.StartTime) && existing.TemplateID == stat.TemplateID && existing.UserID == stat.UserID {
+				q.templateUsageStats[i] = *stat
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			q.templateUsageStats = append(q.templateUsageStats, *stat)
+		}
+	}
+	return nil
+}
+
+func (q *fakeQuerier) GetLatestTemplateUsageStatsStartTime(_ context.Context) (time.Time, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	latest := time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, stat := range q.templateUsageStats {
+		if stat.StartTime.After(latest) {
+			latest = stat.StartTime
+		}
+	}
+	return latest, nil
+}
+
+func (q *fakeQuerier) UpsertTemplateUsageStats(_ context.Context, arg database.UpsertTemplateUsageStatsParams) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	stat := database.TemplateUsageStat(arg)
+	for i, existing := range q.templateUsageStats {
+		if existing.StartTime.Equal(arg.StartTime) && existing.TemplateID == arg.TemplateID && existing.UserID == arg.UserID {
+			q.templateUsageStats[i] = stat
+			return nil
+		}
+	}
+	q.templateUsageStats = append(q.templateUsageStats, stat)
+	return nil
+}
+
+func (q *fakeQuerier) InsertTemplateAppUsageStats(_ context.Context, arg database.InsertTemplateAppUsageStatsParams) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, existing := range q.templateAppUsageStats {
+		if existing.StartTime.Equal(arg.StartTime) && existing.TemplateID == arg.TemplateID && existing.UserID == arg.UserID && existing.Slug == arg.Slug {
+			q.templateAppUsageStats[i].Requests += arg.Requests
+			return nil
+		}
+	}
+	q.templateAppUsageStats = append(q.templateAppUsageStats, database.TemplateAppUsageStat(arg))
+	return nil
+}
+
+func (q *fakeQuerier) GetTemplateInsights(_ context.Context, arg database.GetTemplateInsightsParams) ([]database.GetTemplateInsightsRow, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	rows := make(map[uuid.UUID]*database.GetTemplateInsightsRow)
+	activeUsers := make(map[uuid.UUID]map[uuid.UUID]struct{})
+	for _, stat := range q.templateUsageStats {
+		if stat.StartTime.Before(arg.StartTime) || !stat.StartTime.Before(arg.EndTime) || !slices.Contains(arg.TemplateIds, stat.TemplateID) {
+			continue
+		}
+		row, ok := rows[stat.TemplateID]
+		if !ok {
+			row = &database.GetTemplateInsightsRow{TemplateID: stat.TemplateID}
+			rows[stat.TemplateID] = row
+			activeUsers[stat.TemplateID] = make(map[uuid.UUID]struct{})
+		}
+		if stat.Active {
+			row.ActiveHours++
+			activeUsers[stat.TemplateID][stat.UserID] = struct{}{}
+		}
+		row.ConnectionCount += stat.ConnectionCount
+		row.SshConnectionCount += stat.SshConnectionCount
+		row.ReconnectingPtyConnectionCount += stat.ReconnectingPtyConnectionCount
+		row.VscodeConnectionCount += stat.VscodeConnectionCount
+		row.JetbrainsConnectionCount += stat.JetbrainsConnectionCount
+		row.Cost += stat.Cost
+	}
+
+	insights := make([]database.GetTemplateInsightsRow, 0, len(rows))
+	for templateID, row := range rows {
+		row.ActiveUsers = int64(len(activeUsers[templateID]))
+		insights = append(insights, *row)
+	}
+	sort.Slice(insights, func(i, j int) bool {
+		return insights[i].TemplateID.String() < insights[j].TemplateID.String()
+	})
+	return insights, nil
+}
+
+func (q *fakeQuerier) GetUserInsights(_ context.Context, arg database.GetUserInsightsParams) ([]database.GetUserInsightsRow, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	type key struct {
+		templateID uuid.UUID
+		userID     uuid.UUID
+	}
+	rows := make(map[key]*database.GetUserInsightsRow)
+	for _, stat := range q.templateUsageStats {
+		if stat.StartTime.Before(arg.StartTime) || !stat.StartTime.Before(arg.EndTime) || !slices.Contains(arg.TemplateIds, stat.TemplateID) {
+			continue
+		}
+		k := key{templateID: stat.TemplateID, userID: stat.UserID}
+		row, ok := rows[k]
+		if !ok {
+			row = &database.GetUserInsightsRow{TemplateID: stat.TemplateID, UserID: stat.UserID}
+			rows[k] = row
+		}
+		if stat.Active {
+			row.ActiveHours++
+		}
+		row.ConnectionCount += stat.ConnectionCount
+		row.SshConnectionCount += stat.SshConnectionCount
+		row.ReconnectingPtyConnectionCount += stat.ReconnectingPtyConnectionCount
+		row.VscodeConnectionCount += stat.VscodeConnectionCount
+		row.JetbrainsConnectionCount += stat.JetbrainsConnectionCount
+		row.Cost += stat.Cost
+	}
+
+	insights := make([]database.GetUserInsightsRow, 0, len(rows))
+	for _, row := range rows {
+		insights = append(insights, *row)
+	}
+	sort.Slice(insights, func(i, j int) bool {
+		if insights[i].TemplateID != insights[j].TemplateID {
+			return insights[i].TemplateID.String() < insights[j].TemplateID.String()
+		}
+		return insights[i].UserID.String() < insights[j].UserID.String()
+	})
+	return insights, nil
+}
+
+func (q *fakeQuerier) GetAppInsights(_ context.Context, arg database.GetAppInsightsParams) ([]database.GetAppInsightsRow, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	type key struct {
+		templateID uuid.UUID
+		slug       string
+	}
+	rows := make(map[key]*database.GetAppInsightsRow)
+	users := make(map[key]map[uuid.UUID]struct{})
+	for _, stat := range q.templateAppUsageStats {
+		if stat.StartTime.Before(arg.StartTime) || !stat.StartTime.Before(arg.EndTime) || !slices.Contains(arg.TemplateIds, stat.TemplateID) {
+			continue
+		}
+		k := key{templateID: stat.TemplateID, slug: stat.Slug}
+		row, ok := rows[k]
+		if !ok {
+			row = &database.GetAppInsightsRow{TemplateID: stat.TemplateID, Slug: stat.Slug}
+			rows[k] = row
+			users[k] = make(map[uuid.UUID]struct{})
+		}
+		users[k][stat.UserID] = struct{}{}
+		row.UsageHours++
+		row.Requests += stat.Requests
+	}
+
+	insights := make([]database.GetAppInsightsRow, 0, len(rows))
+	for k, row := range rows {
+		row.Users = int64(len(users[k]))
+		insights = append(insights, *row)
+	}
+	sort.Slice(insights, func(i, j int) bool {
+		if insights[i].TemplateID != insights[j].TemplateID {
+			return insights[i].TemplateID.String() < insights[j].TemplateID.String()
+		}
+		return insights[i].Slug < insights[j].Slug
+	})
+	return insights, nil
+}
+
+func (q *fakeQuerier) GetCustomRoles(_ context.Context) ([]database.CustomRole, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	roles := make([]database.CustomRole, len(q.customRoles))
+	copy(roles, q.customRoles)
+	slices.SortFunc(roles, func(a, b database.CustomRole) bool {
+		if a.OrganizationID.Valid != b.OrganizationID.Valid {
+			return !a.OrganizationID.Valid
+		}
+		if a.OrganizationID.UUID != b.OrganizationID.UUID {
+			return a.OrganizationID.UUID.String() < b.OrganizationID.UUID.String()
+		}
+		return a.Name < b.Name
+	})
+	return roles, nil
+}
+
+func (q *fakeQuerier) GetCustomRoleByName(_ context.Context, arg database.GetCustomRoleByNameParams) (database.CustomRole, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	for _, role := range q.customRoles {
+		if role.Name == arg.Name && role.OrganizationID == arg.OrganizationID {
+			return role, nil
+		}
+	}
+	return database.CustomRole{}, sql.ErrNoRows
+}
+
+func (q *fakeQuerier) InsertCustomRole(_ context.Context, arg database.InsertCustomRoleParams) (database.CustomRole, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for _, role := range q.customRoles {
+		if role.Name == arg.Name && role.OrganizationID == arg.OrganizationID {
+			return database.CustomRole{}, errDuplicateKey
+		}
+	}
+
+	//nolint:gosimple
+	role := database.CustomRole{
+		ID:              arg.ID,
+		Name:            arg.Name,
+		DisplayName:     arg.DisplayName,
+		OrganizationID:  arg.OrganizationID,
+		SitePermissions: arg.SitePermissions,
+		OrgPermissions:  arg.OrgPermissions,
+		UserPermissions: arg.UserPermissions,
+		CreatedAt:       arg.CreatedAt,
+		UpdatedAt:       arg.UpdatedAt,
+	}
+	q.customRoles = append(q.customRoles, role)
+	return role, nil
+}
+
+func (q *fakeQuerier) UpdateCustomRole(_ context.Context, arg database.UpdateCustomRoleParams) (database.CustomRole, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, role := range q.customRoles {
+		if role.ID != arg.ID {
+			continue
+		}
+		role.DisplayName = arg.DisplayName
+		role.SitePermissions = arg.SitePermissions
+		role.OrgPermissions = arg.OrgPermissions
+		role.UserPermissions = arg.UserPermissions
+		role.UpdatedAt = arg.UpdatedAt
+		q.customRoles[i] = role
+		return role, nil
+	}
+	return database.CustomRole{}, sql.ErrNoRows
+}
+
+func (q *fakeQuerier) DeleteCustomRole(_ context.Context, id uuid.UUID) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, role := range q.customRoles {
+		if role.ID == id {
+			q.customRoles = append(q.customRoles[:i], q.customRoles[i+1:]...)
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (q *fakeQuerier) UnassignSiteRole(_ context.Context, roleName string) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, user := range q.users {
+		roles := make([]string, 0, len(user.RBACRoles))
+		for _, role := range user.RBACRoles {
+			if role != roleName {
+				roles = append(roles, role)
+			}
+		}
+		user.RBACRoles = roles
+		q.users[i] = user
+	}
+	return nil
+}
+
+func (q *fakeQuerier) UnassignOrganizationRole(_ context.Context, arg database.UnassignOrganizationRoleParams) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, member := range q.organizationMembers {
+		if member.OrganizationID != arg.OrganizationID {
+			continue
+		}
+		roles := make([]string, 0, len(member.Roles))
+		for _, role := range member.Roles {
+			if role != arg.RoleName {
+				roles = append(roles, role)
+			}
+		}
+		member.Roles = roles
+		q.organizationMembers[i] = member
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+// Package db2sdk converts database types to their codersdk counterparts. It
+// is shared by packages that can't import coderd.
+package db2sdk
+
+import (
+	"encoding/json"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/codersdk"
+)
+
+// ProvisionerJobStatus returns the status of a provisioner job. Running jobs
+// that haven't been updated recently are failed.
+func ProvisionerJobStatus(provisionerJob database.ProvisionerJob) codersdk.ProvisionerJobStatus {
+	switch {
+	case provisionerJob.CanceledAt.Valid:
+		if !provisionerJob.CompletedAt.Valid {
+			return codersdk.ProvisionerJobCanceling
+		}
+		if provisionerJob.Error.String == "" {
+			return codersdk.ProvisionerJobCanceled
+		}
+		return codersdk.ProvisionerJobFailed
+	case !provisionerJob.StartedAt.Valid:
+		return codersdk.ProvisionerJobPending
+	case provisionerJob.CompletedAt.Valid:
+		if provisionerJob.Error.String == "" {
+			return codersdk.ProvisionerJobSucceeded
+		}
+		return codersdk.ProvisionerJobFailed
+	case database.Now().Sub(provisionerJob.UpdatedAt) > 30*time.Second:
+		provisionerJob.Error.String = "Worker failed to update job in time."
+		return codersdk.ProvisionerJobFailed
+	default:
+		return codersdk.ProvisionerJobRunning
+	}
+}
+
+// TemplateVersionParameters converts the rich parameters of a template
+// version.
+func TemplateVersionParameters(dbParams []database.TemplateVersionParameter) ([]codersdk.TemplateVersionParameter, error) {
+	params := make([]codersdk.TemplateVersionParameter, 0, len(dbParams))
+	for _, dbParam := range dbParams {
+		var options []codersdk.TemplateVersionParameterOption
+		err := json.Unmarshal(dbParam.Options, &options)
+		if err != nil {
+			return nil, xerrors.Errorf("unmarshal options of parameter %q: %w", dbParam.Name, err)
+		}
+		if options == nil {
+			options = []codersdk.TemplateVersionParameterOption{}
+		}
+		param := codersdk.TemplateVersionParameter{
+			Name:            dbParam.Name,
+			Description:     dbParam.Description,
+			Type:            dbParam.Type,
+			Mutable:         dbParam.Mutable,
+			DefaultValue:    dbParam.DefaultValue,
+			Icon:            dbParam.Icon,
+			Options:         options,
+			ValidationRegex: dbParam.ValidationRegex,
+			ValidationError: dbParam.ValidationError,
+		}
+		if dbParam.ValidationMin.Valid {
+			param.ValidationMin = &dbParam.ValidationMin.Int32
+		}
+		if dbParam.ValidationMax.Valid {
+			param.ValidationMax = &dbParam.ValidationMax.Int32
+		}
+		params = append(params, param)
+	}
+	return params, nil
+}
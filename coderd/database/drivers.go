@@ -43,3 +43,24 @@ func (t *TemplateACL) Scan(src interface{}) error {
 func (t TemplateACL) Value() (driver.Value, error) {
 	return json.Marshal(t)
 }
+
+// CustomRolePermissions is a list of permissions granted by a custom role.
+type CustomRolePermissions []rbac.Permission
+
+func (p *CustomRolePermissions) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case string:
+		return json.Unmarshal([]byte(v), &p)
+	case []byte:
+		return json.Unmarshal(v, &p)
+	}
+	return xerrors.Errorf("unexpected type %T", src)
+}
+
+func (p CustomRolePermissions) Value() (driver.Value, error) {
+	if p == nil {
+		// Store an empty list rather than a JSON null.
+		return []byte("[]"), nil
+	}
+	return json.Marshal(p)
+}
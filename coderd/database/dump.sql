@@ -2,7 +2,10 @@
 
 CREATE TYPE api_key_scope AS ENUM (
     'all',
-    'application_connect'
+    'application_connect',
+    'read',
+    'workspace_start_stop',
+    'template_push'
 );
 
 CREATE TYPE app_sharing_level AS ENUM (
@@ -16,13 +19,27 @@ CREATE TYPE audit_action AS ENUM (
     'write',
     'delete',
     'start',
-    'stop'
+    'stop',
+    'use'
 );
 
 CREATE TYPE build_reason AS ENUM (
     'initiator',
     'autostart',
-    'autostop'
+    'autostop',
+    'autodelete'
+);
+
+CREATE TYPE file_storage AS ENUM (
+    'database',
+    'local',
+    's3'
+);
+
+CREATE TYPE group_source AS ENUM (
+    'user',
+    'oidc',
+    'scim'
 );
 
 CREATE TYPE log_level AS ENUM (
@@ -67,6 +84,11 @@ CREATE TYPE parameter_type_system AS ENUM (
     'hcl'
 );
 
+CREATE TYPE port_share_protocol AS ENUM (
+    'tcp',
+    'udp'
+);
+
 CREATE TYPE provisioner_job_type AS ENUM (
     'template_version_import',
     'workspace_build',
@@ -91,7 +113,16 @@ CREATE TYPE resource_type AS ENUM (
     'git_ssh_key',
     'api_key',
     'group',
-    'workspace_build'
+    'workspace_build',
+    'workspace_session_recording',
+    'custom_role',
+    'organization_member',
+    'webhook'
+);
+
+CREATE TYPE session_recording_type AS ENUM (
+    'ssh',
+    'reconnecting_pty'
 );
 
 CREATE TYPE user_status AS ENUM (
@@ -99,6 +130,24 @@ CREATE TYPE user_status AS ENUM (
     'suspended'
 );
 
+CREATE TYPE webhook_delivery_status AS ENUM (
+    'pending',
+    'delivered',
+    'failed'
+);
+
+CREATE TYPE workspace_agent_lifecycle_state AS ENUM (
+    'created',
+    'starting',
+    'start_timeout',
+    'start_error',
+    'ready',
+    'shutting_down',
+    'shutdown_timeout',
+    'shutdown_error',
+    'off'
+);
+
 CREATE TYPE workspace_app_health AS ENUM (
     'disabled',
     'initializing',
@@ -119,9 +168,12 @@ CREATE TABLE agent_stats (
     agent_id uuid NOT NULL,
     workspace_id uuid NOT NULL,
     template_id uuid NOT NULL,
-    payload jsonb NOT NULL
+    payload jsonb NOT NULL,
+    connection_activity boolean DEFAULT true NOT NULL
 );
 
+COMMENT ON COLUMN agent_stats.connection_activity IS 'Whether the agent''s connection stats changed since its previous report. Reports where only resource usage changed don''t count as workspace activity.';
+
 CREATE TABLE api_keys (
     id text NOT NULL,
     hashed_secret bytea NOT NULL,
@@ -133,11 +185,17 @@ CREATE TABLE api_keys (
     login_type login_type NOT NULL,
     lifetime_seconds bigint DEFAULT 86400 NOT NULL,
     ip_address inet DEFAULT '0.0.0.0'::inet NOT NULL,
-    scope api_key_scope DEFAULT 'all'::api_key_scope NOT NULL
+    scope api_key_scope DEFAULT 'all'::api_key_scope NOT NULL,
+    token_name text DEFAULT ''::text NOT NULL,
+    allow_list text[] DEFAULT '{}'::text[] NOT NULL
 );
 
 COMMENT ON COLUMN api_keys.hashed_secret IS 'hashed_secret contains a SHA256 hash of the key secret. This is considered a secret and MUST NOT be returned from the API as it is used for API key encryption in app proxying code.';
 
+COMMENT ON COLUMN api_keys.token_name IS 'The name of a token, unique per user. Empty for other API keys.';
+
+COMMENT ON COLUMN api_keys.allow_list IS 'Resources the scope of the key is limited to, formatted as "type:id". The id is "*" for every resource of the type.';
+
 CREATE TABLE audit_logs (
     id uuid NOT NULL,
     "time" timestamp with time zone NOT NULL,
@@ -156,15 +214,42 @@ CREATE TABLE audit_logs (
     resource_icon text NOT NULL
 );
 
+CREATE TABLE custom_roles (
+    id uuid NOT NULL,
+    name text NOT NULL,
+    display_name text NOT NULL,
+    organization_id uuid,
+    site_permissions jsonb DEFAULT '[]'::jsonb NOT NULL,
+    org_permissions jsonb DEFAULT '[]'::jsonb NOT NULL,
+    user_permissions jsonb DEFAULT '[]'::jsonb NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    updated_at timestamp with time zone NOT NULL
+);
+
+COMMENT ON TABLE custom_roles IS 'Roles defined by admins in addition to the builtin roles.';
+
+COMMENT ON COLUMN custom_roles.organization_id IS 'The organization an organization role is scoped to. Null for site wide roles.';
+
+COMMENT ON COLUMN custom_roles.org_permissions IS 'Permissions granted in the organization of the role. Always empty for site wide roles.';
+
+CREATE TABLE file_data (
+    hash character varying(64) NOT NULL,
+    data bytea NOT NULL
+);
+
+COMMENT ON TABLE file_data IS 'Contents of files stored in the database, keyed by hash.';
+
 CREATE TABLE files (
     hash character varying(64) NOT NULL,
     created_at timestamp with time zone NOT NULL,
     created_by uuid NOT NULL,
     mimetype character varying(64) NOT NULL,
-    data bytea NOT NULL,
-    id uuid DEFAULT gen_random_uuid() NOT NULL
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    storage file_storage DEFAULT 'database'::file_storage NOT NULL
 );
 
+COMMENT ON COLUMN files.storage IS 'The storage backend holding the contents of the file.';
+
 CREATE TABLE git_auth_links (
     provider_id text NOT NULL,
     user_id uuid NOT NULL,
@@ -193,9 +278,12 @@ CREATE TABLE groups (
     name text NOT NULL,
     organization_id uuid NOT NULL,
     avatar_url text DEFAULT ''::text NOT NULL,
-    quota_allowance integer DEFAULT 0 NOT NULL
+    quota_allowance integer DEFAULT 0 NOT NULL,
+    source group_source DEFAULT 'user'::group_source NOT NULL
 );
 
+COMMENT ON COLUMN groups.source IS 'Where the group was created. OIDC group sync only manages the membership of groups it created.';
+
 CREATE TABLE licenses (
     id integer NOT NULL,
     uploaded_at timestamp with time zone NOT NULL,
@@ -271,9 +359,12 @@ CREATE TABLE provisioner_daemons (
     name character varying(64) NOT NULL,
     provisioners provisioner_type[] NOT NULL,
     replica_id uuid,
-    tags jsonb DEFAULT '{}'::jsonb NOT NULL
+    tags jsonb DEFAULT '{}'::jsonb NOT NULL,
+    organization_id uuid
 );
 
+COMMENT ON COLUMN provisioner_daemons.organization_id IS 'The organization the daemon acquires jobs from. Null for daemons that acquire jobs from every organization.';
+
 CREATE TABLE provisioner_job_logs (
     job_id uuid NOT NULL,
     created_at timestamp with time zone NOT NULL,
@@ -331,6 +422,67 @@ CREATE TABLE site_configs (
     value character varying(8192) NOT NULL
 );
 
+CREATE TABLE template_app_usage_stats (
+    start_time timestamp with time zone NOT NULL,
+    template_id uuid NOT NULL,
+    user_id uuid NOT NULL,
+    slug text NOT NULL,
+    requests bigint NOT NULL
+);
+
+COMMENT ON TABLE template_app_usage_stats IS 'Hourly number of requests each user made to the apps of each template.';
+
+CREATE TABLE template_usage_stats (
+    start_time timestamp with time zone NOT NULL,
+    template_id uuid NOT NULL,
+    user_id uuid NOT NULL,
+    active boolean NOT NULL,
+    connection_count bigint NOT NULL,
+    ssh_connection_count bigint NOT NULL,
+    reconnecting_pty_connection_count bigint NOT NULL,
+    vscode_connection_count bigint NOT NULL,
+    jetbrains_connection_count bigint NOT NULL,
+    cost double precision NOT NULL
+);
+
+COMMENT ON TABLE template_usage_stats IS 'Hourly usage of the workspaces of each user and template, rolled up from agent_stats and workspace_builds so it outlives the raw stats.';
+
+COMMENT ON COLUMN template_usage_stats.active IS 'Whether any of the user''s workspaces had connection activity in the hour.';
+
+COMMENT ON COLUMN template_usage_stats.cost IS 'The share of the daily cost of the user''s workspaces for the hour.';
+
+CREATE TABLE template_version_parameters (
+    template_version_id uuid NOT NULL,
+    name text NOT NULL,
+    description text NOT NULL,
+    type text NOT NULL,
+    mutable boolean NOT NULL,
+    default_value text NOT NULL,
+    icon text NOT NULL,
+    options jsonb DEFAULT '[]'::jsonb NOT NULL,
+    validation_regex text NOT NULL,
+    validation_min integer,
+    validation_max integer,
+    validation_error text NOT NULL,
+    index integer NOT NULL
+);
+
+COMMENT ON TABLE template_version_parameters IS 'Rich parameters declared by a template version with "coder_parameter" data sources.';
+
+COMMENT ON COLUMN template_version_parameters.type IS 'Type of the parameter: string, number, bool or list(string).';
+
+COMMENT ON COLUMN template_version_parameters.mutable IS 'Whether the value can be changed after the workspace is created.';
+
+COMMENT ON COLUMN template_version_parameters.options IS 'Options the value must be one of, if any.';
+
+COMMENT ON COLUMN template_version_parameters.validation_regex IS 'Regular expression string values must match. Empty disables the check.';
+
+COMMENT ON COLUMN template_version_parameters.validation_min IS 'Minimum value of number parameters, if any.';
+
+COMMENT ON COLUMN template_version_parameters.validation_max IS 'Maximum value of number parameters, if any.';
+
+COMMENT ON COLUMN template_version_parameters.index IS 'Position of the parameter in the template, used to order prompts.';
+
 CREATE TABLE template_versions (
     id uuid NOT NULL,
     template_id uuid,
@@ -358,13 +510,49 @@ CREATE TABLE templates (
     icon character varying(256) DEFAULT ''::character varying NOT NULL,
     user_acl jsonb DEFAULT '{}'::jsonb NOT NULL,
     group_acl jsonb DEFAULT '{}'::jsonb NOT NULL,
-    display_name character varying(64) DEFAULT ''::character varying NOT NULL
+    display_name character varying(64) DEFAULT ''::character varying NOT NULL,
+    inactivity_ttl bigint DEFAULT 0 NOT NULL,
+    stopped_ttl bigint DEFAULT 0 NOT NULL,
+    max_ttl bigint DEFAULT 0 NOT NULL,
+    autostart_days_of_week smallint DEFAULT 127 NOT NULL,
+    autostart_start_hour smallint DEFAULT 0 NOT NULL,
+    autostart_end_hour smallint DEFAULT 24 NOT NULL,
+    quiet_hours_schedule text DEFAULT ''::text NOT NULL,
+    quiet_hours_duration bigint DEFAULT 0 NOT NULL,
+    session_recording boolean DEFAULT false NOT NULL,
+    auto_start_on_connect boolean DEFAULT false NOT NULL,
+    max_port_sharing_level app_sharing_level DEFAULT 'owner'::app_sharing_level NOT NULL,
+    autodelete_warning_ttl bigint DEFAULT '86400000000000'::bigint NOT NULL
 );
 
 COMMENT ON COLUMN templates.default_ttl IS 'The default duration for auto-stop for workspaces created from this template.';
 
 COMMENT ON COLUMN templates.display_name IS 'Display name is a custom, human-friendly template name that user can set.';
 
+COMMENT ON COLUMN templates.inactivity_ttl IS 'The duration a workspace may go unused before it is scheduled for deletion. Zero disables it.';
+
+COMMENT ON COLUMN templates.stopped_ttl IS 'The duration a workspace may remain stopped before it is scheduled for deletion. Zero disables it.';
+
+COMMENT ON COLUMN templates.max_ttl IS 'The maximum duration a workspace created from this template may run for before it is stopped. Zero means there is no maximum.';
+
+COMMENT ON COLUMN templates.autostart_days_of_week IS 'A bitmask of the days of the week autostart is allowed on, with Sunday as the least significant bit.';
+
+COMMENT ON COLUMN templates.autostart_start_hour IS 'The first hour of the day autostart is allowed in, in the timezone of the autostart schedule.';
+
+COMMENT ON COLUMN templates.autostart_end_hour IS 'The hour of the day autostart is no longer allowed from, in the timezone of the autostart schedule.';
+
+COMMENT ON COLUMN templates.quiet_hours_schedule IS 'A cron schedule for the start of quiet hours, during which workspaces are stopped and not autostarted. Empty disables quiet hours.';
+
+COMMENT ON COLUMN templates.quiet_hours_duration IS 'The duration of quiet hours.';
+
+COMMENT ON COLUMN templates.session_recording IS 'Whether terminal sessions in workspaces created from the template are recorded.';
+
+COMMENT ON COLUMN templates.auto_start_on_connect IS 'Whether stopped workspaces created from the template are started when connected to.';
+
+COMMENT ON COLUMN templates.max_port_sharing_level IS 'The highest sharing level ports in workspaces created from the template may be shared with. Shares above it are treated as this level.';
+
+COMMENT ON COLUMN templates.autodelete_warning_ttl IS 'How long a dormant workspace is marked for deletion before it is deleted.';
+
 CREATE TABLE user_links (
     user_id uuid NOT NULL,
     login_type login_type NOT NULL,
@@ -386,9 +574,79 @@ CREATE TABLE users (
     login_type login_type DEFAULT 'password'::login_type NOT NULL,
     avatar_url text,
     deleted boolean DEFAULT false NOT NULL,
-    last_seen_at timestamp without time zone DEFAULT '0001-01-01 00:00:00'::timestamp without time zone NOT NULL
+    last_seen_at timestamp without time zone DEFAULT '0001-01-01 00:00:00'::timestamp without time zone NOT NULL,
+    auto_start_on_connect boolean DEFAULT false NOT NULL
+);
+
+COMMENT ON COLUMN users.auto_start_on_connect IS 'Whether the user''s stopped workspaces are started when connected to.';
+
+CREATE TABLE webhook_deliveries (
+    id uuid NOT NULL,
+    webhook_id uuid NOT NULL,
+    event_id uuid NOT NULL,
+    event_type text NOT NULL,
+    payload jsonb NOT NULL,
+    status webhook_delivery_status DEFAULT 'pending'::webhook_delivery_status NOT NULL,
+    attempts integer DEFAULT 0 NOT NULL,
+    last_status_code integer DEFAULT 0 NOT NULL,
+    last_error text DEFAULT ''::text NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    updated_at timestamp with time zone NOT NULL
+);
+
+COMMENT ON TABLE webhook_deliveries IS 'Deliveries of events to webhooks. Failed deliveries are kept as a dead-letter record.';
+
+COMMENT ON COLUMN webhook_deliveries.event_id IS 'Identifies the event across replicas, so each event is delivered to a webhook at most once.';
+
+CREATE TABLE webhooks (
+    id uuid NOT NULL,
+    organization_id uuid NOT NULL,
+    name text NOT NULL,
+    url text NOT NULL,
+    secret text NOT NULL,
+    events text[] DEFAULT '{}'::text[] NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    updated_at timestamp with time zone NOT NULL
 );
 
+COMMENT ON COLUMN webhooks.secret IS 'The secret used to sign delivered payloads with HMAC-SHA256.';
+
+COMMENT ON COLUMN webhooks.events IS 'The event types delivered to the webhook. Empty means all event types.';
+
+CREATE TABLE workspace_agent_metadata (
+    workspace_agent_id uuid NOT NULL,
+    display_name character varying(127) NOT NULL,
+    key character varying(127) NOT NULL,
+    script character varying(65535) NOT NULL,
+    value character varying(65535) DEFAULT ''::character varying NOT NULL,
+    error character varying(65535) DEFAULT ''::character varying NOT NULL,
+    timeout bigint NOT NULL,
+    "interval" bigint NOT NULL,
+    collected_at timestamp with time zone DEFAULT '0001-01-01 00:00:00+00'::timestamp with time zone NOT NULL
+);
+
+COMMENT ON COLUMN workspace_agent_metadata.timeout IS 'The number of seconds the script is allowed to run before it is killed.';
+
+COMMENT ON COLUMN workspace_agent_metadata."interval" IS 'The number of seconds to wait between runs of the script.';
+
+COMMENT ON COLUMN workspace_agent_metadata.collected_at IS 'The time the agent collected the current value. The zero time indicates no value has been collected yet.';
+
+CREATE TABLE workspace_agent_startup_logs (
+    agent_id uuid NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    output text NOT NULL,
+    id bigint NOT NULL
+);
+
+CREATE SEQUENCE workspace_agent_startup_logs_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+ALTER SEQUENCE workspace_agent_startup_logs_id_seq OWNED BY workspace_agent_startup_logs.id;
+
 CREATE TABLE workspace_agents (
     id uuid NOT NULL,
     created_at timestamp with time zone NOT NULL,
@@ -410,7 +668,15 @@ CREATE TABLE workspace_agents (
     version text DEFAULT ''::text NOT NULL,
     last_connected_replica_id uuid,
     connection_timeout_seconds integer DEFAULT 0 NOT NULL,
-    troubleshooting_url text DEFAULT ''::text NOT NULL
+    troubleshooting_url text DEFAULT ''::text NOT NULL,
+    startup_logs_eof boolean DEFAULT false NOT NULL,
+    lifecycle_state workspace_agent_lifecycle_state DEFAULT 'created'::workspace_agent_lifecycle_state NOT NULL,
+    startup_script_timeout_seconds integer DEFAULT 0 NOT NULL,
+    shutdown_script character varying(65534),
+    shutdown_script_timeout_seconds integer DEFAULT 0 NOT NULL,
+    startup_logs_length integer DEFAULT 0 NOT NULL,
+    startup_logs_overflowed boolean DEFAULT false NOT NULL,
+    CONSTRAINT max_startup_logs_length CHECK ((startup_logs_length <= 1048576))
 );
 
 COMMENT ON COLUMN workspace_agents.version IS 'Version tracks the version of the currently running workspace agent. Workspace agents register their version upon start.';
@@ -419,6 +685,34 @@ COMMENT ON COLUMN workspace_agents.connection_timeout_seconds IS 'Connection tim
 
 COMMENT ON COLUMN workspace_agents.troubleshooting_url IS 'URL for troubleshooting the agent.';
 
+COMMENT ON COLUMN workspace_agents.startup_logs_eof IS 'Indicates that the startup script has exited and no more startup logs are expected.';
+
+COMMENT ON COLUMN workspace_agents.lifecycle_state IS 'The current lifecycle state reported by the workspace agent.';
+
+COMMENT ON COLUMN workspace_agents.startup_script_timeout_seconds IS 'The number of seconds to wait for the startup script to complete, 0 means disabled.';
+
+COMMENT ON COLUMN workspace_agents.shutdown_script IS 'Script that is executed before the agent is stopped.';
+
+COMMENT ON COLUMN workspace_agents.shutdown_script_timeout_seconds IS 'The number of seconds to wait for the shutdown script to complete, 0 means disabled.';
+
+COMMENT ON COLUMN workspace_agents.startup_logs_length IS 'Total length of startup logs';
+
+COMMENT ON COLUMN workspace_agents.startup_logs_overflowed IS 'Whether the startup logs overflowed in length';
+
+CREATE TABLE workspace_agent_port_shares (
+    workspace_id uuid NOT NULL,
+    agent_name text NOT NULL,
+    port integer NOT NULL,
+    sharing_level app_sharing_level NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    updated_at timestamp with time zone NOT NULL,
+    protocol port_share_protocol DEFAULT 'tcp'::port_share_protocol NOT NULL
+);
+
+COMMENT ON TABLE workspace_agent_port_shares IS 'Ports in workspaces shared with other users. Shares are keyed by agent name so they survive workspace builds.';
+
+COMMENT ON COLUMN workspace_agent_port_shares.protocol IS 'TCP ports are shared through the subdomain proxy, and UDP ports are relayed through the API.';
+
 CREATE TABLE workspace_apps (
     id uuid NOT NULL,
     created_at timestamp with time zone NOT NULL,
@@ -436,6 +730,14 @@ CREATE TABLE workspace_apps (
     slug text NOT NULL
 );
 
+CREATE TABLE workspace_build_parameters (
+    workspace_build_id uuid NOT NULL,
+    name text NOT NULL,
+    value text NOT NULL
+);
+
+COMMENT ON TABLE workspace_build_parameters IS 'Values of rich parameters used for a workspace build.';
+
 CREATE TABLE workspace_builds (
     id uuid NOT NULL,
     created_at timestamp with time zone NOT NULL,
@@ -472,6 +774,26 @@ CREATE TABLE workspace_resources (
     daily_cost integer DEFAULT 0 NOT NULL
 );
 
+CREATE TABLE workspace_session_recordings (
+    id uuid NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    organization_id uuid NOT NULL,
+    workspace_id uuid NOT NULL,
+    agent_id uuid NOT NULL,
+    owner_id uuid NOT NULL,
+    type session_recording_type NOT NULL,
+    command text NOT NULL,
+    started_at timestamp with time zone NOT NULL,
+    ended_at timestamp with time zone NOT NULL,
+    file_id uuid NOT NULL
+);
+
+COMMENT ON TABLE workspace_session_recordings IS 'Recordings of terminal sessions in workspaces, saved in asciicast v2 format.';
+
+COMMENT ON COLUMN workspace_session_recordings.owner_id IS 'The owner of the workspace. Connections to agents are authorized for the workspace, so sessions are attributed to its owner.';
+
+COMMENT ON COLUMN workspace_session_recordings.command IS 'The command the session ran. Empty when the session ran the login shell.';
+
 CREATE TABLE workspaces (
     id uuid NOT NULL,
     created_at timestamp with time zone NOT NULL,
@@ -483,13 +805,18 @@ CREATE TABLE workspaces (
     name character varying(64) NOT NULL,
     autostart_schedule text,
     ttl bigint,
-    last_used_at timestamp without time zone DEFAULT '0001-01-01 00:00:00'::timestamp without time zone NOT NULL
+    last_used_at timestamp without time zone DEFAULT '0001-01-01 00:00:00'::timestamp without time zone NOT NULL,
+    deleting_at timestamp with time zone
 );
 
+COMMENT ON COLUMN workspaces.deleting_at IS 'The time at which a dormant workspace will be automatically deleted. Null when the workspace is not scheduled for deletion.';
+
 ALTER TABLE ONLY licenses ALTER COLUMN id SET DEFAULT nextval('licenses_id_seq'::regclass);
 
 ALTER TABLE ONLY provisioner_job_logs ALTER COLUMN id SET DEFAULT nextval('provisioner_job_logs_id_seq'::regclass);
 
+ALTER TABLE ONLY workspace_agent_startup_logs ALTER COLUMN id SET DEFAULT nextval('workspace_agent_startup_logs_id_seq'::regclass);
+
 ALTER TABLE ONLY agent_stats
     ADD CONSTRAINT agent_stats_pkey PRIMARY KEY (id);
 
@@ -499,6 +826,12 @@ ALTER TABLE ONLY api_keys
 ALTER TABLE ONLY audit_logs
     ADD CONSTRAINT audit_logs_pkey PRIMARY KEY (id);
 
+ALTER TABLE ONLY custom_roles
+    ADD CONSTRAINT custom_roles_pkey PRIMARY KEY (id);
+
+ALTER TABLE ONLY file_data
+    ADD CONSTRAINT file_data_pkey PRIMARY KEY (hash);
+
 ALTER TABLE ONLY files
     ADD CONSTRAINT files_hash_created_by_key UNIQUE (hash, created_by);
 
@@ -559,6 +892,15 @@ ALTER TABLE ONLY provisioner_jobs
 ALTER TABLE ONLY site_configs
     ADD CONSTRAINT site_configs_key_key UNIQUE (key);
 
+ALTER TABLE ONLY template_app_usage_stats
+    ADD CONSTRAINT template_app_usage_stats_pkey PRIMARY KEY (start_time, template_id, user_id, slug);
+
+ALTER TABLE ONLY template_usage_stats
+    ADD CONSTRAINT template_usage_stats_pkey PRIMARY KEY (start_time, template_id, user_id);
+
+ALTER TABLE ONLY template_version_parameters
+    ADD CONSTRAINT template_version_parameters_template_version_id_name_key UNIQUE (template_version_id, name);
+
 ALTER TABLE ONLY template_versions
     ADD CONSTRAINT template_versions_pkey PRIMARY KEY (id);
 
@@ -574,15 +916,39 @@ ALTER TABLE ONLY user_links
 ALTER TABLE ONLY users
     ADD CONSTRAINT users_pkey PRIMARY KEY (id);
 
+ALTER TABLE ONLY webhook_deliveries
+    ADD CONSTRAINT webhook_deliveries_pkey PRIMARY KEY (id);
+
+ALTER TABLE ONLY webhook_deliveries
+    ADD CONSTRAINT webhook_deliveries_webhook_id_event_id_key UNIQUE (webhook_id, event_id);
+
+ALTER TABLE ONLY webhooks
+    ADD CONSTRAINT webhooks_organization_id_name_key UNIQUE (organization_id, name);
+
+ALTER TABLE ONLY webhooks
+    ADD CONSTRAINT webhooks_pkey PRIMARY KEY (id);
+
+ALTER TABLE ONLY workspace_agent_metadata
+    ADD CONSTRAINT workspace_agent_metadata_pkey PRIMARY KEY (workspace_agent_id, key);
+
+ALTER TABLE ONLY workspace_agent_startup_logs
+    ADD CONSTRAINT workspace_agent_startup_logs_pkey PRIMARY KEY (id);
+
 ALTER TABLE ONLY workspace_agents
     ADD CONSTRAINT workspace_agents_pkey PRIMARY KEY (id);
 
+ALTER TABLE ONLY workspace_agent_port_shares
+    ADD CONSTRAINT workspace_agent_port_shares_pkey PRIMARY KEY (workspace_id, agent_name, port, protocol);
+
 ALTER TABLE ONLY workspace_apps
     ADD CONSTRAINT workspace_apps_agent_id_slug_idx UNIQUE (agent_id, slug);
 
 ALTER TABLE ONLY workspace_apps
     ADD CONSTRAINT workspace_apps_pkey PRIMARY KEY (id);
 
+ALTER TABLE ONLY workspace_build_parameters
+    ADD CONSTRAINT workspace_build_parameters_workspace_build_id_name_key UNIQUE (workspace_build_id, name);
+
 ALTER TABLE ONLY workspace_builds
     ADD CONSTRAINT workspace_builds_job_id_key UNIQUE (job_id);
 
@@ -598,17 +964,28 @@ ALTER TABLE ONLY workspace_resource_metadata
 ALTER TABLE ONLY workspace_resources
     ADD CONSTRAINT workspace_resources_pkey PRIMARY KEY (id);
 
+ALTER TABLE ONLY workspace_session_recordings
+    ADD CONSTRAINT workspace_session_recordings_pkey PRIMARY KEY (id);
+
 ALTER TABLE ONLY workspaces
     ADD CONSTRAINT workspaces_pkey PRIMARY KEY (id);
 
+CREATE INDEX idx_agent_stats_agent_id_created_at ON agent_stats USING btree (agent_id, created_at);
+
 CREATE INDEX idx_agent_stats_created_at ON agent_stats USING btree (created_at);
 
 CREATE INDEX idx_agent_stats_user_id ON agent_stats USING btree (user_id);
 
 CREATE INDEX idx_api_keys_user ON api_keys USING btree (user_id);
 
+CREATE UNIQUE INDEX idx_api_keys_user_token_name ON api_keys USING btree (user_id, token_name) WHERE (login_type = 'token'::login_type);
+
 CREATE INDEX idx_audit_log_organization_id ON audit_logs USING btree (organization_id);
 
+CREATE UNIQUE INDEX idx_custom_roles_organization_name ON custom_roles USING btree (organization_id, name) WHERE (organization_id IS NOT NULL);
+
+CREATE UNIQUE INDEX idx_custom_roles_site_name ON custom_roles USING btree (name) WHERE (organization_id IS NULL);
+
 CREATE INDEX idx_audit_log_resource_id ON audit_logs USING btree (resource_id);
 
 CREATE INDEX idx_audit_log_user_id ON audit_logs USING btree (user_id);
@@ -635,15 +1012,28 @@ CREATE UNIQUE INDEX users_email_lower_idx ON users USING btree (lower(email)) WH
 
 CREATE UNIQUE INDEX users_username_lower_idx ON users USING btree (lower(username)) WHERE (deleted = false);
 
+CREATE INDEX webhook_deliveries_webhook_id_created_at_idx ON webhook_deliveries USING btree (webhook_id, created_at DESC);
+
+CREATE INDEX workspace_agent_startup_logs_id_agent_id_idx ON workspace_agent_startup_logs USING btree (agent_id, id);
+
 CREATE INDEX workspace_agents_resource_id_idx ON workspace_agents USING btree (resource_id);
 
+CREATE INDEX workspace_builds_created_at_idx ON workspace_builds USING btree (created_at);
+
 CREATE INDEX workspace_resources_job_id_idx ON workspace_resources USING btree (job_id);
 
+CREATE INDEX workspace_session_recordings_owner_id_idx ON workspace_session_recordings USING btree (owner_id);
+
+CREATE INDEX workspace_session_recordings_workspace_id_idx ON workspace_session_recordings USING btree (workspace_id);
+
 CREATE UNIQUE INDEX workspaces_owner_id_lower_idx ON workspaces USING btree (owner_id, lower((name)::text)) WHERE (deleted = false);
 
 ALTER TABLE ONLY api_keys
     ADD CONSTRAINT api_keys_user_id_uuid_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
 
+ALTER TABLE ONLY custom_roles
+    ADD CONSTRAINT custom_roles_organization_id_fkey FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE;
+
 ALTER TABLE ONLY gitsshkeys
     ADD CONSTRAINT gitsshkeys_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id);
 
@@ -665,12 +1055,30 @@ ALTER TABLE ONLY organization_members
 ALTER TABLE ONLY parameter_schemas
     ADD CONSTRAINT parameter_schemas_job_id_fkey FOREIGN KEY (job_id) REFERENCES provisioner_jobs(id) ON DELETE CASCADE;
 
+ALTER TABLE ONLY provisioner_daemons
+    ADD CONSTRAINT provisioner_daemons_organization_id_fkey FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE;
+
 ALTER TABLE ONLY provisioner_job_logs
     ADD CONSTRAINT provisioner_job_logs_job_id_fkey FOREIGN KEY (job_id) REFERENCES provisioner_jobs(id) ON DELETE CASCADE;
 
 ALTER TABLE ONLY provisioner_jobs
     ADD CONSTRAINT provisioner_jobs_organization_id_fkey FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE;
 
+ALTER TABLE ONLY template_app_usage_stats
+    ADD CONSTRAINT template_app_usage_stats_template_id_fkey FOREIGN KEY (template_id) REFERENCES templates(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY template_app_usage_stats
+    ADD CONSTRAINT template_app_usage_stats_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY template_usage_stats
+    ADD CONSTRAINT template_usage_stats_template_id_fkey FOREIGN KEY (template_id) REFERENCES templates(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY template_usage_stats
+    ADD CONSTRAINT template_usage_stats_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY template_version_parameters
+    ADD CONSTRAINT template_version_parameters_template_version_id_fkey FOREIGN KEY (template_version_id) REFERENCES template_versions(id) ON DELETE CASCADE;
+
 ALTER TABLE ONLY template_versions
     ADD CONSTRAINT template_versions_created_by_fkey FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE RESTRICT;
 
@@ -689,12 +1097,30 @@ ALTER TABLE ONLY templates
 ALTER TABLE ONLY user_links
     ADD CONSTRAINT user_links_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
 
+ALTER TABLE ONLY webhook_deliveries
+    ADD CONSTRAINT webhook_deliveries_webhook_id_fkey FOREIGN KEY (webhook_id) REFERENCES webhooks(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY webhooks
+    ADD CONSTRAINT webhooks_organization_id_fkey FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY workspace_agent_metadata
+    ADD CONSTRAINT workspace_agent_metadata_workspace_agent_id_fkey FOREIGN KEY (workspace_agent_id) REFERENCES workspace_agents(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY workspace_agent_startup_logs
+    ADD CONSTRAINT workspace_agent_startup_logs_agent_id_fkey FOREIGN KEY (agent_id) REFERENCES workspace_agents(id) ON DELETE CASCADE;
+
 ALTER TABLE ONLY workspace_agents
     ADD CONSTRAINT workspace_agents_resource_id_fkey FOREIGN KEY (resource_id) REFERENCES workspace_resources(id) ON DELETE CASCADE;
 
+ALTER TABLE ONLY workspace_agent_port_shares
+    ADD CONSTRAINT workspace_agent_port_shares_workspace_id_fkey FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE;
+
 ALTER TABLE ONLY workspace_apps
     ADD CONSTRAINT workspace_apps_agent_id_fkey FOREIGN KEY (agent_id) REFERENCES workspace_agents(id) ON DELETE CASCADE;
 
+ALTER TABLE ONLY workspace_build_parameters
+    ADD CONSTRAINT workspace_build_parameters_workspace_build_id_fkey FOREIGN KEY (workspace_build_id) REFERENCES workspace_builds(id) ON DELETE CASCADE;
+
 ALTER TABLE ONLY workspace_builds
     ADD CONSTRAINT workspace_builds_job_id_fkey FOREIGN KEY (job_id) REFERENCES provisioner_jobs(id) ON DELETE CASCADE;
 
@@ -710,6 +1136,21 @@ ALTER TABLE ONLY workspace_resource_metadata
 ALTER TABLE ONLY workspace_resources
     ADD CONSTRAINT workspace_resources_job_id_fkey FOREIGN KEY (job_id) REFERENCES provisioner_jobs(id) ON DELETE CASCADE;
 
+ALTER TABLE ONLY workspace_session_recordings
+    ADD CONSTRAINT workspace_session_recordings_agent_id_fkey FOREIGN KEY (agent_id) REFERENCES workspace_agents(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY workspace_session_recordings
+    ADD CONSTRAINT workspace_session_recordings_file_id_fkey FOREIGN KEY (file_id) REFERENCES files(id);
+
+ALTER TABLE ONLY workspace_session_recordings
+    ADD CONSTRAINT workspace_session_recordings_organization_id_fkey FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY workspace_session_recordings
+    ADD CONSTRAINT workspace_session_recordings_owner_id_fkey FOREIGN KEY (owner_id) REFERENCES users(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY workspace_session_recordings
+    ADD CONSTRAINT workspace_session_recordings_workspace_id_fkey FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE;
+
 ALTER TABLE ONLY workspaces
     ADD CONSTRAINT workspaces_organization_id_fkey FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE RESTRICT;
 
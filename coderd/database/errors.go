@@ -27,3 +27,14 @@ func IsUniqueViolation(err error, uniqueConstraints ...UniqueConstraint) bool {
 
 	return false
 }
+
+// IsStartupLogsLimitError checks if the error is due to the startup logs
+// of a workspace agent exceeding the maximum length.
+func IsStartupLogsLimitError(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Constraint == "max_startup_logs_length" && pqErr.Table == "workspace_agents"
+	}
+
+	return false
+}
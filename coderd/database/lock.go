@@ -0,0 +1,21 @@
+package database
+
+import "hash/fnv"
+
+// Well-known lock IDs for lock functions in the database. These should not
+// change. If locks are deprecated, they should be kept in this list to avoid
+// reusing the same ID.
+const (
+	// LockIDAutostopNotices serializes the checks for upcoming autostops
+	// across replicas.
+	LockIDAutostopNotices = iota + 1
+)
+
+// LockIDFileHash returns the lock ID that serializes inserting and
+// collecting files with the given hash, so contents are never deleted while a
+// file referencing them is being saved.
+func LockIDFileHash(hash string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte("files:" + hash))
+	return int64(h.Sum64())
+}
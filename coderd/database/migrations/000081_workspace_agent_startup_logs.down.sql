@@ -0,0 +1,2 @@
+ALTER TABLE workspace_agents DROP COLUMN startup_logs_eof;
+DROP TABLE IF EXISTS workspace_agent_startup_logs;
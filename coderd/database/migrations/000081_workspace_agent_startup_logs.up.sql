@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS workspace_agent_startup_logs (
+	agent_id uuid NOT NULL REFERENCES workspace_agents (id) ON DELETE CASCADE,
+	created_at timestamptz NOT NULL,
+	output text NOT NULL,
+	id BIGSERIAL PRIMARY KEY
+);
+CREATE INDEX workspace_agent_startup_logs_id_agent_id_idx ON workspace_agent_startup_logs USING btree (agent_id, id);
+
+ALTER TABLE workspace_agents ADD COLUMN startup_logs_eof boolean NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN workspace_agents.startup_logs_eof IS 'Indicates that the startup script has exited and no more startup logs are expected.';
@@ -0,0 +1,8 @@
+ALTER TYPE workspace_agent_lifecycle_state ADD VALUE 'shutdown_timeout' AFTER 'shutting_down';
+ALTER TYPE workspace_agent_lifecycle_state ADD VALUE 'shutdown_error' AFTER 'shutdown_timeout';
+
+ALTER TABLE workspace_agents ADD COLUMN shutdown_script varchar(65534);
+ALTER TABLE workspace_agents ADD COLUMN shutdown_script_timeout_seconds integer NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN workspace_agents.shutdown_script IS 'Script that is executed before the agent is stopped.';
+COMMENT ON COLUMN workspace_agents.shutdown_script_timeout_seconds IS 'The number of seconds to wait for the shutdown script to complete, 0 means disabled.';
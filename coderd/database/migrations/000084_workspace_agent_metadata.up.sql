@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS workspace_agent_metadata (
+	workspace_agent_id uuid NOT NULL REFERENCES workspace_agents (id) ON DELETE CASCADE,
+	display_name varchar(127) NOT NULL,
+	key varchar(127) NOT NULL,
+	script varchar(65535) NOT NULL,
+	value varchar(65535) NOT NULL DEFAULT '',
+	error varchar(65535) NOT NULL DEFAULT '',
+	timeout bigint NOT NULL,
+	interval bigint NOT NULL,
+	collected_at timestamptz NOT NULL DEFAULT '0001-01-01 00:00:00+00',
+	PRIMARY KEY (workspace_agent_id, key)
+);
+
+COMMENT ON COLUMN workspace_agent_metadata.timeout IS 'The number of seconds the script is allowed to run before it is killed.';
+
+COMMENT ON COLUMN workspace_agent_metadata.interval IS 'The number of seconds to wait between runs of the script.';
+
+COMMENT ON COLUMN workspace_agent_metadata.collected_at IS 'The time the agent collected the current value. The zero time indicates no value has been collected yet.';
@@ -0,0 +1,44 @@
+CREATE TABLE IF NOT EXISTS webhooks (
+	id uuid NOT NULL,
+	organization_id uuid NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+	name text NOT NULL,
+	url text NOT NULL,
+	secret text NOT NULL,
+	events text[] NOT NULL DEFAULT '{}',
+	created_at timestamptz NOT NULL,
+	updated_at timestamptz NOT NULL,
+	PRIMARY KEY (id),
+	UNIQUE (organization_id, name)
+);
+
+COMMENT ON COLUMN webhooks.secret IS 'The secret used to sign delivered payloads with HMAC-SHA256.';
+
+COMMENT ON COLUMN webhooks.events IS 'The event types delivered to the webhook. Empty means all event types.';
+
+CREATE TYPE webhook_delivery_status AS ENUM (
+	'pending',
+	'delivered',
+	'failed'
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+	id uuid NOT NULL,
+	webhook_id uuid NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+	event_id uuid NOT NULL,
+	event_type text NOT NULL,
+	payload jsonb NOT NULL,
+	status webhook_delivery_status NOT NULL DEFAULT 'pending',
+	attempts integer NOT NULL DEFAULT 0,
+	last_status_code integer NOT NULL DEFAULT 0,
+	last_error text NOT NULL DEFAULT '',
+	created_at timestamptz NOT NULL,
+	updated_at timestamptz NOT NULL,
+	PRIMARY KEY (id),
+	UNIQUE (webhook_id, event_id)
+);
+
+CREATE INDEX webhook_deliveries_webhook_id_created_at_idx ON webhook_deliveries USING btree (webhook_id, created_at DESC);
+
+COMMENT ON TABLE webhook_deliveries IS 'Deliveries of events to webhooks. Failed deliveries are kept as a dead-letter record.';
+
+COMMENT ON COLUMN webhook_deliveries.event_id IS 'Identifies the event across replicas, so each event is delivered to a webhook at most once.';
@@ -0,0 +1,6 @@
+ALTER TABLE workspaces DROP COLUMN deleting_at;
+ALTER TABLE templates DROP COLUMN stopped_ttl;
+ALTER TABLE templates DROP COLUMN inactivity_ttl;
+
+-- It's not possible to drop enum values from enum types, so the UP has "IF NOT
+-- EXISTS".
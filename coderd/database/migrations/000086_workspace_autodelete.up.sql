@@ -0,0 +1,11 @@
+ALTER TYPE build_reason ADD VALUE IF NOT EXISTS 'autodelete';
+
+ALTER TABLE templates ADD COLUMN inactivity_ttl bigint DEFAULT 0 NOT NULL;
+ALTER TABLE templates ADD COLUMN stopped_ttl bigint DEFAULT 0 NOT NULL;
+
+COMMENT ON COLUMN templates.inactivity_ttl IS 'The duration a workspace may go unused before it is scheduled for deletion. Zero disables it.';
+COMMENT ON COLUMN templates.stopped_ttl IS 'The duration a workspace may remain stopped before it is scheduled for deletion. Zero disables it.';
+
+ALTER TABLE workspaces ADD COLUMN deleting_at timestamp with time zone;
+
+COMMENT ON COLUMN workspaces.deleting_at IS 'The time at which a dormant workspace will be automatically deleted. Null when the workspace is not scheduled for deletion.';
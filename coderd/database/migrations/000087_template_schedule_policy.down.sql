@@ -0,0 +1,6 @@
+ALTER TABLE templates DROP COLUMN quiet_hours_duration;
+ALTER TABLE templates DROP COLUMN quiet_hours_schedule;
+ALTER TABLE templates DROP COLUMN autostart_end_hour;
+ALTER TABLE templates DROP COLUMN autostart_start_hour;
+ALTER TABLE templates DROP COLUMN autostart_days_of_week;
+ALTER TABLE templates DROP COLUMN max_ttl;
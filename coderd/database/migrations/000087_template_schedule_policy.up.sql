@@ -0,0 +1,13 @@
+ALTER TABLE templates ADD COLUMN max_ttl bigint DEFAULT 0 NOT NULL;
+ALTER TABLE templates ADD COLUMN autostart_days_of_week smallint DEFAULT 127 NOT NULL;
+ALTER TABLE templates ADD COLUMN autostart_start_hour smallint DEFAULT 0 NOT NULL;
+ALTER TABLE templates ADD COLUMN autostart_end_hour smallint DEFAULT 24 NOT NULL;
+ALTER TABLE templates ADD COLUMN quiet_hours_schedule text DEFAULT ''::text NOT NULL;
+ALTER TABLE templates ADD COLUMN quiet_hours_duration bigint DEFAULT 0 NOT NULL;
+
+COMMENT ON COLUMN templates.max_ttl IS 'The maximum duration a workspace created from this template may run for before it is stopped. Zero means there is no maximum.';
+COMMENT ON COLUMN templates.autostart_days_of_week IS 'A bitmask of the days of the week autostart is allowed on, with Sunday as the least significant bit.';
+COMMENT ON COLUMN templates.autostart_start_hour IS 'The first hour of the day autostart is allowed in, in the timezone of the autostart schedule.';
+COMMENT ON COLUMN templates.autostart_end_hour IS 'The hour of the day autostart is no longer allowed from, in the timezone of the autostart schedule.';
+COMMENT ON COLUMN templates.quiet_hours_schedule IS 'A cron schedule for the start of quiet hours, during which workspaces are stopped and not autostarted. Empty disables quiet hours.';
+COMMENT ON COLUMN templates.quiet_hours_duration IS 'The duration of quiet hours.';
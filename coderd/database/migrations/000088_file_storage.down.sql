@@ -0,0 +1,8 @@
+-- Contents of files in other storage backends can not be restored.
+ALTER TABLE files ADD COLUMN data bytea DEFAULT ''::bytea NOT NULL;
+UPDATE files SET data = file_data.data FROM file_data WHERE files.hash = file_data.hash;
+ALTER TABLE files ALTER COLUMN data DROP DEFAULT;
+ALTER TABLE files DROP COLUMN storage;
+
+DROP TABLE file_data;
+DROP TYPE file_storage;
@@ -0,0 +1,23 @@
+CREATE TYPE file_storage AS ENUM (
+	'database',
+	'local',
+	's3'
+);
+
+-- File contents are moved out of the files table so they can be stored
+-- outside of the database, and are deduplicated by hash.
+CREATE TABLE file_data (
+	hash character varying(64) NOT NULL,
+	data bytea NOT NULL,
+	PRIMARY KEY (hash)
+);
+
+COMMENT ON TABLE file_data IS 'Contents of files stored in the database, keyed by hash.';
+
+INSERT INTO file_data (hash, data)
+SELECT DISTINCT ON (hash) hash, data FROM files;
+
+ALTER TABLE files DROP COLUMN data;
+ALTER TABLE files ADD COLUMN storage file_storage DEFAULT 'database'::file_storage NOT NULL;
+
+COMMENT ON COLUMN files.storage IS 'The storage backend holding the contents of the file.';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS workspace_build_parameters;
+DROP TABLE IF EXISTS template_version_parameters;
@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS template_version_parameters (
+    template_version_id uuid NOT NULL REFERENCES template_versions (id) ON DELETE CASCADE,
+    name text NOT NULL,
+    description text NOT NULL,
+    type text NOT NULL,
+    mutable boolean NOT NULL,
+    default_value text NOT NULL,
+    icon text NOT NULL,
+    options jsonb NOT NULL DEFAULT '[]'::jsonb,
+    validation_regex text NOT NULL,
+    validation_min integer,
+    validation_max integer,
+    validation_error text NOT NULL,
+    index integer NOT NULL,
+    UNIQUE (template_version_id, name)
+);
+
+COMMENT ON TABLE template_version_parameters IS 'Rich parameters declared by a template version with "coder_parameter" data sources.';
+COMMENT ON COLUMN template_version_parameters.type IS 'Type of the parameter: string, number, bool or list(string).';
+COMMENT ON COLUMN template_version_parameters.mutable IS 'Whether the value can be changed after the workspace is created.';
+COMMENT ON COLUMN template_version_parameters.options IS 'Options the value must be one of, if any.';
+COMMENT ON COLUMN template_version_parameters.validation_regex IS 'Regular expression string values must match. Empty disables the check.';
+COMMENT ON COLUMN template_version_parameters.validation_min IS 'Minimum value of number parameters, if any.';
+COMMENT ON COLUMN template_version_parameters.validation_max IS 'Maximum value of number parameters, if any.';
+COMMENT ON COLUMN template_version_parameters.index IS 'Position of the parameter in the template, used to order prompts.';
+
+CREATE TABLE IF NOT EXISTS workspace_build_parameters (
+    workspace_build_id uuid NOT NULL REFERENCES workspace_builds (id) ON DELETE CASCADE,
+    name text NOT NULL,
+    value text NOT NULL,
+    UNIQUE (workspace_build_id, name)
+);
+
+COMMENT ON TABLE workspace_build_parameters IS 'Values of rich parameters used for a workspace build.';
@@ -0,0 +1,6 @@
+DROP TABLE workspace_session_recordings;
+DROP TYPE session_recording_type;
+ALTER TABLE templates DROP COLUMN session_recording;
+
+-- It's not possible to drop enum values from enum types, so the UP has "IF NOT
+-- EXISTS".
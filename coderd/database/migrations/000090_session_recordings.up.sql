@@ -0,0 +1,32 @@
+ALTER TYPE resource_type ADD VALUE IF NOT EXISTS 'workspace_session_recording';
+
+ALTER TABLE templates ADD COLUMN session_recording boolean DEFAULT false NOT NULL;
+
+COMMENT ON COLUMN templates.session_recording IS 'Whether terminal sessions in workspaces created from the template are recorded.';
+
+CREATE TYPE session_recording_type AS ENUM (
+	'ssh',
+	'reconnecting_pty'
+);
+
+CREATE TABLE workspace_session_recordings (
+	id uuid NOT NULL,
+	created_at timestamp with time zone NOT NULL,
+	organization_id uuid NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+	workspace_id uuid NOT NULL REFERENCES workspaces (id) ON DELETE CASCADE,
+	agent_id uuid NOT NULL REFERENCES workspace_agents (id) ON DELETE CASCADE,
+	owner_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	type session_recording_type NOT NULL,
+	command text NOT NULL,
+	started_at timestamp with time zone NOT NULL,
+	ended_at timestamp with time zone NOT NULL,
+	file_id uuid NOT NULL REFERENCES files (id),
+	PRIMARY KEY (id)
+);
+
+COMMENT ON TABLE workspace_session_recordings IS 'Recordings of terminal sessions in workspaces, saved in asciicast v2 format.';
+COMMENT ON COLUMN workspace_session_recordings.owner_id IS 'The owner of the workspace. Connections to agents are authorized for the workspace, so sessions are attributed to its owner.';
+COMMENT ON COLUMN workspace_session_recordings.command IS 'The command the session ran. Empty when the session ran the login shell.';
+
+CREATE INDEX workspace_session_recordings_workspace_id_idx ON workspace_session_recordings USING btree (workspace_id);
+CREATE INDEX workspace_session_recordings_owner_id_idx ON workspace_session_recordings USING btree (owner_id);
@@ -0,0 +1,2 @@
+ALTER TABLE users DROP COLUMN auto_start_on_connect;
+ALTER TABLE templates DROP COLUMN auto_start_on_connect;
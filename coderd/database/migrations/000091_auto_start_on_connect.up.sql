@@ -0,0 +1,7 @@
+ALTER TABLE templates ADD COLUMN auto_start_on_connect boolean DEFAULT false NOT NULL;
+
+COMMENT ON COLUMN templates.auto_start_on_connect IS 'Whether stopped workspaces created from the template are started when connected to.';
+
+ALTER TABLE users ADD COLUMN auto_start_on_connect boolean DEFAULT false NOT NULL;
+
+COMMENT ON COLUMN users.auto_start_on_connect IS 'Whether the user''s stopped workspaces are started when connected to.';
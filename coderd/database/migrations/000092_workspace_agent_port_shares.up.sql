@@ -0,0 +1,15 @@
+CREATE TABLE workspace_agent_port_shares (
+	workspace_id uuid NOT NULL REFERENCES workspaces (id) ON DELETE CASCADE,
+	agent_name text NOT NULL,
+	port integer NOT NULL,
+	sharing_level app_sharing_level NOT NULL,
+	created_at timestamp with time zone NOT NULL,
+	updated_at timestamp with time zone NOT NULL,
+	PRIMARY KEY (workspace_id, agent_name, port)
+);
+
+COMMENT ON TABLE workspace_agent_port_shares IS 'Ports in workspaces shared through the subdomain proxy. Shares are keyed by agent name so they survive workspace builds.';
+
+ALTER TABLE templates ADD COLUMN max_port_sharing_level app_sharing_level DEFAULT 'owner'::app_sharing_level NOT NULL;
+
+COMMENT ON COLUMN templates.max_port_sharing_level IS 'The highest sharing level ports in workspaces created from the template may be shared with. Shares above it are treated as this level.';
@@ -0,0 +1 @@
+ALTER TABLE agent_stats DROP COLUMN connection_activity;
@@ -0,0 +1,3 @@
+ALTER TABLE agent_stats ADD COLUMN connection_activity boolean DEFAULT true NOT NULL;
+
+COMMENT ON COLUMN agent_stats.connection_activity IS 'Whether the agent''s connection stats changed since its previous report. Reports where only resource usage changed don''t count as workspace activity.';
@@ -0,0 +1,5 @@
+DROP INDEX idx_agent_stats_agent_id_created_at;
+
+DROP TABLE template_app_usage_stats;
+
+DROP TABLE template_usage_stats;
@@ -0,0 +1,32 @@
+CREATE TABLE template_usage_stats (
+	start_time timestamp with time zone NOT NULL,
+	template_id uuid NOT NULL REFERENCES templates (id) ON DELETE CASCADE,
+	user_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	active boolean NOT NULL,
+	connection_count bigint NOT NULL,
+	ssh_connection_count bigint NOT NULL,
+	reconnecting_pty_connection_count bigint NOT NULL,
+	vscode_connection_count bigint NOT NULL,
+	jetbrains_connection_count bigint NOT NULL,
+	cost double precision NOT NULL,
+	PRIMARY KEY (start_time, template_id, user_id)
+);
+
+COMMENT ON TABLE template_usage_stats IS 'Hourly usage of the workspaces of each user and template, rolled up from agent_stats and workspace_builds so it outlives the raw stats.';
+
+COMMENT ON COLUMN template_usage_stats.active IS 'Whether any of the user''s workspaces had connection activity in the hour.';
+
+COMMENT ON COLUMN template_usage_stats.cost IS 'The share of the daily cost of the user''s workspaces for the hour.';
+
+CREATE TABLE template_app_usage_stats (
+	start_time timestamp with time zone NOT NULL,
+	template_id uuid NOT NULL REFERENCES templates (id) ON DELETE CASCADE,
+	user_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	slug text NOT NULL,
+	requests bigint NOT NULL,
+	PRIMARY KEY (start_time, template_id, user_id, slug)
+);
+
+COMMENT ON TABLE template_app_usage_stats IS 'Hourly number of requests each user made to the apps of each template.';
+
+CREATE INDEX idx_agent_stats_agent_id_created_at ON agent_stats USING btree (agent_id, created_at);
@@ -0,0 +1,9 @@
+DROP INDEX idx_api_keys_user_token_name;
+
+ALTER TABLE api_keys DROP COLUMN allow_list;
+ALTER TABLE api_keys DROP COLUMN token_name;
+
+-- It's not possible to drop enum values from enum types, so the UP has "IF NOT
+-- EXISTS". Keys with the new scopes get the narrowest remaining scope, since
+-- they're unknown to older versions.
+UPDATE api_keys SET scope = 'application_connect' WHERE scope IN ('read', 'workspace_start_stop', 'template_push');
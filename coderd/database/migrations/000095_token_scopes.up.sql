@@ -0,0 +1,16 @@
+ALTER TYPE api_key_scope ADD VALUE IF NOT EXISTS 'read';
+ALTER TYPE api_key_scope ADD VALUE IF NOT EXISTS 'workspace_start_stop';
+ALTER TYPE api_key_scope ADD VALUE IF NOT EXISTS 'template_push';
+
+ALTER TYPE audit_action ADD VALUE IF NOT EXISTS 'use';
+
+ALTER TABLE api_keys ADD COLUMN token_name text DEFAULT ''::text NOT NULL;
+ALTER TABLE api_keys ADD COLUMN allow_list text[] DEFAULT '{}'::text[] NOT NULL;
+
+COMMENT ON COLUMN api_keys.token_name IS 'The name of a token, unique per user. Empty for other API keys.';
+COMMENT ON COLUMN api_keys.allow_list IS 'Resources the scope of the key is limited to, formatted as "type:id". The id is "*" for every resource of the type.';
+
+-- Existing tokens didn't have a name, so they're named after their ID.
+UPDATE api_keys SET token_name = id WHERE login_type = 'token';
+
+CREATE UNIQUE INDEX idx_api_keys_user_token_name ON api_keys USING btree (user_id, token_name) WHERE (login_type = 'token'::login_type);
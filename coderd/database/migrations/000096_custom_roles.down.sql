@@ -0,0 +1,19 @@
+-- Older versions fail to authorize users with roles they don't know about,
+-- so unassign every custom role first.
+UPDATE users SET rbac_roles = ARRAY(
+	SELECT role FROM unnest(rbac_roles) AS role
+	WHERE role NOT IN (SELECT name FROM custom_roles WHERE organization_id IS NULL)
+);
+
+UPDATE organization_members SET roles = ARRAY(
+	SELECT role FROM unnest(roles) AS role
+	WHERE role NOT IN (
+		SELECT name || ':' || organization_id::text FROM custom_roles
+		WHERE organization_id = organization_members.organization_id
+	)
+);
+
+DROP TABLE custom_roles;
+
+-- It's not possible to drop enum values from enum types, so the UP has "IF NOT
+-- EXISTS".
@@ -0,0 +1,21 @@
+ALTER TYPE resource_type ADD VALUE IF NOT EXISTS 'custom_role';
+
+CREATE TABLE custom_roles (
+	id uuid NOT NULL,
+	name text NOT NULL,
+	display_name text NOT NULL,
+	organization_id uuid REFERENCES organizations (id) ON DELETE CASCADE,
+	site_permissions jsonb DEFAULT '[]'::jsonb NOT NULL,
+	org_permissions jsonb DEFAULT '[]'::jsonb NOT NULL,
+	user_permissions jsonb DEFAULT '[]'::jsonb NOT NULL,
+	created_at timestamp with time zone NOT NULL,
+	updated_at timestamp with time zone NOT NULL,
+	PRIMARY KEY (id)
+);
+
+COMMENT ON TABLE custom_roles IS 'Roles defined by admins in addition to the builtin roles.';
+COMMENT ON COLUMN custom_roles.organization_id IS 'The organization an organization role is scoped to. Null for site wide roles.';
+COMMENT ON COLUMN custom_roles.org_permissions IS 'Permissions granted in the organization of the role. Always empty for site wide roles.';
+
+CREATE UNIQUE INDEX idx_custom_roles_site_name ON custom_roles USING btree (name) WHERE (organization_id IS NULL);
+CREATE UNIQUE INDEX idx_custom_roles_organization_name ON custom_roles USING btree (organization_id, name) WHERE (organization_id IS NOT NULL);
@@ -0,0 +1,2 @@
+ALTER TABLE provisioner_daemons
+	DROP COLUMN organization_id;
@@ -0,0 +1,4 @@
+ALTER TABLE provisioner_daemons
+	ADD COLUMN organization_id uuid REFERENCES organizations (id) ON DELETE CASCADE;
+
+COMMENT ON COLUMN provisioner_daemons.organization_id IS 'The organization the daemon acquires jobs from. Null for daemons that acquire jobs from every organization.';
@@ -0,0 +1,3 @@
+ALTER TABLE workspace_agents
+	DROP COLUMN startup_logs_length,
+	DROP COLUMN startup_logs_overflowed;
@@ -0,0 +1,26 @@
+ALTER TABLE workspace_agents
+	ADD COLUMN startup_logs_length integer NOT NULL DEFAULT 0 CONSTRAINT max_startup_logs_length CHECK (startup_logs_length <= 1048576),
+	ADD COLUMN startup_logs_overflowed boolean NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN workspace_agents.startup_logs_length IS 'Total length of startup logs';
+COMMENT ON COLUMN workspace_agents.startup_logs_overflowed IS 'Whether the startup logs overflowed in length';
+
+-- Agents that already stored more than the limit are marked as overflowed.
+WITH lengths AS (
+	SELECT
+		agent_id,
+		SUM(LENGTH(output)) AS length
+	FROM
+		workspace_agent_startup_logs
+	GROUP BY
+		agent_id
+)
+UPDATE
+	workspace_agents
+SET
+	startup_logs_length = LEAST(lengths.length, 1048576),
+	startup_logs_overflowed = lengths.length > 1048576
+FROM
+	lengths
+WHERE
+	lengths.agent_id = workspace_agents.id;
@@ -0,0 +1,11 @@
+DELETE FROM workspace_agent_port_shares WHERE protocol = 'udp';
+
+ALTER TABLE workspace_agent_port_shares DROP CONSTRAINT workspace_agent_port_shares_pkey;
+
+ALTER TABLE workspace_agent_port_shares ADD PRIMARY KEY (workspace_id, agent_name, port);
+
+ALTER TABLE workspace_agent_port_shares DROP COLUMN protocol;
+
+DROP TYPE port_share_protocol;
+
+COMMENT ON TABLE workspace_agent_port_shares IS 'Ports in workspaces shared through the subdomain proxy. Shares are keyed by agent name so they survive workspace builds.';
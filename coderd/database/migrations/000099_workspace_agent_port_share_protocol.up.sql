@@ -0,0 +1,11 @@
+CREATE TYPE port_share_protocol AS ENUM ('tcp', 'udp');
+
+ALTER TABLE workspace_agent_port_shares ADD COLUMN protocol port_share_protocol DEFAULT 'tcp'::port_share_protocol NOT NULL;
+
+COMMENT ON COLUMN workspace_agent_port_shares.protocol IS 'TCP ports are shared through the subdomain proxy, and UDP ports are relayed through the API.';
+
+ALTER TABLE workspace_agent_port_shares DROP CONSTRAINT workspace_agent_port_shares_pkey;
+
+ALTER TABLE workspace_agent_port_shares ADD PRIMARY KEY (workspace_id, agent_name, port, protocol);
+
+COMMENT ON TABLE workspace_agent_port_shares IS 'Ports in workspaces shared with other users. Shares are keyed by agent name so they survive workspace builds.';
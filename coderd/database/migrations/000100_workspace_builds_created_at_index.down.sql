@@ -0,0 +1 @@
+DROP INDEX workspace_builds_created_at_idx;
@@ -0,0 +1 @@
+CREATE INDEX workspace_builds_created_at_idx ON workspace_builds USING btree (created_at);
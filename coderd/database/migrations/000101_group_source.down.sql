@@ -0,0 +1,3 @@
+ALTER TABLE groups DROP COLUMN source;
+
+DROP TYPE group_source;
@@ -0,0 +1,5 @@
+CREATE TYPE group_source AS ENUM ('user', 'oidc', 'scim');
+
+ALTER TABLE groups ADD COLUMN source group_source DEFAULT 'user'::group_source NOT NULL;
+
+COMMENT ON COLUMN groups.source IS 'Where the group was created. OIDC group sync only manages the membership of groups it created.';
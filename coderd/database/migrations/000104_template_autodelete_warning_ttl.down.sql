@@ -0,0 +1 @@
+ALTER TABLE templates DROP COLUMN autodelete_warning_ttl;
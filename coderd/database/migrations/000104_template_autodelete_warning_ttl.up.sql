@@ -0,0 +1,3 @@
+ALTER TABLE templates ADD COLUMN autodelete_warning_ttl bigint DEFAULT 86400000000000 NOT NULL;
+
+COMMENT ON COLUMN templates.autodelete_warning_ttl IS 'How long a dormant workspace is marked for deletion before it is deleted.';
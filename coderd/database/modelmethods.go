@@ -1,6 +1,8 @@
 package database
 
 import (
+	"strings"
+
 	"github.com/coder/coder/coderd/rbac"
 )
 
@@ -12,14 +14,39 @@ func (s APIKeyScope) ToRBAC() rbac.Scope {
 		return rbac.ScopeAll
 	case APIKeyScopeApplicationConnect:
 		return rbac.ScopeApplicationConnect
+	case APIKeyScopeRead:
+		return rbac.ScopeRead
+	case APIKeyScopeWorkspaceStartStop:
+		return rbac.ScopeWorkspaceStartStop
+	case APIKeyScopeTemplatePush:
+		return rbac.ScopeTemplatePush
 	default:
 		panic("developer error: unknown scope type " + string(s))
 	}
 }
 
+// RBACScope returns the scope of the key, limited to the resources in its
+// allow list.
+func (k APIKey) RBACScope() rbac.ExpandableScope {
+	if len(k.AllowList) == 0 {
+		return k.Scope.ToRBAC()
+	}
+	allowList := make([]rbac.AllowListElement, 0, len(k.AllowList))
+	for _, element := range k.AllowList {
+		// Elements are validated when the key is created.
+		resourceType, id, _ := strings.Cut(element, ":")
+		allowList = append(allowList, rbac.AllowListElement{Type: resourceType, ID: id})
+	}
+	return rbac.ScopeWithAllowList{
+		Scope:     k.Scope.ToRBAC(),
+		AllowList: allowList,
+	}
+}
+
 func (t Template) RBACObject() rbac.Object {
 	obj := rbac.ResourceTemplate
-	return obj.InOrg(t.OrganizationID).
+	return obj.WithID(t.ID).
+		InOrg(t.OrganizationID).
 		WithACLUserList(t.UserACL).
 		WithGroupACL(t.GroupACL)
 }
@@ -33,16 +60,55 @@ func (g Group) RBACObject() rbac.Object {
 	return rbac.ResourceGroup.InOrg(g.OrganizationID)
 }
 
+func (w Webhook) RBACObject() rbac.Object {
+	return rbac.ResourceWebhook.InOrg(w.OrganizationID)
+}
+
+func (r WorkspaceSessionRecording) RBACObject() rbac.Object {
+	return rbac.ResourceWorkspaceSessionRecording.InOrg(r.OrganizationID)
+}
+
+func (r CustomRole) RBACObject() rbac.Object {
+	if r.OrganizationID.Valid {
+		return rbac.ResourceCustomRole.InOrg(r.OrganizationID.UUID)
+	}
+	return rbac.ResourceCustomRole
+}
+
+// RoleName is the name the role is assigned with. Like the builtin roles,
+// organization roles are suffixed with the id of their organization.
+func (r CustomRole) RoleName() string {
+	if r.OrganizationID.Valid {
+		return r.Name + ":" + r.OrganizationID.UUID.String()
+	}
+	return r.Name
+}
+
+func (r CustomRole) RBACRole() rbac.Role {
+	role := rbac.Role{
+		Name:        r.RoleName(),
+		DisplayName: r.DisplayName,
+		Site:        r.SitePermissions,
+		User:        r.UserPermissions,
+	}
+	if r.OrganizationID.Valid {
+		role.Org = map[string][]rbac.Permission{
+			r.OrganizationID.UUID.String(): r.OrgPermissions,
+		}
+	}
+	return role
+}
+
 func (w Workspace) RBACObject() rbac.Object {
-	return rbac.ResourceWorkspace.InOrg(w.OrganizationID).WithOwner(w.OwnerID.String())
+	return rbac.ResourceWorkspace.WithID(w.ID).InOrg(w.OrganizationID).WithOwner(w.OwnerID.String())
 }
 
 func (w Workspace) ExecutionRBAC() rbac.Object {
-	return rbac.ResourceWorkspaceExecution.InOrg(w.OrganizationID).WithOwner(w.OwnerID.String())
+	return rbac.ResourceWorkspaceExecution.WithID(w.ID).InOrg(w.OrganizationID).WithOwner(w.OwnerID.String())
 }
 
 func (w Workspace) ApplicationConnectRBAC() rbac.Object {
-	return rbac.ResourceWorkspaceApplicationConnect.InOrg(w.OrganizationID).WithOwner(w.OwnerID.String())
+	return rbac.ResourceWorkspaceApplicationConnect.WithID(w.ID).InOrg(w.OrganizationID).WithOwner(w.OwnerID.String())
 }
 
 func (m OrganizationMember) RBACObject() rbac.Object {
@@ -53,7 +119,10 @@ func (o Organization) RBACObject() rbac.Object {
 	return rbac.ResourceOrganization.InOrg(o.ID)
 }
 
-func (ProvisionerDaemon) RBACObject() rbac.Object {
+func (p ProvisionerDaemon) RBACObject() rbac.Object {
+	if p.OrganizationID.Valid {
+		return rbac.ResourceProvisionerDaemon.InOrg(p.OrganizationID.UUID)
+	}
 	return rbac.ResourceProvisionerDaemon
 }
 
@@ -76,18 +145,19 @@ func ConvertUserRows(rows []GetUsersRow) []User {
 	users := make([]User, len(rows))
 	for i, r := range rows {
 		users[i] = User{
-			ID:             r.ID,
-			Email:          r.Email,
-			Username:       r.Username,
-			HashedPassword: r.HashedPassword,
-			CreatedAt:      r.CreatedAt,
-			UpdatedAt:      r.UpdatedAt,
-			Status:         r.Status,
-			RBACRoles:      r.RBACRoles,
-			LoginType:      r.LoginType,
-			AvatarURL:      r.AvatarURL,
-			Deleted:        r.Deleted,
-			LastSeenAt:     r.LastSeenAt,
+			ID:                 r.ID,
+			Email:              r.Email,
+			Username:           r.Username,
+			HashedPassword:     r.HashedPassword,
+			CreatedAt:          r.CreatedAt,
+			UpdatedAt:          r.UpdatedAt,
+			Status:             r.Status,
+			RBACRoles:          r.RBACRoles,
+			LoginType:          r.LoginType,
+			AvatarURL:          r.AvatarURL,
+			Deleted:            r.Deleted,
+			LastSeenAt:         r.LastSeenAt,
+			AutoStartOnConnect: r.AutoStartOnConnect,
 		}
 	}
 
@@ -109,6 +179,7 @@ func ConvertWorkspaceRows(rows []GetWorkspacesRow) []Workspace {
 			AutostartSchedule: r.AutostartSchedule,
 			Ttl:               r.Ttl,
 			LastUsedAt:        r.LastUsedAt,
+			DeletingAt:        r.DeletingAt,
 		}
 	}
 
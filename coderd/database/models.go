@@ -21,6 +21,9 @@ type APIKeyScope string
 const (
 	APIKeyScopeAll                APIKeyScope = "all"
 	APIKeyScopeApplicationConnect APIKeyScope = "application_connect"
+	APIKeyScopeRead               APIKeyScope = "read"
+	APIKeyScopeWorkspaceStartStop APIKeyScope = "workspace_start_stop"
+	APIKeyScopeTemplatePush       APIKeyScope = "template_push"
 )
 
 func (e *APIKeyScope) Scan(src interface{}) error {
@@ -63,6 +66,7 @@ const (
 	AuditActionDelete AuditAction = "delete"
 	AuditActionStart  AuditAction = "start"
 	AuditActionStop   AuditAction = "stop"
+	AuditActionUse    AuditAction = "use"
 )
 
 func (e *AuditAction) Scan(src interface{}) error {
@@ -80,9 +84,10 @@ func (e *AuditAction) Scan(src interface{}) error {
 type BuildReason string
 
 const (
-	BuildReasonInitiator BuildReason = "initiator"
-	BuildReasonAutostart BuildReason = "autostart"
-	BuildReasonAutostop  BuildReason = "autostop"
+	BuildReasonInitiator  BuildReason = "initiator"
+	BuildReasonAutostart  BuildReason = "autostart"
+	BuildReasonAutostop   BuildReason = "autostop"
+	BuildReasonAutodelete BuildReason = "autodelete"
 )
 
 func (e *BuildReason) Scan(src interface{}) error {
@@ -97,6 +102,46 @@ func (e *BuildReason) Scan(src interface{}) error {
 	return nil
 }
 
+type FileStorage string
+
+const (
+	FileStorageDatabase FileStorage = "database"
+	FileStorageLocal    FileStorage = "local"
+	FileStorageS3       FileStorage = "s3"
+)
+
+func (e *FileStorage) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = FileStorage(s)
+	case string:
+		*e = FileStorage(s)
+	default:
+		return fmt.Errorf("unsupported scan type for FileStorage: %T", src)
+	}
+	return nil
+}
+
+type GroupSource string
+
+const (
+	GroupSourceUser GroupSource = "user"
+	GroupSourceOIDC GroupSource = "oidc"
+	GroupSourceSCIM GroupSource = "scim"
+)
+
+func (e *GroupSource) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = GroupSource(s)
+	case string:
+		*e = GroupSource(s)
+	default:
+		return fmt.Errorf("unsupported scan type for GroupSource: %T", src)
+	}
+	return nil
+}
+
 type LogLevel string
 
 const (
@@ -237,6 +282,25 @@ func (e *ParameterTypeSystem) Scan(src interface{}) error {
 	return nil
 }
 
+type PortShareProtocol string
+
+const (
+	PortShareProtocolTCP PortShareProtocol = "tcp"
+	PortShareProtocolUDP PortShareProtocol = "udp"
+)
+
+func (e *PortShareProtocol) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = PortShareProtocol(s)
+	case string:
+		*e = PortShareProtocol(s)
+	default:
+		return fmt.Errorf("unsupported scan type for PortShareProtocol: %T", src)
+	}
+	return nil
+}
+
 type ProvisionerJobType string
 
 const (
@@ -297,15 +361,19 @@ func (e *ProvisionerType) Scan(src interface{}) error {
 type ResourceType string
 
 const (
-	ResourceTypeOrganization    ResourceType = "organization"
-	ResourceTypeTemplate        ResourceType = "template"
-	ResourceTypeTemplateVersion ResourceType = "template_version"
-	ResourceTypeUser            ResourceType = "user"
-	ResourceTypeWorkspace       ResourceType = "workspace"
-	ResourceTypeGitSshKey       ResourceType = "git_ssh_key"
-	ResourceTypeApiKey          ResourceType = "api_key"
-	ResourceTypeGroup           ResourceType = "group"
-	ResourceTypeWorkspaceBuild  ResourceType = "workspace_build"
+	ResourceTypeOrganization              ResourceType = "organization"
+	ResourceTypeTemplate                  ResourceType = "template"
+	ResourceTypeTemplateVersion           ResourceType = "template_version"
+	ResourceTypeUser                      ResourceType = "user"
+	ResourceTypeWorkspace                 ResourceType = "workspace"
+	ResourceTypeGitSshKey                 ResourceType = "git_ssh_key"
+	ResourceTypeApiKey                    ResourceType = "api_key"
+	ResourceTypeGroup                     ResourceType = "group"
+	ResourceTypeWorkspaceBuild            ResourceType = "workspace_build"
+	ResourceTypeWorkspaceSessionRecording ResourceType = "workspace_session_recording"
+	ResourceTypeCustomRole                ResourceType = "custom_role"
+	ResourceTypeOrganizationMember        ResourceType = "organization_member"
+	ResourceTypeWebhook                   ResourceType = "webhook"
 )
 
 func (e *ResourceType) Scan(src interface{}) error {
@@ -320,6 +388,25 @@ func (e *ResourceType) Scan(src interface{}) error {
 	return nil
 }
 
+type SessionRecordingType string
+
+const (
+	SessionRecordingTypeSSH             SessionRecordingType = "ssh"
+	SessionRecordingTypeReconnectingPTY SessionRecordingType = "reconnecting_pty"
+)
+
+func (e *SessionRecordingType) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = SessionRecordingType(s)
+	case string:
+		*e = SessionRecordingType(s)
+	default:
+		return fmt.Errorf("unsupported scan type for SessionRecordingType: %T", src)
+	}
+	return nil
+}
+
 type UserStatus string
 
 const (
@@ -339,6 +426,52 @@ func (e *UserStatus) Scan(src interface{}) error {
 	return nil
 }
 
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryStatusPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliveryStatusDelivered WebhookDeliveryStatus = "delivered"
+	WebhookDeliveryStatusFailed    WebhookDeliveryStatus = "failed"
+)
+
+func (e *WebhookDeliveryStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = WebhookDeliveryStatus(s)
+	case string:
+		*e = WebhookDeliveryStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for WebhookDeliveryStatus: %T", src)
+	}
+	return nil
+}
+
+type WorkspaceAgentLifecycleState string
+
+const (
+	WorkspaceAgentLifecycleStateCreated         WorkspaceAgentLifecycleState = "created"
+	WorkspaceAgentLifecycleStateStarting        WorkspaceAgentLifecycleState = "starting"
+	WorkspaceAgentLifecycleStateStartTimeout    WorkspaceAgentLifecycleState = "start_timeout"
+	WorkspaceAgentLifecycleStateStartError      WorkspaceAgentLifecycleState = "start_error"
+	WorkspaceAgentLifecycleStateReady           WorkspaceAgentLifecycleState = "ready"
+	WorkspaceAgentLifecycleStateShuttingDown    WorkspaceAgentLifecycleState = "shutting_down"
+	WorkspaceAgentLifecycleStateShutdownTimeout WorkspaceAgentLifecycleState = "shutdown_timeout"
+	WorkspaceAgentLifecycleStateShutdownError   WorkspaceAgentLifecycleState = "shutdown_error"
+	WorkspaceAgentLifecycleStateOff             WorkspaceAgentLifecycleState = "off"
+)
+
+func (e *WorkspaceAgentLifecycleState) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = WorkspaceAgentLifecycleState(s)
+	case string:
+		*e = WorkspaceAgentLifecycleState(s)
+	default:
+		return fmt.Errorf("unsupported scan type for WorkspaceAgentLifecycleState: %T", src)
+	}
+	return nil
+}
+
 type WorkspaceAppHealth string
 
 const (
@@ -393,6 +526,10 @@ type APIKey struct {
 	LifetimeSeconds int64       `db:"lifetime_seconds" json:"lifetime_seconds"`
 	IPAddress       pqtype.Inet `db:"ip_address" json:"ip_address"`
 	Scope           APIKeyScope `db:"scope" json:"scope"`
+	// The name of a token, unique per user. Empty for other API keys.
+	TokenName string `db:"token_name" json:"token_name"`
+	// Resources the scope of the key is limited to, formatted as "type:id". The id is "*" for every resource of the type.
+	AllowList []string `db:"allow_list" json:"allow_list"`
 }
 
 type AgentStat struct {
@@ -403,6 +540,8 @@ type AgentStat struct {
 	WorkspaceID uuid.UUID       `db:"workspace_id" json:"workspace_id"`
 	TemplateID  uuid.UUID       `db:"template_id" json:"template_id"`
 	Payload     json.RawMessage `db:"payload" json:"payload"`
+	// Whether the agent's connection stats changed since its previous report. Reports where only resource usage changed don't count as workspace activity.
+	ConnectionActivity bool `db:"connection_activity" json:"connection_activity"`
 }
 
 type AuditLog struct {
@@ -423,13 +562,35 @@ type AuditLog struct {
 	ResourceIcon     string          `db:"resource_icon" json:"resource_icon"`
 }
 
+// Roles defined by admins in addition to the builtin roles.
+type CustomRole struct {
+	ID          uuid.UUID `db:"id" json:"id"`
+	Name        string    `db:"name" json:"name"`
+	DisplayName string    `db:"display_name" json:"display_name"`
+	// The organization an organization role is scoped to. Null for site wide roles.
+	OrganizationID  uuid.NullUUID         `db:"organization_id" json:"organization_id"`
+	SitePermissions CustomRolePermissions `db:"site_permissions" json:"site_permissions"`
+	// Permissions granted in the organization of the role. Always empty for site wide roles.
+	OrgPermissions  CustomRolePermissions `db:"org_permissions" json:"org_permissions"`
+	UserPermissions CustomRolePermissions `db:"user_permissions" json:"user_permissions"`
+	CreatedAt       time.Time             `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time             `db:"updated_at" json:"updated_at"`
+}
+
 type File struct {
 	Hash      string    `db:"hash" json:"hash"`
 	CreatedAt time.Time `db:"created_at" json:"created_at"`
 	CreatedBy uuid.UUID `db:"created_by" json:"created_by"`
 	Mimetype  string    `db:"mimetype" json:"mimetype"`
-	Data      []byte    `db:"data" json:"data"`
 	ID        uuid.UUID `db:"id" json:"id"`
+	// The storage backend holding the contents of the file.
+	Storage FileStorage `db:"storage" json:"storage"`
+}
+
+// Contents of files stored in the database, keyed by hash.
+type FileData struct {
+	Hash string `db:"hash" json:"hash"`
+	Data []byte `db:"data" json:"data"`
 }
 
 type GitAuthLink struct {
@@ -456,6 +617,8 @@ type Group struct {
 	OrganizationID uuid.UUID `db:"organization_id" json:"organization_id"`
 	AvatarURL      string    `db:"avatar_url" json:"avatar_url"`
 	QuotaAllowance int32     `db:"quota_allowance" json:"quota_allowance"`
+	// Where the group was created. OIDC group sync only manages the membership of groups it created.
+	Source GroupSource `db:"source" json:"source"`
 }
 
 type GroupMember struct {
@@ -528,6 +691,8 @@ type ProvisionerDaemon struct {
 	Provisioners []ProvisionerType `db:"provisioners" json:"provisioners"`
 	ReplicaID    uuid.NullUUID     `db:"replica_id" json:"replica_id"`
 	Tags         dbtype.StringMap  `db:"tags" json:"tags"`
+	// The organization the daemon acquires jobs from. Null for daemons that acquire jobs from every organization.
+	OrganizationID uuid.NullUUID `db:"organization_id" json:"organization_id"`
 }
 
 type ProvisionerJob struct {
@@ -596,6 +761,55 @@ type Template struct {
 	GroupACL   TemplateACL `db:"group_acl" json:"group_acl"`
 	// Display name is a custom, human-friendly template name that user can set.
 	DisplayName string `db:"display_name" json:"display_name"`
+	// The duration a workspace may go unused before it is scheduled for deletion. Zero disables it.
+	InactivityTTL int64 `db:"inactivity_ttl" json:"inactivity_ttl"`
+	// The duration a workspace may remain stopped before it is scheduled for deletion. Zero disables it.
+	StoppedTTL int64 `db:"stopped_ttl" json:"stopped_ttl"`
+	// The maximum duration a workspace created from this template may run for before it is stopped. Zero means there is no maximum.
+	MaxTTL int64 `db:"max_ttl" json:"max_ttl"`
+	// A bitmask of the days of the week autostart is allowed on, with Sunday as the least significant bit.
+	AutostartDaysOfWeek int16 `db:"autostart_days_of_week" json:"autostart_days_of_week"`
+	// The first hour of the day autostart is allowed in, in the timezone of the autostart schedule.
+	AutostartStartHour int16 `db:"autostart_start_hour" json:"autostart_start_hour"`
+	// The hour of the day autostart is no longer allowed from, in the timezone of the autostart schedule.
+	AutostartEndHour int16 `db:"autostart_end_hour" json:"autostart_end_hour"`
+	// A cron schedule for the start of quiet hours, during which workspaces are stopped and not autostarted. Empty disables quiet hours.
+	QuietHoursSchedule string `db:"quiet_hours_schedule" json:"quiet_hours_schedule"`
+	// The duration of quiet hours.
+	QuietHoursDuration int64 `db:"quiet_hours_duration" json:"quiet_hours_duration"`
+	// Whether terminal sessions in workspaces created from the template are recorded.
+	SessionRecording bool `db:"session_recording" json:"session_recording"`
+	// Whether stopped workspaces created from the template are started when connected to.
+	AutoStartOnConnect bool `db:"auto_start_on_connect" json:"auto_start_on_connect"`
+	// The highest sharing level ports in workspaces created from the template may be shared with. Shares above it are treated as this level.
+	MaxPortSharingLevel AppSharingLevel `db:"max_port_sharing_level" json:"max_port_sharing_level"`
+	// How long a dormant workspace is marked for deletion before it is deleted.
+	AutodeleteWarningTTL int64 `db:"autodelete_warning_ttl" json:"autodelete_warning_ttl"`
+}
+
+// Hourly number of requests each user made to the apps of each template.
+type TemplateAppUsageStat struct {
+	StartTime  time.Time `db:"start_time" json:"start_time"`
+	TemplateID uuid.UUID `db:"template_id" json:"template_id"`
+	UserID     uuid.UUID `db:"user_id" json:"user_id"`
+	Slug       string    `db:"slug" json:"slug"`
+	Requests   int64     `db:"requests" json:"requests"`
+}
+
+// Hourly usage of the workspaces of each user and template, rolled up from agent_stats and workspace_builds so it outlives the raw stats.
+type TemplateUsageStat struct {
+	StartTime  time.Time `db:"start_time" json:"start_time"`
+	TemplateID uuid.UUID `db:"template_id" json:"template_id"`
+	UserID     uuid.UUID `db:"user_id" json:"user_id"`
+	// Whether any of the user's workspaces had connection activity in the hour.
+	Active                         bool  `db:"active" json:"active"`
+	ConnectionCount                int64 `db:"connection_count" json:"connection_count"`
+	SshConnectionCount             int64 `db:"ssh_connection_count" json:"ssh_connection_count"`
+	ReconnectingPtyConnectionCount int64 `db:"reconnecting_pty_connection_count" json:"reconnecting_pty_connection_count"`
+	VscodeConnectionCount          int64 `db:"vscode_connection_count" json:"vscode_connection_count"`
+	JetbrainsConnectionCount       int64 `db:"jetbrains_connection_count" json:"jetbrains_connection_count"`
+	// The share of the daily cost of the user's workspaces for the hour.
+	Cost float64 `db:"cost" json:"cost"`
 }
 
 type TemplateVersion struct {
@@ -610,6 +824,30 @@ type TemplateVersion struct {
 	CreatedBy      uuid.UUID     `db:"created_by" json:"created_by"`
 }
 
+// Rich parameters declared by a template version with "coder_parameter" data sources.
+type TemplateVersionParameter struct {
+	TemplateVersionID uuid.UUID `db:"template_version_id" json:"template_version_id"`
+	Name              string    `db:"name" json:"name"`
+	Description       string    `db:"description" json:"description"`
+	// Type of the parameter: string, number, bool or list(string).
+	Type string `db:"type" json:"type"`
+	// Whether the value can be changed after the workspace is created.
+	Mutable      bool   `db:"mutable" json:"mutable"`
+	DefaultValue string `db:"default_value" json:"default_value"`
+	Icon         string `db:"icon" json:"icon"`
+	// Options the value must be one of, if any.
+	Options json.RawMessage `db:"options" json:"options"`
+	// Regular expression string values must match. Empty disables the check.
+	ValidationRegex string `db:"validation_regex" json:"validation_regex"`
+	// Minimum value of number parameters, if any.
+	ValidationMin sql.NullInt32 `db:"validation_min" json:"validation_min"`
+	// Maximum value of number parameters, if any.
+	ValidationMax   sql.NullInt32 `db:"validation_max" json:"validation_max"`
+	ValidationError string        `db:"validation_error" json:"validation_error"`
+	// Position of the parameter in the template, used to order prompts.
+	Index int32 `db:"index" json:"index"`
+}
+
 type User struct {
 	ID             uuid.UUID      `db:"id" json:"id"`
 	Email          string         `db:"email" json:"email"`
@@ -623,6 +861,8 @@ type User struct {
 	AvatarURL      sql.NullString `db:"avatar_url" json:"avatar_url"`
 	Deleted        bool           `db:"deleted" json:"deleted"`
 	LastSeenAt     time.Time      `db:"last_seen_at" json:"last_seen_at"`
+	// Whether the user's stopped workspaces are started when connected to.
+	AutoStartOnConnect bool `db:"auto_start_on_connect" json:"auto_start_on_connect"`
 }
 
 type UserLink struct {
@@ -634,6 +874,35 @@ type UserLink struct {
 	OAuthExpiry       time.Time `db:"oauth_expiry" json:"oauth_expiry"`
 }
 
+type Webhook struct {
+	ID             uuid.UUID `db:"id" json:"id"`
+	OrganizationID uuid.UUID `db:"organization_id" json:"organization_id"`
+	Name           string    `db:"name" json:"name"`
+	Url            string    `db:"url" json:"url"`
+	// The secret used to sign delivered payloads with HMAC-SHA256.
+	Secret string `db:"secret" json:"secret"`
+	// The event types delivered to the webhook. Empty means all event types.
+	Events    []string  `db:"events" json:"events"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// Deliveries of events to webhooks. Failed deliveries are kept as a dead-letter record.
+type WebhookDelivery struct {
+	ID        uuid.UUID `db:"id" json:"id"`
+	WebhookID uuid.UUID `db:"webhook_id" json:"webhook_id"`
+	// Identifies the event across replicas, so each event is delivered to a webhook at most once.
+	EventID        uuid.UUID             `db:"event_id" json:"event_id"`
+	EventType      string                `db:"event_type" json:"event_type"`
+	Payload        json.RawMessage       `db:"payload" json:"payload"`
+	Status         WebhookDeliveryStatus `db:"status" json:"status"`
+	Attempts       int32                 `db:"attempts" json:"attempts"`
+	LastStatusCode int32                 `db:"last_status_code" json:"last_status_code"`
+	LastError      string                `db:"last_error" json:"last_error"`
+	CreatedAt      time.Time             `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time             `db:"updated_at" json:"updated_at"`
+}
+
 type Workspace struct {
 	ID                uuid.UUID      `db:"id" json:"id"`
 	CreatedAt         time.Time      `db:"created_at" json:"created_at"`
@@ -646,6 +915,8 @@ type Workspace struct {
 	AutostartSchedule sql.NullString `db:"autostart_schedule" json:"autostart_schedule"`
 	Ttl               sql.NullInt64  `db:"ttl" json:"ttl"`
 	LastUsedAt        time.Time      `db:"last_used_at" json:"last_used_at"`
+	// The time at which a dormant workspace will be automatically deleted. Null when the workspace is not scheduled for deletion.
+	DeletingAt sql.NullTime `db:"deleting_at" json:"deleting_at"`
 }
 
 type WorkspaceAgent struct {
@@ -673,6 +944,54 @@ type WorkspaceAgent struct {
 	ConnectionTimeoutSeconds int32 `db:"connection_timeout_seconds" json:"connection_timeout_seconds"`
 	// URL for troubleshooting the agent.
 	TroubleshootingURL string `db:"troubleshooting_url" json:"troubleshooting_url"`
+	// Indicates that the startup script has exited and no more startup logs are expected.
+	StartupLogsEOF bool `db:"startup_logs_eof" json:"startup_logs_eof"`
+	// The current lifecycle state reported by the workspace agent.
+	LifecycleState WorkspaceAgentLifecycleState `db:"lifecycle_state" json:"lifecycle_state"`
+	// The number of seconds to wait for the startup script to complete, 0 means disabled.
+	StartupScriptTimeoutSeconds int32 `db:"startup_script_timeout_seconds" json:"startup_script_timeout_seconds"`
+	// Script that is executed before the agent is stopped.
+	ShutdownScript sql.NullString `db:"shutdown_script" json:"shutdown_script"`
+	// The number of seconds to wait for the shutdown script to complete, 0 means disabled.
+	ShutdownScriptTimeoutSeconds int32 `db:"shutdown_script_timeout_seconds" json:"shutdown_script_timeout_seconds"`
+	// Total length of startup logs
+	StartupLogsLength int32 `db:"startup_logs_length" json:"startup_logs_length"`
+	// Whether the startup logs overflowed in length
+	StartupLogsOverflowed bool `db:"startup_logs_overflowed" json:"startup_logs_overflowed"`
+}
+
+type WorkspaceAgentMetadatum struct {
+	WorkspaceAgentID uuid.UUID `db:"workspace_agent_id" json:"workspace_agent_id"`
+	DisplayName      string    `db:"display_name" json:"display_name"`
+	Key              string    `db:"key" json:"key"`
+	Script           string    `db:"script" json:"script"`
+	Value            string    `db:"value" json:"value"`
+	Error            string    `db:"error" json:"error"`
+	// The number of seconds the script is allowed to run before it is killed.
+	Timeout int64 `db:"timeout" json:"timeout"`
+	// The number of seconds to wait between runs of the script.
+	Interval int64 `db:"interval" json:"interval"`
+	// The time the agent collected the current value. The zero time indicates no value has been collected yet.
+	CollectedAt time.Time `db:"collected_at" json:"collected_at"`
+}
+
+// Ports in workspaces shared with other users. Shares are keyed by agent name so they survive workspace builds.
+type WorkspaceAgentPortShare struct {
+	WorkspaceID  uuid.UUID       `db:"workspace_id" json:"workspace_id"`
+	AgentName    string          `db:"agent_name" json:"agent_name"`
+	Port         int32           `db:"port" json:"port"`
+	SharingLevel AppSharingLevel `db:"sharing_level" json:"sharing_level"`
+	CreatedAt    time.Time       `db:"created_at" json:"created_at"`
+	UpdatedAt    time.Time       `db:"updated_at" json:"updated_at"`
+	// TCP ports are shared through the subdomain proxy, and UDP ports are relayed through the API.
+	Protocol PortShareProtocol `db:"protocol" json:"protocol"`
+}
+
+type WorkspaceAgentStartupLog struct {
+	AgentID   uuid.UUID `db:"agent_id" json:"agent_id"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	Output    string    `db:"output" json:"output"`
+	ID        int64     `db:"id" json:"id"`
 }
 
 type WorkspaceApp struct {
@@ -708,6 +1027,13 @@ type WorkspaceBuild struct {
 	DailyCost         int32               `db:"daily_cost" json:"daily_cost"`
 }
 
+// Values of rich parameters used for a workspace build.
+type WorkspaceBuildParameter struct {
+	WorkspaceBuildID uuid.UUID `db:"workspace_build_id" json:"workspace_build_id"`
+	Name             string    `db:"name" json:"name"`
+	Value            string    `db:"value" json:"value"`
+}
+
 type WorkspaceResource struct {
 	ID           uuid.UUID           `db:"id" json:"id"`
 	CreatedAt    time.Time           `db:"created_at" json:"created_at"`
@@ -727,3 +1053,20 @@ type WorkspaceResourceMetadatum struct {
 	Value               sql.NullString `db:"value" json:"value"`
 	Sensitive           bool           `db:"sensitive" json:"sensitive"`
 }
+
+// Recordings of terminal sessions in workspaces, saved in asciicast v2 format.
+type WorkspaceSessionRecording struct {
+	ID             uuid.UUID `db:"id" json:"id"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+	OrganizationID uuid.UUID `db:"organization_id" json:"organization_id"`
+	WorkspaceID    uuid.UUID `db:"workspace_id" json:"workspace_id"`
+	AgentID        uuid.UUID `db:"agent_id" json:"agent_id"`
+	// The owner of the workspace. Connections to agents are authorized for the workspace, so sessions are attributed to its owner.
+	OwnerID uuid.UUID            `db:"owner_id" json:"owner_id"`
+	Type    SessionRecordingType `db:"type" json:"type"`
+	// The command the session ran. Empty when the session ran the login shell.
+	Command   string    `db:"command" json:"command"`
+	StartedAt time.Time `db:"started_at" json:"started_at"`
+	EndedAt   time.Time `db:"ended_at" json:"ended_at"`
+	FileID    uuid.UUID `db:"file_id" json:"file_id"`
+}
@@ -26,7 +26,7 @@ func (q *sqlQuerier) DeleteOldAgentStats(ctx context.Context) error {
 }
 
 const getLatestAgentStat = `-- name: GetLatestAgentStat :one
-SELECT id, created_at, user_id, agent_id, workspace_id, template_id, payload FROM agent_stats WHERE agent_id = $1 ORDER BY created_at DESC LIMIT 1
+SELECT id, created_at, user_id, agent_id, workspace_id, template_id, payload, connection_activity FROM agent_stats WHERE agent_id = $1 ORDER BY created_at DESC LIMIT 1
 `
 
 func (q *sqlQuerier) GetLatestAgentStat(ctx context.Context, agentID uuid.UUID) (AgentStat, error) {
@@ -40,17 +40,83 @@ func (q *sqlQuerier) GetLatestAgentStat(ctx context.Context, agentID uuid.UUID)
 		&i.WorkspaceID,
 		&i.TemplateID,
 		&i.Payload,
+		&i.ConnectionActivity,
 	)
 	return i, err
 }
 
+const getLatestAgentStats = `-- name: GetLatestAgentStats :many
+SELECT
+	DISTINCT ON (agent_stats.agent_id)
+	agent_stats.agent_id,
+	agent_stats.created_at,
+	agent_stats.payload,
+	users.username,
+	workspaces.name AS workspace_name,
+	workspace_agents.name AS agent_name
+FROM
+	agent_stats
+JOIN
+	users ON users.id = agent_stats.user_id
+JOIN
+	workspaces ON workspaces.id = agent_stats.workspace_id
+JOIN
+	workspace_agents ON workspace_agents.id = agent_stats.agent_id
+WHERE
+	agent_stats.created_at > $1
+	AND workspaces.deleted = false
+ORDER BY
+	agent_stats.agent_id, agent_stats.created_at DESC
+`
+
+type GetLatestAgentStatsRow struct {
+	AgentID       uuid.UUID       `db:"agent_id" json:"agent_id"`
+	CreatedAt     time.Time       `db:"created_at" json:"created_at"`
+	Payload       json.RawMessage `db:"payload" json:"payload"`
+	Username      string          `db:"username" json:"username"`
+	WorkspaceName string          `db:"workspace_name" json:"workspace_name"`
+	AgentName     string          `db:"agent_name" json:"agent_name"`
+}
+
+// Returns the latest stats of every agent that reported after a time, with
+// the names of its workspace and owner.
+func (q *sqlQuerier) GetLatestAgentStats(ctx context.Context, createdAfter time.Time) ([]GetLatestAgentStatsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getLatestAgentStats, createdAfter)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetLatestAgentStatsRow
+	for rows.Next() {
+		var i GetLatestAgentStatsRow
+		if err := rows.Scan(
+			&i.AgentID,
+			&i.CreatedAt,
+			&i.Payload,
+			&i.Username,
+			&i.WorkspaceName,
+			&i.AgentName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getTemplateDAUs = `-- name: GetTemplateDAUs :many
 select
 	(created_at at TIME ZONE 'UTC')::date as date,
 	user_id
 from
 	agent_stats
-where template_id = $1
+where template_id = $1 AND connection_activity
 group by
 	date, user_id
 order by
@@ -94,20 +160,22 @@ INSERT INTO
 		workspace_id,
 		template_id,
 		agent_id,
-		payload
+		payload,
+		connection_activity
 	)
 VALUES
-	($1, $2, $3, $4, $5, $6, $7) RETURNING id, created_at, user_id, agent_id, workspace_id, template_id, payload
+	($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id, created_at, user_id, agent_id, workspace_id, template_id, payload, connection_activity
 `
 
 type InsertAgentStatParams struct {
-	ID          uuid.UUID       `db:"id" json:"id"`
-	CreatedAt   time.Time       `db:"created_at" json:"created_at"`
-	UserID      uuid.UUID       `db:"user_id" json:"user_id"`
-	WorkspaceID uuid.UUID       `db:"workspace_id" json:"workspace_id"`
-	TemplateID  uuid.UUID       `db:"template_id" json:"template_id"`
-	AgentID     uuid.UUID       `db:"agent_id" json:"agent_id"`
-	Payload     json.RawMessage `db:"payload" json:"payload"`
+	ID                 uuid.UUID       `db:"id" json:"id"`
+	CreatedAt          time.Time       `db:"created_at" json:"created_at"`
+	UserID             uuid.UUID       `db:"user_id" json:"user_id"`
+	WorkspaceID        uuid.UUID       `db:"workspace_id" json:"workspace_id"`
+	TemplateID         uuid.UUID       `db:"template_id" json:"template_id"`
+	AgentID            uuid.UUID       `db:"agent_id" json:"agent_id"`
+	Payload            json.RawMessage `db:"payload" json:"payload"`
+	ConnectionActivity bool            `db:"connection_activity" json:"connection_activity"`
 }
 
 func (q *sqlQuerier) InsertAgentStat(ctx context.Context, arg InsertAgentStatParams) (AgentStat, error) {
@@ -119,6 +187,7 @@ func (q *sqlQuerier) InsertAgentStat(ctx context.Context, arg InsertAgentStatPar
 		arg.TemplateID,
 		arg.AgentID,
 		arg.Payload,
+		arg.ConnectionActivity,
 	)
 	var i AgentStat
 	err := row.Scan(
@@ -129,6 +198,7 @@ func (q *sqlQuerier) InsertAgentStat(ctx context.Context, arg InsertAgentStatPar
 		&i.WorkspaceID,
 		&i.TemplateID,
 		&i.Payload,
+		&i.ConnectionActivity,
 	)
 	return i, err
 }
@@ -160,7 +230,7 @@ func (q *sqlQuerier) DeleteAPIKeysByUserID(ctx context.Context, userID uuid.UUID
 
 const getAPIKeyByID = `-- name: GetAPIKeyByID :one
 SELECT
-	id, hashed_secret, user_id, last_used, expires_at, created_at, updated_at, login_type, lifetime_seconds, ip_address, scope
+	id, hashed_secret, user_id, last_used, expires_at, created_at, updated_at, login_type, lifetime_seconds, ip_address, scope, token_name, allow_list
 FROM
 	api_keys
 WHERE
@@ -184,12 +254,53 @@ func (q *sqlQuerier) GetAPIKeyByID(ctx context.Context, id string) (APIKey, erro
 		&i.LifetimeSeconds,
 		&i.IPAddress,
 		&i.Scope,
+		&i.TokenName,
+		pq.Array(&i.AllowList),
+	)
+	return i, err
+}
+
+const getAPIKeyByName = `-- name: GetAPIKeyByName :one
+SELECT
+	id, hashed_secret, user_id, last_used, expires_at, created_at, updated_at, login_type, lifetime_seconds, ip_address, scope, token_name, allow_list
+FROM
+	api_keys
+WHERE
+	user_id = $1 AND
+	token_name = $2 AND
+	login_type = 'token'
+LIMIT
+	1
+`
+
+type GetAPIKeyByNameParams struct {
+	UserID    uuid.UUID `db:"user_id" json:"user_id"`
+	TokenName string    `db:"token_name" json:"token_name"`
+}
+
+func (q *sqlQuerier) GetAPIKeyByName(ctx context.Context, arg GetAPIKeyByNameParams) (APIKey, error) {
+	row := q.db.QueryRowContext(ctx, getAPIKeyByName, arg.UserID, arg.TokenName)
+	var i APIKey
+	err := row.Scan(
+		&i.ID,
+		&i.HashedSecret,
+		&i.UserID,
+		&i.LastUsed,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.LoginType,
+		&i.LifetimeSeconds,
+		&i.IPAddress,
+		&i.Scope,
+		&i.TokenName,
+		pq.Array(&i.AllowList),
 	)
 	return i, err
 }
 
 const getAPIKeysByLoginType = `-- name: GetAPIKeysByLoginType :many
-SELECT id, hashed_secret, user_id, last_used, expires_at, created_at, updated_at, login_type, lifetime_seconds, ip_address, scope FROM api_keys WHERE login_type = $1
+SELECT id, hashed_secret, user_id, last_used, expires_at, created_at, updated_at, login_type, lifetime_seconds, ip_address, scope, token_name, allow_list FROM api_keys WHERE login_type = $1
 `
 
 func (q *sqlQuerier) GetAPIKeysByLoginType(ctx context.Context, loginType LoginType) ([]APIKey, error) {
@@ -213,6 +324,54 @@ func (q *sqlQuerier) GetAPIKeysByLoginType(ctx context.Context, loginType LoginT
 			&i.LifetimeSeconds,
 			&i.IPAddress,
 			&i.Scope,
+			&i.TokenName,
+			pq.Array(&i.AllowList),
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAPIKeysByUserID = `-- name: GetAPIKeysByUserID :many
+SELECT id, hashed_secret, user_id, last_used, expires_at, created_at, updated_at, login_type, lifetime_seconds, ip_address, scope, token_name, allow_list FROM api_keys WHERE login_type = $1 AND user_id = $2
+`
+
+type GetAPIKeysByUserIDParams struct {
+	LoginType LoginType `db:"login_type" json:"login_type"`
+	UserID    uuid.UUID `db:"user_id" json:"user_id"`
+}
+
+func (q *sqlQuerier) GetAPIKeysByUserID(ctx context.Context, arg GetAPIKeysByUserIDParams) ([]APIKey, error) {
+	rows, err := q.db.QueryContext(ctx, getAPIKeysByUserID, arg.LoginType, arg.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []APIKey
+	for rows.Next() {
+		var i APIKey
+		if err := rows.Scan(
+			&i.ID,
+			&i.HashedSecret,
+			&i.UserID,
+			&i.LastUsed,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.LoginType,
+			&i.LifetimeSeconds,
+			&i.IPAddress,
+			&i.Scope,
+			&i.TokenName,
+			pq.Array(&i.AllowList),
 		); err != nil {
 			return nil, err
 		}
@@ -228,7 +387,7 @@ func (q *sqlQuerier) GetAPIKeysByLoginType(ctx context.Context, loginType LoginT
 }
 
 const getAPIKeysLastUsedAfter = `-- name: GetAPIKeysLastUsedAfter :many
-SELECT id, hashed_secret, user_id, last_used, expires_at, created_at, updated_at, login_type, lifetime_seconds, ip_address, scope FROM api_keys WHERE last_used > $1
+SELECT id, hashed_secret, user_id, last_used, expires_at, created_at, updated_at, login_type, lifetime_seconds, ip_address, scope, token_name, allow_list FROM api_keys WHERE last_used > $1
 `
 
 func (q *sqlQuerier) GetAPIKeysLastUsedAfter(ctx context.Context, lastUsed time.Time) ([]APIKey, error) {
@@ -252,6 +411,8 @@ func (q *sqlQuerier) GetAPIKeysLastUsedAfter(ctx context.Context, lastUsed time.
 			&i.LifetimeSeconds,
 			&i.IPAddress,
 			&i.Scope,
+			&i.TokenName,
+			pq.Array(&i.AllowList),
 		); err != nil {
 			return nil, err
 		}
@@ -279,7 +440,9 @@ INSERT INTO
 		created_at,
 		updated_at,
 		login_type,
-		scope
+		scope,
+		token_name,
+		allow_list
 	)
 VALUES
 	($1,
@@ -288,7 +451,7 @@ VALUES
 	     WHEN 0 THEN 86400
 		 ELSE $2::bigint
 	 END
-	 , $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id, hashed_secret, user_id, last_used, expires_at, created_at, updated_at, login_type, lifetime_seconds, ip_address, scope
+	 , $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13) RETURNING id, hashed_secret, user_id, last_used, expires_at, created_at, updated_at, login_type, lifetime_seconds, ip_address, scope, token_name, allow_list
 `
 
 type InsertAPIKeyParams struct {
@@ -303,6 +466,8 @@ type InsertAPIKeyParams struct {
 	UpdatedAt       time.Time   `db:"updated_at" json:"updated_at"`
 	LoginType       LoginType   `db:"login_type" json:"login_type"`
 	Scope           APIKeyScope `db:"scope" json:"scope"`
+	TokenName       string      `db:"token_name" json:"token_name"`
+	AllowList       []string    `db:"allow_list" json:"allow_list"`
 }
 
 func (q *sqlQuerier) InsertAPIKey(ctx context.Context, arg InsertAPIKeyParams) (APIKey, error) {
@@ -318,6 +483,8 @@ func (q *sqlQuerier) InsertAPIKey(ctx context.Context, arg InsertAPIKeyParams) (
 		arg.UpdatedAt,
 		arg.LoginType,
 		arg.Scope,
+		arg.TokenName,
+		pq.Array(arg.AllowList),
 	)
 	var i APIKey
 	err := row.Scan(
@@ -332,6 +499,8 @@ func (q *sqlQuerier) InsertAPIKey(ctx context.Context, arg InsertAPIKeyParams) (
 		&i.LifetimeSeconds,
 		&i.IPAddress,
 		&i.Scope,
+		&i.TokenName,
+		pq.Array(&i.AllowList),
 	)
 	return i, err
 }
@@ -692,131 +861,556 @@ func (q *sqlQuerier) InsertAuditLog(ctx context.Context, arg InsertAuditLogParam
 	return i, err
 }
 
-const getFileByHashAndCreator = `-- name: GetFileByHashAndCreator :one
+const deleteCustomRole = `-- name: DeleteCustomRole :exec
+DELETE FROM
+	custom_roles
+WHERE
+	id = $1
+`
+
+func (q *sqlQuerier) DeleteCustomRole(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteCustomRole, id)
+	return err
+}
+
+const getCustomRoleByName = `-- name: GetCustomRoleByName :one
 SELECT
-	hash, created_at, created_by, mimetype, data, id
+	id, name, display_name, organization_id, site_permissions, org_permissions, user_permissions, created_at, updated_at
 FROM
-	files
+	custom_roles
 WHERE
-	hash = $1
+	name = $1
 AND
-	created_by = $2
+	organization_id IS NOT DISTINCT FROM $2
 LIMIT
 	1
 `
 
-type GetFileByHashAndCreatorParams struct {
-	Hash      string    `db:"hash" json:"hash"`
-	CreatedBy uuid.UUID `db:"created_by" json:"created_by"`
+type GetCustomRoleByNameParams struct {
+	Name           string        `db:"name" json:"name"`
+	OrganizationID uuid.NullUUID `db:"organization_id" json:"organization_id"`
 }
 
-func (q *sqlQuerier) GetFileByHashAndCreator(ctx context.Context, arg GetFileByHashAndCreatorParams) (File, error) {
-	row := q.db.QueryRowContext(ctx, getFileByHashAndCreator, arg.Hash, arg.CreatedBy)
-	var i File
+func (q *sqlQuerier) GetCustomRoleByName(ctx context.Context, arg GetCustomRoleByNameParams) (CustomRole, error) {
+	row := q.db.QueryRowContext(ctx, getCustomRoleByName, arg.Name, arg.OrganizationID)
+	var i CustomRole
 	err := row.Scan(
-		&i.Hash,
-		&i.CreatedAt,
-		&i.CreatedBy,
-		&i.Mimetype,
-		&i.Data,
 		&i.ID,
+		&i.Name,
+		&i.DisplayName,
+		&i.OrganizationID,
+		&i.SitePermissions,
+		&i.OrgPermissions,
+		&i.UserPermissions,
+		&i.CreatedAt,
+		&i.UpdatedAt,
 	)
 	return i, err
 }
 
-const getFileByID = `-- name: GetFileByID :one
+const getCustomRoles = `-- name: GetCustomRoles :many
 SELECT
-	hash, created_at, created_by, mimetype, data, id
+	id, name, display_name, organization_id, site_permissions, org_permissions, user_permissions, created_at, updated_at
 FROM
-	files
-WHERE
-	id = $1
-LIMIT
-	1
+	custom_roles
+ORDER BY
+	organization_id NULLS FIRST, name
 `
 
-func (q *sqlQuerier) GetFileByID(ctx context.Context, id uuid.UUID) (File, error) {
-	row := q.db.QueryRowContext(ctx, getFileByID, id)
-	var i File
-	err := row.Scan(
-		&i.Hash,
-		&i.CreatedAt,
-		&i.CreatedBy,
-		&i.Mimetype,
-		&i.Data,
-		&i.ID,
-	)
-	return i, err
+func (q *sqlQuerier) GetCustomRoles(ctx context.Context) ([]CustomRole, error) {
+	rows, err := q.db.QueryContext(ctx, getCustomRoles)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CustomRole
+	for rows.Next() {
+		var i CustomRole
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.DisplayName,
+			&i.OrganizationID,
+			&i.SitePermissions,
+			&i.OrgPermissions,
+			&i.UserPermissions,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const insertFile = `-- name: InsertFile :one
+const insertCustomRole = `-- name: InsertCustomRole :one
 INSERT INTO
-	files (id, hash, created_at, created_by, mimetype, "data")
+	custom_roles (
+		id,
+		name,
+		display_name,
+		organization_id,
+		site_permissions,
+		org_permissions,
+		user_permissions,
+		created_at,
+		updated_at
+	)
 VALUES
-	($1, $2, $3, $4, $5, $6) RETURNING hash, created_at, created_by, mimetype, data, id
+	($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id, name, display_name, organization_id, site_permissions, org_permissions, user_permissions, created_at, updated_at
 `
 
-type InsertFileParams struct {
-	ID        uuid.UUID `db:"id" json:"id"`
-	Hash      string    `db:"hash" json:"hash"`
-	CreatedAt time.Time `db:"created_at" json:"created_at"`
-	CreatedBy uuid.UUID `db:"created_by" json:"created_by"`
-	Mimetype  string    `db:"mimetype" json:"mimetype"`
-	Data      []byte    `db:"data" json:"data"`
+type InsertCustomRoleParams struct {
+	ID              uuid.UUID             `db:"id" json:"id"`
+	Name            string                `db:"name" json:"name"`
+	DisplayName     string                `db:"display_name" json:"display_name"`
+	OrganizationID  uuid.NullUUID         `db:"organization_id" json:"organization_id"`
+	SitePermissions CustomRolePermissions `db:"site_permissions" json:"site_permissions"`
+	OrgPermissions  CustomRolePermissions `db:"org_permissions" json:"org_permissions"`
+	UserPermissions CustomRolePermissions `db:"user_permissions" json:"user_permissions"`
+	CreatedAt       time.Time             `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time             `db:"updated_at" json:"updated_at"`
 }
 
-func (q *sqlQuerier) InsertFile(ctx context.Context, arg InsertFileParams) (File, error) {
-	row := q.db.QueryRowContext(ctx, insertFile,
+func (q *sqlQuerier) InsertCustomRole(ctx context.Context, arg InsertCustomRoleParams) (CustomRole, error) {
+	row := q.db.QueryRowContext(ctx, insertCustomRole,
 		arg.ID,
-		arg.Hash,
+		arg.Name,
+		arg.DisplayName,
+		arg.OrganizationID,
+		arg.SitePermissions,
+		arg.OrgPermissions,
+		arg.UserPermissions,
 		arg.CreatedAt,
-		arg.CreatedBy,
-		arg.Mimetype,
-		arg.Data,
+		arg.UpdatedAt,
 	)
-	var i File
+	var i CustomRole
 	err := row.Scan(
-		&i.Hash,
-		&i.CreatedAt,
-		&i.CreatedBy,
-		&i.Mimetype,
-		&i.Data,
 		&i.ID,
+		&i.Name,
+		&i.DisplayName,
+		&i.OrganizationID,
+		&i.SitePermissions,
+		&i.OrgPermissions,
+		&i.UserPermissions,
+		&i.CreatedAt,
+		&i.UpdatedAt,
 	)
 	return i, err
 }
 
-const getGitAuthLink = `-- name: GetGitAuthLink :one
-SELECT provider_id, user_id, created_at, updated_at, oauth_access_token, oauth_refresh_token, oauth_expiry FROM git_auth_links WHERE provider_id = $1 AND user_id = $2
+const unassignOrganizationRole = `-- name: UnassignOrganizationRole :exec
+UPDATE
+	organization_members
+SET
+	roles = array_remove(roles, $1 :: text)
+WHERE
+	organization_id = $2
 `
 
-type GetGitAuthLinkParams struct {
-	ProviderID string    `db:"provider_id" json:"provider_id"`
-	UserID     uuid.UUID `db:"user_id" json:"user_id"`
+type UnassignOrganizationRoleParams struct {
+	RoleName       string    `db:"role_name" json:"role_name"`
+	OrganizationID uuid.UUID `db:"organization_id" json:"organization_id"`
 }
 
-func (q *sqlQuerier) GetGitAuthLink(ctx context.Context, arg GetGitAuthLinkParams) (GitAuthLink, error) {
-	row := q.db.QueryRowContext(ctx, getGitAuthLink, arg.ProviderID, arg.UserID)
-	var i GitAuthLink
+// UnassignOrganizationRole removes an organization role from every member of
+// the organization.
+func (q *sqlQuerier) UnassignOrganizationRole(ctx context.Context, arg UnassignOrganizationRoleParams) error {
+	_, err := q.db.ExecContext(ctx, unassignOrganizationRole, arg.RoleName, arg.OrganizationID)
+	return err
+}
+
+const unassignSiteRole = `-- name: UnassignSiteRole :exec
+UPDATE
+	users
+SET
+	rbac_roles = array_remove(rbac_roles, $1 :: text)
+`
+
+// UnassignSiteRole removes a site wide role from every user.
+func (q *sqlQuerier) UnassignSiteRole(ctx context.Context, roleName string) error {
+	_, err := q.db.ExecContext(ctx, unassignSiteRole, roleName)
+	return err
+}
+
+const updateCustomRole = `-- name: UpdateCustomRole :one
+UPDATE
+	custom_roles
+SET
+	display_name = $2,
+	site_permissions = $3,
+	org_permissions = $4,
+	user_permissions = $5,
+	updated_at = $6
+WHERE
+	id = $1
+RETURNING id, name, display_name, organization_id, site_permissions, org_permissions, user_permissions, created_at, updated_at
+`
+
+type UpdateCustomRoleParams struct {
+	ID              uuid.UUID             `db:"id" json:"id"`
+	DisplayName     string                `db:"display_name" json:"display_name"`
+	SitePermissions CustomRolePermissions `db:"site_permissions" json:"site_permissions"`
+	OrgPermissions  CustomRolePermissions `db:"org_permissions" json:"org_permissions"`
+	UserPermissions CustomRolePermissions `db:"user_permissions" json:"user_permissions"`
+	UpdatedAt       time.Time             `db:"updated_at" json:"updated_at"`
+}
+
+func (q *sqlQuerier) UpdateCustomRole(ctx context.Context, arg UpdateCustomRoleParams) (CustomRole, error) {
+	row := q.db.QueryRowContext(ctx, updateCustomRole,
+		arg.ID,
+		arg.DisplayName,
+		arg.SitePermissions,
+		arg.OrgPermissions,
+		arg.UserPermissions,
+		arg.UpdatedAt,
+	)
+	var i CustomRole
 	err := row.Scan(
-		&i.ProviderID,
-		&i.UserID,
+		&i.ID,
+		&i.Name,
+		&i.DisplayName,
+		&i.OrganizationID,
+		&i.SitePermissions,
+		&i.OrgPermissions,
+		&i.UserPermissions,
 		&i.CreatedAt,
 		&i.UpdatedAt,
-		&i.OAuthAccessToken,
-		&i.OAuthRefreshToken,
-		&i.OAuthExpiry,
 	)
 	return i, err
 }
 
-const insertGitAuthLink = `-- name: InsertGitAuthLink :one
-INSERT INTO git_auth_links (
-    provider_id,
-    user_id,
-    created_at,
-    updated_at,
-    oauth_access_token,
+const deleteFileDataByHash = `-- name: DeleteFileDataByHash :exec
+DELETE FROM
+	file_data
+WHERE
+	hash = $1
+`
+
+func (q *sqlQuerier) DeleteFileDataByHash(ctx context.Context, hash string) error {
+	_, err := q.db.ExecContext(ctx, deleteFileDataByHash, hash)
+	return err
+}
+
+const deleteUnreferencedFileByID = `-- name: DeleteUnreferencedFileByID :one
+DELETE FROM
+	files
+WHERE
+	files.id = $1
+AND
+	files.created_at < $2
+AND
+	NOT EXISTS (
+		SELECT
+			1
+		FROM
+			provisioner_jobs
+		WHERE
+			provisioner_jobs.file_id = files.id
+	)
+AND
+	NOT EXISTS (
+		SELECT
+			1
+		FROM
+			workspace_session_recordings
+		WHERE
+			workspace_session_recordings.file_id = files.id
+	)
+RETURNING hash, created_at, created_by, mimetype, id, storage
+`
+
+type DeleteUnreferencedFileByIDParams struct {
+	ID            uuid.UUID `db:"id" json:"id"`
+	CreatedBefore time.Time `db:"created_before" json:"created_before"`
+}
+
+// The file is only deleted if it is still unreferenced and wasn't reused
+// since it was listed for collection.
+func (q *sqlQuerier) DeleteUnreferencedFileByID(ctx context.Context, arg DeleteUnreferencedFileByIDParams) (File, error) {
+	row := q.db.QueryRowContext(ctx, deleteUnreferencedFileByID, arg.ID, arg.CreatedBefore)
+	var i File
+	err := row.Scan(
+		&i.Hash,
+		&i.CreatedAt,
+		&i.CreatedBy,
+		&i.Mimetype,
+		&i.ID,
+		&i.Storage,
+	)
+	return i, err
+}
+
+const getFileByHashAndCreator = `-- name: GetFileByHashAndCreator :one
+SELECT
+	hash, created_at, created_by, mimetype, id, storage
+FROM
+	files
+WHERE
+	hash = $1
+AND
+	created_by = $2
+LIMIT
+	1
+`
+
+type GetFileByHashAndCreatorParams struct {
+	Hash      string    `db:"hash" json:"hash"`
+	CreatedBy uuid.UUID `db:"created_by" json:"created_by"`
+}
+
+func (q *sqlQuerier) GetFileByHashAndCreator(ctx context.Context, arg GetFileByHashAndCreatorParams) (File, error) {
+	row := q.db.QueryRowContext(ctx, getFileByHashAndCreator, arg.Hash, arg.CreatedBy)
+	var i File
+	err := row.Scan(
+		&i.Hash,
+		&i.CreatedAt,
+		&i.CreatedBy,
+		&i.Mimetype,
+		&i.ID,
+		&i.Storage,
+	)
+	return i, err
+}
+
+const getFileByID = `-- name: GetFileByID :one
+SELECT
+	hash, created_at, created_by, mimetype, id, storage
+FROM
+	files
+WHERE
+	id = $1
+LIMIT
+	1
+`
+
+func (q *sqlQuerier) GetFileByID(ctx context.Context, id uuid.UUID) (File, error) {
+	row := q.db.QueryRowContext(ctx, getFileByID, id)
+	var i File
+	err := row.Scan(
+		&i.Hash,
+		&i.CreatedAt,
+		&i.CreatedBy,
+		&i.Mimetype,
+		&i.ID,
+		&i.Storage,
+	)
+	return i, err
+}
+
+const getFileCountByHashAndStorage = `-- name: GetFileCountByHashAndStorage :one
+SELECT
+	COUNT(*)
+FROM
+	files
+WHERE
+	hash = $1
+AND
+	storage = $2
+`
+
+type GetFileCountByHashAndStorageParams struct {
+	Hash    string      `db:"hash" json:"hash"`
+	Storage FileStorage `db:"storage" json:"storage"`
+}
+
+func (q *sqlQuerier) GetFileCountByHashAndStorage(ctx context.Context, arg GetFileCountByHashAndStorageParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getFileCountByHashAndStorage, arg.Hash, arg.Storage)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const getFileDataByHash = `-- name: GetFileDataByHash :one
+SELECT
+	"data"
+FROM
+	file_data
+WHERE
+	hash = $1
+`
+
+func (q *sqlQuerier) GetFileDataByHash(ctx context.Context, hash string) ([]byte, error) {
+	row := q.db.QueryRowContext(ctx, getFileDataByHash, hash)
+	var data []byte
+	err := row.Scan(&data)
+	return data, err
+}
+
+const getUnreferencedFilesCreatedBefore = `-- name: GetUnreferencedFilesCreatedBefore :many
+SELECT
+	hash, created_at, created_by, mimetype, id, storage
+FROM
+	files
+WHERE
+	files.created_at < $1
+AND
+	NOT EXISTS (
+		SELECT
+			1
+		FROM
+			provisioner_jobs
+		WHERE
+			provisioner_jobs.file_id = files.id
+	)
+AND
+	NOT EXISTS (
+		SELECT
+			1
+		FROM
+			workspace_session_recordings
+		WHERE
+			workspace_session_recordings.file_id = files.id
+	)
+`
+
+// Files are referenced by the provisioner jobs that import template versions
+// and build workspaces, and by session recordings. Files that were never used
+// by either are unreferenced.
+func (q *sqlQuerier) GetUnreferencedFilesCreatedBefore(ctx context.Context, createdAt time.Time) ([]File, error) {
+	rows, err := q.db.QueryContext(ctx, getUnreferencedFilesCreatedBefore, createdAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []File
+	for rows.Next() {
+		var i File
+		if err := rows.Scan(
+			&i.Hash,
+			&i.CreatedAt,
+			&i.CreatedBy,
+			&i.Mimetype,
+			&i.ID,
+			&i.Storage,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertFile = `-- name: InsertFile :one
+INSERT INTO
+	files (id, hash, created_at, created_by, mimetype, storage)
+VALUES
+	($1, $2, $3, $4, $5, $6) RETURNING hash, created_at, created_by, mimetype, id, storage
+`
+
+type InsertFileParams struct {
+	ID        uuid.UUID   `db:"id" json:"id"`
+	Hash      string      `db:"hash" json:"hash"`
+	CreatedAt time.Time   `db:"created_at" json:"created_at"`
+	CreatedBy uuid.UUID   `db:"created_by" json:"created_by"`
+	Mimetype  string      `db:"mimetype" json:"mimetype"`
+	Storage   FileStorage `db:"storage" json:"storage"`
+}
+
+func (q *sqlQuerier) InsertFile(ctx context.Context, arg InsertFileParams) (File, error) {
+	row := q.db.QueryRowContext(ctx, insertFile,
+		arg.ID,
+		arg.Hash,
+		arg.CreatedAt,
+		arg.CreatedBy,
+		arg.Mimetype,
+		arg.Storage,
+	)
+	var i File
+	err := row.Scan(
+		&i.Hash,
+		&i.CreatedAt,
+		&i.CreatedBy,
+		&i.Mimetype,
+		&i.ID,
+		&i.Storage,
+	)
+	return i, err
+}
+
+const insertFileData = `-- name: InsertFileData :exec
+INSERT INTO
+	file_data (hash, "data")
+VALUES
+	($1, $2)
+ON CONFLICT (hash) DO NOTHING
+`
+
+type InsertFileDataParams struct {
+	Hash string `db:"hash" json:"hash"`
+	Data []byte `db:"data" json:"data"`
+}
+
+func (q *sqlQuerier) InsertFileData(ctx context.Context, arg InsertFileDataParams) error {
+	_, err := q.db.ExecContext(ctx, insertFileData, arg.Hash, arg.Data)
+	return err
+}
+
+const updateFileCreatedAtByID = `-- name: UpdateFileCreatedAtByID :exec
+UPDATE
+	files
+SET
+	created_at = $2
+WHERE
+	id = $1
+`
+
+type UpdateFileCreatedAtByIDParams struct {
+	ID        uuid.UUID `db:"id" json:"id"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+// Reused files have their creation time refreshed so they aren't collected
+// before the caller references them.
+func (q *sqlQuerier) UpdateFileCreatedAtByID(ctx context.Context, arg UpdateFileCreatedAtByIDParams) error {
+	_, err := q.db.ExecContext(ctx, updateFileCreatedAtByID, arg.ID, arg.CreatedAt)
+	return err
+}
+
+const getGitAuthLink = `-- name: GetGitAuthLink :one
+SELECT provider_id, user_id, created_at, updated_at, oauth_access_token, oauth_refresh_token, oauth_expiry FROM git_auth_links WHERE provider_id = $1 AND user_id = $2
+`
+
+type GetGitAuthLinkParams struct {
+	ProviderID string    `db:"provider_id" json:"provider_id"`
+	UserID     uuid.UUID `db:"user_id" json:"user_id"`
+}
+
+func (q *sqlQuerier) GetGitAuthLink(ctx context.Context, arg GetGitAuthLinkParams) (GitAuthLink, error) {
+	row := q.db.QueryRowContext(ctx, getGitAuthLink, arg.ProviderID, arg.UserID)
+	var i GitAuthLink
+	err := row.Scan(
+		&i.ProviderID,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.OAuthAccessToken,
+		&i.OAuthRefreshToken,
+		&i.OAuthExpiry,
+	)
+	return i, err
+}
+
+const insertGitAuthLink = `-- name: InsertGitAuthLink :one
+INSERT INTO git_auth_links (
+    provider_id,
+    user_id,
+    created_at,
+    updated_at,
+    oauth_access_token,
     oauth_refresh_token,
     oauth_expiry
 ) VALUES (
@@ -1029,9 +1623,28 @@ func (q *sqlQuerier) DeleteGroupMember(ctx context.Context, userID uuid.UUID) er
 	return err
 }
 
+const deleteGroupMemberFromGroup = `-- name: DeleteGroupMemberFromGroup :exec
+DELETE FROM
+	group_members
+WHERE
+	user_id = $1
+AND
+	group_id = $2
+`
+
+type DeleteGroupMemberFromGroupParams struct {
+	UserID  uuid.UUID `db:"user_id" json:"user_id"`
+	GroupID uuid.UUID `db:"group_id" json:"group_id"`
+}
+
+func (q *sqlQuerier) DeleteGroupMemberFromGroup(ctx context.Context, arg DeleteGroupMemberFromGroupParams) error {
+	_, err := q.db.ExecContext(ctx, deleteGroupMemberFromGroup, arg.UserID, arg.GroupID)
+	return err
+}
+
 const getAllOrganizationMembers = `-- name: GetAllOrganizationMembers :many
 SELECT
-	users.id, users.email, users.username, users.hashed_password, users.created_at, users.updated_at, users.status, users.rbac_roles, users.login_type, users.avatar_url, users.deleted, users.last_seen_at
+	users.id, users.email, users.username, users.hashed_password, users.created_at, users.updated_at, users.status, users.rbac_roles, users.login_type, users.avatar_url, users.deleted, users.last_seen_at, users.auto_start_on_connect
 FROM
 	users
 JOIN
@@ -1064,6 +1677,7 @@ func (q *sqlQuerier) GetAllOrganizationMembers(ctx context.Context, organization
 			&i.AvatarURL,
 			&i.Deleted,
 			&i.LastSeenAt,
+			&i.AutoStartOnConnect,
 		); err != nil {
 			return nil, err
 		}
@@ -1080,7 +1694,7 @@ func (q *sqlQuerier) GetAllOrganizationMembers(ctx context.Context, organization
 
 const getGroupByID = `-- name: GetGroupByID :one
 SELECT
-	id, name, organization_id, avatar_url, quota_allowance
+	id, name, organization_id, avatar_url, quota_allowance, source
 FROM
 	groups
 WHERE
@@ -1098,13 +1712,14 @@ func (q *sqlQuerier) GetGroupByID(ctx context.Context, id uuid.UUID) (Group, err
 		&i.OrganizationID,
 		&i.AvatarURL,
 		&i.QuotaAllowance,
+		&i.Source,
 	)
 	return i, err
 }
 
 const getGroupByOrgAndName = `-- name: GetGroupByOrgAndName :one
 SELECT
-	id, name, organization_id, avatar_url, quota_allowance
+	id, name, organization_id, avatar_url, quota_allowance, source
 FROM
 	groups
 WHERE
@@ -1129,16 +1744,51 @@ func (q *sqlQuerier) GetGroupByOrgAndName(ctx context.Context, arg GetGroupByOrg
 		&i.OrganizationID,
 		&i.AvatarURL,
 		&i.QuotaAllowance,
+		&i.Source,
 	)
 	return i, err
 }
 
-const getGroupMembers = `-- name: GetGroupMembers :many
+const getGroupMemberIDs = `-- name: GetGroupMemberIDs :many
 SELECT
-	users.id, users.email, users.username, users.hashed_password, users.created_at, users.updated_at, users.status, users.rbac_roles, users.login_type, users.avatar_url, users.deleted, users.last_seen_at
+	user_id
 FROM
-	users
-JOIN
+	group_members
+WHERE
+	group_id = $1
+`
+
+// GetGroupMemberIDs returns the IDs of every member of a group, unlike
+// GetGroupMembers which omits inactive and deleted users.
+func (q *sqlQuerier) GetGroupMemberIDs(ctx context.Context, groupID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, getGroupMemberIDs, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var user_id uuid.UUID
+		if err := rows.Scan(&user_id); err != nil {
+			return nil, err
+		}
+		items = append(items, user_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getGroupMembers = `-- name: GetGroupMembers :many
+SELECT
+	users.id, users.email, users.username, users.hashed_password, users.created_at, users.updated_at, users.status, users.rbac_roles, users.login_type, users.avatar_url, users.deleted, users.last_seen_at, users.auto_start_on_connect
+FROM
+	users
+JOIN
 	group_members
 ON
 	users.id = group_members.user_id
@@ -1172,6 +1822,7 @@ func (q *sqlQuerier) GetGroupMembers(ctx context.Context, groupID uuid.UUID) ([]
 			&i.AvatarURL,
 			&i.Deleted,
 			&i.LastSeenAt,
+			&i.AutoStartOnConnect,
 		); err != nil {
 			return nil, err
 		}
@@ -1188,7 +1839,7 @@ func (q *sqlQuerier) GetGroupMembers(ctx context.Context, groupID uuid.UUID) ([]
 
 const getGroupsByOrganizationID = `-- name: GetGroupsByOrganizationID :many
 SELECT
-	id, name, organization_id, avatar_url, quota_allowance
+	id, name, organization_id, avatar_url, quota_allowance, source
 FROM
 	groups
 WHERE
@@ -1212,6 +1863,7 @@ func (q *sqlQuerier) GetGroupsByOrganizationID(ctx context.Context, organization
 			&i.OrganizationID,
 			&i.AvatarURL,
 			&i.QuotaAllowance,
+			&i.Source,
 		); err != nil {
 			return nil, err
 		}
@@ -1228,7 +1880,7 @@ func (q *sqlQuerier) GetGroupsByOrganizationID(ctx context.Context, organization
 
 const getUserGroups = `-- name: GetUserGroups :many
 SELECT
-	groups.id, groups.name, groups.organization_id, groups.avatar_url, groups.quota_allowance
+	groups.id, groups.name, groups.organization_id, groups.avatar_url, groups.quota_allowance, groups.source
 FROM
 	groups
 JOIN
@@ -1254,6 +1906,7 @@ func (q *sqlQuerier) GetUserGroups(ctx context.Context, userID uuid.UUID) ([]Gro
 			&i.OrganizationID,
 			&i.AvatarURL,
 			&i.QuotaAllowance,
+			&i.Source,
 		); err != nil {
 			return nil, err
 		}
@@ -1275,7 +1928,7 @@ INSERT INTO groups (
 	organization_id
 )
 VALUES
-	( $1, 'Everyone', $1) RETURNING id, name, organization_id, avatar_url, quota_allowance
+	( $1, 'Everyone', $1) RETURNING id, name, organization_id, avatar_url, quota_allowance, source
 `
 
 // We use the organization_id as the id
@@ -1290,6 +1943,7 @@ func (q *sqlQuerier) InsertAllUsersGroup(ctx context.Context, organizationID uui
 		&i.OrganizationID,
 		&i.AvatarURL,
 		&i.QuotaAllowance,
+		&i.Source,
 	)
 	return i, err
 }
@@ -1300,18 +1954,20 @@ INSERT INTO groups (
 	name,
 	organization_id,
 	avatar_url,
-	quota_allowance
+	quota_allowance,
+	source
 )
 VALUES
-	( $1, $2, $3, $4, $5) RETURNING id, name, organization_id, avatar_url, quota_allowance
+	( $1, $2, $3, $4, $5, $6) RETURNING id, name, organization_id, avatar_url, quota_allowance, source
 `
 
 type InsertGroupParams struct {
-	ID             uuid.UUID `db:"id" json:"id"`
-	Name           string    `db:"name" json:"name"`
-	OrganizationID uuid.UUID `db:"organization_id" json:"organization_id"`
-	AvatarURL      string    `db:"avatar_url" json:"avatar_url"`
-	QuotaAllowance int32     `db:"quota_allowance" json:"quota_allowance"`
+	ID             uuid.UUID   `db:"id" json:"id"`
+	Name           string      `db:"name" json:"name"`
+	OrganizationID uuid.UUID   `db:"organization_id" json:"organization_id"`
+	AvatarURL      string      `db:"avatar_url" json:"avatar_url"`
+	QuotaAllowance int32       `db:"quota_allowance" json:"quota_allowance"`
+	Source         GroupSource `db:"source" json:"source"`
 }
 
 func (q *sqlQuerier) InsertGroup(ctx context.Context, arg InsertGroupParams) (Group, error) {
@@ -1321,6 +1977,7 @@ func (q *sqlQuerier) InsertGroup(ctx context.Context, arg InsertGroupParams) (Gr
 		arg.OrganizationID,
 		arg.AvatarURL,
 		arg.QuotaAllowance,
+		arg.Source,
 	)
 	var i Group
 	err := row.Scan(
@@ -1329,6 +1986,7 @@ func (q *sqlQuerier) InsertGroup(ctx context.Context, arg InsertGroupParams) (Gr
 		&i.OrganizationID,
 		&i.AvatarURL,
 		&i.QuotaAllowance,
+		&i.Source,
 	)
 	return i, err
 }
@@ -1360,7 +2018,7 @@ SET
 	quota_allowance = $3
 WHERE
 	id = $4
-RETURNING id, name, organization_id, avatar_url, quota_allowance
+RETURNING id, name, organization_id, avatar_url, quota_allowance, source
 `
 
 type UpdateGroupByIDParams struct {
@@ -1384,10 +2042,511 @@ func (q *sqlQuerier) UpdateGroupByID(ctx context.Context, arg UpdateGroupByIDPar
 		&i.OrganizationID,
 		&i.AvatarURL,
 		&i.QuotaAllowance,
+		&i.Source,
 	)
 	return i, err
 }
 
+const getAppInsights = `-- name: GetAppInsights :many
+SELECT
+	template_id,
+	slug,
+	COUNT(DISTINCT user_id) AS users,
+	COUNT(*) AS usage_hours,
+	COALESCE(SUM(requests), 0)::bigint AS requests
+FROM
+	template_app_usage_stats
+WHERE
+	start_time >= $1
+	AND start_time < $2
+	AND template_id = ANY($3 :: uuid[])
+GROUP BY
+	template_id, slug
+ORDER BY
+	template_id, slug
+`
+
+type GetAppInsightsParams struct {
+	StartTime   time.Time   `db:"start_time" json:"start_time"`
+	EndTime     time.Time   `db:"end_time" json:"end_time"`
+	TemplateIds []uuid.UUID `db:"template_ids" json:"template_ids"`
+}
+
+type GetAppInsightsRow struct {
+	TemplateID uuid.UUID `db:"template_id" json:"template_id"`
+	Slug       string    `db:"slug" json:"slug"`
+	Users      int64     `db:"users" json:"users"`
+	UsageHours int64     `db:"usage_hours" json:"usage_hours"`
+	Requests   int64     `db:"requests" json:"requests"`
+}
+
+func (q *sqlQuerier) GetAppInsights(ctx context.Context, arg GetAppInsightsParams) ([]GetAppInsightsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getAppInsights, arg.StartTime, arg.EndTime, pq.Array(arg.TemplateIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetAppInsightsRow
+	for rows.Next() {
+		var i GetAppInsightsRow
+		if err := rows.Scan(
+			&i.TemplateID,
+			&i.Slug,
+			&i.Users,
+			&i.UsageHours,
+			&i.Requests,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLatestTemplateUsageStatsStartTime = `-- name: GetLatestTemplateUsageStatsStartTime :one
+SELECT
+	COALESCE(MAX(start_time), '0001-01-01 00:00:00+00')::timestamptz
+FROM
+	template_usage_stats
+`
+
+// Returns the start of the latest hour that has been rolled up, or the zero
+// time if none has.
+func (q *sqlQuerier) GetLatestTemplateUsageStatsStartTime(ctx context.Context) (time.Time, error) {
+	row := q.db.QueryRowContext(ctx, getLatestTemplateUsageStatsStartTime)
+	var column_1 time.Time
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const getTemplateInsights = `-- name: GetTemplateInsights :many
+SELECT
+	template_id,
+	COUNT(DISTINCT user_id) FILTER (WHERE active) AS active_users,
+	COUNT(*) FILTER (WHERE active) AS active_hours,
+	COALESCE(SUM(connection_count), 0)::bigint AS connection_count,
+	COALESCE(SUM(ssh_connection_count), 0)::bigint AS ssh_connection_count,
+	COALESCE(SUM(reconnecting_pty_connection_count), 0)::bigint AS reconnecting_pty_connection_count,
+	COALESCE(SUM(vscode_connection_count), 0)::bigint AS vscode_connection_count,
+	COALESCE(SUM(jetbrains_connection_count), 0)::bigint AS jetbrains_connection_count,
+	COALESCE(SUM(cost), 0)::double precision AS cost
+FROM
+	template_usage_stats
+WHERE
+	start_time >= $1
+	AND start_time < $2
+	AND template_id = ANY($3 :: uuid[])
+GROUP BY
+	template_id
+ORDER BY
+	template_id
+`
+
+type GetTemplateInsightsParams struct {
+	StartTime   time.Time   `db:"start_time" json:"start_time"`
+	EndTime     time.Time   `db:"end_time" json:"end_time"`
+	TemplateIds []uuid.UUID `db:"template_ids" json:"template_ids"`
+}
+
+type GetTemplateInsightsRow struct {
+	TemplateID                     uuid.UUID `db:"template_id" json:"template_id"`
+	ActiveUsers                    int64     `db:"active_users" json:"active_users"`
+	ActiveHours                    int64     `db:"active_hours" json:"active_hours"`
+	ConnectionCount                int64     `db:"connection_count" json:"connection_count"`
+	SshConnectionCount             int64     `db:"ssh_connection_count" json:"ssh_connection_count"`
+	ReconnectingPtyConnectionCount int64     `db:"reconnecting_pty_connection_count" json:"reconnecting_pty_connection_count"`
+	VscodeConnectionCount          int64     `db:"vscode_connection_count" json:"vscode_connection_count"`
+	JetbrainsConnectionCount       int64     `db:"jetbrains_connection_count" json:"jetbrains_connection_count"`
+	Cost                           float64   `db:"cost" json:"cost"`
+}
+
+func (q *sqlQuerier) GetTemplateInsights(ctx context.Context, arg GetTemplateInsightsParams) ([]GetTemplateInsightsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getTemplateInsights, arg.StartTime, arg.EndTime, pq.Array(arg.TemplateIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTemplateInsightsRow
+	for rows.Next() {
+		var i GetTemplateInsightsRow
+		if err := rows.Scan(
+			&i.TemplateID,
+			&i.ActiveUsers,
+			&i.ActiveHours,
+			&i.ConnectionCount,
+			&i.SshConnectionCount,
+			&i.ReconnectingPtyConnectionCount,
+			&i.VscodeConnectionCount,
+			&i.JetbrainsConnectionCount,
+			&i.Cost,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserInsights = `-- name: GetUserInsights :many
+SELECT
+	template_id,
+	user_id,
+	COUNT(*) FILTER (WHERE active) AS active_hours,
+	COALESCE(SUM(connection_count), 0)::bigint AS connection_count,
+	COALESCE(SUM(ssh_connection_count), 0)::bigint AS ssh_connection_count,
+	COALESCE(SUM(reconnecting_pty_connection_count), 0)::bigint AS reconnecting_pty_connection_count,
+	COALESCE(SUM(vscode_connection_count), 0)::bigint AS vscode_connection_count,
+	COALESCE(SUM(jetbrains_connection_count), 0)::bigint AS jetbrains_connection_count,
+	COALESCE(SUM(cost), 0)::double precision AS cost
+FROM
+	template_usage_stats
+WHERE
+	start_time >= $1
+	AND start_time < $2
+	AND template_id = ANY($3 :: uuid[])
+GROUP BY
+	template_id, user_id
+ORDER BY
+	template_id, user_id
+`
+
+type GetUserInsightsParams struct {
+	StartTime   time.Time   `db:"start_time" json:"start_time"`
+	EndTime     time.Time   `db:"end_time" json:"end_time"`
+	TemplateIds []uuid.UUID `db:"template_ids" json:"template_ids"`
+}
+
+type GetUserInsightsRow struct {
+	TemplateID                     uuid.UUID `db:"template_id" json:"template_id"`
+	UserID                         uuid.UUID `db:"user_id" json:"user_id"`
+	ActiveHours                    int64     `db:"active_hours" json:"active_hours"`
+	ConnectionCount                int64     `db:"connection_count" json:"connection_count"`
+	SshConnectionCount             int64     `db:"ssh_connection_count" json:"ssh_connection_count"`
+	ReconnectingPtyConnectionCount int64     `db:"reconnecting_pty_connection_count" json:"reconnecting_pty_connection_count"`
+	VscodeConnectionCount          int64     `db:"vscode_connection_count" json:"vscode_connection_count"`
+	JetbrainsConnectionCount       int64     `db:"jetbrains_connection_count" json:"jetbrains_connection_count"`
+	Cost                           float64   `db:"cost" json:"cost"`
+}
+
+func (q *sqlQuerier) GetUserInsights(ctx context.Context, arg GetUserInsightsParams) ([]GetUserInsightsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getUserInsights, arg.StartTime, arg.EndTime, pq.Array(arg.TemplateIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUserInsightsRow
+	for rows.Next() {
+		var i GetUserInsightsRow
+		if err := rows.Scan(
+			&i.TemplateID,
+			&i.UserID,
+			&i.ActiveHours,
+			&i.ConnectionCount,
+			&i.SshConnectionCount,
+			&i.ReconnectingPtyConnectionCount,
+			&i.VscodeConnectionCount,
+			&i.JetbrainsConnectionCount,
+			&i.Cost,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertTemplateAppUsageStats = `-- name: InsertTemplateAppUsageStats :exec
+INSERT INTO
+	template_app_usage_stats (
+		start_time,
+		template_id,
+		user_id,
+		slug,
+		requests
+	)
+VALUES
+	($1, $2, $3, $4, $5)
+ON CONFLICT
+	(start_time, template_id, user_id, slug)
+DO UPDATE SET
+	requests = template_app_usage_stats.requests + $5
+`
+
+type InsertTemplateAppUsageStatsParams struct {
+	StartTime  time.Time `db:"start_time" json:"start_time"`
+	TemplateID uuid.UUID `db:"template_id" json:"template_id"`
+	UserID     uuid.UUID `db:"user_id" json:"user_id"`
+	Slug       string    `db:"slug" json:"slug"`
+	Requests   int64     `db:"requests" json:"requests"`
+}
+
+// Adds requests to the app usage of an hour.
+func (q *sqlQuerier) InsertTemplateAppUsageStats(ctx context.Context, arg InsertTemplateAppUsageStatsParams) error {
+	_, err := q.db.ExecContext(ctx, insertTemplateAppUsageStats,
+		arg.StartTime,
+		arg.TemplateID,
+		arg.UserID,
+		arg.Slug,
+		arg.Requests,
+	)
+	return err
+}
+
+const rollupTemplateUsageStats = `-- name: RollupTemplateUsageStats :exec
+WITH agent_stats_in_range AS (
+	SELECT
+		agent_id, created_at, template_id, user_id, connection_activity, payload
+	FROM
+		agent_stats
+	WHERE
+		agent_stats.created_at >= $1
+		AND agent_stats.created_at < $2
+), previous_agent_stats AS (
+	SELECT
+		previous.agent_id, previous.created_at, previous.template_id, previous.user_id, previous.connection_activity, previous.payload
+	FROM
+		(SELECT DISTINCT agent_id FROM agent_stats_in_range) AS agents
+	JOIN LATERAL (
+		SELECT
+			agent_id, created_at, template_id, user_id, connection_activity, payload
+		FROM
+			agent_stats
+		WHERE
+			agent_stats.agent_id = agents.agent_id
+			AND agent_stats.created_at < $1
+		ORDER BY
+			agent_stats.created_at DESC
+		LIMIT 1
+	) AS previous ON true
+), counters AS (
+	SELECT
+		stats.created_at,
+		stats.template_id,
+		stats.user_id,
+		stats.connection_activity,
+		COALESCE((stats.payload->>'num_comms')::bigint, 0) AS connections,
+		COALESCE((stats.payload->>'connection_count_ssh')::bigint, 0) AS ssh,
+		COALESCE((stats.payload->>'connection_count_reconnecting_pty')::bigint, 0) AS reconnecting_pty,
+		COALESCE((stats.payload->>'connection_count_vscode')::bigint, 0) AS vscode,
+		COALESCE((stats.payload->>'connection_count_jetbrains')::bigint, 0) AS jetbrains,
+		-- Counters start from zero for the first stats of an agent.
+		COALESCE(LAG((stats.payload->>'num_comms')::bigint) OVER agent, 0) AS previous_connections,
+		COALESCE(LAG((stats.payload->>'connection_count_ssh')::bigint) OVER agent, 0) AS previous_ssh,
+		COALESCE(LAG((stats.payload->>'connection_count_reconnecting_pty')::bigint) OVER agent, 0) AS previous_reconnecting_pty,
+		COALESCE(LAG((stats.payload->>'connection_count_vscode')::bigint) OVER agent, 0) AS previous_vscode,
+		COALESCE(LAG((stats.payload->>'connection_count_jetbrains')::bigint) OVER agent, 0) AS previous_jetbrains
+	FROM
+		(SELECT agent_id, created_at, template_id, user_id, connection_activity, payload FROM previous_agent_stats UNION ALL SELECT agent_id, created_at, template_id, user_id, connection_activity, payload FROM agent_stats_in_range) AS stats
+	WINDOW
+		agent AS (PARTITION BY stats.agent_id ORDER BY stats.created_at)
+), usage AS (
+	-- Counters are reset when the agent restarts.
+	SELECT
+		date_trunc('hour', created_at AT TIME ZONE 'UTC') AT TIME ZONE 'UTC' AS start_time,
+		template_id,
+		user_id,
+		bool_or(connection_activity) AS active,
+		SUM(CASE WHEN connections < previous_connections THEN connections ELSE connections - previous_connections END)::bigint AS connection_count,
+		SUM(CASE WHEN ssh < previous_ssh THEN ssh ELSE ssh - previous_ssh END)::bigint AS ssh_connection_count,
+		SUM(CASE WHEN reconnecting_pty < previous_reconnecting_pty THEN reconnecting_pty ELSE reconnecting_pty - previous_reconnecting_pty END)::bigint AS reconnecting_pty_connection_count,
+		SUM(CASE WHEN vscode < previous_vscode THEN vscode ELSE vscode - previous_vscode END)::bigint AS vscode_connection_count,
+		SUM(CASE WHEN jetbrains < previous_jetbrains THEN jetbrains ELSE jetbrains - previous_jetbrains END)::bigint AS jetbrains_connection_count
+	FROM
+		counters
+	WHERE
+		counters.created_at >= $1
+	GROUP BY
+		1, template_id, user_id
+), workspace_builds_in_range AS (
+	SELECT
+		workspace_id, created_at, daily_cost
+	FROM
+		workspace_builds
+	WHERE
+		workspace_builds.created_at >= $1
+		AND workspace_builds.created_at < $2
+), previous_workspace_builds AS (
+	SELECT
+		previous.workspace_id, previous.created_at, previous.daily_cost
+	FROM
+		workspaces
+	JOIN LATERAL (
+		SELECT
+			workspace_id, created_at, daily_cost
+		FROM
+			workspace_builds
+		WHERE
+			workspace_builds.workspace_id = workspaces.id
+			AND workspace_builds.created_at < $1
+		ORDER BY
+			workspace_builds.build_number DESC
+		LIMIT 1
+	) AS previous ON true
+), build_costs AS (
+	-- A build's cost applies until the next build of the workspace.
+	SELECT
+		builds.workspace_id,
+		builds.daily_cost,
+		GREATEST(builds.created_at, $1) AS cost_start,
+		LEAST(COALESCE(LEAD(builds.created_at) OVER (PARTITION BY builds.workspace_id ORDER BY builds.created_at), CAST($3 AS timestamptz)), $2) AS cost_end
+	FROM
+		(SELECT workspace_id, created_at, daily_cost FROM previous_workspace_builds UNION ALL SELECT workspace_id, created_at, daily_cost FROM workspace_builds_in_range) AS builds
+), costs AS (
+	SELECT
+		hours.start_time,
+		workspaces.template_id,
+		workspaces.owner_id AS user_id,
+		SUM(build_costs.daily_cost * EXTRACT(EPOCH FROM LEAST(hours.start_time + INTERVAL '1 hour', build_costs.cost_end) - GREATEST(hours.start_time, build_costs.cost_start)) / 86400)::double precision AS cost
+	FROM
+		build_costs
+	JOIN
+		workspaces ON workspaces.id = build_costs.workspace_id
+	JOIN LATERAL (
+		-- The hours the build's cost applies to.
+		SELECT
+			generate_series(
+				date_trunc('hour', build_costs.cost_start AT TIME ZONE 'UTC') AT TIME ZONE 'UTC',
+				build_costs.cost_end - INTERVAL '1 microsecond',
+				INTERVAL '1 hour'
+			) AS start_time
+	) AS hours ON true
+	WHERE
+		build_costs.daily_cost > 0
+		AND build_costs.cost_end > build_costs.cost_start
+	GROUP BY
+		hours.start_time, workspaces.template_id, workspaces.owner_id
+)
+INSERT INTO
+	template_usage_stats (
+		start_time,
+		template_id,
+		user_id,
+		active,
+		connection_count,
+		ssh_connection_count,
+		reconnecting_pty_connection_count,
+		vscode_connection_count,
+		jetbrains_connection_count,
+		cost
+	)
+SELECT
+	COALESCE(usage.start_time, costs.start_time),
+	COALESCE(usage.template_id, costs.template_id),
+	COALESCE(usage.user_id, costs.user_id),
+	COALESCE(usage.active, false),
+	COALESCE(usage.connection_count, 0),
+	COALESCE(usage.ssh_connection_count, 0),
+	COALESCE(usage.reconnecting_pty_connection_count, 0),
+	COALESCE(usage.vscode_connection_count, 0),
+	COALESCE(usage.jetbrains_connection_count, 0),
+	COALESCE(costs.cost, 0)
+FROM
+	usage
+FULL OUTER JOIN
+	costs ON costs.start_time = usage.start_time AND costs.template_id = usage.template_id AND costs.user_id = usage.user_id
+ON CONFLICT
+	(start_time, template_id, user_id)
+DO UPDATE SET
+	active = EXCLUDED.active,
+	connection_count = EXCLUDED.connection_count,
+	ssh_connection_count = EXCLUDED.ssh_connection_count,
+	reconnecting_pty_connection_count = EXCLUDED.reconnecting_pty_connection_count,
+	vscode_connection_count = EXCLUDED.vscode_connection_count,
+	jetbrains_connection_count = EXCLUDED.jetbrains_connection_count,
+	cost = EXCLUDED.cost
+`
+
+type RollupTemplateUsageStatsParams struct {
+	StartTime time.Time `db:"start_time" json:"start_time"`
+	EndTime   time.Time `db:"end_time" json:"end_time"`
+	Now       time.Time `db:"now" json:"now"`
+}
+
+// Recomputes the hourly usage between two whole hours from the agent stats
+// and workspace builds created in the range. The latest stats of each agent
+// and the latest build of each workspace before the range are looked up by
+// index, so counters can be diffed and costs carried over without scanning
+// older rows. Cost is only accrued until now.
+func (q *sqlQuerier) RollupTemplateUsageStats(ctx context.Context, arg RollupTemplateUsageStatsParams) error {
+	_, err := q.db.ExecContext(ctx, rollupTemplateUsageStats, arg.StartTime, arg.EndTime, arg.Now)
+	return err
+}
+
+const upsertTemplateUsageStats = `-- name: UpsertTemplateUsageStats :exec
+INSERT INTO
+	template_usage_stats (
+		start_time,
+		template_id,
+		user_id,
+		active,
+		connection_count,
+		ssh_connection_count,
+		reconnecting_pty_connection_count,
+		vscode_connection_count,
+		jetbrains_connection_count,
+		cost
+	)
+VALUES
+	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+ON CONFLICT
+	(start_time, template_id, user_id)
+DO UPDATE SET
+	active = $4,
+	connection_count = $5,
+	ssh_connection_count = $6,
+	reconnecting_pty_connection_count = $7,
+	vscode_connection_count = $8,
+	jetbrains_connection_count = $9,
+	cost = $10
+`
+
+type UpsertTemplateUsageStatsParams struct {
+	StartTime                      time.Time `db:"start_time" json:"start_time"`
+	TemplateID                     uuid.UUID `db:"template_id" json:"template_id"`
+	UserID                         uuid.UUID `db:"user_id" json:"user_id"`
+	Active                         bool      `db:"active" json:"active"`
+	ConnectionCount                int64     `db:"connection_count" json:"connection_count"`
+	SshConnectionCount             int64     `db:"ssh_connection_count" json:"ssh_connection_count"`
+	ReconnectingPtyConnectionCount int64     `db:"reconnecting_pty_connection_count" json:"reconnecting_pty_connection_count"`
+	VscodeConnectionCount          int64     `db:"vscode_connection_count" json:"vscode_connection_count"`
+	JetbrainsConnectionCount       int64     `db:"jetbrains_connection_count" json:"jetbrains_connection_count"`
+	Cost                           float64   `db:"cost" json:"cost"`
+}
+
+func (q *sqlQuerier) UpsertTemplateUsageStats(ctx context.Context, arg UpsertTemplateUsageStatsParams) error {
+	_, err := q.db.ExecContext(ctx, upsertTemplateUsageStats,
+		arg.StartTime,
+		arg.TemplateID,
+		arg.UserID,
+		arg.Active,
+		arg.ConnectionCount,
+		arg.SshConnectionCount,
+		arg.ReconnectingPtyConnectionCount,
+		arg.VscodeConnectionCount,
+		arg.JetbrainsConnectionCount,
+		arg.Cost,
+	)
+	return err
+}
+
 const deleteLicense = `-- name: DeleteLicense :one
 DELETE
 FROM licenses
@@ -1509,6 +2668,52 @@ func (q *sqlQuerier) InsertLicense(ctx context.Context, arg InsertLicenseParams)
 	return i, err
 }
 
+const acquireLock = `-- name: AcquireLock :exec
+SELECT pg_advisory_xact_lock($1)
+`
+
+// Blocks until the lock is acquired.
+//
+// This must be called from within a transaction. The lock will be automatically
+// released when the transaction ends.
+func (q *sqlQuerier) AcquireLock(ctx context.Context, pgAdvisoryXactLock int64) error {
+	_, err := q.db.ExecContext(ctx, acquireLock, pgAdvisoryXactLock)
+	return err
+}
+
+const tryAcquireLock = `-- name: TryAcquireLock :one
+SELECT pg_try_advisory_xact_lock($1)
+`
+
+// Non blocking lock. Returns true if the lock was acquired, false otherwise.
+//
+// This must be called from within a transaction. The lock will be automatically
+// released when the transaction ends.
+func (q *sqlQuerier) TryAcquireLock(ctx context.Context, pgTryAdvisoryXactLock int64) (bool, error) {
+	row := q.db.QueryRowContext(ctx, tryAcquireLock, pgTryAdvisoryXactLock)
+	var pg_try_advisory_xact_lock bool
+	err := row.Scan(&pg_try_advisory_xact_lock)
+	return pg_try_advisory_xact_lock, err
+}
+
+const deleteOrganizationMember = `-- name: DeleteOrganizationMember :exec
+DELETE FROM
+	organization_members
+WHERE
+	organization_id = $1
+	AND user_id = $2
+`
+
+type DeleteOrganizationMemberParams struct {
+	OrganizationID uuid.UUID `db:"organization_id" json:"organization_id"`
+	UserID         uuid.UUID `db:"user_id" json:"user_id"`
+}
+
+func (q *sqlQuerier) DeleteOrganizationMember(ctx context.Context, arg DeleteOrganizationMemberParams) error {
+	_, err := q.db.ExecContext(ctx, deleteOrganizationMember, arg.OrganizationID, arg.UserID)
+	return err
+}
+
 const getOrganizationIDsByMemberIDs = `-- name: GetOrganizationIDsByMemberIDs :many
 SELECT
     user_id, array_agg(organization_id) :: uuid [ ] AS "organization_IDs"
@@ -1578,17 +2783,19 @@ func (q *sqlQuerier) GetOrganizationMemberByUserID(ctx context.Context, arg GetO
 	return i, err
 }
 
-const getOrganizationMembershipsByUserID = `-- name: GetOrganizationMembershipsByUserID :many
+const getOrganizationMembers = `-- name: GetOrganizationMembers :many
 SELECT
 	user_id, organization_id, created_at, updated_at, roles
 FROM
 	organization_members
 WHERE
-  user_id = $1
+	organization_id = $1
+ORDER BY
+	created_at
 `
 
-func (q *sqlQuerier) GetOrganizationMembershipsByUserID(ctx context.Context, userID uuid.UUID) ([]OrganizationMember, error) {
-	rows, err := q.db.QueryContext(ctx, getOrganizationMembershipsByUserID, userID)
+func (q *sqlQuerier) GetOrganizationMembers(ctx context.Context, organizationID uuid.UUID) ([]OrganizationMember, error) {
+	rows, err := q.db.QueryContext(ctx, getOrganizationMembers, organizationID)
 	if err != nil {
 		return nil, err
 	}
@@ -1616,13 +2823,51 @@ func (q *sqlQuerier) GetOrganizationMembershipsByUserID(ctx context.Context, use
 	return items, nil
 }
 
-const insertOrganizationMember = `-- name: InsertOrganizationMember :one
-INSERT INTO
-	organization_members (
-		organization_id,
-		user_id,
-		created_at,
-		updated_at,
+const getOrganizationMembershipsByUserID = `-- name: GetOrganizationMembershipsByUserID :many
+SELECT
+	user_id, organization_id, created_at, updated_at, roles
+FROM
+	organization_members
+WHERE
+  user_id = $1
+`
+
+func (q *sqlQuerier) GetOrganizationMembershipsByUserID(ctx context.Context, userID uuid.UUID) ([]OrganizationMember, error) {
+	rows, err := q.db.QueryContext(ctx, getOrganizationMembershipsByUserID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []OrganizationMember
+	for rows.Next() {
+		var i OrganizationMember
+		if err := rows.Scan(
+			&i.UserID,
+			&i.OrganizationID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			pq.Array(&i.Roles),
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertOrganizationMember = `-- name: InsertOrganizationMember :one
+INSERT INTO
+	organization_members (
+		organization_id,
+		user_id,
+		created_at,
+		updated_at,
 		roles
 	)
 VALUES
@@ -1738,8 +2983,12 @@ SELECT
 	id, name, description, created_at, updated_at
 FROM
 	organizations
+ORDER BY
+	created_at ASC, id ASC
 `
 
+// GetOrganizations returns the oldest organization first, which is the
+// default organization created during setup.
 func (q *sqlQuerier) GetOrganizations(ctx context.Context) ([]Organization, error) {
 	rows, err := q.db.QueryContext(ctx, getOrganizations)
 	if err != nil {
@@ -1775,7 +3024,7 @@ SELECT
 FROM
 	organizations
 WHERE
-	id = (
+	id = ANY(
 		SELECT
 			organization_id
 		FROM
@@ -1848,6 +3097,43 @@ func (q *sqlQuerier) InsertOrganization(ctx context.Context, arg InsertOrganizat
 	return i, err
 }
 
+const updateOrganization = `-- name: UpdateOrganization :one
+UPDATE
+	organizations
+SET
+	"name" = $1,
+	description = $2,
+	updated_at = $3
+WHERE
+	id = $4
+RETURNING id, name, description, created_at, updated_at
+`
+
+type UpdateOrganizationParams struct {
+	Name        string    `db:"name" json:"name"`
+	Description string    `db:"description" json:"description"`
+	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+	ID          uuid.UUID `db:"id" json:"id"`
+}
+
+func (q *sqlQuerier) UpdateOrganization(ctx context.Context, arg UpdateOrganizationParams) (Organization, error) {
+	row := q.db.QueryRowContext(ctx, updateOrganization,
+		arg.Name,
+		arg.Description,
+		arg.UpdatedAt,
+		arg.ID,
+	)
+	var i Organization
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const getParameterSchemasByJobID = `-- name: GetParameterSchemasByJobID :many
 SELECT
 	id, created_at, job_id, name, description, default_source_scheme, default_source_value, allow_override_source, default_destination_scheme, allow_override_destination, default_refresh, redisplay_value, validation_error, validation_condition, validation_type_system, validation_value_type, index
@@ -2254,7 +3540,7 @@ func (q *sqlQuerier) ParameterValues(ctx context.Context, arg ParameterValuesPar
 
 const getProvisionerDaemonByID = `-- name: GetProvisionerDaemonByID :one
 SELECT
-	id, created_at, updated_at, name, provisioners, replica_id, tags
+	id, created_at, updated_at, name, provisioners, replica_id, tags, organization_id
 FROM
 	provisioner_daemons
 WHERE
@@ -2272,13 +3558,14 @@ func (q *sqlQuerier) GetProvisionerDaemonByID(ctx context.Context, id uuid.UUID)
 		pq.Array(&i.Provisioners),
 		&i.ReplicaID,
 		&i.Tags,
+		&i.OrganizationID,
 	)
 	return i, err
 }
 
 const getProvisionerDaemons = `-- name: GetProvisionerDaemons :many
 SELECT
-	id, created_at, updated_at, name, provisioners, replica_id, tags
+	id, created_at, updated_at, name, provisioners, replica_id, tags, organization_id
 FROM
 	provisioner_daemons
 `
@@ -2300,6 +3587,7 @@ func (q *sqlQuerier) GetProvisionerDaemons(ctx context.Context) ([]ProvisionerDa
 			pq.Array(&i.Provisioners),
 			&i.ReplicaID,
 			&i.Tags,
+			&i.OrganizationID,
 		); err != nil {
 			return nil, err
 		}
@@ -2321,18 +3609,20 @@ INSERT INTO
 		created_at,
 		"name",
 		provisioners,
-		tags
+		tags,
+		organization_id
 	)
 VALUES
-	($1, $2, $3, $4, $5) RETURNING id, created_at, updated_at, name, provisioners, replica_id, tags
+	($1, $2, $3, $4, $5, $6) RETURNING id, created_at, updated_at, name, provisioners, replica_id, tags, organization_id
 `
 
 type InsertProvisionerDaemonParams struct {
-	ID           uuid.UUID         `db:"id" json:"id"`
-	CreatedAt    time.Time         `db:"created_at" json:"created_at"`
-	Name         string            `db:"name" json:"name"`
-	Provisioners []ProvisionerType `db:"provisioners" json:"provisioners"`
-	Tags         dbtype.StringMap  `db:"tags" json:"tags"`
+	ID             uuid.UUID         `db:"id" json:"id"`
+	CreatedAt      time.Time         `db:"created_at" json:"created_at"`
+	Name           string            `db:"name" json:"name"`
+	Provisioners   []ProvisionerType `db:"provisioners" json:"provisioners"`
+	Tags           dbtype.StringMap  `db:"tags" json:"tags"`
+	OrganizationID uuid.NullUUID     `db:"organization_id" json:"organization_id"`
 }
 
 func (q *sqlQuerier) InsertProvisionerDaemon(ctx context.Context, arg InsertProvisionerDaemonParams) (ProvisionerDaemon, error) {
@@ -2342,6 +3632,7 @@ func (q *sqlQuerier) InsertProvisionerDaemon(ctx context.Context, arg InsertProv
 		arg.Name,
 		pq.Array(arg.Provisioners),
 		arg.Tags,
+		arg.OrganizationID,
 	)
 	var i ProvisionerDaemon
 	err := row.Scan(
@@ -2352,6 +3643,7 @@ func (q *sqlQuerier) InsertProvisionerDaemon(ctx context.Context, arg InsertProv
 		pq.Array(&i.Provisioners),
 		&i.ReplicaID,
 		&i.Tags,
+		&i.OrganizationID,
 	)
 	return i, err
 }
@@ -2505,7 +3797,12 @@ WHERE
 			AND nested.completed_at IS NULL
 			AND nested.provisioner = ANY($3 :: provisioner_type [ ])
 			-- Ensure the caller satisfies all job tags.
-			AND nested.tags <@ $4 :: jsonb 
+			AND nested.tags <@ $4 :: jsonb
+			AND CASE
+				WHEN $5 :: uuid != '00000000-0000-0000-0000-000000000000'::uuid THEN
+					nested.organization_id = $5
+				ELSE true
+			END
 		ORDER BY
 			nested.created_at
 		FOR UPDATE
@@ -2516,14 +3813,16 @@ WHERE
 `
 
 type AcquireProvisionerJobParams struct {
-	StartedAt sql.NullTime      `db:"started_at" json:"started_at"`
-	WorkerID  uuid.NullUUID     `db:"worker_id" json:"worker_id"`
-	Types     []ProvisionerType `db:"types" json:"types"`
-	Tags      json.RawMessage   `db:"tags" json:"tags"`
+	StartedAt      sql.NullTime      `db:"started_at" json:"started_at"`
+	WorkerID       uuid.NullUUID     `db:"worker_id" json:"worker_id"`
+	Types          []ProvisionerType `db:"types" json:"types"`
+	Tags           json.RawMessage   `db:"tags" json:"tags"`
+	OrganizationID uuid.UUID         `db:"organization_id" json:"organization_id"`
 }
 
 // Acquires the lock for a single job that isn't started, completed,
-// canceled, and that matches an array of provisioner types.
+// canceled, and that matches an array of provisioner types. Jobs are only
+// acquired from the given organization, unless it's the zero UUID.
 //
 // SKIP LOCKED is used to jump over locked rows. This prevents
 // multiple provisioners from acquiring the same jobs. See:
@@ -2534,6 +3833,7 @@ func (q *sqlQuerier) AcquireProvisionerJob(ctx context.Context, arg AcquireProvi
 		arg.WorkerID,
 		pq.Array(arg.Types),
 		arg.Tags,
+		arg.OrganizationID,
 	)
 	var i ProvisionerJob
 	err := row.Scan(
@@ -2829,10 +4129,22 @@ JOIN groups g ON
 	g.id = gm.group_id
 WHERE
 	user_id = $1
+	AND CASE
+		WHEN $2 :: uuid != '00000000-0000-0000-0000-000000000000'::uuid THEN
+			g.organization_id = $2
+		ELSE true
+	END
 `
 
-func (q *sqlQuerier) GetQuotaAllowanceForUser(ctx context.Context, userID uuid.UUID) (int64, error) {
-	row := q.db.QueryRowContext(ctx, getQuotaAllowanceForUser, userID)
+type GetQuotaAllowanceForUserParams struct {
+	UserID         uuid.UUID `db:"user_id" json:"user_id"`
+	OrganizationID uuid.UUID `db:"organization_id" json:"organization_id"`
+}
+
+// Quotas are summed within an organization, or across all organizations
+// when the zero UUID is given.
+func (q *sqlQuerier) GetQuotaAllowanceForUser(ctx context.Context, arg GetQuotaAllowanceForUserParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getQuotaAllowanceForUser, arg.UserID, arg.OrganizationID)
 	var column_1 int64
 	err := row.Scan(&column_1)
 	return column_1, err
@@ -2857,11 +4169,23 @@ FROM
 	workspaces
 JOIN latest_builds ON
 	latest_builds.workspace_id = workspaces.id
-WHERE NOT deleted AND workspaces.owner_id = $1
+WHERE
+	NOT deleted
+	AND workspaces.owner_id = $1
+	AND CASE
+		WHEN $2 :: uuid != '00000000-0000-0000-0000-000000000000'::uuid THEN
+			workspaces.organization_id = $2
+		ELSE true
+	END
 `
 
-func (q *sqlQuerier) GetQuotaConsumedForUser(ctx context.Context, ownerID uuid.UUID) (int64, error) {
-	row := q.db.QueryRowContext(ctx, getQuotaConsumedForUser, ownerID)
+type GetQuotaConsumedForUserParams struct {
+	OwnerID        uuid.UUID `db:"owner_id" json:"owner_id"`
+	OrganizationID uuid.UUID `db:"organization_id" json:"organization_id"`
+}
+
+func (q *sqlQuerier) GetQuotaConsumedForUser(ctx context.Context, arg GetQuotaConsumedForUserParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getQuotaConsumedForUser, arg.OwnerID, arg.OrganizationID)
 	var column_1 int64
 	err := row.Scan(&column_1)
 	return column_1, err
@@ -3049,6 +4373,17 @@ func (q *sqlQuerier) GetDeploymentID(ctx context.Context) (string, error) {
 	return value, err
 }
 
+const getLastAutostopNoticeCheck = `-- name: GetLastAutostopNoticeCheck :one
+SELECT COALESCE((SELECT value FROM site_configs WHERE key = 'last_autostop_notice_check'), '') :: text AS value
+`
+
+func (q *sqlQuerier) GetLastAutostopNoticeCheck(ctx context.Context) (string, error) {
+	row := q.db.QueryRowContext(ctx, getLastAutostopNoticeCheck)
+	var value string
+	err := row.Scan(&value)
+	return value, err
+}
+
 const insertDERPMeshKey = `-- name: InsertDERPMeshKey :exec
 INSERT INTO site_configs (key, value) VALUES ('derp_mesh_key', $1)
 `
@@ -3067,6 +4402,16 @@ func (q *sqlQuerier) InsertDeploymentID(ctx context.Context, value string) error
 	return err
 }
 
+const upsertLastAutostopNoticeCheck = `-- name: UpsertLastAutostopNoticeCheck :exec
+INSERT INTO site_configs (key, value) VALUES ('last_autostop_notice_check', $1)
+ON CONFLICT (key) DO UPDATE SET value = $1 WHERE site_configs.key = 'last_autostop_notice_check'
+`
+
+func (q *sqlQuerier) UpsertLastAutostopNoticeCheck(ctx context.Context, value string) error {
+	_, err := q.db.ExecContext(ctx, upsertLastAutostopNoticeCheck, value)
+	return err
+}
+
 const getTemplateAverageBuildTime = `-- name: GetTemplateAverageBuildTime :one
 WITH build_times AS (
 SELECT
@@ -3116,7 +4461,7 @@ func (q *sqlQuerier) GetTemplateAverageBuildTime(ctx context.Context, arg GetTem
 
 const getTemplateByID = `-- name: GetTemplateByID :one
 SELECT
-	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name
+	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name, inactivity_ttl, stopped_ttl, max_ttl, autostart_days_of_week, autostart_start_hour, autostart_end_hour, quiet_hours_schedule, quiet_hours_duration, session_recording, auto_start_on_connect, max_port_sharing_level, autodelete_warning_ttl
 FROM
 	templates
 WHERE
@@ -3144,13 +4489,25 @@ func (q *sqlQuerier) GetTemplateByID(ctx context.Context, id uuid.UUID) (Templat
 		&i.UserACL,
 		&i.GroupACL,
 		&i.DisplayName,
+		&i.InactivityTTL,
+		&i.StoppedTTL,
+		&i.MaxTTL,
+		&i.AutostartDaysOfWeek,
+		&i.AutostartStartHour,
+		&i.AutostartEndHour,
+		&i.QuietHoursSchedule,
+		&i.QuietHoursDuration,
+		&i.SessionRecording,
+		&i.AutoStartOnConnect,
+		&i.MaxPortSharingLevel,
+		&i.AutodeleteWarningTTL,
 	)
 	return i, err
 }
 
 const getTemplateByOrganizationAndName = `-- name: GetTemplateByOrganizationAndName :one
 SELECT
-	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name
+	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name, inactivity_ttl, stopped_ttl, max_ttl, autostart_days_of_week, autostart_start_hour, autostart_end_hour, quiet_hours_schedule, quiet_hours_duration, session_recording, auto_start_on_connect, max_port_sharing_level, autodelete_warning_ttl
 FROM
 	templates
 WHERE
@@ -3186,12 +4543,24 @@ func (q *sqlQuerier) GetTemplateByOrganizationAndName(ctx context.Context, arg G
 		&i.UserACL,
 		&i.GroupACL,
 		&i.DisplayName,
+		&i.InactivityTTL,
+		&i.StoppedTTL,
+		&i.MaxTTL,
+		&i.AutostartDaysOfWeek,
+		&i.AutostartStartHour,
+		&i.AutostartEndHour,
+		&i.QuietHoursSchedule,
+		&i.QuietHoursDuration,
+		&i.SessionRecording,
+		&i.AutoStartOnConnect,
+		&i.MaxPortSharingLevel,
+		&i.AutodeleteWarningTTL,
 	)
 	return i, err
 }
 
 const getTemplates = `-- name: GetTemplates :many
-SELECT id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name FROM templates
+SELECT id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name, inactivity_ttl, stopped_ttl, max_ttl, autostart_days_of_week, autostart_start_hour, autostart_end_hour, quiet_hours_schedule, quiet_hours_duration, session_recording, auto_start_on_connect, max_port_sharing_level, autodelete_warning_ttl FROM templates
 ORDER BY (name, id) ASC
 `
 
@@ -3220,6 +4589,18 @@ func (q *sqlQuerier) GetTemplates(ctx context.Context) ([]Template, error) {
 			&i.UserACL,
 			&i.GroupACL,
 			&i.DisplayName,
+			&i.InactivityTTL,
+			&i.StoppedTTL,
+			&i.MaxTTL,
+			&i.AutostartDaysOfWeek,
+			&i.AutostartStartHour,
+			&i.AutostartEndHour,
+			&i.QuietHoursSchedule,
+			&i.QuietHoursDuration,
+			&i.SessionRecording,
+			&i.AutoStartOnConnect,
+			&i.MaxPortSharingLevel,
+			&i.AutodeleteWarningTTL,
 		); err != nil {
 			return nil, err
 		}
@@ -3236,7 +4617,7 @@ func (q *sqlQuerier) GetTemplates(ctx context.Context) ([]Template, error) {
 
 const getTemplatesWithFilter = `-- name: GetTemplatesWithFilter :many
 SELECT
-	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name
+	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name, inactivity_ttl, stopped_ttl, max_ttl, autostart_days_of_week, autostart_start_hour, autostart_end_hour, quiet_hours_schedule, quiet_hours_duration, session_recording, auto_start_on_connect, max_port_sharing_level, autodelete_warning_ttl
 FROM
 	templates
 WHERE
@@ -3300,6 +4681,18 @@ func (q *sqlQuerier) GetTemplatesWithFilter(ctx context.Context, arg GetTemplate
 			&i.UserACL,
 			&i.GroupACL,
 			&i.DisplayName,
+			&i.InactivityTTL,
+			&i.StoppedTTL,
+			&i.MaxTTL,
+			&i.AutostartDaysOfWeek,
+			&i.AutostartStartHour,
+			&i.AutostartEndHour,
+			&i.QuietHoursSchedule,
+			&i.QuietHoursDuration,
+			&i.SessionRecording,
+			&i.AutoStartOnConnect,
+			&i.MaxPortSharingLevel,
+			&i.AutodeleteWarningTTL,
 		); err != nil {
 			return nil, err
 		}
@@ -3330,27 +4723,49 @@ INSERT INTO
 		icon,
 		user_acl,
 		group_acl,
-		display_name
+		display_name,
+		inactivity_ttl,
+		stopped_ttl,
+		max_ttl,
+		autostart_days_of_week,
+		autostart_start_hour,
+		autostart_end_hour,
+		quiet_hours_schedule,
+		quiet_hours_duration,
+		session_recording,
+		auto_start_on_connect,
+		autodelete_warning_ttl
 	)
 VALUES
-	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) RETURNING id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name
+	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25) RETURNING id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name, inactivity_ttl, stopped_ttl, max_ttl, autostart_days_of_week, autostart_start_hour, autostart_end_hour, quiet_hours_schedule, quiet_hours_duration, session_recording, auto_start_on_connect, max_port_sharing_level, autodelete_warning_ttl
 `
 
 type InsertTemplateParams struct {
-	ID              uuid.UUID       `db:"id" json:"id"`
-	CreatedAt       time.Time       `db:"created_at" json:"created_at"`
-	UpdatedAt       time.Time       `db:"updated_at" json:"updated_at"`
-	OrganizationID  uuid.UUID       `db:"organization_id" json:"organization_id"`
-	Name            string          `db:"name" json:"name"`
-	Provisioner     ProvisionerType `db:"provisioner" json:"provisioner"`
-	ActiveVersionID uuid.UUID       `db:"active_version_id" json:"active_version_id"`
-	Description     string          `db:"description" json:"description"`
-	DefaultTTL      int64           `db:"default_ttl" json:"default_ttl"`
-	CreatedBy       uuid.UUID       `db:"created_by" json:"created_by"`
-	Icon            string          `db:"icon" json:"icon"`
-	UserACL         TemplateACL     `db:"user_acl" json:"user_acl"`
-	GroupACL        TemplateACL     `db:"group_acl" json:"group_acl"`
-	DisplayName     string          `db:"display_name" json:"display_name"`
+	ID                   uuid.UUID       `db:"id" json:"id"`
+	CreatedAt            time.Time       `db:"created_at" json:"created_at"`
+	UpdatedAt            time.Time       `db:"updated_at" json:"updated_at"`
+	OrganizationID       uuid.UUID       `db:"organization_id" json:"organization_id"`
+	Name                 string          `db:"name" json:"name"`
+	Provisioner          ProvisionerType `db:"provisioner" json:"provisioner"`
+	ActiveVersionID      uuid.UUID       `db:"active_version_id" json:"active_version_id"`
+	Description          string          `db:"description" json:"description"`
+	DefaultTTL           int64           `db:"default_ttl" json:"default_ttl"`
+	CreatedBy            uuid.UUID       `db:"created_by" json:"created_by"`
+	Icon                 string          `db:"icon" json:"icon"`
+	UserACL              TemplateACL     `db:"user_acl" json:"user_acl"`
+	GroupACL             TemplateACL     `db:"group_acl" json:"group_acl"`
+	DisplayName          string          `db:"display_name" json:"display_name"`
+	InactivityTTL        int64           `db:"inactivity_ttl" json:"inactivity_ttl"`
+	StoppedTTL           int64           `db:"stopped_ttl" json:"stopped_ttl"`
+	MaxTTL               int64           `db:"max_ttl" json:"max_ttl"`
+	AutostartDaysOfWeek  int16           `db:"autostart_days_of_week" json:"autostart_days_of_week"`
+	AutostartStartHour   int16           `db:"autostart_start_hour" json:"autostart_start_hour"`
+	AutostartEndHour     int16           `db:"autostart_end_hour" json:"autostart_end_hour"`
+	QuietHoursSchedule   string          `db:"quiet_hours_schedule" json:"quiet_hours_schedule"`
+	QuietHoursDuration   int64           `db:"quiet_hours_duration" json:"quiet_hours_duration"`
+	SessionRecording     bool            `db:"session_recording" json:"session_recording"`
+	AutoStartOnConnect   bool            `db:"auto_start_on_connect" json:"auto_start_on_connect"`
+	AutodeleteWarningTTL int64           `db:"autodelete_warning_ttl" json:"autodelete_warning_ttl"`
 }
 
 func (q *sqlQuerier) InsertTemplate(ctx context.Context, arg InsertTemplateParams) (Template, error) {
@@ -3369,6 +4784,17 @@ func (q *sqlQuerier) InsertTemplate(ctx context.Context, arg InsertTemplateParam
 		arg.UserACL,
 		arg.GroupACL,
 		arg.DisplayName,
+		arg.InactivityTTL,
+		arg.StoppedTTL,
+		arg.MaxTTL,
+		arg.AutostartDaysOfWeek,
+		arg.AutostartStartHour,
+		arg.AutostartEndHour,
+		arg.QuietHoursSchedule,
+		arg.QuietHoursDuration,
+		arg.SessionRecording,
+		arg.AutoStartOnConnect,
+		arg.AutodeleteWarningTTL,
 	)
 	var i Template
 	err := row.Scan(
@@ -3387,6 +4813,18 @@ func (q *sqlQuerier) InsertTemplate(ctx context.Context, arg InsertTemplateParam
 		&i.UserACL,
 		&i.GroupACL,
 		&i.DisplayName,
+		&i.InactivityTTL,
+		&i.StoppedTTL,
+		&i.MaxTTL,
+		&i.AutostartDaysOfWeek,
+		&i.AutostartStartHour,
+		&i.AutostartEndHour,
+		&i.QuietHoursSchedule,
+		&i.QuietHoursDuration,
+		&i.SessionRecording,
+		&i.AutoStartOnConnect,
+		&i.MaxPortSharingLevel,
+		&i.AutodeleteWarningTTL,
 	)
 	return i, err
 }
@@ -3400,7 +4838,7 @@ SET
 WHERE
 	id = $3
 RETURNING
-	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name
+	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name, inactivity_ttl, stopped_ttl, max_ttl, autostart_days_of_week, autostart_start_hour, autostart_end_hour, quiet_hours_schedule, quiet_hours_duration, session_recording, auto_start_on_connect, max_port_sharing_level, autodelete_warning_ttl
 `
 
 type UpdateTemplateACLByIDParams struct {
@@ -3428,6 +4866,18 @@ func (q *sqlQuerier) UpdateTemplateACLByID(ctx context.Context, arg UpdateTempla
 		&i.UserACL,
 		&i.GroupACL,
 		&i.DisplayName,
+		&i.InactivityTTL,
+		&i.StoppedTTL,
+		&i.MaxTTL,
+		&i.AutostartDaysOfWeek,
+		&i.AutostartStartHour,
+		&i.AutostartEndHour,
+		&i.QuietHoursSchedule,
+		&i.QuietHoursDuration,
+		&i.SessionRecording,
+		&i.AutoStartOnConnect,
+		&i.MaxPortSharingLevel,
+		&i.AutodeleteWarningTTL,
 	)
 	return i, err
 }
@@ -3483,21 +4933,45 @@ SET
 	default_ttl = $4,
 	name = $5,
 	icon = $6,
-	display_name = $7
+	display_name = $7,
+	inactivity_ttl = $8,
+	stopped_ttl = $9,
+	max_ttl = $10,
+	autostart_days_of_week = $11,
+	autostart_start_hour = $12,
+	autostart_end_hour = $13,
+	quiet_hours_schedule = $14,
+	quiet_hours_duration = $15,
+	session_recording = $16,
+	auto_start_on_connect = $17,
+	max_port_sharing_level = $18,
+	autodelete_warning_ttl = $19
 WHERE
 	id = $1
 RETURNING
-	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name
+	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name, inactivity_ttl, stopped_ttl, max_ttl, autostart_days_of_week, autostart_start_hour, autostart_end_hour, quiet_hours_schedule, quiet_hours_duration, session_recording, auto_start_on_connect, max_port_sharing_level, autodelete_warning_ttl
 `
 
 type UpdateTemplateMetaByIDParams struct {
-	ID          uuid.UUID `db:"id" json:"id"`
-	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
-	Description string    `db:"description" json:"description"`
-	DefaultTTL  int64     `db:"default_ttl" json:"default_ttl"`
-	Name        string    `db:"name" json:"name"`
-	Icon        string    `db:"icon" json:"icon"`
-	DisplayName string    `db:"display_name" json:"display_name"`
+	ID                   uuid.UUID       `db:"id" json:"id"`
+	UpdatedAt            time.Time       `db:"updated_at" json:"updated_at"`
+	Description          string          `db:"description" json:"description"`
+	DefaultTTL           int64           `db:"default_ttl" json:"default_ttl"`
+	Name                 string          `db:"name" json:"name"`
+	Icon                 string          `db:"icon" json:"icon"`
+	DisplayName          string          `db:"display_name" json:"display_name"`
+	InactivityTTL        int64           `db:"inactivity_ttl" json:"inactivity_ttl"`
+	StoppedTTL           int64           `db:"stopped_ttl" json:"stopped_ttl"`
+	MaxTTL               int64           `db:"max_ttl" json:"max_ttl"`
+	AutostartDaysOfWeek  int16           `db:"autostart_days_of_week" json:"autostart_days_of_week"`
+	AutostartStartHour   int16           `db:"autostart_start_hour" json:"autostart_start_hour"`
+	AutostartEndHour     int16           `db:"autostart_end_hour" json:"autostart_end_hour"`
+	QuietHoursSchedule   string          `db:"quiet_hours_schedule" json:"quiet_hours_schedule"`
+	QuietHoursDuration   int64           `db:"quiet_hours_duration" json:"quiet_hours_duration"`
+	SessionRecording     bool            `db:"session_recording" json:"session_recording"`
+	AutoStartOnConnect   bool            `db:"auto_start_on_connect" json:"auto_start_on_connect"`
+	MaxPortSharingLevel  AppSharingLevel `db:"max_port_sharing_level" json:"max_port_sharing_level"`
+	AutodeleteWarningTTL int64           `db:"autodelete_warning_ttl" json:"autodelete_warning_ttl"`
 }
 
 func (q *sqlQuerier) UpdateTemplateMetaByID(ctx context.Context, arg UpdateTemplateMetaByIDParams) (Template, error) {
@@ -3509,6 +4983,18 @@ func (q *sqlQuerier) UpdateTemplateMetaByID(ctx context.Context, arg UpdateTempl
 		arg.Name,
 		arg.Icon,
 		arg.DisplayName,
+		arg.InactivityTTL,
+		arg.StoppedTTL,
+		arg.MaxTTL,
+		arg.AutostartDaysOfWeek,
+		arg.AutostartStartHour,
+		arg.AutostartEndHour,
+		arg.QuietHoursSchedule,
+		arg.QuietHoursDuration,
+		arg.SessionRecording,
+		arg.AutoStartOnConnect,
+		arg.MaxPortSharingLevel,
+		arg.AutodeleteWarningTTL,
 	)
 	var i Template
 	err := row.Scan(
@@ -3527,6 +5013,152 @@ func (q *sqlQuerier) UpdateTemplateMetaByID(ctx context.Context, arg UpdateTempl
 		&i.UserACL,
 		&i.GroupACL,
 		&i.DisplayName,
+		&i.InactivityTTL,
+		&i.StoppedTTL,
+		&i.MaxTTL,
+		&i.AutostartDaysOfWeek,
+		&i.AutostartStartHour,
+		&i.AutostartEndHour,
+		&i.QuietHoursSchedule,
+		&i.QuietHoursDuration,
+		&i.SessionRecording,
+		&i.AutoStartOnConnect,
+		&i.MaxPortSharingLevel,
+		&i.AutodeleteWarningTTL,
+	)
+	return i, err
+}
+
+const getTemplateVersionParameters = `-- name: GetTemplateVersionParameters :many
+SELECT
+	template_version_id, name, description, type, mutable, default_value, icon, options, validation_regex, validation_min, validation_max, validation_error, index
+FROM
+	template_version_parameters
+WHERE
+	template_version_id = $1
+ORDER BY
+	index
+`
+
+func (q *sqlQuerier) GetTemplateVersionParameters(ctx context.Context, templateVersionID uuid.UUID) ([]TemplateVersionParameter, error) {
+	rows, err := q.db.QueryContext(ctx, getTemplateVersionParameters, templateVersionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TemplateVersionParameter
+	for rows.Next() {
+		var i TemplateVersionParameter
+		if err := rows.Scan(
+			&i.TemplateVersionID,
+			&i.Name,
+			&i.Description,
+			&i.Type,
+			&i.Mutable,
+			&i.DefaultValue,
+			&i.Icon,
+			&i.Options,
+			&i.ValidationRegex,
+			&i.ValidationMin,
+			&i.ValidationMax,
+			&i.ValidationError,
+			&i.Index,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertTemplateVersionParameter = `-- name: InsertTemplateVersionParameter :one
+INSERT INTO
+	template_version_parameters (
+		template_version_id,
+		name,
+		description,
+		type,
+		mutable,
+		default_value,
+		icon,
+		options,
+		validation_regex,
+		validation_min,
+		validation_max,
+		validation_error,
+		index
+	)
+VALUES
+	(
+		$1,
+		$2,
+		$3,
+		$4,
+		$5,
+		$6,
+		$7,
+		$8,
+		$9,
+		$10,
+		$11,
+		$12,
+		$13
+	) RETURNING template_version_id, name, description, type, mutable, default_value, icon, options, validation_regex, validation_min, validation_max, validation_error, index
+`
+
+type InsertTemplateVersionParameterParams struct {
+	TemplateVersionID uuid.UUID       `db:"template_version_id" json:"template_version_id"`
+	Name              string          `db:"name" json:"name"`
+	Description       string          `db:"description" json:"description"`
+	Type              string          `db:"type" json:"type"`
+	Mutable           bool            `db:"mutable" json:"mutable"`
+	DefaultValue      string          `db:"default_value" json:"default_value"`
+	Icon              string          `db:"icon" json:"icon"`
+	Options           json.RawMessage `db:"options" json:"options"`
+	ValidationRegex   string          `db:"validation_regex" json:"validation_regex"`
+	ValidationMin     sql.NullInt32   `db:"validation_min" json:"validation_min"`
+	ValidationMax     sql.NullInt32   `db:"validation_max" json:"validation_max"`
+	ValidationError   string          `db:"validation_error" json:"validation_error"`
+	Index             int32           `db:"index" json:"index"`
+}
+
+func (q *sqlQuerier) InsertTemplateVersionParameter(ctx context.Context, arg InsertTemplateVersionParameterParams) (TemplateVersionParameter, error) {
+	row := q.db.QueryRowContext(ctx, insertTemplateVersionParameter,
+		arg.TemplateVersionID,
+		arg.Name,
+		arg.Description,
+		arg.Type,
+		arg.Mutable,
+		arg.DefaultValue,
+		arg.Icon,
+		arg.Options,
+		arg.ValidationRegex,
+		arg.ValidationMin,
+		arg.ValidationMax,
+		arg.ValidationError,
+		arg.Index,
+	)
+	var i TemplateVersionParameter
+	err := row.Scan(
+		&i.TemplateVersionID,
+		&i.Name,
+		&i.Description,
+		&i.Type,
+		&i.Mutable,
+		&i.DefaultValue,
+		&i.Icon,
+		&i.Options,
+		&i.ValidationRegex,
+		&i.ValidationMin,
+		&i.ValidationMax,
+		&i.ValidationError,
+		&i.Index,
 	)
 	return i, err
 }
@@ -4160,7 +5792,7 @@ func (q *sqlQuerier) GetFilteredUserCount(ctx context.Context, arg GetFilteredUs
 
 const getUserByEmailOrUsername = `-- name: GetUserByEmailOrUsername :one
 SELECT
-	id, email, username, hashed_password, created_at, updated_at, status, rbac_roles, login_type, avatar_url, deleted, last_seen_at
+	id, email, username, hashed_password, created_at, updated_at, status, rbac_roles, login_type, avatar_url, deleted, last_seen_at, auto_start_on_connect
 FROM
 	users
 WHERE
@@ -4192,13 +5824,14 @@ func (q *sqlQuerier) GetUserByEmailOrUsername(ctx context.Context, arg GetUserBy
 		&i.AvatarURL,
 		&i.Deleted,
 		&i.LastSeenAt,
+		&i.AutoStartOnConnect,
 	)
 	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
 SELECT
-	id, email, username, hashed_password, created_at, updated_at, status, rbac_roles, login_type, avatar_url, deleted, last_seen_at
+	id, email, username, hashed_password, created_at, updated_at, status, rbac_roles, login_type, avatar_url, deleted, last_seen_at, auto_start_on_connect
 FROM
 	users
 WHERE
@@ -4223,6 +5856,7 @@ func (q *sqlQuerier) GetUserByID(ctx context.Context, id uuid.UUID) (User, error
 		&i.AvatarURL,
 		&i.Deleted,
 		&i.LastSeenAt,
+		&i.AutoStartOnConnect,
 	)
 	return i, err
 }
@@ -4243,7 +5877,7 @@ func (q *sqlQuerier) GetUserCount(ctx context.Context) (int64, error) {
 
 const getUsers = `-- name: GetUsers :many
 SELECT
-	id, email, username, hashed_password, created_at, updated_at, status, rbac_roles, login_type, avatar_url, deleted, last_seen_at, COUNT(*) OVER() AS count
+	id, email, username, hashed_password, created_at, updated_at, status, rbac_roles, login_type, avatar_url, deleted, last_seen_at, auto_start_on_connect, COUNT(*) OVER() AS count
 FROM
 	users
 WHERE
@@ -4313,19 +5947,20 @@ type GetUsersParams struct {
 }
 
 type GetUsersRow struct {
-	ID             uuid.UUID      `db:"id" json:"id"`
-	Email          string         `db:"email" json:"email"`
-	Username       string         `db:"username" json:"username"`
-	HashedPassword []byte         `db:"hashed_password" json:"hashed_password"`
-	CreatedAt      time.Time      `db:"created_at" json:"created_at"`
-	UpdatedAt      time.Time      `db:"updated_at" json:"updated_at"`
-	Status         UserStatus     `db:"status" json:"status"`
-	RBACRoles      pq.StringArray `db:"rbac_roles" json:"rbac_roles"`
-	LoginType      LoginType      `db:"login_type" json:"login_type"`
-	AvatarURL      sql.NullString `db:"avatar_url" json:"avatar_url"`
-	Deleted        bool           `db:"deleted" json:"deleted"`
-	LastSeenAt     time.Time      `db:"last_seen_at" json:"last_seen_at"`
-	Count          int64          `db:"count" json:"count"`
+	ID                 uuid.UUID      `db:"id" json:"id"`
+	Email              string         `db:"email" json:"email"`
+	Username           string         `db:"username" json:"username"`
+	HashedPassword     []byte         `db:"hashed_password" json:"hashed_password"`
+	CreatedAt          time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt          time.Time      `db:"updated_at" json:"updated_at"`
+	Status             UserStatus     `db:"status" json:"status"`
+	RBACRoles          pq.StringArray `db:"rbac_roles" json:"rbac_roles"`
+	LoginType          LoginType      `db:"login_type" json:"login_type"`
+	AvatarURL          sql.NullString `db:"avatar_url" json:"avatar_url"`
+	Deleted            bool           `db:"deleted" json:"deleted"`
+	LastSeenAt         time.Time      `db:"last_seen_at" json:"last_seen_at"`
+	AutoStartOnConnect bool           `db:"auto_start_on_connect" json:"auto_start_on_connect"`
+	Count              int64          `db:"count" json:"count"`
 }
 
 func (q *sqlQuerier) GetUsers(ctx context.Context, arg GetUsersParams) ([]GetUsersRow, error) {
@@ -4358,6 +5993,7 @@ func (q *sqlQuerier) GetUsers(ctx context.Context, arg GetUsersParams) ([]GetUse
 			&i.AvatarURL,
 			&i.Deleted,
 			&i.LastSeenAt,
+			&i.AutoStartOnConnect,
 			&i.Count,
 		); err != nil {
 			return nil, err
@@ -4374,7 +6010,7 @@ func (q *sqlQuerier) GetUsers(ctx context.Context, arg GetUsersParams) ([]GetUse
 }
 
 const getUsersByIDs = `-- name: GetUsersByIDs :many
-SELECT id, email, username, hashed_password, created_at, updated_at, status, rbac_roles, login_type, avatar_url, deleted, last_seen_at FROM users WHERE id = ANY($1 :: uuid [ ])
+SELECT id, email, username, hashed_password, created_at, updated_at, status, rbac_roles, login_type, avatar_url, deleted, last_seen_at, auto_start_on_connect FROM users WHERE id = ANY($1 :: uuid [ ])
 `
 
 // This shouldn't check for deleted, because it's frequently used
@@ -4402,6 +6038,7 @@ func (q *sqlQuerier) GetUsersByIDs(ctx context.Context, ids []uuid.UUID) ([]User
 			&i.AvatarURL,
 			&i.Deleted,
 			&i.LastSeenAt,
+			&i.AutoStartOnConnect,
 		); err != nil {
 			return nil, err
 		}
@@ -4429,7 +6066,7 @@ INSERT INTO
 		login_type
 	)
 VALUES
-	($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id, email, username, hashed_password, created_at, updated_at, status, rbac_roles, login_type, avatar_url, deleted, last_seen_at
+	($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id, email, username, hashed_password, created_at, updated_at, status, rbac_roles, login_type, avatar_url, deleted, last_seen_at, auto_start_on_connect
 `
 
 type InsertUserParams struct {
@@ -4468,6 +6105,7 @@ func (q *sqlQuerier) InsertUser(ctx context.Context, arg InsertUserParams) (User
 		&i.AvatarURL,
 		&i.Deleted,
 		&i.LastSeenAt,
+		&i.AutoStartOnConnect,
 	)
 	return i, err
 }
@@ -4517,7 +6155,7 @@ SET
 	last_seen_at = $2,
 	updated_at = $3
 WHERE
-	id = $1 RETURNING id, email, username, hashed_password, created_at, updated_at, status, rbac_roles, login_type, avatar_url, deleted, last_seen_at
+	id = $1 RETURNING id, email, username, hashed_password, created_at, updated_at, status, rbac_roles, login_type, avatar_url, deleted, last_seen_at, auto_start_on_connect
 `
 
 type UpdateUserLastSeenAtParams struct {
@@ -4542,6 +6180,44 @@ func (q *sqlQuerier) UpdateUserLastSeenAt(ctx context.Context, arg UpdateUserLas
 		&i.AvatarURL,
 		&i.Deleted,
 		&i.LastSeenAt,
+		&i.AutoStartOnConnect,
+	)
+	return i, err
+}
+
+const updateUserPreferences = `-- name: UpdateUserPreferences :one
+UPDATE
+	users
+SET
+	auto_start_on_connect = $2,
+	updated_at = $3
+WHERE
+	id = $1 RETURNING id, email, username, hashed_password, created_at, updated_at, status, rbac_roles, login_type, avatar_url, deleted, last_seen_at, auto_start_on_connect
+`
+
+type UpdateUserPreferencesParams struct {
+	ID                 uuid.UUID `db:"id" json:"id"`
+	AutoStartOnConnect bool      `db:"auto_start_on_connect" json:"auto_start_on_connect"`
+	UpdatedAt          time.Time `db:"updated_at" json:"updated_at"`
+}
+
+func (q *sqlQuerier) UpdateUserPreferences(ctx context.Context, arg UpdateUserPreferencesParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserPreferences, arg.ID, arg.AutoStartOnConnect, arg.UpdatedAt)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.Username,
+		&i.HashedPassword,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Status,
+		&i.RBACRoles,
+		&i.LoginType,
+		&i.AvatarURL,
+		&i.Deleted,
+		&i.LastSeenAt,
+		&i.AutoStartOnConnect,
 	)
 	return i, err
 }
@@ -4555,7 +6231,7 @@ SET
 	avatar_url = $4,
 	updated_at = $5
 WHERE
-	id = $1 RETURNING id, email, username, hashed_password, created_at, updated_at, status, rbac_roles, login_type, avatar_url, deleted, last_seen_at
+	id = $1 RETURNING id, email, username, hashed_password, created_at, updated_at, status, rbac_roles, login_type, avatar_url, deleted, last_seen_at, auto_start_on_connect
 `
 
 type UpdateUserProfileParams struct {
@@ -4588,6 +6264,7 @@ func (q *sqlQuerier) UpdateUserProfile(ctx context.Context, arg UpdateUserProfil
 		&i.AvatarURL,
 		&i.Deleted,
 		&i.LastSeenAt,
+		&i.AutoStartOnConnect,
 	)
 	return i, err
 }
@@ -4600,7 +6277,7 @@ SET
 	rbac_roles = ARRAY(SELECT DISTINCT UNNEST($1 :: text[]))
 WHERE
 	id = $2
-RETURNING id, email, username, hashed_password, created_at, updated_at, status, rbac_roles, login_type, avatar_url, deleted, last_seen_at
+RETURNING id, email, username, hashed_password, created_at, updated_at, status, rbac_roles, login_type, avatar_url, deleted, last_seen_at, auto_start_on_connect
 `
 
 type UpdateUserRolesParams struct {
@@ -4608,65 +6285,613 @@ type UpdateUserRolesParams struct {
 	ID           uuid.UUID `db:"id" json:"id"`
 }
 
-func (q *sqlQuerier) UpdateUserRoles(ctx context.Context, arg UpdateUserRolesParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, updateUserRoles, pq.Array(arg.GrantedRoles), arg.ID)
-	var i User
-	err := row.Scan(
-		&i.ID,
-		&i.Email,
-		&i.Username,
-		&i.HashedPassword,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-		&i.Status,
-		&i.RBACRoles,
-		&i.LoginType,
-		&i.AvatarURL,
-		&i.Deleted,
-		&i.LastSeenAt,
+func (q *sqlQuerier) UpdateUserRoles(ctx context.Context, arg UpdateUserRolesParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserRoles, pq.Array(arg.GrantedRoles), arg.ID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.Username,
+		&i.HashedPassword,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Status,
+		&i.RBACRoles,
+		&i.LoginType,
+		&i.AvatarURL,
+		&i.Deleted,
+		&i.LastSeenAt,
+		&i.AutoStartOnConnect,
+	)
+	return i, err
+}
+
+const updateUserStatus = `-- name: UpdateUserStatus :one
+UPDATE
+	users
+SET
+	status = $2,
+	updated_at = $3
+WHERE
+	id = $1 RETURNING id, email, username, hashed_password, created_at, updated_at, status, rbac_roles, login_type, avatar_url, deleted, last_seen_at, auto_start_on_connect
+`
+
+type UpdateUserStatusParams struct {
+	ID        uuid.UUID  `db:"id" json:"id"`
+	Status    UserStatus `db:"status" json:"status"`
+	UpdatedAt time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+func (q *sqlQuerier) UpdateUserStatus(ctx context.Context, arg UpdateUserStatusParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserStatus, arg.ID, arg.Status, arg.UpdatedAt)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.Username,
+		&i.HashedPassword,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Status,
+		&i.RBACRoles,
+		&i.LoginType,
+		&i.AvatarURL,
+		&i.Deleted,
+		&i.LastSeenAt,
+		&i.AutoStartOnConnect,
+	)
+	return i, err
+}
+
+const acquireStaleWebhookDeliveries = `-- name: AcquireStaleWebhookDeliveries :many
+UPDATE
+	webhook_deliveries
+SET
+	updated_at = $1
+WHERE
+	id IN (
+		SELECT
+			id
+		FROM
+			webhook_deliveries AS stale
+		WHERE
+			stale.status = 'pending'
+			AND stale.updated_at < $2
+		FOR UPDATE SKIP LOCKED
+	)
+RETURNING id, webhook_id, event_id, event_type, payload, status, attempts, last_status_code, last_error, created_at, updated_at
+`
+
+type AcquireStaleWebhookDeliveriesParams struct {
+	Now           time.Time `db:"now" json:"now"`
+	UpdatedBefore time.Time `db:"updated_before" json:"updated_before"`
+}
+
+// AcquireStaleWebhookDeliveries claims pending deliveries that haven't been
+// attempted since updated_before, for example because the replica retrying
+// them stopped. Claiming updates updated_at, so other replicas skip them.
+func (q *sqlQuerier) AcquireStaleWebhookDeliveries(ctx context.Context, arg AcquireStaleWebhookDeliveriesParams) ([]WebhookDelivery, error) {
+	rows, err := q.db.QueryContext(ctx, acquireStaleWebhookDeliveries, arg.Now, arg.UpdatedBefore)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WebhookDelivery
+	for rows.Next() {
+		var i WebhookDelivery
+		if err := rows.Scan(
+			&i.ID,
+			&i.WebhookID,
+			&i.EventID,
+			&i.EventType,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.LastStatusCode,
+			&i.LastError,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteWebhookByID = `-- name: DeleteWebhookByID :exec
+DELETE FROM
+	webhooks
+WHERE
+	id = $1
+`
+
+func (q *sqlQuerier) DeleteWebhookByID(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteWebhookByID, id)
+	return err
+}
+
+const getWebhookByID = `-- name: GetWebhookByID :one
+SELECT
+	id, organization_id, name, url, secret, events, created_at, updated_at
+FROM
+	webhooks
+WHERE
+	id = $1
+LIMIT
+	1
+`
+
+func (q *sqlQuerier) GetWebhookByID(ctx context.Context, id uuid.UUID) (Webhook, error) {
+	row := q.db.QueryRowContext(ctx, getWebhookByID, id)
+	var i Webhook
+	err := row.Scan(
+		&i.ID,
+		&i.OrganizationID,
+		&i.Name,
+		&i.Url,
+		&i.Secret,
+		pq.Array(&i.Events),
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getWebhookDeliveriesByWebhookID = `-- name: GetWebhookDeliveriesByWebhookID :many
+SELECT
+	id, webhook_id, event_id, event_type, payload, status, attempts, last_status_code, last_error, created_at, updated_at
+FROM
+	webhook_deliveries
+WHERE
+	webhook_id = $1
+	AND CASE
+		WHEN $2 :: text != '' THEN
+			status = $2 :: webhook_delivery_status
+		ELSE true
+	END
+ORDER BY
+	created_at DESC
+LIMIT
+	-- A null limit means "no limit", so 0 means return all
+	NULLIF($3 :: int, 0)
+`
+
+type GetWebhookDeliveriesByWebhookIDParams struct {
+	WebhookID uuid.UUID `db:"webhook_id" json:"webhook_id"`
+	Status    string    `db:"status" json:"status"`
+	LimitOpt  int32     `db:"limit_opt" json:"limit_opt"`
+}
+
+func (q *sqlQuerier) GetWebhookDeliveriesByWebhookID(ctx context.Context, arg GetWebhookDeliveriesByWebhookIDParams) ([]WebhookDelivery, error) {
+	rows, err := q.db.QueryContext(ctx, getWebhookDeliveriesByWebhookID, arg.WebhookID, arg.Status, arg.LimitOpt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WebhookDelivery
+	for rows.Next() {
+		var i WebhookDelivery
+		if err := rows.Scan(
+			&i.ID,
+			&i.WebhookID,
+			&i.EventID,
+			&i.EventType,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.LastStatusCode,
+			&i.LastError,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getWebhooksByOrganizationID = `-- name: GetWebhooksByOrganizationID :many
+SELECT
+	id, organization_id, name, url, secret, events, created_at, updated_at
+FROM
+	webhooks
+WHERE
+	organization_id = $1
+ORDER BY
+	name ASC
+`
+
+func (q *sqlQuerier) GetWebhooksByOrganizationID(ctx context.Context, organizationID uuid.UUID) ([]Webhook, error) {
+	rows, err := q.db.QueryContext(ctx, getWebhooksByOrganizationID, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Webhook
+	for rows.Next() {
+		var i Webhook
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrganizationID,
+			&i.Name,
+			&i.Url,
+			&i.Secret,
+			pq.Array(&i.Events),
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertWebhook = `-- name: InsertWebhook :one
+INSERT INTO
+	webhooks (
+		id,
+		organization_id,
+		name,
+		url,
+		secret,
+		events,
+		created_at,
+		updated_at
+	)
+VALUES
+	($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id, organization_id, name, url, secret, events, created_at, updated_at
+`
+
+type InsertWebhookParams struct {
+	ID             uuid.UUID `db:"id" json:"id"`
+	OrganizationID uuid.UUID `db:"organization_id" json:"organization_id"`
+	Name           string    `db:"name" json:"name"`
+	Url            string    `db:"url" json:"url"`
+	Secret         string    `db:"secret" json:"secret"`
+	Events         []string  `db:"events" json:"events"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time `db:"updated_at" json:"updated_at"`
+}
+
+func (q *sqlQuerier) InsertWebhook(ctx context.Context, arg InsertWebhookParams) (Webhook, error) {
+	row := q.db.QueryRowContext(ctx, insertWebhook,
+		arg.ID,
+		arg.OrganizationID,
+		arg.Name,
+		arg.Url,
+		arg.Secret,
+		pq.Array(arg.Events),
+		arg.CreatedAt,
+		arg.UpdatedAt,
+	)
+	var i Webhook
+	err := row.Scan(
+		&i.ID,
+		&i.OrganizationID,
+		&i.Name,
+		&i.Url,
+		&i.Secret,
+		pq.Array(&i.Events),
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const insertWebhookDelivery = `-- name: InsertWebhookDelivery :one
+INSERT INTO
+	webhook_deliveries (
+		id,
+		webhook_id,
+		event_id,
+		event_type,
+		payload,
+		created_at,
+		updated_at
+	)
+VALUES
+	($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (webhook_id, event_id) DO NOTHING RETURNING id, webhook_id, event_id, event_type, payload, status, attempts, last_status_code, last_error, created_at, updated_at
+`
+
+type InsertWebhookDeliveryParams struct {
+	ID        uuid.UUID       `db:"id" json:"id"`
+	WebhookID uuid.UUID       `db:"webhook_id" json:"webhook_id"`
+	EventID   uuid.UUID       `db:"event_id" json:"event_id"`
+	EventType string          `db:"event_type" json:"event_type"`
+	Payload   json.RawMessage `db:"payload" json:"payload"`
+	CreatedAt time.Time       `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time       `db:"updated_at" json:"updated_at"`
+}
+
+// InsertWebhookDelivery returns no rows if the event was already claimed
+// for the webhook, for example by another replica.
+func (q *sqlQuerier) InsertWebhookDelivery(ctx context.Context, arg InsertWebhookDeliveryParams) (WebhookDelivery, error) {
+	row := q.db.QueryRowContext(ctx, insertWebhookDelivery,
+		arg.ID,
+		arg.WebhookID,
+		arg.EventID,
+		arg.EventType,
+		arg.Payload,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+	)
+	var i WebhookDelivery
+	err := row.Scan(
+		&i.ID,
+		&i.WebhookID,
+		&i.EventID,
+		&i.EventType,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.LastStatusCode,
+		&i.LastError,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const updateWebhookByID = `-- name: UpdateWebhookByID :one
+UPDATE
+	webhooks
+SET
+	name = $2,
+	url = $3,
+	events = $4,
+	updated_at = $5
+WHERE
+	id = $1
+RETURNING id, organization_id, name, url, secret, events, created_at, updated_at
+`
+
+type UpdateWebhookByIDParams struct {
+	ID        uuid.UUID `db:"id" json:"id"`
+	Name      string    `db:"name" json:"name"`
+	Url       string    `db:"url" json:"url"`
+	Events    []string  `db:"events" json:"events"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+func (q *sqlQuerier) UpdateWebhookByID(ctx context.Context, arg UpdateWebhookByIDParams) (Webhook, error) {
+	row := q.db.QueryRowContext(ctx, updateWebhookByID,
+		arg.ID,
+		arg.Name,
+		arg.Url,
+		pq.Array(arg.Events),
+		arg.UpdatedAt,
+	)
+	var i Webhook
+	err := row.Scan(
+		&i.ID,
+		&i.OrganizationID,
+		&i.Name,
+		&i.Url,
+		&i.Secret,
+		pq.Array(&i.Events),
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const updateWebhookDeliveryByID = `-- name: UpdateWebhookDeliveryByID :exec
+UPDATE
+	webhook_deliveries
+SET
+	status = $2,
+	attempts = $3,
+	last_status_code = $4,
+	last_error = $5,
+	updated_at = $6
+WHERE
+	id = $1
+`
+
+type UpdateWebhookDeliveryByIDParams struct {
+	ID             uuid.UUID             `db:"id" json:"id"`
+	Status         WebhookDeliveryStatus `db:"status" json:"status"`
+	Attempts       int32                 `db:"attempts" json:"attempts"`
+	LastStatusCode int32                 `db:"last_status_code" json:"last_status_code"`
+	LastError      string                `db:"last_error" json:"last_error"`
+	UpdatedAt      time.Time             `db:"updated_at" json:"updated_at"`
+}
+
+func (q *sqlQuerier) UpdateWebhookDeliveryByID(ctx context.Context, arg UpdateWebhookDeliveryByIDParams) error {
+	_, err := q.db.ExecContext(ctx, updateWebhookDeliveryByID,
+		arg.ID,
+		arg.Status,
+		arg.Attempts,
+		arg.LastStatusCode,
+		arg.LastError,
+		arg.UpdatedAt,
+	)
+	return err
+}
+
+const deleteWorkspaceAgentPortShare = `-- name: DeleteWorkspaceAgentPortShare :exec
+DELETE FROM
+	workspace_agent_port_shares
+WHERE
+	workspace_id = $1
+	AND agent_name = $2
+	AND port = $3
+	AND protocol = $4
+`
+
+type DeleteWorkspaceAgentPortShareParams struct {
+	WorkspaceID uuid.UUID         `db:"workspace_id" json:"workspace_id"`
+	AgentName   string            `db:"agent_name" json:"agent_name"`
+	Port        int32             `db:"port" json:"port"`
+	Protocol    PortShareProtocol `db:"protocol" json:"protocol"`
+}
+
+func (q *sqlQuerier) DeleteWorkspaceAgentPortShare(ctx context.Context, arg DeleteWorkspaceAgentPortShareParams) error {
+	_, err := q.db.ExecContext(ctx, deleteWorkspaceAgentPortShare,
+		arg.WorkspaceID,
+		arg.AgentName,
+		arg.Port,
+		arg.Protocol,
+	)
+	return err
+}
+
+const getWorkspaceAgentPortShare = `-- name: GetWorkspaceAgentPortShare :one
+SELECT
+	workspace_id, agent_name, port, sharing_level, created_at, updated_at, protocol
+FROM
+	workspace_agent_port_shares
+WHERE
+	workspace_id = $1
+	AND agent_name = $2
+	AND port = $3
+	AND protocol = $4
+`
+
+type GetWorkspaceAgentPortShareParams struct {
+	WorkspaceID uuid.UUID         `db:"workspace_id" json:"workspace_id"`
+	AgentName   string            `db:"agent_name" json:"agent_name"`
+	Port        int32             `db:"port" json:"port"`
+	Protocol    PortShareProtocol `db:"protocol" json:"protocol"`
+}
+
+func (q *sqlQuerier) GetWorkspaceAgentPortShare(ctx context.Context, arg GetWorkspaceAgentPortShareParams) (WorkspaceAgentPortShare, error) {
+	row := q.db.QueryRowContext(ctx, getWorkspaceAgentPortShare,
+		arg.WorkspaceID,
+		arg.AgentName,
+		arg.Port,
+		arg.Protocol,
+	)
+	var i WorkspaceAgentPortShare
+	err := row.Scan(
+		&i.WorkspaceID,
+		&i.AgentName,
+		&i.Port,
+		&i.SharingLevel,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Protocol,
+	)
+	return i, err
+}
+
+const getWorkspaceAgentPortSharesByWorkspaceID = `-- name: GetWorkspaceAgentPortSharesByWorkspaceID :many
+SELECT
+	workspace_id, agent_name, port, sharing_level, created_at, updated_at, protocol
+FROM
+	workspace_agent_port_shares
+WHERE
+	workspace_id = $1
+ORDER BY
+	agent_name, port, protocol
+`
+
+func (q *sqlQuerier) GetWorkspaceAgentPortSharesByWorkspaceID(ctx context.Context, workspaceID uuid.UUID) ([]WorkspaceAgentPortShare, error) {
+	rows, err := q.db.QueryContext(ctx, getWorkspaceAgentPortSharesByWorkspaceID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WorkspaceAgentPortShare
+	for rows.Next() {
+		var i WorkspaceAgentPortShare
+		if err := rows.Scan(
+			&i.WorkspaceID,
+			&i.AgentName,
+			&i.Port,
+			&i.SharingLevel,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Protocol,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertWorkspaceAgentPortShare = `-- name: UpsertWorkspaceAgentPortShare :one
+INSERT INTO
+	workspace_agent_port_shares (
+		workspace_id,
+		agent_name,
+		port,
+		protocol,
+		sharing_level,
+		created_at,
+		updated_at
 	)
-	return i, err
-}
-
-const updateUserStatus = `-- name: UpdateUserStatus :one
-UPDATE
-	users
-SET
-	status = $2,
-	updated_at = $3
-WHERE
-	id = $1 RETURNING id, email, username, hashed_password, created_at, updated_at, status, rbac_roles, login_type, avatar_url, deleted, last_seen_at
+VALUES
+	($1, $2, $3, $4, $5, $6, $6)
+ON CONFLICT (workspace_id, agent_name, port, protocol) DO UPDATE SET
+	sharing_level = $5,
+	updated_at = $6
+RETURNING workspace_id, agent_name, port, sharing_level, created_at, updated_at, protocol
 `
 
-type UpdateUserStatusParams struct {
-	ID        uuid.UUID  `db:"id" json:"id"`
-	Status    UserStatus `db:"status" json:"status"`
-	UpdatedAt time.Time  `db:"updated_at" json:"updated_at"`
+type UpsertWorkspaceAgentPortShareParams struct {
+	WorkspaceID  uuid.UUID         `db:"workspace_id" json:"workspace_id"`
+	AgentName    string            `db:"agent_name" json:"agent_name"`
+	Port         int32             `db:"port" json:"port"`
+	Protocol     PortShareProtocol `db:"protocol" json:"protocol"`
+	SharingLevel AppSharingLevel   `db:"sharing_level" json:"sharing_level"`
+	CreatedAt    time.Time         `db:"created_at" json:"created_at"`
 }
 
-func (q *sqlQuerier) UpdateUserStatus(ctx context.Context, arg UpdateUserStatusParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, updateUserStatus, arg.ID, arg.Status, arg.UpdatedAt)
-	var i User
+func (q *sqlQuerier) UpsertWorkspaceAgentPortShare(ctx context.Context, arg UpsertWorkspaceAgentPortShareParams) (WorkspaceAgentPortShare, error) {
+	row := q.db.QueryRowContext(ctx, upsertWorkspaceAgentPortShare,
+		arg.WorkspaceID,
+		arg.AgentName,
+		arg.Port,
+		arg.Protocol,
+		arg.SharingLevel,
+		arg.CreatedAt,
+	)
+	var i WorkspaceAgentPortShare
 	err := row.Scan(
-		&i.ID,
-		&i.Email,
-		&i.Username,
-		&i.HashedPassword,
+		&i.WorkspaceID,
+		&i.AgentName,
+		&i.Port,
+		&i.SharingLevel,
 		&i.CreatedAt,
 		&i.UpdatedAt,
-		&i.Status,
-		&i.RBACRoles,
-		&i.LoginType,
-		&i.AvatarURL,
-		&i.Deleted,
-		&i.LastSeenAt,
+		&i.Protocol,
 	)
 	return i, err
 }
 
 const getWorkspaceAgentByAuthToken = `-- name: GetWorkspaceAgentByAuthToken :one
 SELECT
-	id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version, last_connected_replica_id, connection_timeout_seconds, troubleshooting_url
+	id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version, last_connected_replica_id, connection_timeout_seconds, troubleshooting_url, startup_logs_eof, lifecycle_state, startup_script_timeout_seconds, shutdown_script, shutdown_script_timeout_seconds, startup_logs_length, startup_logs_overflowed
 FROM
 	workspace_agents
 WHERE
@@ -4700,13 +6925,20 @@ func (q *sqlQuerier) GetWorkspaceAgentByAuthToken(ctx context.Context, authToken
 		&i.LastConnectedReplicaID,
 		&i.ConnectionTimeoutSeconds,
 		&i.TroubleshootingURL,
+		&i.StartupLogsEOF,
+		&i.LifecycleState,
+		&i.StartupScriptTimeoutSeconds,
+		&i.ShutdownScript,
+		&i.ShutdownScriptTimeoutSeconds,
+		&i.StartupLogsLength,
+		&i.StartupLogsOverflowed,
 	)
 	return i, err
 }
 
 const getWorkspaceAgentByID = `-- name: GetWorkspaceAgentByID :one
 SELECT
-	id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version, last_connected_replica_id, connection_timeout_seconds, troubleshooting_url
+	id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version, last_connected_replica_id, connection_timeout_seconds, troubleshooting_url, startup_logs_eof, lifecycle_state, startup_script_timeout_seconds, shutdown_script, shutdown_script_timeout_seconds, startup_logs_length, startup_logs_overflowed
 FROM
 	workspace_agents
 WHERE
@@ -4738,13 +6970,20 @@ func (q *sqlQuerier) GetWorkspaceAgentByID(ctx context.Context, id uuid.UUID) (W
 		&i.LastConnectedReplicaID,
 		&i.ConnectionTimeoutSeconds,
 		&i.TroubleshootingURL,
+		&i.StartupLogsEOF,
+		&i.LifecycleState,
+		&i.StartupScriptTimeoutSeconds,
+		&i.ShutdownScript,
+		&i.ShutdownScriptTimeoutSeconds,
+		&i.StartupLogsLength,
+		&i.StartupLogsOverflowed,
 	)
 	return i, err
 }
 
 const getWorkspaceAgentByInstanceID = `-- name: GetWorkspaceAgentByInstanceID :one
 SELECT
-	id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version, last_connected_replica_id, connection_timeout_seconds, troubleshooting_url
+	id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version, last_connected_replica_id, connection_timeout_seconds, troubleshooting_url, startup_logs_eof, lifecycle_state, startup_script_timeout_seconds, shutdown_script, shutdown_script_timeout_seconds, startup_logs_length, startup_logs_overflowed
 FROM
 	workspace_agents
 WHERE
@@ -4778,13 +7017,109 @@ func (q *sqlQuerier) GetWorkspaceAgentByInstanceID(ctx context.Context, authInst
 		&i.LastConnectedReplicaID,
 		&i.ConnectionTimeoutSeconds,
 		&i.TroubleshootingURL,
+		&i.StartupLogsEOF,
+		&i.LifecycleState,
+		&i.StartupScriptTimeoutSeconds,
+		&i.ShutdownScript,
+		&i.ShutdownScriptTimeoutSeconds,
+		&i.StartupLogsLength,
+		&i.StartupLogsOverflowed,
 	)
 	return i, err
 }
 
+const getWorkspaceAgentMetadataByAgentIDs = `-- name: GetWorkspaceAgentMetadataByAgentIDs :many
+SELECT
+	workspace_agent_id, display_name, key, script, value, error, timeout, interval, collected_at
+FROM
+	workspace_agent_metadata
+WHERE
+	workspace_agent_id = ANY($1 :: uuid [ ])
+ORDER BY
+	key ASC
+`
+
+func (q *sqlQuerier) GetWorkspaceAgentMetadataByAgentIDs(ctx context.Context, ids []uuid.UUID) ([]WorkspaceAgentMetadatum, error) {
+	rows, err := q.db.QueryContext(ctx, getWorkspaceAgentMetadataByAgentIDs, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WorkspaceAgentMetadatum
+	for rows.Next() {
+		var i WorkspaceAgentMetadatum
+		if err := rows.Scan(
+			&i.WorkspaceAgentID,
+			&i.DisplayName,
+			&i.Key,
+			&i.Script,
+			&i.Value,
+			&i.Error,
+			&i.Timeout,
+			&i.Interval,
+			&i.CollectedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getWorkspaceAgentStartupLogsAfter = `-- name: GetWorkspaceAgentStartupLogsAfter :many
+SELECT
+	agent_id, created_at, output, id
+FROM
+	workspace_agent_startup_logs
+WHERE
+	agent_id = $1
+	AND (
+		id > $2
+	) ORDER BY id ASC
+`
+
+type GetWorkspaceAgentStartupLogsAfterParams struct {
+	AgentID      uuid.UUID `db:"agent_id" json:"agent_id"`
+	CreatedAfter int64     `db:"created_after" json:"created_after"`
+}
+
+func (q *sqlQuerier) GetWorkspaceAgentStartupLogsAfter(ctx context.Context, arg GetWorkspaceAgentStartupLogsAfterParams) ([]WorkspaceAgentStartupLog, error) {
+	rows, err := q.db.QueryContext(ctx, getWorkspaceAgentStartupLogsAfter, arg.AgentID, arg.CreatedAfter)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WorkspaceAgentStartupLog
+	for rows.Next() {
+		var i WorkspaceAgentStartupLog
+		if err := rows.Scan(
+			&i.AgentID,
+			&i.CreatedAt,
+			&i.Output,
+			&i.ID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getWorkspaceAgentsByResourceIDs = `-- name: GetWorkspaceAgentsByResourceIDs :many
 SELECT
-	id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version, last_connected_replica_id, connection_timeout_seconds, troubleshooting_url
+	id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version, last_connected_replica_id, connection_timeout_seconds, troubleshooting_url, startup_logs_eof, lifecycle_state, startup_script_timeout_seconds, shutdown_script, shutdown_script_timeout_seconds, startup_logs_length, startup_logs_overflowed
 FROM
 	workspace_agents
 WHERE
@@ -4822,6 +7157,13 @@ func (q *sqlQuerier) GetWorkspaceAgentsByResourceIDs(ctx context.Context, ids []
 			&i.LastConnectedReplicaID,
 			&i.ConnectionTimeoutSeconds,
 			&i.TroubleshootingURL,
+			&i.StartupLogsEOF,
+			&i.LifecycleState,
+			&i.StartupScriptTimeoutSeconds,
+			&i.ShutdownScript,
+			&i.ShutdownScriptTimeoutSeconds,
+			&i.StartupLogsLength,
+			&i.StartupLogsOverflowed,
 		); err != nil {
 			return nil, err
 		}
@@ -4837,7 +7179,7 @@ func (q *sqlQuerier) GetWorkspaceAgentsByResourceIDs(ctx context.Context, ids []
 }
 
 const getWorkspaceAgentsCreatedAfter = `-- name: GetWorkspaceAgentsCreatedAfter :many
-SELECT id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version, last_connected_replica_id, connection_timeout_seconds, troubleshooting_url FROM workspace_agents WHERE created_at > $1
+SELECT id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version, last_connected_replica_id, connection_timeout_seconds, troubleshooting_url, startup_logs_eof, lifecycle_state, startup_script_timeout_seconds, shutdown_script, shutdown_script_timeout_seconds, startup_logs_length, startup_logs_overflowed FROM workspace_agents WHERE created_at > $1
 `
 
 func (q *sqlQuerier) GetWorkspaceAgentsCreatedAfter(ctx context.Context, createdAt time.Time) ([]WorkspaceAgent, error) {
@@ -4871,6 +7213,13 @@ func (q *sqlQuerier) GetWorkspaceAgentsCreatedAfter(ctx context.Context, created
 			&i.LastConnectedReplicaID,
 			&i.ConnectionTimeoutSeconds,
 			&i.TroubleshootingURL,
+			&i.StartupLogsEOF,
+			&i.LifecycleState,
+			&i.StartupScriptTimeoutSeconds,
+			&i.ShutdownScript,
+			&i.ShutdownScriptTimeoutSeconds,
+			&i.StartupLogsLength,
+			&i.StartupLogsOverflowed,
 		); err != nil {
 			return nil, err
 		}
@@ -4903,29 +7252,35 @@ INSERT INTO
 		instance_metadata,
 		resource_metadata,
 		connection_timeout_seconds,
-		troubleshooting_url
+		troubleshooting_url,
+		startup_script_timeout_seconds,
+		shutdown_script,
+		shutdown_script_timeout_seconds
 	)
 VALUES
-	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16) RETURNING id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version, last_connected_replica_id, connection_timeout_seconds, troubleshooting_url
+	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19) RETURNING id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version, last_connected_replica_id, connection_timeout_seconds, troubleshooting_url, startup_logs_eof, lifecycle_state, startup_script_timeout_seconds, shutdown_script, shutdown_script_timeout_seconds, startup_logs_length, startup_logs_overflowed
 `
 
 type InsertWorkspaceAgentParams struct {
-	ID                       uuid.UUID             `db:"id" json:"id"`
-	CreatedAt                time.Time             `db:"created_at" json:"created_at"`
-	UpdatedAt                time.Time             `db:"updated_at" json:"updated_at"`
-	Name                     string                `db:"name" json:"name"`
-	ResourceID               uuid.UUID             `db:"resource_id" json:"resource_id"`
-	AuthToken                uuid.UUID             `db:"auth_token" json:"auth_token"`
-	AuthInstanceID           sql.NullString        `db:"auth_instance_id" json:"auth_instance_id"`
-	Architecture             string                `db:"architecture" json:"architecture"`
-	EnvironmentVariables     pqtype.NullRawMessage `db:"environment_variables" json:"environment_variables"`
-	OperatingSystem          string                `db:"operating_system" json:"operating_system"`
-	StartupScript            sql.NullString        `db:"startup_script" json:"startup_script"`
-	Directory                string                `db:"directory" json:"directory"`
-	InstanceMetadata         pqtype.NullRawMessage `db:"instance_metadata" json:"instance_metadata"`
-	ResourceMetadata         pqtype.NullRawMessage `db:"resource_metadata" json:"resource_metadata"`
-	ConnectionTimeoutSeconds int32                 `db:"connection_timeout_seconds" json:"connection_timeout_seconds"`
-	TroubleshootingURL       string                `db:"troubleshooting_url" json:"troubleshooting_url"`
+	ID                           uuid.UUID             `db:"id" json:"id"`
+	CreatedAt                    time.Time             `db:"created_at" json:"created_at"`
+	UpdatedAt                    time.Time             `db:"updated_at" json:"updated_at"`
+	Name                         string                `db:"name" json:"name"`
+	ResourceID                   uuid.UUID             `db:"resource_id" json:"resource_id"`
+	AuthToken                    uuid.UUID             `db:"auth_token" json:"auth_token"`
+	AuthInstanceID               sql.NullString        `db:"auth_instance_id" json:"auth_instance_id"`
+	Architecture                 string                `db:"architecture" json:"architecture"`
+	EnvironmentVariables         pqtype.NullRawMessage `db:"environment_variables" json:"environment_variables"`
+	OperatingSystem              string                `db:"operating_system" json:"operating_system"`
+	StartupScript                sql.NullString        `db:"startup_script" json:"startup_script"`
+	Directory                    string                `db:"directory" json:"directory"`
+	InstanceMetadata             pqtype.NullRawMessage `db:"instance_metadata" json:"instance_metadata"`
+	ResourceMetadata             pqtype.NullRawMessage `db:"resource_metadata" json:"resource_metadata"`
+	ConnectionTimeoutSeconds     int32                 `db:"connection_timeout_seconds" json:"connection_timeout_seconds"`
+	TroubleshootingURL           string                `db:"troubleshooting_url" json:"troubleshooting_url"`
+	StartupScriptTimeoutSeconds  int32                 `db:"startup_script_timeout_seconds" json:"startup_script_timeout_seconds"`
+	ShutdownScript               sql.NullString        `db:"shutdown_script" json:"shutdown_script"`
+	ShutdownScriptTimeoutSeconds int32                 `db:"shutdown_script_timeout_seconds" json:"shutdown_script_timeout_seconds"`
 }
 
 func (q *sqlQuerier) InsertWorkspaceAgent(ctx context.Context, arg InsertWorkspaceAgentParams) (WorkspaceAgent, error) {
@@ -4946,6 +7301,9 @@ func (q *sqlQuerier) InsertWorkspaceAgent(ctx context.Context, arg InsertWorkspa
 		arg.ResourceMetadata,
 		arg.ConnectionTimeoutSeconds,
 		arg.TroubleshootingURL,
+		arg.StartupScriptTimeoutSeconds,
+		arg.ShutdownScript,
+		arg.ShutdownScriptTimeoutSeconds,
 	)
 	var i WorkspaceAgent
 	err := row.Scan(
@@ -4970,10 +7328,109 @@ func (q *sqlQuerier) InsertWorkspaceAgent(ctx context.Context, arg InsertWorkspa
 		&i.LastConnectedReplicaID,
 		&i.ConnectionTimeoutSeconds,
 		&i.TroubleshootingURL,
+		&i.StartupLogsEOF,
+		&i.LifecycleState,
+		&i.StartupScriptTimeoutSeconds,
+		&i.ShutdownScript,
+		&i.ShutdownScriptTimeoutSeconds,
+		&i.StartupLogsLength,
+		&i.StartupLogsOverflowed,
 	)
 	return i, err
 }
 
+const insertWorkspaceAgentMetadata = `-- name: InsertWorkspaceAgentMetadata :exec
+INSERT INTO
+	workspace_agent_metadata (
+		workspace_agent_id,
+		display_name,
+		key,
+		script,
+		timeout,
+		interval
+	)
+VALUES
+	($1, $2, $3, $4, $5, $6)
+`
+
+type InsertWorkspaceAgentMetadataParams struct {
+	WorkspaceAgentID uuid.UUID `db:"workspace_agent_id" json:"workspace_agent_id"`
+	DisplayName      string    `db:"display_name" json:"display_name"`
+	Key              string    `db:"key" json:"key"`
+	Script           string    `db:"script" json:"script"`
+	Timeout          int64     `db:"timeout" json:"timeout"`
+	Interval         int64     `db:"interval" json:"interval"`
+}
+
+func (q *sqlQuerier) InsertWorkspaceAgentMetadata(ctx context.Context, arg InsertWorkspaceAgentMetadataParams) error {
+	_, err := q.db.ExecContext(ctx, insertWorkspaceAgentMetadata,
+		arg.WorkspaceAgentID,
+		arg.DisplayName,
+		arg.Key,
+		arg.Script,
+		arg.Timeout,
+		arg.Interval,
+	)
+	return err
+}
+
+const insertWorkspaceAgentStartupLogs = `-- name: InsertWorkspaceAgentStartupLogs :many
+WITH new_length AS (
+	UPDATE
+		workspace_agents
+	SET
+		startup_logs_length = startup_logs_length + $4
+	WHERE
+		workspace_agents.id = $1
+)
+INSERT INTO
+	workspace_agent_startup_logs
+SELECT
+	$1 :: uuid AS agent_id,
+	unnest($2 :: timestamptz [ ]) AS created_at,
+	unnest($3 :: TEXT [ ]) AS output RETURNING agent_id, created_at, output, id
+`
+
+type InsertWorkspaceAgentStartupLogsParams struct {
+	AgentID      uuid.UUID   `db:"agent_id" json:"agent_id"`
+	CreatedAt    []time.Time `db:"created_at" json:"created_at"`
+	Output       []string    `db:"output" json:"output"`
+	OutputLength int32       `db:"output_length" json:"output_length"`
+}
+
+func (q *sqlQuerier) InsertWorkspaceAgentStartupLogs(ctx context.Context, arg InsertWorkspaceAgentStartupLogsParams) ([]WorkspaceAgentStartupLog, error) {
+	rows, err := q.db.QueryContext(ctx, insertWorkspaceAgentStartupLogs,
+		arg.AgentID,
+		pq.Array(arg.CreatedAt),
+		pq.Array(arg.Output),
+		arg.OutputLength,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WorkspaceAgentStartupLog
+	for rows.Next() {
+		var i WorkspaceAgentStartupLog
+		if err := rows.Scan(
+			&i.AgentID,
+			&i.CreatedAt,
+			&i.Output,
+			&i.ID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const updateWorkspaceAgentConnectionByID = `-- name: UpdateWorkspaceAgentConnectionByID :exec
 UPDATE
 	workspace_agents
@@ -5008,6 +7465,94 @@ func (q *sqlQuerier) UpdateWorkspaceAgentConnectionByID(ctx context.Context, arg
 	return err
 }
 
+const updateWorkspaceAgentLifecycleStateByID = `-- name: UpdateWorkspaceAgentLifecycleStateByID :exec
+UPDATE
+	workspace_agents
+SET
+	lifecycle_state = $2
+WHERE
+	id = $1
+`
+
+type UpdateWorkspaceAgentLifecycleStateByIDParams struct {
+	ID             uuid.UUID                    `db:"id" json:"id"`
+	LifecycleState WorkspaceAgentLifecycleState `db:"lifecycle_state" json:"lifecycle_state"`
+}
+
+func (q *sqlQuerier) UpdateWorkspaceAgentLifecycleStateByID(ctx context.Context, arg UpdateWorkspaceAgentLifecycleStateByIDParams) error {
+	_, err := q.db.ExecContext(ctx, updateWorkspaceAgentLifecycleStateByID, arg.ID, arg.LifecycleState)
+	return err
+}
+
+const updateWorkspaceAgentMetadata = `-- name: UpdateWorkspaceAgentMetadata :exec
+UPDATE
+	workspace_agent_metadata
+SET
+	value = $3,
+	error = $4,
+	collected_at = $5
+WHERE
+	workspace_agent_id = $1
+	AND key = $2
+`
+
+type UpdateWorkspaceAgentMetadataParams struct {
+	WorkspaceAgentID uuid.UUID `db:"workspace_agent_id" json:"workspace_agent_id"`
+	Key              string    `db:"key" json:"key"`
+	Value            string    `db:"value" json:"value"`
+	Error            string    `db:"error" json:"error"`
+	CollectedAt      time.Time `db:"collected_at" json:"collected_at"`
+}
+
+func (q *sqlQuerier) UpdateWorkspaceAgentMetadata(ctx context.Context, arg UpdateWorkspaceAgentMetadataParams) error {
+	_, err := q.db.ExecContext(ctx, updateWorkspaceAgentMetadata,
+		arg.WorkspaceAgentID,
+		arg.Key,
+		arg.Value,
+		arg.Error,
+		arg.CollectedAt,
+	)
+	return err
+}
+
+const updateWorkspaceAgentStartupLogOverflowByID = `-- name: UpdateWorkspaceAgentStartupLogOverflowByID :exec
+UPDATE
+	workspace_agents
+SET
+	startup_logs_overflowed = $2
+WHERE
+	id = $1
+`
+
+type UpdateWorkspaceAgentStartupLogOverflowByIDParams struct {
+	ID                    uuid.UUID `db:"id" json:"id"`
+	StartupLogsOverflowed bool      `db:"startup_logs_overflowed" json:"startup_logs_overflowed"`
+}
+
+func (q *sqlQuerier) UpdateWorkspaceAgentStartupLogOverflowByID(ctx context.Context, arg UpdateWorkspaceAgentStartupLogOverflowByIDParams) error {
+	_, err := q.db.ExecContext(ctx, updateWorkspaceAgentStartupLogOverflowByID, arg.ID, arg.StartupLogsOverflowed)
+	return err
+}
+
+const updateWorkspaceAgentStartupLogsEOFByID = `-- name: UpdateWorkspaceAgentStartupLogsEOFByID :exec
+UPDATE
+	workspace_agents
+SET
+	startup_logs_eof = $2
+WHERE
+	id = $1
+`
+
+type UpdateWorkspaceAgentStartupLogsEOFByIDParams struct {
+	ID             uuid.UUID `db:"id" json:"id"`
+	StartupLogsEOF bool      `db:"startup_logs_eof" json:"startup_logs_eof"`
+}
+
+func (q *sqlQuerier) UpdateWorkspaceAgentStartupLogsEOFByID(ctx context.Context, arg UpdateWorkspaceAgentStartupLogsEOFByIDParams) error {
+	_, err := q.db.ExecContext(ctx, updateWorkspaceAgentStartupLogsEOFByID, arg.ID, arg.StartupLogsEOF)
+	return err
+}
+
 const updateWorkspaceAgentVersionByID = `-- name: UpdateWorkspaceAgentVersionByID :exec
 UPDATE
 	workspace_agents
@@ -5260,22 +7805,76 @@ func (q *sqlQuerier) InsertWorkspaceApp(ctx context.Context, arg InsertWorkspace
 	return i, err
 }
 
-const updateWorkspaceAppHealthByID = `-- name: UpdateWorkspaceAppHealthByID :exec
-UPDATE
-	workspace_apps
-SET
-	health = $2
-WHERE
-	id = $1
+const updateWorkspaceAppHealthByID = `-- name: UpdateWorkspaceAppHealthByID :exec
+UPDATE
+	workspace_apps
+SET
+	health = $2
+WHERE
+	id = $1
+`
+
+type UpdateWorkspaceAppHealthByIDParams struct {
+	ID     uuid.UUID          `db:"id" json:"id"`
+	Health WorkspaceAppHealth `db:"health" json:"health"`
+}
+
+func (q *sqlQuerier) UpdateWorkspaceAppHealthByID(ctx context.Context, arg UpdateWorkspaceAppHealthByIDParams) error {
+	_, err := q.db.ExecContext(ctx, updateWorkspaceAppHealthByID, arg.ID, arg.Health)
+	return err
+}
+
+const getWorkspaceBuildParameters = `-- name: GetWorkspaceBuildParameters :many
+SELECT
+	workspace_build_id, name, value
+FROM
+	workspace_build_parameters
+WHERE
+	workspace_build_id = $1
+ORDER BY
+	name
+`
+
+func (q *sqlQuerier) GetWorkspaceBuildParameters(ctx context.Context, workspaceBuildID uuid.UUID) ([]WorkspaceBuildParameter, error) {
+	rows, err := q.db.QueryContext(ctx, getWorkspaceBuildParameters, workspaceBuildID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WorkspaceBuildParameter
+	for rows.Next() {
+		var i WorkspaceBuildParameter
+		if err := rows.Scan(&i.WorkspaceBuildID, &i.Name, &i.Value); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertWorkspaceBuildParameters = `-- name: InsertWorkspaceBuildParameters :exec
+INSERT INTO
+	workspace_build_parameters (workspace_build_id, name, value)
+SELECT
+	$1 :: uuid AS workspace_build_id,
+	unnest($2 :: text[]) AS name,
+	unnest($3 :: text[]) AS value
 `
 
-type UpdateWorkspaceAppHealthByIDParams struct {
-	ID     uuid.UUID          `db:"id" json:"id"`
-	Health WorkspaceAppHealth `db:"health" json:"health"`
+type InsertWorkspaceBuildParametersParams struct {
+	WorkspaceBuildID uuid.UUID `db:"workspace_build_id" json:"workspace_build_id"`
+	Name             []string  `db:"name" json:"name"`
+	Value            []string  `db:"value" json:"value"`
 }
 
-func (q *sqlQuerier) UpdateWorkspaceAppHealthByID(ctx context.Context, arg UpdateWorkspaceAppHealthByIDParams) error {
-	_, err := q.db.ExecContext(ctx, updateWorkspaceAppHealthByID, arg.ID, arg.Health)
+func (q *sqlQuerier) InsertWorkspaceBuildParameters(ctx context.Context, arg InsertWorkspaceBuildParametersParams) error {
+	_, err := q.db.ExecContext(ctx, insertWorkspaceBuildParameters, arg.WorkspaceBuildID, pq.Array(arg.Name), pq.Array(arg.Value))
 	return err
 }
 
@@ -5313,6 +7912,46 @@ func (q *sqlQuerier) GetLatestWorkspaceBuildByWorkspaceID(ctx context.Context, w
 	return i, err
 }
 
+const getLatestWorkspaceBuildByWorkspaceIDAndTransition = `-- name: GetLatestWorkspaceBuildByWorkspaceIDAndTransition :one
+SELECT
+	id, created_at, updated_at, workspace_id, template_version_id, build_number, transition, initiator_id, provisioner_state, job_id, deadline, reason, daily_cost
+FROM
+	workspace_builds
+WHERE
+	workspace_id = $1
+	AND transition = $2
+ORDER BY
+    build_number desc
+LIMIT
+	1
+`
+
+type GetLatestWorkspaceBuildByWorkspaceIDAndTransitionParams struct {
+	WorkspaceID uuid.UUID           `db:"workspace_id" json:"workspace_id"`
+	Transition  WorkspaceTransition `db:"transition" json:"transition"`
+}
+
+func (q *sqlQuerier) GetLatestWorkspaceBuildByWorkspaceIDAndTransition(ctx context.Context, arg GetLatestWorkspaceBuildByWorkspaceIDAndTransitionParams) (WorkspaceBuild, error) {
+	row := q.db.QueryRowContext(ctx, getLatestWorkspaceBuildByWorkspaceIDAndTransition, arg.WorkspaceID, arg.Transition)
+	var i WorkspaceBuild
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.WorkspaceID,
+		&i.TemplateVersionID,
+		&i.BuildNumber,
+		&i.Transition,
+		&i.InitiatorID,
+		&i.ProvisionerState,
+		&i.JobID,
+		&i.Deadline,
+		&i.Reason,
+		&i.DailyCost,
+	)
+	return i, err
+}
+
 const getLatestWorkspaceBuilds = `-- name: GetLatestWorkspaceBuilds :many
 SELECT wb.id, wb.created_at, wb.updated_at, wb.workspace_id, wb.template_version_id, wb.build_number, wb.transition, wb.initiator_id, wb.provisioner_state, wb.job_id, wb.deadline, wb.reason, wb.daily_cost
 FROM (
@@ -5419,6 +8058,81 @@ func (q *sqlQuerier) GetLatestWorkspaceBuildsByWorkspaceIDs(ctx context.Context,
 	return items, nil
 }
 
+const getUpcomingWorkspaceAutostops = `-- name: GetUpcomingWorkspaceAutostops :many
+SELECT
+	workspaces.id AS workspace_id,
+	workspaces.name AS workspace_name,
+	workspaces.owner_id,
+	workspaces.organization_id,
+	workspace_builds.id AS build_id,
+	workspace_builds.deadline
+FROM
+	workspace_builds
+INNER JOIN
+	workspaces ON workspaces.id = workspace_builds.workspace_id
+WHERE
+	workspaces.deleted = false
+	AND workspaces.ttl IS NOT NULL
+	AND workspace_builds.transition = 'start'
+	AND workspace_builds.deadline > $1
+	AND workspace_builds.deadline <= $2
+	AND workspace_builds.build_number = (
+		SELECT
+			MAX(build_number)
+		FROM
+			workspace_builds AS latest
+		WHERE
+			latest.workspace_id = workspace_builds.workspace_id
+	)
+`
+
+type GetUpcomingWorkspaceAutostopsParams struct {
+	DeadlineAfter  time.Time `db:"deadline_after" json:"deadline_after"`
+	DeadlineBefore time.Time `db:"deadline_before" json:"deadline_before"`
+}
+
+type GetUpcomingWorkspaceAutostopsRow struct {
+	WorkspaceID    uuid.UUID `db:"workspace_id" json:"workspace_id"`
+	WorkspaceName  string    `db:"workspace_name" json:"workspace_name"`
+	OwnerID        uuid.UUID `db:"owner_id" json:"owner_id"`
+	OrganizationID uuid.UUID `db:"organization_id" json:"organization_id"`
+	BuildID        uuid.UUID `db:"build_id" json:"build_id"`
+	Deadline       time.Time `db:"deadline" json:"deadline"`
+}
+
+// GetUpcomingWorkspaceAutostops returns the latest builds of running
+// workspaces with a TTL that will be stopped by their deadline within the
+// given window.
+func (q *sqlQuerier) GetUpcomingWorkspaceAutostops(ctx context.Context, arg GetUpcomingWorkspaceAutostopsParams) ([]GetUpcomingWorkspaceAutostopsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getUpcomingWorkspaceAutostops, arg.DeadlineAfter, arg.DeadlineBefore)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUpcomingWorkspaceAutostopsRow
+	for rows.Next() {
+		var i GetUpcomingWorkspaceAutostopsRow
+		if err := rows.Scan(
+			&i.WorkspaceID,
+			&i.WorkspaceName,
+			&i.OwnerID,
+			&i.OrganizationID,
+			&i.BuildID,
+			&i.Deadline,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getWorkspaceBuildByID = `-- name: GetWorkspaceBuildByID :one
 SELECT
 	id, created_at, updated_at, workspace_id, template_version_id, build_number, transition, initiator_id, provisioner_state, job_id, deadline, reason, daily_cost
@@ -6134,7 +8848,7 @@ func (q *sqlQuerier) InsertWorkspaceResourceMetadata(ctx context.Context, arg In
 
 const getWorkspaceByID = `-- name: GetWorkspaceByID :one
 SELECT
-	id, created_at, updated_at, owner_id, organization_id, template_id, deleted, name, autostart_schedule, ttl, last_used_at
+	id, created_at, updated_at, owner_id, organization_id, template_id, deleted, name, autostart_schedule, ttl, last_used_at, deleting_at
 FROM
 	workspaces
 WHERE
@@ -6158,13 +8872,14 @@ func (q *sqlQuerier) GetWorkspaceByID(ctx context.Context, id uuid.UUID) (Worksp
 		&i.AutostartSchedule,
 		&i.Ttl,
 		&i.LastUsedAt,
+		&i.DeletingAt,
 	)
 	return i, err
 }
 
 const getWorkspaceByOwnerIDAndName = `-- name: GetWorkspaceByOwnerIDAndName :one
 SELECT
-	id, created_at, updated_at, owner_id, organization_id, template_id, deleted, name, autostart_schedule, ttl, last_used_at
+	id, created_at, updated_at, owner_id, organization_id, template_id, deleted, name, autostart_schedule, ttl, last_used_at, deleting_at
 FROM
 	workspaces
 WHERE
@@ -6195,6 +8910,7 @@ func (q *sqlQuerier) GetWorkspaceByOwnerIDAndName(ctx context.Context, arg GetWo
 		&i.AutostartSchedule,
 		&i.Ttl,
 		&i.LastUsedAt,
+		&i.DeletingAt,
 	)
 	return i, err
 }
@@ -6261,7 +8977,7 @@ func (q *sqlQuerier) GetWorkspaceOwnerCountsByTemplateIDs(ctx context.Context, i
 
 const getWorkspaces = `-- name: GetWorkspaces :many
 SELECT
-	workspaces.id, workspaces.created_at, workspaces.updated_at, workspaces.owner_id, workspaces.organization_id, workspaces.template_id, workspaces.deleted, workspaces.name, workspaces.autostart_schedule, workspaces.ttl, workspaces.last_used_at, COUNT(*) OVER () as count
+	workspaces.id, workspaces.created_at, workspaces.updated_at, workspaces.owner_id, workspaces.organization_id, workspaces.template_id, workspaces.deleted, workspaces.name, workspaces.autostart_schedule, workspaces.ttl, workspaces.last_used_at, workspaces.deleting_at, COUNT(*) OVER () as count
 FROM
 	workspaces
 LEFT JOIN LATERAL (
@@ -6420,6 +9136,7 @@ type GetWorkspacesRow struct {
 	AutostartSchedule sql.NullString `db:"autostart_schedule" json:"autostart_schedule"`
 	Ttl               sql.NullInt64  `db:"ttl" json:"ttl"`
 	LastUsedAt        time.Time      `db:"last_used_at" json:"last_used_at"`
+	DeletingAt        sql.NullTime   `db:"deleting_at" json:"deleting_at"`
 	Count             int64          `db:"count" json:"count"`
 }
 
@@ -6454,6 +9171,7 @@ func (q *sqlQuerier) GetWorkspaces(ctx context.Context, arg GetWorkspacesParams)
 			&i.AutostartSchedule,
 			&i.Ttl,
 			&i.LastUsedAt,
+			&i.DeletingAt,
 			&i.Count,
 		); err != nil {
 			return nil, err
@@ -6483,7 +9201,7 @@ INSERT INTO
 		ttl
 	)
 VALUES
-	($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id, created_at, updated_at, owner_id, organization_id, template_id, deleted, name, autostart_schedule, ttl, last_used_at
+	($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id, created_at, updated_at, owner_id, organization_id, template_id, deleted, name, autostart_schedule, ttl, last_used_at, deleting_at
 `
 
 type InsertWorkspaceParams struct {
@@ -6523,6 +9241,7 @@ func (q *sqlQuerier) InsertWorkspace(ctx context.Context, arg InsertWorkspacePar
 		&i.AutostartSchedule,
 		&i.Ttl,
 		&i.LastUsedAt,
+		&i.DeletingAt,
 	)
 	return i, err
 }
@@ -6535,7 +9254,7 @@ SET
 WHERE
 	id = $1
 	AND deleted = false
-RETURNING id, created_at, updated_at, owner_id, organization_id, template_id, deleted, name, autostart_schedule, ttl, last_used_at
+RETURNING id, created_at, updated_at, owner_id, organization_id, template_id, deleted, name, autostart_schedule, ttl, last_used_at, deleting_at
 `
 
 type UpdateWorkspaceParams struct {
@@ -6558,6 +9277,7 @@ func (q *sqlQuerier) UpdateWorkspace(ctx context.Context, arg UpdateWorkspacePar
 		&i.AutostartSchedule,
 		&i.Ttl,
 		&i.LastUsedAt,
+		&i.DeletingAt,
 	)
 	return i, err
 }
@@ -6600,6 +9320,41 @@ func (q *sqlQuerier) UpdateWorkspaceDeletedByID(ctx context.Context, arg UpdateW
 	return err
 }
 
+const updateWorkspaceDeletingAtByID = `-- name: UpdateWorkspaceDeletingAtByID :one
+UPDATE
+	workspaces
+SET
+	deleting_at = $2
+WHERE
+	id = $1
+RETURNING id, created_at, updated_at, owner_id, organization_id, template_id, deleted, name, autostart_schedule, ttl, last_used_at, deleting_at
+`
+
+type UpdateWorkspaceDeletingAtByIDParams struct {
+	ID         uuid.UUID    `db:"id" json:"id"`
+	DeletingAt sql.NullTime `db:"deleting_at" json:"deleting_at"`
+}
+
+func (q *sqlQuerier) UpdateWorkspaceDeletingAtByID(ctx context.Context, arg UpdateWorkspaceDeletingAtByIDParams) (Workspace, error) {
+	row := q.db.QueryRowContext(ctx, updateWorkspaceDeletingAtByID, arg.ID, arg.DeletingAt)
+	var i Workspace
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.OwnerID,
+		&i.OrganizationID,
+		&i.TemplateID,
+		&i.Deleted,
+		&i.Name,
+		&i.AutostartSchedule,
+		&i.Ttl,
+		&i.LastUsedAt,
+		&i.DeletingAt,
+	)
+	return i, err
+}
+
 const updateWorkspaceLastUsedAt = `-- name: UpdateWorkspaceLastUsedAt :exec
 UPDATE
 	workspaces
@@ -6637,3 +9392,190 @@ func (q *sqlQuerier) UpdateWorkspaceTTL(ctx context.Context, arg UpdateWorkspace
 	_, err := q.db.ExecContext(ctx, updateWorkspaceTTL, arg.ID, arg.Ttl)
 	return err
 }
+
+const updateWorkspacesTTLByTemplateID = `-- name: UpdateWorkspacesTTLByTemplateID :exec
+UPDATE
+	workspaces
+SET
+	ttl = $1::bigint
+WHERE
+	template_id = $2
+	AND deleted = false
+	AND (ttl IS NULL OR ttl > $1::bigint)
+`
+
+type UpdateWorkspacesTTLByTemplateIDParams struct {
+	MaxTTL     int64     `db:"max_ttl" json:"max_ttl"`
+	TemplateID uuid.UUID `db:"template_id" json:"template_id"`
+}
+
+// Clamps the TTL of workspaces created from a template to the template's
+// maximum TTL.
+func (q *sqlQuerier) UpdateWorkspacesTTLByTemplateID(ctx context.Context, arg UpdateWorkspacesTTLByTemplateIDParams) error {
+	_, err := q.db.ExecContext(ctx, updateWorkspacesTTLByTemplateID, arg.MaxTTL, arg.TemplateID)
+	return err
+}
+
+const getWorkspaceSessionRecordingByID = `-- name: GetWorkspaceSessionRecordingByID :one
+SELECT
+	id, created_at, organization_id, workspace_id, agent_id, owner_id, type, command, started_at, ended_at, file_id
+FROM
+	workspace_session_recordings
+WHERE
+	id = $1
+`
+
+func (q *sqlQuerier) GetWorkspaceSessionRecordingByID(ctx context.Context, id uuid.UUID) (WorkspaceSessionRecording, error) {
+	row := q.db.QueryRowContext(ctx, getWorkspaceSessionRecordingByID, id)
+	var i WorkspaceSessionRecording
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.OrganizationID,
+		&i.WorkspaceID,
+		&i.AgentID,
+		&i.OwnerID,
+		&i.Type,
+		&i.Command,
+		&i.StartedAt,
+		&i.EndedAt,
+		&i.FileID,
+	)
+	return i, err
+}
+
+const getWorkspaceSessionRecordings = `-- name: GetWorkspaceSessionRecordings :many
+SELECT
+	id, created_at, organization_id, workspace_id, agent_id, owner_id, type, command, started_at, ended_at, file_id
+FROM
+	workspace_session_recordings
+WHERE
+	CASE
+		WHEN $1 :: uuid != '00000000-0000-0000-0000-000000000000'::uuid THEN
+			workspace_id = $1
+		ELSE true
+	END
+	AND CASE
+		WHEN $2 :: uuid != '00000000-0000-0000-0000-000000000000'::uuid THEN
+			owner_id = $2
+		ELSE true
+	END
+ORDER BY
+	started_at DESC, id DESC
+OFFSET
+	$3
+LIMIT
+	-- A null limit means "no limit", so 0 means return all
+	NULLIF($4 :: int, 0)
+`
+
+type GetWorkspaceSessionRecordingsParams struct {
+	WorkspaceID uuid.UUID `db:"workspace_id" json:"workspace_id"`
+	OwnerID     uuid.UUID `db:"owner_id" json:"owner_id"`
+	OffsetOpt   int32     `db:"offset_opt" json:"offset_opt"`
+	LimitOpt    int32     `db:"limit_opt" json:"limit_opt"`
+}
+
+func (q *sqlQuerier) GetWorkspaceSessionRecordings(ctx context.Context, arg GetWorkspaceSessionRecordingsParams) ([]WorkspaceSessionRecording, error) {
+	rows, err := q.db.QueryContext(ctx, getWorkspaceSessionRecordings,
+		arg.WorkspaceID,
+		arg.OwnerID,
+		arg.OffsetOpt,
+		arg.LimitOpt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WorkspaceSessionRecording
+	for rows.Next() {
+		var i WorkspaceSessionRecording
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.OrganizationID,
+			&i.WorkspaceID,
+			&i.AgentID,
+			&i.OwnerID,
+			&i.Type,
+			&i.Command,
+			&i.StartedAt,
+			&i.EndedAt,
+			&i.FileID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertWorkspaceSessionRecording = `-- name: InsertWorkspaceSessionRecording :one
+INSERT INTO
+	workspace_session_recordings (
+		id,
+		created_at,
+		organization_id,
+		workspace_id,
+		agent_id,
+		owner_id,
+		type,
+		command,
+		started_at,
+		ended_at,
+		file_id
+	)
+VALUES
+	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id, created_at, organization_id, workspace_id, agent_id, owner_id, type, command, started_at, ended_at, file_id
+`
+
+type InsertWorkspaceSessionRecordingParams struct {
+	ID             uuid.UUID            `db:"id" json:"id"`
+	CreatedAt      time.Time            `db:"created_at" json:"created_at"`
+	OrganizationID uuid.UUID            `db:"organization_id" json:"organization_id"`
+	WorkspaceID    uuid.UUID            `db:"workspace_id" json:"workspace_id"`
+	AgentID        uuid.UUID            `db:"agent_id" json:"agent_id"`
+	OwnerID        uuid.UUID            `db:"owner_id" json:"owner_id"`
+	Type           SessionRecordingType `db:"type" json:"type"`
+	Command        string               `db:"command" json:"command"`
+	StartedAt      time.Time            `db:"started_at" json:"started_at"`
+	EndedAt        time.Time            `db:"ended_at" json:"ended_at"`
+	FileID         uuid.UUID            `db:"file_id" json:"file_id"`
+}
+
+func (q *sqlQuerier) InsertWorkspaceSessionRecording(ctx context.Context, arg InsertWorkspaceSessionRecordingParams) (WorkspaceSessionRecording, error) {
+	row := q.db.QueryRowContext(ctx, insertWorkspaceSessionRecording,
+		arg.ID,
+		arg.CreatedAt,
+		arg.OrganizationID,
+		arg.WorkspaceID,
+		arg.AgentID,
+		arg.OwnerID,
+		arg.Type,
+		arg.Command,
+		arg.StartedAt,
+		arg.EndedAt,
+		arg.FileID,
+	)
+	var i WorkspaceSessionRecording
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.OrganizationID,
+		&i.WorkspaceID,
+		&i.AgentID,
+		&i.OwnerID,
+		&i.Type,
+		&i.Command,
+		&i.StartedAt,
+		&i.EndedAt,
+		&i.FileID,
+	)
+	return i, err
+}
@@ -7,21 +7,47 @@ INSERT INTO
 		workspace_id,
 		template_id,
 		agent_id,
-		payload
+		payload,
+		connection_activity
 	)
 VALUES
-	($1, $2, $3, $4, $5, $6, $7) RETURNING *;
+	($1, $2, $3, $4, $5, $6, $7, $8) RETURNING *;
 
 -- name: GetLatestAgentStat :one
 SELECT * FROM agent_stats WHERE agent_id = $1 ORDER BY created_at DESC LIMIT 1; 
 
+-- name: GetLatestAgentStats :many
+-- Returns the latest stats of every agent that reported after a time, with
+-- the names of its workspace and owner.
+SELECT
+	DISTINCT ON (agent_stats.agent_id)
+	agent_stats.agent_id,
+	agent_stats.created_at,
+	agent_stats.payload,
+	users.username,
+	workspaces.name AS workspace_name,
+	workspace_agents.name AS agent_name
+FROM
+	agent_stats
+JOIN
+	users ON users.id = agent_stats.user_id
+JOIN
+	workspaces ON workspaces.id = agent_stats.workspace_id
+JOIN
+	workspace_agents ON workspace_agents.id = agent_stats.agent_id
+WHERE
+	agent_stats.created_at > @created_after
+	AND workspaces.deleted = false
+ORDER BY
+	agent_stats.agent_id, agent_stats.created_at DESC;
+
 -- name: GetTemplateDAUs :many
 select
 	(created_at at TIME ZONE 'UTC')::date as date,
 	user_id
 from
 	agent_stats
-where template_id = $1
+where template_id = $1 AND connection_activity
 group by
 	date, user_id
 order by
@@ -14,6 +14,21 @@ SELECT * FROM api_keys WHERE last_used > $1;
 -- name: GetAPIKeysByLoginType :many
 SELECT * FROM api_keys WHERE login_type = $1;
 
+-- name: GetAPIKeysByUserID :many
+SELECT * FROM api_keys WHERE login_type = @login_type AND user_id = @user_id;
+
+-- name: GetAPIKeyByName :one
+SELECT
+	*
+FROM
+	api_keys
+WHERE
+	user_id = @user_id AND
+	token_name = @token_name AND
+	login_type = 'token'
+LIMIT
+	1;
+
 -- name: InsertAPIKey :one
 INSERT INTO
 	api_keys (
@@ -27,7 +42,9 @@ INSERT INTO
 		created_at,
 		updated_at,
 		login_type,
-		scope
+		scope,
+		token_name,
+		allow_list
 	)
 VALUES
 	(@id,
@@ -36,7 +53,7 @@ VALUES
 	     WHEN 0 THEN 86400
 		 ELSE @lifetime_seconds::bigint
 	 END
-	 , @hashed_secret, @ip_address, @user_id, @last_used, @expires_at, @created_at, @updated_at, @login_type, @scope) RETURNING *;
+	 , @hashed_secret, @ip_address, @user_id, @last_used, @expires_at, @created_at, @updated_at, @login_type, @scope, @token_name, @allow_list) RETURNING *;
 
 -- name: UpdateAPIKeyByID :exec
 UPDATE
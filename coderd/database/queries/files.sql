@@ -23,6 +23,105 @@ LIMIT
 
 -- name: InsertFile :one
 INSERT INTO
-	files (id, hash, created_at, created_by, mimetype, "data")
+	files (id, hash, created_at, created_by, mimetype, storage)
 VALUES
 	($1, $2, $3, $4, $5, $6) RETURNING *;
+
+-- Reused files have their creation time refreshed so they aren't collected
+-- before the caller references them.
+-- name: UpdateFileCreatedAtByID :exec
+UPDATE
+	files
+SET
+	created_at = $2
+WHERE
+	id = $1;
+
+-- The file is only deleted if it is still unreferenced and wasn't reused
+-- since it was listed for collection.
+-- name: DeleteUnreferencedFileByID :one
+DELETE FROM
+	files
+WHERE
+	files.id = @id
+AND
+	files.created_at < @created_before
+AND
+	NOT EXISTS (
+		SELECT
+			1
+		FROM
+			provisioner_jobs
+		WHERE
+			provisioner_jobs.file_id = files.id
+	)
+AND
+	NOT EXISTS (
+		SELECT
+			1
+		FROM
+			workspace_session_recordings
+		WHERE
+			workspace_session_recordings.file_id = files.id
+	)
+RETURNING *;
+
+-- name: GetFileCountByHashAndStorage :one
+SELECT
+	COUNT(*)
+FROM
+	files
+WHERE
+	hash = $1
+AND
+	storage = $2;
+
+-- Files are referenced by the provisioner jobs that import template versions
+-- and build workspaces, and by session recordings. Files that were never used
+-- by either are unreferenced.
+-- name: GetUnreferencedFilesCreatedBefore :many
+SELECT
+	*
+FROM
+	files
+WHERE
+	files.created_at < $1
+AND
+	NOT EXISTS (
+		SELECT
+			1
+		FROM
+			provisioner_jobs
+		WHERE
+			provisioner_jobs.file_id = files.id
+	)
+AND
+	NOT EXISTS (
+		SELECT
+			1
+		FROM
+			workspace_session_recordings
+		WHERE
+			workspace_session_recordings.file_id = files.id
+	);
+
+-- name: InsertFileData :exec
+INSERT INTO
+	file_data (hash, "data")
+VALUES
+	($1, $2)
+ON CONFLICT (hash) DO NOTHING;
+
+-- name: GetFileDataByHash :one
+SELECT
+	"data"
+FROM
+	file_data
+WHERE
+	hash = $1;
+
+-- name: DeleteFileDataByHash :exec
+DELETE FROM
+	file_data
+WHERE
+	hash = $1;
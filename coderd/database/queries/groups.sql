@@ -48,6 +48,16 @@ AND
 AND
 	users.deleted = 'false';
 
+-- GetGroupMemberIDs returns the IDs of every member of a group, unlike
+-- GetGroupMembers which omits inactive and deleted users.
+-- name: GetGroupMemberIDs :many
+SELECT
+	user_id
+FROM
+	group_members
+WHERE
+	group_id = $1;
+
 -- name: GetAllOrganizationMembers :many
 SELECT
 	users.*
@@ -76,10 +86,11 @@ INSERT INTO groups (
 	name,
 	organization_id,
 	avatar_url,
-	quota_allowance
+	quota_allowance,
+	source
 )
 VALUES
-	( $1, $2, $3, $4, $5) RETURNING *;
+	( $1, $2, $3, $4, $5, $6) RETURNING *;
 
 -- We use the organization_id as the id
 -- for simplicity since all users is
@@ -117,6 +128,14 @@ DELETE FROM
 WHERE
 	user_id = $1;
 
+-- name: DeleteGroupMemberFromGroup :exec
+DELETE FROM
+	group_members
+WHERE
+	user_id = $1
+AND
+	group_id = $2;
+
 -- name: DeleteGroupByID :exec
 DELETE FROM
 	groups
@@ -0,0 +1,281 @@
+-- name: RollupTemplateUsageStats :exec
+-- Recomputes the hourly usage between two whole hours from the agent stats
+-- and workspace builds created in the range. The latest stats of each agent
+-- and the latest build of each workspace before the range are looked up by
+-- index, so counters can be diffed and costs carried over without scanning
+-- older rows. Cost is only accrued until now.
+WITH agent_stats_in_range AS (
+	SELECT
+		agent_id, created_at, template_id, user_id, connection_activity, payload
+	FROM
+		agent_stats
+	WHERE
+		agent_stats.created_at >= @start_time
+		AND agent_stats.created_at < @end_time
+), previous_agent_stats AS (
+	SELECT
+		previous.*
+	FROM
+		(SELECT DISTINCT agent_id FROM agent_stats_in_range) AS agents
+	JOIN LATERAL (
+		SELECT
+			agent_id, created_at, template_id, user_id, connection_activity, payload
+		FROM
+			agent_stats
+		WHERE
+			agent_stats.agent_id = agents.agent_id
+			AND agent_stats.created_at < @start_time
+		ORDER BY
+			agent_stats.created_at DESC
+		LIMIT 1
+	) AS previous ON true
+), counters AS (
+	SELECT
+		stats.created_at,
+		stats.template_id,
+		stats.user_id,
+		stats.connection_activity,
+		COALESCE((stats.payload->>'num_comms')::bigint, 0) AS connections,
+		COALESCE((stats.payload->>'connection_count_ssh')::bigint, 0) AS ssh,
+		COALESCE((stats.payload->>'connection_count_reconnecting_pty')::bigint, 0) AS reconnecting_pty,
+		COALESCE((stats.payload->>'connection_count_vscode')::bigint, 0) AS vscode,
+		COALESCE((stats.payload->>'connection_count_jetbrains')::bigint, 0) AS jetbrains,
+		-- Counters start from zero for the first stats of an agent.
+		COALESCE(LAG((stats.payload->>'num_comms')::bigint) OVER agent, 0) AS previous_connections,
+		COALESCE(LAG((stats.payload->>'connection_count_ssh')::bigint) OVER agent, 0) AS previous_ssh,
+		COALESCE(LAG((stats.payload->>'connection_count_reconnecting_pty')::bigint) OVER agent, 0) AS previous_reconnecting_pty,
+		COALESCE(LAG((stats.payload->>'connection_count_vscode')::bigint) OVER agent, 0) AS previous_vscode,
+		COALESCE(LAG((stats.payload->>'connection_count_jetbrains')::bigint) OVER agent, 0) AS previous_jetbrains
+	FROM
+		(SELECT * FROM previous_agent_stats UNION ALL SELECT * FROM agent_stats_in_range) AS stats
+	WINDOW
+		agent AS (PARTITION BY stats.agent_id ORDER BY stats.created_at)
+), usage AS (
+	-- Counters are reset when the agent restarts.
+	SELECT
+		date_trunc('hour', created_at AT TIME ZONE 'UTC') AT TIME ZONE 'UTC' AS start_time,
+		template_id,
+		user_id,
+		bool_or(connection_activity) AS active,
+		SUM(CASE WHEN connections < previous_connections THEN connections ELSE connections - previous_connections END)::bigint AS connection_count,
+		SUM(CASE WHEN ssh < previous_ssh THEN ssh ELSE ssh - previous_ssh END)::bigint AS ssh_connection_count,
+		SUM(CASE WHEN reconnecting_pty < previous_reconnecting_pty THEN reconnecting_pty ELSE reconnecting_pty - previous_reconnecting_pty END)::bigint AS reconnecting_pty_connection_count,
+		SUM(CASE WHEN vscode < previous_vscode THEN vscode ELSE vscode - previous_vscode END)::bigint AS vscode_connection_count,
+		SUM(CASE WHEN jetbrains < previous_jetbrains THEN jetbrains ELSE jetbrains - previous_jetbrains END)::bigint AS jetbrains_connection_count
+	FROM
+		counters
+	WHERE
+		counters.created_at >= @start_time
+	GROUP BY
+		1, template_id, user_id
+), workspace_builds_in_range AS (
+	SELECT
+		workspace_id, created_at, daily_cost
+	FROM
+		workspace_builds
+	WHERE
+		workspace_builds.created_at >= @start_time
+		AND workspace_builds.created_at < @end_time
+), previous_workspace_builds AS (
+	SELECT
+		previous.*
+	FROM
+		workspaces
+	JOIN LATERAL (
+		SELECT
+			workspace_id, created_at, daily_cost
+		FROM
+			workspace_builds
+		WHERE
+			workspace_builds.workspace_id = workspaces.id
+			AND workspace_builds.created_at < @start_time
+		ORDER BY
+			workspace_builds.build_number DESC
+		LIMIT 1
+	) AS previous ON true
+), build_costs AS (
+	-- A build's cost applies until the next build of the workspace.
+	SELECT
+		builds.workspace_id,
+		builds.daily_cost,
+		GREATEST(builds.created_at, @start_time) AS cost_start,
+		LEAST(COALESCE(LEAD(builds.created_at) OVER (PARTITION BY builds.workspace_id ORDER BY builds.created_at), CAST(@now AS timestamptz)), @end_time) AS cost_end
+	FROM
+		(SELECT * FROM previous_workspace_builds UNION ALL SELECT * FROM workspace_builds_in_range) AS builds
+), costs AS (
+	SELECT
+		hours.start_time,
+		workspaces.template_id,
+		workspaces.owner_id AS user_id,
+		SUM(build_costs.daily_cost * EXTRACT(EPOCH FROM LEAST(hours.start_time + INTERVAL '1 hour', build_costs.cost_end) - GREATEST(hours.start_time, build_costs.cost_start)) / 86400)::double precision AS cost
+	FROM
+		build_costs
+	JOIN
+		workspaces ON workspaces.id = build_costs.workspace_id
+	JOIN LATERAL (
+		-- The hours the build's cost applies to.
+		SELECT
+			generate_series(
+				date_trunc('hour', build_costs.cost_start AT TIME ZONE 'UTC') AT TIME ZONE 'UTC',
+				build_costs.cost_end - INTERVAL '1 microsecond',
+				INTERVAL '1 hour'
+			) AS start_time
+	) AS hours ON true
+	WHERE
+		build_costs.daily_cost > 0
+		AND build_costs.cost_end > build_costs.cost_start
+	GROUP BY
+		hours.start_time, workspaces.template_id, workspaces.owner_id
+)
+INSERT INTO
+	template_usage_stats (
+		start_time,
+		template_id,
+		user_id,
+		active,
+		connection_count,
+		ssh_connection_count,
+		reconnecting_pty_connection_count,
+		vscode_connection_count,
+		jetbrains_connection_count,
+		cost
+	)
+SELECT
+	COALESCE(usage.start_time, costs.start_time),
+	COALESCE(usage.template_id, costs.template_id),
+	COALESCE(usage.user_id, costs.user_id),
+	COALESCE(usage.active, false),
+	COALESCE(usage.connection_count, 0),
+	COALESCE(usage.ssh_connection_count, 0),
+	COALESCE(usage.reconnecting_pty_connection_count, 0),
+	COALESCE(usage.vscode_connection_count, 0),
+	COALESCE(usage.jetbrains_connection_count, 0),
+	COALESCE(costs.cost, 0)
+FROM
+	usage
+FULL OUTER JOIN
+	costs ON costs.start_time = usage.start_time AND costs.template_id = usage.template_id AND costs.user_id = usage.user_id
+ON CONFLICT
+	(start_time, template_id, user_id)
+DO UPDATE SET
+	active = EXCLUDED.active,
+	connection_count = EXCLUDED.connection_count,
+	ssh_connection_count = EXCLUDED.ssh_connection_count,
+	reconnecting_pty_connection_count = EXCLUDED.reconnecting_pty_connection_count,
+	vscode_connection_count = EXCLUDED.vscode_connection_count,
+	jetbrains_connection_count = EXCLUDED.jetbrains_connection_count,
+	cost = EXCLUDED.cost;
+
+-- name: GetLatestTemplateUsageStatsStartTime :one
+-- Returns the start of the latest hour that has been rolled up, or the zero
+-- time if none has.
+SELECT
+	COALESCE(MAX(start_time), '0001-01-01 00:00:00+00')::timestamptz
+FROM
+	template_usage_stats;
+
+-- name: UpsertTemplateUsageStats :exec
+INSERT INTO
+	template_usage_stats (
+		start_time,
+		template_id,
+		user_id,
+		active,
+		connection_count,
+		ssh_connection_count,
+		reconnecting_pty_connection_count,
+		vscode_connection_count,
+		jetbrains_connection_count,
+		cost
+	)
+VALUES
+	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+ON CONFLICT
+	(start_time, template_id, user_id)
+DO UPDATE SET
+	active = $4,
+	connection_count = $5,
+	ssh_connection_count = $6,
+	reconnecting_pty_connection_count = $7,
+	vscode_connection_count = $8,
+	jetbrains_connection_count = $9,
+	cost = $10;
+
+-- name: InsertTemplateAppUsageStats :exec
+-- Adds requests to the app usage of an hour.
+INSERT INTO
+	template_app_usage_stats (
+		start_time,
+		template_id,
+		user_id,
+		slug,
+		requests
+	)
+VALUES
+	($1, $2, $3, $4, $5)
+ON CONFLICT
+	(start_time, template_id, user_id, slug)
+DO UPDATE SET
+	requests = template_app_usage_stats.requests + $5;
+
+-- name: GetTemplateInsights :many
+SELECT
+	template_id,
+	COUNT(DISTINCT user_id) FILTER (WHERE active) AS active_users,
+	COUNT(*) FILTER (WHERE active) AS active_hours,
+	COALESCE(SUM(connection_count), 0)::bigint AS connection_count,
+	COALESCE(SUM(ssh_connection_count), 0)::bigint AS ssh_connection_count,
+	COALESCE(SUM(reconnecting_pty_connection_count), 0)::bigint AS reconnecting_pty_connection_count,
+	COALESCE(SUM(vscode_connection_count), 0)::bigint AS vscode_connection_count,
+	COALESCE(SUM(jetbrains_connection_count), 0)::bigint AS jetbrains_connection_count,
+	COALESCE(SUM(cost), 0)::double precision AS cost
+FROM
+	template_usage_stats
+WHERE
+	start_time >= @start_time
+	AND start_time < @end_time
+	AND template_id = ANY(@template_ids :: uuid[])
+GROUP BY
+	template_id
+ORDER BY
+	template_id;
+
+-- name: GetUserInsights :many
+SELECT
+	template_id,
+	user_id,
+	COUNT(*) FILTER (WHERE active) AS active_hours,
+	COALESCE(SUM(connection_count), 0)::bigint AS connection_count,
+	COALESCE(SUM(ssh_connection_count), 0)::bigint AS ssh_connection_count,
+	COALESCE(SUM(reconnecting_pty_connection_count), 0)::bigint AS reconnecting_pty_connection_count,
+	COALESCE(SUM(vscode_connection_count), 0)::bigint AS vscode_connection_count,
+	COALESCE(SUM(jetbrains_connection_count), 0)::bigint AS jetbrains_connection_count,
+	COALESCE(SUM(cost), 0)::double precision AS cost
+FROM
+	template_usage_stats
+WHERE
+	start_time >= @start_time
+	AND start_time < @end_time
+	AND template_id = ANY(@template_ids :: uuid[])
+GROUP BY
+	template_id, user_id
+ORDER BY
+	template_id, user_id;
+
+-- name: GetAppInsights :many
+SELECT
+	template_id,
+	slug,
+	COUNT(DISTINCT user_id) AS users,
+	COUNT(*) AS usage_hours,
+	COALESCE(SUM(requests), 0)::bigint AS requests
+FROM
+	template_app_usage_stats
+WHERE
+	start_time >= @start_time
+	AND start_time < @end_time
+	AND template_id = ANY(@template_ids :: uuid[])
+GROUP BY
+	template_id, slug
+ORDER BY
+	template_id, slug;
@@ -22,6 +22,23 @@ VALUES
 	($1, $2, $3, $4, $5) RETURNING *;
 
 
+-- name: GetOrganizationMembers :many
+SELECT
+	*
+FROM
+	organization_members
+WHERE
+	organization_id = $1
+ORDER BY
+	created_at;
+
+-- name: DeleteOrganizationMember :exec
+DELETE FROM
+	organization_members
+WHERE
+	organization_id = $1
+	AND user_id = $2;
+
 -- name: GetOrganizationMembershipsByUserID :many
 SELECT
 	*
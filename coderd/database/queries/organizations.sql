@@ -1,8 +1,12 @@
+-- GetOrganizations returns the oldest organization first, which is the
+-- default organization created during setup.
 -- name: GetOrganizations :many
 SELECT
 	*
 FROM
-	organizations;
+	organizations
+ORDER BY
+	created_at ASC, id ASC;
 
 -- name: GetOrganizationByID :one
 SELECT
@@ -28,7 +32,7 @@ SELECT
 FROM
 	organizations
 WHERE
-	id = (
+	id = ANY(
 		SELECT
 			organization_id
 		FROM
@@ -42,3 +46,14 @@ INSERT INTO
 	organizations (id, "name", description, created_at, updated_at)
 VALUES
 	($1, $2, $3, $4, $5) RETURNING *;
+
+-- name: UpdateOrganization :one
+UPDATE
+	organizations
+SET
+	"name" = @name,
+	description = @description,
+	updated_at = @updated_at
+WHERE
+	id = @id
+RETURNING *;
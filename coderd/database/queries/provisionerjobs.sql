@@ -1,5 +1,6 @@
 -- Acquires the lock for a single job that isn't started, completed,
--- canceled, and that matches an array of provisioner types.
+-- canceled, and that matches an array of provisioner types. Jobs are only
+-- acquired from the given organization, unless it's the zero UUID.
 --
 -- SKIP LOCKED is used to jump over locked rows. This prevents
 -- multiple provisioners from acquiring the same jobs. See:
@@ -23,7 +24,12 @@ WHERE
 			AND nested.completed_at IS NULL
 			AND nested.provisioner = ANY(@types :: provisioner_type [ ])
 			-- Ensure the caller satisfies all job tags.
-			AND nested.tags <@ @tags :: jsonb 
+			AND nested.tags <@ @tags :: jsonb
+			AND CASE
+				WHEN @organization_id :: uuid != '00000000-0000-0000-0000-000000000000'::uuid THEN
+					nested.organization_id = @organization_id
+				ELSE true
+			END
 		ORDER BY
 			nested.created_at
 		FOR UPDATE
@@ -1,3 +1,5 @@
+-- Quotas are summed within an organization, or across all organizations
+-- when the zero UUID is given.
 -- name: GetQuotaAllowanceForUser :one
 SELECT
 	coalesce(SUM(quota_allowance), 0)::BIGINT
@@ -6,7 +8,12 @@ FROM
 JOIN groups g ON
 	g.id = gm.group_id
 WHERE
-	user_id = $1;
+	user_id = @user_id
+	AND CASE
+		WHEN @organization_id :: uuid != '00000000-0000-0000-0000-000000000000'::uuid THEN
+			g.organization_id = @organization_id
+		ELSE true
+	END;
 
 -- name: GetQuotaConsumedForUser :one
 WITH latest_builds AS (
@@ -27,4 +34,11 @@ FROM
 	workspaces
 JOIN latest_builds ON
 	latest_builds.workspace_id = workspaces.id
-WHERE NOT deleted AND workspaces.owner_id = $1;
+WHERE
+	NOT deleted
+	AND workspaces.owner_id = @owner_id
+	AND CASE
+		WHEN @organization_id :: uuid != '00000000-0000-0000-0000-000000000000'::uuid THEN
+			workspaces.organization_id = @organization_id
+		ELSE true
+	END;
@@ -9,3 +9,10 @@ INSERT INTO site_configs (key, value) VALUES ('derp_mesh_key', $1);
 
 -- name: GetDERPMeshKey :one
 SELECT value FROM site_configs WHERE key = 'derp_mesh_key';
+
+-- name: UpsertLastAutostopNoticeCheck :exec
+INSERT INTO site_configs (key, value) VALUES ('last_autostop_notice_check', $1)
+ON CONFLICT (key) DO UPDATE SET value = $1 WHERE site_configs.key = 'last_autostop_notice_check';
+
+-- name: GetLastAutostopNoticeCheck :one
+SELECT COALESCE((SELECT value FROM site_configs WHERE key = 'last_autostop_notice_check'), '') :: text AS value;
@@ -70,10 +70,21 @@ INSERT INTO
 		icon,
 		user_acl,
 		group_acl,
-		display_name
+		display_name,
+		inactivity_ttl,
+		stopped_ttl,
+		max_ttl,
+		autostart_days_of_week,
+		autostart_start_hour,
+		autostart_end_hour,
+		quiet_hours_schedule,
+		quiet_hours_duration,
+		session_recording,
+		auto_start_on_connect,
+		autodelete_warning_ttl
 	)
 VALUES
-	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) RETURNING *;
+	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25) RETURNING *;
 
 -- name: UpdateTemplateActiveVersionByID :exec
 UPDATE
@@ -102,7 +113,19 @@ SET
 	default_ttl = $4,
 	name = $5,
 	icon = $6,
-	display_name = $7
+	display_name = $7,
+	inactivity_ttl = $8,
+	stopped_ttl = $9,
+	max_ttl = $10,
+	autostart_days_of_week = $11,
+	autostart_start_hour = $12,
+	autostart_end_hour = $13,
+	quiet_hours_schedule = $14,
+	quiet_hours_duration = $15,
+	session_recording = $16,
+	auto_start_on_connect = $17,
+	max_port_sharing_level = $18,
+	autodelete_warning_ttl = $19
 WHERE
 	id = $1
 RETURNING
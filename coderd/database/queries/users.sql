@@ -100,6 +100,15 @@ SET
 WHERE
 	id = $1 RETURNING *;
 
+-- name: UpdateUserPreferences :one
+UPDATE
+	users
+SET
+	auto_start_on_connect = $2,
+	updated_at = $3
+WHERE
+	id = $1 RETURNING *;
+
 -- name: UpdateUserRoles :one
 UPDATE
 	users
@@ -0,0 +1,120 @@
+-- name: GetWebhookByID :one
+SELECT
+	*
+FROM
+	webhooks
+WHERE
+	id = $1
+LIMIT
+	1;
+
+-- name: GetWebhooksByOrganizationID :many
+SELECT
+	*
+FROM
+	webhooks
+WHERE
+	organization_id = $1
+ORDER BY
+	name ASC;
+
+-- name: InsertWebhook :one
+INSERT INTO
+	webhooks (
+		id,
+		organization_id,
+		name,
+		url,
+		secret,
+		events,
+		created_at,
+		updated_at
+	)
+VALUES
+	($1, $2, $3, $4, $5, $6, $7, $8) RETURNING *;
+
+-- name: UpdateWebhookByID :one
+UPDATE
+	webhooks
+SET
+	name = $2,
+	url = $3,
+	events = $4,
+	updated_at = $5
+WHERE
+	id = $1
+RETURNING *;
+
+-- name: DeleteWebhookByID :exec
+DELETE FROM
+	webhooks
+WHERE
+	id = $1;
+
+-- InsertWebhookDelivery returns no rows if the event was already claimed
+-- for the webhook, for example by another replica.
+-- name: InsertWebhookDelivery :one
+INSERT INTO
+	webhook_deliveries (
+		id,
+		webhook_id,
+		event_id,
+		event_type,
+		payload,
+		created_at,
+		updated_at
+	)
+VALUES
+	($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (webhook_id, event_id) DO NOTHING RETURNING *;
+
+-- name: UpdateWebhookDeliveryByID :exec
+UPDATE
+	webhook_deliveries
+SET
+	status = $2,
+	attempts = $3,
+	last_status_code = $4,
+	last_error = $5,
+	updated_at = $6
+WHERE
+	id = $1;
+
+-- AcquireStaleWebhookDeliveries claims pending deliveries that haven't been
+-- attempted since updated_before, for example because the replica retrying
+-- them stopped. Claiming updates updated_at, so other replicas skip them.
+-- name: AcquireStaleWebhookDeliveries :many
+UPDATE
+	webhook_deliveries
+SET
+	updated_at = @now
+WHERE
+	id IN (
+		SELECT
+			id
+		FROM
+			webhook_deliveries AS stale
+		WHERE
+			stale.status = 'pending'
+			AND stale.updated_at < @updated_before
+		FOR UPDATE SKIP LOCKED
+	)
+RETURNING *;
+
+-- name: GetWebhookDeliveriesByWebhookID :many
+SELECT
+	*
+FROM
+	webhook_deliveries
+WHERE
+	webhook_id = @webhook_id
+	AND CASE
+		WHEN @status :: text != '' THEN
+			status = @status :: webhook_delivery_status
+		ELSE true
+	END
+ORDER BY
+	created_at DESC
+LIMIT
+	-- A null limit means "no limit", so 0 means return all
+	NULLIF(@limit_opt :: int, 0);
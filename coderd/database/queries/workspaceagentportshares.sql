@@ -0,0 +1,47 @@
+-- name: GetWorkspaceAgentPortShare :one
+SELECT
+	*
+FROM
+	workspace_agent_port_shares
+WHERE
+	workspace_id = $1
+	AND agent_name = $2
+	AND port = $3
+	AND protocol = $4;
+
+-- name: GetWorkspaceAgentPortSharesByWorkspaceID :many
+SELECT
+	*
+FROM
+	workspace_agent_port_shares
+WHERE
+	workspace_id = $1
+ORDER BY
+	agent_name, port, protocol;
+
+-- name: UpsertWorkspaceAgentPortShare :one
+INSERT INTO
+	workspace_agent_port_shares (
+		workspace_id,
+		agent_name,
+		port,
+		protocol,
+		sharing_level,
+		created_at,
+		updated_at
+	)
+VALUES
+	($1, $2, $3, $4, $5, $6, $6)
+ON CONFLICT (workspace_id, agent_name, port, protocol) DO UPDATE SET
+	sharing_level = $5,
+	updated_at = $6
+RETURNING *;
+
+-- name: DeleteWorkspaceAgentPortShare :exec
+DELETE FROM
+	workspace_agent_port_shares
+WHERE
+	workspace_id = $1
+	AND agent_name = $2
+	AND port = $3
+	AND protocol = $4;
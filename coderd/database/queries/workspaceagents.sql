@@ -55,10 +55,13 @@ INSERT INTO
 		instance_metadata,
 		resource_metadata,
 		connection_timeout_seconds,
-		troubleshooting_url
+		troubleshooting_url,
+		startup_script_timeout_seconds,
+		shutdown_script,
+		shutdown_script_timeout_seconds
 	)
 VALUES
-	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16) RETURNING *;
+	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19) RETURNING *;
 
 -- name: UpdateWorkspaceAgentConnectionByID :exec
 UPDATE
@@ -79,3 +82,88 @@ SET
 	version = $2
 WHERE
 	id = $1;
+
+-- name: UpdateWorkspaceAgentLifecycleStateByID :exec
+UPDATE
+	workspace_agents
+SET
+	lifecycle_state = $2
+WHERE
+	id = $1;
+
+-- name: UpdateWorkspaceAgentStartupLogsEOFByID :exec
+UPDATE
+	workspace_agents
+SET
+	startup_logs_eof = $2
+WHERE
+	id = $1;
+
+-- name: GetWorkspaceAgentStartupLogsAfter :many
+SELECT
+	*
+FROM
+	workspace_agent_startup_logs
+WHERE
+	agent_id = $1
+	AND (
+		id > @created_after
+	) ORDER BY id ASC;
+
+-- name: InsertWorkspaceAgentStartupLogs :many
+WITH new_length AS (
+	UPDATE
+		workspace_agents
+	SET
+		startup_logs_length = startup_logs_length + @output_length
+	WHERE
+		workspace_agents.id = @agent_id
+)
+INSERT INTO
+	workspace_agent_startup_logs
+SELECT
+	@agent_id :: uuid AS agent_id,
+	unnest(@created_at :: timestamptz [ ]) AS created_at,
+	unnest(@output :: TEXT [ ]) AS output RETURNING *;
+
+-- name: UpdateWorkspaceAgentStartupLogOverflowByID :exec
+UPDATE
+	workspace_agents
+SET
+	startup_logs_overflowed = $2
+WHERE
+	id = $1;
+
+-- name: InsertWorkspaceAgentMetadata :exec
+INSERT INTO
+	workspace_agent_metadata (
+		workspace_agent_id,
+		display_name,
+		key,
+		script,
+		timeout,
+		interval
+	)
+VALUES
+	($1, $2, $3, $4, $5, $6);
+
+-- name: UpdateWorkspaceAgentMetadata :exec
+UPDATE
+	workspace_agent_metadata
+SET
+	value = $3,
+	error = $4,
+	collected_at = $5
+WHERE
+	workspace_agent_id = $1
+	AND key = $2;
+
+-- name: GetWorkspaceAgentMetadataByAgentIDs :many
+SELECT
+	*
+FROM
+	workspace_agent_metadata
+WHERE
+	workspace_agent_id = ANY(@ids :: uuid [ ])
+ORDER BY
+	key ASC;
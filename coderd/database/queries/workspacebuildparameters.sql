@@ -0,0 +1,17 @@
+-- name: InsertWorkspaceBuildParameters :exec
+INSERT INTO
+	workspace_build_parameters (workspace_build_id, name, value)
+SELECT
+	@workspace_build_id :: uuid AS workspace_build_id,
+	unnest(@name :: text[]) AS name,
+	unnest(@value :: text[]) AS value;
+
+-- name: GetWorkspaceBuildParameters :many
+SELECT
+	*
+FROM
+	workspace_build_parameters
+WHERE
+	workspace_build_id = $1
+ORDER BY
+	name;
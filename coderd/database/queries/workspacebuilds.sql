@@ -75,6 +75,19 @@ ORDER BY
 LIMIT
 	1;
 
+-- name: GetLatestWorkspaceBuildByWorkspaceIDAndTransition :one
+SELECT
+	*
+FROM
+	workspace_builds
+WHERE
+	workspace_id = $1
+	AND transition = $2
+ORDER BY
+    build_number desc
+LIMIT
+	1;
+
 -- name: GetLatestWorkspaceBuildsByWorkspaceIDs :many
 SELECT wb.*
 FROM (
@@ -142,3 +155,33 @@ SET
 WHERE
 	id = $1 RETURNING *;
 
+
+-- GetUpcomingWorkspaceAutostops returns the latest builds of running
+-- workspaces with a TTL that will be stopped by their deadline within the
+-- given window.
+-- name: GetUpcomingWorkspaceAutostops :many
+SELECT
+	workspaces.id AS workspace_id,
+	workspaces.name AS workspace_name,
+	workspaces.owner_id,
+	workspaces.organization_id,
+	workspace_builds.id AS build_id,
+	workspace_builds.deadline
+FROM
+	workspace_builds
+INNER JOIN
+	workspaces ON workspaces.id = workspace_builds.workspace_id
+WHERE
+	workspaces.deleted = false
+	AND workspaces.ttl IS NOT NULL
+	AND workspace_builds.transition = 'start'
+	AND workspace_builds.deadline > @deadline_after
+	AND workspace_builds.deadline <= @deadline_before
+	AND workspace_builds.build_number = (
+		SELECT
+			MAX(build_number)
+		FROM
+			workspace_builds AS latest
+		WHERE
+			latest.workspace_id = workspace_builds.workspace_id
+	);
@@ -229,6 +229,27 @@ SET
 WHERE
 	id = $1;
 
+-- name: UpdateWorkspaceDeletingAtByID :one
+UPDATE
+	workspaces
+SET
+	deleting_at = $2
+WHERE
+	id = $1
+RETURNING *;
+
+-- name: UpdateWorkspacesTTLByTemplateID :exec
+-- Clamps the TTL of workspaces created from a template to the template's
+-- maximum TTL.
+UPDATE
+	workspaces
+SET
+	ttl = @max_ttl::bigint
+WHERE
+	template_id = @template_id
+	AND deleted = false
+	AND (ttl IS NULL OR ttl > @max_ttl::bigint);
+
 -- name: UpdateWorkspaceLastUsedAt :exec
 UPDATE
 	workspaces
@@ -0,0 +1,49 @@
+-- name: InsertWorkspaceSessionRecording :one
+INSERT INTO
+	workspace_session_recordings (
+		id,
+		created_at,
+		organization_id,
+		workspace_id,
+		agent_id,
+		owner_id,
+		type,
+		command,
+		started_at,
+		ended_at,
+		file_id
+	)
+VALUES
+	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING *;
+
+-- name: GetWorkspaceSessionRecordingByID :one
+SELECT
+	*
+FROM
+	workspace_session_recordings
+WHERE
+	id = $1;
+
+-- name: GetWorkspaceSessionRecordings :many
+SELECT
+	*
+FROM
+	workspace_session_recordings
+WHERE
+	CASE
+		WHEN @workspace_id :: uuid != '00000000-0000-0000-0000-000000000000'::uuid THEN
+			workspace_id = @workspace_id
+		ELSE true
+	END
+	AND CASE
+		WHEN @owner_id :: uuid != '00000000-0000-0000-0000-000000000000'::uuid THEN
+			owner_id = @owner_id
+		ELSE true
+	END
+ORDER BY
+	started_at DESC, id DESC
+OFFSET
+	@offset_opt
+LIMIT
+	-- A null limit means "no limit", so 0 means return all
+	NULLIF(@limit_opt :: int, 0);
@@ -13,6 +13,7 @@ import (
 	"github.com/google/uuid"
 
 	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/filestore"
 	"github.com/coder/coder/coderd/httpapi"
 	"github.com/coder/coder/coderd/httpmw"
 	"github.com/coder/coder/coderd/rbac"
@@ -51,26 +52,13 @@ func (api *API) postFile(rw http.ResponseWriter, r *http.Request) {
 	}
 	hashBytes := sha256.Sum256(data)
 	hash := hex.EncodeToString(hashBytes[:])
-	file, err := api.Database.GetFileByHashAndCreator(ctx, database.GetFileByHashAndCreatorParams{
-		Hash:      hash,
-		CreatedBy: apiKey.UserID,
-	})
-	if err == nil {
-		// The file already exists!
-		httpapi.Write(ctx, rw, http.StatusOK, codersdk.UploadResponse{
-			ID: file.ID,
-		})
-		return
-	}
-	id := uuid.New()
-	file, err = api.Database.InsertFile(ctx, database.InsertFileParams{
-		ID:        id,
+	file, inserted, err := filestore.Upsert(ctx, api.Database, api.FileStore, database.InsertFileParams{
+		ID:        uuid.New(),
 		Hash:      hash,
 		CreatedBy: apiKey.UserID,
 		CreatedAt: database.Now(),
 		Mimetype:  contentType,
-		Data:      data,
-	})
+	}, data)
 	if err != nil {
 		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
 			Message: "Internal error saving file.",
@@ -78,6 +66,13 @@ func (api *API) postFile(rw http.ResponseWriter, r *http.Request) {
 		})
 		return
 	}
+	if !inserted {
+		// The file already exists!
+		httpapi.Write(ctx, rw, http.StatusOK, codersdk.UploadResponse{
+			ID: file.ID,
+		})
+		return
+	}
 
 	httpapi.Write(ctx, rw, http.StatusCreated, codersdk.UploadResponse{
 		ID: file.ID,
@@ -123,7 +118,16 @@ func (api *API) fileByID(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	data, err := filestore.Get(ctx, api.Database, api.FileStore, file)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching file contents.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
 	rw.Header().Set("Content-Type", file.Mimetype)
 	rw.WriteHeader(http.StatusOK)
-	_, _ = rw.Write(file.Data)
+	_, _ = rw.Write(data)
 }
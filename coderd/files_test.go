@@ -9,6 +9,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/filestore/filestoretest"
 	"github.com/coder/coder/codersdk"
 	"github.com/coder/coder/testutil"
 )
@@ -86,4 +87,22 @@ func TestDownload(t *testing.T) {
 		require.Len(t, data, 1024)
 		require.Equal(t, codersdk.ContentTypeTar, contentType)
 	})
+	t.Run("S3", func(t *testing.T) {
+		t.Parallel()
+		srv := filestoretest.NewS3Server(t)
+		client := coderdtest.New(t, &coderdtest.Options{
+			FileStore: srv.Store(t, "coder"),
+		})
+		_ = coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		resp, err := client.Upload(ctx, codersdk.ContentTypeTar, make([]byte, 1024))
+		require.NoError(t, err)
+		require.Len(t, srv.Objects(), 1)
+		data, _, err := client.Download(ctx, resp.ID)
+		require.NoError(t, err)
+		require.Len(t, data, 1024)
+	})
 }
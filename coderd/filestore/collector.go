@@ -0,0 +1,151 @@
+package filestore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"github.com/coder/coder/coderd/database"
+)
+
+// GracePeriod is how long files must exist before they are collected. Files
+// are uploaded before the provisioner jobs that reference them are created,
+// so recently uploaded files are never collected.
+const GracePeriod = 24 * time.Hour
+
+// Collector periodically deletes files that are not referenced by any
+// provisioner job.
+type Collector struct {
+	database database.Store
+	store    Store
+	log      slog.Logger
+
+	done   chan struct{}
+	cancel func()
+
+	interval time.Duration
+}
+
+// NewCollector starts a collector that deletes unreferenced files every
+// interval. A nil store saves file contents in the database.
+func NewCollector(db database.Store, store Store, log slog.Logger, interval time.Duration) *Collector {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	c := &Collector{
+		database: db,
+		store:    store,
+		log:      log,
+		done:     make(chan struct{}),
+		cancel:   cancel,
+		interval: interval,
+	}
+	go c.run(ctx)
+	return c
+}
+
+func (c *Collector) run(ctx context.Context) {
+	defer close(c.done)
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		start := time.Now()
+		deleted, err := Collect(ctx, c.database, c.store, start.Add(-GracePeriod))
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			c.log.Warn(ctx, "collect unreferenced files", slog.Error(err))
+		} else {
+			c.log.Debug(ctx, "collected unreferenced files",
+				slog.F("deleted", deleted),
+				slog.F("took", time.Since(start)),
+			)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (c *Collector) Close() error {
+	c.cancel()
+	<-c.done
+	return nil
+}
+
+// Collect deletes files created before the given time that are not
+// referenced by any provisioner job. Contents are deleted from their store
+// once no files reference them. Files saved in a store that is not configured
+// are skipped. It returns the number of files deleted.
+//
+// Each file is deleted in its own transaction holding the lock for its hash,
+// so files reused or inserted with the same hash by concurrent uploads are
+// never deleted or left without contents.
+func Collect(ctx context.Context, db database.Store, store Store, before time.Time) (int, error) {
+	files, err := db.GetUnreferencedFilesCreatedBefore(ctx, before)
+	if err != nil {
+		return 0, xerrors.Errorf("get unreferenced files: %w", err)
+	}
+
+	deleted := 0
+	for _, file := range files {
+		collected := false
+		err = db.InTx(func(tx database.Store) error {
+			fileStore, err := storeFor(tx, store, file.Storage)
+			if err != nil {
+				return nil
+			}
+			err = tx.AcquireLock(ctx, database.LockIDFileHash(file.Hash))
+			if err != nil {
+				return xerrors.Errorf("acquire lock: %w", err)
+			}
+			_, err = tx.DeleteUnreferencedFileByID(ctx, database.DeleteUnreferencedFileByIDParams{
+				ID:            file.ID,
+				CreatedBefore: before,
+			})
+			if errors.Is(err, sql.ErrNoRows) {
+				// The file was reused or referenced since it was listed.
+				return nil
+			}
+			if err != nil {
+				return xerrors.Errorf("delete file %q: %w", file.ID, err)
+			}
+			collected = true
+
+			count, err := tx.GetFileCountByHashAndStorage(ctx, database.GetFileCountByHashAndStorageParams{
+				Hash:    file.Hash,
+				Storage: file.Storage,
+			})
+			if err != nil {
+				return xerrors.Errorf("get file count: %w", err)
+			}
+			if count > 0 {
+				return nil
+			}
+			err = fileStore.Delete(ctx, file.Hash)
+			if err != nil {
+				return xerrors.Errorf("delete file contents %q: %w", file.Hash, err)
+			}
+			return nil
+		}, nil)
+		if err != nil {
+			return deleted, err
+		}
+		if collected {
+			deleted++
+		}
+	}
+	return deleted, nil
+}
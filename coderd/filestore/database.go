@@ -0,0 +1,42 @@
+package filestore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/coder/coder/coderd/database"
+)
+
+// NewDatabase returns a store that saves file contents in the database. This
+// is the default store.
+func NewDatabase(db database.Store) Store {
+	return &databaseStore{db: db}
+}
+
+type databaseStore struct {
+	db database.Store
+}
+
+func (*databaseStore) Storage() database.FileStorage {
+	return database.FileStorageDatabase
+}
+
+func (s *databaseStore) Put(ctx context.Context, hash string, data []byte) error {
+	return s.db.InsertFileData(ctx, database.InsertFileDataParams{
+		Hash: hash,
+		Data: data,
+	})
+}
+
+func (s *databaseStore) Get(ctx context.Context, hash string) ([]byte, error) {
+	data, err := s.db.GetFileDataByHash(ctx, hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+func (s *databaseStore) Delete(ctx context.Context, hash string) error {
+	return s.db.DeleteFileDataByHash(ctx, hash)
+}
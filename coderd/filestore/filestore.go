@@ -0,0 +1,150 @@
+// Package filestore stores the contents of uploaded files and template
+// archives. Contents are keyed by their SHA256 hash, so identical uploads are
+// only stored once regardless of who uploaded them.
+package filestore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/coderd/database"
+)
+
+// ErrNotFound is returned when the contents of a file do not exist in a store.
+var ErrNotFound = xerrors.New("file contents not found")
+
+// Store stores the contents of files, keyed by their hex-encoded SHA256 hash.
+type Store interface {
+	// Storage is the backend recorded on files saved in the store.
+	Storage() database.FileStorage
+	// Put saves the contents of a file. It is a no-op if the contents
+	// already exist.
+	Put(ctx context.Context, hash string, data []byte) error
+	// Get returns the contents of a file, or ErrNotFound.
+	Get(ctx context.Context, hash string) ([]byte, error)
+	// Delete removes the contents of a file. It is a no-op if the contents
+	// do not exist.
+	Delete(ctx context.Context, hash string) error
+}
+
+// Insert saves the contents of a file to the store and inserts the file. The
+// storage of the file is set to the storage of the store.
+func Insert(ctx context.Context, db database.Store, store Store, arg database.InsertFileParams, data []byte) (database.File, error) {
+	var file database.File
+	err := db.InTx(func(tx database.Store) error {
+		err := tx.AcquireLock(ctx, database.LockIDFileHash(arg.Hash))
+		if err != nil {
+			return xerrors.Errorf("acquire lock: %w", err)
+		}
+		file, err = insertLocked(ctx, tx, store, arg, data)
+		return err
+	}, nil)
+	return file, err
+}
+
+// Upsert returns the file with the same hash and creator as arg, or inserts
+// one if none exists. Existing files have their creation time refreshed so
+// they aren't collected before the caller references them. It returns
+// whether the file was inserted.
+func Upsert(ctx context.Context, db database.Store, store Store, arg database.InsertFileParams, data []byte) (database.File, bool, error) {
+	var (
+		file     database.File
+		inserted bool
+	)
+	err := db.InTx(func(tx database.Store) error {
+		err := tx.AcquireLock(ctx, database.LockIDFileHash(arg.Hash))
+		if err != nil {
+			return xerrors.Errorf("acquire lock: %w", err)
+		}
+		file, err = tx.GetFileByHashAndCreator(ctx, database.GetFileByHashAndCreatorParams{
+			Hash:      arg.Hash,
+			CreatedBy: arg.CreatedBy,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			file, err = insertLocked(ctx, tx, store, arg, data)
+			inserted = err == nil
+			return err
+		}
+		if err != nil {
+			return xerrors.Errorf("get file: %w", err)
+		}
+		err = tx.UpdateFileCreatedAtByID(ctx, database.UpdateFileCreatedAtByIDParams{
+			ID:        file.ID,
+			CreatedAt: arg.CreatedAt,
+		})
+		if err != nil {
+			return xerrors.Errorf("refresh file: %w", err)
+		}
+		file.CreatedAt = arg.CreatedAt
+		return nil
+	}, nil)
+	return file, inserted, err
+}
+
+// insertLocked saves the contents of a file and inserts it. The caller must
+// hold the lock for the hash of the file.
+func insertLocked(ctx context.Context, db database.Store, store Store, arg database.InsertFileParams, data []byte) (database.File, error) {
+	store = withDatabase(db, store)
+	arg.Storage = store.Storage()
+	err := store.Put(ctx, arg.Hash, data)
+	if err != nil {
+		return database.File{}, xerrors.Errorf("put file contents: %w", err)
+	}
+	file, err := db.InsertFile(ctx, arg)
+	if err != nil {
+		return database.File{}, xerrors.Errorf("insert file: %w", err)
+	}
+	return file, nil
+}
+
+// Get returns the contents of a file from the store it was saved in. Files
+// saved in the database can always be read, so the configured store can be
+// changed without migrating existing files.
+func Get(ctx context.Context, db database.Store, store Store, file database.File) ([]byte, error) {
+	fileStore, err := storeFor(db, store, file.Storage)
+	if err != nil {
+		return nil, err
+	}
+	return fileStore.Get(ctx, file.Hash)
+}
+
+// storeFor returns the store holding the contents of files saved with the
+// given storage.
+func storeFor(db database.Store, store Store, storage database.FileStorage) (Store, error) {
+	store = withDatabase(db, store)
+	switch storage {
+	case store.Storage():
+		return store, nil
+	case database.FileStorageDatabase:
+		return NewDatabase(db), nil
+	default:
+		return nil, xerrors.Errorf("file contents are saved in %q storage, which is not configured", storage)
+	}
+}
+
+// withDatabase returns a database store using db if store is nil or saves to
+// the database, so contents are written in the same transaction as files.
+func withDatabase(db database.Store, store Store) Store {
+	if store == nil || store.Storage() == database.FileStorageDatabase {
+		return NewDatabase(db)
+	}
+	return store
+}
+
+// validHash returns an error if hash is not a hex-encoded SHA256 hash. Hashes
+// are used as object keys and file names, so they must be validated.
+func validHash(hash string) error {
+	if len(hash) != hex.EncodedLen(32) {
+		return xerrors.Errorf("invalid hash %q: must be %d characters", hash, hex.EncodedLen(32))
+	}
+	for _, r := range hash {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return xerrors.Errorf("invalid hash %q: must be lowercase hex", hash)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+package filestore_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/database/databasefake"
+	"github.com/coder/coder/coderd/filestore"
+	"github.com/coder/coder/coderd/filestore/filestoretest"
+	"github.com/coder/coder/testutil"
+)
+
+func TestStores(t *testing.T) {
+	t.Parallel()
+
+	stores := map[string]func(t *testing.T) filestore.Store{
+		"Database": func(t *testing.T) filestore.Store {
+			return filestore.NewDatabase(databasefake.New())
+		},
+		"Local": func(t *testing.T) filestore.Store {
+			store, err := filestore.NewLocal(t.TempDir())
+			require.NoError(t, err)
+			return store
+		},
+		"S3": func(t *testing.T) filestore.Store {
+			return filestoretest.NewS3Server(t).Store(t, "coder")
+		},
+	}
+
+	for name, newStore := range stores {
+		newStore := newStore
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx, cancel := testutil.Context(t)
+			defer cancel()
+			store := newStore(t)
+
+			data := []byte("hello world")
+			hash := hashOf(data)
+			_, err := store.Get(ctx, hash)
+			require.ErrorIs(t, err, filestore.ErrNotFound)
+
+			require.NoError(t, store.Put(ctx, hash, data))
+			// Putting the same contents again is a no-op.
+			require.NoError(t, store.Put(ctx, hash, data))
+			got, err := store.Get(ctx, hash)
+			require.NoError(t, err)
+			require.Equal(t, data, got)
+
+			require.NoError(t, store.Delete(ctx, hash))
+			require.NoError(t, store.Delete(ctx, hash))
+			_, err = store.Get(ctx, hash)
+			require.ErrorIs(t, err, filestore.ErrNotFound)
+		})
+	}
+}
+
+func TestInvalidHash(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := testutil.Context(t)
+	defer cancel()
+
+	store, err := filestore.NewLocal(t.TempDir())
+	require.NoError(t, err)
+	require.Error(t, store.Put(ctx, "../../etc/passwd", []byte("nope")))
+	_, err = store.Get(ctx, hashOf(nil)[:32])
+	require.Error(t, err)
+}
+
+func TestInsert(t *testing.T) {
+	t.Parallel()
+
+	t.Run("S3", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := testutil.Context(t)
+		defer cancel()
+		db := databasefake.New()
+		srv := filestoretest.NewS3Server(t)
+		store := srv.Store(t, "coder")
+
+		data := []byte("some archive")
+		first, err := filestore.Insert(ctx, db, store, fileParams(data, time.Now()), data)
+		require.NoError(t, err)
+		require.Equal(t, database.FileStorageS3, first.Storage)
+		second, err := filestore.Insert(ctx, db, store, fileParams(data, time.Now()), data)
+		require.NoError(t, err)
+		require.NotEqual(t, first.ID, second.ID)
+		// Identical contents are only stored once.
+		require.Len(t, srv.Objects(), 1)
+
+		got, err := filestore.Get(ctx, db, store, first)
+		require.NoError(t, err)
+		require.Equal(t, data, got)
+	})
+
+	t.Run("DatabaseAfterSwitching", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := testutil.Context(t)
+		defer cancel()
+		db := databasefake.New()
+
+		data := []byte("some archive")
+		file, err := filestore.Insert(ctx, db, nil, fileParams(data, time.Now()), data)
+		require.NoError(t, err)
+		require.Equal(t, database.FileStorageDatabase, file.Storage)
+
+		// Files saved in the database can be read after switching stores.
+		local, err := filestore.NewLocal(t.TempDir())
+		require.NoError(t, err)
+		got, err := filestore.Get(ctx, db, local, file)
+		require.NoError(t, err)
+		require.Equal(t, data, got)
+	})
+
+	t.Run("NotConfigured", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := testutil.Context(t)
+		defer cancel()
+		db := databasefake.New()
+		local, err := filestore.NewLocal(t.TempDir())
+		require.NoError(t, err)
+
+		data := []byte("some archive")
+		file, err := filestore.Insert(ctx, db, local, fileParams(data, time.Now()), data)
+		require.NoError(t, err)
+		_, err = filestore.Get(ctx, db, nil, file)
+		require.ErrorContains(t, err, "not configured")
+	})
+}
+
+func TestUpsert(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := testutil.Context(t)
+	defer cancel()
+	db := databasefake.New()
+	store, err := filestore.NewLocal(t.TempDir())
+	require.NoError(t, err)
+
+	data := []byte("some archive")
+	arg := fileParams(data, time.Now().Add(-2*filestore.GracePeriod))
+	first, inserted, err := filestore.Upsert(ctx, db, store, arg, data)
+	require.NoError(t, err)
+	require.True(t, inserted)
+
+	// Reusing the file refreshes it, so it isn't collected before the
+	// uploader references it.
+	arg.ID = uuid.New()
+	arg.CreatedAt = time.Now()
+	second, inserted, err := filestore.Upsert(ctx, db, store, arg, data)
+	require.NoError(t, err)
+	require.False(t, inserted)
+	require.Equal(t, first.ID, second.ID)
+
+	deleted, err := filestore.Collect(ctx, db, store, time.Now().Add(-filestore.GracePeriod))
+	require.NoError(t, err)
+	require.Zero(t, deleted)
+	got, err := filestore.Get(ctx, db, store, second)
+	require.NoError(t, err)
+	require.Equal(t, data, got)
+}
+
+func TestCollect(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := testutil.Context(t)
+	defer cancel()
+	db := databasefake.New()
+	store, err := filestore.NewLocal(t.TempDir())
+	require.NoError(t, err)
+
+	old := time.Now().Add(-2 * filestore.GracePeriod)
+	shared := []byte("shared")
+	referenced, err := filestore.Insert(ctx, db, store, fileParams(shared, old), shared)
+	require.NoError(t, err)
+	_, err = db.InsertProvisionerJob(ctx, database.InsertProvisionerJobParams{
+		ID:            uuid.New(),
+		CreatedAt:     old,
+		UpdatedAt:     old,
+		Provisioner:   database.ProvisionerTypeEcho,
+		StorageMethod: database.ProvisionerStorageMethodFile,
+		FileID:        referenced.ID,
+		Type:          database.ProvisionerJobTypeTemplateVersionImport,
+		Input:         []byte("{}"),
+	})
+	require.NoError(t, err)
+	// Shares contents with a referenced file.
+	duplicate, err := filestore.Insert(ctx, db, store, fileParams(shared, old), shared)
+	require.NoError(t, err)
+
+	unreferencedData := []byte("unreferenced")
+	unreferenced, err := filestore.Insert(ctx, db, store, fileParams(unreferencedData, old), unreferencedData)
+	require.NoError(t, err)
+
+	recentData := []byte("recent")
+	recent, err := filestore.Insert(ctx, db, store, fileParams(recentData, time.Now()), recentData)
+	require.NoError(t, err)
+
+	deleted, err := filestore.Collect(ctx, db, store, time.Now().Add(-filestore.GracePeriod))
+	require.NoError(t, err)
+	require.Equal(t, 2, deleted)
+
+	for _, file := range []database.File{referenced, recent} {
+		_, err = db.GetFileByID(ctx, file.ID)
+		require.NoError(t, err)
+		_, err = filestore.Get(ctx, db, store, file)
+		require.NoError(t, err)
+	}
+	for _, file := range []database.File{duplicate, unreferenced} {
+		_, err = db.GetFileByID(ctx, file.ID)
+		require.Error(t, err)
+	}
+	_, err = store.Get(ctx, unreferenced.Hash)
+	require.ErrorIs(t, err, filestore.ErrNotFound)
+}
+
+func fileParams(data []byte, createdAt time.Time) database.InsertFileParams {
+	return database.InsertFileParams{
+		ID:        uuid.New(),
+		Hash:      hashOf(data),
+		CreatedAt: createdAt,
+		CreatedBy: uuid.New(),
+		Mimetype:  "application/x-tar",
+	}
+}
+
+func hashOf(data []byte) string {
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,118 @@
+// Package filestoretest provides an in-memory S3-compatible server for
+// testing object storage without a real MinIO or AWS deployment.
+package filestoretest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/filestore"
+)
+
+// S3Server is an in-memory S3-compatible server. It supports the subset of
+// the API used by filestore, with path-style addressing.
+type S3Server struct {
+	URL *url.URL
+
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+// NewS3Server starts an S3-compatible server that is closed when the test
+// finishes.
+func NewS3Server(t *testing.T) *S3Server {
+	t.Helper()
+
+	s := &S3Server{
+		objects: map[string][]byte{},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	t.Cleanup(srv.Close)
+
+	var err error
+	s.URL, err = url.Parse(srv.URL)
+	require.NoError(t, err)
+	return s
+}
+
+// Store returns a store using the server.
+func (s *S3Server) Store(t *testing.T, bucket string) filestore.Store {
+	t.Helper()
+
+	store, err := filestore.NewS3(filestore.S3Options{
+		Endpoint:        s.URL,
+		Region:          "us-east-1",
+		Bucket:          bucket,
+		AccessKeyID:     "access-key",
+		SecretAccessKey: "secret-key",
+		UsePathStyle:    true,
+	})
+	require.NoError(t, err)
+	return store
+}
+
+// Objects returns the keys of all objects in the server, prefixed by their
+// bucket.
+func (s *S3Server) Objects() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]string, 0, len(s.objects))
+	for key := range s.objects {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func (s *S3Server) serveHTTP(rw http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ") {
+		http.Error(rw, "AccessDenied", http.StatusForbidden)
+		return
+	}
+	key := strings.TrimPrefix(r.URL.Path, "/")
+	if !strings.Contains(key, "/") {
+		http.Error(rw, "InvalidRequest", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch r.Method {
+	case http.MethodHead, http.MethodGet:
+		data, ok := s.objects[key]
+		if !ok {
+			http.Error(rw, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		rw.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodGet {
+			_, _ = rw.Write(data)
+		}
+	case http.MethodPut:
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		hash := sha256.Sum256(data)
+		if r.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(hash[:]) {
+			http.Error(rw, "XAmzContentSHA256Mismatch", http.StatusBadRequest)
+			return
+		}
+		s.objects[key] = data
+		rw.WriteHeader(http.StatusOK)
+	case http.MethodDelete:
+		delete(s.objects, key)
+		rw.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(rw, "MethodNotAllowed", http.StatusMethodNotAllowed)
+	}
+}
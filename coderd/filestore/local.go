@@ -0,0 +1,110 @@
+package filestore
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/coderd/database"
+)
+
+// NewLocal returns a store that saves file contents in a directory on the
+// local filesystem. The directory must be shared by all coderd replicas.
+func NewLocal(dir string) (Store, error) {
+	if dir == "" {
+		return nil, xerrors.New("directory must be set")
+	}
+	err := os.MkdirAll(dir, 0o700)
+	if err != nil {
+		return nil, xerrors.Errorf("create directory: %w", err)
+	}
+	return &localStore{dir: dir}, nil
+}
+
+type localStore struct {
+	dir string
+}
+
+func (*localStore) Storage() database.FileStorage {
+	return database.FileStorageLocal
+}
+
+func (s *localStore) Put(_ context.Context, hash string, data []byte) error {
+	name, err := s.path(hash)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stat(name)
+	if err == nil {
+		return nil
+	}
+	err = os.MkdirAll(filepath.Dir(name), 0o700)
+	if err != nil {
+		return xerrors.Errorf("create directory: %w", err)
+	}
+
+	// Write to a temporary file first so partially written contents are
+	// never read.
+	tmp, err := os.CreateTemp(filepath.Dir(name), hash+".tmp*")
+	if err != nil {
+		return xerrors.Errorf("create temporary file: %w", err)
+	}
+	defer func() {
+		// The file no longer exists once it has been renamed.
+		_ = os.Remove(tmp.Name())
+	}()
+	_, err = tmp.Write(data)
+	if err != nil {
+		_ = tmp.Close()
+		return xerrors.Errorf("write file: %w", err)
+	}
+	err = tmp.Close()
+	if err != nil {
+		return xerrors.Errorf("close file: %w", err)
+	}
+	err = os.Rename(tmp.Name(), name)
+	if err != nil {
+		return xerrors.Errorf("rename file: %w", err)
+	}
+	return nil
+}
+
+func (s *localStore) Get(_ context.Context, hash string) ([]byte, error) {
+	name, err := s.path(hash)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, xerrors.Errorf("read file: %w", err)
+	}
+	return data, nil
+}
+
+func (s *localStore) Delete(_ context.Context, hash string) error {
+	name, err := s.path(hash)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(name)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return xerrors.Errorf("remove file: %w", err)
+	}
+	return nil
+}
+
+// path returns the name of the file holding the contents. Files are sharded
+// by the first two characters of their hash to keep directories small.
+func (s *localStore) path(hash string) (string, error) {
+	err := validHash(hash)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(s.dir, hash[:2], hash), nil
+}
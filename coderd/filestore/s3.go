@@ -0,0 +1,211 @@
+package filestore
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/coderd/database"
+)
+
+// S3Options configures a store backed by an S3-compatible object storage
+// service, such as AWS S3 or MinIO.
+type S3Options struct {
+	// Endpoint is the URL of the service. It defaults to the AWS S3 endpoint
+	// of the region.
+	Endpoint *url.URL
+	Region   string
+	Bucket   string
+	// Prefix is prepended to the keys of objects.
+	Prefix string
+	// AccessKeyID and SecretAccessKey are used to sign requests. Requests
+	// are not signed if they are empty.
+	AccessKeyID     string
+	SecretAccessKey string
+	// UsePathStyle addresses the bucket in the path of requests instead of
+	// the hostname. Most self-hosted services require it.
+	UsePathStyle bool
+	HTTPClient   *http.Client
+}
+
+// NewS3 returns a store that saves file contents as objects in an
+// S3-compatible bucket.
+func NewS3(opts S3Options) (Store, error) {
+	if opts.Bucket == "" {
+		return nil, xerrors.New("bucket must be set")
+	}
+	if opts.Region == "" {
+		return nil, xerrors.New("region must be set")
+	}
+	if opts.Endpoint == nil {
+		opts.Endpoint = &url.URL{
+			Scheme: "https",
+			Host:   fmt.Sprintf("s3.%s.amazonaws.com", opts.Region),
+		}
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = http.DefaultClient
+	}
+	return &s3Store{opts: opts}, nil
+}
+
+type s3Store struct {
+	opts S3Options
+}
+
+func (*s3Store) Storage() database.FileStorage {
+	return database.FileStorageS3
+}
+
+func (s *s3Store) Put(ctx context.Context, hash string, data []byte) error {
+	// Objects are keyed by hash, so existing objects are never uploaded
+	// again.
+	res, err := s.request(ctx, http.MethodHead, hash, nil)
+	if err != nil {
+		return err
+	}
+	_ = res.Body.Close()
+	if res.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	res, err = s.request(ctx, http.MethodPut, hash, data)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return s3Error(res)
+	}
+	return nil
+}
+
+func (s *s3Store) Get(ctx context.Context, hash string) ([]byte, error) {
+	res, err := s.request(ctx, http.MethodGet, hash, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, s3Error(res)
+	}
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, xerrors.Errorf("read object: %w", err)
+	}
+	return data, nil
+}
+
+func (s *s3Store) Delete(ctx context.Context, hash string) error {
+	res, err := s.request(ctx, http.MethodDelete, hash, nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	switch res.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	default:
+		return s3Error(res)
+	}
+}
+
+func (s *s3Store) request(ctx context.Context, method, hash string, body []byte) (*http.Response, error) {
+	err := validHash(hash)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, s.objectURL(hash).String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, xerrors.Errorf("create request: %w", err)
+	}
+	if s.opts.AccessKeyID != "" {
+		s.sign(req, body, time.Now().UTC())
+	}
+	res, err := s.opts.HTTPClient.Do(req)
+	if err != nil {
+		return nil, xerrors.Errorf("%s object: %w", strings.ToLower(method), err)
+	}
+	return res, nil
+}
+
+func (s *s3Store) objectURL(hash string) *url.URL {
+	u := *s.opts.Endpoint
+	key := s.opts.Prefix + hash
+	if s.opts.UsePathStyle {
+		u.Path = path.Join("/", u.Path, s.opts.Bucket, key)
+	} else {
+		u.Host = s.opts.Bucket + "." + u.Host
+		u.Path = path.Join("/", u.Path, key)
+	}
+	return &u
+}
+
+// sign adds an AWS Signature Version 4 to the request.
+// See: https://docs.aws.amazon.com/AmazonS3/latest/API/sig-v4-header-based-auth.html
+func (s *s3Store) sign(req *http.Request, body []byte, now time.Time) {
+	var (
+		payloadHash = sha256Hex(body)
+		amzDate     = now.Format("20060102T150405Z")
+		date        = now.Format("20060102")
+		scope       = strings.Join([]string{date, s.opts.Region, "s3", "aws4_request"}, "/")
+	)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	const signedHeaders = "host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := fmt.Sprintf("host:%s\nx-amz-content-sha256:%s\nx-amz-date:%s\n", req.URL.Host, payloadHash, amzDate)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.opts.SecretAccessKey), date)
+	key = hmacSHA256(key, s.opts.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.opts.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func s3Error(res *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(res.Body, 4096))
+	return xerrors.Errorf("unexpected status code %d: %s", res.StatusCode, strings.TrimSpace(string(body)))
+}
+
+func sha256Hex(data []byte) string {
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
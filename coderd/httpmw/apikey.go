@@ -20,6 +20,7 @@ import (
 
 	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/coderd/rbac"
 	"github.com/coder/coder/codersdk"
 )
 
@@ -55,7 +56,7 @@ type Authorization struct {
 	Username string
 	Roles    []string
 	Groups   []string
-	Scope    database.APIKeyScope
+	Scope    rbac.ExpandableScope
 }
 
 // UserAuthorizationOptional may return the roles and scope used for
@@ -104,6 +105,10 @@ type ExtractAPIKeyConfig struct {
 	// will be deleted and the request will continue. If the request is not a
 	// cookie-based request, the request will be rejected with a 401.
 	Optional bool
+
+	// TokenUsed is called when the last used time of a token is updated,
+	// which happens at most once an hour for each token. It's optional.
+	TokenUsed func(r *http.Request, key database.APIKey)
 }
 
 // ExtractAPIKey requires authentication using a valid API key. It handles
@@ -248,7 +253,9 @@ func ExtractAPIKey(cfg ExtractAPIKeyConfig) func(http.Handler) http.Handler {
 			}
 
 			// Only update LastUsed once an hour to prevent database spam.
+			lastUsedChanged := false
 			if now.Sub(key.LastUsed) > time.Hour {
+				lastUsedChanged = true
 				key.LastUsed = now
 				remoteIP := net.ParseIP(r.RemoteAddr)
 				if remoteIP == nil {
@@ -265,9 +272,10 @@ func ExtractAPIKey(cfg ExtractAPIKeyConfig) func(http.Handler) http.Handler {
 				changed = true
 			}
 			// Only update the ExpiresAt once an hour to prevent database spam.
-			// We extend the ExpiresAt to reduce re-authentication.
+			// We extend the ExpiresAt to reduce re-authentication. Tokens
+			// expire at a fixed time, so they're never extended.
 			apiKeyLifetime := time.Duration(key.LifetimeSeconds) * time.Second
-			if key.ExpiresAt.Sub(now) <= apiKeyLifetime-time.Hour {
+			if key.LoginType != database.LoginTypeToken && key.ExpiresAt.Sub(now) <= apiKeyLifetime-time.Hour {
 				key.ExpiresAt = now.Add(apiKeyLifetime)
 				changed = true
 			}
@@ -321,6 +329,10 @@ func ExtractAPIKey(cfg ExtractAPIKeyConfig) func(http.Handler) http.Handler {
 				}
 			}
 
+			if lastUsedChanged && key.LoginType == database.LoginTypeToken && cfg.TokenUsed != nil {
+				cfg.TokenUsed(r, key)
+			}
+
 			// If the key is valid, we also fetch the user roles and status.
 			// The roles are used for RBAC authorize checks, and the status
 			// is to block 'suspended' users from accessing the platform.
@@ -345,7 +357,7 @@ func ExtractAPIKey(cfg ExtractAPIKeyConfig) func(http.Handler) http.Handler {
 				ID:       key.UserID,
 				Username: roles.Username,
 				Roles:    roles.Roles,
-				Scope:    key.Scope,
+				Scope:    key.RBACScope(),
 				Groups:   roles.Groups,
 			})
 
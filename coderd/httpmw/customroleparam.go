@@ -0,0 +1,78 @@
+package httpmw
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/codersdk"
+)
+
+type customRoleParamContextKey struct{}
+
+// CustomRoleParam returns the custom role extracted via the
+// ExtractCustomRoleParam middleware.
+func CustomRoleParam(r *http.Request) database.CustomRole {
+	role, ok := r.Context().Value(customRoleParamContextKey{}).(database.CustomRole)
+	if !ok {
+		panic("developer error: custom role param middleware not provided")
+	}
+	return role
+}
+
+// ExtractCustomRoleParam grabs a custom role from the "role" URL parameter.
+// The parameter is the name the role is assigned with, so organization roles
+// are suffixed with ":<organization_id>".
+func ExtractCustomRoleParam(db database.Store) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+
+			name, orgID, isOrgRole := strings.Cut(chi.URLParam(r, "role"), ":")
+			if name == "" {
+				httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+					Message: "\"role\" must be provided.",
+				})
+				return
+			}
+			var organizationID uuid.NullUUID
+			if isOrgRole {
+				parsed, err := uuid.Parse(orgID)
+				if err != nil {
+					httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+						Message: "Invalid organization id in role name.",
+						Detail:  err.Error(),
+					})
+					return
+				}
+				organizationID = uuid.NullUUID{UUID: parsed, Valid: true}
+			}
+
+			role, err := db.GetCustomRoleByName(ctx, database.GetCustomRoleByNameParams{
+				Name:           name,
+				OrganizationID: organizationID,
+			})
+			if errors.Is(err, sql.ErrNoRows) {
+				httpapi.ResourceNotFound(rw)
+				return
+			}
+			if err != nil {
+				httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+					Message: "Internal error fetching role.",
+					Detail:  err.Error(),
+				})
+				return
+			}
+
+			ctx = context.WithValue(ctx, customRoleParamContextKey{}, role)
+			next.ServeHTTP(rw, r.WithContext(ctx))
+		})
+	}
+}
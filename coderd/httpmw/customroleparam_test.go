@@ -0,0 +1,100 @@
+package httpmw_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/database/databasefake"
+	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/testutil"
+)
+
+func TestCustomRoleParam(t *testing.T) {
+	t.Parallel()
+
+	setup := func(t *testing.T, organizationID uuid.NullUUID) (database.Store, database.CustomRole) {
+		t.Helper()
+
+		ctx, _ := testutil.Context(t)
+		db := databasefake.New()
+
+		role, err := db.InsertCustomRole(ctx, database.InsertCustomRoleParams{
+			ID:             uuid.New(),
+			Name:           "developer",
+			DisplayName:    "Developer",
+			OrganizationID: organizationID,
+			CreatedAt:      database.Now(),
+			UpdatedAt:      database.Now(),
+		})
+		require.NoError(t, err)
+
+		return db, role
+	}
+
+	serve := func(t *testing.T, db database.Store, param string, handler http.HandlerFunc) *http.Response {
+		t.Helper()
+
+		r := httptest.NewRequest("GET", "/", nil)
+		w := httptest.NewRecorder()
+
+		router := chi.NewRouter()
+		router.Use(httpmw.ExtractCustomRoleParam(db))
+		router.Get("/", handler)
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("role", param)
+		r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+		router.ServeHTTP(w, r)
+		res := w.Result()
+		t.Cleanup(func() {
+			_ = res.Body.Close()
+		})
+		return res
+	}
+
+	t.Run("Site", func(t *testing.T) {
+		t.Parallel()
+
+		db, role := setup(t, uuid.NullUUID{})
+		res := serve(t, db, "developer", func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, role, httpmw.CustomRoleParam(r))
+			w.WriteHeader(http.StatusOK)
+		})
+		require.Equal(t, http.StatusOK, res.StatusCode)
+	})
+
+	t.Run("Organization", func(t *testing.T) {
+		t.Parallel()
+
+		db, role := setup(t, uuid.NullUUID{UUID: uuid.New(), Valid: true})
+		res := serve(t, db, role.RoleName(), func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, role, httpmw.CustomRoleParam(r))
+			w.WriteHeader(http.StatusOK)
+		})
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		// The organization role isn't a site wide role.
+		res = serve(t, db, "developer", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})
+		require.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("BadOrganization", func(t *testing.T) {
+		t.Parallel()
+
+		db, _ := setup(t, uuid.NullUUID{})
+		res := serve(t, db, "developer:nope", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})
+		require.Equal(t, http.StatusBadRequest, res.StatusCode)
+	})
+}
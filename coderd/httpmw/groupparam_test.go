@@ -39,6 +39,7 @@ func TestGroupParam(t *testing.T) {
 			ID:             uuid.New(),
 			Name:           "yeww",
 			OrganizationID: organization.ID,
+			Source:         database.GroupSourceUser,
 		})
 		require.NoError(t, err)
 
@@ -0,0 +1,108 @@
+package httpmw_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/database/databasefake"
+	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/testutil"
+)
+
+func TestWebhookParam(t *testing.T) {
+	t.Parallel()
+
+	setup := func(t *testing.T) (database.Store, database.Webhook) {
+		t.Helper()
+
+		ctx, _ := testutil.Context(t)
+		db := databasefake.New()
+
+		orgID := uuid.New()
+		organization, err := db.InsertOrganization(ctx, database.InsertOrganizationParams{
+			ID:          orgID,
+			Name:        "banana",
+			Description: "wowie",
+			CreatedAt:   database.Now(),
+			UpdatedAt:   database.Now(),
+		})
+		require.NoError(t, err)
+
+		webhook, err := db.InsertWebhook(ctx, database.InsertWebhookParams{
+			ID:             uuid.New(),
+			OrganizationID: organization.ID,
+			Name:           "yeww",
+			Url:            "https://example.com/hook",
+			Secret:         "secret",
+			Events:         []string{},
+			CreatedAt:      database.Now(),
+			UpdatedAt:      database.Now(),
+		})
+		require.NoError(t, err)
+
+		return db, webhook
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			db, webhook = setup(t)
+			r           = httptest.NewRequest("GET", "/", nil)
+			w           = httptest.NewRecorder()
+		)
+
+		router := chi.NewRouter()
+		router.Use(httpmw.ExtractWebhookParam(db))
+		router.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			wh := httpmw.WebhookParam(r)
+			require.Equal(t, webhook, wh)
+			w.WriteHeader(http.StatusOK)
+		})
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("webhook", webhook.ID.String())
+		r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+		router.ServeHTTP(w, r)
+
+		res := w.Result()
+		defer res.Body.Close()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			db, webhook = setup(t)
+			r           = httptest.NewRequest("GET", "/", nil)
+			w           = httptest.NewRecorder()
+		)
+
+		router := chi.NewRouter()
+		router.Use(httpmw.ExtractWebhookParam(db))
+		router.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			wh := httpmw.WebhookParam(r)
+			require.Equal(t, webhook, wh)
+			w.WriteHeader(http.StatusOK)
+		})
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("webhook", uuid.NewString())
+		r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+		router.ServeHTTP(w, r)
+
+		res := w.Result()
+		defer res.Body.Close()
+		require.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	"golang.org/x/xerrors"
 
 	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/coderd/httpapi"
@@ -61,6 +62,8 @@ func ExtractWorkspaceParam(db database.Store) func(http.Handler) http.Handler {
 // This can be in the form of:
 //   - "<workspace-name>.[workspace-agent]"	: If multiple agents exist
 //   - "<workspace-name>"					: If one agent exists
+//
+// Agents of a stopped workspace are resolved from its last start build.
 func ExtractWorkspaceAndAgentParam(db database.Store) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
@@ -94,26 +97,40 @@ func ExtractWorkspaceAndAgentParam(db database.Store) func(http.Handler) http.Ha
 				return
 			}
 
-			resources, err := db.GetWorkspaceResourcesByJobID(ctx, build.JobID)
+			agents, err := workspaceAgentsByJobID(ctx, db, build.JobID)
 			if err != nil {
 				httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
-					Message: "Internal error fetching workspace resources.",
+					Message: "Internal error fetching workspace agents.",
 					Detail:  err.Error(),
 				})
 				return
 			}
-			resourceIDs := make([]uuid.UUID, 0)
-			for _, resource := range resources {
-				resourceIDs = append(resourceIDs, resource.ID)
-			}
 
-			agents, err := db.GetWorkspaceAgentsByResourceIDs(ctx, resourceIDs)
-			if err != nil {
-				httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
-					Message: "Internal error fetching workspace agents.",
-					Detail:  err.Error(),
+			// Stopped workspaces usually have no agents. Fall back to the
+			// agents of the last start build so handlers like app access
+			// can start the workspace again.
+			if len(agents) == 0 && build.Transition == database.WorkspaceTransitionStop {
+				startBuild, err := db.GetLatestWorkspaceBuildByWorkspaceIDAndTransition(ctx, database.GetLatestWorkspaceBuildByWorkspaceIDAndTransitionParams{
+					WorkspaceID: workspace.ID,
+					Transition:  database.WorkspaceTransitionStart,
 				})
-				return
+				if err != nil && !errors.Is(err, sql.ErrNoRows) {
+					httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+						Message: "Internal error fetching workspace build.",
+						Detail:  err.Error(),
+					})
+					return
+				}
+				if err == nil {
+					agents, err = workspaceAgentsByJobID(ctx, db, startBuild.JobID)
+					if err != nil {
+						httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+							Message: "Internal error fetching workspace agents.",
+							Detail:  err.Error(),
+						})
+						return
+					}
+				}
 			}
 
 			if len(agents) == 0 {
@@ -160,3 +177,19 @@ func ExtractWorkspaceAndAgentParam(db database.Store) func(http.Handler) http.Ha
 		})
 	}
 }
+
+func workspaceAgentsByJobID(ctx context.Context, db database.Store, jobID uuid.UUID) ([]database.WorkspaceAgent, error) {
+	resources, err := db.GetWorkspaceResourcesByJobID(ctx, jobID)
+	if err != nil {
+		return nil, xerrors.Errorf("get workspace resources: %w", err)
+	}
+	resourceIDs := make([]uuid.UUID, 0)
+	for _, resource := range resources {
+		resourceIDs = append(resourceIDs, resource.ID)
+	}
+	agents, err := db.GetWorkspaceAgentsByResourceIDs(ctx, resourceIDs)
+	if err != nil {
+		return nil, xerrors.Errorf("get workspace agents: %w", err)
+	}
+	return agents, nil
+}
@@ -0,0 +1,58 @@
+package httpmw
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/codersdk"
+)
+
+type workspaceSessionRecordingParamContextKey struct{}
+
+// WorkspaceSessionRecordingParam returns the session recording extracted via
+// the ExtractWorkspaceSessionRecordingParam middleware.
+func WorkspaceSessionRecordingParam(r *http.Request) database.WorkspaceSessionRecording {
+	recording, ok := r.Context().Value(workspaceSessionRecordingParamContextKey{}).(database.WorkspaceSessionRecording)
+	if !ok {
+		panic("developer error: session recording param middleware not provided")
+	}
+	return recording
+}
+
+// ExtractWorkspaceSessionRecordingParam grabs a session recording from the
+// "sessionrecording" URL parameter.
+func ExtractWorkspaceSessionRecordingParam(db database.Store) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+
+			recordingID, parsed := parseUUID(rw, r, "sessionrecording")
+			if !parsed {
+				return
+			}
+
+			recording, err := db.GetWorkspaceSessionRecordingByID(ctx, recordingID)
+			if errors.Is(err, sql.ErrNoRows) {
+				httpapi.ResourceNotFound(rw)
+				return
+			}
+			if err != nil {
+				httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+					Message: "Internal error fetching session recording.",
+					Detail:  err.Error(),
+				})
+				return
+			}
+
+			ctx = context.WithValue(ctx, workspaceSessionRecordingParamContextKey{}, recording)
+			chi.RouteContext(ctx).URLParams.Add("organization", recording.OrganizationID.String())
+			next.ServeHTTP(rw, r.WithContext(ctx))
+		})
+	}
+}
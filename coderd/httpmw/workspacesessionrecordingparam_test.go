@@ -0,0 +1,100 @@
+package httpmw_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/database/databasefake"
+	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/testutil"
+)
+
+func TestWorkspaceSessionRecordingParam(t *testing.T) {
+	t.Parallel()
+
+	setup := func(t *testing.T) (database.Store, database.WorkspaceSessionRecording) {
+		t.Helper()
+
+		ctx, _ := testutil.Context(t)
+		db := databasefake.New()
+
+		now := database.Now()
+		recording, err := db.InsertWorkspaceSessionRecording(ctx, database.InsertWorkspaceSessionRecordingParams{
+			ID:             uuid.New(),
+			CreatedAt:      now,
+			OrganizationID: uuid.New(),
+			WorkspaceID:    uuid.New(),
+			AgentID:        uuid.New(),
+			OwnerID:        uuid.New(),
+			Type:           database.SessionRecordingTypeSSH,
+			StartedAt:      now.Add(-time.Minute),
+			EndedAt:        now,
+			FileID:         uuid.New(),
+		})
+		require.NoError(t, err)
+
+		return db, recording
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			db, recording = setup(t)
+			r             = httptest.NewRequest("GET", "/", nil)
+			w             = httptest.NewRecorder()
+		)
+
+		router := chi.NewRouter()
+		router.Use(httpmw.ExtractWorkspaceSessionRecordingParam(db))
+		router.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, recording, httpmw.WorkspaceSessionRecordingParam(r))
+			require.Equal(t, recording.OrganizationID.String(), chi.URLParam(r, "organization"))
+			w.WriteHeader(http.StatusOK)
+		})
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("sessionrecording", recording.ID.String())
+		r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+		router.ServeHTTP(w, r)
+
+		res := w.Result()
+		defer res.Body.Close()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			db, _ = setup(t)
+			r     = httptest.NewRequest("GET", "/", nil)
+			w     = httptest.NewRecorder()
+		)
+
+		router := chi.NewRouter()
+		router.Use(httpmw.ExtractWorkspaceSessionRecordingParam(db))
+		router.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("sessionrecording", uuid.NewString())
+		r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+		router.ServeHTTP(w, r)
+
+		res := w.Result()
+		defer res.Body.Close()
+		require.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+}
@@ -0,0 +1,356 @@
+package coderd
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"cdr.dev/slog"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/codersdk"
+)
+
+// insightsRequest is the parsed filter of an insights report.
+type insightsRequest struct {
+	startTime time.Time
+	endTime   time.Time
+	// templates are the templates to report on, all of which the user can
+	// update.
+	templates []database.Template
+	csv       bool
+}
+
+func (i insightsRequest) templateIDs() []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(i.templates))
+	for _, template := range i.templates {
+		ids = append(ids, template.ID)
+	}
+	return ids
+}
+
+func (i insightsRequest) templateNames() map[uuid.UUID]string {
+	names := make(map[uuid.UUID]string, len(i.templates))
+	for _, template := range i.templates {
+		names[template.ID] = template.Name
+	}
+	return names
+}
+
+// parseInsightsRequest parses the filter of an insights report. Insights
+// reveal the usage of each user, so they're limited to template admins.
+func (api *API) parseInsightsRequest(rw http.ResponseWriter, r *http.Request) (insightsRequest, bool) {
+	ctx := r.Context()
+	vals := r.URL.Query()
+	parser := httpapi.NewQueryParamParser()
+	parseTime := func(v string) (time.Time, error) {
+		return time.Parse(time.RFC3339, v)
+	}
+	now := database.Now()
+	endTime := httpapi.ParseCustom(parser, vals, now, "end_time", parseTime)
+	startTime := httpapi.ParseCustom(parser, vals, endTime.Add(-7*24*time.Hour), "start_time", parseTime)
+	templateIDs := parser.UUIDs(vals, []uuid.UUID{}, "template_ids")
+	format := parser.String(vals, "json", "format")
+	if format != "json" && format != "csv" {
+		parser.Errors = append(parser.Errors, codersdk.ValidationError{
+			Field:  "format",
+			Detail: fmt.Sprintf("Query param %q must be %q or %q", "format", "json", "csv"),
+		})
+	}
+	if len(parser.Errors) > 0 {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message:     "Invalid query parameters.",
+			Validations: parser.Errors,
+		})
+		return insightsRequest{}, false
+	}
+	if !endTime.After(startTime) {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "End time must be after start time.",
+		})
+		return insightsRequest{}, false
+	}
+
+	templates, err := api.Database.GetTemplatesWithFilter(ctx, database.GetTemplatesWithFilterParams{
+		IDs: templateIDs,
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching templates.",
+			Detail:  err.Error(),
+		})
+		return insightsRequest{}, false
+	}
+	if len(templateIDs) > 0 {
+		if len(templates) != len(templateIDs) {
+			httpapi.ResourceNotFound(rw)
+			return insightsRequest{}, false
+		}
+		for _, template := range templates {
+			if !api.Authorize(r, rbac.ActionUpdate, template) {
+				httpapi.ResourceNotFound(rw)
+				return insightsRequest{}, false
+			}
+		}
+	} else {
+		templates, err = AuthorizeFilter(api.HTTPAuth, r, rbac.ActionUpdate, templates)
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+				Message: "Internal error fetching templates.",
+				Detail:  err.Error(),
+			})
+			return insightsRequest{}, false
+		}
+	}
+
+	return insightsRequest{
+		startTime: startTime,
+		endTime:   endTime,
+		templates: templates,
+		csv:       format == "csv",
+	}, true
+}
+
+func (api *API) insightsTemplates(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	req, ok := api.parseInsightsRequest(rw, r)
+	if !ok {
+		return
+	}
+
+	rows, err := api.Database.GetTemplateInsights(ctx, database.GetTemplateInsightsParams{
+		StartTime:   req.startTime,
+		EndTime:     req.endTime,
+		TemplateIds: req.templateIDs(),
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching template insights.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	insightsByTemplate := make(map[uuid.UUID]database.GetTemplateInsightsRow, len(rows))
+	for _, row := range rows {
+		insightsByTemplate[row.TemplateID] = row
+	}
+
+	resp := codersdk.TemplateInsightsResponse{
+		StartTime: req.startTime,
+		EndTime:   req.endTime,
+		Templates: make([]codersdk.TemplateInsight, 0, len(req.templates)),
+	}
+	// Templates without usage are reported with zeros.
+	for _, template := range req.templates {
+		row := insightsByTemplate[template.ID]
+		resp.Templates = append(resp.Templates, codersdk.TemplateInsight{
+			TemplateID:                     template.ID,
+			TemplateName:                   template.Name,
+			ActiveUsers:                    row.ActiveUsers,
+			ActiveHours:                    row.ActiveHours,
+			ConnectionCount:                row.ConnectionCount,
+			SSHConnectionCount:             row.SshConnectionCount,
+			ReconnectingPTYConnectionCount: row.ReconnectingPtyConnectionCount,
+			VSCodeConnectionCount:          row.VscodeConnectionCount,
+			JetBrainsConnectionCount:       row.JetbrainsConnectionCount,
+			Cost:                           row.Cost,
+		})
+	}
+
+	if req.csv {
+		records := make([][]string, 0, len(resp.Templates))
+		for _, insight := range resp.Templates {
+			records = append(records, []string{
+				insight.TemplateID.String(),
+				insight.TemplateName,
+				formatInt(insight.ActiveUsers),
+				formatInt(insight.ActiveHours),
+				formatInt(insight.ConnectionCount),
+				formatInt(insight.SSHConnectionCount),
+				formatInt(insight.ReconnectingPTYConnectionCount),
+				formatInt(insight.VSCodeConnectionCount),
+				formatInt(insight.JetBrainsConnectionCount),
+				formatCost(insight.Cost),
+			})
+		}
+		api.writeInsightsCSV(ctx, rw, codersdk.InsightsReportTemplates, []string{
+			"template_id", "template_name", "active_users", "active_hours",
+			"connection_count", "ssh_connection_count", "reconnecting_pty_connection_count",
+			"vscode_connection_count", "jetbrains_connection_count", "cost",
+		}, records)
+		return
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, resp)
+}
+
+func (api *API) insightsUsers(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	req, ok := api.parseInsightsRequest(rw, r)
+	if !ok {
+		return
+	}
+
+	rows, err := api.Database.GetUserInsights(ctx, database.GetUserInsightsParams{
+		StartTime:   req.startTime,
+		EndTime:     req.endTime,
+		TemplateIds: req.templateIDs(),
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching user insights.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	userIDs := make([]uuid.UUID, 0, len(rows))
+	for _, row := range rows {
+		userIDs = append(userIDs, row.UserID)
+	}
+	users, err := api.Database.GetUsersByIDs(ctx, userIDs)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching users.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	usernames := make(map[uuid.UUID]string, len(users))
+	for _, user := range users {
+		usernames[user.ID] = user.Username
+	}
+
+	templateNames := req.templateNames()
+	resp := codersdk.UserInsightsResponse{
+		StartTime: req.startTime,
+		EndTime:   req.endTime,
+		Users:     make([]codersdk.UserInsight, 0, len(rows)),
+	}
+	for _, row := range rows {
+		resp.Users = append(resp.Users, codersdk.UserInsight{
+			TemplateID:                     row.TemplateID,
+			TemplateName:                   templateNames[row.TemplateID],
+			UserID:                         row.UserID,
+			Username:                       usernames[row.UserID],
+			ActiveHours:                    row.ActiveHours,
+			ConnectionCount:                row.ConnectionCount,
+			SSHConnectionCount:             row.SshConnectionCount,
+			ReconnectingPTYConnectionCount: row.ReconnectingPtyConnectionCount,
+			VSCodeConnectionCount:          row.VscodeConnectionCount,
+			JetBrainsConnectionCount:       row.JetbrainsConnectionCount,
+			Cost:                           row.Cost,
+		})
+	}
+
+	if req.csv {
+		records := make([][]string, 0, len(resp.Users))
+		for _, insight := range resp.Users {
+			records = append(records, []string{
+				insight.TemplateID.String(),
+				insight.TemplateName,
+				insight.UserID.String(),
+				insight.Username,
+				formatInt(insight.ActiveHours),
+				formatInt(insight.ConnectionCount),
+				formatInt(insight.SSHConnectionCount),
+				formatInt(insight.ReconnectingPTYConnectionCount),
+				formatInt(insight.VSCodeConnectionCount),
+				formatInt(insight.JetBrainsConnectionCount),
+				formatCost(insight.Cost),
+			})
+		}
+		api.writeInsightsCSV(ctx, rw, codersdk.InsightsReportUsers, []string{
+			"template_id", "template_name", "user_id", "username", "active_hours",
+			"connection_count", "ssh_connection_count", "reconnecting_pty_connection_count",
+			"vscode_connection_count", "jetbrains_connection_count", "cost",
+		}, records)
+		return
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, resp)
+}
+
+func (api *API) insightsApps(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	req, ok := api.parseInsightsRequest(rw, r)
+	if !ok {
+		return
+	}
+
+	rows, err := api.Database.GetAppInsights(ctx, database.GetAppInsightsParams{
+		StartTime:   req.startTime,
+		EndTime:     req.endTime,
+		TemplateIds: req.templateIDs(),
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching app insights.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	templateNames := req.templateNames()
+	resp := codersdk.AppInsightsResponse{
+		StartTime: req.startTime,
+		EndTime:   req.endTime,
+		Apps:      make([]codersdk.AppInsight, 0, len(rows)),
+	}
+	for _, row := range rows {
+		resp.Apps = append(resp.Apps, codersdk.AppInsight{
+			TemplateID:   row.TemplateID,
+			TemplateName: templateNames[row.TemplateID],
+			Slug:         row.Slug,
+			Users:        row.Users,
+			UsageHours:   row.UsageHours,
+			Requests:     row.Requests,
+		})
+	}
+
+	if req.csv {
+		records := make([][]string, 0, len(resp.Apps))
+		for _, insight := range resp.Apps {
+			records = append(records, []string{
+				insight.TemplateID.String(),
+				insight.TemplateName,
+				insight.Slug,
+				formatInt(insight.Users),
+				formatInt(insight.UsageHours),
+				formatInt(insight.Requests),
+			})
+		}
+		api.writeInsightsCSV(ctx, rw, codersdk.InsightsReportApps, []string{
+			"template_id", "template_name", "slug", "users", "usage_hours", "requests",
+		}, records)
+		return
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, resp)
+}
+
+// writeInsightsCSV writes an insights report as a CSV attachment.
+func (api *API) writeInsightsCSV(ctx context.Context, rw http.ResponseWriter, report codersdk.InsightsReport, header []string, records [][]string) {
+	rw.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	rw.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fmt.Sprintf("insights-%s.csv", report)))
+	rw.WriteHeader(http.StatusOK)
+
+	w := csv.NewWriter(rw)
+	_ = w.Write(header)
+	_ = w.WriteAll(records)
+	if err := w.Error(); err != nil {
+		// The status has already been written, so the error can only be
+		// logged.
+		api.Logger.Debug(ctx, "write insights csv", slog.Error(err))
+	}
+}
+
+func formatInt(v int64) string {
+	return strconv.FormatInt(v, 10)
+}
+
+func formatCost(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
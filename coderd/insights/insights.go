@@ -0,0 +1,193 @@
+package insights
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/retry"
+)
+
+// BackfillWindow is how far back usage is rolled up when the rollup starts.
+// Agent stats are deleted after 30 days, so older hours are left untouched.
+const BackfillWindow = 29 * 24 * time.Hour
+
+// Rollup aggregates agent stats and workspace builds into hourly usage per
+// template and user. Raw agent stats are only kept for a month, and
+// aggregating them on each request would be too slow for reports over long
+// ranges. Requests to workspace apps are counted in memory by the app proxy,
+// and written with each refresh.
+type Rollup struct {
+	database database.Store
+	log      slog.Logger
+
+	// backfilled is only accessed by the run goroutine.
+	backfilled bool
+
+	appUsageMu sync.Mutex
+	appUsage   map[appUsageKey]int64
+
+	done   chan struct{}
+	cancel func()
+
+	interval time.Duration
+}
+
+func New(db database.Store, log slog.Logger, interval time.Duration) *Rollup {
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	r := &Rollup{
+		database: db,
+		log:      log,
+		appUsage: map[appUsageKey]int64{},
+		done:     make(chan struct{}),
+		cancel:   cancel,
+		interval: interval,
+	}
+	go r.run(ctx)
+	return r
+}
+
+func (r *Rollup) run(ctx context.Context) {
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		for rt := retry.New(time.Millisecond*100, time.Minute); rt.Wait(ctx); {
+			start := time.Now()
+			err := r.refresh(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				r.log.Error(ctx, "refresh", slog.Error(err))
+				continue
+			}
+			r.log.Debug(
+				ctx,
+				"insights refreshed",
+				slog.F("took", time.Since(start)),
+				slog.F("interval", r.interval),
+			)
+			break
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (r *Rollup) refresh(ctx context.Context) error {
+	err := r.flushAppUsage(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := database.Now()
+	end := now.Truncate(time.Hour).Add(time.Hour)
+	// The previous hour is rolled up again, since it may have ended after
+	// the last refresh.
+	start := end.Add(-2 * time.Hour)
+	if !r.backfilled {
+		// Catch up from the latest hour rolled up before, by any replica.
+		latest, err := r.database.GetLatestTemplateUsageStatsStartTime(ctx)
+		if err != nil {
+			return xerrors.Errorf("get latest template usage stats: %w", err)
+		}
+		if latest.Before(start) {
+			start = latest.Truncate(time.Hour)
+			if start.Before(end.Add(-BackfillWindow)) {
+				start = end.Add(-BackfillWindow)
+			}
+		}
+	}
+	err = r.database.RollupTemplateUsageStats(ctx, database.RollupTemplateUsageStatsParams{
+		StartTime: start,
+		EndTime:   end,
+		Now:       now,
+	})
+	if err != nil {
+		return xerrors.Errorf("rollup template usage stats: %w", err)
+	}
+	r.backfilled = true
+	return nil
+}
+
+// appUsageKey is the hour and app that requests are counted in.
+type appUsageKey struct {
+	startTime  time.Time
+	templateID uuid.UUID
+	userID     uuid.UUID
+	slug       string
+}
+
+// TrackAppRequest counts a request to a workspace app. Requests are written
+// with the next refresh, so proxying doesn't wait on the database.
+func (r *Rollup) TrackAppRequest(templateID, userID uuid.UUID, slug string) {
+	key := appUsageKey{
+		startTime:  database.Now().Truncate(time.Hour),
+		templateID: templateID,
+		userID:     userID,
+		slug:       slug,
+	}
+	r.appUsageMu.Lock()
+	defer r.appUsageMu.Unlock()
+	r.appUsage[key]++
+}
+
+// flushAppUsage writes the app requests counted since the last flush. Requests
+// that couldn't be written are kept for the next one.
+func (r *Rollup) flushAppUsage(ctx context.Context) error {
+	r.appUsageMu.Lock()
+	usage := r.appUsage
+	r.appUsage = map[appUsageKey]int64{}
+	r.appUsageMu.Unlock()
+
+	for key, requests := range usage {
+		err := r.database.InsertTemplateAppUsageStats(ctx, database.InsertTemplateAppUsageStatsParams{
+			StartTime:  key.startTime,
+			TemplateID: key.templateID,
+			UserID:     key.userID,
+			Slug:       key.slug,
+			Requests:   requests,
+		})
+		if err != nil {
+			r.appUsageMu.Lock()
+			for k, n := range usage {
+				r.appUsage[k] += n
+			}
+			r.appUsageMu.Unlock()
+			return xerrors.Errorf("insert template app usage stats: %w", err)
+		}
+		delete(usage, key)
+	}
+	return nil
+}
+
+func (r *Rollup) Close() error {
+	r.cancel()
+	<-r.done
+
+	// Write the app requests counted since the last refresh, so they aren't
+	// lost on shutdown.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err := r.flushAppUsage(ctx)
+	if err != nil {
+		r.log.Warn(ctx, "flush app usage", slog.Error(err))
+	}
+	return nil
+}
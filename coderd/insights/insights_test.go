@@ -0,0 +1,167 @@
+package insights_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"cdr.dev/slog/sloggers/slogtest"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/database/databasefake"
+	"github.com/coder/coder/coderd/insights"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/testutil"
+)
+
+func TestRollup(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	var (
+		db         = databasefake.New()
+		templateID = uuid.New()
+		userID     = uuid.New()
+		agentID    = uuid.New()
+		// The previous hour has ended, so it's fully rolled up.
+		hour = database.Now().Truncate(time.Hour).Add(-time.Hour)
+	)
+
+	workspace, err := db.InsertWorkspace(ctx, database.InsertWorkspaceParams{
+		ID:         uuid.New(),
+		OwnerID:    userID,
+		TemplateID: templateID,
+		Name:       "test",
+	})
+	require.NoError(t, err)
+
+	// The workspace costs 24 a day until it's stopped half way through the
+	// hour.
+	for i, build := range []struct {
+		createdAt time.Time
+		dailyCost int32
+	}{
+		{createdAt: hour.Add(-48 * time.Hour), dailyCost: 24},
+		{createdAt: hour.Add(30 * time.Minute), dailyCost: 0},
+	} {
+		inserted, err := db.InsertWorkspaceBuild(ctx, database.InsertWorkspaceBuildParams{
+			ID:          uuid.New(),
+			CreatedAt:   build.createdAt,
+			WorkspaceID: workspace.ID,
+			BuildNumber: int32(i + 1),
+		})
+		require.NoError(t, err)
+		_, err = db.UpdateWorkspaceBuildCostByID(ctx, database.UpdateWorkspaceBuildCostByIDParams{
+			ID:        inserted.ID,
+			DailyCost: build.dailyCost,
+		})
+		require.NoError(t, err)
+	}
+
+	for _, stat := range []struct {
+		createdAt time.Time
+		payload   codersdk.AgentStatsReportResponse
+	}{
+		{
+			createdAt: hour.Add(10 * time.Minute),
+			payload:   codersdk.AgentStatsReportResponse{NumConns: 2, ConnectionCountSSH: 2},
+		},
+		{
+			createdAt: hour.Add(20 * time.Minute),
+			payload:   codersdk.AgentStatsReportResponse{NumConns: 3, ConnectionCountSSH: 2, ConnectionCountVSCode: 1},
+		},
+		// The agent restarted, so its counters were reset.
+		{
+			createdAt: hour.Add(30 * time.Minute),
+			payload:   codersdk.AgentStatsReportResponse{NumConns: 1, ConnectionCountReconnectingPTY: 1},
+		},
+	} {
+		payload, err := json.Marshal(stat.payload)
+		require.NoError(t, err)
+		_, err = db.InsertAgentStat(ctx, database.InsertAgentStatParams{
+			ID:                 uuid.New(),
+			CreatedAt:          stat.createdAt,
+			UserID:             userID,
+			WorkspaceID:        workspace.ID,
+			TemplateID:         templateID,
+			AgentID:            agentID,
+			Payload:            payload,
+			ConnectionActivity: true,
+		})
+		require.NoError(t, err)
+	}
+
+	rollup := insights.New(db, slogtest.Make(t, nil), testutil.IntervalFast)
+	defer rollup.Close()
+
+	var templateInsights []database.GetTemplateInsightsRow
+	require.Eventually(t, func() bool {
+		templateInsights, err = db.GetTemplateInsights(ctx, database.GetTemplateInsightsParams{
+			StartTime:   hour,
+			EndTime:     hour.Add(time.Hour),
+			TemplateIds: []uuid.UUID{templateID},
+		})
+		return err == nil && len(templateInsights) == 1
+	}, testutil.WaitShort, testutil.IntervalFast)
+	insight := templateInsights[0]
+	assert.EqualValues(t, 1, insight.ActiveUsers)
+	assert.EqualValues(t, 1, insight.ActiveHours)
+	assert.EqualValues(t, 4, insight.ConnectionCount)
+	assert.EqualValues(t, 2, insight.SshConnectionCount)
+	assert.EqualValues(t, 1, insight.ReconnectingPtyConnectionCount)
+	assert.EqualValues(t, 1, insight.VscodeConnectionCount)
+	assert.EqualValues(t, 0, insight.JetbrainsConnectionCount)
+	assert.InDelta(t, 0.5, insight.Cost, 0.001)
+
+	// The cost of the previous days was backfilled.
+	templateInsights, err = db.GetTemplateInsights(ctx, database.GetTemplateInsightsParams{
+		StartTime:   hour.Add(-48 * time.Hour),
+		EndTime:     hour.Add(time.Hour),
+		TemplateIds: []uuid.UUID{templateID},
+	})
+	require.NoError(t, err)
+	require.Len(t, templateInsights, 1)
+	assert.InDelta(t, 48.5, templateInsights[0].Cost, 0.001)
+}
+
+func TestRollupAppUsage(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	var (
+		db         = databasefake.New()
+		templateID = uuid.New()
+		userID     = uuid.New()
+		hour       = database.Now().Truncate(time.Hour)
+	)
+
+	// Requests are counted in memory, and written in one row per hour and app.
+	rollup := insights.New(db, slogtest.Make(t, nil), time.Hour)
+	for i := 0; i < 5; i++ {
+		rollup.TrackAppRequest(templateID, userID, "code-server")
+	}
+	rollup.TrackAppRequest(templateID, userID, "jupyter")
+
+	// Closing writes the requests counted since the last refresh.
+	require.NoError(t, rollup.Close())
+
+	apps, err := db.GetAppInsights(ctx, database.GetAppInsightsParams{
+		StartTime:   hour.Add(-time.Hour),
+		EndTime:     hour.Add(time.Hour),
+		TemplateIds: []uuid.UUID{templateID},
+	})
+	require.NoError(t, err)
+	require.Len(t, apps, 2)
+	assert.Equal(t, "code-server", apps[0].Slug)
+	assert.EqualValues(t, 5, apps[0].Requests)
+	assert.Equal(t, "jupyter", apps[1].Slug)
+	assert.EqualValues(t, 1, apps[1].Requests)
+}
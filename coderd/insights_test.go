@@ -0,0 +1,156 @@
+package coderd_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/testutil"
+)
+
+func TestInsights(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := testutil.Context(t)
+	defer cancel()
+
+	client, _, api := coderdtest.NewWithAPI(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+	user := coderdtest.CreateFirstUser(t, client)
+	version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+	coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+	template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+	me, err := client.User(ctx, codersdk.Me)
+	require.NoError(t, err)
+
+	hour := database.Now().Truncate(time.Hour).Add(-time.Hour)
+	for i := 0; i < 2; i++ {
+		err := api.Database.UpsertTemplateUsageStats(ctx, database.UpsertTemplateUsageStatsParams{
+			StartTime:          hour.Add(-time.Duration(i) * time.Hour),
+			TemplateID:         template.ID,
+			UserID:             user.UserID,
+			Active:             true,
+			ConnectionCount:    2,
+			SshConnectionCount: 2,
+			Cost:               1,
+		})
+		require.NoError(t, err)
+	}
+	err = api.Database.InsertTemplateAppUsageStats(ctx, database.InsertTemplateAppUsageStatsParams{
+		StartTime:  hour,
+		TemplateID: template.ID,
+		UserID:     user.UserID,
+		Slug:       "code-server",
+		Requests:   5,
+	})
+	require.NoError(t, err)
+
+	t.Run("Templates", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := testutil.Context(t)
+		defer cancel()
+
+		insights, err := client.TemplateInsights(ctx, codersdk.InsightsRequest{})
+		require.NoError(t, err)
+		require.Len(t, insights.Templates, 1)
+		insight := insights.Templates[0]
+		assert.Equal(t, template.ID, insight.TemplateID)
+		assert.Equal(t, template.Name, insight.TemplateName)
+		assert.EqualValues(t, 1, insight.ActiveUsers)
+		assert.EqualValues(t, 2, insight.ActiveHours)
+		assert.EqualValues(t, 4, insight.ConnectionCount)
+		assert.EqualValues(t, 4, insight.SSHConnectionCount)
+		assert.InDelta(t, 2, insight.Cost, 0.001)
+
+		// Only the hours starting in the range are counted.
+		insights, err = client.TemplateInsights(ctx, codersdk.InsightsRequest{
+			StartTime:   hour,
+			EndTime:     hour.Add(time.Hour),
+			TemplateIDs: []uuid.UUID{template.ID},
+		})
+		require.NoError(t, err)
+		require.Len(t, insights.Templates, 1)
+		assert.EqualValues(t, 1, insights.Templates[0].ActiveHours)
+	})
+
+	t.Run("Users", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := testutil.Context(t)
+		defer cancel()
+
+		insights, err := client.UserInsights(ctx, codersdk.InsightsRequest{})
+		require.NoError(t, err)
+		require.Len(t, insights.Users, 1)
+		assert.Equal(t, user.UserID, insights.Users[0].UserID)
+		assert.Equal(t, me.Username, insights.Users[0].Username)
+		assert.Equal(t, template.Name, insights.Users[0].TemplateName)
+		assert.EqualValues(t, 2, insights.Users[0].ActiveHours)
+	})
+
+	t.Run("Apps", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := testutil.Context(t)
+		defer cancel()
+
+		insights, err := client.AppInsights(ctx, codersdk.InsightsRequest{})
+		require.NoError(t, err)
+		require.Len(t, insights.Apps, 1)
+		assert.Equal(t, "code-server", insights.Apps[0].Slug)
+		assert.EqualValues(t, 1, insights.Apps[0].Users)
+		assert.EqualValues(t, 1, insights.Apps[0].UsageHours)
+		assert.EqualValues(t, 5, insights.Apps[0].Requests)
+	})
+
+	t.Run("CSV", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := testutil.Context(t)
+		defer cancel()
+
+		data, err := client.InsightsCSV(ctx, codersdk.InsightsReportApps, codersdk.InsightsRequest{})
+		require.NoError(t, err)
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		require.Len(t, lines, 2)
+		assert.Equal(t, "template_id,template_name,slug,users,usage_hours,requests", lines[0])
+		assert.Equal(t, strings.Join([]string{template.ID.String(), template.Name, "code-server", "1", "1", "5"}, ","), lines[1])
+	})
+
+	t.Run("InvalidRange", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := testutil.Context(t)
+		defer cancel()
+
+		_, err := client.TemplateInsights(ctx, codersdk.InsightsRequest{
+			StartTime: hour,
+			EndTime:   hour.Add(-time.Hour),
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+	})
+
+	t.Run("Member", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := testutil.Context(t)
+		defer cancel()
+
+		// Members can't update templates, so they don't see any insights.
+		member := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+		insights, err := member.TemplateInsights(ctx, codersdk.InsightsRequest{})
+		require.NoError(t, err)
+		require.Empty(t, insights.Templates)
+
+		_, err = member.TemplateInsights(ctx, codersdk.InsightsRequest{
+			TemplateIDs: []uuid.UUID{template.ID},
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+	})
+}
@@ -2,6 +2,9 @@ package coderd
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/google/uuid"
@@ -10,6 +13,7 @@ import (
 
 	"github.com/coder/coder/coderd/rbac"
 
+	"github.com/coder/coder/coderd/audit"
 	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/coderd/httpapi"
 	"github.com/coder/coder/coderd/httpmw"
@@ -74,7 +78,203 @@ func (api *API) putMemberRoles(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	httpapi.Write(ctx, rw, http.StatusOK, convertOrganizationMember(updatedUser))
+	httpapi.Write(ctx, rw, http.StatusOK, convertOrganizationMember(updatedUser, api.CustomRoles))
+}
+
+func (api *API) organizationMembers(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx          = r.Context()
+		organization = httpmw.OrganizationParam(r)
+	)
+
+	members, err := api.Database.GetOrganizationMembers(ctx, organization.ID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching organization members.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	members, err = AuthorizeFilter(api.HTTPAuth, r, rbac.ActionRead, members)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error authorizing organization members.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	userIDs := make([]uuid.UUID, 0, len(members))
+	for _, member := range members {
+		userIDs = append(userIDs, member.UserID)
+	}
+	users, err := api.Database.GetUsersByIDs(ctx, userIDs)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching users.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	usersByID := make(map[uuid.UUID]database.User, len(users))
+	for _, user := range users {
+		usersByID[user.ID] = user
+	}
+
+	apiMembers := make([]codersdk.OrganizationMemberWithUser, 0, len(members))
+	for _, member := range members {
+		user, ok := usersByID[member.UserID]
+		if !ok || user.Deleted {
+			continue
+		}
+		apiMembers = append(apiMembers, codersdk.OrganizationMemberWithUser{
+			OrganizationMember: convertOrganizationMember(member, api.CustomRoles),
+			Username:           user.Username,
+			Email:              user.Email,
+		})
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, apiMembers)
+}
+
+func (api *API) postOrganizationMember(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx               = r.Context()
+		organization      = httpmw.OrganizationParam(r)
+		user              = httpmw.UserParam(r)
+		auditor           = *api.Auditor.Load()
+		aReq, commitAudit = audit.InitRequest[database.OrganizationMember](rw, &audit.RequestParams{
+			Audit:   auditor,
+			Log:     api.Logger,
+			Request: r,
+			Action:  database.AuditActionCreate,
+		})
+	)
+	defer commitAudit()
+
+	if !api.Authorize(r, rbac.ActionCreate, rbac.ResourceOrganizationMember.InOrg(organization.ID)) {
+		httpapi.Forbidden(rw)
+		return
+	}
+
+	_, err := api.Database.GetOrganizationMemberByUserID(ctx, database.GetOrganizationMemberByUserIDParams{
+		OrganizationID: organization.ID,
+		UserID:         user.ID,
+	})
+	if err == nil {
+		httpapi.Write(ctx, rw, http.StatusConflict, codersdk.Response{
+			Message: fmt.Sprintf("User %q is already a member of the organization.", user.Username),
+		})
+		return
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching organization member.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	member, err := api.Database.InsertOrganizationMember(ctx, database.InsertOrganizationMemberParams{
+		OrganizationID: organization.ID,
+		UserID:         user.ID,
+		CreatedAt:      database.Now(),
+		UpdatedAt:      database.Now(),
+		// The org-member role is always implied.
+		Roles: []string{},
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error adding organization member.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	aReq.New = member
+
+	httpapi.Write(ctx, rw, http.StatusCreated, convertOrganizationMember(member, api.CustomRoles))
+}
+
+func (api *API) deleteOrganizationMember(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx               = r.Context()
+		organization      = httpmw.OrganizationParam(r)
+		member            = httpmw.OrganizationMemberParam(r)
+		auditor           = *api.Auditor.Load()
+		aReq, commitAudit = audit.InitRequest[database.OrganizationMember](rw, &audit.RequestParams{
+			Audit:   auditor,
+			Log:     api.Logger,
+			Request: r,
+			Action:  database.AuditActionDelete,
+		})
+	)
+	defer commitAudit()
+	aReq.Old = member
+
+	if !api.Authorize(r, rbac.ActionDelete, member) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	// Workspaces belong to an organization, so they would be orphaned if
+	// their owner left it.
+	workspaces, err := api.Database.GetWorkspaces(ctx, database.GetWorkspacesParams{
+		OwnerID: member.UserID,
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspaces.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	for _, workspace := range workspaces {
+		if workspace.OrganizationID == organization.ID {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: "You cannot remove a member that has workspaces in the organization. Delete their workspaces and try again!",
+			})
+			return
+		}
+	}
+
+	err = api.Database.InTx(func(tx database.Store) error {
+		// Group memberships grant access to templates and quota in the
+		// organization, so they're removed along with the membership.
+		groups, err := tx.GetUserGroups(ctx, member.UserID)
+		if err != nil {
+			return xerrors.Errorf("get user groups: %w", err)
+		}
+		for _, group := range groups {
+			if group.OrganizationID != organization.ID {
+				continue
+			}
+			err = tx.DeleteGroupMemberFromGroup(ctx, database.DeleteGroupMemberFromGroupParams{
+				UserID:  member.UserID,
+				GroupID: group.ID,
+			})
+			if err != nil {
+				return xerrors.Errorf("delete group member: %w", err)
+			}
+		}
+
+		err = tx.DeleteOrganizationMember(ctx, database.DeleteOrganizationMemberParams{
+			OrganizationID: organization.ID,
+			UserID:         member.UserID,
+		})
+		if err != nil {
+			return xerrors.Errorf("delete organization member: %w", err)
+		}
+		return nil
+	}, nil)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error removing organization member.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
 }
 
 func (api *API) updateOrganizationMemberRoles(ctx context.Context, args database.UpdateMemberRolesParams) (database.OrganizationMember, error) {
@@ -95,7 +295,7 @@ func (api *API) updateOrganizationMemberRoles(ctx context.Context, args database
 			return database.OrganizationMember{}, xerrors.Errorf("Must only pass roles for org %q", args.OrgID.String())
 		}
 
-		if _, err := rbac.RoleByName(r); err != nil {
+		if _, err := api.CustomRoles.RoleByName(r); err != nil {
 			return database.OrganizationMember{}, xerrors.Errorf("%q is not a supported role", r)
 		}
 	}
@@ -107,7 +307,7 @@ func (api *API) updateOrganizationMemberRoles(ctx context.Context, args database
 	return updatedUser, nil
 }
 
-func convertOrganizationMember(mem database.OrganizationMember) codersdk.OrganizationMember {
+func convertOrganizationMember(mem database.OrganizationMember, customRoles *rbac.CustomRoles) codersdk.OrganizationMember {
 	convertedMember := codersdk.OrganizationMember{
 		UserID:         mem.UserID,
 		OrganizationID: mem.OrganizationID,
@@ -117,8 +317,7 @@ func convertOrganizationMember(mem database.OrganizationMember) codersdk.Organiz
 	}
 
 	for _, roleName := range mem.Roles {
-		rbacRole, _ := rbac.RoleByName(roleName)
-		convertedMember.Roles = append(convertedMember.Roles, convertRole(rbacRole))
+		convertedMember.Roles = append(convertedMember.Roles, convertRoleName(customRoles, roleName))
 	}
 	return convertedMember
 }
@@ -179,6 +179,7 @@ func TestCache_TemplateUsers(t *testing.T) {
 
 			for _, row := range tt.args.rows {
 				row.TemplateID = templateID
+				row.ConnectionActivity = true
 				db.InsertAgentStat(context.Background(), row)
 			}
 
@@ -0,0 +1,465 @@
+// Package notifications delivers organization events to webhooks.
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/codersdk"
+)
+
+const (
+	// EventChannel is the pubsub channel events are published on. Every
+	// replica runs a Notifier subscribed to it, and the first to record a
+	// delivery for a webhook sends it.
+	//
+	// Events aren't derived from the existing workspace:<id> and job log
+	// channels. Those are per workspace and per job, so there's no single
+	// channel a notifier could subscribe to, and their messages don't say
+	// what changed or whether a job failed. Instead provisionerd publishes
+	// the outcome of a build here when it completes or fails the job.
+	EventChannel = "notification_events"
+
+	// SignatureHeader contains "sha256=" followed by the hex encoded
+	// HMAC-SHA256 of the request body, keyed with the webhook secret.
+	SignatureHeader = "X-Coder-Signature"
+	// EventHeader contains the type of the event being delivered.
+	EventHeader = "X-Coder-Event"
+	// DeliveryHeader contains the ID of the delivery. It is the same for
+	// every attempt.
+	DeliveryHeader = "X-Coder-Delivery"
+)
+
+// autostopNamespace is used to derive a stable event ID for autostop
+// notices, so each replica that notices an upcoming autostop produces the
+// same event.
+var autostopNamespace = uuid.MustParse("5d0c7a57-7c59-4a36-9a3e-62a5d3ac8c7b")
+
+// Publish sends an event to the webhooks of the organization that are
+// subscribed to its type.
+func Publish(pubsub database.Pubsub, organizationID uuid.UUID, eventType codersdk.WebhookEventType, data interface{}) error {
+	event, err := newEvent(uuid.New(), organizationID, eventType, data)
+	if err != nil {
+		return err
+	}
+	message, err := json.Marshal(event)
+	if err != nil {
+		return xerrors.Errorf("marshal event: %w", err)
+	}
+	err = pubsub.Publish(EventChannel, message)
+	if err != nil {
+		return xerrors.Errorf("publish event: %w", err)
+	}
+	return nil
+}
+
+func newEvent(id, organizationID uuid.UUID, eventType codersdk.WebhookEventType, data interface{}) (codersdk.WebhookEvent, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return codersdk.WebhookEvent{}, xerrors.Errorf("marshal event data: %w", err)
+	}
+	return codersdk.WebhookEvent{
+		ID:             id,
+		Type:           eventType,
+		OrganizationID: organizationID,
+		CreatedAt:      database.Now(),
+		Data:           raw,
+	}, nil
+}
+
+// Sign returns the value of the SignatureHeader for the payload.
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+type Options struct {
+	Database database.Store
+	Pubsub   database.Pubsub
+	Logger   slog.Logger
+
+	// HTTPClient sends deliveries. Defaults to a client with a 10 second
+	// timeout.
+	HTTPClient *http.Client
+	// MaxAttempts is the number of times a delivery is sent before it is
+	// marked as failed. Defaults to 5.
+	MaxAttempts int
+	// RetryInterval is the delay before the first retry. It doubles after
+	// every failed attempt. Defaults to 10 seconds.
+	RetryInterval time.Duration
+	// AutostopNotice is how long before a workspace is stopped by its TTL
+	// that the workspace.autostop event is sent. Defaults to 30 minutes.
+	AutostopNotice time.Duration
+	// AutostopCheckInterval is how often workspaces are checked for an
+	// upcoming autostop. Only one replica checks per interval. Defaults to
+	// 1 minute.
+	AutostopCheckInterval time.Duration
+	// ResumeInterval is how often pending deliveries abandoned by a stopped
+	// replica are resumed. Defaults to 1 minute.
+	ResumeInterval time.Duration
+}
+
+// Notifier delivers published events to webhooks.
+type Notifier struct {
+	options Options
+	logger  slog.Logger
+	// staleAfter is how long a pending delivery may go without an attempt
+	// before it's considered abandoned and resumed.
+	staleAfter time.Duration
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu     sync.Mutex
+	closed bool
+	wg     sync.WaitGroup
+
+	unsubscribe func()
+}
+
+// New subscribes to published events, resumes pending deliveries and starts
+// checking for upcoming autostops. Close must be called to stop it.
+func New(options Options) (*Notifier, error) {
+	if options.HTTPClient == nil {
+		options.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	if options.MaxAttempts <= 0 {
+		options.MaxAttempts = 5
+	}
+	if options.RetryInterval <= 0 {
+		options.RetryInterval = 10 * time.Second
+	}
+	if options.AutostopNotice <= 0 {
+		options.AutostopNotice = 30 * time.Minute
+	}
+	if options.AutostopCheckInterval <= 0 {
+		options.AutostopCheckInterval = time.Minute
+	}
+	if options.ResumeInterval <= 0 {
+		options.ResumeInterval = time.Minute
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	n := &Notifier{
+		options: options,
+		logger:  options.Logger,
+		// A replica waits at most the longest retry interval between
+		// attempts, plus the time an attempt takes.
+		staleAfter: options.RetryInterval<<(options.MaxAttempts-1) + options.HTTPClient.Timeout + time.Minute,
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+	unsubscribe, err := options.Pubsub.Subscribe(EventChannel, func(_ context.Context, message []byte) {
+		var event codersdk.WebhookEvent
+		err := json.Unmarshal(message, &event)
+		if err != nil {
+			n.logger.Warn(ctx, "unmarshal notification event", slog.Error(err))
+			return
+		}
+		n.dispatch(event)
+	})
+	if err != nil {
+		cancel()
+		return nil, xerrors.Errorf("subscribe to notification events: %w", err)
+	}
+	n.unsubscribe = unsubscribe
+
+	n.goTracked(n.resumeLoop)
+	n.goTracked(n.checkAutostopLoop)
+	return n, nil
+}
+
+// Close stops the notifier. Deliveries waiting to be retried remain
+// pending, and are resumed by another replica or the next notifier to
+// start.
+func (n *Notifier) Close() error {
+	n.unsubscribe()
+	n.mu.Lock()
+	n.closed = true
+	n.mu.Unlock()
+	n.cancel()
+	n.wg.Wait()
+	return nil
+}
+
+// goTracked runs fn in a goroutine that Close waits for.
+func (n *Notifier) goTracked(fn func()) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.closed {
+		return
+	}
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		fn()
+	}()
+}
+
+// dispatch records a delivery for every subscribed webhook of the event's
+// organization and sends them.
+func (n *Notifier) dispatch(event codersdk.WebhookEvent) {
+	n.goTracked(func() {
+		logger := n.logger.With(slog.F("event_id", event.ID), slog.F("event_type", event.Type))
+		payload, err := json.Marshal(event)
+		if err != nil {
+			logger.Error(n.ctx, "marshal event payload", slog.Error(err))
+			return
+		}
+		webhooks, err := n.options.Database.GetWebhooksByOrganizationID(n.ctx, event.OrganizationID)
+		if err != nil {
+			if n.ctx.Err() == nil {
+				logger.Error(n.ctx, "get webhooks", slog.Error(err))
+			}
+			return
+		}
+		for _, webhook := range webhooks {
+			if !subscribed(webhook, event.Type) {
+				continue
+			}
+			now := database.Now()
+			delivery, err := n.options.Database.InsertWebhookDelivery(n.ctx, database.InsertWebhookDeliveryParams{
+				ID:        uuid.New(),
+				WebhookID: webhook.ID,
+				EventID:   event.ID,
+				EventType: string(event.Type),
+				Payload:   payload,
+				CreatedAt: now,
+				UpdatedAt: now,
+			})
+			if errors.Is(err, sql.ErrNoRows) {
+				// Another replica is delivering this event.
+				continue
+			}
+			if err != nil {
+				logger.Error(n.ctx, "insert webhook delivery", slog.F("webhook_id", webhook.ID), slog.Error(err))
+				continue
+			}
+			webhook := webhook
+			n.goTracked(func() {
+				n.deliver(webhook, delivery)
+			})
+		}
+	})
+}
+
+func subscribed(webhook database.Webhook, eventType codersdk.WebhookEventType) bool {
+	if len(webhook.Events) == 0 {
+		return true
+	}
+	for _, event := range webhook.Events {
+		if event == string(eventType) {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *Notifier) resumeLoop() {
+	ticker := time.NewTicker(n.options.ResumeInterval)
+	defer ticker.Stop()
+	for {
+		err := n.resume(database.Now())
+		if err != nil && n.ctx.Err() == nil {
+			n.logger.Error(n.ctx, "resume pending webhook deliveries", slog.Error(err))
+		}
+		select {
+		case <-n.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// resume continues pending deliveries that no replica has attempted for
+// longer than a replica waits between attempts, for example because the
+// replica retrying them was stopped.
+func (n *Notifier) resume(now time.Time) error {
+	deliveries, err := n.options.Database.AcquireStaleWebhookDeliveries(n.ctx, database.AcquireStaleWebhookDeliveriesParams{
+		Now:           now,
+		UpdatedBefore: now.Add(-n.staleAfter),
+	})
+	if err != nil {
+		return xerrors.Errorf("acquire stale webhook deliveries: %w", err)
+	}
+	for _, delivery := range deliveries {
+		webhook, err := n.options.Database.GetWebhookByID(n.ctx, delivery.WebhookID)
+		if err != nil {
+			n.logger.Error(n.ctx, "get webhook of pending delivery", slog.F("delivery_id", delivery.ID), slog.Error(err))
+			continue
+		}
+		n.logger.Debug(n.ctx, "resuming webhook delivery", slog.F("delivery_id", delivery.ID), slog.F("attempts", delivery.Attempts))
+		delivery := delivery
+		n.goTracked(func() {
+			n.deliver(webhook, delivery)
+		})
+	}
+	return nil
+}
+
+// deliver sends the delivery until it succeeds or runs out of attempts,
+// recording the outcome of every attempt. Resumed deliveries continue from
+// their previous attempt.
+func (n *Notifier) deliver(webhook database.Webhook, delivery database.WebhookDelivery) {
+	logger := n.logger.With(slog.F("webhook_id", webhook.ID), slog.F("delivery_id", delivery.ID))
+	first := int(delivery.Attempts) + 1
+	interval := n.options.RetryInterval << (first - 1)
+	for attempt := first; ; attempt++ {
+		statusCode, err := n.send(webhook, delivery)
+		status := database.WebhookDeliveryStatusPending
+		lastError := ""
+		switch {
+		case err == nil:
+			status = database.WebhookDeliveryStatusDelivered
+		case attempt >= n.options.MaxAttempts:
+			status = database.WebhookDeliveryStatusFailed
+			fallthrough
+		default:
+			lastError = err.Error()
+		}
+		if n.ctx.Err() != nil {
+			return
+		}
+		updateErr := n.options.Database.UpdateWebhookDeliveryByID(n.ctx, database.UpdateWebhookDeliveryByIDParams{
+			ID:             delivery.ID,
+			Status:         status,
+			Attempts:       int32(attempt),
+			LastStatusCode: int32(statusCode),
+			LastError:      lastError,
+			UpdatedAt:      database.Now(),
+		})
+		if updateErr != nil {
+			logger.Error(n.ctx, "update webhook delivery", slog.Error(updateErr))
+		}
+		if status == database.WebhookDeliveryStatusFailed {
+			logger.Warn(n.ctx, "webhook delivery failed", slog.F("attempts", attempt), slog.Error(err))
+		}
+		if status != database.WebhookDeliveryStatusPending {
+			return
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-n.ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		interval *= 2
+	}
+}
+
+// send makes a single delivery attempt. The status code is zero if no
+// response was received.
+func (n *Notifier) send(webhook database.Webhook, delivery database.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(n.ctx, http.MethodPost, webhook.Url, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, xerrors.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Coder-Webhook")
+	req.Header.Set(EventHeader, delivery.EventType)
+	req.Header.Set(DeliveryHeader, delivery.ID.String())
+	req.Header.Set(SignatureHeader, Sign(webhook.Secret, delivery.Payload))
+
+	res, err := n.options.HTTPClient.Do(req)
+	if err != nil {
+		return 0, xerrors.Errorf("send request: %w", err)
+	}
+	defer res.Body.Close()
+	// Drain a bit of the body so the connection can be reused.
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 4096))
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return res.StatusCode, xerrors.Errorf("unexpected status code %d", res.StatusCode)
+	}
+	return res.StatusCode, nil
+}
+
+func (n *Notifier) checkAutostopLoop() {
+	ticker := time.NewTicker(n.options.AutostopCheckInterval)
+	defer ticker.Stop()
+	for {
+		err := n.checkAutostop(database.Now())
+		if err != nil && n.ctx.Err() == nil {
+			n.logger.Error(n.ctx, "check upcoming autostops", slog.Error(err))
+		}
+		select {
+		case <-n.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkAutostop dispatches a workspace.autostop event for every running
+// workspace that will be stopped by its deadline within the notice period.
+// Only one replica checks per interval. The event ID is derived from the
+// build and deadline, so repeated checks don't notify twice.
+func (n *Notifier) checkAutostop(now time.Time) error {
+	return n.options.Database.InTx(func(db database.Store) error {
+		ok, err := db.TryAcquireLock(n.ctx, database.LockIDAutostopNotices)
+		if err != nil {
+			return xerrors.Errorf("acquire lock: %w", err)
+		}
+		if !ok {
+			// Another replica is checking.
+			return nil
+		}
+		lastCheck, err := db.GetLastAutostopNoticeCheck(n.ctx)
+		if err != nil {
+			return xerrors.Errorf("get last check: %w", err)
+		}
+		if last, err := time.Parse(time.RFC3339Nano, lastCheck); err == nil {
+			// Leave some slack so the replica that checked last isn't
+			// skipped because its ticker fired slightly early.
+			if now.Sub(last) < n.options.AutostopCheckInterval*9/10 {
+				return nil
+			}
+		}
+		err = db.UpsertLastAutostopNoticeCheck(n.ctx, now.Format(time.RFC3339Nano))
+		if err != nil {
+			return xerrors.Errorf("update last check: %w", err)
+		}
+
+		autostops, err := db.GetUpcomingWorkspaceAutostops(n.ctx, database.GetUpcomingWorkspaceAutostopsParams{
+			DeadlineAfter:  now,
+			DeadlineBefore: now.Add(n.options.AutostopNotice),
+		})
+		if err != nil {
+			return xerrors.Errorf("get upcoming workspace autostops: %w", err)
+		}
+		for _, autostop := range autostops {
+			id := uuid.NewSHA1(autostopNamespace, []byte(fmt.Sprintf("%s:%d", autostop.BuildID, autostop.Deadline.Unix())))
+			event, err := newEvent(id, autostop.OrganizationID, codersdk.WebhookEventWorkspaceAutostop, codersdk.WebhookWorkspaceAutostopData{
+				WorkspaceID:      autostop.WorkspaceID,
+				WorkspaceName:    autostop.WorkspaceName,
+				WorkspaceOwnerID: autostop.OwnerID,
+				BuildID:          autostop.BuildID,
+				Deadline:         autostop.Deadline,
+			})
+			if err != nil {
+				return err
+			}
+			n.dispatch(event)
+		}
+		return nil
+	}, nil)
+}
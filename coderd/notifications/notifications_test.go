@@ -0,0 +1,338 @@
+package notifications_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+
+	"cdr.dev/slog/sloggers/slogtest"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/database/databasefake"
+	"github.com/coder/coder/coderd/notifications"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/testutil"
+)
+
+func TestMain(m *testing.M) {
+	goleak.VerifyTestMain(m)
+}
+
+func TestNotifier(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Delivered", func(t *testing.T) {
+		t.Parallel()
+		db, pubsub := databasefake.New(), database.NewPubsubInMemory()
+		orgID := uuid.New()
+
+		received := make(chan *http.Request, 1)
+		bodies := make(chan []byte, 1)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			received <- r
+			bodies <- body
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer srv.Close()
+		webhook := insertWebhook(t, db, orgID, srv.URL)
+
+		newNotifier(t, db, pubsub, notifications.Options{})
+		data := codersdk.WebhookTemplateVersionData{
+			TemplateID:   uuid.New(),
+			TemplateName: "example",
+		}
+		err := notifications.Publish(pubsub, orgID, codersdk.WebhookEventTemplateVersionPromoted, data)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitShort)
+		defer cancel()
+		var (
+			req  *http.Request
+			body []byte
+		)
+		select {
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for delivery")
+		case req = <-received:
+			body = <-bodies
+		}
+		require.Equal(t, "application/json", req.Header.Get("Content-Type"))
+		require.Equal(t, string(codersdk.WebhookEventTemplateVersionPromoted), req.Header.Get(notifications.EventHeader))
+		require.Equal(t, notifications.Sign(webhook.Secret, body), req.Header.Get(notifications.SignatureHeader))
+
+		var event codersdk.WebhookEvent
+		require.NoError(t, json.Unmarshal(body, &event))
+		require.Equal(t, orgID, event.OrganizationID)
+		var gotData codersdk.WebhookTemplateVersionData
+		require.NoError(t, json.Unmarshal(event.Data, &gotData))
+		require.Equal(t, data, gotData)
+
+		delivery := waitForDelivery(ctx, t, db, webhook.ID, database.WebhookDeliveryStatusDelivered)
+		require.Equal(t, req.Header.Get(notifications.DeliveryHeader), delivery.ID.String())
+		require.Equal(t, event.ID, delivery.EventID)
+		require.EqualValues(t, 1, delivery.Attempts)
+		require.EqualValues(t, http.StatusNoContent, delivery.LastStatusCode)
+	})
+
+	t.Run("RetryThenFail", func(t *testing.T) {
+		t.Parallel()
+		db, pubsub := databasefake.New(), database.NewPubsubInMemory()
+		orgID := uuid.New()
+
+		var requests atomic.Int64
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer srv.Close()
+		webhook := insertWebhook(t, db, orgID, srv.URL)
+
+		newNotifier(t, db, pubsub, notifications.Options{
+			MaxAttempts:   3,
+			RetryInterval: time.Millisecond,
+		})
+		err := notifications.Publish(pubsub, orgID, codersdk.WebhookEventWorkspaceBuildFailed, codersdk.WebhookWorkspaceBuildData{})
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitShort)
+		defer cancel()
+		delivery := waitForDelivery(ctx, t, db, webhook.ID, database.WebhookDeliveryStatusFailed)
+		require.EqualValues(t, 3, delivery.Attempts)
+		require.EqualValues(t, http.StatusInternalServerError, delivery.LastStatusCode)
+		require.Contains(t, delivery.LastError, "unexpected status code 500")
+		require.EqualValues(t, 3, requests.Load())
+	})
+
+	t.Run("ResumePending", func(t *testing.T) {
+		t.Parallel()
+		db, pubsub := databasefake.New(), database.NewPubsubInMemory()
+		orgID := uuid.New()
+
+		var requests atomic.Int64
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer srv.Close()
+		webhook := insertWebhook(t, db, orgID, srv.URL)
+
+		// A delivery left pending by a replica that stopped while waiting
+		// to retry it.
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitShort)
+		defer cancel()
+		createdAt := database.Now().Add(-time.Hour)
+		delivery, err := db.InsertWebhookDelivery(ctx, database.InsertWebhookDeliveryParams{
+			ID:        uuid.New(),
+			WebhookID: webhook.ID,
+			EventID:   uuid.New(),
+			EventType: string(codersdk.WebhookEventWorkspaceBuildFailed),
+			Payload:   []byte("{}"),
+			CreatedAt: createdAt,
+			UpdatedAt: createdAt,
+		})
+		require.NoError(t, err)
+		err = db.UpdateWebhookDeliveryByID(ctx, database.UpdateWebhookDeliveryByIDParams{
+			ID:        delivery.ID,
+			Status:    database.WebhookDeliveryStatusPending,
+			Attempts:  1,
+			LastError: "unexpected status code 500",
+			UpdatedAt: createdAt,
+		})
+		require.NoError(t, err)
+
+		newNotifier(t, db, pubsub, notifications.Options{})
+		delivery = waitForDelivery(ctx, t, db, webhook.ID, database.WebhookDeliveryStatusDelivered)
+		require.EqualValues(t, 2, delivery.Attempts)
+		require.EqualValues(t, 1, requests.Load())
+	})
+
+	t.Run("Unsubscribed", func(t *testing.T) {
+		t.Parallel()
+		db, pubsub := databasefake.New(), database.NewPubsubInMemory()
+		orgID := uuid.New()
+
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer srv.Close()
+		buildWebhook := insertWebhook(t, db, orgID, srv.URL, codersdk.WebhookEventWorkspaceBuildFailed)
+		templateWebhook := insertWebhook(t, db, orgID, srv.URL, codersdk.WebhookEventTemplateVersionPromoted)
+
+		newNotifier(t, db, pubsub, notifications.Options{})
+		err := notifications.Publish(pubsub, orgID, codersdk.WebhookEventTemplateVersionPromoted, codersdk.WebhookTemplateVersionData{})
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitShort)
+		defer cancel()
+		waitForDelivery(ctx, t, db, templateWebhook.ID, database.WebhookDeliveryStatusDelivered)
+		deliveries, err := db.GetWebhookDeliveriesByWebhookID(ctx, database.GetWebhookDeliveriesByWebhookIDParams{
+			WebhookID: buildWebhook.ID,
+		})
+		require.NoError(t, err)
+		require.Empty(t, deliveries)
+	})
+
+	t.Run("DeliveredOnceAcrossReplicas", func(t *testing.T) {
+		t.Parallel()
+		db, pubsub := databasefake.New(), database.NewPubsubInMemory()
+		orgID := uuid.New()
+
+		var requests atomic.Int64
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer srv.Close()
+		webhook := insertWebhook(t, db, orgID, srv.URL)
+
+		first := newNotifier(t, db, pubsub, notifications.Options{})
+		second := newNotifier(t, db, pubsub, notifications.Options{})
+		err := notifications.Publish(pubsub, orgID, codersdk.WebhookEventWorkspaceBuildSucceeded, codersdk.WebhookWorkspaceBuildData{})
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitShort)
+		defer cancel()
+		waitForDelivery(ctx, t, db, webhook.ID, database.WebhookDeliveryStatusDelivered)
+		// Wait for both replicas to finish handling the event.
+		require.NoError(t, first.Close())
+		require.NoError(t, second.Close())
+		require.EqualValues(t, 1, requests.Load())
+	})
+
+	t.Run("Autostop", func(t *testing.T) {
+		t.Parallel()
+		db, pubsub := databasefake.New(), database.NewPubsubInMemory()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitShort)
+		defer cancel()
+		orgID := uuid.New()
+
+		events := make(chan codersdk.WebhookEvent, 10)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var event codersdk.WebhookEvent
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+			events <- event
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer srv.Close()
+		insertWebhook(t, db, orgID, srv.URL)
+
+		workspace, err := db.InsertWorkspace(ctx, database.InsertWorkspaceParams{
+			ID:             uuid.New(),
+			OwnerID:        uuid.New(),
+			OrganizationID: orgID,
+			TemplateID:     uuid.New(),
+			Name:           "example",
+			Ttl:            sql.NullInt64{Int64: int64(8 * time.Hour), Valid: true},
+		})
+		require.NoError(t, err)
+		build, err := db.InsertWorkspaceBuild(ctx, database.InsertWorkspaceBuildParams{
+			ID:          uuid.New(),
+			WorkspaceID: workspace.ID,
+			BuildNumber: 1,
+			Transition:  database.WorkspaceTransitionStart,
+			Reason:      database.BuildReasonInitiator,
+			Deadline:    database.Now().Add(10 * time.Minute),
+		})
+		require.NoError(t, err)
+
+		newNotifier(t, db, pubsub, notifications.Options{
+			AutostopCheckInterval: time.Millisecond,
+		})
+
+		var event codersdk.WebhookEvent
+		select {
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for autostop event")
+		case event = <-events:
+		}
+		require.Equal(t, codersdk.WebhookEventWorkspaceAutostop, event.Type)
+		var data codersdk.WebhookWorkspaceAutostopData
+		require.NoError(t, json.Unmarshal(event.Data, &data))
+		require.Equal(t, workspace.ID, data.WorkspaceID)
+		require.Equal(t, build.ID, data.BuildID)
+
+		// Later checks must not notify again for the same deadline.
+		time.Sleep(50 * time.Millisecond)
+		require.Len(t, events, 0)
+	})
+
+	t.Run("AutostopCheckedOncePerInterval", func(t *testing.T) {
+		t.Parallel()
+		db := databasefake.New()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitShort)
+		defer cancel()
+
+		// Another replica checked recently, so this one skips the check.
+		checkedAt := database.Now()
+		err := db.UpsertLastAutostopNoticeCheck(ctx, checkedAt.Format(time.RFC3339Nano))
+		require.NoError(t, err)
+		newNotifier(t, db, database.NewPubsubInMemory(), notifications.Options{
+			AutostopCheckInterval: time.Hour,
+		})
+		time.Sleep(50 * time.Millisecond)
+		lastCheck, err := db.GetLastAutostopNoticeCheck(ctx)
+		require.NoError(t, err)
+		require.Equal(t, checkedAt.Format(time.RFC3339Nano), lastCheck)
+	})
+}
+
+func newNotifier(t *testing.T, db database.Store, pubsub database.Pubsub, options notifications.Options) *notifications.Notifier {
+	t.Helper()
+	options.Database = db
+	options.Pubsub = pubsub
+	options.Logger = slogtest.Make(t, nil)
+	notifier, err := notifications.New(options)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = notifier.Close()
+	})
+	return notifier
+}
+
+func insertWebhook(t *testing.T, db database.Store, orgID uuid.UUID, url string, events ...codersdk.WebhookEventType) database.Webhook {
+	t.Helper()
+	eventStrings := make([]string, 0, len(events))
+	for _, event := range events {
+		eventStrings = append(eventStrings, string(event))
+	}
+	webhook, err := db.InsertWebhook(context.Background(), database.InsertWebhookParams{
+		ID:             uuid.New(),
+		OrganizationID: orgID,
+		Name:           uuid.NewString(),
+		Url:            url,
+		Secret:         "secret",
+		Events:         eventStrings,
+		CreatedAt:      database.Now(),
+		UpdatedAt:      database.Now(),
+	})
+	require.NoError(t, err)
+	return webhook
+}
+
+func waitForDelivery(ctx context.Context, t *testing.T, db database.Store, webhookID uuid.UUID, status database.WebhookDeliveryStatus) database.WebhookDelivery {
+	t.Helper()
+	var delivery database.WebhookDelivery
+	require.True(t, testutil.Eventually(ctx, t, func(ctx context.Context) bool {
+		deliveries, err := db.GetWebhookDeliveriesByWebhookID(ctx, database.GetWebhookDeliveriesByWebhookIDParams{
+			WebhookID: webhookID,
+			Status:    string(status),
+		})
+		if err != nil || len(deliveries) == 0 {
+			return false
+		}
+		delivery = deliveries[0]
+		return true
+	}, testutil.IntervalFast))
+	return delivery
+}
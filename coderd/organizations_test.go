@@ -7,7 +7,9 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/coder/coder/coderd/audit"
 	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/codersdk"
 	"github.com/coder/coder/testutil"
 )
@@ -110,3 +112,205 @@ func TestPostOrganizationsByUser(t *testing.T) {
 		require.NoError(t, err)
 	})
 }
+
+func TestPatchOrganization(t *testing.T) {
+	t.Parallel()
+	t.Run("Update", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		_ = coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		org, err := client.CreateOrganization(ctx, codersdk.CreateOrganizationRequest{
+			Name:        "new",
+			Description: "A new organization.",
+		})
+		require.NoError(t, err)
+		require.Equal(t, "A new organization.", org.Description)
+
+		description := "Renamed."
+		org, err = client.UpdateOrganization(ctx, org.ID, codersdk.UpdateOrganizationRequest{
+			Name:        "renamed",
+			Description: &description,
+		})
+		require.NoError(t, err)
+		require.Equal(t, "renamed", org.Name)
+		require.Equal(t, description, org.Description)
+
+		// Unset fields are left untouched.
+		org, err = client.UpdateOrganization(ctx, org.ID, codersdk.UpdateOrganizationRequest{
+			Name: "again",
+		})
+		require.NoError(t, err)
+		require.Equal(t, description, org.Description)
+	})
+
+	t.Run("Conflict", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		first, err := client.Organization(ctx, user.OrganizationID)
+		require.NoError(t, err)
+		org, err := client.CreateOrganization(ctx, codersdk.CreateOrganizationRequest{
+			Name: "new",
+		})
+		require.NoError(t, err)
+		_, err = client.UpdateOrganization(ctx, org.ID, codersdk.UpdateOrganizationRequest{
+			Name: first.Name,
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusConflict, apiErr.StatusCode())
+	})
+
+	t.Run("Member", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		other := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := other.UpdateOrganization(ctx, user.OrganizationID, codersdk.UpdateOrganizationRequest{
+			Name: "mine",
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+	})
+}
+
+func TestOrganizationMembers(t *testing.T) {
+	t.Parallel()
+	t.Run("AddAndRemove", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		other := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		otherUser, err := other.User(ctx, codersdk.Me)
+		require.NoError(t, err)
+		org, err := client.CreateOrganization(ctx, codersdk.CreateOrganizationRequest{
+			Name: "new",
+		})
+		require.NoError(t, err)
+
+		member, err := client.AddOrganizationMember(ctx, org.ID, otherUser.Username)
+		require.NoError(t, err)
+		require.Equal(t, otherUser.ID, member.UserID)
+		require.Equal(t, org.ID, member.OrganizationID)
+
+		_, err = client.AddOrganizationMember(ctx, org.ID, otherUser.Username)
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusConflict, apiErr.StatusCode())
+
+		orgs, err := other.OrganizationsByUser(ctx, codersdk.Me)
+		require.NoError(t, err)
+		require.Len(t, orgs, 2)
+
+		members, err := other.OrganizationMembers(ctx, org.ID)
+		require.NoError(t, err)
+		usernames := make([]string, 0, len(members))
+		for _, member := range members {
+			usernames = append(usernames, member.Username)
+		}
+		require.ElementsMatch(t, []string{"testuser", otherUser.Username}, usernames)
+
+		err = client.RemoveOrganizationMember(ctx, org.ID, otherUser.Username)
+		require.NoError(t, err)
+
+		orgs, err = other.OrganizationsByUser(ctx, codersdk.Me)
+		require.NoError(t, err)
+		require.Len(t, orgs, 1)
+
+		err = client.RemoveOrganizationMember(ctx, org.ID, otherUser.Username)
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+	})
+
+	t.Run("Audit", func(t *testing.T) {
+		t.Parallel()
+		auditor := audit.NewMock()
+		client := coderdtest.New(t, &coderdtest.Options{Auditor: auditor})
+		user := coderdtest.CreateFirstUser(t, client)
+		other := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		otherUser, err := other.User(ctx, codersdk.Me)
+		require.NoError(t, err)
+		org, err := client.CreateOrganization(ctx, codersdk.CreateOrganizationRequest{
+			Name: "new",
+		})
+		require.NoError(t, err)
+
+		_, err = client.AddOrganizationMember(ctx, org.ID, otherUser.Username)
+		require.NoError(t, err)
+		alog := auditor.AuditLogs[len(auditor.AuditLogs)-1]
+		require.Equal(t, database.AuditActionCreate, alog.Action)
+		require.Equal(t, database.ResourceTypeOrganizationMember, alog.ResourceType)
+		require.Equal(t, otherUser.ID, alog.ResourceID)
+
+		err = client.RemoveOrganizationMember(ctx, org.ID, otherUser.Username)
+		require.NoError(t, err)
+		alog = auditor.AuditLogs[len(auditor.AuditLogs)-1]
+		require.Equal(t, database.AuditActionDelete, alog.Action)
+		require.Equal(t, database.ResourceTypeOrganizationMember, alog.ResourceType)
+		require.Equal(t, otherUser.ID, alog.ResourceID)
+	})
+
+	t.Run("MemberCannotAdd", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		other := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		org, err := client.CreateOrganization(ctx, codersdk.CreateOrganizationRequest{
+			Name: "new",
+		})
+		require.NoError(t, err)
+		_, err = client.AddOrganizationMember(ctx, org.ID, codersdk.Me)
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusConflict, apiErr.StatusCode())
+
+		_, err = other.AddOrganizationMember(ctx, user.OrganizationID, codersdk.Me)
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusForbidden, apiErr.StatusCode())
+	})
+
+	t.Run("RemoveWithWorkspaces", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		other := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		workspace := coderdtest.CreateWorkspace(t, other, user.OrganizationID, template.ID)
+		coderdtest.AwaitWorkspaceBuildJob(t, other, workspace.LatestBuild.ID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		err := client.RemoveOrganizationMember(ctx, user.OrganizationID, workspace.OwnerID.String())
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+	})
+}
@@ -0,0 +1,375 @@
+package coderd
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"golang.org/x/xerrors"
+	"nhooyr.io/websocket"
+
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/codersdk"
+)
+
+// appSharingLevelRanks orders sharing levels from the least to the most
+// permissive.
+var appSharingLevelRanks = map[database.AppSharingLevel]int{
+	database.AppSharingLevelOwner:         0,
+	database.AppSharingLevelAuthenticated: 1,
+	database.AppSharingLevelPublic:        2,
+}
+
+// lowestAppSharingLevel returns the less permissive of two sharing levels.
+func lowestAppSharingLevel(a, b database.AppSharingLevel) database.AppSharingLevel {
+	if appSharingLevelRanks[a] <= appSharingLevelRanks[b] {
+		return a
+	}
+	return b
+}
+
+func (api *API) workspaceAgentPortShares(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	workspace := httpmw.WorkspaceParam(r)
+	if !api.Authorize(r, rbac.ActionRead, workspace) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	template, err := api.Database.GetTemplateByID(ctx, workspace.TemplateID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace template.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	shares, err := api.Database.GetWorkspaceAgentPortSharesByWorkspaceID(ctx, workspace.ID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching port shares.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	res := codersdk.WorkspaceAgentPortShares{
+		Shares:          make([]codersdk.WorkspaceAgentPortShare, 0, len(shares)),
+		MaxSharingLevel: codersdk.WorkspaceAppSharingLevel(template.MaxPortSharingLevel),
+	}
+	for _, share := range shares {
+		res.Shares = append(res.Shares, convertWorkspaceAgentPortShare(share))
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, res)
+}
+
+func (api *API) postWorkspaceAgentPortShare(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	workspace := httpmw.WorkspaceParam(r)
+	if !api.Authorize(r, rbac.ActionUpdate, workspace) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	var req codersdk.UpsertWorkspaceAgentPortShareRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+	sharingLevel := database.AppSharingLevel(req.SharingLevel)
+	protocol := database.PortShareProtocolTCP
+	if req.Protocol != "" {
+		protocol = database.PortShareProtocol(req.Protocol)
+	}
+	var validErrs []codersdk.ValidationError
+	if int(req.Port) < codersdk.MinimumListeningPort || req.Port > 65535 {
+		validErrs = append(validErrs, codersdk.ValidationError{
+			Field:  "port",
+			Detail: fmt.Sprintf("Must be between %d and 65535.", codersdk.MinimumListeningPort),
+		})
+	}
+	if _, ok := appSharingLevelRanks[sharingLevel]; !ok {
+		validErrs = append(validErrs, codersdk.ValidationError{
+			Field:  "sharing_level",
+			Detail: `Must be "owner", "authenticated" or "public".`,
+		})
+	}
+	if !validPortShareProtocol(protocol) {
+		validErrs = append(validErrs, codersdk.ValidationError{
+			Field:  "protocol",
+			Detail: `Must be "tcp" or "udp".`,
+		})
+	}
+	if len(validErrs) > 0 {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message:     "Invalid port share.",
+			Validations: validErrs,
+		})
+		return
+	}
+
+	template, err := api.Database.GetTemplateByID(ctx, workspace.TemplateID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace template.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	if lowestAppSharingLevel(sharingLevel, template.MaxPortSharingLevel) != sharingLevel {
+		httpapi.Write(ctx, rw, http.StatusForbidden, codersdk.Response{
+			Message: fmt.Sprintf("The template only allows ports to be shared with the %q sharing level or lower.", template.MaxPortSharingLevel),
+		})
+		return
+	}
+	ok, err := api.workspaceHasAgent(ctx, workspace, req.AgentName)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace agents.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	if !ok {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: fmt.Sprintf("The workspace has no agent named %q.", req.AgentName),
+		})
+		return
+	}
+
+	share, err := api.Database.UpsertWorkspaceAgentPortShare(ctx, database.UpsertWorkspaceAgentPortShareParams{
+		WorkspaceID:  workspace.ID,
+		AgentName:    req.AgentName,
+		Port:         req.Port,
+		Protocol:     protocol,
+		SharingLevel: sharingLevel,
+		CreatedAt:    database.Now(),
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error sharing port.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, convertWorkspaceAgentPortShare(share))
+}
+
+func (api *API) deleteWorkspaceAgentPortShare(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	workspace := httpmw.WorkspaceParam(r)
+	if !api.Authorize(r, rbac.ActionUpdate, workspace) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	agentName := chi.URLParam(r, "agentname")
+	port, err := strconv.ParseUint(chi.URLParam(r, "port"), 10, 16)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Invalid port.",
+			Validations: []codersdk.ValidationError{
+				{Field: "port", Detail: err.Error()},
+			},
+		})
+		return
+	}
+	protocol := database.PortShareProtocolTCP
+	if raw := r.URL.Query().Get("protocol"); raw != "" {
+		protocol = database.PortShareProtocol(raw)
+	}
+	if !validPortShareProtocol(protocol) {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Invalid protocol.",
+			Validations: []codersdk.ValidationError{
+				{Field: "protocol", Detail: `Must be "tcp" or "udp".`},
+			},
+		})
+		return
+	}
+	params := database.GetWorkspaceAgentPortShareParams{
+		WorkspaceID: workspace.ID,
+		AgentName:   agentName,
+		Port:        int32(port),
+		Protocol:    protocol,
+	}
+	_, err = api.Database.GetWorkspaceAgentPortShare(ctx, params)
+	if xerrors.Is(err, sql.ErrNoRows) {
+		httpapi.Write(ctx, rw, http.StatusNotFound, codersdk.Response{
+			Message: fmt.Sprintf("%s port %d of agent %q isn't shared.", strings.ToUpper(string(protocol)), port, agentName),
+		})
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching port share.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	err = api.Database.DeleteWorkspaceAgentPortShare(ctx, database.DeleteWorkspaceAgentPortShareParams(params))
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error deleting port share.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// workspaceHasAgent returns whether the latest build of the workspace has an
+// agent with the name.
+func (api *API) workspaceHasAgent(ctx context.Context, workspace database.Workspace, agentName string) (bool, error) {
+	agents, err := api.latestWorkspaceAgents(ctx, workspace)
+	if err != nil {
+		return false, err
+	}
+	for _, agent := range agents {
+		if agent.Name == agentName {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// portSharingLevel returns the level a port in a workspace is shared with,
+// capped at the maximum level of the template.
+func (api *API) portSharingLevel(ctx context.Context, workspace database.Workspace, agentName string, port uint16, protocol database.PortShareProtocol) (database.AppSharingLevel, error) {
+	share, err := api.Database.GetWorkspaceAgentPortShare(ctx, database.GetWorkspaceAgentPortShareParams{
+		WorkspaceID: workspace.ID,
+		AgentName:   agentName,
+		Port:        int32(port),
+		Protocol:    protocol,
+	})
+	if xerrors.Is(err, sql.ErrNoRows) {
+		return database.AppSharingLevelOwner, nil
+	}
+	if err != nil {
+		return "", xerrors.Errorf("get port share: %w", err)
+	}
+	template, err := api.Database.GetTemplateByID(ctx, workspace.TemplateID)
+	if err != nil {
+		return "", xerrors.Errorf("get template: %w", err)
+	}
+	return lowestAppSharingLevel(share.SharingLevel, template.MaxPortSharingLevel), nil
+}
+
+func validPortShareProtocol(protocol database.PortShareProtocol) bool {
+	return protocol == database.PortShareProtocolTCP || protocol == database.PortShareProtocolUDP
+}
+
+// workspaceAgentPortShareUDP relays datagrams between a websocket and a UDP
+// port in a workspace. Every websocket message is a datagram.
+func (api *API) workspaceAgentPortShareUDP(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	api.WebsocketWaitMutex.Lock()
+	api.WebsocketWaitGroup.Add(1)
+	api.WebsocketWaitMutex.Unlock()
+	defer api.WebsocketWaitGroup.Done()
+
+	workspace := httpmw.WorkspaceParam(r)
+	workspaceAgent := httpmw.WorkspaceAgentParam(r)
+	port, err := strconv.ParseUint(chi.URLParam(r, "port"), 10, 16)
+	if err != nil || port < uint64(codersdk.MinimumListeningPort) {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: fmt.Sprintf("Port must be between %d and 65535.", codersdk.MinimumListeningPort),
+		})
+		return
+	}
+	sharingLevel, err := api.portSharingLevel(ctx, workspace, workspaceAgent.Name, uint16(port), database.PortShareProtocolUDP)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching port sharing level.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	ok, err := api.authorizeWorkspaceApp(r, sharingLevel, workspace)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error checking port access.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	if !ok {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	agentConn, release, ok := api.acquireConnectedWorkspaceAgent(rw, r, workspaceAgent)
+	if !ok {
+		return
+	}
+	defer release()
+	udpConn, err := agentConn.DialContext(ctx, "udp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusBadGateway, codersdk.Response{
+			Message: fmt.Sprintf("Failed to dial UDP port %d in the workspace.", port),
+			Detail:  err.Error(),
+		})
+		return
+	}
+	defer udpConn.Close()
+
+	conn, err := websocket.Accept(rw, r, &websocket.AcceptOptions{
+		CompressionMode: websocket.CompressionDisabled,
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Failed to accept websocket.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	conn.SetReadLimit(codersdk.MaxDatagramSize)
+	go httpapi.Heartbeat(ctx, conn)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		defer cancel()
+		buf := make([]byte, codersdk.MaxDatagramSize)
+		for {
+			n, err := udpConn.Read(buf)
+			if err != nil {
+				return
+			}
+			err = conn.Write(ctx, websocket.MessageBinary, buf[:n])
+			if err != nil {
+				return
+			}
+		}
+	}()
+	for {
+		_, data, err := conn.Read(ctx)
+		if err != nil {
+			break
+		}
+		_, err = udpConn.Write(data)
+		if err != nil {
+			break
+		}
+	}
+	_ = conn.Close(websocket.StatusNormalClosure, "")
+}
+
+func convertWorkspaceAgentPortShare(share database.WorkspaceAgentPortShare) codersdk.WorkspaceAgentPortShare {
+	return codersdk.WorkspaceAgentPortShare{
+		WorkspaceID:  share.WorkspaceID,
+		AgentName:    share.AgentName,
+		Port:         share.Port,
+		Protocol:     codersdk.WorkspaceAgentPortShareProtocol(share.Protocol),
+		SharingLevel: codersdk.WorkspaceAppSharingLevel(share.SharingLevel),
+		CreatedAt:    share.CreatedAt,
+		UpdatedAt:    share.UpdatedAt,
+	}
+}
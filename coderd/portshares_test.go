@@ -0,0 +1,256 @@
+package coderd_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/testutil"
+)
+
+func TestWorkspaceAgentPortShares(t *testing.T) {
+	t.Parallel()
+
+	client, user, workspace, port := setupProxyTest(t)
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	share := codersdk.UpsertWorkspaceAgentPortShareRequest{
+		AgentName:    proxyTestAgentName,
+		Port:         int32(port),
+		SharingLevel: codersdk.WorkspaceAppSharingLevelAuthenticated,
+	}
+
+	// Ports can't be shared until the template allows it.
+	_, err := client.UpsertWorkspaceAgentPortShare(ctx, workspace.ID, share)
+	var apiErr *codersdk.Error
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusForbidden, apiErr.StatusCode())
+
+	public := codersdk.WorkspaceAppSharingLevelPublic
+	template, err := client.UpdateTemplateMeta(ctx, workspace.TemplateID, codersdk.UpdateTemplateMeta{
+		MaxPortSharingLevel: &public,
+	})
+	require.NoError(t, err)
+	require.Equal(t, public, template.MaxPortSharingLevel)
+
+	created, err := client.UpsertWorkspaceAgentPortShare(ctx, workspace.ID, share)
+	require.NoError(t, err)
+	require.Equal(t, codersdk.WorkspaceAppSharingLevelAuthenticated, created.SharingLevel)
+
+	// Sharing the port again changes its level.
+	share.SharingLevel = codersdk.WorkspaceAppSharingLevelPublic
+	_, err = client.UpsertWorkspaceAgentPortShare(ctx, workspace.ID, share)
+	require.NoError(t, err)
+	shares, err := client.WorkspaceAgentPortShares(ctx, workspace.ID)
+	require.NoError(t, err)
+	require.Equal(t, public, shares.MaxSharingLevel)
+	require.Len(t, shares.Shares, 1)
+	require.Equal(t, codersdk.WorkspaceAppSharingLevelPublic, shares.Shares[0].SharingLevel)
+
+	t.Run("UnknownAgent", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := client.UpsertWorkspaceAgentPortShare(ctx, workspace.ID, codersdk.UpsertWorkspaceAgentPortShareRequest{
+			AgentName:    "unknown",
+			Port:         8080,
+			SharingLevel: codersdk.WorkspaceAppSharingLevelPublic,
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+	})
+
+	t.Run("InvalidPort", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := client.UpsertWorkspaceAgentPortShare(ctx, workspace.ID, codersdk.UpsertWorkspaceAgentPortShareRequest{
+			AgentName:    proxyTestAgentName,
+			Port:         70000,
+			SharingLevel: codersdk.WorkspaceAppSharingLevelPublic,
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+	})
+
+	t.Run("OtherUsers", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		otherClient := coderdtest.CreateAnotherUser(t, client, user.OrganizationID, rbac.RoleMember())
+		_, err := otherClient.UpsertWorkspaceAgentPortShare(ctx, workspace.ID, codersdk.UpsertWorkspaceAgentPortShareRequest{
+			AgentName:    proxyTestAgentName,
+			Port:         8080,
+			SharingLevel: codersdk.WorkspaceAppSharingLevelPublic,
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+	})
+}
+
+func TestWorkspaceAgentPortSharesProxy(t *testing.T) {
+	t.Parallel()
+
+	client, user, workspace, port := setupProxyTest(t)
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	owner, err := client.User(ctx, codersdk.Me)
+	require.NoError(t, err)
+	appHost, err := client.GetAppHost(ctx)
+	require.NoError(t, err)
+	portURL := (&url.URL{
+		Scheme: "http",
+		Host: strings.Replace(appHost.Host, "*", httpapi.ApplicationURL{
+			Port:          port,
+			AgentName:     proxyTestAgentName,
+			WorkspaceName: workspace.Name,
+			Username:      owner.Username,
+		}.String(), 1),
+		Path:     "/",
+		RawQuery: proxyTestAppQuery,
+	}).String()
+
+	otherClient := coderdtest.CreateAnotherUser(t, client, user.OrganizationID, rbac.RoleMember())
+	otherClient.HTTPClient.CheckRedirect = client.HTTPClient.CheckRedirect
+	otherClient.HTTPClient.Transport = client.HTTPClient.Transport
+	requireStatus := func(t *testing.T, status int) {
+		t.Helper()
+		res, err := otherClient.Request(ctx, http.MethodGet, portURL, nil)
+		require.NoError(t, err)
+		_ = res.Body.Close()
+		require.Equal(t, status, res.StatusCode)
+	}
+
+	// Ports are only accessible to the owner by default.
+	requireStatus(t, http.StatusNotFound)
+
+	public := codersdk.WorkspaceAppSharingLevelPublic
+	_, err = client.UpdateTemplateMeta(ctx, workspace.TemplateID, codersdk.UpdateTemplateMeta{
+		MaxPortSharingLevel: &public,
+	})
+	require.NoError(t, err)
+	_, err = client.UpsertWorkspaceAgentPortShare(ctx, workspace.ID, codersdk.UpsertWorkspaceAgentPortShareRequest{
+		AgentName:    proxyTestAgentName,
+		Port:         int32(port),
+		SharingLevel: codersdk.WorkspaceAppSharingLevelAuthenticated,
+	})
+	require.NoError(t, err)
+	requireStatus(t, http.StatusOK)
+
+	// Lowering the maximum of the template applies to existing shares.
+	ownerLevel := codersdk.WorkspaceAppSharingLevelOwner
+	_, err = client.UpdateTemplateMeta(ctx, workspace.TemplateID, codersdk.UpdateTemplateMeta{
+		MaxPortSharingLevel: &ownerLevel,
+	})
+	require.NoError(t, err)
+	requireStatus(t, http.StatusNotFound)
+
+	_, err = client.UpdateTemplateMeta(ctx, workspace.TemplateID, codersdk.UpdateTemplateMeta{
+		MaxPortSharingLevel: &public,
+	})
+	require.NoError(t, err)
+	err = client.DeleteWorkspaceAgentPortShare(ctx, workspace.ID, proxyTestAgentName, int32(port), codersdk.WorkspaceAgentPortShareProtocolTCP)
+	require.NoError(t, err)
+	requireStatus(t, http.StatusNotFound)
+
+	err = client.DeleteWorkspaceAgentPortShare(ctx, workspace.ID, proxyTestAgentName, int32(port), codersdk.WorkspaceAgentPortShareProtocolTCP)
+	var apiErr *codersdk.Error
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+}
+
+func TestWorkspaceAgentPortSharesUDP(t *testing.T) {
+	t.Parallel()
+
+	client, user, workspace, _ := setupProxyTest(t)
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	// The agent runs on this machine, so it can reach the echo server.
+	echo, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = echo.Close()
+	})
+	go func() {
+		buf := make([]byte, 1024)
+		for {
+			n, addr, err := echo.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			_, _ = echo.WriteTo(buf[:n], addr)
+		}
+	}()
+	udpAddr, ok := echo.LocalAddr().(*net.UDPAddr)
+	require.True(t, ok)
+	port := uint16(udpAddr.Port)
+
+	owner, err := client.User(ctx, codersdk.Me)
+	require.NoError(t, err)
+	otherClient := coderdtest.CreateAnotherUser(t, client, user.OrganizationID, rbac.RoleMember())
+	workspaceAndAgent := workspace.Name + "." + proxyTestAgentName
+
+	// Ports are only accessible to the owner by default.
+	_, err = otherClient.DialWorkspaceAgentPortShareUDP(ctx, owner.Username, workspaceAndAgent, port)
+	var apiErr *codersdk.Error
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+
+	public := codersdk.WorkspaceAppSharingLevelPublic
+	_, err = client.UpdateTemplateMeta(ctx, workspace.TemplateID, codersdk.UpdateTemplateMeta{
+		MaxPortSharingLevel: &public,
+	})
+	require.NoError(t, err)
+	share, err := client.UpsertWorkspaceAgentPortShare(ctx, workspace.ID, codersdk.UpsertWorkspaceAgentPortShareRequest{
+		AgentName:    proxyTestAgentName,
+		Port:         int32(port),
+		Protocol:     codersdk.WorkspaceAgentPortShareProtocolUDP,
+		SharingLevel: codersdk.WorkspaceAppSharingLevelAuthenticated,
+	})
+	require.NoError(t, err)
+	require.Equal(t, codersdk.WorkspaceAgentPortShareProtocolUDP, share.Protocol)
+
+	conn, err := otherClient.DialWorkspaceAgentPortShareUDP(ctx, owner.Username, workspaceAndAgent, port)
+	require.NoError(t, err)
+	defer conn.Close()
+	for _, datagram := range []string{"hello", "world"} {
+		_, err = conn.Write([]byte(datagram))
+		require.NoError(t, err)
+		buf := make([]byte, 1024)
+		n, err := conn.Read(buf)
+		require.NoError(t, err)
+		require.Equal(t, datagram, string(buf[:n]))
+	}
+
+	// Sharing a UDP port doesn't share the TCP port.
+	shares, err := client.WorkspaceAgentPortShares(ctx, workspace.ID)
+	require.NoError(t, err)
+	require.Len(t, shares.Shares, 1)
+	err = client.DeleteWorkspaceAgentPortShare(ctx, workspace.ID, proxyTestAgentName, int32(port), codersdk.WorkspaceAgentPortShareProtocolTCP)
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+
+	err = client.DeleteWorkspaceAgentPortShare(ctx, workspace.ID, proxyTestAgentName, int32(port), codersdk.WorkspaceAgentPortShareProtocolUDP)
+	require.NoError(t, err)
+	_, err = otherClient.DialWorkspaceAgentPortShareUDP(ctx, owner.Username, workspaceAndAgent, port)
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+}
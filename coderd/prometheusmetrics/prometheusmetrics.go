@@ -2,13 +2,17 @@ package prometheusmetrics
 
 import (
 	"context"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/prometheus/client_golang/prometheus"
 
-	"github.com/coder/coder/coderd"
+	"cdr.dev/slog"
+
 	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/database/db2sdk"
+	"github.com/coder/coder/codersdk"
 )
 
 // ActiveUsers tracks the number of users that have authenticated within the past hour.
@@ -99,10 +103,110 @@ func Workspaces(ctx context.Context, registerer prometheus.Registerer, db databa
 
 			gauge.Reset()
 			for _, job := range jobs {
-				status := coderd.ConvertProvisionerJobStatus(job)
+				status := db2sdk.ProvisionerJobStatus(job)
 				gauge.WithLabelValues(string(status)).Add(1)
 			}
 		}
 	}()
 	return cancelFunc, nil
 }
+
+// AgentStats tracks the latest stats reported by workspace agents, labeled
+// with the agent and the name and owner of its workspace. Agents that haven't
+// reported stats within maxAge are omitted, so it should be longer than the
+// agent stats refresh interval.
+func AgentStats(ctx context.Context, logger slog.Logger, registerer prometheus.Registerer, db database.Store, duration, maxAge time.Duration) (context.CancelFunc, error) {
+	if duration == 0 {
+		duration = 5 * time.Minute
+	}
+
+	labels := []string{"username", "workspace_name", "agent_name"}
+	newGauge := func(name, help string, extraLabels ...string) (*prometheus.GaugeVec, error) {
+		gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "coderd",
+			Subsystem: "agentstats",
+			Name:      name,
+			Help:      help,
+		}, append(labels[:len(labels):len(labels)], extraLabels...))
+		return gauge, registerer.Register(gauge)
+	}
+	gauges := map[string]*prometheus.GaugeVec{}
+	for _, metric := range []struct {
+		name, help string
+	}{
+		{"rx_bytes", "The number of bytes the agent received since it started."},
+		{"tx_bytes", "The number of bytes the agent sent since it started."},
+		{"connection_count", "The number of connections the agent accepted since it started."},
+		{"cpu_used_cores", "The average number of CPU cores used by the workspace between the agent's last two reports."},
+		{"cpu_total_cores", "The number of CPU cores available to the workspace."},
+		{"memory_used_bytes", "The memory used by the workspace, excluding inactive file cache."},
+		{"memory_total_bytes", "The memory available to the workspace."},
+		{"disk_used_bytes", "The disk space used on the filesystem of the home directory of the workspace."},
+		{"disk_total_bytes", "The size of the filesystem of the home directory of the workspace."},
+	} {
+		gauge, err := newGauge(metric.name, metric.help)
+		if err != nil {
+			return nil, err
+		}
+		gauges[metric.name] = gauge
+	}
+	sessionGauge, err := newGauge("session_count", "The number of open sessions of each type.", "session_type")
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancelFunc := context.WithCancel(ctx)
+	ticker := time.NewTicker(duration)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			rows, err := db.GetLatestAgentStats(ctx, database.Now().Add(-maxAge))
+			if err != nil {
+				logger.Debug(ctx, "get latest agent stats", slog.Error(err))
+				continue
+			}
+
+			for _, gauge := range gauges {
+				gauge.Reset()
+			}
+			sessionGauge.Reset()
+			for _, row := range rows {
+				var stats codersdk.AgentStatsReportResponse
+				err = json.Unmarshal(row.Payload, &stats)
+				if err != nil {
+					logger.Debug(ctx, "unmarshal agent stats", slog.F("agent_id", row.AgentID), slog.Error(err))
+					continue
+				}
+				labelValues := []string{row.Username, row.WorkspaceName, row.AgentName}
+				for name, value := range map[string]float64{
+					"rx_bytes":           float64(stats.RxBytes),
+					"tx_bytes":           float64(stats.TxBytes),
+					"connection_count":   float64(stats.NumConns),
+					"cpu_used_cores":     stats.CPUUsedCores,
+					"cpu_total_cores":    stats.CPUTotalCores,
+					"memory_used_bytes":  float64(stats.MemoryUsedBytes),
+					"memory_total_bytes": float64(stats.MemoryTotalBytes),
+					"disk_used_bytes":    float64(stats.DiskUsedBytes),
+					"disk_total_bytes":   float64(stats.DiskTotalBytes),
+				} {
+					gauges[name].WithLabelValues(labelValues...).Set(value)
+				}
+				for sessionType, count := range map[string]int64{
+					"ssh":              stats.SessionCountSSH,
+					"reconnecting_pty": stats.SessionCountReconnectingPTY,
+					"vscode":           stats.SessionCountVSCode,
+					"jetbrains":        stats.SessionCountJetBrains,
+				} {
+					sessionGauge.WithLabelValues(append(labelValues, sessionType)...).Set(float64(count))
+				}
+			}
+		}
+	}()
+	return cancelFunc, nil
+}
@@ -3,6 +3,7 @@ package prometheusmetrics_test
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"testing"
 	"time"
 
@@ -11,6 +12,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"cdr.dev/slog/sloggers/slogtest"
+
 	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/coderd/database/databasefake"
 	"github.com/coder/coder/coderd/prometheusmetrics"
@@ -233,3 +236,79 @@ func TestWorkspaces(t *testing.T) {
 		})
 	}
 }
+
+func TestAgentStats(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	db := databasefake.New()
+	user, err := db.InsertUser(ctx, database.InsertUserParams{
+		ID:        uuid.New(),
+		Username:  "alice",
+		LoginType: database.LoginTypePassword,
+	})
+	require.NoError(t, err)
+	workspace, err := db.InsertWorkspace(ctx, database.InsertWorkspaceParams{
+		ID:      uuid.New(),
+		OwnerID: user.ID,
+		Name:    "dev",
+	})
+	require.NoError(t, err)
+	agent, err := db.InsertWorkspaceAgent(ctx, database.InsertWorkspaceAgentParams{
+		ID:   uuid.New(),
+		Name: "main",
+	})
+	require.NoError(t, err)
+	insertStat := func(createdAt time.Time, stats codersdk.AgentStatsReportResponse) {
+		payload, err := json.Marshal(stats)
+		require.NoError(t, err)
+		_, err = db.InsertAgentStat(ctx, database.InsertAgentStatParams{
+			ID:          uuid.New(),
+			CreatedAt:   createdAt,
+			UserID:      user.ID,
+			WorkspaceID: workspace.ID,
+			AgentID:     agent.ID,
+			Payload:     payload,
+		})
+		require.NoError(t, err)
+	}
+	insertStat(database.Now().Add(-2*time.Minute), codersdk.AgentStatsReportResponse{MemoryUsedBytes: 1})
+	insertStat(database.Now().Add(-time.Minute), codersdk.AgentStatsReportResponse{
+		NumConns:           3,
+		SessionCountVSCode: 2,
+		CPUUsedCores:       1.5,
+		MemoryUsedBytes:    1024,
+	})
+
+	registry := prometheus.NewRegistry()
+	cancel, err := prometheusmetrics.AgentStats(ctx, slogtest.Make(t, nil), registry, db, time.Millisecond, time.Hour)
+	require.NoError(t, err)
+	t.Cleanup(cancel)
+
+	require.Eventually(t, func() bool {
+		metrics, err := registry.Gather()
+		assert.NoError(t, err)
+		values := map[string]float64{}
+		for _, family := range metrics {
+			for _, metric := range family.Metric {
+				labels := map[string]string{}
+				for _, label := range metric.Label {
+					labels[label.GetName()] = label.GetValue()
+				}
+				assert.Equal(t, "alice", labels["username"])
+				assert.Equal(t, "dev", labels["workspace_name"])
+				assert.Equal(t, "main", labels["agent_name"])
+				name := family.GetName()
+				if sessionType, ok := labels["session_type"]; ok {
+					name += "/" + sessionType
+				}
+				values[name] = metric.Gauge.GetValue()
+			}
+		}
+		return values["coderd_agentstats_connection_count"] == 3 &&
+			values["coderd_agentstats_session_count/vscode"] == 2 &&
+			values["coderd_agentstats_session_count/ssh"] == 0 &&
+			values["coderd_agentstats_cpu_used_cores"] == 1.5 &&
+			values["coderd_agentstats_memory_used_bytes"] == 1024
+	}, testutil.WaitShort, testutil.IntervalFast)
+}
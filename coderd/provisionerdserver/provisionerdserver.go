@@ -19,7 +19,10 @@ import (
 
 	"cdr.dev/slog"
 
+	"github.com/coder/coder/coderd/autobuild/schedule"
 	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/filestore"
+	"github.com/coder/coder/coderd/notifications"
 	"github.com/coder/coder/coderd/parameter"
 	"github.com/coder/coder/coderd/telemetry"
 	"github.com/coder/coder/codersdk"
@@ -34,16 +37,31 @@ var (
 	lastAcquireMutex sync.RWMutex
 )
 
+const (
+	// agentShutdownDefaultTimeout is how long to wait for an agent to run
+	// its shutdown script when the template doesn't specify a timeout.
+	agentShutdownDefaultTimeout = 5 * time.Minute
+	// agentShutdownInactiveTimeout is how recently an agent must have been
+	// connected for a stop build to wait on its shutdown script.
+	agentShutdownInactiveTimeout = time.Minute
+)
+
 type Server struct {
-	AccessURL      *url.URL
-	ID             uuid.UUID
-	Logger         slog.Logger
-	Provisioners   []database.ProvisionerType
-	Tags           json.RawMessage
+	AccessURL    *url.URL
+	ID           uuid.UUID
+	Logger       slog.Logger
+	Provisioners []database.ProvisionerType
+	Tags         json.RawMessage
+	// OrganizationID limits the jobs acquired to a single organization.
+	// Jobs are acquired from every organization when unset.
+	OrganizationID uuid.UUID
 	Database       database.Store
 	Pubsub         database.Pubsub
 	Telemetry      telemetry.Reporter
 	QuotaCommitter *atomic.Pointer[proto.QuotaCommitter]
+	// FileStore stores the contents of template archives. Defaults to the
+	// database.
+	FileStore filestore.Store
 
 	AcquireJobDebounce time.Duration
 }
@@ -71,8 +89,9 @@ func (server *Server) AcquireJob(ctx context.Context, _ *proto.Empty) (*proto.Ac
 			UUID:  server.ID,
 			Valid: true,
 		},
-		Types: server.Provisioners,
-		Tags:  server.Tags,
+		Types:          server.Provisioners,
+		Tags:           server.Tags,
+		OrganizationID: server.OrganizationID,
 	})
 	if errors.Is(err, sql.ErrNoRows) {
 		// The provisioner daemon assumes no jobs are available if
@@ -174,13 +193,18 @@ func (server *Server) AcquireJob(ctx context.Context, _ *proto.Empty) (*proto.Ac
 		if err != nil {
 			return nil, failJob(fmt.Sprintf("convert workspace transition: %s", err))
 		}
+		richParameters, err := server.Database.GetWorkspaceBuildParameters(ctx, workspaceBuild.ID)
+		if err != nil {
+			return nil, failJob(fmt.Sprintf("get workspace build parameters: %s", err))
+		}
 
 		protoJob.Type = &proto.AcquiredJob_WorkspaceBuild_{
 			WorkspaceBuild: &proto.AcquiredJob_WorkspaceBuild{
-				WorkspaceBuildId: workspaceBuild.ID.String(),
-				WorkspaceName:    workspace.Name,
-				State:            workspaceBuild.ProvisionerState,
-				ParameterValues:  protoParameters,
+				WorkspaceBuildId:    workspaceBuild.ID.String(),
+				WorkspaceName:       workspace.Name,
+				State:               workspaceBuild.ProvisionerState,
+				ParameterValues:     protoParameters,
+				RichParameterValues: convertRichParameterValues(richParameters),
 				Metadata: &sdkproto.Provision_Metadata{
 					CoderUrl:            server.AccessURL.String(),
 					WorkspaceTransition: transition,
@@ -223,7 +247,8 @@ func (server *Server) AcquireJob(ctx context.Context, _ *proto.Empty) (*proto.Ac
 
 		protoJob.Type = &proto.AcquiredJob_TemplateDryRun_{
 			TemplateDryRun: &proto.AcquiredJob_TemplateDryRun{
-				ParameterValues: protoParameters,
+				ParameterValues:     protoParameters,
+				RichParameterValues: convertSDKRichParameterValues(input.RichParameterValues),
 				Metadata: &sdkproto.Provision_Metadata{
 					CoderUrl:      server.AccessURL.String(),
 					WorkspaceName: input.WorkspaceName,
@@ -245,7 +270,10 @@ func (server *Server) AcquireJob(ctx context.Context, _ *proto.Empty) (*proto.Ac
 		if err != nil {
 			return nil, failJob(fmt.Sprintf("get file by hash: %s", err))
 		}
-		protoJob.TemplateSourceArchive = file.Data
+		protoJob.TemplateSourceArchive, err = filestore.Get(ctx, server.Database, server.FileStore, file)
+		if err != nil {
+			return nil, failJob(fmt.Sprintf("get file contents: %s", err))
+		}
 	default:
 		return nil, failJob(fmt.Sprintf("unsupported storage method: %s", job.StorageMethod))
 	}
@@ -285,6 +313,46 @@ func (server *Server) CommitQuota(ctx context.Context, request *proto.CommitQuot
 	return (*q).CommitQuota(ctx, request)
 }
 
+// CheckAgentsShutdown reports whether the agents of the build being replaced
+// by a stop or delete are still running their shutdown scripts.
+func (server *Server) CheckAgentsShutdown(ctx context.Context, request *proto.CheckAgentsShutdownRequest) (*proto.CheckAgentsShutdownResponse, error) {
+	jobID, err := uuid.Parse(request.JobId)
+	if err != nil {
+		return nil, xerrors.Errorf("parse job id: %w", err)
+	}
+
+	job, err := server.Database.GetProvisionerJobByID(ctx, jobID)
+	if err != nil {
+		return nil, xerrors.Errorf("get job: %w", err)
+	}
+	if !job.WorkerID.Valid {
+		return nil, xerrors.New("job isn't running yet")
+	}
+	if job.WorkerID.UUID.String() != server.ID.String() {
+		return nil, xerrors.New("you don't own this job")
+	}
+	if job.Type != database.ProvisionerJobTypeWorkspaceBuild {
+		return nil, xerrors.Errorf("job type %q has no agents", job.Type)
+	}
+
+	workspaceBuild, err := server.Database.GetWorkspaceBuildByJobID(ctx, job.ID)
+	if err != nil {
+		return nil, xerrors.Errorf("get workspace build: %w", err)
+	}
+	pending, timeout, err := server.pendingAgentsShutdown(ctx, workspaceBuild)
+	if err != nil {
+		return nil, err
+	}
+	if len(pending) > 0 {
+		server.Logger.Debug(ctx, "workspace agents are shutting down",
+			slog.F("workspace_build_id", workspaceBuild.ID), slog.F("agents", pending), slog.F("timeout", timeout))
+	}
+	return &proto.CheckAgentsShutdownResponse{
+		Pending:        len(pending) > 0,
+		TimeoutSeconds: int64(timeout / time.Second),
+	}, nil
+}
+
 func (server *Server) UpdateJob(ctx context.Context, request *proto.UpdateJobRequest) (*proto.UpdateJobResponse, error) {
 	parsedID, err := uuid.Parse(request.JobId)
 	if err != nil {
@@ -519,6 +587,19 @@ func (server *Server) FailJob(ctx context.Context, failJob *proto.FailedJob) (*p
 		}
 	case *proto.FailedJob_TemplateImport_:
 	}
+	if job.Type == database.ProvisionerJobTypeWorkspaceBuild {
+		var input WorkspaceProvisionJob
+		err = json.Unmarshal(job.Input, &input)
+		if err != nil {
+			return nil, xerrors.Errorf("unmarshal workspace provision input: %w", err)
+		}
+		build, err := server.Database.GetWorkspaceBuildByID(ctx, input.WorkspaceBuildID)
+		if err == nil {
+			server.publishWorkspaceBuildEvent(ctx, build, codersdk.WebhookEventWorkspaceBuildFailed, failJob.Error)
+		} else {
+			server.Logger.Warn(ctx, "get workspace build for notification", slog.F("job_id", jobID), slog.Error(err))
+		}
+	}
 
 	data, err := json.Marshal(ProvisionerJobLogsNotifyMessage{EndOfLogs: true})
 	if err != nil {
@@ -571,6 +652,23 @@ func (server *Server) CompleteJob(ctx context.Context, completed *proto.Complete
 			}
 		}
 
+		if len(jobType.TemplateImport.RichParameters) > 0 {
+			templateVersion, err := server.Database.GetTemplateVersionByJobID(ctx, jobID)
+			if err != nil {
+				return nil, xerrors.Errorf("get template version: %w", err)
+			}
+			for index, richParameter := range jobType.TemplateImport.RichParameters {
+				server.Logger.Info(ctx, "inserting template import job rich parameter",
+					slog.F("job_id", job.ID.String()),
+					slog.F("parameter_name", richParameter.Name))
+
+				err = InsertTemplateVersionParameter(ctx, server.Database, templateVersion.ID, int32(index), richParameter)
+				if err != nil {
+					return nil, xerrors.Errorf("insert rich parameter: %w", err)
+				}
+			}
+		}
+
 		err = server.Database.UpdateProvisionerJobWithCompleteByID(ctx, database.UpdateProvisionerJobWithCompleteByIDParams{
 			ID:        jobID,
 			UpdatedAt: database.Now(),
@@ -606,6 +704,9 @@ func (server *Server) CompleteJob(ctx context.Context, completed *proto.Complete
 				if workspace.Ttl.Valid {
 					workspaceDeadline = now.Add(time.Duration(workspace.Ttl.Int64))
 				}
+				if workspaceBuild.Transition == database.WorkspaceTransitionStart {
+					workspaceDeadline = server.clampDeadline(ctx, db, workspace, now, workspaceDeadline)
+				}
 			} else {
 				// Huh? Did the workspace get deleted?
 				// In any case, since this is just for the TTL, try and continue anyway.
@@ -662,6 +763,7 @@ func (server *Server) CompleteJob(ctx context.Context, completed *proto.Complete
 		if err != nil {
 			return nil, xerrors.Errorf("update workspace: %w", err)
 		}
+		server.publishWorkspaceBuildEvent(ctx, workspaceBuild, codersdk.WebhookEventWorkspaceBuildSucceeded, "")
 	case *proto.CompletedJob_TemplateDryRun_:
 		for _, resource := range jobType.TemplateDryRun.Resources {
 			server.Logger.Info(ctx, "inserting template dry-run job resource",
@@ -779,14 +881,43 @@ func InsertWorkspaceResource(ctx context.Context, db database.Store, jobID uuid.
 				String: prAgent.StartupScript,
 				Valid:  prAgent.StartupScript != "",
 			},
-			ConnectionTimeoutSeconds: prAgent.GetConnectionTimeoutSeconds(),
-			TroubleshootingURL:       prAgent.GetTroubleshootingUrl(),
+			ConnectionTimeoutSeconds:    prAgent.GetConnectionTimeoutSeconds(),
+			TroubleshootingURL:          prAgent.GetTroubleshootingUrl(),
+			StartupScriptTimeoutSeconds: prAgent.GetStartupScriptTimeoutSeconds(),
+			ShutdownScript: sql.NullString{
+				String: prAgent.ShutdownScript,
+				Valid:  prAgent.ShutdownScript != "",
+			},
+			ShutdownScriptTimeoutSeconds: prAgent.GetShutdownScriptTimeoutSeconds(),
 		})
 		if err != nil {
 			return xerrors.Errorf("insert agent: %w", err)
 		}
 		snapshot.WorkspaceAgents = append(snapshot.WorkspaceAgents, telemetry.ConvertWorkspaceAgent(dbAgent))
 
+		metadataKeys := make(map[string]struct{})
+		for _, md := range prAgent.Metadata {
+			if md.Key == "" {
+				return xerrors.Errorf("agent metadata must have a key set")
+			}
+			if _, exists := metadataKeys[md.Key]; exists {
+				return xerrors.Errorf("duplicate agent metadata key, must be unique per agent: %q", md.Key)
+			}
+			metadataKeys[md.Key] = struct{}{}
+
+			err = db.InsertWorkspaceAgentMetadata(ctx, database.InsertWorkspaceAgentMetadataParams{
+				WorkspaceAgentID: dbAgent.ID,
+				DisplayName:      md.DisplayName,
+				Key:              md.Key,
+				Script:           md.Script,
+				Timeout:          md.Timeout,
+				Interval:         md.Interval,
+			})
+			if err != nil {
+				return xerrors.Errorf("insert agent metadata: %w", err)
+			}
+		}
+
 		for _, app := range prAgent.Apps {
 			slug := app.Slug
 			if slug == "" {
@@ -956,6 +1087,167 @@ func convertComputedParameterValue(param parameter.ComputedValue) (*sdkproto.Par
 	}, nil
 }
 
+func convertRichParameterValues(values []database.WorkspaceBuildParameter) []*sdkproto.RichParameterValue {
+	protoValues := make([]*sdkproto.RichParameterValue, 0, len(values))
+	for _, value := range values {
+		protoValues = append(protoValues, &sdkproto.RichParameterValue{
+			Name:  value.Name,
+			Value: value.Value,
+		})
+	}
+	return protoValues
+}
+
+func convertSDKRichParameterValues(values []codersdk.WorkspaceBuildParameter) []*sdkproto.RichParameterValue {
+	protoValues := make([]*sdkproto.RichParameterValue, 0, len(values))
+	for _, value := range values {
+		protoValues = append(protoValues, &sdkproto.RichParameterValue{
+			Name:  value.Name,
+			Value: value.Value,
+		})
+	}
+	return protoValues
+}
+
+// InsertTemplateVersionParameter saves a rich parameter declared by a
+// template version.
+func InsertTemplateVersionParameter(ctx context.Context, db database.Store, templateVersionID uuid.UUID, index int32, param *sdkproto.RichParameter) error {
+	options := make([]codersdk.TemplateVersionParameterOption, 0, len(param.Options))
+	for _, option := range param.Options {
+		options = append(options, codersdk.TemplateVersionParameterOption{
+			Name:        option.Name,
+			Description: option.Description,
+			Value:       option.Value,
+			Icon:        option.Icon,
+		})
+	}
+	optionsJSON, err := json.Marshal(options)
+	if err != nil {
+		return xerrors.Errorf("marshal options: %w", err)
+	}
+	insert := database.InsertTemplateVersionParameterParams{
+		TemplateVersionID: templateVersionID,
+		Name:              param.Name,
+		Description:       param.Description,
+		Type:              param.Type,
+		Mutable:           param.Mutable,
+		DefaultValue:      param.DefaultValue,
+		Icon:              param.Icon,
+		Options:           optionsJSON,
+		ValidationRegex:   param.ValidationRegex,
+		ValidationError:   param.ValidationError,
+		Index:             index,
+	}
+	if param.ValidationMin != nil {
+		insert.ValidationMin = sql.NullInt32{Int32: *param.ValidationMin, Valid: true}
+	}
+	if param.ValidationMax != nil {
+		insert.ValidationMax = sql.NullInt32{Int32: *param.ValidationMax, Valid: true}
+	}
+	_, err = db.InsertTemplateVersionParameter(ctx, insert)
+	if err != nil {
+		return xerrors.Errorf("insert template version parameter %q: %w", param.Name, err)
+	}
+	return nil
+}
+
+// publishWorkspaceBuildEvent notifies webhooks of the outcome of a workspace
+// build. Errors are only logged since the job has already been completed.
+func (server *Server) publishWorkspaceBuildEvent(ctx context.Context, build database.WorkspaceBuild, eventType codersdk.WebhookEventType, buildError string) {
+	logger := server.Logger.With(slog.F("workspace_build_id", build.ID))
+	workspace, err := server.Database.GetWorkspaceByID(ctx, build.WorkspaceID)
+	if err != nil {
+		logger.Warn(ctx, "get workspace for notification", slog.Error(err))
+		return
+	}
+	err = notifications.Publish(server.Pubsub, workspace.OrganizationID, eventType, codersdk.WebhookWorkspaceBuildData{
+		WorkspaceID:      workspace.ID,
+		WorkspaceName:    workspace.Name,
+		WorkspaceOwnerID: workspace.OwnerID,
+		TemplateID:       workspace.TemplateID,
+		BuildID:          build.ID,
+		BuildNumber:      build.BuildNumber,
+		Transition:       codersdk.WorkspaceTransition(build.Transition),
+		Error:            buildError,
+	})
+	if err != nil {
+		logger.Warn(ctx, "publish workspace build notification", slog.Error(err))
+	}
+}
+
+// pendingAgentsShutdown returns the agents of the build being replaced
+// that are still running their shutdown scripts, and the longest timeout
+// of those scripts.
+func (server *Server) pendingAgentsShutdown(ctx context.Context, workspaceBuild database.WorkspaceBuild) ([]uuid.UUID, time.Duration, error) {
+	if workspaceBuild.Transition == database.WorkspaceTransitionStart || workspaceBuild.BuildNumber <= 1 {
+		return nil, 0, nil
+	}
+	previousBuild, err := server.Database.GetWorkspaceBuildByWorkspaceIDAndBuildNumber(ctx, database.GetWorkspaceBuildByWorkspaceIDAndBuildNumberParams{
+		WorkspaceID: workspaceBuild.WorkspaceID,
+		BuildNumber: workspaceBuild.BuildNumber - 1,
+	})
+	if err != nil {
+		return nil, 0, xerrors.Errorf("get previous workspace build: %w", err)
+	}
+	if previousBuild.Transition != database.WorkspaceTransitionStart {
+		return nil, 0, nil
+	}
+	resources, err := server.Database.GetWorkspaceResourcesByJobID(ctx, previousBuild.JobID)
+	if err != nil {
+		return nil, 0, xerrors.Errorf("get workspace resources: %w", err)
+	}
+	resourceIDs := make([]uuid.UUID, 0, len(resources))
+	for _, resource := range resources {
+		resourceIDs = append(resourceIDs, resource.ID)
+	}
+	agents, err := server.Database.GetWorkspaceAgentsByResourceIDs(ctx, resourceIDs)
+	if err != nil {
+		return nil, 0, xerrors.Errorf("get workspace agents: %w", err)
+	}
+
+	timeout := time.Duration(0)
+	pending := make([]uuid.UUID, 0, len(agents))
+	for _, agent := range agents {
+		// The agent is disconnected from coordination once the new build
+		// exists, so only agents connected shortly before it was created
+		// are waited on.
+		if !agent.LastConnectedAt.Valid || workspaceBuild.CreatedAt.Sub(agent.LastConnectedAt.Time) > agentShutdownInactiveTimeout {
+			continue
+		}
+		if !agentShutdownPending(agent) {
+			continue
+		}
+		pending = append(pending, agent.ID)
+		agentTimeout := time.Duration(agent.ShutdownScriptTimeoutSeconds) * time.Second
+		if agentTimeout == 0 {
+			agentTimeout = agentShutdownDefaultTimeout
+		}
+		if agentTimeout > timeout {
+			timeout = agentTimeout
+		}
+	}
+	return pending, timeout, nil
+}
+
+// agentShutdownPending returns whether the agent is expected to run a
+// shutdown script that hasn't finished yet.
+func agentShutdownPending(agent database.WorkspaceAgent) bool {
+	if !agent.ShutdownScript.Valid {
+		return false
+	}
+	switch agent.LifecycleState {
+	case database.WorkspaceAgentLifecycleStateCreated:
+		// Agents that don't report their lifecycle can't be waited on.
+		return false
+	case database.WorkspaceAgentLifecycleStateShutdownTimeout,
+		database.WorkspaceAgentLifecycleStateShutdownError,
+		database.WorkspaceAgentLifecycleStateOff:
+		return false
+	default:
+		return true
+	}
+}
+
 func convertWorkspaceTransition(transition database.WorkspaceTransition) (sdkproto.WorkspaceTransition, error) {
 	switch transition {
 	case database.WorkspaceTransitionStart:
@@ -980,6 +1272,8 @@ type TemplateVersionDryRunJob struct {
 	TemplateVersionID uuid.UUID                 `json:"template_version_id"`
 	WorkspaceName     string                    `json:"workspace_name"`
 	ParameterValues   []database.ParameterValue `json:"parameter_values"`
+	// RichParameterValues are the rich parameter values to plan with.
+	RichParameterValues []codersdk.WorkspaceBuildParameter `json:"rich_parameter_values"`
 }
 
 // ProvisionerJobLogsNotifyMessage is the payload published on
@@ -994,3 +1288,19 @@ type ProvisionerJobLogsNotifyMessage struct {
 func ProvisionerJobLogsNotifyChannel(jobID uuid.UUID) string {
 	return fmt.Sprintf("provisioner-log-logs:%s", jobID)
 }
+
+// clampDeadline returns the deadline of a workspace started at now, clamped to
+// the schedule policy of its template.
+func (server *Server) clampDeadline(ctx context.Context, db database.Store, workspace database.Workspace, now, deadline time.Time) time.Time {
+	template, err := db.GetTemplateByID(ctx, workspace.TemplateID)
+	if err != nil {
+		server.Logger.Warn(ctx, "fetch template for workspace deadline", slog.F("workspace_id", workspace.ID), slog.Error(err))
+		return deadline
+	}
+	policy, err := schedule.NewTemplatePolicy(template)
+	if err != nil {
+		server.Logger.Warn(ctx, "parse template schedule policy", slog.F("template_id", template.ID), slog.Error(err))
+		return deadline
+	}
+	return policy.Deadline(now, deadline)
+}
@@ -115,7 +115,12 @@ func TestAcquireJob(t *testing.T) {
 		require.NoError(t, err)
 
 		file, err := srv.Database.InsertFile(ctx, database.InsertFileParams{
-			ID:   uuid.New(),
+			ID:      uuid.New(),
+			Hash:    "something",
+			Storage: database.FileStorageDatabase,
+		})
+		require.NoError(t, err)
+		err = srv.Database.InsertFileData(ctx, database.InsertFileDataParams{
 			Hash: "something",
 			Data: []byte{},
 		})
@@ -192,7 +197,12 @@ func TestAcquireJob(t *testing.T) {
 		require.NoError(t, err)
 
 		file, err := srv.Database.InsertFile(ctx, database.InsertFileParams{
-			ID:   uuid.New(),
+			ID:      uuid.New(),
+			Hash:    "something",
+			Storage: database.FileStorageDatabase,
+		})
+		require.NoError(t, err)
+		err = srv.Database.InsertFileData(ctx, database.InsertFileDataParams{
 			Hash: "something",
 			Data: []byte{},
 		})
@@ -241,7 +251,12 @@ func TestAcquireJob(t *testing.T) {
 		require.NoError(t, err)
 
 		file, err := srv.Database.InsertFile(ctx, database.InsertFileParams{
-			ID:   uuid.New(),
+			ID:      uuid.New(),
+			Hash:    "something",
+			Storage: database.FileStorageDatabase,
+		})
+		require.NoError(t, err)
+		err = srv.Database.InsertFileData(ctx, database.InsertFileDataParams{
 			Hash: "something",
 			Data: []byte{},
 		})
@@ -740,6 +755,21 @@ func TestInsertWorkspaceResource(t *testing.T) {
 		})
 		require.ErrorContains(t, err, "duplicate app slug")
 	})
+	t.Run("DuplicateAgentMetadata", func(t *testing.T) {
+		t.Parallel()
+		err := insert(databasefake.New(), uuid.New(), &sdkproto.Resource{
+			Name: "something",
+			Type: "aws_instance",
+			Agents: []*sdkproto.Agent{{
+				Metadata: []*sdkproto.Agent_Metadata{{
+					Key: "a",
+				}, {
+					Key: "a",
+				}},
+			}},
+		})
+		require.ErrorContains(t, err, "duplicate agent metadata key")
+	})
 	t.Run("Success", func(t *testing.T) {
 		t.Parallel()
 		db := databasefake.New()
@@ -14,8 +14,8 @@ import (
 )
 
 type Authorizer interface {
-	ByRoleName(ctx context.Context, subjectID string, roleNames []string, scope Scope, groups []string, action Action, object Object) error
-	PrepareByRoleName(ctx context.Context, subjectID string, roleNames []string, scope Scope, groups []string, action Action, objectType string) (PreparedAuthorized, error)
+	ByRoleName(ctx context.Context, subjectID string, roleNames []string, scope ExpandableScope, groups []string, action Action, object Object) error
+	PrepareByRoleName(ctx context.Context, subjectID string, roleNames []string, scope ExpandableScope, groups []string, action Action, objectType string) (PreparedAuthorized, error)
 }
 
 type PreparedAuthorized interface {
@@ -26,7 +26,7 @@ type PreparedAuthorized interface {
 // Filter takes in a list of objects, and will filter the list removing all
 // the elements the subject does not have permission for. All objects must be
 // of the same type.
-func Filter[O Objecter](ctx context.Context, auth Authorizer, subjID string, subjRoles []string, scope Scope, groups []string, action Action, objects []O) ([]O, error) {
+func Filter[O Objecter](ctx context.Context, auth Authorizer, subjID string, subjRoles []string, scope ExpandableScope, groups []string, action Action, objects []O) ([]O, error) {
 	ctx, span := tracing.StartSpan(ctx, trace.WithAttributes(
 		attribute.String("subject_id", subjID),
 		attribute.StringSlice("subject_roles", subjRoles),
@@ -81,6 +81,9 @@ func Filter[O Objecter](ctx context.Context, auth Authorizer, subjID string, sub
 // RegoAuthorizer will use a prepared rego query for performing authorize()
 type RegoAuthorizer struct {
 	query rego.PreparedEvalQuery
+	// customRoles expands custom role names when the context doesn't carry a
+	// set, like outside of requests.
+	customRoles *CustomRoles
 }
 
 var _ Authorizer = (*RegoAuthorizer)(nil)
@@ -108,28 +111,47 @@ func NewAuthorizer() *RegoAuthorizer {
 	return &RegoAuthorizer{query: query}
 }
 
+// NewAuthorizerWithCustomRoles returns an authorizer that expands role names
+// with the custom roles in the set, unless the context carries its own set.
+func NewAuthorizerWithCustomRoles(roles *CustomRoles) *RegoAuthorizer {
+	authorizer := NewAuthorizer()
+	authorizer.customRoles = roles
+	return authorizer
+}
+
+// rolesByNames expands role names with the custom roles in the context, or
+// the authorizer's own set.
+func (a RegoAuthorizer) rolesByNames(ctx context.Context, roleNames []string) ([]Role, error) {
+	customRoles := CustomRolesFromContext(ctx)
+	if customRoles == nil {
+		customRoles = a.customRoles
+	}
+	return customRoles.RolesByNames(roleNames)
+}
+
 type authSubject struct {
-	ID     string   `json:"id"`
-	Roles  []Role   `json:"roles"`
-	Groups []string `json:"groups"`
-	Scope  Role     `json:"scope"`
+	ID     string        `json:"id"`
+	Roles  []Role        `json:"roles"`
+	Groups []string      `json:"groups"`
+	Scope  ExpandedScope `json:"scope"`
 }
 
 // ByRoleName will expand all roleNames into roles before calling Authorize().
 // This is the function intended to be used outside this package.
-// The role is fetched from the builtin map located in memory.
-func (a RegoAuthorizer) ByRoleName(ctx context.Context, subjectID string, roleNames []string, scope Scope, groups []string, action Action, object Object) error {
-	roles, err := RolesByNames(roleNames)
+// The role is fetched from the builtin map located in memory, or the custom
+// roles attached to the context or the authorizer.
+func (a RegoAuthorizer) ByRoleName(ctx context.Context, subjectID string, roleNames []string, scope ExpandableScope, groups []string, action Action, object Object) error {
+	roles, err := a.rolesByNames(ctx, roleNames)
 	if err != nil {
 		return err
 	}
 
-	scopeRole, err := ScopeRole(scope)
+	expanded, err := scope.Expand()
 	if err != nil {
 		return err
 	}
 
-	err = a.Authorize(ctx, subjectID, roles, scopeRole, groups, action, object)
+	err = a.Authorize(ctx, subjectID, roles, expanded, groups, action, object)
 	if err != nil {
 		return err
 	}
@@ -139,7 +161,7 @@ func (a RegoAuthorizer) ByRoleName(ctx context.Context, subjectID string, roleNa
 
 // Authorize allows passing in custom Roles.
 // This is really helpful for unit testing, as we can create custom roles to exercise edge cases.
-func (a RegoAuthorizer) Authorize(ctx context.Context, subjectID string, roles []Role, scope Role, groups []string, action Action, object Object) error {
+func (a RegoAuthorizer) Authorize(ctx context.Context, subjectID string, roles []Role, scope ExpandedScope, groups []string, action Action, object Object) error {
 	ctx, span := tracing.StartSpan(ctx)
 	defer span.End()
 
@@ -167,7 +189,7 @@ func (a RegoAuthorizer) Authorize(ctx context.Context, subjectID string, roles [
 
 // Prepare will partially execute the rego policy leaving the object fields unknown (except for the type).
 // This will vastly speed up performance if batch authorization on the same type of objects is needed.
-func (RegoAuthorizer) Prepare(ctx context.Context, subjectID string, roles []Role, scope Role, groups []string, action Action, objectType string) (*PartialAuthorizer, error) {
+func (RegoAuthorizer) Prepare(ctx context.Context, subjectID string, roles []Role, scope ExpandedScope, groups []string, action Action, objectType string) (*PartialAuthorizer, error) {
 	ctx, span := tracing.StartSpan(ctx)
 	defer span.End()
 
@@ -179,19 +201,19 @@ func (RegoAuthorizer) Prepare(ctx context.Context, subjectID string, roles []Rol
 	return auth, nil
 }
 
-func (a RegoAuthorizer) PrepareByRoleName(ctx context.Context, subjectID string, roleNames []string, scope Scope, groups []string, action Action, objectType string) (PreparedAuthorized, error) {
+func (a RegoAuthorizer) PrepareByRoleName(ctx context.Context, subjectID string, roleNames []string, scope ExpandableScope, groups []string, action Action, objectType string) (PreparedAuthorized, error) {
 	ctx, span := tracing.StartSpan(ctx)
 	defer span.End()
 
-	roles, err := RolesByNames(roleNames)
+	roles, err := a.rolesByNames(ctx, roleNames)
 	if err != nil {
 		return nil, err
 	}
 
-	scopeRole, err := ScopeRole(scope)
+	expanded, err := scope.Expand()
 	if err != nil {
 		return nil, err
 	}
 
-	return a.Prepare(ctx, subjectID, roles, scopeRole, groups, action, objectType)
+	return a.Prepare(ctx, subjectID, roles, expanded, groups, action, objectType)
 }
@@ -19,9 +19,9 @@ type subject struct {
 	// For the unit test we want to pass in the roles directly, instead of just
 	// by name. This allows us to test custom roles that do not exist in the product,
 	// but test edge cases of the implementation.
-	Roles  []Role   `json:"roles"`
-	Groups []string `json:"groups"`
-	Scope  Role     `json:"scope"`
+	Roles  []Role        `json:"roles"`
+	Groups []string      `json:"groups"`
+	Scope  ExpandedScope `json:"scope"`
 }
 
 type fakeObject struct {
@@ -199,7 +199,7 @@ func TestAuthorizeDomain(t *testing.T) {
 
 	user := subject{
 		UserID: "me",
-		Scope:  must(ScopeRole(ScopeAll)),
+		Scope:  must(ScopeAll.Expand()),
 		Groups: []string{allUsersGroup},
 		Roles: []Role{
 			must(RoleByName(RoleMember())),
@@ -298,7 +298,7 @@ func TestAuthorizeDomain(t *testing.T) {
 
 	user = subject{
 		UserID: "me",
-		Scope:  must(ScopeRole(ScopeAll)),
+		Scope:  must(ScopeAll.Expand()),
 		Roles: []Role{{
 			Name: "deny-all",
 			// List out deny permissions explicitly
@@ -339,7 +339,7 @@ func TestAuthorizeDomain(t *testing.T) {
 
 	user = subject{
 		UserID: "me",
-		Scope:  must(ScopeRole(ScopeAll)),
+		Scope:  must(ScopeAll.Expand()),
 		Roles: []Role{
 			must(RoleByName(RoleOrgAdmin(defOrg))),
 			must(RoleByName(RoleMember())),
@@ -373,7 +373,7 @@ func TestAuthorizeDomain(t *testing.T) {
 
 	user = subject{
 		UserID: "me",
-		Scope:  must(ScopeRole(ScopeAll)),
+		Scope:  must(ScopeAll.Expand()),
 		Roles: []Role{
 			must(RoleByName(RoleOwner())),
 			must(RoleByName(RoleMember())),
@@ -407,7 +407,7 @@ func TestAuthorizeDomain(t *testing.T) {
 
 	user = subject{
 		UserID: "me",
-		Scope:  must(ScopeRole(ScopeApplicationConnect)),
+		Scope:  must(ScopeApplicationConnect.Expand()),
 		Roles: []Role{
 			must(RoleByName(RoleOrgMember(defOrg))),
 			must(RoleByName(RoleMember())),
@@ -506,7 +506,7 @@ func TestAuthorizeDomain(t *testing.T) {
 	// In practice this is a token scope on a regular subject
 	user = subject{
 		UserID: "me",
-		Scope:  must(ScopeRole(ScopeAll)),
+		Scope:  must(ScopeAll.Expand()),
 		Roles: []Role{
 			{
 				Name: "ReadOnlyOrgAndUser",
@@ -599,7 +599,7 @@ func TestAuthorizeLevels(t *testing.T) {
 
 	user := subject{
 		UserID: "me",
-		Scope:  must(ScopeRole(ScopeAll)),
+		Scope:  must(ScopeAll.Expand()),
 		Roles: []Role{
 			must(RoleByName(RoleOwner())),
 			{
@@ -660,7 +660,7 @@ func TestAuthorizeLevels(t *testing.T) {
 
 	user = subject{
 		UserID: "me",
-		Scope:  must(ScopeRole(ScopeAll)),
+		Scope:  must(ScopeAll.Expand()),
 		Roles: []Role{
 			{
 				Name: "site-noise",
@@ -725,7 +725,7 @@ func TestAuthorizeScope(t *testing.T) {
 	user := subject{
 		UserID: "me",
 		Roles:  []Role{must(RoleByName(RoleOwner()))},
-		Scope:  must(ScopeRole(ScopeApplicationConnect)),
+		Scope:  must(ScopeApplicationConnect.Expand()),
 	}
 
 	testAuthorize(t, "Admin_ScopeApplicationConnect", user,
@@ -759,7 +759,7 @@ func TestAuthorizeScope(t *testing.T) {
 			must(RoleByName(RoleMember())),
 			must(RoleByName(RoleOrgMember(defOrg))),
 		},
-		Scope: must(ScopeRole(ScopeApplicationConnect)),
+		Scope: must(ScopeApplicationConnect.Expand()),
 	}
 
 	testAuthorize(t, "User_ScopeApplicationConnect", user,
@@ -787,6 +787,87 @@ func TestAuthorizeScope(t *testing.T) {
 			{resource: ResourceWorkspaceApplicationConnect.InOrg(unusedID).WithOwner("not-me"), actions: []Action{ActionCreate}, allow: false},
 		},
 	)
+
+	user = subject{
+		UserID: "me",
+		Roles: []Role{
+			must(RoleByName(RoleMember())),
+			must(RoleByName(RoleOrgMember(defOrg))),
+		},
+		Scope: must(ScopeRead.Expand()),
+	}
+
+	testAuthorize(t, "User_ScopeRead", user,
+		cases(func(c authTestCase) authTestCase {
+			c.actions = []Action{ActionCreate, ActionUpdate, ActionDelete}
+			c.allow = false
+			return c
+		}, []authTestCase{
+			{resource: ResourceWorkspace.InOrg(defOrg).WithOwner(user.UserID)},
+			{resource: ResourceTemplate.InOrg(defOrg)},
+		}),
+		// Allowed by scope:
+		[]authTestCase{
+			{resource: ResourceWorkspace.InOrg(defOrg).WithOwner(user.UserID), actions: []Action{ActionRead}, allow: true},
+			{resource: ResourceOrganization.InOrg(defOrg), actions: []Action{ActionRead}, allow: true},
+			{resource: ResourceWorkspace.InOrg(defOrg).WithOwner("not-me"), actions: []Action{ActionRead}, allow: false},
+		},
+	)
+
+	workspaceID := uuid.New()
+	user = subject{
+		UserID: "me",
+		Roles: []Role{
+			must(RoleByName(RoleMember())),
+			must(RoleByName(RoleOrgMember(defOrg))),
+		},
+		Scope: must(ScopeWithAllowList{
+			Scope:     ScopeWorkspaceStartStop,
+			AllowList: []AllowListElement{{Type: ResourceWorkspace.Type, ID: workspaceID.String()}},
+		}.Expand()),
+	}
+
+	testAuthorize(t, "User_ScopeWorkspaceStartStopAllowList", user,
+		cases(func(c authTestCase) authTestCase {
+			c.actions = []Action{ActionRead, ActionUpdate}
+			return c
+		}, []authTestCase{
+			{resource: ResourceWorkspace.InOrg(defOrg).WithOwner(user.UserID).WithID(workspaceID), allow: true},
+			{resource: ResourceWorkspace.InOrg(defOrg).WithOwner(user.UserID).WithID(unusedID), allow: false},
+			{resource: ResourceWorkspace.InOrg(defOrg).WithOwner(user.UserID), allow: false},
+		}),
+		[]authTestCase{
+			// Types without an element in the allow list aren't limited.
+			{resource: ResourceOrganization.InOrg(defOrg).WithID(defOrg), actions: []Action{ActionRead}, allow: true},
+			{resource: ResourceOrganization.InOrg(defOrg).WithID(defOrg), actions: []Action{ActionUpdate}, allow: false},
+			{resource: ResourceWorkspace.InOrg(defOrg).WithOwner(user.UserID).WithID(workspaceID), actions: []Action{ActionDelete}, allow: false},
+		},
+	)
+
+	user = subject{
+		UserID: "me",
+		Roles: []Role{
+			must(RoleByName(RoleMember())),
+			must(RoleByName(RoleOrgMember(defOrg))),
+		},
+		Scope: must(ScopeWithAllowList{
+			Scope:     ScopeAll,
+			AllowList: []AllowListElement{{Type: ResourceWorkspace.Type, ID: workspaceID.String()}},
+		}.Expand()),
+	}
+
+	// Workspace entries in the allow list limit SSH and app access too.
+	testAuthorize(t, "User_ScopeAllowListWorkspaceAccess", user,
+		cases(func(c authTestCase) authTestCase {
+			c.actions = []Action{ActionCreate}
+			return c
+		}, []authTestCase{
+			{resource: ResourceWorkspaceExecution.InOrg(defOrg).WithOwner(user.UserID).WithID(workspaceID), allow: true},
+			{resource: ResourceWorkspaceExecution.InOrg(defOrg).WithOwner(user.UserID).WithID(unusedID), allow: false},
+			{resource: ResourceWorkspaceApplicationConnect.InOrg(defOrg).WithOwner(user.UserID).WithID(workspaceID), allow: true},
+			{resource: ResourceWorkspaceApplicationConnect.InOrg(defOrg).WithOwner(user.UserID).WithID(unusedID), allow: false},
+		}),
+	)
 }
 
 // cases applies a given function to all test cases. This makes generalities easier to create.
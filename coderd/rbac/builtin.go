@@ -57,6 +57,8 @@ var (
 	//
 	// This map will be replaced by database storage defined by this ticket.
 	// https://github.com/coder/coder/issues/1194
+	// Roles defined by admins are already stored in the database, see
+	// CustomRoles.
 	builtInRoles = map[string]func(orgID string) Role{
 		// admin grants all actions to all resources.
 		owner: func(_ string) Role {
@@ -97,8 +99,9 @@ var (
 				Site: permissions(map[string][]Action{
 					// Should be able to read all template details, even in orgs they
 					// are not in.
-					ResourceTemplate.Type: {ActionRead},
-					ResourceAuditLog.Type: {ActionRead},
+					ResourceTemplate.Type:                  {ActionRead},
+					ResourceAuditLog.Type:                  {ActionRead},
+					ResourceWorkspaceSessionRecording.Type: {ActionRead},
 				}),
 			}
 		},
@@ -218,6 +221,7 @@ func CanAssignRole(roles []string, assignedRole string) bool {
 	if err != nil {
 		return false
 	}
+	_, builtIn := builtInRoles[assigned]
 
 	for _, longRole := range roles {
 		role, orgID, err := roleSplit(longRole)
@@ -230,6 +234,15 @@ func CanAssignRole(roles []string, assignedRole string) bool {
 			continue
 		}
 
+		if !builtIn {
+			// Custom roles can grant any permission in their scope, so only
+			// the roles with every permission in the scope can assign them.
+			if role == owner || (role == orgAdmin && assignedOrg != "") {
+				return true
+			}
+			continue
+		}
+
 		allowed, ok := assignRoles[role]
 		if !ok {
 			continue
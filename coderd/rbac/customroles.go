@@ -0,0 +1,142 @@
+package rbac
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+)
+
+// CustomRoles is a set of roles defined by admins in addition to the builtin
+// roles. Custom roles are stored in the database, so each coderd keeps its own
+// set in sync with it. The Authorizer expands role names with the set in the
+// context, or the set it was created with.
+type CustomRoles struct {
+	mutex sync.RWMutex
+	roles map[string]Role
+}
+
+func NewCustomRoles() *CustomRoles {
+	return &CustomRoles{
+		roles: map[string]Role{},
+	}
+}
+
+// Replace replaces every role in the set. Organization roles must be named
+// "name:organization_id", like the builtin organization roles.
+func (c *CustomRoles) Replace(roles []Role) {
+	byName := make(map[string]Role, len(roles))
+	for _, role := range roles {
+		byName[role.Name] = role
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.roles = byName
+}
+
+// RoleByName is like the package level RoleByName, but it also returns the
+// custom roles in the set. A nil set only has the builtin roles.
+func (c *CustomRoles) RoleByName(name string) (Role, error) {
+	role, err := RoleByName(name)
+	if err == nil || c == nil {
+		return role, err
+	}
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	custom, ok := c.roles[name]
+	if !ok {
+		return Role{}, err
+	}
+	return custom, nil
+}
+
+func (c *CustomRoles) RolesByNames(roleNames []string) ([]Role, error) {
+	roles := make([]Role, 0, len(roleNames))
+	for _, n := range roleNames {
+		r, err := c.RoleByName(n)
+		if err != nil {
+			return nil, xerrors.Errorf("get role permissions: %w", err)
+		}
+		roles = append(roles, r)
+	}
+	return roles, nil
+}
+
+// SiteRoles lists the builtin and custom roles that can be applied to a user.
+func (c *CustomRoles) SiteRoles() []Role {
+	return append(SiteRoles(), c.list("")...)
+}
+
+// OrganizationRoles lists the builtin and custom roles that can be applied to
+// an organization user in the given organization.
+func (c *CustomRoles) OrganizationRoles(organizationID uuid.UUID) []Role {
+	return append(OrganizationRoles(organizationID), c.list(organizationID.String())...)
+}
+
+// list returns the custom roles scoped to the organization, or the site wide
+// roles if the organization is empty. Roles are sorted by name.
+func (c *CustomRoles) list(orgID string) []Role {
+	if c == nil {
+		return nil
+	}
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	roles := make([]Role, 0)
+	for name, role := range c.roles {
+		_, scope, err := roleSplit(name)
+		if err != nil {
+			continue
+		}
+		if scope == orgID {
+			roles = append(roles, role)
+		}
+	}
+	sort.Slice(roles, func(i, j int) bool {
+		return roles[i].Name < roles[j].Name
+	})
+	return roles
+}
+
+type customRolesContextKey struct{}
+
+// WithCustomRoles returns a context that expands role names with the custom
+// roles in the set.
+func WithCustomRoles(ctx context.Context, roles *CustomRoles) context.Context {
+	return context.WithValue(ctx, customRolesContextKey{}, roles)
+}
+
+// CustomRolesFromContext returns the custom roles attached to the context, or
+// nil if there aren't any.
+func CustomRolesFromContext(ctx context.Context) *CustomRoles {
+	roles, _ := ctx.Value(customRolesContextKey{}).(*CustomRoles)
+	return roles
+}
+
+// IsBuiltInRole returns true if the role name, without an organization id,
+// is one of the builtin roles. Custom roles can't reuse these names.
+func IsBuiltInRole(name string) bool {
+	_, ok := builtInRoles[name]
+	return ok
+}
+
+// ValidatePermission returns an error if the permission isn't for one of the
+// resource types and actions, or the wildcard.
+func ValidatePermission(permission Permission) error {
+	switch permission.Action {
+	case ActionCreate, ActionRead, ActionUpdate, ActionDelete, WildcardSymbol:
+	default:
+		return xerrors.Errorf("unknown action %q", permission.Action)
+	}
+
+	for _, resource := range AllResources() {
+		if resource.Type == permission.ResourceType {
+			return nil
+		}
+	}
+	return xerrors.Errorf("unknown resource type %q", permission.ResourceType)
+}
@@ -0,0 +1,129 @@
+package rbac_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/rbac"
+)
+
+func TestCustomRoles(t *testing.T) {
+	t.Parallel()
+
+	orgID := uuid.New()
+	siteRole := rbac.Role{
+		Name:        "log-reader",
+		DisplayName: "Log Reader",
+		Site: []rbac.Permission{{
+			ResourceType: rbac.ResourceAuditLog.Type,
+			Action:       rbac.ActionRead,
+		}},
+	}
+	orgRole := rbac.Role{
+		Name:        "template-reader:" + orgID.String(),
+		DisplayName: "Template Reader",
+		Org: map[string][]rbac.Permission{
+			orgID.String(): {{
+				ResourceType: rbac.ResourceTemplate.Type,
+				Action:       rbac.ActionRead,
+			}},
+		},
+	}
+	roles := rbac.NewCustomRoles()
+	roles.Replace([]rbac.Role{siteRole, orgRole})
+
+	t.Run("RoleByName", func(t *testing.T) {
+		t.Parallel()
+
+		role, err := roles.RoleByName(siteRole.Name)
+		require.NoError(t, err)
+		require.Equal(t, siteRole, role)
+		role, err = roles.RoleByName(orgRole.Name)
+		require.NoError(t, err)
+		require.Equal(t, orgRole, role)
+		role, err = roles.RoleByName(rbac.RoleOwner())
+		require.NoError(t, err)
+		require.Equal(t, rbac.RoleOwner(), role.Name)
+
+		_, err = roles.RoleByName("missing")
+		require.Error(t, err)
+		// Custom roles aren't builtin.
+		_, err = rbac.RoleByName(siteRole.Name)
+		require.Error(t, err)
+		// A nil set only has the builtin roles.
+		var nilRoles *rbac.CustomRoles
+		_, err = nilRoles.RoleByName(siteRole.Name)
+		require.Error(t, err)
+		_, err = nilRoles.RoleByName(rbac.RoleMember())
+		require.NoError(t, err)
+	})
+
+	t.Run("List", func(t *testing.T) {
+		t.Parallel()
+
+		require.Contains(t, roles.SiteRoles(), siteRole)
+		require.NotContains(t, roles.SiteRoles(), orgRole)
+		require.Len(t, roles.SiteRoles(), len(rbac.SiteRoles())+1)
+		require.Contains(t, roles.OrganizationRoles(orgID), orgRole)
+		require.NotContains(t, roles.OrganizationRoles(uuid.New()), orgRole)
+	})
+
+	t.Run("Authorize", func(t *testing.T) {
+		t.Parallel()
+
+		auth := rbac.NewAuthorizer()
+		subjectID := uuid.NewString()
+		subjectRoles := []string{rbac.RoleMember(), siteRole.Name}
+
+		// Without the custom roles in the context the role is unknown.
+		err := auth.ByRoleName(context.Background(), subjectID, subjectRoles, rbac.ScopeAll, nil, rbac.ActionRead, rbac.ResourceAuditLog)
+		require.Error(t, err)
+
+		ctx := rbac.WithCustomRoles(context.Background(), roles)
+		err = auth.ByRoleName(ctx, subjectID, subjectRoles, rbac.ScopeAll, nil, rbac.ActionRead, rbac.ResourceAuditLog)
+		require.NoError(t, err)
+		err = auth.ByRoleName(ctx, subjectID, subjectRoles, rbac.ScopeAll, nil, rbac.ActionDelete, rbac.ResourceAuditLog)
+		require.Error(t, err)
+
+		subjectRoles = []string{rbac.RoleMember(), rbac.RoleOrgMember(orgID), orgRole.Name}
+		err = auth.ByRoleName(ctx, subjectID, subjectRoles, rbac.ScopeAll, nil, rbac.ActionRead, rbac.ResourceTemplate.InOrg(orgID))
+		require.NoError(t, err)
+		err = auth.ByRoleName(ctx, subjectID, subjectRoles, rbac.ScopeAll, nil, rbac.ActionRead, rbac.ResourceTemplate.InOrg(uuid.New()))
+		require.Error(t, err)
+	})
+
+	t.Run("AuthorizeWithoutContext", func(t *testing.T) {
+		t.Parallel()
+
+		// Authorizers created with the set expand custom roles outside of
+		// requests too.
+		auth := rbac.NewAuthorizerWithCustomRoles(roles)
+		subjectID := uuid.NewString()
+		subjectRoles := []string{rbac.RoleMember(), siteRole.Name}
+		err := auth.ByRoleName(context.Background(), subjectID, subjectRoles, rbac.ScopeAll, nil, rbac.ActionRead, rbac.ResourceAuditLog)
+		require.NoError(t, err)
+		prepared, err := auth.PrepareByRoleName(context.Background(), subjectID, subjectRoles, rbac.ScopeAll, nil, rbac.ActionRead, rbac.ResourceAuditLog.Type)
+		require.NoError(t, err)
+		err = prepared.Authorize(context.Background(), rbac.ResourceAuditLog)
+		require.NoError(t, err)
+
+		// The set in the context takes precedence.
+		ctx := rbac.WithCustomRoles(context.Background(), rbac.NewCustomRoles())
+		err = auth.ByRoleName(ctx, subjectID, subjectRoles, rbac.ScopeAll, nil, rbac.ActionRead, rbac.ResourceAuditLog)
+		require.Error(t, err)
+	})
+
+	t.Run("CanAssign", func(t *testing.T) {
+		t.Parallel()
+
+		require.True(t, rbac.CanAssignRole([]string{rbac.RoleOwner()}, siteRole.Name))
+		require.True(t, rbac.CanAssignRole([]string{rbac.RoleOwner()}, orgRole.Name))
+		require.False(t, rbac.CanAssignRole([]string{rbac.RoleUserAdmin()}, siteRole.Name))
+		require.False(t, rbac.CanAssignRole([]string{rbac.RoleOrgAdmin(orgID)}, siteRole.Name))
+		require.True(t, rbac.CanAssignRole([]string{rbac.RoleOrgAdmin(orgID)}, orgRole.Name))
+		require.False(t, rbac.CanAssignRole([]string{rbac.RoleOrgAdmin(uuid.New())}, orgRole.Name))
+	})
+}
@@ -62,10 +62,26 @@ var (
 		Type: "group",
 	}
 
+	// ResourceWebhook CRUD. Org admins only.
+	//	create/delete = Register or remove a webhook.
+	//	update = Change the url, name or events of a webhook.
+	//	read = Read webhooks and their deliveries.
+	ResourceWebhook = Object{
+		Type: "webhook",
+	}
+
 	ResourceFile = Object{
 		Type: "file",
 	}
 
+	// ResourceWorkspaceSessionRecording is a recording of a terminal session
+	// in a workspace. Recordings are not owned by the workspace owner, so
+	// only admins and auditors can watch them.
+	//	read = List and play back recordings.
+	ResourceWorkspaceSessionRecording = Object{
+		Type: "workspace_session_recording",
+	}
+
 	ResourceProvisionerDaemon = Object{
 		Type: "provisioner_daemon",
 	}
@@ -95,6 +111,15 @@ var (
 		Type: "assign_org_role",
 	}
 
+	// ResourceCustomRole is a role defined by an admin. Site wide roles never
+	// have an org, organization roles are in the org they're scoped to.
+	//	create/delete = Define or remove a role.
+	//	update = Change the display name or permissions of a role.
+	//	read = View roles and their permissions.
+	ResourceCustomRole = Object{
+		Type: "custom_role",
+	}
+
 	// ResourceAPIKey is owned by a user.
 	//	create  = Create a new api key for user
 	//	update  = ??
@@ -152,12 +177,43 @@ var (
 	}
 )
 
+// AllResources returns every resource, including the wildcard.
+func AllResources() []Object {
+	return []Object{
+		ResourceWorkspace,
+		ResourceWorkspaceExecution,
+		ResourceWorkspaceApplicationConnect,
+		ResourceAuditLog,
+		ResourceTemplate,
+		ResourceGroup,
+		ResourceWebhook,
+		ResourceFile,
+		ResourceWorkspaceSessionRecording,
+		ResourceProvisionerDaemon,
+		ResourceOrganization,
+		ResourceRoleAssignment,
+		ResourceOrgRoleAssignment,
+		ResourceCustomRole,
+		ResourceAPIKey,
+		ResourceUser,
+		ResourceUserData,
+		ResourceOrganizationMember,
+		ResourceWildcard,
+		ResourceLicense,
+		ResourceDeploymentConfig,
+		ResourceReplicas,
+	}
+}
+
 // Object is used to create objects for authz checks when you have none in
 // hand to run the check on.
 // An example is if you want to list all workspaces, you can create a Object
 // that represents the set of workspaces you are trying to get access too.
 // Do not export this type, as it can be created from a resource type constant.
 type Object struct {
+	// ID is the unique identifier of the object. It's only used to limit
+	// scopes to specific resources, so not every object sets it.
+	ID    string `json:"id"`
 	Owner string `json:"owner"`
 	// OrgID specifies which org the object is a part of.
 	OrgID string `json:"org_owner"`
@@ -176,6 +232,7 @@ func (z Object) RBACObject() Object {
 // All returns an object matching all resources of the same type.
 func (z Object) All() Object {
 	return Object{
+		ID:           "",
 		Owner:        "",
 		OrgID:        "",
 		Type:         z.Type,
@@ -184,9 +241,22 @@ func (z Object) All() Object {
 	}
 }
 
+// WithID adds the ID of the object, so scopes can be limited to it.
+func (z Object) WithID(id uuid.UUID) Object {
+	return Object{
+		ID:           id.String(),
+		Owner:        z.Owner,
+		OrgID:        z.OrgID,
+		Type:         z.Type,
+		ACLUserList:  z.ACLUserList,
+		ACLGroupList: z.ACLGroupList,
+	}
+}
+
 // InOrg adds an org OwnerID to the resource
 func (z Object) InOrg(orgID uuid.UUID) Object {
 	return Object{
+		ID:           z.ID,
 		Owner:        z.Owner,
 		OrgID:        orgID.String(),
 		Type:         z.Type,
@@ -198,6 +268,7 @@ func (z Object) InOrg(orgID uuid.UUID) Object {
 // WithOwner adds an OwnerID to the resource
 func (z Object) WithOwner(ownerID string) Object {
 	return Object{
+		ID:           z.ID,
 		Owner:        ownerID,
 		OrgID:        z.OrgID,
 		Type:         z.Type,
@@ -209,6 +280,7 @@ func (z Object) WithOwner(ownerID string) Object {
 // WithACLUserList adds an ACL list to a given object
 func (z Object) WithACLUserList(acl map[string][]Action) Object {
 	return Object{
+		ID:           z.ID,
 		Owner:        z.Owner,
 		OrgID:        z.OrgID,
 		Type:         z.Type,
@@ -219,6 +291,7 @@ func (z Object) WithACLUserList(acl map[string][]Action) Object {
 
 func (z Object) WithGroupACL(groups map[string][]Action) Object {
 	return Object{
+		ID:           z.ID,
 		Owner:        z.Owner,
 		OrgID:        z.OrgID,
 		Type:         z.Type,
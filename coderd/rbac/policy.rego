@@ -3,7 +3,7 @@ import future.keywords
 # A great playground: https://play.openpolicyagent.org/
 # Helpful cli commands to debug.
 # opa eval --format=pretty 'data.authz.allow' -d policy.rego  -i input.json
-# opa eval --partial --format=pretty 'data.authz.allow' -d policy.rego --unknowns input.object.owner --unknowns input.object.org_owner --unknowns input.object.acl_user_list --unknowns input.object.acl_group_list -i input.json
+# opa eval --partial --format=pretty 'data.authz.allow' -d policy.rego --unknowns input.object.id --unknowns input.object.owner --unknowns input.object.org_owner --unknowns input.object.acl_user_list --unknowns input.object.acl_group_list -i input.json
 
 #
 # This policy is specifically constructed to compress to a set of queries if the
@@ -196,6 +196,35 @@ scope_allow {
 	scope_user = 1
 }
 
+# The allow list of the scope limits it to specific resources. Resource types
+# without an element in the allow list aren't limited. Only known fields are
+# iterated over, so the object's 'id' compresses to simple queries.
+scope_allow_list_types := { element.type |
+	element := input.subject.scope.allow_list[_]
+}
+
+# Executing in and connecting to the apps of a workspace are limited by the
+# workspace entries of the allow list, their objects have the workspace ID.
+scope_allow_list_object_type := "workspace" {
+	input.object.type in {"workspace_execution", "application_connect"}
+} else := input.object.type
+
+scope_allow_list {
+	not scope_allow_list_object_type in scope_allow_list_types
+}
+
+scope_allow_list {
+	element := input.subject.scope.allow_list[_]
+	element.type == scope_allow_list_object_type
+	element.id == "*"
+}
+
+scope_allow_list {
+	element := input.subject.scope.allow_list[_]
+	element.type == scope_allow_list_object_type
+	element.id == input.object.id
+}
+
 # ACL for users
 acl_allow {
 	# Should you have to be a member of the org too?
@@ -230,10 +259,12 @@ acl_allow {
 allow {
 	role_allow
 	scope_allow
+	scope_allow_list
 }
 
 # ACL list must also have the scope_allow to pass
 allow {
 	acl_allow
 	scope_allow
+	scope_allow_list
 }
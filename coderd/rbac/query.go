@@ -78,6 +78,11 @@ func DefaultConfig() SQLConfig {
 				ColumnSelect: "owner_id :: text",
 				Type:         VarTypeText,
 			},
+			{
+				RegoMatch:    regexp.MustCompile(`^input\.object\.id$`),
+				ColumnSelect: "id :: text",
+				Type:         VarTypeText,
+			},
 		},
 	}
 }
@@ -105,6 +110,11 @@ func NoACLConfig() SQLConfig {
 				ColumnSelect: "owner_id :: text",
 				Type:         VarTypeText,
 			},
+			{
+				RegoMatch:    regexp.MustCompile(`^input\.object\.id$`),
+				ColumnSelect: "id :: text",
+				Type:         VarTypeText,
+			},
 		},
 	}
 }
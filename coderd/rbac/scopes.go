@@ -11,6 +11,9 @@ type Scope string
 const (
 	ScopeAll                Scope = "all"
 	ScopeApplicationConnect Scope = "application_connect"
+	ScopeRead               Scope = "read"
+	ScopeWorkspaceStartStop Scope = "workspace_start_stop"
+	ScopeTemplatePush       Scope = "template_push"
 )
 
 var builtinScopes map[Scope]Role = map[Scope]Role{
@@ -35,6 +38,42 @@ var builtinScopes map[Scope]Role = map[Scope]Role{
 		Org:  map[string][]Permission{},
 		User: []Permission{},
 	},
+
+	ScopeRead: {
+		Name:        fmt.Sprintf("Scope_%s", ScopeRead),
+		DisplayName: "Read-only access to all resources",
+		Site: permissions(map[string][]Action{
+			ResourceWildcard.Type: {ActionRead},
+		}),
+		Org:  map[string][]Permission{},
+		User: []Permission{},
+	},
+
+	ScopeWorkspaceStartStop: {
+		Name:        fmt.Sprintf("Scope_%s", ScopeWorkspaceStartStop),
+		DisplayName: "Ability to start and stop workspaces",
+		Site: permissions(map[string][]Action{
+			ResourceWorkspace.Type:    {ActionRead, ActionUpdate},
+			ResourceTemplate.Type:     {ActionRead},
+			ResourceOrganization.Type: {ActionRead},
+			ResourceUser.Type:         {ActionRead},
+		}),
+		Org:  map[string][]Permission{},
+		User: []Permission{},
+	},
+
+	ScopeTemplatePush: {
+		Name:        fmt.Sprintf("Scope_%s", ScopeTemplatePush),
+		DisplayName: "Ability to create and update templates",
+		Site: permissions(map[string][]Action{
+			ResourceTemplate.Type:     {ActionCreate, ActionRead, ActionUpdate},
+			ResourceFile.Type:         {ActionCreate, ActionRead},
+			ResourceOrganization.Type: {ActionRead},
+			ResourceUser.Type:         {ActionRead},
+		}),
+		Org:  map[string][]Permission{},
+		User: []Permission{},
+	},
 }
 
 func ScopeRole(scope Scope) (Role, error) {
@@ -44,3 +83,58 @@ func ScopeRole(scope Scope) (Role, error) {
 	}
 	return role, nil
 }
+
+// ExpandableScope is a scope that can be expanded into the role it grants and
+// the resources it's limited to.
+type ExpandableScope interface {
+	Expand() (ExpandedScope, error)
+}
+
+// ExpandedScope is the role granted by a scope, limited to the resources in
+// its allow list.
+type ExpandedScope struct {
+	Role
+	// AllowList limits the scope to specific resources. Resource types
+	// without an element in the list aren't limited.
+	AllowList []AllowListElement `json:"allow_list"`
+}
+
+// AllowListElement allows a scope to access a resource, or every resource of
+// the type if the ID is the wildcard symbol.
+type AllowListElement struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// AllowListTypes are the resource types that can be in the allow list of a
+// scope. Only their objects have an ID. Workspace entries also limit executing
+// in and connecting to the apps of workspaces.
+var AllowListTypes = []string{
+	ResourceWorkspace.Type,
+	ResourceTemplate.Type,
+}
+
+// Expand returns the builtin scope without an allow list.
+func (s Scope) Expand() (ExpandedScope, error) {
+	role, err := ScopeRole(s)
+	if err != nil {
+		return ExpandedScope{}, err
+	}
+	return ExpandedScope{Role: role}, nil
+}
+
+// ScopeWithAllowList is a builtin scope limited to the resources in the allow
+// list.
+type ScopeWithAllowList struct {
+	Scope     Scope              `json:"scope"`
+	AllowList []AllowListElement `json:"allow_list"`
+}
+
+func (s ScopeWithAllowList) Expand() (ExpandedScope, error) {
+	expanded, err := s.Scope.Expand()
+	if err != nil {
+		return ExpandedScope{}, err
+	}
+	expanded.AllowList = s.AllowList
+	return expanded, nil
+}
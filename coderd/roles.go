@@ -1,8 +1,19 @@
 package coderd
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 	"net/http"
 
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+
+	"github.com/coder/coder/coderd/audit"
+	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/coderd/httpmw"
 	"github.com/coder/coder/codersdk"
 
@@ -19,7 +30,7 @@ func (api *API) assignableSiteRoles(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	roles := rbac.SiteRoles()
+	roles := api.CustomRoles.SiteRoles()
 	httpapi.Write(ctx, rw, http.StatusOK, assignableRoles(actorRoles.Roles, roles))
 }
 
@@ -34,7 +45,7 @@ func (api *API) assignableOrgRoles(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	roles := rbac.OrganizationRoles(organization.ID)
+	roles := api.CustomRoles.OrganizationRoles(organization.ID)
 	httpapi.Write(ctx, rw, http.StatusOK, assignableRoles(actorRoles.Roles, roles))
 }
 
@@ -61,3 +72,395 @@ func assignableRoles(actorRoles []string, roles []rbac.Role) []codersdk.Assignab
 	}
 	return assignable
 }
+
+const customRolesPubsubEvent = "custom_roles"
+
+// reloadCustomRoles replaces the custom roles of the API with the roles in the
+// database.
+func (api *API) reloadCustomRoles(ctx context.Context) error {
+	roles, err := api.Database.GetCustomRoles(ctx)
+	if err != nil {
+		return xerrors.Errorf("get custom roles: %w", err)
+	}
+	rbacRoles := make([]rbac.Role, 0, len(roles))
+	for _, role := range roles {
+		rbacRoles = append(rbacRoles, role.RBACRole())
+	}
+	api.CustomRoles.Replace(rbacRoles)
+	return nil
+}
+
+// customRolesChanged reloads the custom roles of the API, and notifies other
+// replicas to do the same.
+func (api *API) customRolesChanged(ctx context.Context) {
+	err := api.reloadCustomRoles(ctx)
+	if err != nil {
+		api.Logger.Error(ctx, "reload custom roles", slog.Error(err))
+	}
+	err = api.Pubsub.Publish(customRolesPubsubEvent, nil)
+	if err != nil {
+		api.Logger.Error(ctx, "publish custom roles changed", slog.Error(err))
+	}
+}
+
+// customRoles lists the custom roles the user can read.
+func (api *API) customRoles(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	roles, err := api.Database.GetCustomRoles(ctx)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching roles.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	roles, err = AuthorizeFilter(api.HTTPAuth, r, rbac.ActionRead, roles)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching roles.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	converted := make([]codersdk.CustomRole, 0, len(roles))
+	for _, role := range roles {
+		converted = append(converted, convertCustomRole(role))
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, converted)
+}
+
+func (api *API) customRole(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx  = r.Context()
+		role = httpmw.CustomRoleParam(r)
+	)
+	if !api.Authorize(r, rbac.ActionRead, role) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, convertCustomRole(role))
+}
+
+func (api *API) postCustomRole(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx               = r.Context()
+		auditor           = *api.Auditor.Load()
+		aReq, commitAudit = audit.InitRequest[database.CustomRole](rw, &audit.RequestParams{
+			Audit:   auditor,
+			Log:     api.Logger,
+			Request: r,
+			Action:  database.AuditActionCreate,
+		})
+	)
+	defer commitAudit()
+
+	var req codersdk.CreateCustomRoleRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+	// Organization roles are stored as "name:organization_id", so names
+	// can't contain separators.
+	if err := httpapi.NameValid(req.Name); err != nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: fmt.Sprintf("Invalid role name %q.", req.Name),
+			Validations: []codersdk.ValidationError{
+				{Field: "name", Detail: err.Error()},
+			},
+		})
+		return
+	}
+
+	params := database.InsertCustomRoleParams{
+		ID:              uuid.New(),
+		Name:            req.Name,
+		DisplayName:     req.DisplayName,
+		SitePermissions: convertPermissions(req.SitePermissions),
+		OrgPermissions:  convertPermissions(req.OrgPermissions),
+		UserPermissions: convertPermissions(req.UserPermissions),
+		CreatedAt:       database.Now(),
+		UpdatedAt:       database.Now(),
+	}
+	if req.OrganizationID != nil {
+		params.OrganizationID = uuid.NullUUID{UUID: *req.OrganizationID, Valid: true}
+	}
+	if params.DisplayName == "" {
+		params.DisplayName = params.Name
+	}
+	role := database.CustomRole{
+		Name:            params.Name,
+		OrganizationID:  params.OrganizationID,
+		SitePermissions: params.SitePermissions,
+		OrgPermissions:  params.OrgPermissions,
+		UserPermissions: params.UserPermissions,
+	}
+	if !api.Authorize(r, rbac.ActionCreate, role) {
+		httpapi.Forbidden(rw)
+		return
+	}
+	if rbac.IsBuiltInRole(req.Name) {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: fmt.Sprintf("%q is the name of a builtin role.", req.Name),
+		})
+		return
+	}
+	if !validateCustomRolePermissions(ctx, rw, role) {
+		return
+	}
+	if params.OrganizationID.Valid {
+		_, err := api.Database.GetOrganizationByID(ctx, params.OrganizationID.UUID)
+		if errors.Is(err, sql.ErrNoRows) {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: fmt.Sprintf("Organization %q does not exist.", params.OrganizationID.UUID),
+			})
+			return
+		}
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+				Message: "Internal error fetching organization.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+	}
+
+	inserted, err := api.Database.InsertCustomRole(ctx, params)
+	if database.IsUniqueViolation(err) {
+		httpapi.Write(ctx, rw, http.StatusConflict, codersdk.Response{
+			Message: fmt.Sprintf("Role with name %q already exists.", params.Name),
+		})
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error creating role.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	aReq.New = inserted
+	api.customRolesChanged(ctx)
+
+	httpapi.Write(ctx, rw, http.StatusCreated, convertCustomRole(inserted))
+}
+
+func (api *API) patchCustomRole(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx               = r.Context()
+		role              = httpmw.CustomRoleParam(r)
+		auditor           = *api.Auditor.Load()
+		aReq, commitAudit = audit.InitRequest[database.CustomRole](rw, &audit.RequestParams{
+			Audit:   auditor,
+			Log:     api.Logger,
+			Request: r,
+			Action:  database.AuditActionWrite,
+		})
+	)
+	defer commitAudit()
+	aReq.Old = role
+
+	if !api.Authorize(r, rbac.ActionUpdate, role) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	var req codersdk.UpdateCustomRoleRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+	if req.DisplayName != nil {
+		role.DisplayName = *req.DisplayName
+	}
+	if req.SitePermissions != nil {
+		role.SitePermissions = convertPermissions(*req.SitePermissions)
+	}
+	if req.OrgPermissions != nil {
+		role.OrgPermissions = convertPermissions(*req.OrgPermissions)
+	}
+	if req.UserPermissions != nil {
+		role.UserPermissions = convertPermissions(*req.UserPermissions)
+	}
+	if role.DisplayName == "" {
+		role.DisplayName = role.Name
+	}
+	if !validateCustomRolePermissions(ctx, rw, role) {
+		return
+	}
+
+	updated, err := api.Database.UpdateCustomRole(ctx, database.UpdateCustomRoleParams{
+		ID:              role.ID,
+		DisplayName:     role.DisplayName,
+		SitePermissions: role.SitePermissions,
+		OrgPermissions:  role.OrgPermissions,
+		UserPermissions: role.UserPermissions,
+		UpdatedAt:       database.Now(),
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error updating role.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	aReq.New = updated
+	api.customRolesChanged(ctx)
+
+	httpapi.Write(ctx, rw, http.StatusOK, convertCustomRole(updated))
+}
+
+func (api *API) deleteCustomRole(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx               = r.Context()
+		role              = httpmw.CustomRoleParam(r)
+		auditor           = *api.Auditor.Load()
+		aReq, commitAudit = audit.InitRequest[database.CustomRole](rw, &audit.RequestParams{
+			Audit:   auditor,
+			Log:     api.Logger,
+			Request: r,
+			Action:  database.AuditActionDelete,
+		})
+	)
+	defer commitAudit()
+	aReq.Old = role
+
+	if !api.Authorize(r, rbac.ActionDelete, role) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	// Users keep the names of their roles, and roles that don't exist fail
+	// authorization. So the role is unassigned from everyone first.
+	err := api.Database.InTx(func(tx database.Store) error {
+		var err error
+		if role.OrganizationID.Valid {
+			err = tx.UnassignOrganizationRole(ctx, database.UnassignOrganizationRoleParams{
+				RoleName:       role.RoleName(),
+				OrganizationID: role.OrganizationID.UUID,
+			})
+		} else {
+			err = tx.UnassignSiteRole(ctx, role.RoleName())
+		}
+		if err != nil {
+			return xerrors.Errorf("unassign role: %w", err)
+		}
+		return tx.DeleteCustomRole(ctx, role.ID)
+	}, nil)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error deleting role.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	api.customRolesChanged(ctx)
+
+	httpapi.Write(ctx, rw, http.StatusOK, codersdk.Response{
+		Message: "Role has been deleted!",
+	})
+}
+
+// validateCustomRolePermissions writes an error if the permissions of the
+// role are invalid. Organization roles can only grant permissions in their
+// organization, and site wide roles can't grant permissions in one.
+func validateCustomRolePermissions(ctx context.Context, rw http.ResponseWriter, role database.CustomRole) bool {
+	var validations []codersdk.ValidationError
+	validate := func(field string, permissions []rbac.Permission) {
+		for _, permission := range permissions {
+			err := rbac.ValidatePermission(permission)
+			if err != nil {
+				validations = append(validations, codersdk.ValidationError{
+					Field:  field,
+					Detail: err.Error(),
+				})
+			}
+		}
+	}
+	validate("site_permissions", role.SitePermissions)
+	validate("org_permissions", role.OrgPermissions)
+	validate("user_permissions", role.UserPermissions)
+
+	if role.OrganizationID.Valid {
+		if len(role.SitePermissions) > 0 {
+			validations = append(validations, codersdk.ValidationError{
+				Field:  "site_permissions",
+				Detail: "Organization roles can't have site permissions.",
+			})
+		}
+		if len(role.UserPermissions) > 0 {
+			validations = append(validations, codersdk.ValidationError{
+				Field:  "user_permissions",
+				Detail: "Organization roles can't have user permissions.",
+			})
+		}
+	} else if len(role.OrgPermissions) > 0 {
+		validations = append(validations, codersdk.ValidationError{
+			Field:  "org_permissions",
+			Detail: "Only organization roles can have org permissions.",
+		})
+	}
+
+	if len(validations) > 0 {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message:     "Invalid role permissions.",
+			Validations: validations,
+		})
+		return false
+	}
+	return true
+}
+
+func convertPermissions(permissions []codersdk.Permission) database.CustomRolePermissions {
+	converted := make(database.CustomRolePermissions, 0, len(permissions))
+	for _, permission := range permissions {
+		converted = append(converted, rbac.Permission{
+			Negate:       permission.Negate,
+			ResourceType: permission.ResourceType,
+			Action:       rbac.Action(permission.Action),
+		})
+	}
+	return converted
+}
+
+func convertCustomRole(role database.CustomRole) codersdk.CustomRole {
+	convert := func(permissions []rbac.Permission) []codersdk.Permission {
+		converted := make([]codersdk.Permission, 0, len(permissions))
+		for _, permission := range permissions {
+			converted = append(converted, codersdk.Permission{
+				Negate:       permission.Negate,
+				ResourceType: permission.ResourceType,
+				Action:       string(permission.Action),
+			})
+		}
+		return converted
+	}
+
+	converted := codersdk.CustomRole{
+		ID:              role.ID,
+		Name:            role.RoleName(),
+		DisplayName:     role.DisplayName,
+		SitePermissions: convert(role.SitePermissions),
+		OrgPermissions:  convert(role.OrgPermissions),
+		UserPermissions: convert(role.UserPermissions),
+		CreatedAt:       role.CreatedAt,
+		UpdatedAt:       role.UpdatedAt,
+	}
+	if role.OrganizationID.Valid {
+		converted.OrganizationID = &role.OrganizationID.UUID
+	}
+	return converted
+}
+
+// convertRoleName converts a role assigned to a user. Roles that no longer
+// exist, like deleted custom roles, are converted with just their name.
+func convertRoleName(customRoles *rbac.CustomRoles, roleName string) codersdk.Role {
+	rbacRole, err := customRoles.RoleByName(roleName)
+	if err != nil {
+		return codersdk.Role{
+			Name:        roleName,
+			DisplayName: roleName,
+		}
+	}
+	return convertRole(rbacRole)
+}
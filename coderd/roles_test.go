@@ -7,7 +7,9 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/coder/coder/coderd/audit"
 	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/coderd/rbac"
 	"github.com/coder/coder/codersdk"
 	"github.com/coder/coder/testutil"
@@ -143,6 +145,217 @@ func TestListRoles(t *testing.T) {
 	}
 }
 
+func TestCustomRoles(t *testing.T) {
+	t.Parallel()
+
+	readAuditLogs := []codersdk.Permission{{
+		ResourceType: rbac.ResourceAuditLog.Type,
+		Action:       rbac.ActionRead,
+	}}
+
+	t.Run("CRUD", func(t *testing.T) {
+		t.Parallel()
+
+		auditor := audit.NewMock()
+		client := coderdtest.New(t, &coderdtest.Options{Auditor: auditor})
+		_ = coderdtest.CreateFirstUser(t, client)
+		ctx, _ := testutil.Context(t)
+
+		role, err := client.CreateCustomRole(ctx, codersdk.CreateCustomRoleRequest{
+			Name:            "log-reader",
+			SitePermissions: readAuditLogs,
+		})
+		require.NoError(t, err)
+		require.Equal(t, "log-reader", role.Name)
+		// The display name defaults to the name.
+		require.Equal(t, "log-reader", role.DisplayName)
+		require.Nil(t, role.OrganizationID)
+		require.Equal(t, readAuditLogs, role.SitePermissions)
+		require.Equal(t, database.AuditActionCreate, auditor.AuditLogs[len(auditor.AuditLogs)-1].Action)
+		require.Equal(t, database.ResourceTypeCustomRole, auditor.AuditLogs[len(auditor.AuditLogs)-1].ResourceType)
+
+		_, err = client.CreateCustomRole(ctx, codersdk.CreateCustomRoleRequest{
+			Name: "log-reader",
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusConflict, apiErr.StatusCode())
+
+		roles, err := client.CustomRoles(ctx)
+		require.NoError(t, err)
+		require.Len(t, roles, 1)
+		require.Equal(t, role.ID, roles[0].ID)
+
+		displayName := "Log Reader"
+		role, err = client.UpdateCustomRole(ctx, role.Name, codersdk.UpdateCustomRoleRequest{
+			DisplayName: &displayName,
+		})
+		require.NoError(t, err)
+		require.Equal(t, displayName, role.DisplayName)
+		// Unset permissions are left unchanged.
+		require.Equal(t, readAuditLogs, role.SitePermissions)
+		require.Equal(t, database.AuditActionWrite, auditor.AuditLogs[len(auditor.AuditLogs)-1].Action)
+
+		role, err = client.CustomRole(ctx, role.Name)
+		require.NoError(t, err)
+		require.Equal(t, displayName, role.DisplayName)
+
+		err = client.DeleteCustomRole(ctx, role.Name)
+		require.NoError(t, err)
+		require.Equal(t, database.AuditActionDelete, auditor.AuditLogs[len(auditor.AuditLogs)-1].Action)
+		_, err = client.CustomRole(ctx, role.Name)
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		t.Parallel()
+
+		client := coderdtest.New(t, nil)
+		admin := coderdtest.CreateFirstUser(t, client)
+		ctx, _ := testutil.Context(t)
+
+		for _, req := range []codersdk.CreateCustomRoleRequest{
+			// Builtin roles can't be redefined.
+			{Name: "auditor"},
+			{Name: "organization-admin", OrganizationID: &admin.OrganizationID},
+			{Name: ""},
+			{Name: "not a name"},
+			{Name: "reader:" + admin.OrganizationID.String()},
+			{Name: "unknown-resource", SitePermissions: []codersdk.Permission{{
+				ResourceType: "spaceship",
+				Action:       rbac.ActionRead,
+			}}},
+			{Name: "unknown-action", SitePermissions: []codersdk.Permission{{
+				ResourceType: rbac.ResourceTemplate.Type,
+				Action:       "launch",
+			}}},
+			// Site roles can't have org permissions, and the other way around.
+			{Name: "site-with-org", OrgPermissions: readAuditLogs},
+			{Name: "org-with-site", OrganizationID: &admin.OrganizationID, SitePermissions: readAuditLogs},
+			{Name: "org-with-user", OrganizationID: &admin.OrganizationID, UserPermissions: readAuditLogs},
+		} {
+			_, err := client.CreateCustomRole(ctx, req)
+			var apiErr *codersdk.Error
+			require.ErrorAs(t, err, &apiErr, req.Name)
+			require.Equal(t, http.StatusBadRequest, apiErr.StatusCode(), req.Name)
+		}
+	})
+
+	t.Run("AssignSiteRole", func(t *testing.T) {
+		t.Parallel()
+
+		client := coderdtest.New(t, nil)
+		admin := coderdtest.CreateFirstUser(t, client)
+		member := coderdtest.CreateAnotherUser(t, client, admin.OrganizationID)
+		userAdmin := coderdtest.CreateAnotherUser(t, client, admin.OrganizationID, rbac.RoleUserAdmin())
+		ctx, _ := testutil.Context(t)
+
+		role, err := client.CreateCustomRole(ctx, codersdk.CreateCustomRoleRequest{
+			Name:            "log-reader",
+			DisplayName:     "Log Reader",
+			SitePermissions: readAuditLogs,
+		})
+		require.NoError(t, err)
+
+		_, err = member.AuditLogs(ctx, codersdk.AuditLogsRequest{})
+		require.Error(t, err, "members can't read audit logs")
+
+		roles, err := client.ListSiteRoles(ctx)
+		require.NoError(t, err)
+		require.Contains(t, roles, codersdk.AssignableRoles{
+			Role:       codersdk.Role{Name: role.Name, DisplayName: role.DisplayName},
+			Assignable: true,
+		})
+
+		memberUser, err := member.User(ctx, codersdk.Me)
+		require.NoError(t, err)
+
+		// Custom roles can grant anything, so only owners can assign them.
+		_, err = userAdmin.UpdateUserRoles(ctx, memberUser.ID.String(), codersdk.UpdateRoles{
+			Roles: []string{role.Name},
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusForbidden, apiErr.StatusCode())
+
+		memberUser, err = client.UpdateUserRoles(ctx, memberUser.ID.String(), codersdk.UpdateRoles{
+			Roles: []string{role.Name},
+		})
+		require.NoError(t, err)
+		require.Equal(t, []codersdk.Role{{Name: role.Name, DisplayName: "Log Reader"}}, memberUser.Roles)
+		_, err = member.AuditLogs(ctx, codersdk.AuditLogsRequest{})
+		require.NoError(t, err)
+
+		// Changing the permissions of the role applies to everyone with it.
+		_, err = client.UpdateCustomRole(ctx, role.Name, codersdk.UpdateCustomRoleRequest{
+			SitePermissions: &[]codersdk.Permission{},
+		})
+		require.NoError(t, err)
+		_, err = member.AuditLogs(ctx, codersdk.AuditLogsRequest{})
+		require.Error(t, err)
+
+		// Deleting the role unassigns it, so the member can still use the API.
+		err = client.DeleteCustomRole(ctx, role.Name)
+		require.NoError(t, err)
+		memberUser, err = member.User(ctx, codersdk.Me)
+		require.NoError(t, err)
+		require.Empty(t, memberUser.Roles)
+	})
+
+	t.Run("AssignOrganizationRole", func(t *testing.T) {
+		t.Parallel()
+
+		client := coderdtest.New(t, nil)
+		admin := coderdtest.CreateFirstUser(t, client)
+		member := coderdtest.CreateAnotherUser(t, client, admin.OrganizationID)
+		orgAdmin := coderdtest.CreateAnotherUser(t, client, admin.OrganizationID, rbac.RoleOrgAdmin(admin.OrganizationID))
+		ctx, _ := testutil.Context(t)
+
+		// Organization admins can define roles in their organization.
+		role, err := orgAdmin.CreateCustomRole(ctx, codersdk.CreateCustomRoleRequest{
+			Name:           "webhook-reader",
+			OrganizationID: &admin.OrganizationID,
+			OrgPermissions: []codersdk.Permission{{
+				ResourceType: rbac.ResourceWebhook.Type,
+				Action:       rbac.ActionRead,
+			}},
+		})
+		require.NoError(t, err)
+		require.Equal(t, "webhook-reader:"+admin.OrganizationID.String(), role.Name)
+
+		// But not site wide roles.
+		_, err = orgAdmin.CreateCustomRole(ctx, codersdk.CreateCustomRoleRequest{
+			Name:            "log-reader",
+			SitePermissions: readAuditLogs,
+		})
+		require.Error(t, err)
+
+		roles, err := orgAdmin.ListOrganizationRoles(ctx, admin.OrganizationID)
+		require.NoError(t, err)
+		require.Contains(t, roles, codersdk.AssignableRoles{
+			Role:       codersdk.Role{Name: role.Name, DisplayName: role.DisplayName},
+			Assignable: true,
+		})
+
+		_, err = member.WebhooksByOrganization(ctx, admin.OrganizationID)
+		require.Error(t, err)
+		memberUser, err := member.User(ctx, codersdk.Me)
+		require.NoError(t, err)
+		_, err = orgAdmin.UpdateOrganizationMemberRoles(ctx, admin.OrganizationID, memberUser.ID.String(), codersdk.UpdateRoles{
+			Roles: []string{role.Name},
+		})
+		require.NoError(t, err)
+		_, err = member.WebhooksByOrganization(ctx, admin.OrganizationID)
+		require.NoError(t, err)
+
+		err = orgAdmin.DeleteCustomRole(ctx, role.Name)
+		require.NoError(t, err)
+		_, err = member.WebhooksByOrganization(ctx, admin.OrganizationID)
+		require.Error(t, err)
+	})
+}
+
 func convertRole(roleName string) codersdk.Role {
 	role, _ := rbac.RoleByName(roleName)
 	return codersdk.Role{
@@ -0,0 +1,288 @@
+package coderd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/coder/coder/agent/asciicast"
+	"github.com/coder/coder/coderd/audit"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/filestore"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/codersdk"
+)
+
+// maxSessionRecordingSize limits the size of uploaded recordings. The agent
+// stops recording sessions well before this.
+const maxSessionRecordingSize = 32 << 20
+
+func (api *API) postWorkspaceAgentSessionRecording(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	workspaceAgent := httpmw.WorkspaceAgent(r)
+
+	r.Body = http.MaxBytesReader(rw, r.Body, maxSessionRecordingSize)
+	var req codersdk.PostWorkspaceAgentSessionRecordingRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+	switch req.Type {
+	case codersdk.SessionRecordingTypeSSH, codersdk.SessionRecordingTypeReconnectingPTY:
+	default:
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: fmt.Sprintf("Unsupported session recording type %q.", req.Type),
+		})
+		return
+	}
+	if len(req.Recording) == 0 {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Recording must not be empty.",
+		})
+		return
+	}
+
+	resource, err := api.Database.GetWorkspaceResourceByID(ctx, workspaceAgent.ResourceID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace resource.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	build, err := api.Database.GetWorkspaceBuildByJobID(ctx, resource.JobID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace build.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	workspace, err := api.Database.GetWorkspaceByID(ctx, build.WorkspaceID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	template, err := api.Database.GetTemplateByID(ctx, workspace.TemplateID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching template.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	if !template.SessionRecording {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Session recording is disabled for the template.",
+		})
+		return
+	}
+
+	// Recordings are created by the agent rather than a user, so workspace
+	// owners can't download them through the files API.
+	hashBytes := sha256.Sum256(req.Recording)
+	hash := hex.EncodeToString(hashBytes[:])
+	file, _, err := filestore.Upsert(ctx, api.Database, api.FileStore, database.InsertFileParams{
+		ID:        uuid.New(),
+		Hash:      hash,
+		CreatedBy: workspaceAgent.ID,
+		CreatedAt: database.Now(),
+		Mimetype:  asciicast.MimeType,
+	}, req.Recording)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error saving session recording.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	recording, err := api.Database.InsertWorkspaceSessionRecording(ctx, database.InsertWorkspaceSessionRecordingParams{
+		ID:             uuid.New(),
+		CreatedAt:      database.Now(),
+		OrganizationID: workspace.OrganizationID,
+		WorkspaceID:    workspace.ID,
+		AgentID:        workspaceAgent.ID,
+		OwnerID:        workspace.OwnerID,
+		Type:           database.SessionRecordingType(req.Type),
+		Command:        req.Command,
+		StartedAt:      req.StartedAt,
+		EndedAt:        req.EndedAt,
+		FileID:         file.ID,
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error inserting session recording.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	audit.BackgroundAudit(ctx, &audit.BackgroundAuditParams[database.WorkspaceSessionRecording]{
+		Audit:          *api.Auditor.Load(),
+		Log:            api.Logger,
+		UserID:         workspace.OwnerID,
+		OrganizationID: workspace.OrganizationID,
+		Status:         http.StatusCreated,
+		Action:         database.AuditActionCreate,
+		New:            recording,
+	})
+
+	httpapi.Write(ctx, rw, http.StatusNoContent, nil)
+}
+
+func (api *API) workspaceSessionRecordings(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	paginationParams, ok := parsePagination(rw, r)
+	if !ok {
+		return
+	}
+	if paginationParams.AfterID != uuid.Nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Session recordings can only be paginated with offset and limit.",
+		})
+		return
+	}
+	queryParams := r.URL.Query()
+	parser := httpapi.NewQueryParamParser()
+	filter := database.GetWorkspaceSessionRecordingsParams{
+		WorkspaceID: parser.UUID(queryParams, uuid.Nil, "workspace_id"),
+		OwnerID:     parser.UUID(queryParams, uuid.Nil, "owner_id"),
+		OffsetOpt:   int32(paginationParams.Offset),
+		LimitOpt:    int32(paginationParams.Limit),
+	}
+	if len(parser.Errors) > 0 {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message:     "Query parameters have invalid values.",
+			Validations: parser.Errors,
+		})
+		return
+	}
+
+	recordings, err := api.Database.GetWorkspaceSessionRecordings(ctx, filter)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching session recordings.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	recordings, err = AuthorizeFilter(api.HTTPAuth, r, rbac.ActionRead, recordings)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error authorizing session recordings.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	resp, err := api.convertWorkspaceSessionRecordings(r, recordings)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error converting session recordings.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, resp)
+}
+
+func (api *API) workspaceSessionRecording(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	recording := httpmw.WorkspaceSessionRecordingParam(r)
+	if !api.Authorize(r, rbac.ActionRead, recording) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	resp, err := api.convertWorkspaceSessionRecordings(r, []database.WorkspaceSessionRecording{recording})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error converting session recording.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, resp[0])
+}
+
+func (api *API) workspaceSessionRecordingContent(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	recording := httpmw.WorkspaceSessionRecordingParam(r)
+	if !api.Authorize(r, rbac.ActionRead, recording) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	file, err := api.Database.GetFileByID(ctx, recording.FileID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching session recording file.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	data, err := filestore.Get(ctx, api.Database, api.FileStore, file)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching session recording contents.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	rw.Header().Set("Content-Type", file.Mimetype)
+	rw.WriteHeader(http.StatusOK)
+	_, _ = rw.Write(data)
+}
+
+func (api *API) convertWorkspaceSessionRecordings(r *http.Request, recordings []database.WorkspaceSessionRecording) ([]codersdk.WorkspaceSessionRecording, error) {
+	ctx := r.Context()
+	ownerIDs := make([]uuid.UUID, 0, len(recordings))
+	workspaceNames := map[uuid.UUID]string{}
+	for _, recording := range recordings {
+		ownerIDs = append(ownerIDs, recording.OwnerID)
+		if _, ok := workspaceNames[recording.WorkspaceID]; ok {
+			continue
+		}
+		workspace, err := api.Database.GetWorkspaceByID(ctx, recording.WorkspaceID)
+		if err != nil {
+			return nil, err
+		}
+		workspaceNames[recording.WorkspaceID] = workspace.Name
+	}
+	owners, err := api.Database.GetUsersByIDs(ctx, ownerIDs)
+	if err != nil {
+		return nil, err
+	}
+	ownerNames := map[uuid.UUID]string{}
+	for _, owner := range owners {
+		ownerNames[owner.ID] = owner.Username
+	}
+
+	converted := make([]codersdk.WorkspaceSessionRecording, 0, len(recordings))
+	for _, recording := range recordings {
+		converted = append(converted, codersdk.WorkspaceSessionRecording{
+			ID:             recording.ID,
+			CreatedAt:      recording.CreatedAt,
+			OrganizationID: recording.OrganizationID,
+			WorkspaceID:    recording.WorkspaceID,
+			WorkspaceName:  workspaceNames[recording.WorkspaceID],
+			AgentID:        recording.AgentID,
+			OwnerID:        recording.OwnerID,
+			OwnerName:      ownerNames[recording.OwnerID],
+			Type:           codersdk.SessionRecordingType(recording.Type),
+			Command:        recording.Command,
+			StartedAt:      recording.StartedAt,
+			EndedAt:        recording.EndedAt,
+		})
+	}
+	return converted, nil
+}
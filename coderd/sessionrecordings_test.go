@@ -0,0 +1,202 @@
+package coderd_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/audit"
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/provisioner/echo"
+	"github.com/coder/coder/provisionersdk/proto"
+	"github.com/coder/coder/testutil"
+)
+
+func TestWorkspaceSessionRecordings(t *testing.T) {
+	t.Parallel()
+
+	setup := func(t *testing.T, sessionRecording bool) (*codersdk.Client, *codersdk.Client, codersdk.CreateFirstUserResponse, codersdk.Workspace, *audit.MockAuditor) {
+		t.Helper()
+		auditor := audit.NewMock()
+		client := coderdtest.New(t, &coderdtest.Options{
+			IncludeProvisionerDaemon: true,
+			Auditor:                  auditor,
+		})
+		user := coderdtest.CreateFirstUser(t, client)
+		authToken := uuid.NewString()
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, &echo.Responses{
+			Parse:         echo.ParseComplete,
+			ProvisionPlan: echo.ProvisionComplete,
+			ProvisionApply: []*proto.Provision_Response{{
+				Type: &proto.Provision_Response_Complete{
+					Complete: &proto.Provision_Complete{
+						Resources: []*proto.Resource{{
+							Name: "example",
+							Type: "aws_instance",
+							Agents: []*proto.Agent{{
+								Id: uuid.NewString(),
+								Auth: &proto.Agent_Token{
+									Token: authToken,
+								},
+							}},
+						}},
+					},
+				},
+			}},
+		})
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID, func(ctr *codersdk.CreateTemplateRequest) {
+			ctr.SessionRecording = &sessionRecording
+		})
+		require.Equal(t, sessionRecording, template.SessionRecording)
+		workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+		coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+		agentClient := codersdk.New(client.URL)
+		agentClient.SetSessionToken(authToken)
+		return client, agentClient, user, workspace, auditor
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		t.Parallel()
+		client, agentClient, user, workspace, auditor := setup(t, true)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		metadata, err := agentClient.WorkspaceAgentMetadata(ctx)
+		require.NoError(t, err)
+		require.True(t, metadata.SessionRecording)
+
+		content := []byte(`{"version": 2, "width": 80, "height": 24}` + "\n" + `[0.1, "o", "hello"]` + "\n")
+		startedAt := time.Now().Add(-time.Minute).UTC().Truncate(time.Second)
+		err = agentClient.PostWorkspaceAgentSessionRecording(ctx, codersdk.PostWorkspaceAgentSessionRecordingRequest{
+			Type:      codersdk.SessionRecordingTypeSSH,
+			Command:   "vim",
+			StartedAt: startedAt,
+			EndedAt:   startedAt.Add(time.Minute),
+			Recording: content,
+		})
+		require.NoError(t, err)
+
+		recordings, err := client.WorkspaceSessionRecordings(ctx, codersdk.WorkspaceSessionRecordingsRequest{
+			WorkspaceID: workspace.ID,
+		})
+		require.NoError(t, err)
+		require.Len(t, recordings, 1)
+		recording := recordings[0]
+		require.Equal(t, workspace.ID, recording.WorkspaceID)
+		require.Equal(t, workspace.Name, recording.WorkspaceName)
+		require.Equal(t, user.UserID, recording.OwnerID)
+		require.Equal(t, coderdtest.FirstUserParams.Username, recording.OwnerName)
+		require.Equal(t, codersdk.SessionRecordingTypeSSH, recording.Type)
+		require.Equal(t, "vim", recording.Command)
+		require.True(t, startedAt.Equal(recording.StartedAt))
+
+		got, err := client.WorkspaceSessionRecording(ctx, recording.ID)
+		require.NoError(t, err)
+		require.Equal(t, recording.ID, got.ID)
+
+		data, err := client.WorkspaceSessionRecordingContent(ctx, recording.ID)
+		require.NoError(t, err)
+		require.Equal(t, content, data)
+
+		// Filters exclude recordings of other owners.
+		recordings, err = client.WorkspaceSessionRecordings(ctx, codersdk.WorkspaceSessionRecordingsRequest{
+			OwnerID: uuid.New(),
+		})
+		require.NoError(t, err)
+		require.Empty(t, recordings)
+
+		require.NotEmpty(t, auditor.AuditLogs)
+		auditLog := auditor.AuditLogs[len(auditor.AuditLogs)-1]
+		require.Equal(t, database.ResourceTypeWorkspaceSessionRecording, auditLog.ResourceType)
+		require.Equal(t, database.AuditActionCreate, auditLog.Action)
+		require.Equal(t, recording.ID, auditLog.ResourceID)
+	})
+
+	t.Run("AuditorCanRead", func(t *testing.T) {
+		t.Parallel()
+		client, agentClient, user, _, _ := setup(t, true)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		content := []byte(`{"version": 2, "width": 80, "height": 24}` + "\n")
+		err := agentClient.PostWorkspaceAgentSessionRecording(ctx, codersdk.PostWorkspaceAgentSessionRecordingRequest{
+			Type:      codersdk.SessionRecordingTypeReconnectingPTY,
+			StartedAt: time.Now(),
+			EndedAt:   time.Now(),
+			Recording: content,
+		})
+		require.NoError(t, err)
+
+		auditor := coderdtest.CreateAnotherUser(t, client, user.OrganizationID, "auditor")
+		recordings, err := auditor.WorkspaceSessionRecordings(ctx, codersdk.WorkspaceSessionRecordingsRequest{})
+		require.NoError(t, err)
+		require.Len(t, recordings, 1)
+		got, err := auditor.WorkspaceSessionRecording(ctx, recordings[0].ID)
+		require.NoError(t, err)
+		require.Equal(t, recordings[0].ID, got.ID)
+		data, err := auditor.WorkspaceSessionRecordingContent(ctx, recordings[0].ID)
+		require.NoError(t, err)
+		require.Equal(t, content, data)
+	})
+
+	t.Run("MemberCannotRead", func(t *testing.T) {
+		t.Parallel()
+		client, agentClient, user, _, _ := setup(t, true)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		err := agentClient.PostWorkspaceAgentSessionRecording(ctx, codersdk.PostWorkspaceAgentSessionRecordingRequest{
+			Type:      codersdk.SessionRecordingTypeReconnectingPTY,
+			StartedAt: time.Now(),
+			EndedAt:   time.Now(),
+			Recording: []byte(`{"version": 2, "width": 80, "height": 24}` + "\n"),
+		})
+		require.NoError(t, err)
+		recordings, err := client.WorkspaceSessionRecordings(ctx, codersdk.WorkspaceSessionRecordingsRequest{})
+		require.NoError(t, err)
+		require.Len(t, recordings, 1)
+
+		member := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+		memberRecordings, err := member.WorkspaceSessionRecordings(ctx, codersdk.WorkspaceSessionRecordingsRequest{})
+		require.NoError(t, err)
+		require.Empty(t, memberRecordings)
+		_, err = member.WorkspaceSessionRecordingContent(ctx, recordings[0].ID)
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+
+		// Auditors can watch recordings.
+		auditorClient := coderdtest.CreateAnotherUser(t, client, user.OrganizationID, "auditor")
+		_, err = auditorClient.WorkspaceSessionRecordingContent(ctx, recordings[0].ID)
+		require.NoError(t, err)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		t.Parallel()
+		_, agentClient, _, _, _ := setup(t, false)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		err := agentClient.PostWorkspaceAgentSessionRecording(ctx, codersdk.PostWorkspaceAgentSessionRecordingRequest{
+			Type:      codersdk.SessionRecordingTypeSSH,
+			StartedAt: time.Now(),
+			EndedAt:   time.Now(),
+			Recording: []byte(`{"version": 2, "width": 80, "height": 24}` + "\n"),
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+	})
+}
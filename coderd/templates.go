@@ -17,7 +17,9 @@ import (
 	"golang.org/x/xerrors"
 
 	"github.com/coder/coder/coderd/audit"
+	"github.com/coder/coder/coderd/autobuild/schedule"
 	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/filestore"
 	"github.com/coder/coder/coderd/httpapi"
 	"github.com/coder/coder/coderd/httpmw"
 	"github.com/coder/coder/coderd/rbac"
@@ -33,6 +35,10 @@ const (
 	AutoImportTemplateKubernetes AutoImportTemplate = "kubernetes"
 )
 
+// defaultAutodeleteWarningTTL is how long dormant workspaces are marked for
+// deletion before they are deleted, unless the template sets otherwise.
+const defaultAutodeleteWarningTTL = 24 * time.Hour
+
 // Returns a single template.
 func (api *API) template(rw http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -220,6 +226,30 @@ func (api *API) postTemplateByOrganization(rw http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	var inactivityTTL, stoppedTTL time.Duration
+	if createTemplate.InactivityTTLMillis != nil {
+		inactivityTTL = time.Duration(*createTemplate.InactivityTTLMillis) * time.Millisecond
+	}
+	if createTemplate.StoppedTTLMillis != nil {
+		stoppedTTL = time.Duration(*createTemplate.StoppedTTLMillis) * time.Millisecond
+	}
+	autodeleteWarningTTL := defaultAutodeleteWarningTTL
+	if createTemplate.AutodeleteWarningTTLMillis != nil {
+		autodeleteWarningTTL = time.Duration(*createTemplate.AutodeleteWarningTTLMillis) * time.Millisecond
+	}
+	validErrs := validateAutodeleteTTLs(inactivityTTL, stoppedTTL, autodeleteWarningTTL)
+	policy, policyErrs := mergeTemplatePolicy(schedule.DefaultTemplatePolicy(),
+		createTemplate.MaxTTLMillis, createTemplate.AutostartRequirement, createTemplate.QuietHours)
+	validErrs = append(validErrs, policyErrs...)
+	validErrs = append(validErrs, validateTemplateDefaultTTL(ttl, policy)...)
+	if len(validErrs) > 0 {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message:     "Invalid create template request.",
+			Validations: validErrs,
+		})
+		return
+	}
+
 	var dbTemplate database.Template
 	var template codersdk.Template
 	err = api.Database.InTx(func(tx database.Store) error {
@@ -239,8 +269,19 @@ func (api *API) postTemplateByOrganization(rw http.ResponseWriter, r *http.Reque
 			GroupACL: database.TemplateACL{
 				organization.ID.String(): []rbac.Action{rbac.ActionRead},
 			},
-			DisplayName: createTemplate.DisplayName,
-			Icon:        createTemplate.Icon,
+			DisplayName:          createTemplate.DisplayName,
+			Icon:                 createTemplate.Icon,
+			InactivityTTL:        int64(inactivityTTL),
+			StoppedTTL:           int64(stoppedTTL),
+			MaxTTL:               int64(policy.MaxTTL),
+			AutostartDaysOfWeek:  int16(policy.AutostartDaysOfWeek),
+			AutostartStartHour:   int16(policy.AutostartStartHour),
+			AutostartEndHour:     int16(policy.AutostartEndHour),
+			QuietHoursSchedule:   quietHoursSchedule(policy),
+			QuietHoursDuration:   int64(policy.QuietHoursDuration),
+			SessionRecording:     createTemplate.SessionRecording != nil && *createTemplate.SessionRecording,
+			AutoStartOnConnect:   createTemplate.AutoStartOnConnect != nil && *createTemplate.AutoStartOnConnect,
+			AutodeleteWarningTTL: int64(autodeleteWarningTTL),
 		})
 		if err != nil {
 			return xerrors.Errorf("insert template: %s", err)
@@ -443,10 +484,49 @@ func (api *API) patchTemplateMeta(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Autodelete TTLs are only changed when they are set in the request.
+	inactivityTTL := time.Duration(template.InactivityTTL)
+	if req.InactivityTTLMillis != nil {
+		inactivityTTL = time.Duration(*req.InactivityTTLMillis) * time.Millisecond
+	}
+	stoppedTTL := time.Duration(template.StoppedTTL)
+	if req.StoppedTTLMillis != nil {
+		stoppedTTL = time.Duration(*req.StoppedTTLMillis) * time.Millisecond
+	}
+	autodeleteWarningTTL := time.Duration(template.AutodeleteWarningTTL)
+	if req.AutodeleteWarningTTLMillis != nil {
+		autodeleteWarningTTL = time.Duration(*req.AutodeleteWarningTTLMillis) * time.Millisecond
+	}
+
 	var validErrs []codersdk.ValidationError
 	if req.DefaultTTLMillis < 0 {
 		validErrs = append(validErrs, codersdk.ValidationError{Field: "default_ttl_ms", Detail: "Must be a positive integer."})
 	}
+	validErrs = append(validErrs, validateAutodeleteTTLs(inactivityTTL, stoppedTTL, autodeleteWarningTTL)...)
+
+	currentPolicy, err := schedule.NewTemplatePolicy(template)
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return
+	}
+	policy, policyErrs := mergeTemplatePolicy(currentPolicy, req.MaxTTLMillis, req.AutostartRequirement, req.QuietHours)
+	validErrs = append(validErrs, policyErrs...)
+	validErrs = append(validErrs, validateTemplateDefaultTTL(time.Duration(req.DefaultTTLMillis)*time.Millisecond, policy)...)
+	sessionRecording := template.SessionRecording
+	if req.SessionRecording != nil {
+		sessionRecording = *req.SessionRecording
+	}
+	autoStartOnConnect := template.AutoStartOnConnect
+	if req.AutoStartOnConnect != nil {
+		autoStartOnConnect = *req.AutoStartOnConnect
+	}
+	maxPortSharingLevel := template.MaxPortSharingLevel
+	if req.MaxPortSharingLevel != nil {
+		maxPortSharingLevel = database.AppSharingLevel(*req.MaxPortSharingLevel)
+		if _, ok := appSharingLevelRanks[maxPortSharingLevel]; !ok {
+			validErrs = append(validErrs, codersdk.ValidationError{Field: "max_port_sharing_level", Detail: `Must be "owner", "authenticated" or "public".`})
+		}
+	}
 
 	if len(validErrs) > 0 {
 		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
@@ -458,7 +538,7 @@ func (api *API) patchTemplateMeta(rw http.ResponseWriter, r *http.Request) {
 
 	count := uint32(0)
 	var updated database.Template
-	err := api.Database.InTx(func(tx database.Store) error {
+	err = api.Database.InTx(func(tx database.Store) error {
 		// Fetch workspace counts
 		workspaceCounts, err := tx.GetWorkspaceOwnerCountsByTemplateIDs(ctx, []uuid.UUID{template.ID})
 		if xerrors.Is(err, sql.ErrNoRows) {
@@ -476,7 +556,19 @@ func (api *API) patchTemplateMeta(rw http.ResponseWriter, r *http.Request) {
 			req.Description == template.Description &&
 			req.DisplayName == template.DisplayName &&
 			req.Icon == template.Icon &&
-			req.DefaultTTLMillis == time.Duration(template.DefaultTTL).Milliseconds() {
+			req.DefaultTTLMillis == time.Duration(template.DefaultTTL).Milliseconds() &&
+			int64(inactivityTTL) == template.InactivityTTL &&
+			int64(stoppedTTL) == template.StoppedTTL &&
+			int64(autodeleteWarningTTL) == template.AutodeleteWarningTTL &&
+			int64(policy.MaxTTL) == template.MaxTTL &&
+			int16(policy.AutostartDaysOfWeek) == template.AutostartDaysOfWeek &&
+			int16(policy.AutostartStartHour) == template.AutostartStartHour &&
+			int16(policy.AutostartEndHour) == template.AutostartEndHour &&
+			quietHoursSchedule(policy) == template.QuietHoursSchedule &&
+			int64(policy.QuietHoursDuration) == template.QuietHoursDuration &&
+			sessionRecording == template.SessionRecording &&
+			autoStartOnConnect == template.AutoStartOnConnect &&
+			maxPortSharingLevel == template.MaxPortSharingLevel {
 			return nil
 		}
 
@@ -497,18 +589,43 @@ func (api *API) patchTemplateMeta(rw http.ResponseWriter, r *http.Request) {
 		}
 
 		updated, err = tx.UpdateTemplateMetaByID(ctx, database.UpdateTemplateMetaByIDParams{
-			ID:          template.ID,
-			UpdatedAt:   database.Now(),
-			Name:        name,
-			DisplayName: displayName,
-			Description: desc,
-			Icon:        icon,
-			DefaultTTL:  int64(maxTTL),
+			ID:                   template.ID,
+			UpdatedAt:            database.Now(),
+			Name:                 name,
+			DisplayName:          displayName,
+			Description:          desc,
+			Icon:                 icon,
+			DefaultTTL:           int64(maxTTL),
+			InactivityTTL:        int64(inactivityTTL),
+			StoppedTTL:           int64(stoppedTTL),
+			MaxTTL:               int64(policy.MaxTTL),
+			AutostartDaysOfWeek:  int16(policy.AutostartDaysOfWeek),
+			AutostartStartHour:   int16(policy.AutostartStartHour),
+			AutostartEndHour:     int16(policy.AutostartEndHour),
+			QuietHoursSchedule:   quietHoursSchedule(policy),
+			QuietHoursDuration:   int64(policy.QuietHoursDuration),
+			SessionRecording:     sessionRecording,
+			AutoStartOnConnect:   autoStartOnConnect,
+			MaxPortSharingLevel:  maxPortSharingLevel,
+			AutodeleteWarningTTL: int64(autodeleteWarningTTL),
 		})
 		if err != nil {
 			return err
 		}
 
+		// Workspaces without a TTL or with a TTL above the new maximum are
+		// clamped. Deadlines of running workspaces are re-evaluated by the
+		// lifecycle executor.
+		if policy.MaxTTL > 0 {
+			err = tx.UpdateWorkspacesTTLByTemplateID(ctx, database.UpdateWorkspacesTTLByTemplateIDParams{
+				TemplateID: template.ID,
+				MaxTTL:     int64(policy.MaxTTL),
+			})
+			if err != nil {
+				return xerrors.Errorf("update workspace ttls: %w", err)
+			}
+		}
+
 		return nil
 	}, nil)
 	if err != nil {
@@ -569,14 +686,13 @@ func (api *API) autoImportTemplate(ctx context.Context, opts autoImportTemplateO
 			hash = sha256.Sum256(opts.archive)
 			now  = database.Now()
 		)
-		file, err := tx.InsertFile(ctx, database.InsertFileParams{
+		file, err := filestore.Insert(ctx, tx, api.FileStore, database.InsertFileParams{
 			ID:        uuid.New(),
 			Hash:      hex.EncodeToString(hash[:]),
 			CreatedAt: now,
 			CreatedBy: opts.userID,
 			Mimetype:  "application/x-tar",
-			Data:      opts.archive,
-		})
+		}, opts.archive)
 		if err != nil {
 			return xerrors.Errorf("insert auto-imported template archive into files table: %w", err)
 		}
@@ -653,6 +769,10 @@ func (api *API) autoImportTemplate(ctx context.Context, opts autoImportTemplateO
 			GroupACL: database.TemplateACL{
 				opts.orgID.String(): []rbac.Action{rbac.ActionRead},
 			},
+			AutostartDaysOfWeek:  int16(schedule.AllDaysOfWeek),
+			AutostartStartHour:   0,
+			AutostartEndHour:     24,
+			AutodeleteWarningTTL: int64(defaultAutodeleteWarningTTL),
 		})
 		if err != nil {
 			return xerrors.Errorf("insert template: %w", err)
@@ -740,21 +860,126 @@ func (api *API) convertTemplate(
 	buildTimeStats := api.metricsCache.TemplateBuildTimeStats(template.ID)
 
 	return codersdk.Template{
-		ID:                  template.ID,
-		CreatedAt:           template.CreatedAt,
-		UpdatedAt:           template.UpdatedAt,
-		OrganizationID:      template.OrganizationID,
-		Name:                template.Name,
-		DisplayName:         template.DisplayName,
-		Provisioner:         codersdk.ProvisionerType(template.Provisioner),
-		ActiveVersionID:     template.ActiveVersionID,
-		WorkspaceOwnerCount: workspaceOwnerCount,
-		ActiveUserCount:     activeCount,
-		BuildTimeStats:      buildTimeStats,
-		Description:         template.Description,
-		Icon:                template.Icon,
-		DefaultTTLMillis:    time.Duration(template.DefaultTTL).Milliseconds(),
+		ID:                         template.ID,
+		CreatedAt:                  template.CreatedAt,
+		UpdatedAt:                  template.UpdatedAt,
+		OrganizationID:             template.OrganizationID,
+		Name:                       template.Name,
+		DisplayName:                template.DisplayName,
+		Provisioner:                codersdk.ProvisionerType(template.Provisioner),
+		ActiveVersionID:            template.ActiveVersionID,
+		WorkspaceOwnerCount:        workspaceOwnerCount,
+		ActiveUserCount:            activeCount,
+		BuildTimeStats:             buildTimeStats,
+		Description:                template.Description,
+		Icon:                       template.Icon,
+		DefaultTTLMillis:           time.Duration(template.DefaultTTL).Milliseconds(),
+		InactivityTTLMillis:        time.Duration(template.InactivityTTL).Milliseconds(),
+		StoppedTTLMillis:           time.Duration(template.StoppedTTL).Milliseconds(),
+		AutodeleteWarningTTLMillis: time.Duration(template.AutodeleteWarningTTL).Milliseconds(),
+		MaxTTLMillis:               time.Duration(template.MaxTTL).Milliseconds(),
+		AutostartRequirement: codersdk.TemplateAutostartRequirement{
+			DaysOfWeek: schedule.DaysOfWeek(uint8(template.AutostartDaysOfWeek)),
+			StartHour:  int(template.AutostartStartHour),
+			EndHour:    int(template.AutostartEndHour),
+		},
+		QuietHours: codersdk.TemplateQuietHours{
+			Schedule:       template.QuietHoursSchedule,
+			DurationMillis: time.Duration(template.QuietHoursDuration).Milliseconds(),
+		},
+		SessionRecording:    template.SessionRecording,
+		AutoStartOnConnect:  template.AutoStartOnConnect,
+		MaxPortSharingLevel: codersdk.WorkspaceAppSharingLevel(template.MaxPortSharingLevel),
 		CreatedByID:         template.CreatedBy,
 		CreatedByName:       createdByName,
 	}
 }
+
+// validateAutodeleteTTLs returns validation errors for template inactivity and
+// stopped TTLs, where zero disables the policy, and for the warning period
+// before dormant workspaces are deleted.
+func validateAutodeleteTTLs(inactivityTTL, stoppedTTL, warningTTL time.Duration) []codersdk.ValidationError {
+	var validErrs []codersdk.ValidationError
+	if inactivityTTL < 0 {
+		validErrs = append(validErrs, codersdk.ValidationError{Field: "inactivity_ttl_ms", Detail: "Must be a positive integer."})
+	} else if inactivityTTL > 0 && inactivityTTL < time.Minute {
+		validErrs = append(validErrs, codersdk.ValidationError{Field: "inactivity_ttl_ms", Detail: "Must be at least one minute."})
+	}
+	if stoppedTTL < 0 {
+		validErrs = append(validErrs, codersdk.ValidationError{Field: "stopped_ttl_ms", Detail: "Must be a positive integer."})
+	} else if stoppedTTL > 0 && stoppedTTL < time.Minute {
+		validErrs = append(validErrs, codersdk.ValidationError{Field: "stopped_ttl_ms", Detail: "Must be at least one minute."})
+	}
+	if warningTTL < 0 {
+		validErrs = append(validErrs, codersdk.ValidationError{Field: "autodelete_warning_ttl_ms", Detail: "Must be a positive integer."})
+	}
+	return validErrs
+}
+
+// mergeTemplatePolicy applies the schedule policy fields of a request to
+// policy, leaving nil fields unchanged, and returns validation errors for the
+// result.
+func mergeTemplatePolicy(
+	policy schedule.TemplatePolicy,
+	maxTTLMillis *int64,
+	autostart *codersdk.TemplateAutostartRequirement,
+	quietHours *codersdk.TemplateQuietHours,
+) (schedule.TemplatePolicy, []codersdk.ValidationError) {
+	var validErrs []codersdk.ValidationError
+	if maxTTLMillis != nil {
+		policy.MaxTTL = time.Duration(*maxTTLMillis) * time.Millisecond
+		if policy.MaxTTL < 0 {
+			validErrs = append(validErrs, codersdk.ValidationError{Field: "max_ttl_ms", Detail: "Must be a positive integer."})
+		} else if policy.MaxTTL > 0 && policy.MaxTTL < time.Minute {
+			validErrs = append(validErrs, codersdk.ValidationError{Field: "max_ttl_ms", Detail: "Must be at least one minute."})
+		}
+	}
+	if autostart != nil {
+		days, err := schedule.DaysOfWeekMask(autostart.DaysOfWeek)
+		if err != nil {
+			validErrs = append(validErrs, codersdk.ValidationError{Field: "autostart_requirement.days_of_week", Detail: err.Error()})
+		}
+		policy.AutostartDaysOfWeek = days
+		policy.AutostartStartHour = autostart.StartHour
+		policy.AutostartEndHour = autostart.EndHour
+		if autostart.StartHour < 0 || autostart.StartHour > 23 {
+			validErrs = append(validErrs, codersdk.ValidationError{Field: "autostart_requirement.start_hour", Detail: "Must be between 0 and 23."})
+		}
+		if autostart.EndHour <= autostart.StartHour || autostart.EndHour > 24 {
+			validErrs = append(validErrs, codersdk.ValidationError{Field: "autostart_requirement.end_hour", Detail: "Must be after start_hour and at most 24."})
+		}
+	}
+	if quietHours != nil {
+		policy.QuietHours = nil
+		policy.QuietHoursDuration = time.Duration(quietHours.DurationMillis) * time.Millisecond
+		if quietHours.Schedule != "" {
+			sched, err := schedule.Weekly(quietHours.Schedule)
+			if err != nil {
+				validErrs = append(validErrs, codersdk.ValidationError{Field: "quiet_hours.schedule", Detail: err.Error()})
+			}
+			policy.QuietHours = sched
+			if policy.QuietHoursDuration < time.Minute || policy.QuietHoursDuration > 24*time.Hour {
+				validErrs = append(validErrs, codersdk.ValidationError{Field: "quiet_hours.duration_ms", Detail: "Must be between one minute and 24 hours."})
+			}
+		} else if policy.QuietHoursDuration != 0 {
+			validErrs = append(validErrs, codersdk.ValidationError{Field: "quiet_hours.duration_ms", Detail: "Must be zero when quiet hours are disabled."})
+		}
+	}
+	return policy, validErrs
+}
+
+// validateTemplateDefaultTTL returns validation errors if the default TTL of
+// a template exceeds its maximum TTL.
+func validateTemplateDefaultTTL(defaultTTL time.Duration, policy schedule.TemplatePolicy) []codersdk.ValidationError {
+	if policy.MaxTTL > 0 && defaultTTL > policy.MaxTTL {
+		return []codersdk.ValidationError{{Field: "default_ttl_ms", Detail: "Must not exceed max_ttl_ms."}}
+	}
+	return nil
+}
+
+func quietHoursSchedule(policy schedule.TemplatePolicy) string {
+	if policy.QuietHours == nil {
+		return ""
+	}
+	return policy.QuietHours.String()
+}
@@ -159,6 +159,142 @@ func TestPostTemplateByOrganization(t *testing.T) {
 		require.Zero(t, got.DefaultTTLMillis)
 	})
 
+	t.Run("AutodeleteTTLs", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		got, err := client.CreateTemplate(ctx, user.OrganizationID, codersdk.CreateTemplateRequest{
+			Name:                "testing",
+			VersionID:           version.ID,
+			InactivityTTLMillis: ptr.Ref((30 * 24 * time.Hour).Milliseconds()),
+			StoppedTTLMillis:    ptr.Ref((7 * 24 * time.Hour).Milliseconds()),
+		})
+		require.NoError(t, err)
+		require.Equal(t, (30 * 24 * time.Hour).Milliseconds(), got.InactivityTTLMillis)
+		require.Equal(t, (7 * 24 * time.Hour).Milliseconds(), got.StoppedTTLMillis)
+	})
+
+	t.Run("AutodeleteTTLTooLow", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := client.CreateTemplate(ctx, user.OrganizationID, codersdk.CreateTemplateRequest{
+			Name:                "testing",
+			VersionID:           version.ID,
+			InactivityTTLMillis: ptr.Ref(time.Second.Milliseconds()),
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+		require.Contains(t, err.Error(), "inactivity_ttl_ms: Must be at least one minute")
+	})
+
+	t.Run("SchedulePolicy", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		got, err := client.CreateTemplate(ctx, user.OrganizationID, codersdk.CreateTemplateRequest{
+			Name:         "testing",
+			VersionID:    version.ID,
+			MaxTTLMillis: ptr.Ref((8 * time.Hour).Milliseconds()),
+			AutostartRequirement: &codersdk.TemplateAutostartRequirement{
+				DaysOfWeek: []string{"monday", "friday"},
+				StartHour:  6,
+				EndHour:    10,
+			},
+			QuietHours: &codersdk.TemplateQuietHours{
+				Schedule:       "CRON_TZ=US/Central 0 22 * * *",
+				DurationMillis: (8 * time.Hour).Milliseconds(),
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, (8 * time.Hour).Milliseconds(), got.MaxTTLMillis)
+		require.Equal(t, []string{"monday", "friday"}, got.AutostartRequirement.DaysOfWeek)
+		require.Equal(t, 6, got.AutostartRequirement.StartHour)
+		require.Equal(t, 10, got.AutostartRequirement.EndHour)
+		require.Equal(t, "CRON_TZ=US/Central 0 22 * * *", got.QuietHours.Schedule)
+		require.Equal(t, (8 * time.Hour).Milliseconds(), got.QuietHours.DurationMillis)
+	})
+
+	t.Run("SchedulePolicyDefaults", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+
+		require.Zero(t, template.MaxTTLMillis)
+		require.Len(t, template.AutostartRequirement.DaysOfWeek, 7)
+		require.Equal(t, 0, template.AutostartRequirement.StartHour)
+		require.Equal(t, 24, template.AutostartRequirement.EndHour)
+		require.Empty(t, template.QuietHours.Schedule)
+	})
+
+	t.Run("DefaultTTLAboveMaxTTL", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := client.CreateTemplate(ctx, user.OrganizationID, codersdk.CreateTemplateRequest{
+			Name:             "testing",
+			VersionID:        version.ID,
+			DefaultTTLMillis: ptr.Ref((12 * time.Hour).Milliseconds()),
+			MaxTTLMillis:     ptr.Ref((8 * time.Hour).Milliseconds()),
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+		require.Contains(t, err.Error(), "default_ttl_ms: Must not exceed max_ttl_ms")
+	})
+
+	t.Run("InvalidSchedulePolicy", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := client.CreateTemplate(ctx, user.OrganizationID, codersdk.CreateTemplateRequest{
+			Name:      "testing",
+			VersionID: version.ID,
+			AutostartRequirement: &codersdk.TemplateAutostartRequirement{
+				DaysOfWeek: []string{"someday"},
+				StartHour:  10,
+				EndHour:    6,
+			},
+			QuietHours: &codersdk.TemplateQuietHours{
+				Schedule: "CRON_TZ=US/Central 0 22 * * *",
+			},
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+		require.Contains(t, err.Error(), "autostart_requirement.days_of_week")
+		require.Contains(t, err.Error(), "autostart_requirement.end_hour")
+		require.Contains(t, err.Error(), "quiet_hours.duration_ms")
+	})
+
 	t.Run("Unauthorized", func(t *testing.T) {
 		t.Parallel()
 		client := coderdtest.New(t, nil)
@@ -376,6 +512,120 @@ func TestPatchTemplateMeta(t *testing.T) {
 		assert.Equal(t, updated.DefaultTTLMillis, template.DefaultTTLMillis)
 	})
 
+	t.Run("AutodeleteTTLs", func(t *testing.T) {
+		t.Parallel()
+
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID, func(ctr *codersdk.CreateTemplateRequest) {
+			ctr.StoppedTTLMillis = ptr.Ref(time.Hour.Milliseconds())
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		// Unset autodelete TTLs are left unchanged.
+		updated, err := client.UpdateTemplateMeta(ctx, template.ID, codersdk.UpdateTemplateMeta{
+			InactivityTTLMillis: ptr.Ref((24 * time.Hour).Milliseconds()),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, (24 * time.Hour).Milliseconds(), updated.InactivityTTLMillis)
+		assert.Equal(t, time.Hour.Milliseconds(), updated.StoppedTTLMillis)
+
+		// Zero disables them.
+		updated, err = client.UpdateTemplateMeta(ctx, template.ID, codersdk.UpdateTemplateMeta{
+			StoppedTTLMillis: ptr.Ref(int64(0)),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, (24 * time.Hour).Milliseconds(), updated.InactivityTTLMillis)
+		assert.Zero(t, updated.StoppedTTLMillis)
+
+		_, err = client.UpdateTemplateMeta(ctx, template.ID, codersdk.UpdateTemplateMeta{
+			StoppedTTLMillis: ptr.Ref(int64(-1)),
+		})
+		require.ErrorContains(t, err, "stopped_ttl_ms: Must be a positive integer")
+	})
+
+	t.Run("SessionRecording", func(t *testing.T) {
+		t.Parallel()
+
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		require.False(t, template.SessionRecording)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		updated, err := client.UpdateTemplateMeta(ctx, template.ID, codersdk.UpdateTemplateMeta{
+			SessionRecording: ptr.Ref(true),
+		})
+		require.NoError(t, err)
+		assert.True(t, updated.SessionRecording)
+
+		// Unset is left unchanged.
+		updated, err = client.UpdateTemplateMeta(ctx, template.ID, codersdk.UpdateTemplateMeta{
+			Description: "recorded",
+		})
+		require.NoError(t, err)
+		assert.True(t, updated.SessionRecording)
+	})
+
+	t.Run("AutoStartOnConnect", func(t *testing.T) {
+		t.Parallel()
+
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		require.False(t, template.AutoStartOnConnect)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		updated, err := client.UpdateTemplateMeta(ctx, template.ID, codersdk.UpdateTemplateMeta{
+			AutoStartOnConnect: ptr.Ref(true),
+		})
+		require.NoError(t, err)
+		assert.True(t, updated.AutoStartOnConnect)
+	})
+
+	t.Run("MaxTTL", func(t *testing.T) {
+		t.Parallel()
+
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID, func(cwr *codersdk.CreateWorkspaceRequest) {
+			cwr.TTLMillis = nil
+		})
+		require.Nil(t, workspace.TTLMillis)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		updated, err := client.UpdateTemplateMeta(ctx, template.ID, codersdk.UpdateTemplateMeta{
+			MaxTTLMillis: ptr.Ref((8 * time.Hour).Milliseconds()),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, (8 * time.Hour).Milliseconds(), updated.MaxTTLMillis)
+
+		// Workspaces without autostop are clamped to the maximum.
+		workspace, err = client.Workspace(ctx, workspace.ID)
+		require.NoError(t, err)
+		require.NotNil(t, workspace.TTLMillis)
+		assert.Equal(t, (8 * time.Hour).Milliseconds(), *workspace.TTLMillis)
+
+		_, err = client.UpdateTemplateMeta(ctx, template.ID, codersdk.UpdateTemplateMeta{
+			DefaultTTLMillis: (12 * time.Hour).Milliseconds(),
+		})
+		require.ErrorContains(t, err, "default_ttl_ms: Must not exceed max_ttl_ms")
+	})
+
 	t.Run("NotModified", func(t *testing.T) {
 		t.Parallel()
 
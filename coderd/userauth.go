@@ -17,9 +17,13 @@ import (
 	"golang.org/x/oauth2"
 	"golang.org/x/xerrors"
 
+	"cdr.dev/slog"
+	"github.com/coder/coder/coderd/audit"
 	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/coderd/httpapi"
 	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/coderd/util/slice"
 	"github.com/coder/coder/codersdk"
 )
 
@@ -195,6 +199,23 @@ type OIDCConfig struct {
 	// EmailDomain is the domain to enforce when a user authenticates.
 	EmailDomain  string
 	AllowSignups bool
+	// GroupField is the claim with the groups of a user. When it's set, the
+	// user's group memberships are synced on each login that includes the
+	// claim. Groups are an enterprise feature, so this does nothing unless
+	// they're licensed.
+	GroupField string
+	// GroupMapping maps the groups in the claim to Coder group names. Groups
+	// without a mapping are used as is.
+	GroupMapping map[string]string
+	// CreateMissingGroups creates the groups in the claim that don't exist.
+	CreateMissingGroups bool
+	// RoleField is the claim with the site roles of a user. When it's set,
+	// the user's site roles are synced on each login that includes the claim.
+	RoleField string
+	// RoleMapping maps the roles in the claim to Coder site role names. Only
+	// the roles it maps to are synced, and roles in the claim without a
+	// mapping are ignored.
+	RoleMapping map[string]string
 }
 
 func (api *API) userOIDC(rw http.ResponseWriter, r *http.Request) {
@@ -297,7 +318,7 @@ func (api *API) userOIDC(rw http.ResponseWriter, r *http.Request) {
 		picture, _ = pictureRaw.(string)
 	}
 
-	cookie, err := api.oauthLogin(r, oauthLoginParams{
+	params := oauthLoginParams{
 		State:        state,
 		LinkedID:     oidcLinkedID(idToken),
 		LoginType:    database.LoginTypeOIDC,
@@ -305,7 +326,43 @@ func (api *API) userOIDC(rw http.ResponseWriter, r *http.Request) {
 		Email:        email,
 		Username:     username,
 		AvatarURL:    picture,
-	})
+	}
+	// Like roles, groups are only synced when the claim is present.
+	if _, present := claims[api.OIDCConfig.GroupField]; api.OIDCConfig.GroupField != "" && present {
+		groups, ok := claimValues(claims, api.OIDCConfig.GroupField)
+		if !ok {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: fmt.Sprintf("The %q claim in the OIDC payload must be a string or a list of strings.", api.OIDCConfig.GroupField),
+			})
+			return
+		}
+		params.SyncGroups = true
+		params.Groups = mapClaimValues(groups, api.OIDCConfig.GroupMapping)
+	}
+	// Roles are only synced when the claim is present, so an IdP that leaves
+	// it out of some tokens doesn't strip the user's roles.
+	if _, present := claims[api.OIDCConfig.RoleField]; api.OIDCConfig.RoleField != "" && present {
+		roles, ok := claimValues(claims, api.OIDCConfig.RoleField)
+		if !ok {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: fmt.Sprintf("The %q claim in the OIDC payload must be a string or a list of strings.", api.OIDCConfig.RoleField),
+			})
+			return
+		}
+		params.SyncRoles = true
+		params.Roles = make([]string, 0, len(roles))
+		for _, role := range roles {
+			if name, ok := api.OIDCConfig.RoleMapping[role]; ok {
+				params.Roles = append(params.Roles, name)
+			}
+		}
+		params.SyncedRoles = make([]string, 0, len(api.OIDCConfig.RoleMapping))
+		for _, name := range api.OIDCConfig.RoleMapping {
+			params.SyncedRoles = append(params.SyncedRoles, name)
+		}
+	}
+
+	cookie, err := api.oauthLogin(r, params)
 	var httpErr httpError
 	if xerrors.As(err, &httpErr) {
 		httpapi.Write(ctx, rw, httpErr.code, codersdk.Response{
@@ -342,6 +399,15 @@ type oauthLoginParams struct {
 	Email        string
 	Username     string
 	AvatarURL    string
+
+	// SyncGroups sets the user's groups to Groups, and SyncRoles sets which
+	// of the SyncedRoles site roles they have to Roles. Their other site
+	// roles are kept.
+	SyncGroups  bool
+	Groups      []string
+	SyncRoles   bool
+	Roles       []string
+	SyncedRoles []string
 }
 
 type httpError struct {
@@ -362,6 +428,9 @@ func (api *API) oauthLogin(r *http.Request, params oauthLoginParams) (*http.Cook
 	var (
 		ctx  = r.Context()
 		user database.User
+		// rolesBefore is the user before their roles were synced, if they
+		// changed.
+		rolesBefore database.User
 	)
 
 	err := api.Database.InTx(func(tx database.Store) error {
@@ -531,13 +600,49 @@ func (api *API) oauthLogin(r *http.Request, params oauthLoginParams) (*http.Cook
 			}
 		}
 
+		if params.SyncRoles {
+			before := user
+			var changed bool
+			user, changed, err = api.syncSiteRoles(ctx, tx, user, params.Roles, params.SyncedRoles)
+			if err != nil {
+				return xerrors.Errorf("sync site roles: %w", err)
+			}
+			if changed {
+				rolesBefore = before
+			}
+		}
+
+		if params.SyncGroups {
+			syncGroups := api.GroupSync.Load()
+			if syncGroups != nil {
+				err = (*syncGroups)(ctx, tx, user.ID, params.Groups)
+				if err != nil {
+					return xerrors.Errorf("sync groups: %w", err)
+				}
+			}
+		}
+
 		return nil
 	}, nil)
 	if err != nil {
 		return nil, xerrors.Errorf("in tx: %w", err)
 	}
 
-	cookie, err := api.createAPIKey(ctx, createAPIKeyParams{
+	// Role changes from the identity provider are audited like the ones made
+	// by admins.
+	if rolesBefore.ID != uuid.Nil {
+		audit.BackgroundAudit(ctx, &audit.BackgroundAuditParams[database.User]{
+			Audit:  *api.Auditor.Load(),
+			Log:    api.Logger,
+			UserID: user.ID,
+			Status: http.StatusOK,
+			Action: database.AuditActionWrite,
+			Old:    rolesBefore,
+			New:    user,
+		})
+	}
+
+	cookie, _, err := api.createAPIKey(ctx, createAPIKeyParams{
 		UserID:     user.ID,
 		LoginType:  params.LoginType,
 		RemoteAddr: r.RemoteAddr,
@@ -549,6 +654,85 @@ func (api *API) oauthLogin(r *http.Request, params oauthLoginParams) (*http.Cook
 	return cookie, nil
 }
 
+// syncSiteRoles sets which of the synced site roles a user has to the named
+// roles, and keeps their other roles. Names that aren't site roles are
+// ignored. It returns the updated user, and whether their roles changed.
+func (api *API) syncSiteRoles(ctx context.Context, tx database.Store, user database.User, roleNames []string, syncedRoles []string) (database.User, bool, error) {
+	roles := make([]string, 0, len(user.RBACRoles)+len(roleNames))
+	for _, name := range user.RBACRoles {
+		if !slice.Contains(syncedRoles, name) {
+			roles = append(roles, name)
+		}
+	}
+	for _, name := range roleNames {
+		_, isOrgRole := rbac.IsOrgRole(name)
+		_, err := api.CustomRoles.RoleByName(name)
+		if isOrgRole || err != nil {
+			api.Logger.Warn(ctx, "ignoring unknown site role from identity provider",
+				slog.F("user_id", user.ID), slog.F("role", name))
+			continue
+		}
+		if name == rbac.RoleMember() {
+			// The member role is always implied.
+			continue
+		}
+		if slice.Contains(roles, name) {
+			continue
+		}
+		roles = append(roles, name)
+	}
+	added, removed := rbac.ChangeRoleSet(user.RBACRoles, roles)
+	if len(added) == 0 && len(removed) == 0 {
+		return user, false, nil
+	}
+	updated, err := tx.UpdateUserRoles(ctx, database.UpdateUserRolesParams{
+		ID:           user.ID,
+		GrantedRoles: roles,
+	})
+	if err != nil {
+		return database.User{}, false, err
+	}
+	return updated, true, nil
+}
+
+// claimValues returns the values of a claim that's a string or a list of
+// strings. A missing claim has no values.
+func claimValues(claims map[string]interface{}, field string) ([]string, bool) {
+	raw, ok := claims[field]
+	if !ok || raw == nil {
+		return []string{}, true
+	}
+	switch value := raw.(type) {
+	case string:
+		return []string{value}, true
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, v := range value {
+			s, ok := v.(string)
+			if !ok {
+				return nil, false
+			}
+			values = append(values, s)
+		}
+		return values, true
+	default:
+		return nil, false
+	}
+}
+
+// mapClaimValues maps claim values to Coder names. Values without a mapping
+// are used as is.
+func mapClaimValues(values []string, mapping map[string]string) []string {
+	mapped := make([]string, 0, len(values))
+	for _, value := range values {
+		if name, ok := mapping[value]; ok {
+			value = name
+		}
+		mapped = append(mapped, value)
+	}
+	return mapped
+}
+
 // githubLinkedID returns the unique ID for a GitHub user.
 func githubLinkedID(u *github.User) string {
 	return strconv.FormatInt(u.GetID(), 10)
@@ -20,8 +20,10 @@ import (
 	"golang.org/x/xerrors"
 
 	"github.com/coder/coder/coderd"
+	"github.com/coder/coder/coderd/audit"
 	"github.com/coder/coder/coderd/coderdtest"
 	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/rbac"
 	"github.com/coder/coder/codersdk"
 	"github.com/coder/coder/testutil"
 )
@@ -658,6 +660,90 @@ func TestUserOIDC(t *testing.T) {
 		require.True(t, strings.HasPrefix(user.Username, "jon-"), "username %q should have prefix %q", user.Username, "jon-")
 	})
 
+	t.Run("RoleSync", func(t *testing.T) {
+		t.Parallel()
+
+		conf := coderdtest.NewOIDCConfig(t, "")
+
+		config := conf.OIDCConfig()
+		config.AllowSignups = true
+		config.RoleField = "roles"
+		config.RoleMapping = map[string]string{
+			"admins":   rbac.RoleTemplateAdmin(),
+			"auditors": "auditor",
+		}
+
+		auditor := audit.NewMock()
+		owner := coderdtest.New(t, &coderdtest.Options{
+			OIDCConfig: config,
+			Auditor:    auditor,
+		})
+		coderdtest.CreateFirstUser(t, owner)
+		client := codersdk.New(owner.URL)
+
+		ctx, _ := testutil.Context(t)
+		userRoles := func() []string {
+			user, err := client.User(ctx, "me")
+			require.NoError(t, err)
+			roles := make([]string, 0, len(user.Roles))
+			for _, role := range user.Roles {
+				roles = append(roles, role.Name)
+			}
+			return roles
+		}
+
+		// Roles in the claim without a mapping are ignored, even when they're
+		// Coder roles.
+		resp := oidcCallback(t, client, conf.EncodeClaims(t, jwt.MapClaims{
+			"email": "kyle@kwc.io",
+			"roles": []string{"admins", rbac.RoleOwner(), "unknown"},
+		}))
+		require.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+		client.SetSessionToken(authCookieValue(resp.Cookies()))
+		require.ElementsMatch(t, []string{rbac.RoleTemplateAdmin()}, userRoles())
+		require.NotEmpty(t, auditor.AuditLogs)
+		auditLog := auditor.AuditLogs[len(auditor.AuditLogs)-1]
+		require.Equal(t, database.ResourceTypeUser, auditLog.ResourceType)
+		require.Equal(t, database.AuditActionWrite, auditLog.Action)
+
+		// Roles that aren't in the mapping are kept, and mapped roles missing
+		// from the claim are removed on the next login.
+		me, err := client.User(ctx, "me")
+		require.NoError(t, err)
+		_, err = owner.UpdateUserRoles(ctx, me.ID.String(), codersdk.UpdateRoles{
+			Roles: []string{rbac.RoleTemplateAdmin(), rbac.RoleUserAdmin()},
+		})
+		require.NoError(t, err)
+		resp = oidcCallback(t, client, conf.EncodeClaims(t, jwt.MapClaims{
+			"email": "kyle@kwc.io",
+			"roles": "auditors",
+		}))
+		require.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+		require.ElementsMatch(t, []string{"auditor", rbac.RoleUserAdmin()}, userRoles())
+
+		// Logins that don't change roles aren't audited.
+		logs := len(auditor.AuditLogs)
+		resp = oidcCallback(t, client, conf.EncodeClaims(t, jwt.MapClaims{
+			"email": "kyle@kwc.io",
+			"roles": "auditors",
+		}))
+		require.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+		require.Len(t, auditor.AuditLogs, logs)
+
+		// Roles are left alone when the claim is absent.
+		resp = oidcCallback(t, client, conf.EncodeClaims(t, jwt.MapClaims{
+			"email": "kyle@kwc.io",
+		}))
+		require.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+		require.ElementsMatch(t, []string{"auditor", rbac.RoleUserAdmin()}, userRoles())
+
+		resp = oidcCallback(t, client, conf.EncodeClaims(t, jwt.MapClaims{
+			"email": "kyle@kwc.io",
+			"roles": 1,
+		}))
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
 	t.Run("Disabled", func(t *testing.T) {
 		t.Parallel()
 		client := coderdtest.New(t, nil)
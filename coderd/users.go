@@ -265,7 +265,7 @@ func (api *API) users(rw http.ResponseWriter, r *http.Request) {
 
 	render.Status(r, http.StatusOK)
 	render.JSON(rw, r, codersdk.GetUsersResponse{
-		Users: convertUsers(users, organizationIDsByUserID),
+		Users: convertUsers(users, organizationIDsByUserID, api.CustomRoles),
 		Count: int(userRows[0].Count),
 	})
 }
@@ -354,7 +354,7 @@ func (api *API) postUser(rw http.ResponseWriter, r *http.Request) {
 		Users: []telemetry.User{telemetry.ConvertUser(user)},
 	})
 
-	httpapi.Write(ctx, rw, http.StatusCreated, convertUser(user, []uuid.UUID{req.OrganizationID}))
+	httpapi.Write(ctx, rw, http.StatusCreated, convertUser(user, []uuid.UUID{req.OrganizationID}, api.CustomRoles))
 }
 
 func (api *API) deleteUser(rw http.ResponseWriter, r *http.Request) {
@@ -430,7 +430,7 @@ func (api *API) userByName(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	httpapi.Write(ctx, rw, http.StatusOK, convertUser(user, organizationIDs))
+	httpapi.Write(ctx, rw, http.StatusOK, convertUser(user, organizationIDs, api.CustomRoles))
 }
 
 func (api *API) putUserProfile(rw http.ResponseWriter, r *http.Request) {
@@ -510,7 +510,58 @@ func (api *API) putUserProfile(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	httpapi.Write(ctx, rw, http.StatusOK, convertUser(updatedUserProfile, organizationIDs))
+	httpapi.Write(ctx, rw, http.StatusOK, convertUser(updatedUserProfile, organizationIDs, api.CustomRoles))
+}
+
+func (api *API) putUserPreferences(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx               = r.Context()
+		user              = httpmw.UserParam(r)
+		auditor           = *api.Auditor.Load()
+		aReq, commitAudit = audit.InitRequest[database.User](rw, &audit.RequestParams{
+			Audit:   auditor,
+			Log:     api.Logger,
+			Request: r,
+			Action:  database.AuditActionWrite,
+		})
+	)
+	defer commitAudit()
+	aReq.Old = user
+
+	if !api.Authorize(r, rbac.ActionUpdate, rbac.ResourceUserData.WithOwner(user.ID.String())) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	var params codersdk.UpdateUserPreferencesRequest
+	if !httpapi.Read(ctx, rw, r, &params) {
+		return
+	}
+
+	updatedUser, err := api.Database.UpdateUserPreferences(ctx, database.UpdateUserPreferencesParams{
+		ID:                 user.ID,
+		AutoStartOnConnect: params.AutoStartOnConnect,
+		UpdatedAt:          database.Now(),
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error updating user preferences.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	aReq.New = updatedUser
+
+	organizationIDs, err := userOrganizationIDs(ctx, api, user)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching user's organizations.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, convertUser(updatedUser, organizationIDs, api.CustomRoles))
 }
 
 func (api *API) putUserStatus(status database.UserStatus) func(rw http.ResponseWriter, r *http.Request) {
@@ -578,7 +629,7 @@ func (api *API) putUserStatus(status database.UserStatus) func(rw http.ResponseW
 			return
 		}
 
-		httpapi.Write(ctx, rw, http.StatusOK, convertUser(suspendedUser, organizations))
+		httpapi.Write(ctx, rw, http.StatusOK, convertUser(suspendedUser, organizations, api.CustomRoles))
 	}
 }
 
@@ -822,7 +873,7 @@ func (api *API) putUserRoles(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	httpapi.Write(ctx, rw, http.StatusOK, convertUser(updatedUser, organizationIDs))
+	httpapi.Write(ctx, rw, http.StatusOK, convertUser(updatedUser, organizationIDs, api.CustomRoles))
 }
 
 // updateSiteUserRoles will ensure only site wide roles are passed in as arguments.
@@ -834,7 +885,7 @@ func (api *API) updateSiteUserRoles(ctx context.Context, args database.UpdateUse
 			return database.User{}, xerrors.Errorf("Must only update site wide roles")
 		}
 
-		if _, err := rbac.RoleByName(r); err != nil {
+		if _, err := api.CustomRoles.RoleByName(r); err != nil {
 			return database.User{}, xerrors.Errorf("%q is not a supported role", r)
 		}
 	}
@@ -958,7 +1009,7 @@ func (api *API) postLogin(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cookie, err := api.createAPIKey(ctx, createAPIKeyParams{
+	cookie, _, err := api.createAPIKey(ctx, createAPIKeyParams{
 		UserID:     user.ID,
 		LoginType:  database.LoginTypePassword,
 		RemoteAddr: r.RemoteAddr,
@@ -1133,7 +1184,7 @@ func (api *API) CreateUser(ctx context.Context, store database.Store, req Create
 	}, nil)
 }
 
-func convertUser(user database.User, organizationIDs []uuid.UUID) codersdk.User {
+func convertUser(user database.User, organizationIDs []uuid.UUID, customRoles *rbac.CustomRoles) codersdk.User {
 	convertedUser := codersdk.User{
 		ID:              user.ID,
 		Email:           user.Email,
@@ -1144,21 +1195,22 @@ func convertUser(user database.User, organizationIDs []uuid.UUID) codersdk.User
 		OrganizationIDs: organizationIDs,
 		Roles:           make([]codersdk.Role, 0, len(user.RBACRoles)),
 		AvatarURL:       user.AvatarURL.String,
+
+		AutoStartOnConnect: user.AutoStartOnConnect,
 	}
 
 	for _, roleName := range user.RBACRoles {
-		rbacRole, _ := rbac.RoleByName(roleName)
-		convertedUser.Roles = append(convertedUser.Roles, convertRole(rbacRole))
+		convertedUser.Roles = append(convertedUser.Roles, convertRoleName(customRoles, roleName))
 	}
 
 	return convertedUser
 }
 
-func convertUsers(users []database.User, organizationIDsByUserID map[uuid.UUID][]uuid.UUID) []codersdk.User {
+func convertUsers(users []database.User, organizationIDsByUserID map[uuid.UUID][]uuid.UUID, customRoles *rbac.CustomRoles) []codersdk.User {
 	converted := make([]codersdk.User, 0, len(users))
 	for _, u := range users {
 		userOrganizationIDs := organizationIDsByUserID[u.ID]
-		converted = append(converted, convertUser(u, userOrganizationIDs))
+		converted = append(converted, convertUser(u, userOrganizationIDs, customRoles))
 	}
 	return converted
 }
@@ -1248,6 +1300,8 @@ func convertAPIKey(k database.APIKey) codersdk.APIKey {
 		UpdatedAt:       k.UpdatedAt,
 		LoginType:       codersdk.LoginType(k.LoginType),
 		Scope:           codersdk.APIKeyScope(k.Scope),
+		TokenName:       k.TokenName,
 		LifetimeSeconds: k.LifetimeSeconds,
+		AllowList:       k.AllowList,
 	}
 }
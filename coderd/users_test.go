@@ -313,7 +313,7 @@ func TestPostLogin(t *testing.T) {
 		apiKey, err := client.GetAPIKey(ctx, admin.UserID.String(), split[0])
 		require.NoError(t, err, "fetch api key")
 
-		require.True(t, apiKey.ExpiresAt.After(time.Now().Add(time.Hour*438300)), "tokens lasts more than 50 years")
+		require.True(t, apiKey.ExpiresAt.After(time.Now().Add(29*24*time.Hour)), "tokens last 30 days by default")
 		require.Greater(t, apiKey.LifetimeSeconds, key.LifetimeSeconds, "token should have longer lifetime")
 	})
 }
@@ -510,6 +510,34 @@ func TestPostUsers(t *testing.T) {
 	})
 }
 
+func TestUpdateUserPreferences(t *testing.T) {
+	t.Parallel()
+	client := coderdtest.New(t, nil)
+	admin := coderdtest.CreateFirstUser(t, client)
+	member := coderdtest.CreateAnotherUser(t, client, admin.OrganizationID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	me, err := member.User(ctx, codersdk.Me)
+	require.NoError(t, err)
+	require.False(t, me.AutoStartOnConnect)
+
+	updated, err := member.UpdateUserPreferences(ctx, codersdk.Me, codersdk.UpdateUserPreferencesRequest{
+		AutoStartOnConnect: true,
+	})
+	require.NoError(t, err)
+	require.True(t, updated.AutoStartOnConnect)
+
+	// Members can't edit the preferences of other users.
+	_, err = member.UpdateUserPreferences(ctx, admin.UserID.String(), codersdk.UpdateUserPreferencesRequest{
+		AutoStartOnConnect: true,
+	})
+	var apiErr *codersdk.Error
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+}
+
 func TestUpdateUserProfile(t *testing.T) {
 	t.Parallel()
 	t.Run("UserNotFound", func(t *testing.T) {
@@ -0,0 +1,344 @@
+package coderd
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/coderd/audit"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/cryptorand"
+)
+
+func (api *API) postWebhookByOrganization(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx               = r.Context()
+		org               = httpmw.OrganizationParam(r)
+		auditor           = *api.Auditor.Load()
+		aReq, commitAudit = audit.InitRequest[database.Webhook](rw, &audit.RequestParams{
+			Audit:   auditor,
+			Log:     api.Logger,
+			Request: r,
+			Action:  database.AuditActionCreate,
+		})
+	)
+	defer commitAudit()
+
+	if !api.Authorize(r, rbac.ActionCreate, rbac.ResourceWebhook.InOrg(org.ID)) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	var req codersdk.CreateWebhookRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+	if !validateWebhookRequest(rw, r, req.URL, req.Events) {
+		return
+	}
+
+	secret, err := cryptorand.String(32)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error generating webhook secret.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	now := database.Now()
+	webhook, err := api.Database.InsertWebhook(ctx, database.InsertWebhookParams{
+		ID:             uuid.New(),
+		OrganizationID: org.ID,
+		Name:           req.Name,
+		Url:            req.URL,
+		Secret:         secret,
+		Events:         webhookEventStrings(req.Events),
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	})
+	if database.IsUniqueViolation(err) {
+		httpapi.Write(ctx, rw, http.StatusConflict, codersdk.Response{
+			Message: fmt.Sprintf("Webhook with name %q already exists.", req.Name),
+		})
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error creating webhook.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	aReq.New = webhook
+
+	// The secret is only ever returned here.
+	resp := convertWebhook(webhook)
+	resp.Secret = webhook.Secret
+	httpapi.Write(ctx, rw, http.StatusCreated, resp)
+}
+
+func (api *API) webhooksByOrganization(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx = r.Context()
+		org = httpmw.OrganizationParam(r)
+	)
+	if !api.Authorize(r, rbac.ActionRead, rbac.ResourceWebhook.InOrg(org.ID)) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	webhooks, err := api.Database.GetWebhooksByOrganizationID(ctx, org.ID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching webhooks.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	resp := make([]codersdk.Webhook, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		resp = append(resp, convertWebhook(webhook))
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, resp)
+}
+
+func (api *API) webhook(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx     = r.Context()
+		webhook = httpmw.WebhookParam(r)
+	)
+	if !api.Authorize(r, rbac.ActionRead, webhook) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, convertWebhook(webhook))
+}
+
+func (api *API) patchWebhook(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx               = r.Context()
+		webhook           = httpmw.WebhookParam(r)
+		auditor           = *api.Auditor.Load()
+		aReq, commitAudit = audit.InitRequest[database.Webhook](rw, &audit.RequestParams{
+			Audit:   auditor,
+			Log:     api.Logger,
+			Request: r,
+			Action:  database.AuditActionWrite,
+		})
+	)
+	defer commitAudit()
+	aReq.Old = webhook
+
+	if !api.Authorize(r, rbac.ActionUpdate, webhook) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	var req codersdk.UpdateWebhookRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+	params := database.UpdateWebhookByIDParams{
+		ID:        webhook.ID,
+		Name:      webhook.Name,
+		Url:       webhook.Url,
+		Events:    webhook.Events,
+		UpdatedAt: database.Now(),
+	}
+	if req.Name != "" {
+		params.Name = req.Name
+	}
+	if req.URL != "" {
+		params.Url = req.URL
+	}
+	if req.Events != nil {
+		params.Events = webhookEventStrings(req.Events)
+	}
+	if !validateWebhookRequest(rw, r, params.Url, req.Events) {
+		return
+	}
+
+	updated, err := api.Database.UpdateWebhookByID(ctx, params)
+	if database.IsUniqueViolation(err) {
+		httpapi.Write(ctx, rw, http.StatusConflict, codersdk.Response{
+			Message: fmt.Sprintf("Webhook with name %q already exists.", params.Name),
+		})
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error updating webhook.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	aReq.New = updated
+
+	httpapi.Write(ctx, rw, http.StatusOK, convertWebhook(updated))
+}
+
+func (api *API) deleteWebhook(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx               = r.Context()
+		webhook           = httpmw.WebhookParam(r)
+		auditor           = *api.Auditor.Load()
+		aReq, commitAudit = audit.InitRequest[database.Webhook](rw, &audit.RequestParams{
+			Audit:   auditor,
+			Log:     api.Logger,
+			Request: r,
+			Action:  database.AuditActionDelete,
+		})
+	)
+	defer commitAudit()
+	aReq.Old = webhook
+
+	if !api.Authorize(r, rbac.ActionDelete, webhook) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	err := api.Database.DeleteWebhookByID(ctx, webhook.ID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error deleting webhook.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, codersdk.Response{
+		Message: "Webhook has been deleted!",
+	})
+}
+
+func (api *API) webhookDeliveries(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx     = r.Context()
+		webhook = httpmw.WebhookParam(r)
+	)
+	if !api.Authorize(r, rbac.ActionRead, webhook) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	queryParams := r.URL.Query()
+	parser := httpapi.NewQueryParamParser()
+	status := httpapi.ParseCustom(parser, queryParams, "", "status", parseWebhookDeliveryStatus)
+	limit := parser.Int(queryParams, 0, "limit")
+	if limit < 0 {
+		parser.Errors = append(parser.Errors, codersdk.ValidationError{
+			Field:  "limit",
+			Detail: "Limit must not be negative.",
+		})
+	}
+	if len(parser.Errors) > 0 {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message:     "Query parameters have invalid values.",
+			Validations: parser.Errors,
+		})
+		return
+	}
+
+	deliveries, err := api.Database.GetWebhookDeliveriesByWebhookID(ctx, database.GetWebhookDeliveriesByWebhookIDParams{
+		WebhookID: webhook.ID,
+		Status:    string(status),
+		LimitOpt:  int32(limit),
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching webhook deliveries.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	resp := make([]codersdk.WebhookDelivery, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		resp = append(resp, codersdk.WebhookDelivery{
+			ID:             delivery.ID,
+			WebhookID:      delivery.WebhookID,
+			EventID:        delivery.EventID,
+			EventType:      codersdk.WebhookEventType(delivery.EventType),
+			Status:         codersdk.WebhookDeliveryStatus(delivery.Status),
+			Attempts:       delivery.Attempts,
+			LastStatusCode: delivery.LastStatusCode,
+			LastError:      delivery.LastError,
+			Payload:        delivery.Payload,
+			CreatedAt:      delivery.CreatedAt,
+			UpdatedAt:      delivery.UpdatedAt,
+		})
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, resp)
+}
+
+// validateWebhookRequest writes an error response and returns false if the
+// URL or events are invalid.
+func validateWebhookRequest(rw http.ResponseWriter, r *http.Request, rawURL string, events []codersdk.WebhookEventType) bool {
+	ctx := r.Context()
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Webhook URL must be an absolute http or https URL.",
+			Validations: []codersdk.ValidationError{{
+				Field:  "url",
+				Detail: fmt.Sprintf("%q is not a valid webhook URL", rawURL),
+			}},
+		})
+		return false
+	}
+	for _, event := range events {
+		if !event.Valid() {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: fmt.Sprintf("Unknown webhook event %q.", event),
+				Validations: []codersdk.ValidationError{{
+					Field:  "events",
+					Detail: fmt.Sprintf("Must be one of %v", codersdk.WebhookEventTypes),
+				}},
+			})
+			return false
+		}
+	}
+	return true
+}
+
+func parseWebhookDeliveryStatus(v string) (codersdk.WebhookDeliveryStatus, error) {
+	switch status := codersdk.WebhookDeliveryStatus(v); status {
+	case "", codersdk.WebhookDeliveryStatusPending, codersdk.WebhookDeliveryStatusDelivered, codersdk.WebhookDeliveryStatusFailed:
+		return status, nil
+	default:
+		return "", xerrors.Errorf("%q is not a valid delivery status", v)
+	}
+}
+
+func webhookEventStrings(events []codersdk.WebhookEventType) []string {
+	strs := make([]string, 0, len(events))
+	for _, event := range events {
+		strs = append(strs, string(event))
+	}
+	return strs
+}
+
+func convertWebhook(webhook database.Webhook) codersdk.Webhook {
+	events := make([]codersdk.WebhookEventType, 0, len(webhook.Events))
+	for _, event := range webhook.Events {
+		events = append(events, codersdk.WebhookEventType(event))
+	}
+	return codersdk.Webhook{
+		ID:             webhook.ID,
+		OrganizationID: webhook.OrganizationID,
+		Name:           webhook.Name,
+		URL:            webhook.Url,
+		Events:         events,
+		CreatedAt:      webhook.CreatedAt,
+		UpdatedAt:      webhook.UpdatedAt,
+	}
+}
@@ -0,0 +1,301 @@
+package coderd_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/audit"
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/notifications"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/testutil"
+)
+
+func TestPostWebhook(t *testing.T) {
+	t.Parallel()
+
+	t.Run("OK", func(t *testing.T) {
+		t.Parallel()
+		auditor := audit.NewMock()
+		client := coderdtest.New(t, &coderdtest.Options{Auditor: auditor})
+		user := coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		webhook, err := client.CreateWebhook(ctx, user.OrganizationID, codersdk.CreateWebhookRequest{
+			Name:   "builds",
+			URL:    "https://example.com/hook",
+			Events: []codersdk.WebhookEventType{codersdk.WebhookEventWorkspaceBuildFailed},
+		})
+		require.NoError(t, err)
+		require.Equal(t, "builds", webhook.Name)
+		require.Equal(t, user.OrganizationID, webhook.OrganizationID)
+		require.NotEmpty(t, webhook.Secret)
+		require.Equal(t, database.AuditActionCreate, auditor.AuditLogs[len(auditor.AuditLogs)-1].Action)
+		require.Equal(t, database.ResourceTypeWebhook, auditor.AuditLogs[len(auditor.AuditLogs)-1].ResourceType)
+
+		// The secret is only returned on creation.
+		fetched, err := client.Webhook(ctx, webhook.ID)
+		require.NoError(t, err)
+		require.Empty(t, fetched.Secret)
+		require.Equal(t, webhook.Events, fetched.Events)
+	})
+
+	t.Run("Conflict", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		req := codersdk.CreateWebhookRequest{
+			Name: "builds",
+			URL:  "https://example.com/hook",
+		}
+		_, err := client.CreateWebhook(ctx, user.OrganizationID, req)
+		require.NoError(t, err)
+		_, err = client.CreateWebhook(ctx, user.OrganizationID, req)
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusConflict, apiErr.StatusCode())
+	})
+
+	t.Run("InvalidURL", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := client.CreateWebhook(ctx, user.OrganizationID, codersdk.CreateWebhookRequest{
+			Name: "builds",
+			URL:  "ftp://example.com/hook",
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+	})
+
+	t.Run("InvalidEvent", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := client.CreateWebhook(ctx, user.OrganizationID, codersdk.CreateWebhookRequest{
+			Name:   "builds",
+			URL:    "https://example.com/hook",
+			Events: []codersdk.WebhookEventType{"workspace.exploded"},
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+	})
+
+	t.Run("NotAdmin", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		member := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := member.CreateWebhook(ctx, user.OrganizationID, codersdk.CreateWebhookRequest{
+			Name: "builds",
+			URL:  "https://example.com/hook",
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+	})
+}
+
+func TestPatchWebhook(t *testing.T) {
+	t.Parallel()
+	auditor := audit.NewMock()
+	client := coderdtest.New(t, &coderdtest.Options{Auditor: auditor})
+	user := coderdtest.CreateFirstUser(t, client)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	webhook, err := client.CreateWebhook(ctx, user.OrganizationID, codersdk.CreateWebhookRequest{
+		Name:   "builds",
+		URL:    "https://example.com/hook",
+		Events: []codersdk.WebhookEventType{codersdk.WebhookEventWorkspaceBuildFailed},
+	})
+	require.NoError(t, err)
+
+	updated, err := client.UpdateWebhook(ctx, webhook.ID, codersdk.UpdateWebhookRequest{
+		URL: "https://example.com/other",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "builds", updated.Name)
+	require.Equal(t, "https://example.com/other", updated.URL)
+	require.Equal(t, webhook.Events, updated.Events)
+	require.Equal(t, database.AuditActionWrite, auditor.AuditLogs[len(auditor.AuditLogs)-1].Action)
+	require.Equal(t, database.ResourceTypeWebhook, auditor.AuditLogs[len(auditor.AuditLogs)-1].ResourceType)
+	require.Equal(t, webhook.ID, auditor.AuditLogs[len(auditor.AuditLogs)-1].ResourceID)
+
+	updated, err = client.UpdateWebhook(ctx, webhook.ID, codersdk.UpdateWebhookRequest{
+		Name:   "templates",
+		Events: []codersdk.WebhookEventType{codersdk.WebhookEventTemplateVersionPromoted},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "templates", updated.Name)
+	require.Equal(t, []codersdk.WebhookEventType{codersdk.WebhookEventTemplateVersionPromoted}, updated.Events)
+}
+
+func TestDeleteWebhook(t *testing.T) {
+	t.Parallel()
+	auditor := audit.NewMock()
+	client := coderdtest.New(t, &coderdtest.Options{Auditor: auditor})
+	user := coderdtest.CreateFirstUser(t, client)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	webhook, err := client.CreateWebhook(ctx, user.OrganizationID, codersdk.CreateWebhookRequest{
+		Name: "builds",
+		URL:  "https://example.com/hook",
+	})
+	require.NoError(t, err)
+
+	err = client.DeleteWebhook(ctx, webhook.ID)
+	require.NoError(t, err)
+	require.Equal(t, database.AuditActionDelete, auditor.AuditLogs[len(auditor.AuditLogs)-1].Action)
+	require.Equal(t, database.ResourceTypeWebhook, auditor.AuditLogs[len(auditor.AuditLogs)-1].ResourceType)
+
+	webhooks, err := client.WebhooksByOrganization(ctx, user.OrganizationID)
+	require.NoError(t, err)
+	require.Empty(t, webhooks)
+	_, err = client.Webhook(ctx, webhook.ID)
+	var apiErr *codersdk.Error
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+}
+
+func TestWebhookDeliveries(t *testing.T) {
+	t.Parallel()
+
+	t.Run("WorkspaceBuild", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		secret := make(chan string, 1)
+		events := make(chan codersdk.WebhookEvent, 1)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := io.ReadAll(r.Body)
+			assert.NoError(t, err)
+			// The secret is only known once the webhook has been created.
+			s := <-secret
+			secret <- s
+			assert.Equal(t, notifications.Sign(s, body), r.Header.Get(notifications.SignatureHeader))
+			var event codersdk.WebhookEvent
+			assert.NoError(t, json.Unmarshal(body, &event))
+			events <- event
+		}))
+		defer srv.Close()
+
+		webhook, err := client.CreateWebhook(ctx, user.OrganizationID, codersdk.CreateWebhookRequest{
+			Name:   "builds",
+			URL:    srv.URL,
+			Events: []codersdk.WebhookEventType{codersdk.WebhookEventWorkspaceBuildSucceeded},
+		})
+		require.NoError(t, err)
+		secret <- webhook.Secret
+
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+		coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+		var event codersdk.WebhookEvent
+		select {
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for webhook")
+		case event = <-events:
+		}
+		require.Equal(t, codersdk.WebhookEventWorkspaceBuildSucceeded, event.Type)
+		var data codersdk.WebhookWorkspaceBuildData
+		require.NoError(t, json.Unmarshal(event.Data, &data))
+		require.Equal(t, workspace.ID, data.WorkspaceID)
+		require.Equal(t, workspace.LatestBuild.ID, data.BuildID)
+		require.Equal(t, codersdk.WorkspaceTransitionStart, data.Transition)
+
+		require.Eventually(t, func() bool {
+			deliveries, err := client.WebhookDeliveries(ctx, webhook.ID, codersdk.WebhookDeliveriesRequest{
+				Status: codersdk.WebhookDeliveryStatusDelivered,
+			})
+			return err == nil && len(deliveries) == 1 && deliveries[0].EventID == event.ID
+		}, testutil.WaitShort, testutil.IntervalFast)
+	})
+
+	t.Run("Failed", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{
+			IncludeProvisionerDaemon: true,
+			WebhookRetryInterval:     testutil.IntervalFast,
+		})
+		user := coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer srv.Close()
+
+		webhook, err := client.CreateWebhook(ctx, user.OrganizationID, codersdk.CreateWebhookRequest{
+			Name:   "templates",
+			URL:    srv.URL,
+			Events: []codersdk.WebhookEventType{codersdk.WebhookEventTemplateVersionPromoted},
+		})
+		require.NoError(t, err)
+
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		version = coderdtest.UpdateTemplateVersion(t, client, user.OrganizationID, nil, template.ID)
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		err = client.UpdateActiveTemplateVersion(ctx, template.ID, codersdk.UpdateActiveTemplateVersion{
+			ID: version.ID,
+		})
+		require.NoError(t, err)
+
+		var delivery codersdk.WebhookDelivery
+		require.Eventually(t, func() bool {
+			deliveries, err := client.WebhookDeliveries(ctx, webhook.ID, codersdk.WebhookDeliveriesRequest{
+				Status: codersdk.WebhookDeliveryStatusFailed,
+			})
+			if err != nil || len(deliveries) == 0 {
+				return false
+			}
+			delivery = deliveries[0]
+			return true
+		}, testutil.WaitLong, testutil.IntervalFast)
+		require.Equal(t, codersdk.WebhookEventTemplateVersionPromoted, delivery.EventType)
+		require.EqualValues(t, 5, delivery.Attempts)
+		require.EqualValues(t, http.StatusServiceUnavailable, delivery.LastStatusCode)
+	})
+}
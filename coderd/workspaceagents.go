@@ -14,8 +14,11 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/mod/semver"
@@ -52,7 +55,15 @@ func (api *API) workspaceAgent(rw http.ResponseWriter, r *http.Request) {
 		})
 		return
 	}
-	apiAgent, err := convertWorkspaceAgent(api.DERPMap, *api.TailnetCoordinator.Load(), workspaceAgent, convertApps(dbApps), api.AgentInactiveDisconnectTimeout, api.DeploymentConfig.AgentFallbackTroubleshootingURL.Value)
+	dbMetadata, err := api.Database.GetWorkspaceAgentMetadataByAgentIDs(ctx, []uuid.UUID{workspaceAgent.ID})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace agent metadata.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	apiAgent, err := convertWorkspaceAgent(api.DERPMap, *api.TailnetCoordinator.Load(), workspaceAgent, convertApps(dbApps), convertWorkspaceAgentMetadata(dbMetadata), api.AgentInactiveDisconnectTimeout, api.DeploymentConfig.AgentFallbackTroubleshootingURL.Value)
 	if err != nil {
 		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
 			Message: "Internal error reading workspace agent.",
@@ -67,7 +78,7 @@ func (api *API) workspaceAgent(rw http.ResponseWriter, r *http.Request) {
 func (api *API) workspaceAgentMetadata(rw http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	workspaceAgent := httpmw.WorkspaceAgent(r)
-	apiAgent, err := convertWorkspaceAgent(api.DERPMap, *api.TailnetCoordinator.Load(), workspaceAgent, nil, api.AgentInactiveDisconnectTimeout, api.DeploymentConfig.AgentFallbackTroubleshootingURL.Value)
+	apiAgent, err := convertWorkspaceAgent(api.DERPMap, *api.TailnetCoordinator.Load(), workspaceAgent, nil, nil, api.AgentInactiveDisconnectTimeout, api.DeploymentConfig.AgentFallbackTroubleshootingURL.Value)
 	if err != nil {
 		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
 			Message: "Internal error reading workspace agent.",
@@ -83,6 +94,14 @@ func (api *API) workspaceAgentMetadata(rw http.ResponseWriter, r *http.Request)
 		})
 		return
 	}
+	dbMetadata, err := api.Database.GetWorkspaceAgentMetadataByAgentIDs(ctx, []uuid.UUID{workspaceAgent.ID})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace agent metadata.",
+			Detail:  err.Error(),
+		})
+		return
+	}
 	resource, err := api.Database.GetWorkspaceResourceByID(r.Context(), workspaceAgent.ResourceID)
 	if err != nil {
 		httpapi.Write(r.Context(), rw, http.StatusInternalServerError, codersdk.Response{
@@ -115,6 +134,14 @@ func (api *API) workspaceAgentMetadata(rw http.ResponseWriter, r *http.Request)
 		})
 		return
 	}
+	template, err := api.Database.GetTemplateByID(r.Context(), workspace.TemplateID)
+	if err != nil {
+		httpapi.Write(r.Context(), rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching template.",
+			Detail:  err.Error(),
+		})
+		return
+	}
 
 	vscodeProxyURI := strings.ReplaceAll(api.AppHostname, "*",
 		fmt.Sprintf("%s://{{port}}--%s--%s--%s",
@@ -125,20 +152,25 @@ func (api *API) workspaceAgentMetadata(rw http.ResponseWriter, r *http.Request)
 		))
 
 	httpapi.Write(ctx, rw, http.StatusOK, codersdk.WorkspaceAgentMetadata{
-		Apps:                 convertApps(dbApps),
-		DERPMap:              api.DERPMap,
-		GitAuthConfigs:       len(api.GitAuthConfigs),
-		EnvironmentVariables: apiAgent.EnvironmentVariables,
-		StartupScript:        apiAgent.StartupScript,
-		Directory:            apiAgent.Directory,
-		VSCodePortProxyURI:   vscodeProxyURI,
+		Apps:                  convertApps(dbApps),
+		DERPMap:               api.DERPMap,
+		GitAuthConfigs:        len(api.GitAuthConfigs),
+		EnvironmentVariables:  apiAgent.EnvironmentVariables,
+		StartupScript:         apiAgent.StartupScript,
+		StartupScriptTimeout:  time.Duration(apiAgent.StartupScriptTimeoutSeconds) * time.Second,
+		ShutdownScript:        apiAgent.ShutdownScript,
+		ShutdownScriptTimeout: time.Duration(apiAgent.ShutdownScriptTimeoutSeconds) * time.Second,
+		Directory:             apiAgent.Directory,
+		VSCodePortProxyURI:    vscodeProxyURI,
+		Metadata:              convertWorkspaceAgentMetadataDescriptions(dbMetadata),
+		SessionRecording:      template.SessionRecording,
 	})
 }
 
 func (api *API) postWorkspaceAgentVersion(rw http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	workspaceAgent := httpmw.WorkspaceAgent(r)
-	apiAgent, err := convertWorkspaceAgent(api.DERPMap, *api.TailnetCoordinator.Load(), workspaceAgent, nil, api.AgentInactiveDisconnectTimeout, api.DeploymentConfig.AgentFallbackTroubleshootingURL.Value)
+	apiAgent, err := convertWorkspaceAgent(api.DERPMap, *api.TailnetCoordinator.Load(), workspaceAgent, nil, nil, api.AgentInactiveDisconnectTimeout, api.DeploymentConfig.AgentFallbackTroubleshootingURL.Value)
 	if err != nil {
 		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
 			Message: "Internal error reading workspace agent.",
@@ -176,6 +208,625 @@ func (api *API) postWorkspaceAgentVersion(rw http.ResponseWriter, r *http.Reques
 	httpapi.Write(ctx, rw, http.StatusOK, nil)
 }
 
+func (api *API) workspaceAgentReportLifecycle(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	workspaceAgent := httpmw.WorkspaceAgent(r)
+
+	var req codersdk.PostWorkspaceAgentLifecycleRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+
+	switch req.State {
+	case codersdk.WorkspaceAgentLifecycleCreated:
+	case codersdk.WorkspaceAgentLifecycleStarting:
+	case codersdk.WorkspaceAgentLifecycleStartTimeout:
+	case codersdk.WorkspaceAgentLifecycleStartError:
+	case codersdk.WorkspaceAgentLifecycleReady:
+	case codersdk.WorkspaceAgentLifecycleShuttingDown:
+	case codersdk.WorkspaceAgentLifecycleShutdownTimeout:
+	case codersdk.WorkspaceAgentLifecycleShutdownError:
+	case codersdk.WorkspaceAgentLifecycleOff:
+	default:
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Invalid lifecycle state.",
+			Detail:  xerrors.Errorf("lifecycle state %q is not a valid value", req.State).Error(),
+		})
+		return
+	}
+
+	err := api.Database.UpdateWorkspaceAgentLifecycleStateByID(ctx, database.UpdateWorkspaceAgentLifecycleStateByIDParams{
+		ID:             workspaceAgent.ID,
+		LifecycleState: database.WorkspaceAgentLifecycleState(req.State),
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Failed to update lifecycle state.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusNoContent, nil)
+}
+
+// workspaceAgentPostMetadata stores the result of a metadata script run by
+// the agent and notifies watchers of the agent metadata.
+func (api *API) workspaceAgentPostMetadata(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	workspaceAgent := httpmw.WorkspaceAgent(r)
+	key := chi.URLParam(r, "key")
+
+	var req codersdk.PostWorkspaceAgentMetadataRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+
+	const maxLength = 65535
+	if utf8.RuneCountInString(req.Value) > maxLength || utf8.RuneCountInString(req.Error) > maxLength {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: fmt.Sprintf("Metadata value and error must be at most %d characters.", maxLength),
+		})
+		return
+	}
+
+	err := api.Database.UpdateWorkspaceAgentMetadata(ctx, database.UpdateWorkspaceAgentMetadataParams{
+		WorkspaceAgentID: workspaceAgent.ID,
+		Key:              key,
+		Value:            req.Value,
+		Error:            req.Error,
+		// The time is set by coderd so the age of values is unaffected by
+		// clock skew between the agent and coderd.
+		CollectedAt: database.Now(),
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Failed to update workspace agent metadata.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	err = api.Pubsub.Publish(watchWorkspaceAgentMetadataChannel(workspaceAgent.ID), []byte(key))
+	if err != nil {
+		api.Logger.Warn(ctx, "publish workspace agent metadata", slog.F("agent_id", workspaceAgent.ID), slog.Error(err))
+	}
+
+	httpapi.Write(ctx, rw, http.StatusNoContent, nil)
+}
+
+// watchWorkspaceAgentMetadata streams all metadata of the agent as
+// server-sent events. The full set is sent initially and each time the
+// agent reports a new value.
+func (api *API) watchWorkspaceAgentMetadata(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	workspaceAgent := httpmw.WorkspaceAgentParam(r)
+	workspace := httpmw.WorkspaceParam(r)
+	if !api.Authorize(r, rbac.ActionRead, workspace) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	sendEvent, senderClosed, err := httpapi.ServerSentEventSender(rw, r)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error setting up server-sent events.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	// Prevent handler from returning until the sender is closed.
+	defer func() {
+		<-senderClosed
+	}()
+
+	// Ignore all trace spans after this, they're not too useful.
+	ctx = trace.ContextWithSpan(ctx, tracing.NoopSpan)
+
+	// Updates are coalesced, a burst of reported values results in a
+	// single event.
+	update := make(chan struct{}, 1)
+	cancelSubscribe, err := api.Pubsub.Subscribe(watchWorkspaceAgentMetadataChannel(workspaceAgent.ID), func(_ context.Context, _ []byte) {
+		select {
+		case update <- struct{}{}:
+		default:
+		}
+	})
+	if err != nil {
+		_ = sendEvent(ctx, codersdk.ServerSentEvent{
+			Type: codersdk.ServerSentEventTypeError,
+			Data: codersdk.Response{
+				Message: "Internal error subscribing to workspace agent metadata.",
+				Detail:  err.Error(),
+			},
+		})
+		return
+	}
+	defer cancelSubscribe()
+
+	sendMetadata := func() error {
+		dbMetadata, err := api.Database.GetWorkspaceAgentMetadataByAgentIDs(ctx, []uuid.UUID{workspaceAgent.ID})
+		if err != nil {
+			_ = sendEvent(ctx, codersdk.ServerSentEvent{
+				Type: codersdk.ServerSentEventTypeError,
+				Data: codersdk.Response{
+					Message: "Internal error fetching workspace agent metadata.",
+					Detail:  err.Error(),
+				},
+			})
+			return err
+		}
+		return sendEvent(ctx, codersdk.ServerSentEvent{
+			Type: codersdk.ServerSentEventTypeData,
+			Data: convertWorkspaceAgentMetadata(dbMetadata),
+		})
+	}
+
+	err = sendMetadata()
+	if err != nil {
+		return
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-senderClosed:
+			return
+		case <-update:
+			err = sendMetadata()
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+func watchWorkspaceAgentMetadataChannel(id uuid.UUID) string {
+	return fmt.Sprintf("workspace_agent_metadata:%s", id)
+}
+
+// workspaceAgentAwaitShutdown blocks until a build that stops or deletes
+// the workspace of the agent has started, so the agent can run its shutdown
+// script before the infrastructure is torn down.
+func (api *API) workspaceAgentAwaitShutdown(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	workspaceAgent := httpmw.WorkspaceAgent(r)
+	resource, err := api.Database.GetWorkspaceResourceByID(ctx, workspaceAgent.ResourceID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace resource.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	build, err := api.Database.GetWorkspaceBuildByJobID(ctx, resource.JobID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace build.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	// Subscribe before checking the latest build so no builds are missed.
+	notify := make(chan struct{}, 1)
+	closeSubscribe, err := api.Pubsub.Subscribe(watchWorkspaceChannel(build.WorkspaceID), func(_ context.Context, _ []byte) {
+		select {
+		case notify <- struct{}{}:
+		default:
+		}
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error watching workspace.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	defer closeSubscribe()
+
+	for {
+		latestBuild, err := api.Database.GetLatestWorkspaceBuildByWorkspaceID(ctx, build.WorkspaceID)
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+				Message: "Internal error fetching latest workspace build.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+		if latestBuild.ID != build.ID && latestBuild.Transition != database.WorkspaceTransitionStart {
+			httpapi.Write(ctx, rw, http.StatusOK, nil)
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-notify:
+		}
+	}
+}
+
+func (api *API) patchWorkspaceAgentStartupLogs(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	workspaceAgent := httpmw.WorkspaceAgent(r)
+
+	var req codersdk.PatchStartupLogs
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+
+	var (
+		createdAfter int64
+		overflowed   bool
+	)
+	if len(req.Logs) > 0 {
+		createdAt := make([]time.Time, 0, len(req.Logs))
+		output := make([]string, 0, len(req.Logs))
+		for _, log := range req.Logs {
+			createdAt = append(createdAt, log.CreatedAt)
+			output = append(output, log.Output)
+		}
+		logs, err := api.Database.InsertWorkspaceAgentStartupLogs(ctx, database.InsertWorkspaceAgentStartupLogsParams{
+			AgentID:      workspaceAgent.ID,
+			CreatedAt:    createdAt,
+			Output:       output,
+			OutputLength: startupLogsLength(output),
+		})
+		if database.IsStartupLogsLimitError(err) {
+			// Store the lines that fit, so the logs are only cut at the
+			// limit rather than at the start of the batch.
+			overflowed = true
+			logs, err = api.insertStartupLogsUnderLimit(ctx, workspaceAgent.ID, createdAt, output)
+		}
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+				Message: "Failed to upload startup logs.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+		if len(logs) > 0 {
+			createdAfter = logs[0].ID - 1
+		}
+	}
+
+	if overflowed {
+		if !workspaceAgent.StartupLogsOverflowed {
+			err := api.Database.UpdateWorkspaceAgentStartupLogOverflowByID(ctx, database.UpdateWorkspaceAgentStartupLogOverflowByIDParams{
+				ID:                    workspaceAgent.ID,
+				StartupLogsOverflowed: true,
+			})
+			if err != nil {
+				// We don't want to return here, because the agent will retry
+				// on failure and this isn't a huge deal. The overflow state
+				// is just a hint to the user that the logs are incomplete.
+				api.Logger.Warn(ctx, "failed to update workspace agent startup log overflow", slog.Error(err))
+			}
+		}
+		if createdAfter != 0 {
+			api.publishWorkspaceAgentStartupLogs(ctx, workspaceAgent.ID, workspaceAgentStartupLogsMessage{
+				CreatedAfter: createdAfter,
+			})
+		}
+		// The agent drops the rest of its logs and sends EOF on its own.
+		httpapi.Write(ctx, rw, http.StatusRequestEntityTooLarge, codersdk.Response{
+			Message: "Startup logs limit exceeded",
+		})
+		return
+	}
+
+	if req.EOF != workspaceAgent.StartupLogsEOF {
+		err := api.Database.UpdateWorkspaceAgentStartupLogsEOFByID(ctx, database.UpdateWorkspaceAgentStartupLogsEOFByIDParams{
+			ID:             workspaceAgent.ID,
+			StartupLogsEOF: req.EOF,
+		})
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+				Message: "Failed to update startup logs state.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+	}
+
+	api.publishWorkspaceAgentStartupLogs(ctx, workspaceAgent.ID, workspaceAgentStartupLogsMessage{
+		CreatedAfter: createdAfter,
+		EndOfLogs:    req.EOF,
+	})
+
+	httpapi.Write(ctx, rw, http.StatusOK, nil)
+}
+
+// insertStartupLogsUnderLimit inserts the leading lines of output that fit
+// in the remaining startup logs length of the agent.
+func (api *API) insertStartupLogsUnderLimit(ctx context.Context, agentID uuid.UUID, createdAt []time.Time, output []string) ([]database.WorkspaceAgentStartupLog, error) {
+	agent, err := api.Database.GetWorkspaceAgentByID(ctx, agentID)
+	if err != nil {
+		return nil, xerrors.Errorf("get workspace agent: %w", err)
+	}
+	remaining := int(maxStartupLogsLength - agent.StartupLogsLength)
+	fit := 0
+	for fit < len(output) && len(output[fit]) <= remaining {
+		remaining -= len(output[fit])
+		fit++
+	}
+	if fit == 0 {
+		return nil, nil
+	}
+	logs, err := api.Database.InsertWorkspaceAgentStartupLogs(ctx, database.InsertWorkspaceAgentStartupLogsParams{
+		AgentID:      agentID,
+		CreatedAt:    createdAt[:fit],
+		Output:       output[:fit],
+		OutputLength: startupLogsLength(output[:fit]),
+	})
+	if database.IsStartupLogsLimitError(err) {
+		// Logs were inserted concurrently, so nothing fits anymore.
+		return nil, nil
+	}
+	if err != nil {
+		return nil, xerrors.Errorf("insert startup logs: %w", err)
+	}
+	return logs, nil
+}
+
+func startupLogsLength(output []string) int32 {
+	length := 0
+	for _, line := range output {
+		length += len(line)
+	}
+	return int32(length)
+}
+
+func (api *API) publishWorkspaceAgentStartupLogs(ctx context.Context, agentID uuid.UUID, msg workspaceAgentStartupLogsMessage) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		api.Logger.Warn(ctx, "failed to marshal startup logs notification",
+			slog.F("workspace_agent_id", agentID), slog.Error(err))
+		return
+	}
+	err = api.Pubsub.Publish(workspaceAgentStartupLogsChannel(agentID), data)
+	if err != nil {
+		api.Logger.Warn(ctx, "failed to publish startup logs",
+			slog.F("workspace_agent_id", agentID), slog.Error(err))
+	}
+}
+
+// workspaceAgentStartupLogs returns the output of the agent startup script.
+// The intended usage for a client to stream all logs:
+// 1. GET /startup-logs?after=<id>&follow
+// Following stops once the agent reports that the startup script exited.
+func (api *API) workspaceAgentStartupLogs(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx            = r.Context()
+		workspace      = httpmw.WorkspaceParam(r)
+		workspaceAgent = httpmw.WorkspaceAgentParam(r)
+		logger         = api.Logger.With(slog.F("workspace_agent_id", workspaceAgent.ID))
+		follow         = r.URL.Query().Has("follow")
+		afterRaw       = r.URL.Query().Get("after")
+	)
+	if !api.Authorize(r, rbac.ActionRead, workspace) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	var after int64
+	if afterRaw != "" {
+		var err error
+		after, err = strconv.ParseInt(afterRaw, 10, 64)
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: "Query param \"after\" must be an integer.",
+				Validations: []codersdk.ValidationError{
+					{Field: "after", Detail: "Must be an integer"},
+				},
+			})
+			return
+		}
+	}
+
+	// Subscribe before querying the database so no logs are missed between
+	// the query and the subscription. Duplicates are filtered by ID below.
+	var (
+		notify    = make(chan struct{}, 1)
+		endOfLogs atomic.Bool
+	)
+	if follow {
+		closeSubscribe, err := api.Pubsub.Subscribe(workspaceAgentStartupLogsChannel(workspaceAgent.ID), func(ctx context.Context, message []byte) {
+			var msg workspaceAgentStartupLogsMessage
+			err := json.Unmarshal(message, &msg)
+			if err != nil {
+				logger.Warn(ctx, "invalid startup logs message on channel", slog.Error(err))
+				return
+			}
+			if msg.EndOfLogs {
+				endOfLogs.Store(true)
+			}
+			select {
+			case notify <- struct{}{}:
+			default:
+			}
+		})
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+				Message: "Internal error watching startup logs.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+		defer closeSubscribe()
+
+		// The agent may have finished between the middleware fetching it
+		// and the subscription starting.
+		workspaceAgent, err = api.Database.GetWorkspaceAgentByID(ctx, workspaceAgent.ID)
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+				Message: "Internal error fetching workspace agent.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+	}
+
+	logs, err := api.Database.GetWorkspaceAgentStartupLogsAfter(ctx, database.GetWorkspaceAgentStartupLogsAfterParams{
+		AgentID:      workspaceAgent.ID,
+		CreatedAfter: after,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		err = nil
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching startup logs.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	if logs == nil {
+		logs = []database.WorkspaceAgentStartupLog{}
+	}
+
+	if !follow {
+		httpapi.Write(ctx, rw, http.StatusOK, convertWorkspaceAgentStartupLogs(logs))
+		return
+	}
+
+	api.WebsocketWaitMutex.Lock()
+	api.WebsocketWaitGroup.Add(1)
+	api.WebsocketWaitMutex.Unlock()
+	defer api.WebsocketWaitGroup.Done()
+	conn, err := websocket.Accept(rw, r, nil)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Failed to accept websocket.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	go httpapi.Heartbeat(ctx, conn)
+
+	ctx, wsNetConn := websocketNetConn(ctx, conn, websocket.MessageText)
+	defer wsNetConn.Close() // Also closes conn.
+
+	// The Go stdlib JSON encoder appends a newline character after message write.
+	encoder := json.NewEncoder(wsNetConn)
+	send := func(logs []database.WorkspaceAgentStartupLog) error {
+		for _, log := range logs {
+			if log.ID <= after {
+				continue
+			}
+			err := encoder.Encode(convertWorkspaceAgentStartupLog(log))
+			if err != nil {
+				return err
+			}
+			after = log.ID
+		}
+		return nil
+	}
+	err = send(logs)
+	if err != nil {
+		return
+	}
+	if workspaceAgent.StartupLogsEOF {
+		return
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Debug(context.Background(), "startup logs context canceled")
+			return
+		case <-notify:
+		}
+		// Read the flag before querying so logs written alongside the
+		// end of logs notification are always included.
+		done := endOfLogs.Load()
+		logs, err := api.Database.GetWorkspaceAgentStartupLogsAfter(ctx, database.GetWorkspaceAgentStartupLogsAfterParams{
+			AgentID:      workspaceAgent.ID,
+			CreatedAfter: after,
+		})
+		if err != nil {
+			_ = conn.Close(websocket.StatusInternalError, httpapi.WebsocketCloseSprintf("get startup logs: %s", err))
+			return
+		}
+		err = send(logs)
+		if err != nil {
+			return
+		}
+		if done {
+			return
+		}
+	}
+}
+
+// maxStartupLogsLength is the most startup log output stored for an agent,
+// enforced by the max_startup_logs_length constraint.
+const maxStartupLogsLength = 1 << 20
+
+func workspaceAgentStartupLogsChannel(agentID uuid.UUID) string {
+	return fmt.Sprintf("workspace-agent-startup-logs:%s", agentID)
+}
+
+// workspaceAgentStartupLogsMessage is the message type published on the
+// workspaceAgentStartupLogsChannel() channel.
+type workspaceAgentStartupLogsMessage struct {
+	CreatedAfter int64 `json:"created_after"`
+	EndOfLogs    bool  `json:"end_of_logs,omitempty"`
+}
+
+func convertWorkspaceAgentMetadataDescriptions(dbMetadata []database.WorkspaceAgentMetadatum) []codersdk.WorkspaceAgentMetadataDescription {
+	descriptions := make([]codersdk.WorkspaceAgentMetadataDescription, 0, len(dbMetadata))
+	for _, metadatum := range dbMetadata {
+		descriptions = append(descriptions, convertWorkspaceAgentMetadataDescription(metadatum))
+	}
+	return descriptions
+}
+
+func convertWorkspaceAgentMetadataDescription(metadatum database.WorkspaceAgentMetadatum) codersdk.WorkspaceAgentMetadataDescription {
+	return codersdk.WorkspaceAgentMetadataDescription{
+		DisplayName: metadatum.DisplayName,
+		Key:         metadatum.Key,
+		Script:      metadatum.Script,
+		Interval:    metadatum.Interval,
+		Timeout:     metadatum.Timeout,
+	}
+}
+
+func convertWorkspaceAgentMetadata(dbMetadata []database.WorkspaceAgentMetadatum) []codersdk.WorkspaceAgentMetadataItem {
+	items := make([]codersdk.WorkspaceAgentMetadataItem, 0, len(dbMetadata))
+	for _, metadatum := range dbMetadata {
+		result := codersdk.WorkspaceAgentMetadataResult{
+			CollectedAt: metadatum.CollectedAt,
+			Value:       metadatum.Value,
+			Error:       metadatum.Error,
+		}
+		if !metadatum.CollectedAt.IsZero() {
+			result.Age = int64(database.Now().Sub(metadatum.CollectedAt).Round(time.Second).Seconds())
+		}
+		items = append(items, codersdk.WorkspaceAgentMetadataItem{
+			Description: convertWorkspaceAgentMetadataDescription(metadatum),
+			Result:      result,
+		})
+	}
+	return items
+}
+
+func convertWorkspaceAgentStartupLogs(logs []database.WorkspaceAgentStartupLog) []codersdk.WorkspaceAgentStartupLog {
+	sdk := make([]codersdk.WorkspaceAgentStartupLog, 0, len(logs))
+	for _, log := range logs {
+		sdk = append(sdk, convertWorkspaceAgentStartupLog(log))
+	}
+	return sdk
+}
+
+func convertWorkspaceAgentStartupLog(log database.WorkspaceAgentStartupLog) codersdk.WorkspaceAgentStartupLog {
+	return codersdk.WorkspaceAgentStartupLog{
+		ID:        log.ID,
+		CreatedAt: log.CreatedAt,
+		Output:    log.Output,
+	}
+}
+
 // workspaceAgentPTY spawns a PTY and pipes it over a WebSocket.
 // This is used for the web terminal.
 func (api *API) workspaceAgentPTY(rw http.ResponseWriter, r *http.Request) {
@@ -192,7 +843,7 @@ func (api *API) workspaceAgentPTY(rw http.ResponseWriter, r *http.Request) {
 		httpapi.ResourceNotFound(rw)
 		return
 	}
-	apiAgent, err := convertWorkspaceAgent(api.DERPMap, *api.TailnetCoordinator.Load(), workspaceAgent, nil, api.AgentInactiveDisconnectTimeout, api.DeploymentConfig.AgentFallbackTroubleshootingURL.Value)
+	apiAgent, err := convertWorkspaceAgent(api.DERPMap, *api.TailnetCoordinator.Load(), workspaceAgent, nil, nil, api.AgentInactiveDisconnectTimeout, api.DeploymentConfig.AgentFallbackTroubleshootingURL.Value)
 	if err != nil {
 		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
 			Message: "Internal error reading workspace agent.",
@@ -269,27 +920,8 @@ func (api *API) workspaceAgentListeningPorts(rw http.ResponseWriter, r *http.Req
 		return
 	}
 
-	apiAgent, err := convertWorkspaceAgent(api.DERPMap, *api.TailnetCoordinator.Load(), workspaceAgent, nil, api.AgentInactiveDisconnectTimeout, api.DeploymentConfig.AgentFallbackTroubleshootingURL.Value)
-	if err != nil {
-		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
-			Message: "Internal error reading workspace agent.",
-			Detail:  err.Error(),
-		})
-		return
-	}
-	if apiAgent.Status != codersdk.WorkspaceAgentConnected {
-		httpapi.Write(ctx, rw, http.StatusPreconditionRequired, codersdk.Response{
-			Message: fmt.Sprintf("Agent state is %q, it must be in the %q state.", apiAgent.Status, codersdk.WorkspaceAgentConnected),
-		})
-		return
-	}
-
-	agentConn, release, err := api.workspaceAgentCache.Acquire(r, workspaceAgent.ID)
-	if err != nil {
-		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
-			Message: "Internal error dialing workspace agent.",
-			Detail:  err.Error(),
-		})
+	agentConn, release, ok := api.acquireConnectedWorkspaceAgent(rw, r, workspaceAgent)
+	if !ok {
 		return
 	}
 	defer release()
@@ -359,6 +991,144 @@ func (api *API) workspaceAgentListeningPorts(rw http.ResponseWriter, r *http.Req
 	httpapi.Write(ctx, rw, http.StatusOK, portsResponse)
 }
 
+func (api *API) workspaceAgentReconnectingPTYSessions(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	workspace := httpmw.WorkspaceParam(r)
+	workspaceAgent := httpmw.WorkspaceAgentParam(r)
+	// Sessions are managed by the users that can open them.
+	if !api.Authorize(r, rbac.ActionCreate, workspace.ExecutionRBAC()) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	agentConn, release, ok := api.acquireConnectedWorkspaceAgent(rw, r, workspaceAgent)
+	if !ok {
+		return
+	}
+	defer release()
+
+	sessions, err := agentConn.ReconnectingPTYSessions(ctx)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching terminal sessions.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, sessions)
+}
+
+func (api *API) patchWorkspaceAgentReconnectingPTYSession(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	workspace := httpmw.WorkspaceParam(r)
+	workspaceAgent := httpmw.WorkspaceAgentParam(r)
+	if !api.Authorize(r, rbac.ActionCreate, workspace.ExecutionRBAC()) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	var req codersdk.UpdateReconnectingPTYSessionRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+	// Names are used to attach to sessions from the CLI, so they follow the
+	// same rules as other names.
+	if req.Name != "" {
+		if err := httpapi.NameValid(req.Name); err != nil {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: "Invalid terminal session name.",
+				Validations: []codersdk.ValidationError{
+					{Field: "name", Detail: err.Error()},
+				},
+			})
+			return
+		}
+	}
+
+	agentConn, release, ok := api.acquireConnectedWorkspaceAgent(rw, r, workspaceAgent)
+	if !ok {
+		return
+	}
+	defer release()
+
+	session, err := agentConn.UpdateReconnectingPTYSession(ctx, chi.URLParam(r, "ptysession"), req)
+	if err != nil {
+		var sdkErr *codersdk.Error
+		if errors.As(err, &sdkErr) && sdkErr.StatusCode() == http.StatusNotFound {
+			httpapi.ResourceNotFound(rw)
+			return
+		}
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error renaming terminal session.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, session)
+}
+
+func (api *API) deleteWorkspaceAgentReconnectingPTYSession(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	workspace := httpmw.WorkspaceParam(r)
+	workspaceAgent := httpmw.WorkspaceAgentParam(r)
+	if !api.Authorize(r, rbac.ActionCreate, workspace.ExecutionRBAC()) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	agentConn, release, ok := api.acquireConnectedWorkspaceAgent(rw, r, workspaceAgent)
+	if !ok {
+		return
+	}
+	defer release()
+
+	err := agentConn.CloseReconnectingPTYSession(ctx, chi.URLParam(r, "ptysession"))
+	if err != nil {
+		var sdkErr *codersdk.Error
+		if errors.As(err, &sdkErr) && sdkErr.StatusCode() == http.StatusNotFound {
+			httpapi.ResourceNotFound(rw)
+			return
+		}
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error closing terminal session.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	httpapi.Write(ctx, rw, http.StatusNoContent, nil)
+}
+
+// acquireConnectedWorkspaceAgent returns a connection to the agent for making
+// requests to its statistics server. An error is written if the agent isn't
+// connected.
+func (api *API) acquireConnectedWorkspaceAgent(rw http.ResponseWriter, r *http.Request, workspaceAgent database.WorkspaceAgent) (*codersdk.AgentConn, func(), bool) {
+	ctx := r.Context()
+	apiAgent, err := convertWorkspaceAgent(api.DERPMap, *api.TailnetCoordinator.Load(), workspaceAgent, nil, nil, api.AgentInactiveDisconnectTimeout, api.DeploymentConfig.AgentFallbackTroubleshootingURL.Value)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error reading workspace agent.",
+			Detail:  err.Error(),
+		})
+		return nil, nil, false
+	}
+	if apiAgent.Status != codersdk.WorkspaceAgentConnected {
+		httpapi.Write(ctx, rw, http.StatusPreconditionRequired, codersdk.Response{
+			Message: fmt.Sprintf("Agent state is %q, it must be in the %q state.", apiAgent.Status, codersdk.WorkspaceAgentConnected),
+		})
+		return nil, nil, false
+	}
+
+	agentConn, release, err := api.workspaceAgentCache.Acquire(r, workspaceAgent.ID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error dialing workspace agent.",
+			Detail:  err.Error(),
+		})
+		return nil, nil, false
+	}
+	return agentConn.AgentConn, release, true
+}
+
 func (api *API) dialWorkspaceAgentTailnet(r *http.Request, agentID uuid.UUID) (*codersdk.AgentConn, error) {
 	clientConn, serverConn := net.Pipe()
 	go func() {
@@ -660,7 +1430,7 @@ func convertApps(dbApps []database.WorkspaceApp) []codersdk.WorkspaceApp {
 	return apps
 }
 
-func convertWorkspaceAgent(derpMap *tailcfg.DERPMap, coordinator tailnet.Coordinator, dbAgent database.WorkspaceAgent, apps []codersdk.WorkspaceApp, agentInactiveDisconnectTimeout time.Duration, agentFallbackTroubleshootingURL string) (codersdk.WorkspaceAgent, error) {
+func convertWorkspaceAgent(derpMap *tailcfg.DERPMap, coordinator tailnet.Coordinator, dbAgent database.WorkspaceAgent, apps []codersdk.WorkspaceApp, metadata []codersdk.WorkspaceAgentMetadataItem, agentInactiveDisconnectTimeout time.Duration, agentFallbackTroubleshootingURL string) (codersdk.WorkspaceAgent, error) {
 	var envs map[string]string
 	if dbAgent.EnvironmentVariables.Valid {
 		err := json.Unmarshal(dbAgent.EnvironmentVariables.RawMessage, &envs)
@@ -673,21 +1443,28 @@ func convertWorkspaceAgent(derpMap *tailcfg.DERPMap, coordinator tailnet.Coordin
 		troubleshootingURL = dbAgent.TroubleshootingURL
 	}
 	workspaceAgent := codersdk.WorkspaceAgent{
-		ID:                       dbAgent.ID,
-		CreatedAt:                dbAgent.CreatedAt,
-		UpdatedAt:                dbAgent.UpdatedAt,
-		ResourceID:               dbAgent.ResourceID,
-		InstanceID:               dbAgent.AuthInstanceID.String,
-		Name:                     dbAgent.Name,
-		Architecture:             dbAgent.Architecture,
-		OperatingSystem:          dbAgent.OperatingSystem,
-		StartupScript:            dbAgent.StartupScript.String,
-		Version:                  dbAgent.Version,
-		EnvironmentVariables:     envs,
-		Directory:                dbAgent.Directory,
-		Apps:                     apps,
-		ConnectionTimeoutSeconds: dbAgent.ConnectionTimeoutSeconds,
-		TroubleshootingURL:       troubleshootingURL,
+		ID:                           dbAgent.ID,
+		CreatedAt:                    dbAgent.CreatedAt,
+		UpdatedAt:                    dbAgent.UpdatedAt,
+		ResourceID:                   dbAgent.ResourceID,
+		InstanceID:                   dbAgent.AuthInstanceID.String,
+		Name:                         dbAgent.Name,
+		Architecture:                 dbAgent.Architecture,
+		OperatingSystem:              dbAgent.OperatingSystem,
+		StartupScript:                dbAgent.StartupScript.String,
+		Version:                      dbAgent.Version,
+		EnvironmentVariables:         envs,
+		Directory:                    dbAgent.Directory,
+		Apps:                         apps,
+		ConnectionTimeoutSeconds:     dbAgent.ConnectionTimeoutSeconds,
+		TroubleshootingURL:           troubleshootingURL,
+		LifecycleState:               codersdk.WorkspaceAgentLifecycle(dbAgent.LifecycleState),
+		StartupScriptTimeoutSeconds:  dbAgent.StartupScriptTimeoutSeconds,
+		ShutdownScript:               dbAgent.ShutdownScript.String,
+		ShutdownScriptTimeoutSeconds: dbAgent.ShutdownScriptTimeoutSeconds,
+		StartupLogsLength:            dbAgent.StartupLogsLength,
+		StartupLogsOverflowed:        dbAgent.StartupLogsOverflowed,
+		Metadata:                     metadata,
 	}
 	node := coordinator.Node(dbAgent.ID)
 	if node != nil {
@@ -858,9 +1635,19 @@ func (api *API) workspaceAgentReportStats(rw http.ResponseWriter, r *http.Reques
 		// We will see duplicate reports when on idle connections
 		// (e.g. web terminal left open) or when there are no connections at
 		// all.
-		// We also don't want to update the workspace last used at on duplicate
-		// reports.
-		updateDB := !reflect.DeepEqual(lastReport, rep)
+		// Resource usage changes with nearly every report, so it's left out
+		// of the comparison and only stored with reports that differ
+		// otherwise.
+		// We also don't want to update the workspace last used at unless
+		// there was network activity.
+		updateDB := !reflect.DeepEqual(lastReport, withoutResourceUsage(rep))
+		activity := lastReport.NumConns != rep.NumConns ||
+			lastReport.RxBytes != rep.RxBytes ||
+			lastReport.TxBytes != rep.TxBytes ||
+			lastReport.ConnectionCountSSH != rep.ConnectionCountSSH ||
+			lastReport.ConnectionCountReconnectingPTY != rep.ConnectionCountReconnectingPTY ||
+			lastReport.ConnectionCountVSCode != rep.ConnectionCountVSCode ||
+			lastReport.ConnectionCountJetBrains != rep.ConnectionCountJetBrains
 
 		api.Logger.Debug(ctx, "read stats report",
 			slog.F("interval", api.AgentStatsRefreshInterval),
@@ -868,28 +1655,32 @@ func (api *API) workspaceAgentReportStats(rw http.ResponseWriter, r *http.Reques
 			slog.F("resource", resource.ID),
 			slog.F("workspace", workspace.ID),
 			slog.F("update_db", updateDB),
+			slog.F("activity", activity),
 			slog.F("payload", rep),
 		)
 
 		if updateDB {
-			go activityBumpWorkspace(api.Logger.Named("activity_bump"), api.Database, workspace)
-
-			lastReport = rep
+			lastReport = withoutResourceUsage(rep)
 
 			_, err = api.Database.InsertAgentStat(ctx, database.InsertAgentStatParams{
-				ID:          uuid.New(),
-				CreatedAt:   database.Now(),
-				AgentID:     workspaceAgent.ID,
-				WorkspaceID: build.WorkspaceID,
-				UserID:      workspace.OwnerID,
-				TemplateID:  workspace.TemplateID,
-				Payload:     json.RawMessage(repJSON),
+				ID:                 uuid.New(),
+				CreatedAt:          database.Now(),
+				AgentID:            workspaceAgent.ID,
+				WorkspaceID:        build.WorkspaceID,
+				UserID:             workspace.OwnerID,
+				TemplateID:         workspace.TemplateID,
+				Payload:            json.RawMessage(repJSON),
+				ConnectionActivity: activity,
 			})
 			if err != nil {
 				api.Logger.Debug(ctx, "insert agent stat", slog.Error(err))
 				conn.Close(websocket.StatusInternalError, httpapi.WebsocketCloseSprintf("insert agent stat: %s", err))
 				return
 			}
+		}
+
+		if activity {
+			go activityBumpWorkspace(api.Logger.Named("activity_bump"), api.Database, workspace)
 
 			err = api.Database.UpdateWorkspaceLastUsedAt(ctx, database.UpdateWorkspaceLastUsedAtParams{
 				ID:         build.WorkspaceID,
@@ -904,6 +1695,88 @@ func (api *API) workspaceAgentReportStats(rw http.ResponseWriter, r *http.Reques
 	}
 }
 
+// withoutResourceUsage returns the report with its resource usage zeroed.
+func withoutResourceUsage(rep codersdk.AgentStatsReportResponse) codersdk.AgentStatsReportResponse {
+	rep.CPUUsedCores = 0
+	rep.CPUTotalCores = 0
+	rep.MemoryUsedBytes = 0
+	rep.MemoryTotalBytes = 0
+	rep.DiskUsedBytes = 0
+	rep.DiskTotalBytes = 0
+	return rep
+}
+
+func (api *API) workspaceStats(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	workspace := httpmw.WorkspaceParam(r)
+	if !api.Authorize(r, rbac.ActionRead, workspace) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	agents, err := api.latestWorkspaceAgents(ctx, workspace)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace agents.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	res := codersdk.WorkspaceStats{
+		Agents: make([]codersdk.WorkspaceAgentStats, 0, len(agents)),
+	}
+	for _, agent := range agents {
+		stat, err := api.Database.GetLatestAgentStat(ctx, agent.ID)
+		if xerrors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+				Message: "Internal error fetching agent stats.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+		agentStats := codersdk.WorkspaceAgentStats{
+			AgentID:   agent.ID,
+			AgentName: agent.Name,
+			CreatedAt: stat.CreatedAt,
+		}
+		err = json.Unmarshal(stat.Payload, &agentStats.Stats)
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+				Message: "Internal error reading agent stats.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+		res.Agents = append(res.Agents, agentStats)
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, res)
+}
+
+// latestWorkspaceAgents returns the agents of the latest build of a
+// workspace.
+func (api *API) latestWorkspaceAgents(ctx context.Context, workspace database.Workspace) ([]database.WorkspaceAgent, error) {
+	build, err := api.Database.GetLatestWorkspaceBuildByWorkspaceID(ctx, workspace.ID)
+	if err != nil {
+		return nil, xerrors.Errorf("get latest workspace build: %w", err)
+	}
+	resources, err := api.Database.GetWorkspaceResourcesByJobID(ctx, build.JobID)
+	if err != nil {
+		return nil, xerrors.Errorf("get workspace resources: %w", err)
+	}
+	resourceIDs := make([]uuid.UUID, 0, len(resources))
+	for _, resource := range resources {
+		resourceIDs = append(resourceIDs, resource.ID)
+	}
+	agents, err := api.Database.GetWorkspaceAgentsByResourceIDs(ctx, resourceIDs)
+	if err != nil && !xerrors.Is(err, sql.ErrNoRows) {
+		return nil, xerrors.Errorf("get workspace agents: %w", err)
+	}
+	return agents, nil
+}
+
 func (api *API) postWorkspaceAppHealth(rw http.ResponseWriter, r *http.Request) {
 	workspaceAgent := httpmw.WorkspaceAgent(r)
 	var req codersdk.PostWorkspaceAppHealthsRequest
@@ -11,6 +11,7 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -463,6 +464,89 @@ func TestWorkspaceAgentPTY(t *testing.T) {
 	expectLine(matchEchoOutput)
 }
 
+func TestWorkspaceAgentReconnectingPTYSessions(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("ConPTY appears to be inconsistent on Windows.")
+	}
+	client := coderdtest.New(t, &coderdtest.Options{
+		IncludeProvisionerDaemon: true,
+	})
+	user := coderdtest.CreateFirstUser(t, client)
+	authToken := uuid.NewString()
+	version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, &echo.Responses{
+		Parse:         echo.ParseComplete,
+		ProvisionPlan: echo.ProvisionComplete,
+		ProvisionApply: []*proto.Provision_Response{{
+			Type: &proto.Provision_Response_Complete{
+				Complete: &proto.Provision_Complete{
+					Resources: []*proto.Resource{{
+						Name: "example",
+						Type: "aws_instance",
+						Agents: []*proto.Agent{{
+							Id: uuid.NewString(),
+							Auth: &proto.Agent_Token{
+								Token: authToken,
+							},
+						}},
+					}},
+				},
+			},
+		}},
+	})
+	template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+	coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+	workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+	coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+	agentClient := codersdk.New(client.URL)
+	agentClient.SetSessionToken(authToken)
+	agentCloser := agent.New(agent.Options{
+		Client: agentClient,
+		Logger: slogtest.Make(t, nil).Named("agent").Leveled(slog.LevelDebug),
+	})
+	defer func() {
+		_ = agentCloser.Close()
+	}()
+	resources := coderdtest.AwaitWorkspaceAgents(t, client, workspace.ID)
+	agentID := resources[0].Agents[0].ID
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	id := uuid.New()
+	conn, err := client.WorkspaceAgentReconnectingPTY(ctx, agentID, id, 80, 80, "/bin/bash")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	var res codersdk.ReconnectingPTYSessionsResponse
+	require.Eventually(t, func() bool {
+		res, err = client.WorkspaceAgentReconnectingPTYSessions(ctx, agentID)
+		return err == nil && len(res.Sessions) == 1 && res.Sessions[0].Connections == 1
+	}, testutil.WaitShort, testutil.IntervalFast)
+	require.Equal(t, id.String(), res.Sessions[0].ID)
+	require.Equal(t, "/bin/bash", res.Sessions[0].Command)
+
+	// Other users can't see or kill the session.
+	member := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+	_, err = member.WorkspaceAgentReconnectingPTYSessions(ctx, agentID)
+	var apiErr *codersdk.Error
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+	err = member.DeleteWorkspaceAgentReconnectingPTYSession(ctx, agentID, id.String())
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+
+	err = client.DeleteWorkspaceAgentReconnectingPTYSession(ctx, agentID, id.String())
+	require.NoError(t, err)
+	res, err = client.WorkspaceAgentReconnectingPTYSessions(ctx, agentID)
+	require.NoError(t, err)
+	require.Empty(t, res.Sessions)
+
+	err = client.DeleteWorkspaceAgentReconnectingPTYSession(ctx, agentID, id.String())
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+}
+
 func TestWorkspaceAgentListeningPorts(t *testing.T) {
 	t.Parallel()
 
@@ -811,6 +895,276 @@ func TestWorkspaceAgentAppHealth(t *testing.T) {
 	require.EqualValues(t, codersdk.WorkspaceAppHealthUnhealthy, metadata.Apps[1].Health)
 }
 
+func TestWorkspaceAgentStartupLogs(t *testing.T) {
+	t.Parallel()
+	client := coderdtest.New(t, &coderdtest.Options{
+		IncludeProvisionerDaemon: true,
+	})
+	user := coderdtest.CreateFirstUser(t, client)
+	authToken := uuid.NewString()
+	version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, &echo.Responses{
+		Parse:         echo.ParseComplete,
+		ProvisionPlan: echo.ProvisionComplete,
+		ProvisionApply: []*proto.Provision_Response{{
+			Type: &proto.Provision_Response_Complete{
+				Complete: &proto.Provision_Complete{
+					Resources: []*proto.Resource{{
+						Name: "example",
+						Type: "aws_instance",
+						Agents: []*proto.Agent{{
+							Id: uuid.NewString(),
+							Auth: &proto.Agent_Token{
+								Token: authToken,
+							},
+						}},
+					}},
+				},
+			},
+		}},
+	})
+	coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+	template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+	workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+	build := coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	agentClient := codersdk.New(client.URL)
+	agentClient.SetSessionToken(authToken)
+	err := agentClient.PatchStartupLogs(ctx, codersdk.PatchStartupLogs{
+		Logs: []codersdk.StartupLog{{
+			CreatedAt: database.Now(),
+			Output:    "testing",
+		}},
+	})
+	require.NoError(t, err)
+
+	logs, closer, err := client.WorkspaceAgentStartupLogsAfter(ctx, build.Resources[0].Agents[0].ID, 0)
+	require.NoError(t, err)
+	defer closer.Close()
+
+	var log codersdk.WorkspaceAgentStartupLog
+	select {
+	case <-ctx.Done():
+	case log = <-logs:
+	}
+	require.Equal(t, "testing", log.Output)
+
+	err = agentClient.PatchStartupLogs(ctx, codersdk.PatchStartupLogs{
+		Logs: []codersdk.StartupLog{{
+			CreatedAt: database.Now(),
+			Output:    "done",
+		}},
+		EOF: true,
+	})
+	require.NoError(t, err)
+
+	select {
+	case <-ctx.Done():
+	case log = <-logs:
+	}
+	require.Equal(t, "done", log.Output)
+
+	// The stream ends once the agent reports the end of logs.
+	select {
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for startup logs to end")
+	case _, ok := <-logs:
+		require.False(t, ok)
+	}
+
+	// Output beyond the maximum length is rejected.
+	err = agentClient.PatchStartupLogs(ctx, codersdk.PatchStartupLogs{
+		Logs: []codersdk.StartupLog{{
+			CreatedAt: database.Now(),
+			Output:    strings.Repeat("a", (1<<20)+1),
+		}},
+	})
+	var apiError *codersdk.Error
+	require.ErrorAs(t, err, &apiError)
+	require.Equal(t, http.StatusRequestEntityTooLarge, apiError.StatusCode())
+
+	agent, err := client.WorkspaceAgent(ctx, build.Resources[0].Agents[0].ID)
+	require.NoError(t, err)
+	require.True(t, agent.StartupLogsOverflowed)
+	require.EqualValues(t, len("testing")+len("done"), agent.StartupLogsLength)
+
+	// The lines of a batch that fit are stored before it's rejected.
+	err = agentClient.PatchStartupLogs(ctx, codersdk.PatchStartupLogs{
+		Logs: []codersdk.StartupLog{{
+			CreatedAt: database.Now(),
+			Output:    strings.Repeat("b", (1<<20)-int(agent.StartupLogsLength)),
+		}, {
+			CreatedAt: database.Now(),
+			Output:    "c",
+		}},
+	})
+	require.ErrorAs(t, err, &apiError)
+	require.Equal(t, http.StatusRequestEntityTooLarge, apiError.StatusCode())
+
+	agent, err = client.WorkspaceAgent(ctx, build.Resources[0].Agents[0].ID)
+	require.NoError(t, err)
+	require.EqualValues(t, 1<<20, agent.StartupLogsLength)
+}
+
+func TestWorkspaceAgentReportLifecycle(t *testing.T) {
+	t.Parallel()
+	client := coderdtest.New(t, &coderdtest.Options{
+		IncludeProvisionerDaemon: true,
+	})
+	user := coderdtest.CreateFirstUser(t, client)
+	authToken := uuid.NewString()
+	version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, &echo.Responses{
+		Parse:         echo.ParseComplete,
+		ProvisionPlan: echo.ProvisionComplete,
+		ProvisionApply: []*proto.Provision_Response{{
+			Type: &proto.Provision_Response_Complete{
+				Complete: &proto.Provision_Complete{
+					Resources: []*proto.Resource{{
+						Name: "example",
+						Type: "aws_instance",
+						Agents: []*proto.Agent{{
+							Id: uuid.NewString(),
+							Auth: &proto.Agent_Token{
+								Token: authToken,
+							},
+							StartupScriptTimeoutSeconds: 60,
+						}},
+					}},
+				},
+			},
+		}},
+	})
+	coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+	template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+	workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+	build := coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+	agentID := build.Resources[0].Agents[0].ID
+	require.Equal(t, codersdk.WorkspaceAgentLifecycleCreated, build.Resources[0].Agents[0].LifecycleState)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	agentClient := codersdk.New(client.URL)
+	agentClient.SetSessionToken(authToken)
+
+	metadata, err := agentClient.WorkspaceAgentMetadata(ctx)
+	require.NoError(t, err)
+	require.Equal(t, time.Minute, metadata.StartupScriptTimeout)
+
+	for _, state := range []codersdk.WorkspaceAgentLifecycle{
+		codersdk.WorkspaceAgentLifecycleStarting,
+		codersdk.WorkspaceAgentLifecycleStartError,
+		codersdk.WorkspaceAgentLifecycleReady,
+		codersdk.WorkspaceAgentLifecycleShuttingDown,
+		codersdk.WorkspaceAgentLifecycleOff,
+	} {
+		err := agentClient.PostWorkspaceAgentLifecycle(ctx, codersdk.PostWorkspaceAgentLifecycleRequest{
+			State: state,
+		})
+		require.NoError(t, err, "post lifecycle state %q", state)
+
+		agent, err := client.WorkspaceAgent(ctx, agentID)
+		require.NoError(t, err)
+		require.Equal(t, state, agent.LifecycleState)
+	}
+
+	err = agentClient.PostWorkspaceAgentLifecycle(ctx, codersdk.PostWorkspaceAgentLifecycleRequest{
+		State: "nonexistent",
+	})
+	var apiErr *codersdk.Error
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+}
+
+func TestWorkspaceAgentAwaitShutdown(t *testing.T) {
+	t.Parallel()
+	client := coderdtest.New(t, &coderdtest.Options{
+		IncludeProvisionerDaemon: true,
+	})
+	user := coderdtest.CreateFirstUser(t, client)
+	authToken := uuid.NewString()
+	version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, &echo.Responses{
+		Parse:         echo.ParseComplete,
+		ProvisionPlan: echo.ProvisionComplete,
+		ProvisionApply: []*proto.Provision_Response{{
+			Type: &proto.Provision_Response_Complete{
+				Complete: &proto.Provision_Complete{
+					Resources: []*proto.Resource{{
+						Name: "example",
+						Type: "aws_instance",
+						Agents: []*proto.Agent{{
+							Id: uuid.NewString(),
+							Auth: &proto.Agent_Token{
+								Token: authToken,
+							},
+							ShutdownScript:               "echo goodbye",
+							ShutdownScriptTimeoutSeconds: 60,
+						}},
+					}},
+				},
+			},
+		}},
+	})
+	coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+	template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+	workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+	coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	agentClient := codersdk.New(client.URL)
+	agentClient.SetSessionToken(authToken)
+	metadata, err := agentClient.WorkspaceAgentMetadata(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "echo goodbye", metadata.ShutdownScript)
+	require.Equal(t, time.Minute, metadata.ShutdownScriptTimeout)
+
+	conn, err := agentClient.ListenWorkspaceAgent(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+	err = agentClient.PostWorkspaceAgentLifecycle(ctx, codersdk.PostWorkspaceAgentLifecycleRequest{
+		State: codersdk.WorkspaceAgentLifecycleReady,
+	})
+	require.NoError(t, err)
+
+	awaitErr := make(chan error, 1)
+	go func() {
+		awaitErr <- agentClient.WorkspaceAgentAwaitShutdown(ctx)
+	}()
+
+	stopBuild, err := client.CreateWorkspaceBuild(ctx, workspace.ID, codersdk.CreateWorkspaceBuildRequest{
+		Transition: codersdk.WorkspaceTransitionStop,
+	})
+	require.NoError(t, err)
+	select {
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for shutdown request")
+	case err = <-awaitErr:
+		require.NoError(t, err)
+	}
+
+	err = agentClient.PostWorkspaceAgentLifecycle(ctx, codersdk.PostWorkspaceAgentLifecycleRequest{
+		State: codersdk.WorkspaceAgentLifecycleShuttingDown,
+	})
+	require.NoError(t, err)
+
+	// The stop build must wait for the shutdown script to finish.
+	require.Never(t, func() bool {
+		build, err := client.WorkspaceBuild(ctx, stopBuild.ID)
+		return err == nil && build.Job.CompletedAt != nil
+	}, 2*time.Second, testutil.IntervalMedium)
+
+	err = agentClient.PostWorkspaceAgentLifecycle(ctx, codersdk.PostWorkspaceAgentLifecycleRequest{
+		State: codersdk.WorkspaceAgentLifecycleOff,
+	})
+	require.NoError(t, err)
+	stopBuild = coderdtest.AwaitWorkspaceBuildJob(t, client, stopBuild.ID)
+	require.Equal(t, codersdk.ProvisionerJobSucceeded, stopBuild.Job.Status)
+}
+
 // nolint:bodyclose
 func TestWorkspaceAgentsGitAuth(t *testing.T) {
 	t.Parallel()
@@ -1089,3 +1443,275 @@ func gitAuthCallback(t *testing.T, id string, client *codersdk.Client) *http.Res
 	})
 	return res
 }
+
+func TestWorkspaceAgentMetadata(t *testing.T) {
+	t.Parallel()
+	client := coderdtest.New(t, &coderdtest.Options{
+		IncludeProvisionerDaemon: true,
+	})
+	user := coderdtest.CreateFirstUser(t, client)
+	authToken := uuid.NewString()
+	version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, &echo.Responses{
+		Parse:         echo.ParseComplete,
+		ProvisionPlan: echo.ProvisionComplete,
+		ProvisionApply: []*proto.Provision_Response{{
+			Type: &proto.Provision_Response_Complete{
+				Complete: &proto.Provision_Complete{
+					Resources: []*proto.Resource{{
+						Name: "example",
+						Type: "aws_instance",
+						Agents: []*proto.Agent{{
+							Id: uuid.NewString(),
+							Auth: &proto.Agent_Token{
+								Token: authToken,
+							},
+							Metadata: []*proto.Agent_Metadata{{
+								Key:         "load",
+								DisplayName: "CPU Load",
+								Script:      "cat /proc/loadavg",
+								Interval:    5,
+								Timeout:     1,
+							}, {
+								Key:         "branch",
+								DisplayName: "Git Branch",
+								Script:      "git branch --show-current",
+								Interval:    10,
+							}},
+						}},
+					}},
+				},
+			},
+		}},
+	})
+	coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+	template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+	workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+	build := coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+	agentID := build.Resources[0].Agents[0].ID
+
+	// Items are sorted by key and have no value until the agent reports one.
+	metadata := build.Resources[0].Agents[0].Metadata
+	require.Len(t, metadata, 2)
+	require.Equal(t, "branch", metadata[0].Description.Key)
+	require.Equal(t, "load", metadata[1].Description.Key)
+	require.Equal(t, "CPU Load", metadata[1].Description.DisplayName)
+	require.EqualValues(t, 5, metadata[1].Description.Interval)
+	require.True(t, metadata[1].Result.CollectedAt.IsZero())
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	agentClient := codersdk.New(client.URL)
+	agentClient.SetSessionToken(authToken)
+
+	manifest, err := agentClient.WorkspaceAgentMetadata(ctx)
+	require.NoError(t, err)
+	require.Len(t, manifest.Metadata, 2)
+	require.Equal(t, "cat /proc/loadavg", manifest.Metadata[1].Script)
+
+	updates, err := client.WatchWorkspaceAgentMetadata(ctx, agentID)
+	require.NoError(t, err)
+	recv := func() []codersdk.WorkspaceAgentMetadataItem {
+		select {
+		case <-ctx.Done():
+			require.FailNow(t, "timed out waiting for metadata")
+		case items, ok := <-updates:
+			require.True(t, ok, "metadata stream closed")
+			return items
+		}
+		return nil
+	}
+	// The current metadata is sent immediately.
+	require.Len(t, recv(), 2)
+
+	err = agentClient.PostWorkspaceAgentMetadata(ctx, "load", codersdk.PostWorkspaceAgentMetadataRequest{
+		Value: "0.42 0.40 0.38",
+	})
+	require.NoError(t, err)
+	items := recv()
+	require.Equal(t, "0.42 0.40 0.38", items[1].Result.Value)
+	require.Empty(t, items[1].Result.Error)
+	require.False(t, items[1].Result.CollectedAt.IsZero())
+
+	err = agentClient.PostWorkspaceAgentMetadata(ctx, "branch", codersdk.PostWorkspaceAgentMetadataRequest{
+		Error: "not a git repository",
+	})
+	require.NoError(t, err)
+	items = recv()
+	require.Equal(t, "not a git repository", items[0].Result.Error)
+
+	agent, err := client.WorkspaceAgent(ctx, agentID)
+	require.NoError(t, err)
+	require.Len(t, agent.Metadata, 2)
+	require.Equal(t, "not a git repository", agent.Metadata[0].Result.Error)
+	require.Equal(t, "0.42 0.40 0.38", agent.Metadata[1].Result.Value)
+
+	err = agentClient.PostWorkspaceAgentMetadata(ctx, "load", codersdk.PostWorkspaceAgentMetadataRequest{
+		Value: strings.Repeat("a", 65536),
+	})
+	var apiErr *codersdk.Error
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+}
+
+func TestWorkspaceStats(t *testing.T) {
+	t.Parallel()
+	client := coderdtest.New(t, &coderdtest.Options{
+		IncludeProvisionerDaemon:  true,
+		AgentStatsRefreshInterval: 100 * time.Millisecond,
+	})
+	user := coderdtest.CreateFirstUser(t, client)
+	authToken := uuid.NewString()
+	version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, &echo.Responses{
+		Parse:         echo.ParseComplete,
+		ProvisionPlan: echo.ProvisionComplete,
+		ProvisionApply: []*proto.Provision_Response{{
+			Type: &proto.Provision_Response_Complete{
+				Complete: &proto.Provision_Complete{
+					Resources: []*proto.Resource{{
+						Name: "example",
+						Type: "aws_instance",
+						Agents: []*proto.Agent{{
+							Id: uuid.NewString(),
+							Auth: &proto.Agent_Token{
+								Token: authToken,
+							},
+						}},
+					}},
+				},
+			},
+		}},
+	})
+	coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+	template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+	workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+	coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	stats, err := client.WorkspaceStats(ctx, workspace.ID)
+	require.NoError(t, err)
+	require.Empty(t, stats.Agents, "agents that haven't reported are omitted")
+
+	agentClient := codersdk.New(client.URL)
+	agentClient.SetSessionToken(authToken)
+	agentCloser := agent.New(agent.Options{
+		Client: agentClient,
+		Logger: slogtest.Make(t, nil).Named("agent").Leveled(slog.LevelDebug),
+	})
+	defer func() {
+		_ = agentCloser.Close()
+	}()
+	resources := coderdtest.AwaitWorkspaceAgents(t, client, workspace.ID)
+
+	conn, err := client.DialWorkspaceAgent(ctx, resources[0].Agents[0].ID, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	sshClient, err := conn.SSHClient(ctx)
+	require.NoError(t, err)
+	defer sshClient.Close()
+	session, err := sshClient.NewSession()
+	require.NoError(t, err)
+	defer session.Close()
+	err = session.Start("sleep 30")
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		stats, err = client.WorkspaceStats(ctx, workspace.ID)
+		require.NoError(t, err)
+		return len(stats.Agents) == 1 && stats.Agents[0].Stats.SessionCountSSH == 1
+	}, testutil.WaitLong, testutil.IntervalFast)
+	agentStats := stats.Agents[0]
+	require.Equal(t, resources[0].Agents[0].ID, agentStats.AgentID)
+	require.Equal(t, resources[0].Agents[0].Name, agentStats.AgentName)
+	require.EqualValues(t, 1, agentStats.Stats.NumConns)
+	if runtime.GOOS == "linux" {
+		require.Greater(t, agentStats.Stats.CPUTotalCores, float64(0))
+		require.Greater(t, agentStats.Stats.MemoryTotalBytes, int64(0))
+		require.Greater(t, agentStats.Stats.DiskTotalBytes, int64(0))
+	}
+}
+
+func TestWorkspaceAgentReportStatsResourceUsage(t *testing.T) {
+	t.Parallel()
+	client, _, api := coderdtest.NewWithAPI(t, &coderdtest.Options{
+		IncludeProvisionerDaemon:  true,
+		AgentStatsRefreshInterval: 10 * time.Millisecond,
+	})
+	user := coderdtest.CreateFirstUser(t, client)
+	authToken := uuid.NewString()
+	version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, &echo.Responses{
+		Parse:         echo.ParseComplete,
+		ProvisionPlan: echo.ProvisionComplete,
+		ProvisionApply: []*proto.Provision_Response{{
+			Type: &proto.Provision_Response_Complete{
+				Complete: &proto.Provision_Complete{
+					Resources: []*proto.Resource{{
+						Name: "example",
+						Type: "aws_instance",
+						Agents: []*proto.Agent{{
+							Id: uuid.NewString(),
+							Auth: &proto.Agent_Token{
+								Token: authToken,
+							},
+						}},
+					}},
+				},
+			},
+		}},
+	})
+	coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+	template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+	workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+	build := coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+	agentID := build.Resources[0].Agents[0].ID
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	// Only the memory usage changes between reports until a session is
+	// opened.
+	var (
+		memoryUsed atomic.Int64
+		sessions   atomic.Int64
+	)
+	agentClient := codersdk.New(client.URL)
+	agentClient.SetSessionToken(authToken)
+	closer, err := agentClient.AgentReportStats(ctx, slogtest.Make(t, nil), func() *codersdk.AgentStats {
+		return &codersdk.AgentStats{
+			NumConns:        1,
+			SessionCountSSH: sessions.Load(),
+			MemoryUsedBytes: memoryUsed.Add(1),
+		}
+	})
+	require.NoError(t, err)
+	defer closer.Close()
+
+	var first database.AgentStat
+	require.Eventually(t, func() bool {
+		first, err = api.Database.GetLatestAgentStat(ctx, agentID)
+		return err == nil
+	}, testutil.WaitLong, testutil.IntervalFast)
+
+	// Reports where only the resource usage changed aren't stored.
+	require.Eventually(t, func() bool {
+		return memoryUsed.Load() > 5
+	}, testutil.WaitLong, testutil.IntervalFast)
+	latest, err := api.Database.GetLatestAgentStat(ctx, agentID)
+	require.NoError(t, err)
+	require.Equal(t, first.ID, latest.ID)
+
+	// Reports that differ otherwise are stored with their resource usage.
+	sessions.Store(1)
+	var payload codersdk.AgentStatsReportResponse
+	require.Eventually(t, func() bool {
+		latest, err = api.Database.GetLatestAgentStat(ctx, agentID)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(latest.Payload, &payload))
+		return payload.SessionCountSSH == 1
+	}, testutil.WaitLong, testutil.IntervalFast)
+	require.NotEqual(t, first.ID, latest.ID)
+	require.Greater(t, payload.MemoryUsedBytes, int64(5))
+	require.False(t, latest.ConnectionActivity, "session count changes aren't workspace activity")
+}
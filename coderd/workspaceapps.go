@@ -22,11 +22,13 @@ import (
 	jose "gopkg.in/square/go-jose.v2"
 
 	"cdr.dev/slog"
+	"github.com/coder/coder/coderd/audit"
 	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/coderd/httpapi"
 	"github.com/coder/coder/coderd/httpmw"
 	"github.com/coder/coder/coderd/rbac"
 	"github.com/coder/coder/coderd/tracing"
+	"github.com/coder/coder/coderd/wsbuilder"
 	"github.com/coder/coder/codersdk"
 	"github.com/coder/coder/site"
 )
@@ -80,6 +82,10 @@ func (api *API) workspaceAppsProxyPath(rw http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if !api.autostartWorkspaceForApp(rw, r, workspace, agent) {
+		return
+	}
+
 	// Determine the real path that was hit. The * URL parameter in Chi will not
 	// include the leading slash if it was present, so we need to add it back.
 	chiPath := chi.URLParam(r, "*")
@@ -97,6 +103,127 @@ func (api *API) workspaceAppsProxyPath(rw http.ResponseWriter, r *http.Request)
 	}, rw, r)
 }
 
+// autostartWorkspaceForApp starts the workspace if it's stopped and starting
+// on connect is enabled by the template or the workspace owner. It returns
+// false if the workspace is starting or stopped, in which case a page telling
+// the user has been rendered.
+func (api *API) autostartWorkspaceForApp(rw http.ResponseWriter, r *http.Request, workspace database.Workspace, agent database.WorkspaceAgent) bool {
+	ctx := r.Context()
+	// The agent is only connected while the workspace is running. Checking
+	// it first keeps every request proxied to a running workspace from
+	// querying the template and latest build.
+	if agent.LastConnectedAt.Valid &&
+		!agent.DisconnectedAt.Time.After(agent.LastConnectedAt.Time) &&
+		database.Now().Sub(agent.LastConnectedAt.Time) <= api.AgentInactiveDisconnectTimeout {
+		return true
+	}
+	build, err := api.Database.GetLatestWorkspaceBuildByWorkspaceID(ctx, workspace.ID)
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return false
+	}
+	job, err := api.Database.GetProvisionerJobByID(ctx, build.JobID)
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return false
+	}
+	jobStatus := convertProvisionerJob(job).Status
+
+	switch {
+	case build.Transition == database.WorkspaceTransitionStart && jobStatus.Active():
+		// The workspace is already starting.
+	case build.Transition == database.WorkspaceTransitionStop && jobStatus == codersdk.ProvisionerJobSucceeded:
+		enabled, err := api.autoStartOnConnect(ctx, workspace)
+		if err != nil {
+			httpapi.InternalServerError(rw, err)
+			return false
+		}
+		// Only users who can start the workspace start it, so public apps
+		// can't be used to start workspaces.
+		apiKey, ok := httpmw.APIKeyOptional(r)
+		if !enabled || !ok || !api.Authorize(r, rbac.ActionUpdate, workspace) {
+			// The agent of a stopped workspace won't connect, so there's
+			// nothing to proxy to.
+			site.RenderStaticErrorPage(rw, r, site.ErrorPageData{
+				Status:       http.StatusServiceUnavailable,
+				Title:        "Workspace Stopped",
+				Description:  fmt.Sprintf("The workspace %q is stopped. Start it and retry.", workspace.Name),
+				RetryEnabled: true,
+				DashboardURL: api.AccessURL.String(),
+			})
+			return false
+		}
+		err = api.startWorkspace(ctx, rw, r, workspace, build, apiKey.UserID)
+		if err != nil {
+			writeWorkspaceBuildError(ctx, rw, err)
+			return false
+		}
+	default:
+		return true
+	}
+
+	site.RenderStaticErrorPage(rw, r, site.ErrorPageData{
+		Status:       http.StatusAccepted,
+		Title:        "Workspace Starting",
+		Description:  fmt.Sprintf("The workspace %q is starting. Retry once it has started.", workspace.Name),
+		RetryEnabled: true,
+		DashboardURL: api.AccessURL.String(),
+	})
+	return false
+}
+
+// autoStartOnConnect returns whether the workspace should be started when
+// it's connected to while stopped.
+func (api *API) autoStartOnConnect(ctx context.Context, workspace database.Workspace) (bool, error) {
+	template, err := api.Database.GetTemplateByID(ctx, workspace.TemplateID)
+	if err != nil {
+		return false, xerrors.Errorf("get template: %w", err)
+	}
+	if template.AutoStartOnConnect {
+		return true, nil
+	}
+	owner, err := api.Database.GetUserByID(ctx, workspace.OwnerID)
+	if err != nil {
+		return false, xerrors.Errorf("get workspace owner: %w", err)
+	}
+	return owner.AutoStartOnConnect, nil
+}
+
+// startWorkspace starts a stopped workspace with the template version and
+// parameters of its prior build.
+func (api *API) startWorkspace(ctx context.Context, rw http.ResponseWriter, r *http.Request, workspace database.Workspace, priorBuild database.WorkspaceBuild, initiatorID uuid.UUID) error {
+	// We pass the workspace name to the Auditor so that it
+	// can form a friendly string for the user.
+	workspaceResourceInfo, err := json.Marshal(map[string]string{
+		"workspaceName": workspace.Name,
+	})
+	if err != nil {
+		return xerrors.Errorf("marshal workspace name: %w", err)
+	}
+	aReq, commitAudit := audit.InitRequest[database.WorkspaceBuild](rw, &audit.RequestParams{
+		Audit:            *api.Auditor.Load(),
+		Log:              api.Logger,
+		Request:          r,
+		Action:           database.AuditActionStart,
+		AdditionalFields: workspaceResourceInfo,
+	})
+	defer commitAudit()
+	aReq.Old = priorBuild
+
+	workspaceBuild, _, err := wsbuilder.Build(ctx, api.Database, workspace, wsbuilder.Options{
+		Transition:  database.WorkspaceTransitionStart,
+		Reason:      database.BuildReasonInitiator,
+		InitiatorID: initiatorID,
+	})
+	if err != nil {
+		return err
+	}
+	aReq.New = workspaceBuild
+
+	api.publishWorkspaceUpdate(ctx, workspace.ID)
+	return nil
+}
+
 // handleSubdomainApplications handles subdomain-based application proxy
 // requests (aka. DevURLs in Coder V1).
 //
@@ -195,16 +322,31 @@ func (api *API) handleSubdomainApplications(middlewares ...func(http.Handler) ht
 				if workspaceAppPtr != nil && workspaceAppPtr.SharingLevel != "" {
 					sharingLevel = workspaceAppPtr.SharingLevel
 				}
+				if workspaceAppPtr == nil && app.Port != 0 {
+					var err error
+					sharingLevel, err = api.portSharingLevel(ctx, workspace, agent.Name, app.Port, database.PortShareProtocolTCP)
+					if err != nil {
+						site.RenderStaticErrorPage(rw, r, site.ErrorPageData{
+							Status:       http.StatusInternalServerError,
+							Title:        "Internal Server Error",
+							Description:  "Could not fetch port sharing level: " + err.Error(),
+							RetryEnabled: true,
+							DashboardURL: api.AccessURL.String(),
+						})
+						return
+					}
+				}
 				if !api.verifyWorkspaceApplicationSubdomainAuth(rw, r, host, workspace, sharingLevel) {
 					return
 				}
 
 				api.proxyWorkspaceApplication(proxyApplication{
-					Workspace: workspace,
-					Agent:     agent,
-					App:       workspaceAppPtr,
-					Port:      app.Port,
-					Path:      r.URL.Path,
+					Workspace:    workspace,
+					Agent:        agent,
+					App:          workspaceAppPtr,
+					Port:         app.Port,
+					SharingLevel: sharingLevel,
+					Path:         r.URL.Path,
 				}, rw, r)
 			})).ServeHTTP(rw, r.WithContext(ctx))
 		})
@@ -295,7 +437,7 @@ func (api *API) authorizeWorkspaceApp(r *http.Request, sharingLevel database.App
 	// Regardless of share level or whether it's enabled or not, the owner of
 	// the workspace can always access applications (as long as their API key's
 	// scope allows it).
-	err := api.Authorizer.ByRoleName(ctx, roles.ID.String(), roles.Roles, roles.Scope.ToRBAC(), []string{}, rbac.ActionCreate, workspace.ApplicationConnectRBAC())
+	err := api.Authorizer.ByRoleName(ctx, roles.ID.String(), roles.Roles, roles.Scope, []string{}, rbac.ActionCreate, workspace.ApplicationConnectRBAC())
 	if err == nil {
 		return true, nil
 	}
@@ -311,7 +453,7 @@ func (api *API) authorizeWorkspaceApp(r *http.Request, sharingLevel database.App
 		// workspaces. This ensures that the key's scope has permission to
 		// connect to workspace apps.
 		object := rbac.ResourceWorkspaceApplicationConnect.WithOwner(roles.ID.String())
-		err := api.Authorizer.ByRoleName(ctx, roles.ID.String(), roles.Roles, roles.Scope.ToRBAC(), []string{}, rbac.ActionCreate, object)
+		err := api.Authorizer.ByRoleName(ctx, roles.ID.String(), roles.Roles, roles.Scope, []string{}, rbac.ActionCreate, object)
 		if err == nil {
 			return true, nil
 		}
@@ -534,7 +676,7 @@ func (api *API) workspaceApplicationAuth(rw http.ResponseWriter, r *http.Request
 	if lifetime > int64((time.Hour * 24 * 7).Seconds()) {
 		lifetime = int64((time.Hour * 24 * 7).Seconds())
 	}
-	cookie, err := api.createAPIKey(ctx, createAPIKeyParams{
+	cookie, _, err := api.createAPIKey(ctx, createAPIKeyParams{
 		UserID:          apiKey.UserID,
 		LoginType:       database.LoginTypePassword,
 		ExpiresAt:       exp,
@@ -579,7 +721,7 @@ type proxyApplication struct {
 	Port uint16
 
 	// SharingLevel MUST be set to database.AppSharingLevelOwner by default for
-	// ports.
+	// ports that aren't shared.
 	SharingLevel database.AppSharingLevel
 	// Path must either be empty or have a leading slash.
 	Path string
@@ -589,6 +731,9 @@ func (api *API) proxyWorkspaceApplication(proxyApp proxyApplication, rw http.Res
 	ctx := r.Context()
 
 	sharingLevel := database.AppSharingLevelOwner
+	if proxyApp.SharingLevel != "" {
+		sharingLevel = proxyApp.SharingLevel
+	}
 	if proxyApp.App != nil && proxyApp.App.SharingLevel != "" {
 		sharingLevel = proxyApp.App.SharingLevel
 	}
@@ -712,6 +857,12 @@ func (api *API) proxyWorkspaceApplication(proxyApp proxyApplication, rw http.Res
 	}
 	proxy.Transport = conn.HTTPTransport()
 
+	// Record the request for app usage insights. Requests to public apps
+	// without a session aren't attributed to a user, so they aren't counted.
+	if apiKey, ok := httpmw.APIKeyOptional(r); ok && proxyApp.App != nil {
+		api.insightsRollup.TrackAppRequest(proxyApp.Workspace.TemplateID, apiKey.UserID, proxyApp.App.Slug)
+	}
+
 	// end span so we don't get long lived trace data
 	tracing.EndHTTPSpan(r, http.StatusOK, trace.SpanFromContext(ctx))
 
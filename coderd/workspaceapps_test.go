@@ -20,6 +20,7 @@ import (
 	"cdr.dev/slog/sloggers/slogtest"
 	"github.com/coder/coder/agent"
 	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/coderd/httpapi"
 	"github.com/coder/coder/coderd/httpmw"
 	"github.com/coder/coder/coderd/rbac"
@@ -109,6 +110,7 @@ func setupProxyTest(t *testing.T, customAppHost ...string) (*codersdk.Client, co
 		IncludeProvisionerDaemon:    true,
 		AgentStatsRefreshInterval:   time.Millisecond * 100,
 		MetricsCacheRefreshInterval: time.Millisecond * 100,
+		InsightsRefreshInterval:     time.Millisecond * 100,
 		RealIPConfig: &httpmw.RealIPConfig{
 			TrustedOrigins: []*net.IPNet{{
 				IP:   net.ParseIP("127.0.0.1"),
@@ -143,6 +145,36 @@ func setupProxyTest(t *testing.T, customAppHost ...string) (*codersdk.Client, co
 }
 
 func createWorkspaceWithApps(t *testing.T, client *codersdk.Client, orgID uuid.UUID, appHost string, port uint16, workspaceMutators ...func(*codersdk.CreateWorkspaceRequest)) codersdk.Workspace {
+	workspace, authToken := createWorkspaceWithAppsWithoutAgent(t, client, orgID, port, workspaceMutators...)
+
+	agentClient := codersdk.New(client.URL)
+	agentClient.SetSessionToken(authToken)
+	if appHost != "" {
+		metadata, err := agentClient.WorkspaceAgentMetadata(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, fmt.Sprintf(
+			"http://{{port}}--%s--%s--%s%s",
+			proxyTestAgentName,
+			workspace.Name,
+			"testuser",
+			strings.ReplaceAll(appHost, "*", ""),
+		), metadata.VSCodePortProxyURI)
+	}
+	agentCloser := agent.New(agent.Options{
+		Client: agentClient,
+		Logger: slogtest.Make(t, nil).Named("agent"),
+	})
+	t.Cleanup(func() {
+		_ = agentCloser.Close()
+	})
+	coderdtest.AwaitWorkspaceAgents(t, client, workspace.ID)
+
+	return workspace
+}
+
+// createWorkspaceWithAppsWithoutAgent creates a workspace with the proxy test
+// apps, and returns the auth token of its agent.
+func createWorkspaceWithAppsWithoutAgent(t *testing.T, client *codersdk.Client, orgID uuid.UUID, port uint16, workspaceMutators ...func(*codersdk.CreateWorkspaceRequest)) (codersdk.Workspace, string) {
 	authToken := uuid.NewString()
 
 	appURL := fmt.Sprintf("http://127.0.0.1:%d?%s", port, proxyTestAppQuery)
@@ -199,29 +231,7 @@ func createWorkspaceWithApps(t *testing.T, client *codersdk.Client, orgID uuid.U
 	workspace := coderdtest.CreateWorkspace(t, client, orgID, template.ID, workspaceMutators...)
 	coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
 
-	agentClient := codersdk.New(client.URL)
-	agentClient.SetSessionToken(authToken)
-	if appHost != "" {
-		metadata, err := agentClient.WorkspaceAgentMetadata(context.Background())
-		require.NoError(t, err)
-		require.Equal(t, fmt.Sprintf(
-			"http://{{port}}--%s--%s--%s%s",
-			proxyTestAgentName,
-			workspace.Name,
-			"testuser",
-			strings.ReplaceAll(appHost, "*", ""),
-		), metadata.VSCodePortProxyURI)
-	}
-	agentCloser := agent.New(agent.Options{
-		Client: agentClient,
-		Logger: slogtest.Make(t, nil).Named("agent"),
-	})
-	t.Cleanup(func() {
-		_ = agentCloser.Close()
-	})
-	coderdtest.AwaitWorkspaceAgents(t, client, workspace.ID)
-
-	return workspace
+	return workspace, authToken
 }
 
 func TestWorkspaceAppsProxyPath(t *testing.T) {
@@ -305,6 +315,20 @@ func TestWorkspaceAppsProxyPath(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, proxyTestAppBody, string(body))
 		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		// The request is counted for app insights with the next refresh.
+		require.Eventually(t, func() bool {
+			insights, err := client.AppInsights(ctx, codersdk.InsightsRequest{})
+			if err != nil {
+				return false
+			}
+			for _, app := range insights.Apps {
+				if app.Slug == proxyTestAppNameOwner {
+					return app.Requests >= 1
+				}
+			}
+			return false
+		}, testutil.WaitShort, testutil.IntervalFast)
 	})
 
 	t.Run("ForwardsIP", func(t *testing.T) {
@@ -338,6 +362,84 @@ func TestWorkspaceAppsProxyPath(t *testing.T) {
 	})
 }
 
+func TestWorkspaceAppsProxyPathAutoStart(t *testing.T) {
+	t.Parallel()
+	client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+	user := coderdtest.CreateFirstUser(t, client)
+	client.HTTPClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	// The agent isn't started. Agents of stopped workspaces aren't connected,
+	// and a connected agent skips starting the workspace.
+	version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, &echo.Responses{
+		Parse:         echo.ParseComplete,
+		ProvisionPlan: echo.ProvisionComplete,
+		ProvisionApply: []*proto.Provision_Response{{
+			Type: &proto.Provision_Response_Complete{
+				Complete: &proto.Provision_Complete{
+					Resources: []*proto.Resource{{
+						Name: "example",
+						Type: "aws_instance",
+						Agents: []*proto.Agent{{
+							Id:   uuid.NewString(),
+							Name: proxyTestAgentName,
+							Auth: &proto.Agent_Token{
+								Token: uuid.NewString(),
+							},
+							Apps: []*proto.App{{
+								Slug:         proxyTestAppNameOwner,
+								DisplayName:  proxyTestAppNameOwner,
+								SharingLevel: proto.AppSharingLevel_OWNER,
+								Url:          "http://127.1.0.1:65535",
+							}},
+						}},
+					}},
+				},
+			},
+		}},
+		// Like most templates, stopping the workspace removes its agent.
+		ProvisionApplyMap: map[proto.WorkspaceTransition][]*proto.Provision_Response{
+			proto.WorkspaceTransition_STOP: echo.ProvisionComplete,
+		},
+	})
+	template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+	coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+	workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+	coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	workspace = coderdtest.MustTransitionWorkspace(t, client, workspace.ID, database.WorkspaceTransitionStart, database.WorkspaceTransitionStop)
+	require.Empty(t, workspace.LatestBuild.Resources)
+
+	// Stopped workspaces aren't started unless enabled.
+	resp, err := client.Request(ctx, http.MethodGet, fmt.Sprintf("/@me/%s/apps/%s/", workspace.Name, proxyTestAppNameOwner), nil)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	workspace = coderdtest.MustWorkspace(t, client, workspace.ID)
+	require.Equal(t, codersdk.WorkspaceTransitionStop, workspace.LatestBuild.Transition)
+
+	enabled := true
+	_, err = client.UpdateTemplateMeta(ctx, workspace.TemplateID, codersdk.UpdateTemplateMeta{
+		AutoStartOnConnect: &enabled,
+	})
+	require.NoError(t, err)
+
+	resp, err = client.Request(ctx, http.MethodGet, fmt.Sprintf("/@me/%s/apps/%s/", workspace.Name, proxyTestAppNameOwner), nil)
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	require.Contains(t, string(body), "is starting")
+
+	workspace = coderdtest.MustWorkspace(t, client, workspace.ID)
+	require.Equal(t, codersdk.WorkspaceTransitionStart, workspace.LatestBuild.Transition)
+	coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+}
+
 func TestWorkspaceApplicationAuth(t *testing.T) {
 	t.Parallel()
 
@@ -20,8 +20,8 @@ import (
 	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/coderd/httpapi"
 	"github.com/coder/coder/coderd/httpmw"
-	"github.com/coder/coder/coderd/provisionerdserver"
 	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/coderd/wsbuilder"
 	"github.com/coder/coder/codersdk"
 )
 
@@ -53,6 +53,7 @@ func (api *API) workspaceBuild(rw http.ResponseWriter, r *http.Request) {
 		data.metadata,
 		data.agents,
 		data.apps,
+		data.agentMetadata,
 	)
 	if err != nil {
 		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
@@ -159,6 +160,7 @@ func (api *API) workspaceBuilds(rw http.ResponseWriter, r *http.Request) {
 		data.metadata,
 		data.agents,
 		data.apps,
+		data.agentMetadata,
 	)
 	if err != nil {
 		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
@@ -241,6 +243,7 @@ func (api *API) workspaceBuildByBuildNumber(rw http.ResponseWriter, r *http.Requ
 		data.metadata,
 		data.agents,
 		data.apps,
+		data.agentMetadata,
 	)
 	if err != nil {
 		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
@@ -295,7 +298,14 @@ func (api *API) postWorkspaceBuilds(rw http.ResponseWriter, r *http.Request) {
 		aReq.Old = workspace
 	}
 
-	latestBuild, latestBuildErr := api.Database.GetLatestWorkspaceBuildByWorkspaceID(ctx, workspace.ID)
+	latestBuild, err := api.Database.GetLatestWorkspaceBuildByWorkspaceID(ctx, workspace.ID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching the latest workspace build.",
+			Detail:  err.Error(),
+		})
+		return
+	}
 
 	// if a user starts/stops a workspace, audit the workspace build
 	if action == rbac.ActionUpdate {
@@ -331,37 +341,7 @@ func (api *API) postWorkspaceBuilds(rw http.ResponseWriter, r *http.Request) {
 		aReq.Old = latestBuild
 	}
 
-	if createBuild.TemplateVersionID == uuid.Nil {
-		if latestBuildErr != nil {
-			httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
-				Message: "Internal error fetching the latest workspace build.",
-				Detail:  latestBuildErr.Error(),
-			})
-			return
-		}
-		createBuild.TemplateVersionID = latestBuild.TemplateVersionID
-	}
-
-	templateVersion, err := api.Database.GetTemplateVersionByID(ctx, createBuild.TemplateVersionID)
-	if errors.Is(err, sql.ErrNoRows) {
-		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
-			Message: "Template version not found.",
-			Validations: []codersdk.ValidationError{{
-				Field:  "template_version_id",
-				Detail: "template version not found",
-			}},
-		})
-		return
-	}
-	if err != nil {
-		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
-			Message: "Internal error fetching template version.",
-			Detail:  err.Error(),
-		})
-		return
-	}
-
-	template, err := api.Database.GetTemplateByID(ctx, templateVersion.TemplateID.UUID)
+	template, err := api.Database.GetTemplateByID(ctx, workspace.TemplateID)
 	if err != nil {
 		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
 			Message: "Failed to get template",
@@ -387,7 +367,6 @@ func (api *API) postWorkspaceBuilds(rw http.ResponseWriter, r *http.Request) {
 		if createBuild.Transition != codersdk.WorkspaceTransitionDelete {
 			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
 				Message: "Orphan is only permitted when deleting a workspace.",
-				Detail:  err.Error(),
 			})
 			return
 		}
@@ -401,150 +380,17 @@ func (api *API) postWorkspaceBuilds(rw http.ResponseWriter, r *http.Request) {
 		state = []byte{}
 	}
 
-	templateVersionJob, err := api.Database.GetProvisionerJobByID(ctx, templateVersion.JobID)
-	if err != nil {
-		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
-			Message: "Internal error fetching provisioner job.",
-			Detail:  err.Error(),
-		})
-		return
-	}
-	templateVersionJobStatus := convertProvisionerJob(templateVersionJob).Status
-	switch templateVersionJobStatus {
-	case codersdk.ProvisionerJobPending, codersdk.ProvisionerJobRunning:
-		httpapi.Write(ctx, rw, http.StatusNotAcceptable, codersdk.Response{
-			Message: fmt.Sprintf("The provided template version is %s. Wait for it to complete importing!", templateVersionJobStatus),
-		})
-		return
-	case codersdk.ProvisionerJobFailed:
-		httpapi.Write(ctx, rw, http.StatusPreconditionFailed, codersdk.Response{
-			Message: fmt.Sprintf("The provided template version %q has failed to import: %q. You cannot build workspaces with it!", templateVersion.Name, templateVersionJob.Error.String),
-		})
-		return
-	case codersdk.ProvisionerJobCanceled:
-		httpapi.Write(ctx, rw, http.StatusPreconditionFailed, codersdk.Response{
-			Message: "The provided template version was canceled during import. You cannot builds workspaces with it!",
-		})
-		return
-	}
-
-	tags := provisionerdserver.MutateTags(workspace.OwnerID, templateVersionJob.Tags)
-
-	// Store prior build number to compute new build number
-	var priorBuildNum int32
-	priorHistory, err := api.Database.GetLatestWorkspaceBuildByWorkspaceID(ctx, workspace.ID)
-	if err == nil {
-		priorJob, err := api.Database.GetProvisionerJobByID(ctx, priorHistory.JobID)
-		if err == nil && convertProvisionerJob(priorJob).Status.Active() {
-			httpapi.Write(ctx, rw, http.StatusConflict, codersdk.Response{
-				Message: "A workspace build is already active.",
-			})
-			return
-		}
-
-		priorBuildNum = priorHistory.BuildNumber
-	} else if !errors.Is(err, sql.ErrNoRows) {
-		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
-			Message: "Internal error fetching prior workspace build.",
-			Detail:  err.Error(),
-		})
-		return
-	}
-
-	if state == nil {
-		state = priorHistory.ProvisionerState
-	}
-
-	var workspaceBuild database.WorkspaceBuild
-	var provisionerJob database.ProvisionerJob
-	// This must happen in a transaction to ensure history can be inserted, and
-	// the prior history can update it's "after" column to point at the new.
-	err = api.Database.InTx(func(db database.Store) error {
-		existing, err := db.ParameterValues(ctx, database.ParameterValuesParams{
-			Scopes:   []database.ParameterScope{database.ParameterScopeWorkspace},
-			ScopeIds: []uuid.UUID{workspace.ID},
-		})
-		if err != nil && !xerrors.Is(err, sql.ErrNoRows) {
-			return xerrors.Errorf("Fetch previous parameters: %w", err)
-		}
-
-		// Write/Update any new params
-		now := database.Now()
-		for _, param := range createBuild.ParameterValues {
-			for _, exists := range existing {
-				// If the param exists, delete the old param before inserting the new one
-				if exists.Name == param.Name {
-					err = db.DeleteParameterValueByID(ctx, exists.ID)
-					if err != nil && !xerrors.Is(err, sql.ErrNoRows) {
-						return xerrors.Errorf("Failed to delete old param %q: %w", exists.Name, err)
-					}
-				}
-			}
-
-			_, err = db.InsertParameterValue(ctx, database.InsertParameterValueParams{
-				ID:                uuid.New(),
-				Name:              param.Name,
-				CreatedAt:         now,
-				UpdatedAt:         now,
-				Scope:             database.ParameterScopeWorkspace,
-				ScopeID:           workspace.ID,
-				SourceScheme:      database.ParameterSourceScheme(param.SourceScheme),
-				SourceValue:       param.SourceValue,
-				DestinationScheme: database.ParameterDestinationScheme(param.DestinationScheme),
-			})
-			if err != nil {
-				return xerrors.Errorf("insert parameter value: %w", err)
-			}
-		}
-
-		workspaceBuildID := uuid.New()
-		input, err := json.Marshal(provisionerdserver.WorkspaceProvisionJob{
-			WorkspaceBuildID: workspaceBuildID,
-		})
-		if err != nil {
-			return xerrors.Errorf("marshal provision job: %w", err)
-		}
-		provisionerJob, err = db.InsertProvisionerJob(ctx, database.InsertProvisionerJobParams{
-			ID:             uuid.New(),
-			CreatedAt:      database.Now(),
-			UpdatedAt:      database.Now(),
-			InitiatorID:    apiKey.UserID,
-			OrganizationID: template.OrganizationID,
-			Provisioner:    template.Provisioner,
-			Type:           database.ProvisionerJobTypeWorkspaceBuild,
-			StorageMethod:  templateVersionJob.StorageMethod,
-			FileID:         templateVersionJob.FileID,
-			Input:          input,
-			Tags:           tags,
-		})
-		if err != nil {
-			return xerrors.Errorf("insert provisioner job: %w", err)
-		}
-
-		workspaceBuild, err = db.InsertWorkspaceBuild(ctx, database.InsertWorkspaceBuildParams{
-			ID:                workspaceBuildID,
-			CreatedAt:         database.Now(),
-			UpdatedAt:         database.Now(),
-			WorkspaceID:       workspace.ID,
-			TemplateVersionID: templateVersion.ID,
-			BuildNumber:       priorBuildNum + 1,
-			ProvisionerState:  state,
-			InitiatorID:       apiKey.UserID,
-			Transition:        database.WorkspaceTransition(createBuild.Transition),
-			JobID:             provisionerJob.ID,
-			Reason:            database.BuildReasonInitiator,
-		})
-		if err != nil {
-			return xerrors.Errorf("insert workspace build: %w", err)
-		}
-
-		return nil
-	}, nil)
+	workspaceBuild, provisionerJob, err := wsbuilder.Build(ctx, api.Database, workspace, wsbuilder.Options{
+		Transition:          database.WorkspaceTransition(createBuild.Transition),
+		Reason:              database.BuildReasonInitiator,
+		InitiatorID:         apiKey.UserID,
+		TemplateVersionID:   createBuild.TemplateVersionID,
+		ProvisionerState:    state,
+		ParameterValues:     createBuild.ParameterValues,
+		RichParameterValues: createBuild.RichParameterValues,
+	})
 	if err != nil {
-		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
-			Message: "Internal error inserting workspace build.",
-			Detail:  err.Error(),
-		})
+		writeWorkspaceBuildError(ctx, rw, err)
 		return
 	}
 
@@ -569,6 +415,7 @@ func (api *API) postWorkspaceBuilds(rw http.ResponseWriter, r *http.Request) {
 		[]database.WorkspaceResourceMetadatum{},
 		[]database.WorkspaceAgent{},
 		[]database.WorkspaceApp{},
+		[]database.WorkspaceAgentMetadatum{},
 	)
 	if err != nil {
 		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
@@ -583,6 +430,24 @@ func (api *API) postWorkspaceBuilds(rw http.ResponseWriter, r *http.Request) {
 	httpapi.Write(ctx, rw, http.StatusCreated, apiBuild)
 }
 
+// writeWorkspaceBuildError writes the response for an error returned by
+// wsbuilder.Build.
+func writeWorkspaceBuildError(ctx context.Context, rw http.ResponseWriter, err error) {
+	var buildErr *wsbuilder.BuildError
+	if !xerrors.As(err, &buildErr) {
+		httpapi.InternalServerError(rw, err)
+		return
+	}
+	resp := codersdk.Response{
+		Message:     buildErr.Message,
+		Validations: buildErr.Validations,
+	}
+	if buildErr.Err != nil {
+		resp.Detail = buildErr.Err.Error()
+	}
+	httpapi.Write(ctx, rw, buildErr.Status, resp)
+}
+
 func (api *API) patchCancelWorkspaceBuild(rw http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	workspaceBuild := httpmw.WorkspaceBuildParam(r)
@@ -721,13 +586,41 @@ func (api *API) workspaceBuildState(rw http.ResponseWriter, r *http.Request) {
 	_, _ = rw.Write(workspaceBuild.ProvisionerState)
 }
 
+func (api *API) workspaceBuildParameters(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	workspaceBuild := httpmw.WorkspaceBuildParam(r)
+	workspace := httpmw.WorkspaceParam(r)
+	if !api.Authorize(r, rbac.ActionRead, workspace) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	dbParams, err := api.Database.GetWorkspaceBuildParameters(ctx, workspaceBuild.ID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace build parameters.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	params := make([]codersdk.WorkspaceBuildParameter, 0, len(dbParams))
+	for _, dbParam := range dbParams {
+		params = append(params, codersdk.WorkspaceBuildParameter{
+			Name:  dbParam.Name,
+			Value: dbParam.Value,
+		})
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, params)
+}
+
 type workspaceBuildsData struct {
-	users     []database.User
-	jobs      []database.ProvisionerJob
-	resources []database.WorkspaceResource
-	metadata  []database.WorkspaceResourceMetadatum
-	agents    []database.WorkspaceAgent
-	apps      []database.WorkspaceApp
+	users         []database.User
+	jobs          []database.ProvisionerJob
+	resources     []database.WorkspaceResource
+	metadata      []database.WorkspaceResourceMetadatum
+	agents        []database.WorkspaceAgent
+	apps          []database.WorkspaceApp
+	agentMetadata []database.WorkspaceAgentMetadatum
 }
 
 func (api *API) workspaceBuildsData(ctx context.Context, workspaces []database.Workspace, workspaceBuilds []database.WorkspaceBuild) (workspaceBuildsData, error) {
@@ -798,13 +691,19 @@ func (api *API) workspaceBuildsData(ctx context.Context, workspaces []database.W
 		return workspaceBuildsData{}, xerrors.Errorf("fetching workspace apps: %w", err)
 	}
 
+	agentMetadata, err := api.Database.GetWorkspaceAgentMetadataByAgentIDs(ctx, agentIDs)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return workspaceBuildsData{}, xerrors.Errorf("fetching workspace agent metadata: %w", err)
+	}
+
 	return workspaceBuildsData{
-		users:     users,
-		jobs:      jobs,
-		resources: resources,
-		metadata:  metadata,
-		agents:    agents,
-		apps:      apps,
+		users:         users,
+		jobs:          jobs,
+		resources:     resources,
+		metadata:      metadata,
+		agents:        agents,
+		apps:          apps,
+		agentMetadata: agentMetadata,
 	}, nil
 }
 
@@ -817,6 +716,7 @@ func (api *API) convertWorkspaceBuilds(
 	resourceMetadata []database.WorkspaceResourceMetadatum,
 	resourceAgents []database.WorkspaceAgent,
 	agentApps []database.WorkspaceApp,
+	agentMetadata []database.WorkspaceAgentMetadatum,
 ) ([]codersdk.WorkspaceBuild, error) {
 	workspaceByID := map[uuid.UUID]database.Workspace{}
 	for _, workspace := range workspaces {
@@ -847,6 +747,7 @@ func (api *API) convertWorkspaceBuilds(
 			resourceMetadata,
 			resourceAgents,
 			agentApps,
+			agentMetadata,
 		)
 		if err != nil {
 			return nil, xerrors.Errorf("converting workspace build: %w", err)
@@ -867,6 +768,7 @@ func (api *API) convertWorkspaceBuild(
 	resourceMetadata []database.WorkspaceResourceMetadatum,
 	resourceAgents []database.WorkspaceAgent,
 	agentApps []database.WorkspaceApp,
+	agentMetadata []database.WorkspaceAgentMetadatum,
 ) (codersdk.WorkspaceBuild, error) {
 	userByID := map[uuid.UUID]database.User{}
 	for _, user := range users {
@@ -888,6 +790,10 @@ func (api *API) convertWorkspaceBuild(
 	for _, app := range agentApps {
 		appsByAgentID[app.AgentID] = append(appsByAgentID[app.AgentID], app)
 	}
+	metadataByAgentID := map[uuid.UUID][]database.WorkspaceAgentMetadatum{}
+	for _, metadata := range agentMetadata {
+		metadataByAgentID[metadata.WorkspaceAgentID] = append(metadataByAgentID[metadata.WorkspaceAgentID], metadata)
+	}
 
 	owner, exists := userByID[workspace.OwnerID]
 	if !exists {
@@ -905,7 +811,7 @@ func (api *API) convertWorkspaceBuild(
 		apiAgents := make([]codersdk.WorkspaceAgent, 0)
 		for _, agent := range agents {
 			apps := appsByAgentID[agent.ID]
-			apiAgent, err := convertWorkspaceAgent(api.DERPMap, *api.TailnetCoordinator.Load(), agent, convertApps(apps), api.AgentInactiveDisconnectTimeout, api.DeploymentConfig.AgentFallbackTroubleshootingURL.Value)
+			apiAgent, err := convertWorkspaceAgent(api.DERPMap, *api.TailnetCoordinator.Load(), agent, convertApps(apps), convertWorkspaceAgentMetadata(metadataByAgentID[agent.ID]), api.AgentInactiveDisconnectTimeout, api.DeploymentConfig.AgentFallbackTroubleshootingURL.Value)
 			if err != nil {
 				return codersdk.WorkspaceBuild{}, xerrors.Errorf("converting workspace agent: %w", err)
 			}
@@ -16,6 +16,7 @@ import (
 	"github.com/coder/coder/coderd/audit"
 	"github.com/coder/coder/coderd/coderdtest"
 	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/util/ptr"
 	"github.com/coder/coder/codersdk"
 	"github.com/coder/coder/provisioner/echo"
 	"github.com/coder/coder/provisionersdk/proto"
@@ -600,3 +601,162 @@ func TestWorkspaceBuildStatus(t *testing.T) {
 	require.Len(t, auditor.AuditLogs, numLogs)
 	require.Equal(t, database.AuditActionDelete, auditor.AuditLogs[numLogs-1].Action)
 }
+
+func TestWorkspaceBuildParameters(t *testing.T) {
+	t.Parallel()
+	client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+	user := coderdtest.CreateFirstUser(t, client)
+	version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, &echo.Responses{
+		Parse: echo.ParseComplete,
+		ProvisionPlan: []*proto.Provision_Response{{
+			Type: &proto.Provision_Response_Complete{
+				Complete: &proto.Provision_Complete{
+					Parameters: []*proto.RichParameter{{
+						Name:         "region",
+						Type:         codersdk.RichParameterTypeString,
+						DefaultValue: "us",
+						Options: []*proto.RichParameterOption{{
+							Name:  "United States",
+							Value: "us",
+							Icon:  "/emojis/1f1fa-1f1f8.png",
+						}, {
+							Name:  "Europe",
+							Value: "eu",
+						}},
+					}, {
+						Name:          "disk_size",
+						Type:          codersdk.RichParameterTypeNumber,
+						Mutable:       true,
+						DefaultValue:  "10",
+						ValidationMin: ptr.Ref(int32(5)),
+						ValidationMax: ptr.Ref(int32(50)),
+					}},
+				},
+			},
+		}},
+		ProvisionApply: echo.ProvisionComplete,
+	})
+	coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+	template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	templateVersionParameters, err := client.TemplateVersionRichParameters(ctx, version.ID)
+	require.NoError(t, err)
+	require.Len(t, templateVersionParameters, 2)
+	require.Equal(t, "region", templateVersionParameters[0].Name)
+	require.Len(t, templateVersionParameters[0].Options, 2)
+	require.Equal(t, "/emojis/1f1fa-1f1f8.png", templateVersionParameters[0].Options[0].Icon)
+	require.Equal(t, "disk_size", templateVersionParameters[1].Name)
+	require.EqualValues(t, 50, *templateVersionParameters[1].ValidationMax)
+
+	t.Run("Invalid", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := client.CreateWorkspace(ctx, user.OrganizationID, codersdk.Me, codersdk.CreateWorkspaceRequest{
+			TemplateID: template.ID,
+			Name:       "invalid",
+			RichParameterValues: []codersdk.WorkspaceBuildParameter{{
+				Name:  "disk_size",
+				Value: "100",
+			}},
+		})
+		var apiError *codersdk.Error
+		require.ErrorAs(t, err, &apiError)
+		require.Equal(t, http.StatusBadRequest, apiError.StatusCode())
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID, func(req *codersdk.CreateWorkspaceRequest) {
+			req.RichParameterValues = []codersdk.WorkspaceBuildParameter{{
+				Name:  "region",
+				Value: "eu",
+			}}
+		})
+		coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+		parameters, err := client.WorkspaceBuildParameters(ctx, workspace.LatestBuild.ID)
+		require.NoError(t, err)
+		require.Equal(t, []codersdk.WorkspaceBuildParameter{
+			{Name: "disk_size", Value: "10"},
+			{Name: "region", Value: "eu"},
+		}, parameters)
+
+		// Immutable parameters cannot be changed.
+		_, err = client.CreateWorkspaceBuild(ctx, workspace.ID, codersdk.CreateWorkspaceBuildRequest{
+			Transition: codersdk.WorkspaceTransitionStart,
+			RichParameterValues: []codersdk.WorkspaceBuildParameter{{
+				Name:  "region",
+				Value: "us",
+			}},
+		})
+		var apiError *codersdk.Error
+		require.ErrorAs(t, err, &apiError)
+		require.Equal(t, http.StatusBadRequest, apiError.StatusCode())
+
+		build, err := client.CreateWorkspaceBuild(ctx, workspace.ID, codersdk.CreateWorkspaceBuildRequest{
+			Transition: codersdk.WorkspaceTransitionStart,
+			RichParameterValues: []codersdk.WorkspaceBuildParameter{{
+				Name:  "disk_size",
+				Value: "20",
+			}},
+		})
+		require.NoError(t, err)
+		coderdtest.AwaitWorkspaceBuildJob(t, client, build.ID)
+
+		// Values from the previous build are reused.
+		parameters, err = client.WorkspaceBuildParameters(ctx, build.ID)
+		require.NoError(t, err)
+		require.Equal(t, []codersdk.WorkspaceBuildParameter{
+			{Name: "disk_size", Value: "20"},
+			{Name: "region", Value: "eu"},
+		}, parameters)
+	})
+
+	t.Run("Stop", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID, func(req *codersdk.CreateWorkspaceRequest) {
+			req.RichParameterValues = []codersdk.WorkspaceBuildParameter{{
+				Name:  "disk_size",
+				Value: "30",
+			}}
+		})
+		coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+		// Parameters can only be changed when starting a workspace.
+		_, err := client.CreateWorkspaceBuild(ctx, workspace.ID, codersdk.CreateWorkspaceBuildRequest{
+			Transition: codersdk.WorkspaceTransitionStop,
+			RichParameterValues: []codersdk.WorkspaceBuildParameter{{
+				Name:  "disk_size",
+				Value: "40",
+			}},
+		})
+		var apiError *codersdk.Error
+		require.ErrorAs(t, err, &apiError)
+		require.Equal(t, http.StatusBadRequest, apiError.StatusCode())
+
+		build, err := client.CreateWorkspaceBuild(ctx, workspace.ID, codersdk.CreateWorkspaceBuildRequest{
+			Transition: codersdk.WorkspaceTransitionStop,
+		})
+		require.NoError(t, err)
+		coderdtest.AwaitWorkspaceBuildJob(t, client, build.ID)
+
+		// Values from the previous build are carried forward.
+		parameters, err := client.WorkspaceBuildParameters(ctx, build.ID)
+		require.NoError(t, err)
+		require.Equal(t, []codersdk.WorkspaceBuildParameter{
+			{Name: "disk_size", Value: "30"},
+			{Name: "region", Value: "us"},
+		}, parameters)
+	})
+}
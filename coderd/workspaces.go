@@ -3,7 +3,6 @@ package coderd
 import (
 	"context"
 	"database/sql"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
@@ -25,11 +24,11 @@ import (
 	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/coderd/httpapi"
 	"github.com/coder/coder/coderd/httpmw"
-	"github.com/coder/coder/coderd/provisionerdserver"
 	"github.com/coder/coder/coderd/rbac"
 	"github.com/coder/coder/coderd/telemetry"
 	"github.com/coder/coder/coderd/tracing"
 	"github.com/coder/coder/coderd/util/ptr"
+	"github.com/coder/coder/coderd/wsbuilder"
 	"github.com/coder/coder/codersdk"
 )
 
@@ -41,6 +40,7 @@ var (
 	errTTLMax              = xerrors.New("time until shutdown must be less than 7 days")
 	errDeadlineTooSoon     = xerrors.New("new deadline must be at least 30 minutes in the future")
 	errDeadlineBeforeStart = xerrors.New("new deadline must be before workspace start time")
+	errDeadlineAfterPolicy = xerrors.New("new deadline exceeds the maximum allowed by the template")
 )
 
 func (api *API) workspace(rw http.ResponseWriter, r *http.Request) {
@@ -297,7 +297,16 @@ func (api *API) postWorkspacesByOrganization(rw http.ResponseWriter, r *http.Req
 		return
 	}
 
-	dbAutostartSchedule, err := validWorkspaceSchedule(createWorkspace.AutostartSchedule)
+	policy, err := schedule.NewTemplatePolicy(template)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error parsing template schedule policy.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	dbAutostartSchedule, err := validWorkspaceSchedule(createWorkspace.AutostartSchedule, policy)
 	if err != nil {
 		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
 			Message:     "Invalid Autostart Schedule.",
@@ -307,6 +316,13 @@ func (api *API) postWorkspacesByOrganization(rw http.ResponseWriter, r *http.Req
 	}
 
 	dbTTL, err := validWorkspaceTTLMillis(createWorkspace.TTLMillis, template.DefaultTTL)
+	if err == nil {
+		// Workspaces of templates with a maximum TTL always have autostop.
+		if !dbTTL.Valid && policy.MaxTTL > 0 {
+			dbTTL = sql.NullInt64{Int64: int64(policy.MaxTTL), Valid: true}
+		}
+		err = validWorkspaceTTLPolicy(dbTTL, policy)
+	}
 	if err != nil {
 		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
 			Message:     "Invalid Workspace Time to Shutdown.",
@@ -338,50 +354,12 @@ func (api *API) postWorkspacesByOrganization(rw http.ResponseWriter, r *http.Req
 		return
 	}
 
-	templateVersion, err := api.Database.GetTemplateVersionByID(ctx, template.ActiveVersionID)
-	if err != nil {
-		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
-			Message: "Internal error fetching template version.",
-			Detail:  err.Error(),
-		})
-		return
-	}
-	templateVersionJob, err := api.Database.GetProvisionerJobByID(ctx, templateVersion.JobID)
-	if err != nil {
-		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
-			Message: "Internal error fetching template version job.",
-			Detail:  err.Error(),
-		})
-		return
-	}
-	templateVersionJobStatus := convertProvisionerJob(templateVersionJob).Status
-	switch templateVersionJobStatus {
-	case codersdk.ProvisionerJobPending, codersdk.ProvisionerJobRunning:
-		httpapi.Write(ctx, rw, http.StatusNotAcceptable, codersdk.Response{
-			Message: fmt.Sprintf("The provided template version is %s. Wait for it to complete importing!", templateVersionJobStatus),
-		})
-		return
-	case codersdk.ProvisionerJobFailed:
-		httpapi.Write(ctx, rw, http.StatusPreconditionFailed, codersdk.Response{
-			Message: fmt.Sprintf("The provided template version %q has failed to import. You cannot create workspaces using it!", templateVersion.Name),
-		})
-		return
-	case codersdk.ProvisionerJobCanceled:
-		httpapi.Write(ctx, rw, http.StatusPreconditionFailed, codersdk.Response{
-			Message: "The provided template version was canceled during import. You cannot create workspaces using it!",
-		})
-		return
-	}
-
-	tags := provisionerdserver.MutateTags(user.ID, templateVersionJob.Tags)
-
 	var (
 		provisionerJob database.ProvisionerJob
 		workspaceBuild database.WorkspaceBuild
 	)
 	err = api.Database.InTx(func(db database.Store) error {
 		now := database.Now()
-		workspaceBuildID := uuid.New()
 		// Workspaces are created without any versions.
 		workspace, err = db.InsertWorkspace(ctx, database.InsertWorkspaceParams{
 			ID:                uuid.New(),
@@ -420,51 +398,17 @@ func (api *API) postWorkspacesByOrganization(rw http.ResponseWriter, r *http.Req
 			}
 		}
 
-		input, err := json.Marshal(provisionerdserver.WorkspaceProvisionJob{
-			WorkspaceBuildID: workspaceBuildID,
+		workspaceBuild, provisionerJob, err = wsbuilder.Build(ctx, db, workspace, wsbuilder.Options{
+			Transition:          database.WorkspaceTransitionStart,
+			Reason:              database.BuildReasonInitiator,
+			InitiatorID:         apiKey.UserID,
+			TemplateVersionID:   template.ActiveVersionID,
+			RichParameterValues: createWorkspace.RichParameterValues,
 		})
-		if err != nil {
-			return xerrors.Errorf("marshal provision job: %w", err)
-		}
-		provisionerJob, err = db.InsertProvisionerJob(ctx, database.InsertProvisionerJobParams{
-			ID:             uuid.New(),
-			CreatedAt:      now,
-			UpdatedAt:      now,
-			InitiatorID:    apiKey.UserID,
-			OrganizationID: template.OrganizationID,
-			Provisioner:    template.Provisioner,
-			Type:           database.ProvisionerJobTypeWorkspaceBuild,
-			StorageMethod:  templateVersionJob.StorageMethod,
-			FileID:         templateVersionJob.FileID,
-			Input:          input,
-			Tags:           tags,
-		})
-		if err != nil {
-			return xerrors.Errorf("insert provisioner job: %w", err)
-		}
-		workspaceBuild, err = db.InsertWorkspaceBuild(ctx, database.InsertWorkspaceBuildParams{
-			ID:                workspaceBuildID,
-			CreatedAt:         now,
-			UpdatedAt:         now,
-			WorkspaceID:       workspace.ID,
-			TemplateVersionID: templateVersion.ID,
-			InitiatorID:       apiKey.UserID,
-			Transition:        database.WorkspaceTransitionStart,
-			JobID:             provisionerJob.ID,
-			BuildNumber:       1,           // First build!
-			Deadline:          time.Time{}, // provisionerd will set this upon success
-			Reason:            database.BuildReasonInitiator,
-		})
-		if err != nil {
-			return xerrors.Errorf("insert workspace build: %w", err)
-		}
-		return nil
+		return err
 	}, nil)
 	if err != nil {
-		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
-			Message: "Internal error creating workspace.",
-			Detail:  err.Error(),
-		})
+		writeWorkspaceBuildError(ctx, rw, err)
 		return
 	}
 	aReq.New = workspace
@@ -492,6 +436,7 @@ func (api *API) postWorkspacesByOrganization(rw http.ResponseWriter, r *http.Req
 		[]database.WorkspaceResourceMetadatum{},
 		[]database.WorkspaceAgent{},
 		[]database.WorkspaceApp{},
+		[]database.WorkspaceAgentMetadatum{},
 	)
 	if err != nil {
 		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
@@ -614,7 +559,24 @@ func (api *API) putWorkspaceAutostart(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	dbSched, err := validWorkspaceSchedule(req.Schedule)
+	template, err := api.Database.GetTemplateByID(ctx, workspace.TemplateID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Error fetching workspace template.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	policy, err := schedule.NewTemplatePolicy(template)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error parsing template schedule policy.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	dbSched, err := validWorkspaceSchedule(req.Schedule, policy)
 	if err != nil {
 		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
 			Message:     "Invalid autostart schedule.",
@@ -678,10 +640,18 @@ func (api *API) putWorkspaceTTL(rw http.ResponseWriter, r *http.Request) {
 			return xerrors.Errorf("fetch workspace template: %w", err)
 		}
 
+		policy, err := schedule.NewTemplatePolicy(template)
+		if err != nil {
+			return xerrors.Errorf("parse template schedule policy: %w", err)
+		}
+
 		dbTTL, err = validWorkspaceTTLMillis(req.TTLMillis, template.DefaultTTL)
 		if err != nil {
 			return codersdk.ValidationError{Field: "ttl_ms", Detail: err.Error()}
 		}
+		if err := validWorkspaceTTLPolicy(dbTTL, policy); err != nil {
+			return codersdk.ValidationError{Field: "ttl_ms", Detail: err.Error()}
+		}
 		if err := s.UpdateWorkspaceTTL(ctx, database.UpdateWorkspaceTTLParams{
 			ID:  workspace.ID,
 			Ttl: dbTTL,
@@ -764,8 +734,21 @@ func (api *API) putExtendWorkspace(rw http.ResponseWriter, r *http.Request) {
 			return xerrors.Errorf("workspace shutdown is manual")
 		}
 
+		template, err := s.GetTemplateByID(ctx, workspace.TemplateID)
+		if err != nil {
+			code = http.StatusInternalServerError
+			resp.Message = "Error fetching workspace template."
+			return xerrors.Errorf("get template: %w", err)
+		}
+		policy, err := schedule.NewTemplatePolicy(template)
+		if err != nil {
+			code = http.StatusInternalServerError
+			resp.Message = "Error parsing template schedule policy."
+			return xerrors.Errorf("parse template schedule policy: %w", err)
+		}
+
 		newDeadline := req.Deadline.UTC()
-		if err := validWorkspaceDeadline(job.CompletedAt.Time, newDeadline); err != nil {
+		if err := validWorkspaceDeadline(job.CompletedAt.Time, newDeadline, policy); err != nil {
 			// NOTE(Cian): Putting the error in the Message field on request from the FE folks.
 			// Normally, we would put the validation error in Validations, but this endpoint is
 			// not tied to a form or specific named user input on the FE.
@@ -937,6 +920,7 @@ func (api *API) workspaceData(ctx context.Context, workspaces []database.Workspa
 		data.metadata,
 		data.agents,
 		data.apps,
+		data.agentMetadata,
 	)
 	if err != nil {
 		return workspaceData{}, xerrors.Errorf("convert workspace builds: %w", err)
@@ -1008,6 +992,11 @@ func convertWorkspace(
 		autostartSchedule = &workspace.AutostartSchedule.String
 	}
 
+	var deletingAt *time.Time
+	if workspace.DeletingAt.Valid {
+		deletingAt = &workspace.DeletingAt.Time
+	}
+
 	ttlMillis := convertWorkspaceTTLMillis(workspace.Ttl)
 	return codersdk.Workspace{
 		ID:                  workspace.ID,
@@ -1025,6 +1014,7 @@ func convertWorkspace(
 		AutostartSchedule:   autostartSchedule,
 		TTLMillis:           ttlMillis,
 		LastUsedAt:          workspace.LastUsedAt,
+		DeletingAt:          deletingAt,
 	}
 }
 
@@ -1065,7 +1055,15 @@ func validWorkspaceTTLMillis(millis *int64, def int64) (sql.NullInt64, error) {
 	}, nil
 }
 
-func validWorkspaceDeadline(startedAt, newDeadline time.Time) error {
+func validWorkspaceTTLPolicy(ttl sql.NullInt64, policy schedule.TemplatePolicy) error {
+	if !ttl.Valid {
+		return policy.ValidateTTL(nil)
+	}
+	dur := time.Duration(ttl.Int64)
+	return policy.ValidateTTL(&dur)
+}
+
+func validWorkspaceDeadline(startedAt, newDeadline time.Time, policy schedule.TemplatePolicy) error {
 	soon := time.Now().Add(29 * time.Minute)
 	if newDeadline.Before(soon) {
 		return errDeadlineTooSoon
@@ -1076,19 +1074,27 @@ func validWorkspaceDeadline(startedAt, newDeadline time.Time) error {
 		return errDeadlineBeforeStart
 	}
 
+	if newDeadline.After(policy.Deadline(startedAt, newDeadline)) {
+		return errDeadlineAfterPolicy
+	}
+
 	return nil
 }
 
-func validWorkspaceSchedule(s *string) (sql.NullString, error) {
+func validWorkspaceSchedule(s *string, policy schedule.TemplatePolicy) (sql.NullString, error) {
 	if ptr.NilOrEmpty(s) {
 		return sql.NullString{}, nil
 	}
 
-	_, err := schedule.Weekly(*s)
+	sched, err := schedule.Weekly(*s)
 	if err != nil {
 		return sql.NullString{}, err
 	}
 
+	if err := policy.ValidateAutostart(sched); err != nil {
+		return sql.NullString{}, err
+	}
+
 	return sql.NullString{
 		Valid:  true,
 		String: *s,
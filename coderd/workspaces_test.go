@@ -388,6 +388,47 @@ func TestPostWorkspacesByOrganization(t *testing.T) {
 		require.NoError(t, err)
 		require.EqualValues(t, exp, *ws.TTLMillis)
 	})
+
+	t.Run("TemplateSchedulePolicy", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		maxTTL := 8 * time.Hour
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID, func(ctr *codersdk.CreateTemplateRequest) {
+			ctr.MaxTTLMillis = ptr.Ref(maxTTL.Milliseconds())
+			ctr.AutostartRequirement = &codersdk.TemplateAutostartRequirement{
+				DaysOfWeek: []string{"monday", "tuesday", "wednesday", "thursday", "friday"},
+				StartHour:  0,
+				EndHour:    24,
+			}
+		})
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		// No TTL provided should use the template maximum.
+		req := codersdk.CreateWorkspaceRequest{
+			TemplateID: template.ID,
+			Name:       "testing",
+		}
+		ws, err := client.CreateWorkspace(ctx, template.OrganizationID, codersdk.Me, req)
+		require.NoError(t, err)
+		require.EqualValues(t, maxTTL.Milliseconds(), *ws.TTLMillis)
+
+		// TTL above the template maximum should fail.
+		req.Name = "testing2"
+		req.TTLMillis = ptr.Ref((12 * time.Hour).Milliseconds())
+		_, err = client.CreateWorkspace(ctx, template.OrganizationID, codersdk.Me, req)
+		require.ErrorContains(t, err, "time until shutdown must be at most 8h0m0s")
+
+		// Autostart on a day that is not allowed should fail.
+		req.TTLMillis = nil
+		req.AutostartSchedule = ptr.Ref("CRON_TZ=US/Central 30 9 * * *")
+		_, err = client.CreateWorkspace(ctx, template.OrganizationID, codersdk.Me, req)
+		require.ErrorContains(t, err, "autostart is not allowed on")
+	})
 }
 
 func TestWorkspaceByOwnerAndName(t *testing.T) {
@@ -1137,6 +1178,45 @@ func TestWorkspaceUpdateAutostart(t *testing.T) {
 		})
 	}
 
+	t.Run("TemplatePolicy", func(t *testing.T) {
+		t.Parallel()
+		var (
+			client   = coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+			user     = coderdtest.CreateFirstUser(t, client)
+			version  = coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+			_        = coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+			template = coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID, func(ctr *codersdk.CreateTemplateRequest) {
+				ctr.AutostartRequirement = &codersdk.TemplateAutostartRequirement{
+					DaysOfWeek: []string{"monday", "tuesday", "wednesday", "thursday", "friday"},
+					StartHour:  6,
+					EndHour:    10,
+				}
+			})
+			workspace = coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID, func(cwr *codersdk.CreateWorkspaceRequest) {
+				cwr.AutostartSchedule = nil
+				cwr.TTLMillis = nil
+			})
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		err := client.UpdateWorkspaceAutostart(ctx, workspace.ID, codersdk.UpdateWorkspaceAutostartRequest{
+			Schedule: ptr.Ref("CRON_TZ=Europe/Dublin 30 9 * * 1-5"),
+		})
+		require.NoError(t, err)
+
+		err = client.UpdateWorkspaceAutostart(ctx, workspace.ID, codersdk.UpdateWorkspaceAutostartRequest{
+			Schedule: ptr.Ref("CRON_TZ=Europe/Dublin 30 9 * * 6"),
+		})
+		require.ErrorContains(t, err, "autostart is not allowed on Saturday")
+
+		err = client.UpdateWorkspaceAutostart(ctx, workspace.ID, codersdk.UpdateWorkspaceAutostartRequest{
+			Schedule: ptr.Ref("CRON_TZ=Europe/Dublin 30 11 * * 1-5"),
+		})
+		require.ErrorContains(t, err, "autostart is only allowed from 06:00 to 10:00")
+	})
+
 	t.Run("NotFound", func(t *testing.T) {
 		var (
 			client = coderdtest.New(t, nil)
@@ -1197,6 +1277,22 @@ func TestWorkspaceUpdateTTL(t *testing.T) {
 			ttlMillis:     ptr.Ref((24*7*time.Hour + time.Minute).Milliseconds()),
 			expectedError: "time until shutdown must be less than 7 days",
 		},
+		{
+			name:          "above template maximum ttl",
+			ttlMillis:     ptr.Ref((12 * time.Hour).Milliseconds()),
+			expectedError: "time until shutdown must be at most 8h0m0s",
+			modifyTemplate: func(ctr *codersdk.CreateTemplateRequest) {
+				ctr.MaxTTLMillis = ptr.Ref((8 * time.Hour).Milliseconds())
+			},
+		},
+		{
+			name:          "disable ttl with template maximum ttl",
+			ttlMillis:     nil,
+			expectedError: "autostop is required by the template",
+			modifyTemplate: func(ctr *codersdk.CreateTemplateRequest) {
+				ctr.MaxTTLMillis = ptr.Ref((8 * time.Hour).Milliseconds())
+			},
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -1334,6 +1430,40 @@ func TestWorkspaceExtend(t *testing.T) {
 	require.WithinDuration(t, oldDeadline.Add(-time.Hour), updated.LatestBuild.Deadline.Time, time.Minute)
 }
 
+func TestWorkspaceExtendTemplateMaxTTL(t *testing.T) {
+	t.Parallel()
+	var (
+		maxTTL   = 8 * time.Hour
+		client   = coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user     = coderdtest.CreateFirstUser(t, client)
+		version  = coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		_        = coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template = coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID, func(ctr *codersdk.CreateTemplateRequest) {
+			ctr.MaxTTLMillis = ptr.Ref(maxTTL.Milliseconds())
+		})
+		workspace = coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID, func(cwr *codersdk.CreateWorkspaceRequest) {
+			cwr.AutostartSchedule = nil
+			cwr.TTLMillis = ptr.Ref(time.Hour.Milliseconds())
+		})
+		_ = coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	// Extending within the template maximum should succeed.
+	err := client.PutExtendWorkspace(ctx, workspace.ID, codersdk.PutExtendWorkspaceRequest{
+		Deadline: time.Now().Add(maxTTL - time.Hour),
+	})
+	require.NoError(t, err)
+
+	// Extending past the template maximum should fail.
+	err = client.PutExtendWorkspace(ctx, workspace.ID, codersdk.PutExtendWorkspaceRequest{
+		Deadline: time.Now().Add(maxTTL + time.Hour),
+	})
+	require.ErrorContains(t, err, "new deadline exceeds the maximum allowed by the template")
+}
+
 func TestWorkspaceWatcher(t *testing.T) {
 	t.Parallel()
 	client, closeFunc := coderdtest.NewWithProvisionerCloser(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
@@ -0,0 +1,414 @@
+// Package wsbuilder creates workspace builds. Builds started by users,
+// by connecting to stopped workspaces and by the autobuild executor are all
+// validated and inserted the same way.
+package wsbuilder
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/database/db2sdk"
+	"github.com/coder/coder/coderd/provisionerdserver"
+	"github.com/coder/coder/codersdk"
+)
+
+// Options are the options for a new workspace build.
+type Options struct {
+	Transition  database.WorkspaceTransition
+	Reason      database.BuildReason
+	InitiatorID uuid.UUID
+	// TemplateVersionID is the template version to build. The template
+	// version of the previous build is used if it's not set.
+	TemplateVersionID uuid.UUID
+	// ProvisionerState replaces the state of the previous build if it's not
+	// nil.
+	ProvisionerState []byte
+	// ParameterValues are written to the workspace scope, replacing existing
+	// values with the same name.
+	ParameterValues []codersdk.CreateParameterRequest
+	// RichParameterValues are the values of the rich parameters of the
+	// template version. Values not included are carried over from the
+	// previous build, or set to their default value.
+	RichParameterValues []codersdk.WorkspaceBuildParameter
+}
+
+// BuildError is returned when a workspace build can't be created. Status is
+// the HTTP status code for the error.
+type BuildError struct {
+	Status      int
+	Message     string
+	Validations []codersdk.ValidationError
+	Err         error
+}
+
+func (e *BuildError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %s", e.Message, e.Err)
+	}
+	return e.Message
+}
+
+func (e *BuildError) Unwrap() error {
+	return e.Err
+}
+
+func internalError(message string, err error) error {
+	return &BuildError{
+		Status:  http.StatusInternalServerError,
+		Message: message,
+		Err:     err,
+	}
+}
+
+// Build validates and inserts a workspace build, its provisioner job and its
+// parameters in a transaction. Errors are *BuildError.
+func Build(ctx context.Context, db database.Store, workspace database.Workspace, opts Options) (database.WorkspaceBuild, database.ProvisionerJob, error) {
+	var (
+		workspaceBuild database.WorkspaceBuild
+		provisionerJob database.ProvisionerJob
+	)
+	err := db.InTx(func(tx database.Store) error {
+		var err error
+		workspaceBuild, provisionerJob, err = build(ctx, tx, workspace, opts)
+		return err
+	}, nil)
+	if err != nil {
+		var buildErr *BuildError
+		if xerrors.As(err, &buildErr) {
+			return database.WorkspaceBuild{}, database.ProvisionerJob{}, buildErr
+		}
+		return database.WorkspaceBuild{}, database.ProvisionerJob{}, internalError("Internal error inserting workspace build.", err)
+	}
+	return workspaceBuild, provisionerJob, nil
+}
+
+func build(ctx context.Context, db database.Store, workspace database.Workspace, opts Options) (database.WorkspaceBuild, database.ProvisionerJob, error) {
+	var (
+		priorBuild    database.WorkspaceBuild
+		hasPriorBuild bool
+	)
+	priorBuild, err := db.GetLatestWorkspaceBuildByWorkspaceID(ctx, workspace.ID)
+	switch {
+	case err == nil:
+		hasPriorBuild = true
+	case errors.Is(err, sql.ErrNoRows):
+	default:
+		return database.WorkspaceBuild{}, database.ProvisionerJob{}, internalError("Internal error fetching prior workspace build.", err)
+	}
+
+	templateVersionID := opts.TemplateVersionID
+	if templateVersionID == uuid.Nil {
+		if !hasPriorBuild {
+			return database.WorkspaceBuild{}, database.ProvisionerJob{}, &BuildError{
+				Status:  http.StatusBadRequest,
+				Message: "A template version is required for the first workspace build.",
+				Validations: []codersdk.ValidationError{{
+					Field:  "template_version_id",
+					Detail: "template version is required",
+				}},
+			}
+		}
+		templateVersionID = priorBuild.TemplateVersionID
+	}
+	templateVersion, err := db.GetTemplateVersionByID(ctx, templateVersionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.WorkspaceBuild{}, database.ProvisionerJob{}, &BuildError{
+			Status:  http.StatusBadRequest,
+			Message: "Template version not found.",
+			Validations: []codersdk.ValidationError{{
+				Field:  "template_version_id",
+				Detail: "template version not found",
+			}},
+		}
+	}
+	if err != nil {
+		return database.WorkspaceBuild{}, database.ProvisionerJob{}, internalError("Internal error fetching template version.", err)
+	}
+	template, err := db.GetTemplateByID(ctx, workspace.TemplateID)
+	if err != nil {
+		return database.WorkspaceBuild{}, database.ProvisionerJob{}, internalError("Internal error fetching template.", err)
+	}
+
+	templateVersionJob, err := db.GetProvisionerJobByID(ctx, templateVersion.JobID)
+	if err != nil {
+		return database.WorkspaceBuild{}, database.ProvisionerJob{}, internalError("Internal error fetching template version job.", err)
+	}
+	switch status := db2sdk.ProvisionerJobStatus(templateVersionJob); status {
+	case codersdk.ProvisionerJobPending, codersdk.ProvisionerJobRunning:
+		return database.WorkspaceBuild{}, database.ProvisionerJob{}, &BuildError{
+			Status:  http.StatusNotAcceptable,
+			Message: fmt.Sprintf("The provided template version is %s. Wait for it to complete importing!", status),
+		}
+	case codersdk.ProvisionerJobFailed:
+		return database.WorkspaceBuild{}, database.ProvisionerJob{}, &BuildError{
+			Status:  http.StatusPreconditionFailed,
+			Message: fmt.Sprintf("The provided template version %q has failed to import: %q. You cannot build workspaces with it!", templateVersion.Name, templateVersionJob.Error.String),
+		}
+	case codersdk.ProvisionerJobCanceled:
+		return database.WorkspaceBuild{}, database.ProvisionerJob{}, &BuildError{
+			Status:  http.StatusPreconditionFailed,
+			Message: "The provided template version was canceled during import. You cannot build workspaces with it!",
+		}
+	}
+
+	if hasPriorBuild {
+		priorJob, err := db.GetProvisionerJobByID(ctx, priorBuild.JobID)
+		if err != nil {
+			return database.WorkspaceBuild{}, database.ProvisionerJob{}, internalError("Internal error fetching prior provisioner job.", err)
+		}
+		if db2sdk.ProvisionerJobStatus(priorJob).Active() {
+			return database.WorkspaceBuild{}, database.ProvisionerJob{}, &BuildError{
+				Status:  http.StatusConflict,
+				Message: "A workspace build is already active.",
+			}
+		}
+	}
+
+	dbTemplateVersionParams, err := db.GetTemplateVersionParameters(ctx, templateVersion.ID)
+	if err != nil {
+		return database.WorkspaceBuild{}, database.ProvisionerJob{}, internalError("Internal error fetching template version parameters.", err)
+	}
+	templateVersionParams, err := db2sdk.TemplateVersionParameters(dbTemplateVersionParams)
+	if err != nil {
+		return database.WorkspaceBuild{}, database.ProvisionerJob{}, internalError("Internal error converting template version parameters.", err)
+	}
+	var priorParams []database.WorkspaceBuildParameter
+	if hasPriorBuild {
+		priorParams, err = db.GetWorkspaceBuildParameters(ctx, priorBuild.ID)
+		if err != nil {
+			return database.WorkspaceBuild{}, database.ProvisionerJob{}, internalError("Internal error fetching prior workspace build parameters.", err)
+		}
+	}
+	var (
+		buildParams database.InsertWorkspaceBuildParametersParams
+		validations []codersdk.ValidationError
+	)
+	if opts.Transition == database.WorkspaceTransitionStart {
+		buildParams, validations = resolveParameters(templateVersionParams, priorParams, opts.RichParameterValues)
+	} else {
+		buildParams, validations = carryParameters(templateVersionParams, priorParams, opts.RichParameterValues)
+	}
+	if len(validations) > 0 {
+		return database.WorkspaceBuild{}, database.ProvisionerJob{}, &BuildError{
+			Status:      http.StatusBadRequest,
+			Message:     "Invalid rich parameter values.",
+			Validations: validations,
+		}
+	}
+
+	state := opts.ProvisionerState
+	if state == nil {
+		state = priorBuild.ProvisionerState
+	}
+
+	err = insertParameterValues(ctx, db, workspace.ID, opts.ParameterValues)
+	if err != nil {
+		return database.WorkspaceBuild{}, database.ProvisionerJob{}, internalError("Internal error inserting parameter values.", err)
+	}
+
+	now := database.Now()
+	workspaceBuildID := uuid.New()
+	input, err := json.Marshal(provisionerdserver.WorkspaceProvisionJob{
+		WorkspaceBuildID: workspaceBuildID,
+	})
+	if err != nil {
+		return database.WorkspaceBuild{}, database.ProvisionerJob{}, internalError("Internal error marshaling provision job.", err)
+	}
+	provisionerJob, err := db.InsertProvisionerJob(ctx, database.InsertProvisionerJobParams{
+		ID:             uuid.New(),
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		InitiatorID:    opts.InitiatorID,
+		OrganizationID: template.OrganizationID,
+		Provisioner:    template.Provisioner,
+		Type:           database.ProvisionerJobTypeWorkspaceBuild,
+		StorageMethod:  templateVersionJob.StorageMethod,
+		FileID:         templateVersionJob.FileID,
+		Input:          input,
+		Tags:           provisionerdserver.MutateTags(workspace.OwnerID, templateVersionJob.Tags),
+	})
+	if err != nil {
+		return database.WorkspaceBuild{}, database.ProvisionerJob{}, internalError("Internal error inserting provisioner job.", err)
+	}
+	workspaceBuild, err := db.InsertWorkspaceBuild(ctx, database.InsertWorkspaceBuildParams{
+		ID:                workspaceBuildID,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+		WorkspaceID:       workspace.ID,
+		TemplateVersionID: templateVersion.ID,
+		BuildNumber:       priorBuild.BuildNumber + 1,
+		ProvisionerState:  state,
+		InitiatorID:       opts.InitiatorID,
+		Transition:        opts.Transition,
+		JobID:             provisionerJob.ID,
+		Reason:            opts.Reason,
+	})
+	if err != nil {
+		return database.WorkspaceBuild{}, database.ProvisionerJob{}, internalError("Internal error inserting workspace build.", err)
+	}
+	buildParams.WorkspaceBuildID = workspaceBuild.ID
+	err = db.InsertWorkspaceBuildParameters(ctx, buildParams)
+	if err != nil {
+		return database.WorkspaceBuild{}, database.ProvisionerJob{}, internalError("Internal error inserting workspace build parameters.", err)
+	}
+	return workspaceBuild, provisionerJob, nil
+}
+
+// insertParameterValues writes parameter values to the workspace scope,
+// replacing existing values with the same name.
+func insertParameterValues(ctx context.Context, db database.Store, workspaceID uuid.UUID, values []codersdk.CreateParameterRequest) error {
+	if len(values) == 0 {
+		return nil
+	}
+	existing, err := db.ParameterValues(ctx, database.ParameterValuesParams{
+		Scopes:   []database.ParameterScope{database.ParameterScopeWorkspace},
+		ScopeIds: []uuid.UUID{workspaceID},
+	})
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return xerrors.Errorf("fetch previous parameters: %w", err)
+	}
+
+	now := database.Now()
+	for _, param := range values {
+		for _, exists := range existing {
+			if exists.Name != param.Name {
+				continue
+			}
+			err = db.DeleteParameterValueByID(ctx, exists.ID)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return xerrors.Errorf("delete old param %q: %w", exists.Name, err)
+			}
+		}
+
+		_, err = db.InsertParameterValue(ctx, database.InsertParameterValueParams{
+			ID:                uuid.New(),
+			Name:              param.Name,
+			CreatedAt:         now,
+			UpdatedAt:         now,
+			Scope:             database.ParameterScopeWorkspace,
+			ScopeID:           workspaceID,
+			SourceScheme:      database.ParameterSourceScheme(param.SourceScheme),
+			SourceValue:       param.SourceValue,
+			DestinationScheme: database.ParameterDestinationScheme(param.DestinationScheme),
+		})
+		if err != nil {
+			return xerrors.Errorf("insert parameter value: %w", err)
+		}
+	}
+	return nil
+}
+
+// resolveParameters returns the values of the rich parameters of a
+// template version for a build that starts a workspace. Values that aren't
+// requested are carried over from the previous build, or set to their
+// default. Validation errors are returned for invalid values and for changes
+// to immutable parameters.
+func resolveParameters(
+	templateVersionParams []codersdk.TemplateVersionParameter,
+	previousParams []database.WorkspaceBuildParameter,
+	requestedParams []codersdk.WorkspaceBuildParameter,
+) (database.InsertWorkspaceBuildParametersParams, []codersdk.ValidationError) {
+	var (
+		resolved    database.InsertWorkspaceBuildParametersParams
+		validations []codersdk.ValidationError
+		previous    = map[string]string{}
+		requested   = map[string]string{}
+		declared    = map[string]bool{}
+	)
+	for _, param := range previousParams {
+		previous[param.Name] = param.Value
+	}
+	for _, param := range requestedParams {
+		requested[param.Name] = param.Value
+	}
+
+	for _, param := range templateVersionParams {
+		declared[param.Name] = true
+		previousValue, hasPrevious := previous[param.Name]
+		value, ok := requested[param.Name]
+		switch {
+		case ok:
+			if !param.Mutable && hasPrevious && value != previousValue {
+				validations = append(validations, codersdk.ValidationError{
+					Field:  "rich_parameter_values",
+					Detail: fmt.Sprintf("Parameter %q is immutable and cannot be updated.", param.Name),
+				})
+				continue
+			}
+		case hasPrevious:
+			value = previousValue
+		default:
+			value = param.DefaultValue
+		}
+
+		err := codersdk.ValidateWorkspaceBuildParameter(param, value)
+		if err != nil {
+			validations = append(validations, codersdk.ValidationError{
+				Field:  "rich_parameter_values",
+				Detail: fmt.Sprintf("Parameter %q is invalid: %s", param.Name, err),
+			})
+			continue
+		}
+		resolved.Name = append(resolved.Name, param.Name)
+		resolved.Value = append(resolved.Value, value)
+	}
+
+	for _, param := range requestedParams {
+		if !declared[param.Name] {
+			validations = append(validations, codersdk.ValidationError{
+				Field:  "rich_parameter_values",
+				Detail: fmt.Sprintf("Parameter %q is not declared by the template version.", param.Name),
+			})
+		}
+	}
+	return resolved, validations
+}
+
+// carryParameters returns the values of the rich parameters
+// of a template version for a build that stops or deletes a workspace. The
+// values of the previous build are carried forward as-is, even if they're no
+// longer valid, so workspaces can always be stopped and deleted. Parameters
+// the previous build didn't have are set to their default. Validation errors
+// are returned for requested values, since parameters can only be changed
+// when starting a workspace.
+func carryParameters(
+	templateVersionParams []codersdk.TemplateVersionParameter,
+	previousParams []database.WorkspaceBuildParameter,
+	requestedParams []codersdk.WorkspaceBuildParameter,
+) (database.InsertWorkspaceBuildParametersParams, []codersdk.ValidationError) {
+	var (
+		resolved    database.InsertWorkspaceBuildParametersParams
+		validations []codersdk.ValidationError
+		previous    = map[string]string{}
+	)
+	for _, param := range previousParams {
+		previous[param.Name] = param.Value
+	}
+	for _, param := range templateVersionParams {
+		value, ok := previous[param.Name]
+		if !ok {
+			value = param.DefaultValue
+		}
+		resolved.Name = append(resolved.Name, param.Name)
+		resolved.Value = append(resolved.Value, value)
+	}
+
+	for _, param := range requestedParams {
+		if value, ok := previous[param.Name]; ok && value == param.Value {
+			continue
+		}
+		validations = append(validations, codersdk.ValidationError{
+			Field:  "rich_parameter_values",
+			Detail: fmt.Sprintf("Parameter %q can only be changed when starting a workspace.", param.Name),
+		})
+	}
+	return resolved, validations
+}
@@ -0,0 +1,181 @@
+package wsbuilder_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/database/databasefake"
+	"github.com/coder/coder/coderd/wsbuilder"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/testutil"
+)
+
+func TestBuild(t *testing.T) {
+	t.Parallel()
+
+	t.Run("CarriesParameters", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := testutil.Context(t)
+		defer cancel()
+		db := databasefake.New()
+		workspace := setupWorkspace(ctx, t, db)
+
+		build, _, err := wsbuilder.Build(ctx, db, workspace, wsbuilder.Options{
+			Transition:        database.WorkspaceTransitionStart,
+			Reason:            database.BuildReasonInitiator,
+			InitiatorID:       workspace.OwnerID,
+			TemplateVersionID: workspace.TemplateID,
+			RichParameterValues: []codersdk.WorkspaceBuildParameter{{
+				Name:  "region",
+				Value: "eu",
+			}},
+		})
+		require.NoError(t, err)
+		require.EqualValues(t, 1, build.BuildNumber)
+
+		// Builds can't be created while another is active.
+		_, _, err = wsbuilder.Build(ctx, db, workspace, wsbuilder.Options{
+			Transition:  database.WorkspaceTransitionStop,
+			Reason:      database.BuildReasonAutostop,
+			InitiatorID: workspace.OwnerID,
+		})
+		requireBuildError(t, err, http.StatusConflict)
+
+		completeJob(ctx, t, db, build.JobID)
+		build, _, err = wsbuilder.Build(ctx, db, workspace, wsbuilder.Options{
+			Transition:  database.WorkspaceTransitionStop,
+			Reason:      database.BuildReasonAutostop,
+			InitiatorID: workspace.OwnerID,
+		})
+		require.NoError(t, err)
+		require.EqualValues(t, 2, build.BuildNumber)
+		require.Equal(t, database.BuildReasonAutostop, build.Reason)
+
+		params, err := db.GetWorkspaceBuildParameters(ctx, build.ID)
+		require.NoError(t, err)
+		require.Len(t, params, 1)
+		require.Equal(t, "eu", params[0].Value)
+	})
+
+	t.Run("InvalidParameters", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := testutil.Context(t)
+		defer cancel()
+		db := databasefake.New()
+		workspace := setupWorkspace(ctx, t, db)
+
+		_, _, err := wsbuilder.Build(ctx, db, workspace, wsbuilder.Options{
+			Transition:        database.WorkspaceTransitionStart,
+			Reason:            database.BuildReasonInitiator,
+			InitiatorID:       workspace.OwnerID,
+			TemplateVersionID: workspace.TemplateID,
+			RichParameterValues: []codersdk.WorkspaceBuildParameter{{
+				Name:  "region",
+				Value: "mars",
+			}},
+		})
+		requireBuildError(t, err, http.StatusBadRequest)
+	})
+}
+
+// setupWorkspace inserts a workspace whose template has an imported
+// template version with the same ID as the template.
+func setupWorkspace(ctx context.Context, t *testing.T, db database.Store) database.Workspace {
+	t.Helper()
+	now := database.Now()
+	orgID := uuid.New()
+	ownerID := uuid.New()
+	templateID := uuid.New()
+
+	job, err := db.InsertProvisionerJob(ctx, database.InsertProvisionerJobParams{
+		ID:             uuid.New(),
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		OrganizationID: orgID,
+		Provisioner:    database.ProvisionerTypeEcho,
+		StorageMethod:  database.ProvisionerStorageMethodFile,
+		FileID:         uuid.New(),
+		Type:           database.ProvisionerJobTypeTemplateVersionImport,
+		Input:          []byte("{}"),
+	})
+	require.NoError(t, err)
+	completeJob(ctx, t, db, job.ID)
+	_, err = db.InsertTemplateVersion(ctx, database.InsertTemplateVersionParams{
+		ID:             templateID,
+		TemplateID:     uuid.NullUUID{UUID: templateID, Valid: true},
+		OrganizationID: orgID,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		Name:           "v1",
+		JobID:          job.ID,
+	})
+	require.NoError(t, err)
+	_, err = db.InsertTemplateVersionParameter(ctx, database.InsertTemplateVersionParameterParams{
+		TemplateVersionID: templateID,
+		Name:              "region",
+		Type:              codersdk.RichParameterTypeString,
+		DefaultValue:      "us",
+		Options:           []byte(`[{"name":"United States","value":"us"},{"name":"Europe","value":"eu"}]`),
+	})
+	require.NoError(t, err)
+	_, err = db.InsertTemplate(ctx, database.InsertTemplateParams{
+		ID:              templateID,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+		OrganizationID:  orgID,
+		Name:            "template",
+		Provisioner:     database.ProvisionerTypeEcho,
+		ActiveVersionID: templateID,
+	})
+	require.NoError(t, err)
+	workspace, err := db.InsertWorkspace(ctx, database.InsertWorkspaceParams{
+		ID:             uuid.New(),
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		OwnerID:        ownerID,
+		OrganizationID: orgID,
+		TemplateID:     templateID,
+		Name:           "workspace",
+	})
+	require.NoError(t, err)
+	return workspace
+}
+
+func completeJob(ctx context.Context, t *testing.T, db database.Store, id uuid.UUID) {
+	t.Helper()
+	now := database.Now()
+	job, err := db.GetProvisionerJobByID(ctx, id)
+	require.NoError(t, err)
+	tags, err := json.Marshal(job.Tags)
+	require.NoError(t, err)
+	acquired, err := db.AcquireProvisionerJob(ctx, database.AcquireProvisionerJobParams{
+		StartedAt:      sql.NullTime{Time: now, Valid: true},
+		WorkerID:       uuid.NullUUID{UUID: uuid.New(), Valid: true},
+		Types:          []database.ProvisionerType{job.Provisioner},
+		Tags:           tags,
+		OrganizationID: job.OrganizationID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, id, acquired.ID)
+	err = db.UpdateProvisionerJobWithCompleteByID(ctx, database.UpdateProvisionerJobWithCompleteByIDParams{
+		ID:          id,
+		UpdatedAt:   now,
+		CompletedAt: sql.NullTime{Time: now, Valid: true},
+	})
+	require.NoError(t, err)
+}
+
+func requireBuildError(t *testing.T, err error, status int) {
+	t.Helper()
+	var buildErr *wsbuilder.BuildError
+	require.True(t, xerrors.As(err, &buildErr), "error is not a build error: %v", err)
+	require.Equal(t, status, buildErr.Status)
+}
@@ -221,3 +221,24 @@ func (*client) PostWorkspaceAgentAppHealth(_ context.Context, _ codersdk.PostWor
 func (*client) PostWorkspaceAgentVersion(_ context.Context, _ string) error {
 	return nil
 }
+
+func (*client) PostWorkspaceAgentLifecycle(_ context.Context, _ codersdk.PostWorkspaceAgentLifecycleRequest) error {
+	return nil
+}
+
+func (*client) WorkspaceAgentAwaitShutdown(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (*client) PostWorkspaceAgentMetadata(_ context.Context, _ string, _ codersdk.PostWorkspaceAgentMetadataRequest) error {
+	return nil
+}
+
+func (*client) PostWorkspaceAgentSessionRecording(_ context.Context, _ codersdk.PostWorkspaceAgentSessionRecordingRequest) error {
+	return nil
+}
+
+func (*client) PatchStartupLogs(_ context.Context, _ codersdk.PatchStartupLogs) error {
+	return nil
+}
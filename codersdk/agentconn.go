@@ -1,6 +1,7 @@
 package codersdk
 
 import (
+	"bytes"
 	"context"
 	"encoding/binary"
 	"encoding/json"
@@ -9,6 +10,7 @@ import (
 	"net"
 	"net/http"
 	"net/netip"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
@@ -329,3 +331,82 @@ func (c *AgentConn) ListeningPorts(ctx context.Context) (ListeningPortsResponse,
 	var resp ListeningPortsResponse
 	return resp, json.NewDecoder(res.Body).Decode(&resp)
 }
+
+// ReconnectingPTYSessionsResponse lists the reconnecting PTYs running in a
+// workspace agent.
+type ReconnectingPTYSessionsResponse struct {
+	Sessions []ReconnectingPTYSession `json:"sessions"`
+}
+
+// ReconnectingPTYSession is a terminal that keeps running in the workspace
+// agent between connections, so clients can reattach to it.
+type ReconnectingPTYSession struct {
+	ID string `json:"id"`
+	// Name is empty until the session is named.
+	Name string `json:"name"`
+	// Command is empty when the session runs the user's shell.
+	Command   string    `json:"command"`
+	StartedAt time.Time `json:"started_at"`
+	// Connections is the number of clients attached to the session.
+	Connections int `json:"connections"`
+}
+
+// ReconnectingPTYSessions returns the reconnecting PTYs running in the agent.
+func (c *AgentConn) ReconnectingPTYSessions(ctx context.Context) (ReconnectingPTYSessionsResponse, error) {
+	ctx, span := tracing.StartSpan(ctx)
+	defer span.End()
+	res, err := c.doStatisticsRequest(ctx, http.MethodGet, "/api/v0/reconnecting-ptys", nil)
+	if err != nil {
+		return ReconnectingPTYSessionsResponse{}, xerrors.Errorf("do request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return ReconnectingPTYSessionsResponse{}, readBodyAsError(res)
+	}
+
+	var resp ReconnectingPTYSessionsResponse
+	return resp, json.NewDecoder(res.Body).Decode(&resp)
+}
+
+// UpdateReconnectingPTYSessionRequest renames a reconnecting PTY. An empty
+// name clears it.
+type UpdateReconnectingPTYSessionRequest struct {
+	Name string `json:"name"`
+}
+
+// UpdateReconnectingPTYSession renames a reconnecting PTY.
+func (c *AgentConn) UpdateReconnectingPTYSession(ctx context.Context, id string, req UpdateReconnectingPTYSessionRequest) (ReconnectingPTYSession, error) {
+	ctx, span := tracing.StartSpan(ctx)
+	defer span.End()
+	data, err := json.Marshal(req)
+	if err != nil {
+		return ReconnectingPTYSession{}, xerrors.Errorf("marshal request: %w", err)
+	}
+	res, err := c.doStatisticsRequest(ctx, http.MethodPatch, "/api/v0/reconnecting-ptys/"+url.PathEscape(id), bytes.NewReader(data))
+	if err != nil {
+		return ReconnectingPTYSession{}, xerrors.Errorf("do request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return ReconnectingPTYSession{}, readBodyAsError(res)
+	}
+
+	var session ReconnectingPTYSession
+	return session, json.NewDecoder(res.Body).Decode(&session)
+}
+
+// CloseReconnectingPTYSession kills the process of a reconnecting PTY and
+// disconnects all clients attached to it.
+func (c *AgentConn) CloseReconnectingPTYSession(ctx context.Context, id string) error {
+	ctx, span := tracing.StartSpan(ctx)
+	defer span.End()
+	res, err := c.doStatisticsRequest(ctx, http.MethodDelete, "/api/v0/reconnecting-ptys/"+url.PathEscape(id), nil)
+	if err != nil {
+		return xerrors.Errorf("do request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusNoContent {
+		return readBodyAsError(res)
+	}
+	return nil
+}
@@ -20,7 +20,11 @@ type APIKey struct {
 	UpdatedAt       time.Time   `json:"updated_at" validate:"required"`
 	LoginType       LoginType   `json:"login_type" validate:"required"`
 	Scope           APIKeyScope `json:"scope" validate:"required"`
+	TokenName       string      `json:"token_name"`
 	LifetimeSeconds int64       `json:"lifetime_seconds" validate:"required"`
+	// AllowList limits the scope to specific resources, formatted as
+	// "type:id". See CreateTokenRequest.
+	AllowList []string `json:"allow_list"`
 }
 
 type LoginType string
@@ -37,10 +41,24 @@ type APIKeyScope string
 const (
 	APIKeyScopeAll                APIKeyScope = "all"
 	APIKeyScopeApplicationConnect APIKeyScope = "application_connect"
+	APIKeyScopeRead               APIKeyScope = "read"
+	APIKeyScopeWorkspaceStartStop APIKeyScope = "workspace_start_stop"
+	APIKeyScopeTemplatePush       APIKeyScope = "template_push"
 )
 
 type CreateTokenRequest struct {
-	Scope APIKeyScope `json:"scope"`
+	// TokenName must be unique among the user's tokens. A random name is
+	// generated if it's empty.
+	TokenName string `json:"token_name"`
+	// Lifetime defaults to 30 days, and must not exceed the maximum token
+	// lifetime of the deployment.
+	Lifetime time.Duration `json:"lifetime"`
+	Scope    APIKeyScope   `json:"scope"`
+	// AllowList limits the scope to specific resources, formatted as
+	// "type:id", such as "workspace:<uuid>". The id is "*" for every resource
+	// of the type. Only workspaces and templates are supported. Resource
+	// types without an element in the list aren't limited.
+	AllowList []string `json:"allow_list"`
 }
 
 // GenerateAPIKeyResponse contains an API key for a user.
@@ -48,7 +66,8 @@ type GenerateAPIKeyResponse struct {
 	Key string `json:"key"`
 }
 
-// CreateToken generates an API key that doesn't expire.
+// CreateToken generates an API key that expires after the requested
+// lifetime.
 func (c *Client) CreateToken(ctx context.Context, userID string, req CreateTokenRequest) (GenerateAPIKeyResponse, error) {
 	res, err := c.Request(ctx, http.MethodPost, fmt.Sprintf("/api/v2/users/%s/keys/tokens", userID), req)
 	if err != nil {
@@ -93,6 +112,20 @@ func (c *Client) GetTokens(ctx context.Context, userID string) ([]APIKey, error)
 	return apiKey, json.NewDecoder(res.Body).Decode(&apiKey)
 }
 
+// APIKeyByName returns a token of the user by name.
+func (c *Client) APIKeyByName(ctx context.Context, userID string, name string) (*APIKey, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/users/%s/keys/tokens/%s", userID, name), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode > http.StatusCreated {
+		return nil, readBodyAsError(res)
+	}
+	apiKey := &APIKey{}
+	return apiKey, json.NewDecoder(res.Body).Decode(apiKey)
+}
+
 // GetAPIKey returns the api key by id.
 func (c *Client) GetAPIKey(ctx context.Context, userID string, id string) (*APIKey, error) {
 	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/users/%s/keys/%s", userID, id), nil)
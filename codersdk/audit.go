@@ -14,15 +14,19 @@ import (
 type ResourceType string
 
 const (
-	ResourceTypeOrganization    ResourceType = "organization"
-	ResourceTypeTemplate        ResourceType = "template"
-	ResourceTypeTemplateVersion ResourceType = "template_version"
-	ResourceTypeUser            ResourceType = "user"
-	ResourceTypeWorkspace       ResourceType = "workspace"
-	ResourceTypeWorkspaceBuild  ResourceType = "workspace_build"
-	ResourceTypeGitSSHKey       ResourceType = "git_ssh_key"
-	ResourceTypeAPIKey          ResourceType = "api_key"
-	ResourceTypeGroup           ResourceType = "group"
+	ResourceTypeOrganization              ResourceType = "organization"
+	ResourceTypeTemplate                  ResourceType = "template"
+	ResourceTypeTemplateVersion           ResourceType = "template_version"
+	ResourceTypeUser                      ResourceType = "user"
+	ResourceTypeWorkspace                 ResourceType = "workspace"
+	ResourceTypeWorkspaceBuild            ResourceType = "workspace_build"
+	ResourceTypeGitSSHKey                 ResourceType = "git_ssh_key"
+	ResourceTypeAPIKey                    ResourceType = "api_key"
+	ResourceTypeGroup                     ResourceType = "group"
+	ResourceTypeWorkspaceSessionRecording ResourceType = "workspace_session_recording"
+	ResourceTypeCustomRole                ResourceType = "custom_role"
+	ResourceTypeOrganizationMember        ResourceType = "organization_member"
+	ResourceTypeWebhook                   ResourceType = "webhook"
 )
 
 func (r ResourceType) FriendlyString() string {
@@ -45,6 +49,14 @@ func (r ResourceType) FriendlyString() string {
 		return "api key"
 	case ResourceTypeGroup:
 		return "group"
+	case ResourceTypeWorkspaceSessionRecording:
+		return "session recording"
+	case ResourceTypeCustomRole:
+		return "role"
+	case ResourceTypeOrganizationMember:
+		return "organization member"
+	case ResourceTypeWebhook:
+		return "webhook"
 	default:
 		return "unknown"
 	}
@@ -58,6 +70,7 @@ const (
 	AuditActionDelete AuditAction = "delete"
 	AuditActionStart  AuditAction = "start"
 	AuditActionStop   AuditAction = "stop"
+	AuditActionUse    AuditAction = "use"
 )
 
 func (a AuditAction) FriendlyString() string {
@@ -72,6 +85,8 @@ func (a AuditAction) FriendlyString() string {
 		return "started"
 	case AuditActionStop:
 		return "stopped"
+	case AuditActionUse:
+		return "used"
 	default:
 		return "unknown"
 	}
@@ -39,7 +39,9 @@ type DeploymentConfig struct {
 	BrowserOnly                     *DeploymentConfigField[bool]            `json:"browser_only" typescript:",notnull"`
 	SCIMAPIKey                      *DeploymentConfigField[string]          `json:"scim_api_key" typescript:",notnull"`
 	Provisioner                     *ProvisionerConfig                      `json:"provisioner" typescript:",notnull"`
+	FileStorage                     *FileStorageConfig                      `json:"file_storage" typescript:",notnull"`
 	APIRateLimit                    *DeploymentConfigField[int]             `json:"api_rate_limit" typescript:",notnull"`
+	MaxTokenLifetime                *DeploymentConfigField[time.Duration]   `json:"max_token_lifetime" typescript:",notnull"`
 	Experimental                    *DeploymentConfigField[bool]            `json:"experimental" typescript:",notnull"`
 }
 
@@ -87,12 +89,17 @@ type OAuth2GithubConfig struct {
 }
 
 type OIDCConfig struct {
-	AllowSignups *DeploymentConfigField[bool]     `json:"allow_signups" typescript:",notnull"`
-	ClientID     *DeploymentConfigField[string]   `json:"client_id" typescript:",notnull"`
-	ClientSecret *DeploymentConfigField[string]   `json:"client_secret" typescript:",notnull"`
-	EmailDomain  *DeploymentConfigField[string]   `json:"email_domain" typescript:",notnull"`
-	IssuerURL    *DeploymentConfigField[string]   `json:"issuer_url" typescript:",notnull"`
-	Scopes       *DeploymentConfigField[[]string] `json:"scopes" typescript:",notnull"`
+	AllowSignups        *DeploymentConfigField[bool]     `json:"allow_signups" typescript:",notnull"`
+	ClientID            *DeploymentConfigField[string]   `json:"client_id" typescript:",notnull"`
+	ClientSecret        *DeploymentConfigField[string]   `json:"client_secret" typescript:",notnull"`
+	EmailDomain         *DeploymentConfigField[string]   `json:"email_domain" typescript:",notnull"`
+	IssuerURL           *DeploymentConfigField[string]   `json:"issuer_url" typescript:",notnull"`
+	Scopes              *DeploymentConfigField[[]string] `json:"scopes" typescript:",notnull"`
+	GroupField          *DeploymentConfigField[string]   `json:"group_field" typescript:",notnull"`
+	GroupMapping        *DeploymentConfigField[[]string] `json:"group_mapping" typescript:",notnull"`
+	CreateMissingGroups *DeploymentConfigField[bool]     `json:"create_missing_groups" typescript:",notnull"`
+	RoleField           *DeploymentConfigField[string]   `json:"role_field" typescript:",notnull"`
+	RoleMapping         *DeploymentConfigField[[]string] `json:"role_mapping" typescript:",notnull"`
 }
 
 type TelemetryConfig struct {
@@ -135,6 +142,22 @@ type ProvisionerConfig struct {
 	ForceCancelInterval *DeploymentConfigField[time.Duration] `json:"force_cancel_interval" typescript:",notnull"`
 }
 
+type FileStorageConfig struct {
+	Backend   *DeploymentConfigField[string] `json:"backend" typescript:",notnull"`
+	LocalPath *DeploymentConfigField[string] `json:"local_path" typescript:",notnull"`
+	S3        *FileStorageS3Config           `json:"s3" typescript:",notnull"`
+}
+
+type FileStorageS3Config struct {
+	Endpoint        *DeploymentConfigField[string] `json:"endpoint" typescript:",notnull"`
+	Region          *DeploymentConfigField[string] `json:"region" typescript:",notnull"`
+	Bucket          *DeploymentConfigField[string] `json:"bucket" typescript:",notnull"`
+	Prefix          *DeploymentConfigField[string] `json:"prefix" typescript:",notnull"`
+	AccessKeyID     *DeploymentConfigField[string] `json:"access_key_id" typescript:",notnull"`
+	SecretAccessKey *DeploymentConfigField[string] `json:"secret_access_key" typescript:",notnull"`
+	UsePathStyle    *DeploymentConfigField[bool]   `json:"use_path_style" typescript:",notnull"`
+}
+
 type Flaggable interface {
 	string | time.Duration | bool | int | []string | []GitAuthConfig
 }
@@ -0,0 +1,161 @@
+package codersdk
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+)
+
+// InsightsReport is the name of an insights report.
+type InsightsReport string
+
+const (
+	InsightsReportTemplates InsightsReport = "templates"
+	InsightsReportUsers     InsightsReport = "users"
+	InsightsReportApps      InsightsReport = "apps"
+)
+
+// InsightsRequest filters an insights report. Usage is aggregated by hour, and
+// the hours starting within the range are included. The range defaults to the
+// last 7 days, and the templates to every template the user can update.
+// @typescript-ignore InsightsRequest
+type InsightsRequest struct {
+	StartTime   time.Time
+	EndTime     time.Time
+	TemplateIDs []uuid.UUID
+}
+
+func (r InsightsRequest) asRequestOption() RequestOption {
+	return func(req *http.Request) {
+		q := req.URL.Query()
+		if !r.StartTime.IsZero() {
+			q.Set("start_time", r.StartTime.Format(time.RFC3339))
+		}
+		if !r.EndTime.IsZero() {
+			q.Set("end_time", r.EndTime.Format(time.RFC3339))
+		}
+		if len(r.TemplateIDs) > 0 {
+			ids := make([]string, 0, len(r.TemplateIDs))
+			for _, id := range r.TemplateIDs {
+				ids = append(ids, id.String())
+			}
+			q.Set("template_ids", strings.Join(ids, ","))
+		}
+		req.URL.RawQuery = q.Encode()
+	}
+}
+
+// TemplateInsight is the usage of the workspaces of a template.
+type TemplateInsight struct {
+	TemplateID   uuid.UUID `json:"template_id"`
+	TemplateName string    `json:"template_name"`
+	// ActiveUsers is the number of users with connection activity in the
+	// template's workspaces.
+	ActiveUsers int64 `json:"active_users"`
+	// ActiveHours is the sum of the hours each user was active.
+	ActiveHours                    int64 `json:"active_hours"`
+	ConnectionCount                int64 `json:"connection_count"`
+	SSHConnectionCount             int64 `json:"ssh_connection_count"`
+	ReconnectingPTYConnectionCount int64 `json:"reconnecting_pty_connection_count"`
+	VSCodeConnectionCount          int64 `json:"vscode_connection_count"`
+	JetBrainsConnectionCount       int64 `json:"jetbrains_connection_count"`
+	// Cost is the daily cost of the workspaces' builds, prorated by the hour.
+	Cost float64 `json:"cost"`
+}
+
+// UserInsight is the usage of a user's workspaces of a template.
+type UserInsight struct {
+	TemplateID                     uuid.UUID `json:"template_id"`
+	TemplateName                   string    `json:"template_name"`
+	UserID                         uuid.UUID `json:"user_id"`
+	Username                       string    `json:"username"`
+	ActiveHours                    int64     `json:"active_hours"`
+	ConnectionCount                int64     `json:"connection_count"`
+	SSHConnectionCount             int64     `json:"ssh_connection_count"`
+	ReconnectingPTYConnectionCount int64     `json:"reconnecting_pty_connection_count"`
+	VSCodeConnectionCount          int64     `json:"vscode_connection_count"`
+	JetBrainsConnectionCount       int64     `json:"jetbrains_connection_count"`
+	Cost                           float64   `json:"cost"`
+}
+
+// AppInsight is the usage of the apps with a slug in a template's workspaces.
+// Only requests made with a session are counted.
+type AppInsight struct {
+	TemplateID   uuid.UUID `json:"template_id"`
+	TemplateName string    `json:"template_name"`
+	Slug         string    `json:"slug"`
+	Users        int64     `json:"users"`
+	// UsageHours is the sum of the hours each user made requests.
+	UsageHours int64 `json:"usage_hours"`
+	Requests   int64 `json:"requests"`
+}
+
+type TemplateInsightsResponse struct {
+	StartTime time.Time         `json:"start_time"`
+	EndTime   time.Time         `json:"end_time"`
+	Templates []TemplateInsight `json:"templates"`
+}
+
+type UserInsightsResponse struct {
+	StartTime time.Time     `json:"start_time"`
+	EndTime   time.Time     `json:"end_time"`
+	Users     []UserInsight `json:"users"`
+}
+
+type AppInsightsResponse struct {
+	StartTime time.Time    `json:"start_time"`
+	EndTime   time.Time    `json:"end_time"`
+	Apps      []AppInsight `json:"apps"`
+}
+
+// TemplateInsights returns the usage of templates.
+func (c *Client) TemplateInsights(ctx context.Context, req InsightsRequest) (TemplateInsightsResponse, error) {
+	var resp TemplateInsightsResponse
+	return resp, c.insights(ctx, InsightsReportTemplates, req, &resp)
+}
+
+// UserInsights returns the usage of templates by user.
+func (c *Client) UserInsights(ctx context.Context, req InsightsRequest) (UserInsightsResponse, error) {
+	var resp UserInsightsResponse
+	return resp, c.insights(ctx, InsightsReportUsers, req, &resp)
+}
+
+// AppInsights returns the usage of templates' apps by slug.
+func (c *Client) AppInsights(ctx context.Context, req InsightsRequest) (AppInsightsResponse, error) {
+	var resp AppInsightsResponse
+	return resp, c.insights(ctx, InsightsReportApps, req, &resp)
+}
+
+func (c *Client) insights(ctx context.Context, report InsightsReport, req InsightsRequest, resp interface{}) error {
+	res, err := c.Request(ctx, http.MethodGet, "/api/v2/insights/"+string(report), nil, req.asRequestOption())
+	if err != nil {
+		return xerrors.Errorf("execute request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return readBodyAsError(res)
+	}
+	return json.NewDecoder(res.Body).Decode(resp)
+}
+
+// InsightsCSV returns an insights report as CSV, with a header row of the
+// JSON field names.
+func (c *Client) InsightsCSV(ctx context.Context, report InsightsReport, req InsightsRequest) ([]byte, error) {
+	res, err := c.Request(ctx, http.MethodGet, "/api/v2/insights/"+string(report), nil, req.asRequestOption(), WithQueryParam("format", "csv"))
+	if err != nil {
+		return nil, xerrors.Errorf("execute request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, readBodyAsError(res)
+	}
+	return io.ReadAll(res.Body)
+}
@@ -26,10 +26,18 @@ const (
 
 // Organization is the JSON representation of a Coder organization.
 type Organization struct {
-	ID        uuid.UUID `json:"id" validate:"required"`
-	Name      string    `json:"name" validate:"required"`
-	CreatedAt time.Time `json:"created_at" validate:"required"`
-	UpdatedAt time.Time `json:"updated_at" validate:"required"`
+	ID          uuid.UUID `json:"id" validate:"required"`
+	Name        string    `json:"name" validate:"required"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at" validate:"required"`
+	UpdatedAt   time.Time `json:"updated_at" validate:"required"`
+}
+
+// UpdateOrganizationRequest changes the settings of an organization. Unset
+// fields are left untouched.
+type UpdateOrganizationRequest struct {
+	Name        string  `json:"name,omitempty" validate:"omitempty,username"`
+	Description *string `json:"description,omitempty" validate:"omitempty,lt=256"`
 }
 
 // CreateTemplateVersionRequest enables callers to create a new Template Version.
@@ -72,6 +80,41 @@ type CreateTemplateRequest struct {
 	// DefaultTTLMillis allows optionally specifying the default TTL
 	// for all workspaces created from this template.
 	DefaultTTLMillis *int64 `json:"default_ttl_ms,omitempty"`
+
+	// InactivityTTLMillis allows optionally specifying how long a workspace
+	// created from this template may go unused before it is scheduled for
+	// deletion.
+	InactivityTTLMillis *int64 `json:"inactivity_ttl_ms,omitempty"`
+
+	// StoppedTTLMillis allows optionally specifying how long a workspace
+	// created from this template may remain stopped before it is scheduled
+	// for deletion.
+	StoppedTTLMillis *int64 `json:"stopped_ttl_ms,omitempty"`
+
+	// AutodeleteWarningTTLMillis allows optionally specifying how long a
+	// dormant workspace is marked for deletion before it is deleted. Defaults
+	// to 24 hours.
+	AutodeleteWarningTTLMillis *int64 `json:"autodelete_warning_ttl_ms,omitempty"`
+
+	// MaxTTLMillis allows optionally specifying the maximum time workspaces
+	// created from this template may run for.
+	MaxTTLMillis *int64 `json:"max_ttl_ms,omitempty"`
+
+	// AutostartRequirement allows optionally restricting when workspaces
+	// created from this template may be autostarted.
+	AutostartRequirement *TemplateAutostartRequirement `json:"autostart_requirement,omitempty"`
+
+	// QuietHours allows optionally specifying a daily window during which
+	// workspaces created from this template are stopped.
+	QuietHours *TemplateQuietHours `json:"quiet_hours,omitempty"`
+
+	// SessionRecording allows optionally recording terminal sessions in
+	// workspaces created from this template.
+	SessionRecording *bool `json:"session_recording,omitempty"`
+
+	// AutoStartOnConnect allows optionally starting stopped workspaces
+	// created from this template when they're connected to.
+	AutoStartOnConnect *bool `json:"auto_start_on_connect,omitempty"`
 }
 
 // CreateWorkspaceRequest provides options for creating a new workspace.
@@ -83,6 +126,9 @@ type CreateWorkspaceRequest struct {
 	// ParameterValues allows for additional parameters to be provided
 	// during the initial provision.
 	ParameterValues []CreateParameterRequest `json:"parameter_values,omitempty"`
+	// RichParameterValues are the values of the rich parameters of the
+	// template. Values not included are set to their default value.
+	RichParameterValues []WorkspaceBuildParameter `json:"rich_parameter_values,omitempty"`
 }
 
 func (c *Client) Organization(ctx context.Context, id uuid.UUID) (Organization, error) {
@@ -100,6 +146,22 @@ func (c *Client) Organization(ctx context.Context, id uuid.UUID) (Organization,
 	return organization, json.NewDecoder(res.Body).Decode(&organization)
 }
 
+// UpdateOrganization changes the settings of an organization.
+func (c *Client) UpdateOrganization(ctx context.Context, id uuid.UUID, req UpdateOrganizationRequest) (Organization, error) {
+	res, err := c.Request(ctx, http.MethodPatch, fmt.Sprintf("/api/v2/organizations/%s", id.String()), req)
+	if err != nil {
+		return Organization{}, xerrors.Errorf("execute request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return Organization{}, readBodyAsError(res)
+	}
+
+	var organization Organization
+	return organization, json.NewDecoder(res.Body).Decode(&organization)
+}
+
 // ProvisionerDaemonsByOrganization returns provisioner daemons available for an organization.
 func (c *Client) ProvisionerDaemons(ctx context.Context) ([]ProvisionerDaemon, error) {
 	res, err := c.Request(ctx, http.MethodGet,
@@ -0,0 +1,176 @@
+package codersdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+	"nhooyr.io/websocket"
+)
+
+// WorkspaceAgentPortShareProtocol is the protocol of a shared port. TCP ports
+// are reached through the subdomain proxy, and UDP ports are relayed through
+// the API with DialWorkspaceAgentPortShareUDP.
+type WorkspaceAgentPortShareProtocol string
+
+const (
+	WorkspaceAgentPortShareProtocolTCP WorkspaceAgentPortShareProtocol = "tcp"
+	WorkspaceAgentPortShareProtocolUDP WorkspaceAgentPortShareProtocol = "udp"
+)
+
+// WorkspaceAgentPortShare shares a port in a workspace with other users.
+// Shares are keyed by the name of the agent, so they apply to every build of
+// the workspace.
+type WorkspaceAgentPortShare struct {
+	WorkspaceID uuid.UUID                       `json:"workspace_id"`
+	AgentName   string                          `json:"agent_name"`
+	Port        int32                           `json:"port"`
+	Protocol    WorkspaceAgentPortShareProtocol `json:"protocol"`
+	// SharingLevel is the level the port was shared with. Ports are never
+	// shared above the maximum of the template.
+	SharingLevel WorkspaceAppSharingLevel `json:"sharing_level"`
+	CreatedAt    time.Time                `json:"created_at"`
+	UpdatedAt    time.Time                `json:"updated_at"`
+}
+
+type WorkspaceAgentPortShares struct {
+	Shares []WorkspaceAgentPortShare `json:"shares"`
+	// MaxSharingLevel is the maximum level ports in the workspace can be
+	// shared with, set by the template.
+	MaxSharingLevel WorkspaceAppSharingLevel `json:"max_sharing_level"`
+}
+
+type UpsertWorkspaceAgentPortShareRequest struct {
+	AgentName string `json:"agent_name" validate:"required"`
+	Port      int32  `json:"port" validate:"required"`
+	// Protocol defaults to TCP.
+	Protocol     WorkspaceAgentPortShareProtocol `json:"protocol,omitempty"`
+	SharingLevel WorkspaceAppSharingLevel        `json:"sharing_level" validate:"required"`
+}
+
+// WorkspaceAgentPortShares returns the ports shared in a workspace.
+func (c *Client) WorkspaceAgentPortShares(ctx context.Context, workspaceID uuid.UUID) (WorkspaceAgentPortShares, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/workspaces/%s/port-shares", workspaceID), nil)
+	if err != nil {
+		return WorkspaceAgentPortShares{}, xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return WorkspaceAgentPortShares{}, readBodyAsError(res)
+	}
+	var shares WorkspaceAgentPortShares
+	return shares, json.NewDecoder(res.Body).Decode(&shares)
+}
+
+// UpsertWorkspaceAgentPortShare shares a port in a workspace, or changes the
+// sharing level of a port that's already shared.
+func (c *Client) UpsertWorkspaceAgentPortShare(ctx context.Context, workspaceID uuid.UUID, req UpsertWorkspaceAgentPortShareRequest) (WorkspaceAgentPortShare, error) {
+	res, err := c.Request(ctx, http.MethodPost, fmt.Sprintf("/api/v2/workspaces/%s/port-shares", workspaceID), req)
+	if err != nil {
+		return WorkspaceAgentPortShare{}, xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return WorkspaceAgentPortShare{}, readBodyAsError(res)
+	}
+	var share WorkspaceAgentPortShare
+	return share, json.NewDecoder(res.Body).Decode(&share)
+}
+
+// DeleteWorkspaceAgentPortShare stops sharing a port in a workspace.
+func (c *Client) DeleteWorkspaceAgentPortShare(ctx context.Context, workspaceID uuid.UUID, agentName string, port int32, protocol WorkspaceAgentPortShareProtocol) error {
+	path := fmt.Sprintf("/api/v2/workspaces/%s/port-shares/%s/%d", workspaceID, url.PathEscape(agentName), port)
+	if protocol != "" {
+		path += "?protocol=" + url.QueryEscape(string(protocol))
+	}
+	res, err := c.Request(ctx, http.MethodDelete, path, nil)
+	if err != nil {
+		return xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusNoContent {
+		return readBodyAsError(res)
+	}
+	return nil
+}
+
+// DialWorkspaceAgentPortShareUDP connects to a UDP port shared in a
+// workspace. The workspace is given as <workspace>[.<agent>] of the user, and
+// the agent may be omitted if the workspace has a single agent. Every write
+// to the connection is sent as a datagram, and every read returns one.
+func (c *Client) DialWorkspaceAgentPortShareUDP(ctx context.Context, user, workspaceAndAgent string, port uint16) (net.Conn, error) {
+	serverURL, err := c.URL.Parse(fmt.Sprintf("/@%s/%s/udp/%d", url.PathEscape(user), url.PathEscape(workspaceAndAgent), port))
+	if err != nil {
+		return nil, xerrors.Errorf("parse url: %w", err)
+	}
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, xerrors.Errorf("create cookie jar: %w", err)
+	}
+	jar.SetCookies(serverURL, []*http.Cookie{{
+		Name:  SessionTokenKey,
+		Value: c.SessionToken(),
+	}})
+	conn, res, err := websocket.Dial(ctx, serverURL.String(), &websocket.DialOptions{
+		HTTPClient: &http.Client{
+			Jar:       jar,
+			Transport: c.HTTPClient.Transport,
+		},
+		CompressionMode: websocket.CompressionDisabled,
+	})
+	if err != nil {
+		if res == nil {
+			return nil, err
+		}
+		return nil, readBodyAsError(res)
+	}
+	conn.SetReadLimit(MaxDatagramSize)
+	return &datagramConn{
+		Conn: websocket.NetConn(ctx, conn, websocket.MessageBinary),
+		ctx:  ctx,
+		conn: conn,
+	}, nil
+}
+
+// MaxDatagramSize is the largest datagram relayed to and from shared UDP
+// ports.
+const MaxDatagramSize = 65535
+
+// datagramConn sends every write as a websocket message and returns a
+// message from every read, so the boundaries of datagrams are kept.
+// @typescript-ignore datagramConn
+type datagramConn struct {
+	// Conn is used for addresses, deadlines and closing.
+	net.Conn
+	ctx  context.Context
+	conn *websocket.Conn
+}
+
+// Read reads a datagram. Datagrams larger than b are truncated.
+func (c *datagramConn) Read(b []byte) (int, error) {
+	_, data, err := c.conn.Read(c.ctx)
+	if err != nil {
+		if websocket.CloseStatus(err) == websocket.StatusNormalClosure {
+			return 0, io.EOF
+		}
+		return 0, err
+	}
+	return copy(b, data), nil
+}
+
+// Write writes b as a datagram.
+func (c *datagramConn) Write(b []byte) (int, error) {
+	err := c.conn.Write(c.ctx, websocket.MessageBinary, b)
+	if err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
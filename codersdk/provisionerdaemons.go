@@ -43,6 +43,9 @@ type ProvisionerDaemon struct {
 	Name         string            `json:"name"`
 	Provisioners []ProvisionerType `json:"provisioners"`
 	Tags         map[string]string `json:"tags"`
+	// OrganizationID is set for daemons that only acquire the jobs of an
+	// organization.
+	OrganizationID *uuid.UUID `json:"organization_id,omitempty"`
 }
 
 // ProvisionerJobStatus represents the at-time state of a job.
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+
+	"github.com/google/uuid"
 )
 
 type WorkspaceQuota struct {
@@ -12,6 +14,7 @@ type WorkspaceQuota struct {
 	Budget          int `json:"budget"`
 }
 
+// WorkspaceQuota returns the quota of a user summed across all organizations.
 func (c *Client) WorkspaceQuota(ctx context.Context, userID string) (WorkspaceQuota, error) {
 	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/workspace-quota/%s", userID), nil)
 	if err != nil {
@@ -24,3 +27,18 @@ func (c *Client) WorkspaceQuota(ctx context.Context, userID string) (WorkspaceQu
 	var quota WorkspaceQuota
 	return quota, json.NewDecoder(res.Body).Decode(&quota)
 }
+
+// WorkspaceQuotaByOrganization returns the quota of a user in an organization.
+// Builds are checked against the quota of the organization of the workspace.
+func (c *Client) WorkspaceQuotaByOrganization(ctx context.Context, organizationID uuid.UUID, userID string) (WorkspaceQuota, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/organizations/%s/members/%s/workspace-quota", organizationID, userID), nil)
+	if err != nil {
+		return WorkspaceQuota{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return WorkspaceQuota{}, readBodyAsError(res)
+	}
+	var quota WorkspaceQuota
+	return quota, json.NewDecoder(res.Body).Decode(&quota)
+}
@@ -0,0 +1,118 @@
+package codersdk
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+
+	"golang.org/x/xerrors"
+)
+
+const (
+	RichParameterTypeString     = "string"
+	RichParameterTypeNumber     = "number"
+	RichParameterTypeBool       = "bool"
+	RichParameterTypeListString = "list(string)"
+)
+
+// TemplateVersionParameter is a rich parameter declared by a template version
+// with a "coder_parameter" data source.
+type TemplateVersionParameter struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Type is one of "string", "number", "bool" or "list(string)". Values of
+	// "list(string)" parameters are JSON-encoded arrays of strings.
+	Type string `json:"type"`
+	// Mutable parameters can be changed after the workspace is created.
+	Mutable         bool                             `json:"mutable"`
+	DefaultValue    string                           `json:"default_value"`
+	Icon            string                           `json:"icon"`
+	Options         []TemplateVersionParameterOption `json:"options"`
+	ValidationRegex string                           `json:"validation_regex,omitempty"`
+	ValidationMin   *int32                           `json:"validation_min,omitempty"`
+	ValidationMax   *int32                           `json:"validation_max,omitempty"`
+	ValidationError string                           `json:"validation_error,omitempty"`
+}
+
+// TemplateVersionParameterOption is a value a rich parameter may be set to.
+type TemplateVersionParameterOption struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Value       string `json:"value"`
+	Icon        string `json:"icon"`
+}
+
+// WorkspaceBuildParameter is the value of a rich parameter used for a
+// workspace build.
+type WorkspaceBuildParameter struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// ValidateWorkspaceBuildParameter returns an error if value is not a valid
+// value for the parameter.
+func ValidateWorkspaceBuildParameter(param TemplateVersionParameter, value string) error {
+	err := validateWorkspaceBuildParameter(param, value)
+	if err != nil && param.ValidationError != "" {
+		return xerrors.New(param.ValidationError)
+	}
+	return err
+}
+
+func validateWorkspaceBuildParameter(param TemplateVersionParameter, value string) error {
+	values := []string{value}
+	switch param.Type {
+	case RichParameterTypeString:
+		if param.ValidationRegex != "" {
+			regex, err := regexp.Compile(param.ValidationRegex)
+			if err != nil {
+				return xerrors.Errorf("invalid validation regex %q: %w", param.ValidationRegex, err)
+			}
+			if !regex.MatchString(value) {
+				return xerrors.Errorf("value %q does not match %q", value, param.ValidationRegex)
+			}
+		}
+	case RichParameterTypeNumber:
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return xerrors.Errorf("value %q is not a number", value)
+		}
+		if param.ValidationMin != nil && number < float64(*param.ValidationMin) {
+			return xerrors.Errorf("value %s is less than the minimum %d", value, *param.ValidationMin)
+		}
+		if param.ValidationMax != nil && number > float64(*param.ValidationMax) {
+			return xerrors.Errorf("value %s is more than the maximum %d", value, *param.ValidationMax)
+		}
+	case RichParameterTypeBool:
+		if value != "true" && value != "false" {
+			return xerrors.Errorf("value %q must be \"true\" or \"false\"", value)
+		}
+	case RichParameterTypeListString:
+		values = nil
+		err := json.Unmarshal([]byte(value), &values)
+		if err != nil {
+			return xerrors.Errorf("value %q is not a JSON array of strings", value)
+		}
+	default:
+		return xerrors.Errorf("unsupported parameter type %q", param.Type)
+	}
+
+	if len(param.Options) == 0 {
+		return nil
+	}
+	for _, value := range values {
+		if !parameterHasOption(param, value) {
+			return xerrors.Errorf("value %q is not one of the options", value)
+		}
+	}
+	return nil
+}
+
+func parameterHasOption(param TemplateVersionParameter, value string) bool {
+	for _, option := range param.Options {
+		if option.Value == value {
+			return true
+		}
+	}
+	return false
+}
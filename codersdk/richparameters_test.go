@@ -0,0 +1,97 @@
+package codersdk_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/util/ptr"
+	"github.com/coder/coder/codersdk"
+)
+
+func TestValidateWorkspaceBuildParameter(t *testing.T) {
+	t.Parallel()
+
+	options := []codersdk.TemplateVersionParameterOption{
+		{Name: "First", Value: "first"},
+		{Name: "Second", Value: "second"},
+	}
+	for _, tc := range []struct {
+		name  string
+		param codersdk.TemplateVersionParameter
+		value string
+		error string
+	}{{
+		name:  "String",
+		param: codersdk.TemplateVersionParameter{Type: codersdk.RichParameterTypeString},
+		value: "anything",
+	}, {
+		name:  "Regex",
+		param: codersdk.TemplateVersionParameter{Type: codersdk.RichParameterTypeString, ValidationRegex: "^[a-z]+$"},
+		value: "Nope",
+		error: "does not match",
+	}, {
+		name:  "CustomError",
+		param: codersdk.TemplateVersionParameter{Type: codersdk.RichParameterTypeString, ValidationRegex: "^[a-z]+$", ValidationError: "lowercase only"},
+		value: "Nope",
+		error: "lowercase only",
+	}, {
+		name:  "Number",
+		param: codersdk.TemplateVersionParameter{Type: codersdk.RichParameterTypeNumber, ValidationMin: ptr.Ref(int32(1)), ValidationMax: ptr.Ref(int32(10))},
+		value: "5",
+	}, {
+		name:  "NotNumber",
+		param: codersdk.TemplateVersionParameter{Type: codersdk.RichParameterTypeNumber},
+		value: "five",
+		error: "not a number",
+	}, {
+		name:  "BelowMinimum",
+		param: codersdk.TemplateVersionParameter{Type: codersdk.RichParameterTypeNumber, ValidationMin: ptr.Ref(int32(1))},
+		value: "0",
+		error: "less than the minimum",
+	}, {
+		name:  "AboveMaximum",
+		param: codersdk.TemplateVersionParameter{Type: codersdk.RichParameterTypeNumber, ValidationMax: ptr.Ref(int32(10))},
+		value: "11",
+		error: "more than the maximum",
+	}, {
+		name:  "Bool",
+		param: codersdk.TemplateVersionParameter{Type: codersdk.RichParameterTypeBool},
+		value: "yes",
+		error: "must be",
+	}, {
+		name:  "List",
+		param: codersdk.TemplateVersionParameter{Type: codersdk.RichParameterTypeListString},
+		value: `["a","b"]`,
+	}, {
+		name:  "NotList",
+		param: codersdk.TemplateVersionParameter{Type: codersdk.RichParameterTypeListString},
+		value: "a,b",
+		error: "not a JSON array",
+	}, {
+		name:  "Option",
+		param: codersdk.TemplateVersionParameter{Type: codersdk.RichParameterTypeString, Options: options},
+		value: "second",
+	}, {
+		name:  "NotOption",
+		param: codersdk.TemplateVersionParameter{Type: codersdk.RichParameterTypeString, Options: options},
+		value: "third",
+		error: "not one of the options",
+	}, {
+		name:  "ListOptions",
+		param: codersdk.TemplateVersionParameter{Type: codersdk.RichParameterTypeListString, Options: options},
+		value: `["first","third"]`,
+		error: "not one of the options",
+	}} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			err := codersdk.ValidateWorkspaceBuildParameter(tc.param, tc.value)
+			if tc.error == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tc.error)
+		})
+	}
+}
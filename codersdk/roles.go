@@ -5,8 +5,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/google/uuid"
+	"golang.org/x/xerrors"
 )
 
 type Role struct {
@@ -46,3 +48,119 @@ func (c *Client) ListOrganizationRoles(ctx context.Context, org uuid.UUID) ([]As
 	var roles []AssignableRoles
 	return roles, json.NewDecoder(res.Body).Decode(&roles)
 }
+
+// Permission allows, or denies if negated, an action on a resource type.
+// './coderd/rbac/object.go' has the list of valid resource types.
+type Permission struct {
+	Negate       bool   `json:"negate"`
+	ResourceType string `json:"resource_type"`
+	// Action can be 'create', 'read', 'update', 'delete' or '*'.
+	Action string `json:"action"`
+}
+
+// CustomRole is a role defined by an admin, in addition to the builtin roles.
+type CustomRole struct {
+	ID uuid.UUID `json:"id"`
+	// Name is the name the role is assigned with. Like the builtin roles, the
+	// names of organization roles are suffixed with ":<organization_id>".
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+	// OrganizationID is set for organization roles, and unset for site wide
+	// roles.
+	OrganizationID  *uuid.UUID   `json:"organization_id,omitempty"`
+	SitePermissions []Permission `json:"site_permissions"`
+	OrgPermissions  []Permission `json:"org_permissions"`
+	UserPermissions []Permission `json:"user_permissions"`
+	CreatedAt       time.Time    `json:"created_at"`
+	UpdatedAt       time.Time    `json:"updated_at"`
+}
+
+type CreateCustomRoleRequest struct {
+	// Name must not be the name of a builtin role, and is unique per
+	// organization or across site wide roles.
+	Name        string `json:"name" validate:"required,username"`
+	DisplayName string `json:"display_name"`
+	// OrganizationID scopes the role to an organization. Organization roles
+	// only have org permissions.
+	OrganizationID  *uuid.UUID   `json:"organization_id,omitempty"`
+	SitePermissions []Permission `json:"site_permissions"`
+	OrgPermissions  []Permission `json:"org_permissions"`
+	UserPermissions []Permission `json:"user_permissions"`
+}
+
+// UpdateCustomRoleRequest replaces the display name and permissions of a
+// role. Unset fields are left unchanged.
+type UpdateCustomRoleRequest struct {
+	DisplayName     *string       `json:"display_name,omitempty"`
+	SitePermissions *[]Permission `json:"site_permissions,omitempty"`
+	OrgPermissions  *[]Permission `json:"org_permissions,omitempty"`
+	UserPermissions *[]Permission `json:"user_permissions,omitempty"`
+}
+
+// CustomRoles lists the custom site wide roles, and the custom roles of every
+// organization the user can read the roles of.
+func (c *Client) CustomRoles(ctx context.Context) ([]CustomRole, error) {
+	res, err := c.Request(ctx, http.MethodGet, "/api/v2/roles", nil)
+	if err != nil {
+		return nil, xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, readBodyAsError(res)
+	}
+	var roles []CustomRole
+	return roles, json.NewDecoder(res.Body).Decode(&roles)
+}
+
+// CustomRole returns a custom role by the name it's assigned with.
+func (c *Client) CustomRole(ctx context.Context, name string) (CustomRole, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/roles/%s", name), nil)
+	if err != nil {
+		return CustomRole{}, xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return CustomRole{}, readBodyAsError(res)
+	}
+	var role CustomRole
+	return role, json.NewDecoder(res.Body).Decode(&role)
+}
+
+func (c *Client) CreateCustomRole(ctx context.Context, req CreateCustomRoleRequest) (CustomRole, error) {
+	res, err := c.Request(ctx, http.MethodPost, "/api/v2/roles", req)
+	if err != nil {
+		return CustomRole{}, xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusCreated {
+		return CustomRole{}, readBodyAsError(res)
+	}
+	var role CustomRole
+	return role, json.NewDecoder(res.Body).Decode(&role)
+}
+
+func (c *Client) UpdateCustomRole(ctx context.Context, name string, req UpdateCustomRoleRequest) (CustomRole, error) {
+	res, err := c.Request(ctx, http.MethodPatch, fmt.Sprintf("/api/v2/roles/%s", name), req)
+	if err != nil {
+		return CustomRole{}, xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return CustomRole{}, readBodyAsError(res)
+	}
+	var role CustomRole
+	return role, json.NewDecoder(res.Body).Decode(&role)
+}
+
+// DeleteCustomRole deletes a custom role, and unassigns it from every user.
+func (c *Client) DeleteCustomRole(ctx context.Context, name string) error {
+	res, err := c.Request(ctx, http.MethodDelete, fmt.Sprintf("/api/v2/roles/%s", name), nil)
+	if err != nil {
+		return xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return readBodyAsError(res)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package codersdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+)
+
+// SessionRecordingType is the kind of terminal session that was recorded.
+type SessionRecordingType string
+
+const (
+	SessionRecordingTypeSSH             SessionRecordingType = "ssh"
+	SessionRecordingTypeReconnectingPTY SessionRecordingType = "reconnecting_pty"
+)
+
+// WorkspaceSessionRecording is a recording of a terminal session in a
+// workspace. Recordings are only made for templates with session recording
+// enabled. The contents are in asciicast v2 format.
+type WorkspaceSessionRecording struct {
+	ID             uuid.UUID            `json:"id"`
+	CreatedAt      time.Time            `json:"created_at"`
+	OrganizationID uuid.UUID            `json:"organization_id"`
+	WorkspaceID    uuid.UUID            `json:"workspace_id"`
+	WorkspaceName  string               `json:"workspace_name"`
+	AgentID        uuid.UUID            `json:"agent_id"`
+	OwnerID        uuid.UUID            `json:"owner_id"`
+	OwnerName      string               `json:"owner_name"`
+	Type           SessionRecordingType `json:"type"`
+	// Command is empty when the session ran the login shell.
+	Command   string    `json:"command"`
+	StartedAt time.Time `json:"started_at"`
+	EndedAt   time.Time `json:"ended_at"`
+}
+
+type WorkspaceSessionRecordingsRequest struct {
+	// WorkspaceID filters recordings by workspace.
+	WorkspaceID uuid.UUID `json:"workspace_id,omitempty"`
+	// OwnerID filters recordings by the owner of the workspace.
+	OwnerID uuid.UUID `json:"owner_id,omitempty"`
+	Pagination
+}
+
+// WorkspaceSessionRecordings returns session recordings, newest first.
+func (c *Client) WorkspaceSessionRecordings(ctx context.Context, req WorkspaceSessionRecordingsRequest) ([]WorkspaceSessionRecording, error) {
+	opts := []RequestOption{req.Pagination.asRequestOption()}
+	if req.WorkspaceID != uuid.Nil {
+		opts = append(opts, WithQueryParam("workspace_id", req.WorkspaceID.String()))
+	}
+	if req.OwnerID != uuid.Nil {
+		opts = append(opts, WithQueryParam("owner_id", req.OwnerID.String()))
+	}
+	res, err := c.Request(ctx, http.MethodGet, "/api/v2/session-recordings", nil, opts...)
+	if err != nil {
+		return nil, xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, readBodyAsError(res)
+	}
+	var recordings []WorkspaceSessionRecording
+	return recordings, json.NewDecoder(res.Body).Decode(&recordings)
+}
+
+func (c *Client) WorkspaceSessionRecording(ctx context.Context, id uuid.UUID) (WorkspaceSessionRecording, error) {
+	res, err := c.Request(ctx, http.MethodGet,
+		fmt.Sprintf("/api/v2/session-recordings/%s", id.String()),
+		nil,
+	)
+	if err != nil {
+		return WorkspaceSessionRecording{}, xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return WorkspaceSessionRecording{}, readBodyAsError(res)
+	}
+	var recording WorkspaceSessionRecording
+	return recording, json.NewDecoder(res.Body).Decode(&recording)
+}
+
+// WorkspaceSessionRecordingContent returns the asciicast v2 contents of a
+// session recording.
+func (c *Client) WorkspaceSessionRecordingContent(ctx context.Context, id uuid.UUID) ([]byte, error) {
+	res, err := c.Request(ctx, http.MethodGet,
+		fmt.Sprintf("/api/v2/session-recordings/%s/content", id.String()),
+		nil,
+	)
+	if err != nil {
+		return nil, xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, readBodyAsError(res)
+	}
+	return io.ReadAll(res.Body)
+}
+
+// @typescript-ignore PostWorkspaceAgentSessionRecordingRequest
+type PostWorkspaceAgentSessionRecordingRequest struct {
+	Type      SessionRecordingType `json:"type"`
+	Command   string               `json:"command"`
+	StartedAt time.Time            `json:"started_at"`
+	EndedAt   time.Time            `json:"ended_at"`
+	// Recording is the session in asciicast v2 format.
+	Recording []byte `json:"recording"`
+}
+
+// PostWorkspaceAgentSessionRecording uploads the recording of a terminal
+// session that has ended.
+func (c *Client) PostWorkspaceAgentSessionRecording(ctx context.Context, req PostWorkspaceAgentSessionRecordingRequest) error {
+	res, err := c.Request(ctx, http.MethodPost, "/api/v2/workspaceagents/me/session-recordings", req)
+	if err != nil {
+		return xerrors.Errorf("agent session recording post request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusNoContent {
+		return readBodyAsError(res)
+	}
+	return nil
+}
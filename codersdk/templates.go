@@ -29,8 +29,48 @@ type Template struct {
 	Description      string                 `json:"description"`
 	Icon             string                 `json:"icon"`
 	DefaultTTLMillis int64                  `json:"default_ttl_ms"`
-	CreatedByID      uuid.UUID              `json:"created_by_id"`
-	CreatedByName    string                 `json:"created_by_name"`
+	// InactivityTTLMillis and StoppedTTLMillis are zero when the policy is
+	// disabled.
+	InactivityTTLMillis int64 `json:"inactivity_ttl_ms"`
+	StoppedTTLMillis    int64 `json:"stopped_ttl_ms"`
+	// AutodeleteWarningTTLMillis is how long a dormant workspace is marked
+	// for deletion before it is deleted.
+	AutodeleteWarningTTLMillis int64 `json:"autodelete_warning_ttl_ms"`
+	// MaxTTLMillis is zero when workspaces may run indefinitely.
+	MaxTTLMillis         int64                        `json:"max_ttl_ms"`
+	AutostartRequirement TemplateAutostartRequirement `json:"autostart_requirement"`
+	QuietHours           TemplateQuietHours           `json:"quiet_hours"`
+	// SessionRecording records terminal sessions in workspaces created from
+	// the template.
+	SessionRecording bool `json:"session_recording"`
+	// AutoStartOnConnect starts stopped workspaces created from the template
+	// when they're connected to.
+	AutoStartOnConnect bool `json:"auto_start_on_connect"`
+	// MaxPortSharingLevel is the highest level ports in workspaces created
+	// from the template may be shared with.
+	MaxPortSharingLevel WorkspaceAppSharingLevel `json:"max_port_sharing_level"`
+	CreatedByID         uuid.UUID                `json:"created_by_id"`
+	CreatedByName       string                   `json:"created_by_name"`
+}
+
+// TemplateAutostartRequirement restricts when workspaces created from a
+// template may be autostarted. Hours are in the timezone of the workspace's
+// autostart schedule.
+type TemplateAutostartRequirement struct {
+	// DaysOfWeek are the lowercase names of the days autostart is allowed on.
+	DaysOfWeek []string `json:"days_of_week"`
+	StartHour  int      `json:"start_hour"`
+	// EndHour is exclusive.
+	EndHour int `json:"end_hour"`
+}
+
+// TemplateQuietHours is a daily window during which workspaces created from a
+// template are stopped and not autostarted.
+type TemplateQuietHours struct {
+	// Schedule is a cron expression for the start of quiet hours, e.g.
+	// "CRON_TZ=US/Central 0 22 * * *". Empty disables quiet hours.
+	Schedule       string `json:"schedule"`
+	DurationMillis int64  `json:"duration_ms"`
 }
 
 type TemplateBuildTimeStats struct {
@@ -77,6 +117,25 @@ type UpdateTemplateMeta struct {
 	Description      string `json:"description,omitempty"`
 	Icon             string `json:"icon,omitempty"`
 	DefaultTTLMillis int64  `json:"default_ttl_ms,omitempty"`
+	// InactivityTTLMillis and StoppedTTLMillis are left unchanged when nil.
+	// Setting them to zero disables the policy.
+	InactivityTTLMillis *int64 `json:"inactivity_ttl_ms,omitempty"`
+	StoppedTTLMillis    *int64 `json:"stopped_ttl_ms,omitempty"`
+	// AutodeleteWarningTTLMillis is left unchanged when nil. It doesn't
+	// affect workspaces that are already marked for deletion.
+	AutodeleteWarningTTLMillis *int64 `json:"autodelete_warning_ttl_ms,omitempty"`
+	// MaxTTLMillis, AutostartRequirement and QuietHours are left unchanged
+	// when nil. Changing them re-evaluates existing workspaces.
+	MaxTTLMillis         *int64                        `json:"max_ttl_ms,omitempty"`
+	AutostartRequirement *TemplateAutostartRequirement `json:"autostart_requirement,omitempty"`
+	QuietHours           *TemplateQuietHours           `json:"quiet_hours,omitempty"`
+	// SessionRecording is left unchanged when nil.
+	SessionRecording *bool `json:"session_recording,omitempty"`
+	// AutoStartOnConnect is left unchanged when nil.
+	AutoStartOnConnect *bool `json:"auto_start_on_connect,omitempty"`
+	// MaxPortSharingLevel is left unchanged when nil. Lowering it applies to
+	// ports that are already shared.
+	MaxPortSharingLevel *WorkspaceAppSharingLevel `json:"max_port_sharing_level,omitempty"`
 }
 
 // Template returns a single template.
@@ -234,4 +293,33 @@ type AgentStatsReportResponse struct {
 	RxBytes int64 `json:"rx_bytes"`
 	// TxBytes is the number of received bytes.
 	TxBytes int64 `json:"tx_bytes"`
+
+	// SessionCountSSH is the number of open SSH sessions that aren't from
+	// an IDE.
+	SessionCountSSH int64 `json:"session_count_ssh"`
+	// SessionCountReconnectingPTY is the number of clients attached to web
+	// terminals.
+	SessionCountReconnectingPTY int64 `json:"session_count_reconnecting_pty"`
+	// SessionCountVSCode is the number of open SSH sessions from VS Code.
+	SessionCountVSCode int64 `json:"session_count_vscode"`
+	// SessionCountJetBrains is the number of open SSH sessions from JetBrains
+	// IDEs.
+	SessionCountJetBrains int64 `json:"session_count_jetbrains"`
+
+	// The number of sessions of each type opened since the agent started.
+	ConnectionCountSSH             int64 `json:"connection_count_ssh"`
+	ConnectionCountReconnectingPTY int64 `json:"connection_count_reconnecting_pty"`
+	ConnectionCountVSCode          int64 `json:"connection_count_vscode"`
+	ConnectionCountJetBrains       int64 `json:"connection_count_jetbrains"`
+
+	// CPUUsedCores is the average number of cores used since the previous
+	// report, and CPUTotalCores the number of cores available to the
+	// workspace. Memory and disk usage are in bytes. Resource usage is only
+	// reported by agents on Linux, and respects cgroup limits.
+	CPUUsedCores     float64 `json:"cpu_used_cores"`
+	CPUTotalCores    float64 `json:"cpu_total_cores"`
+	MemoryUsedBytes  int64   `json:"memory_used_bytes"`
+	MemoryTotalBytes int64   `json:"memory_total_bytes"`
+	DiskUsedBytes    int64   `json:"disk_used_bytes"`
+	DiskTotalBytes   int64   `json:"disk_total_bytes"`
 }
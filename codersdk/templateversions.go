@@ -79,6 +79,21 @@ func (c *Client) TemplateVersionParameters(ctx context.Context, version uuid.UUI
 	return params, json.NewDecoder(res.Body).Decode(&params)
 }
 
+// TemplateVersionRichParameters returns the rich parameters a template version
+// declares.
+func (c *Client) TemplateVersionRichParameters(ctx context.Context, version uuid.UUID) ([]TemplateVersionParameter, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/templateversions/%s/rich-parameters", version), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, readBodyAsError(res)
+	}
+	var params []TemplateVersionParameter
+	return params, json.NewDecoder(res.Body).Decode(&params)
+}
+
 // TemplateVersionResources returns resources a template version declares.
 func (c *Client) TemplateVersionResources(ctx context.Context, version uuid.UUID) ([]WorkspaceResource, error) {
 	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/templateversions/%s/resources", version), nil)
@@ -106,8 +121,9 @@ func (c *Client) TemplateVersionLogsAfter(ctx context.Context, version uuid.UUID
 // CreateTemplateVersionDryRunRequest defines the request parameters for
 // CreateTemplateVersionDryRun.
 type CreateTemplateVersionDryRunRequest struct {
-	WorkspaceName   string                   `json:"workspace_name"`
-	ParameterValues []CreateParameterRequest `json:"parameter_values"`
+	WorkspaceName       string                    `json:"workspace_name"`
+	ParameterValues     []CreateParameterRequest  `json:"parameter_values"`
+	RichParameterValues []WorkspaceBuildParameter `json:"rich_parameter_values"`
 }
 
 // CreateTemplateVersionDryRun begins a dry-run provisioner job against the
@@ -45,6 +45,9 @@ type User struct {
 	OrganizationIDs []uuid.UUID `json:"organization_ids"`
 	Roles           []Role      `json:"roles"`
 	AvatarURL       string      `json:"avatar_url"`
+	// AutoStartOnConnect starts the user's stopped workspaces when they're
+	// connected to.
+	AutoStartOnConnect bool `json:"auto_start_on_connect"`
 }
 
 type GetUsersResponse struct {
@@ -76,6 +79,10 @@ type UpdateUserProfileRequest struct {
 	Username string `json:"username" validate:"required,username"`
 }
 
+type UpdateUserPreferencesRequest struct {
+	AutoStartOnConnect bool `json:"auto_start_on_connect"`
+}
+
 type UpdateUserPasswordRequest struct {
 	OldPassword string `json:"old_password" validate:""`
 	Password    string `json:"password" validate:"required"`
@@ -102,7 +109,8 @@ type LoginWithPasswordResponse struct {
 }
 
 type CreateOrganizationRequest struct {
-	Name string `json:"name" validate:"required,username"`
+	Name        string `json:"name" validate:"required,username"`
+	Description string `json:"description,omitempty" validate:"lt=256"`
 }
 
 // AuthMethods contains whether authentication types are enabled or not.
@@ -184,6 +192,20 @@ func (c *Client) UpdateUserProfile(ctx context.Context, user string, req UpdateU
 	return resp, json.NewDecoder(res.Body).Decode(&resp)
 }
 
+// UpdateUserPreferences updates the preferences of a user.
+func (c *Client) UpdateUserPreferences(ctx context.Context, user string, req UpdateUserPreferencesRequest) (User, error) {
+	res, err := c.Request(ctx, http.MethodPut, fmt.Sprintf("/api/v2/users/%s/preferences", user), req)
+	if err != nil {
+		return User{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return User{}, readBodyAsError(res)
+	}
+	var resp User
+	return resp, json.NewDecoder(res.Body).Decode(&resp)
+}
+
 // UpdateUserStatus sets the user status to the given status
 func (c *Client) UpdateUserStatus(ctx context.Context, user string, status UserStatus) (User, error) {
 	path := fmt.Sprintf("/api/v2/users/%s/status/", user)
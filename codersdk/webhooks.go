@@ -0,0 +1,253 @@
+package codersdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+)
+
+// WebhookEventType is the type of event a webhook is notified about.
+type WebhookEventType string
+
+const (
+	WebhookEventWorkspaceBuildSucceeded WebhookEventType = "workspace_build.succeeded"
+	WebhookEventWorkspaceBuildFailed    WebhookEventType = "workspace_build.failed"
+	// WebhookEventWorkspaceAutostop is sent once per build shortly before
+	// the workspace is stopped by its TTL.
+	WebhookEventWorkspaceAutostop       WebhookEventType = "workspace.autostop"
+	WebhookEventTemplateVersionPromoted WebhookEventType = "template_version.promoted"
+)
+
+// WebhookEventTypes are all of the event types a webhook can subscribe to.
+var WebhookEventTypes = []WebhookEventType{
+	WebhookEventWorkspaceBuildSucceeded,
+	WebhookEventWorkspaceBuildFailed,
+	WebhookEventWorkspaceAutostop,
+	WebhookEventTemplateVersionPromoted,
+}
+
+// Valid returns whether the event type is known.
+func (e WebhookEventType) Valid() bool {
+	for _, eventType := range WebhookEventTypes {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+type Webhook struct {
+	ID             uuid.UUID `json:"id"`
+	OrganizationID uuid.UUID `json:"organization_id"`
+	Name           string    `json:"name"`
+	URL            string    `json:"url"`
+	// Events the webhook is notified about. Empty means all events.
+	Events []WebhookEventType `json:"events"`
+	// Secret is used to sign deliveries. It is only returned when the
+	// webhook is created.
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type CreateWebhookRequest struct {
+	Name   string             `json:"name" validate:"required,username"`
+	URL    string             `json:"url" validate:"required,url"`
+	Events []WebhookEventType `json:"events"`
+}
+
+// UpdateWebhookRequest changes the fields that are set. A nil Events leaves
+// the subscribed events unchanged.
+type UpdateWebhookRequest struct {
+	Name   string             `json:"name,omitempty" validate:"omitempty,username"`
+	URL    string             `json:"url,omitempty" validate:"omitempty,url"`
+	Events []WebhookEventType `json:"events,omitempty"`
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryStatusPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliveryStatusDelivered WebhookDeliveryStatus = "delivered"
+	// WebhookDeliveryStatusFailed deliveries exhausted their retries and
+	// will not be attempted again.
+	WebhookDeliveryStatusFailed WebhookDeliveryStatus = "failed"
+)
+
+type WebhookDelivery struct {
+	ID             uuid.UUID             `json:"id"`
+	WebhookID      uuid.UUID             `json:"webhook_id"`
+	EventID        uuid.UUID             `json:"event_id"`
+	EventType      WebhookEventType      `json:"event_type"`
+	Status         WebhookDeliveryStatus `json:"status"`
+	Attempts       int32                 `json:"attempts"`
+	LastStatusCode int32                 `json:"last_status_code"`
+	LastError      string                `json:"last_error"`
+	Payload        json.RawMessage       `json:"payload"`
+	CreatedAt      time.Time             `json:"created_at"`
+	UpdatedAt      time.Time             `json:"updated_at"`
+}
+
+type WebhookDeliveriesRequest struct {
+	// Status filters deliveries by status. Empty returns all deliveries.
+	Status WebhookDeliveryStatus `json:"status,omitempty"`
+	// Limit is the maximum number of deliveries returned, newest first.
+	Limit int `json:"limit,omitempty"`
+}
+
+// WebhookEvent is the body of every webhook delivery. Data holds one of the
+// Webhook*Data types depending on Type.
+type WebhookEvent struct {
+	ID             uuid.UUID        `json:"id"`
+	Type           WebhookEventType `json:"type"`
+	OrganizationID uuid.UUID        `json:"organization_id"`
+	CreatedAt      time.Time        `json:"created_at"`
+	Data           json.RawMessage  `json:"data"`
+}
+
+// WebhookWorkspaceBuildData is sent for the workspace_build.* events.
+type WebhookWorkspaceBuildData struct {
+	WorkspaceID      uuid.UUID           `json:"workspace_id"`
+	WorkspaceName    string              `json:"workspace_name"`
+	WorkspaceOwnerID uuid.UUID           `json:"workspace_owner_id"`
+	TemplateID       uuid.UUID           `json:"template_id"`
+	BuildID          uuid.UUID           `json:"build_id"`
+	BuildNumber      int32               `json:"build_number"`
+	Transition       WorkspaceTransition `json:"transition"`
+	Error            string              `json:"error,omitempty"`
+}
+
+// WebhookWorkspaceAutostopData is sent for the workspace.autostop event.
+type WebhookWorkspaceAutostopData struct {
+	WorkspaceID      uuid.UUID `json:"workspace_id"`
+	WorkspaceName    string    `json:"workspace_name"`
+	WorkspaceOwnerID uuid.UUID `json:"workspace_owner_id"`
+	BuildID          uuid.UUID `json:"build_id"`
+	Deadline         time.Time `json:"deadline"`
+}
+
+// WebhookTemplateVersionData is sent for the template_version.* events.
+type WebhookTemplateVersionData struct {
+	TemplateID          uuid.UUID `json:"template_id"`
+	TemplateName        string    `json:"template_name"`
+	TemplateVersionID   uuid.UUID `json:"template_version_id"`
+	TemplateVersionName string    `json:"template_version_name"`
+}
+
+// CreateWebhook registers a webhook in the organization. The returned
+// webhook includes the signing secret.
+func (c *Client) CreateWebhook(ctx context.Context, orgID uuid.UUID, req CreateWebhookRequest) (Webhook, error) {
+	res, err := c.Request(ctx, http.MethodPost,
+		fmt.Sprintf("/api/v2/organizations/%s/webhooks", orgID.String()),
+		req,
+	)
+	if err != nil {
+		return Webhook{}, xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusCreated {
+		return Webhook{}, readBodyAsError(res)
+	}
+	var resp Webhook
+	return resp, json.NewDecoder(res.Body).Decode(&resp)
+}
+
+func (c *Client) WebhooksByOrganization(ctx context.Context, orgID uuid.UUID) ([]Webhook, error) {
+	res, err := c.Request(ctx, http.MethodGet,
+		fmt.Sprintf("/api/v2/organizations/%s/webhooks", orgID.String()),
+		nil,
+	)
+	if err != nil {
+		return nil, xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, readBodyAsError(res)
+	}
+	var webhooks []Webhook
+	return webhooks, json.NewDecoder(res.Body).Decode(&webhooks)
+}
+
+func (c *Client) Webhook(ctx context.Context, webhook uuid.UUID) (Webhook, error) {
+	res, err := c.Request(ctx, http.MethodGet,
+		fmt.Sprintf("/api/v2/webhooks/%s", webhook.String()),
+		nil,
+	)
+	if err != nil {
+		return Webhook{}, xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return Webhook{}, readBodyAsError(res)
+	}
+	var resp Webhook
+	return resp, json.NewDecoder(res.Body).Decode(&resp)
+}
+
+func (c *Client) UpdateWebhook(ctx context.Context, webhook uuid.UUID, req UpdateWebhookRequest) (Webhook, error) {
+	res, err := c.Request(ctx, http.MethodPatch,
+		fmt.Sprintf("/api/v2/webhooks/%s", webhook.String()),
+		req,
+	)
+	if err != nil {
+		return Webhook{}, xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return Webhook{}, readBodyAsError(res)
+	}
+	var resp Webhook
+	return resp, json.NewDecoder(res.Body).Decode(&resp)
+}
+
+func (c *Client) DeleteWebhook(ctx context.Context, webhook uuid.UUID) error {
+	res, err := c.Request(ctx, http.MethodDelete,
+		fmt.Sprintf("/api/v2/webhooks/%s", webhook.String()),
+		nil,
+	)
+	if err != nil {
+		return xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return readBodyAsError(res)
+	}
+	return nil
+}
+
+// WebhookDeliveries returns the deliveries of a webhook, newest first.
+func (c *Client) WebhookDeliveries(ctx context.Context, webhook uuid.UUID, req WebhookDeliveriesRequest) ([]WebhookDelivery, error) {
+	var opts []RequestOption
+	if req.Status != "" {
+		opts = append(opts, WithQueryParam("status", string(req.Status)))
+	}
+	if req.Limit > 0 {
+		opts = append(opts, WithQueryParam("limit", strconv.Itoa(req.Limit)))
+	}
+	res, err := c.Request(ctx, http.MethodGet,
+		fmt.Sprintf("/api/v2/webhooks/%s/deliveries", webhook.String()),
+		nil,
+		opts...,
+	)
+	if err != nil {
+		return nil, xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, readBodyAsError(res)
+	}
+	var deliveries []WebhookDelivery
+	return deliveries, json.NewDecoder(res.Body).Decode(&deliveries)
+}
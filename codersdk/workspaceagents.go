@@ -35,6 +35,47 @@ const (
 	WorkspaceAgentTimeout      WorkspaceAgentStatus = "timeout"
 )
 
+// WorkspaceAgentLifecycle represents the lifecycle state of a workspace
+// agent. It is reported by the agent itself, independently of whether it
+// is connected.
+type WorkspaceAgentLifecycle string
+
+// WorkspaceAgentLifecycle enums.
+const (
+	WorkspaceAgentLifecycleCreated         WorkspaceAgentLifecycle = "created"
+	WorkspaceAgentLifecycleStarting        WorkspaceAgentLifecycle = "starting"
+	WorkspaceAgentLifecycleStartTimeout    WorkspaceAgentLifecycle = "start_timeout"
+	WorkspaceAgentLifecycleStartError      WorkspaceAgentLifecycle = "start_error"
+	WorkspaceAgentLifecycleReady           WorkspaceAgentLifecycle = "ready"
+	WorkspaceAgentLifecycleShuttingDown    WorkspaceAgentLifecycle = "shutting_down"
+	WorkspaceAgentLifecycleShutdownTimeout WorkspaceAgentLifecycle = "shutdown_timeout"
+	WorkspaceAgentLifecycleShutdownError   WorkspaceAgentLifecycle = "shutdown_error"
+	WorkspaceAgentLifecycleOff             WorkspaceAgentLifecycle = "off"
+)
+
+// ShuttingDown returns true if the agent is running or has finished
+// running its shutdown script.
+func (l WorkspaceAgentLifecycle) ShuttingDown() bool {
+	switch l {
+	case WorkspaceAgentLifecycleShuttingDown, WorkspaceAgentLifecycleShutdownTimeout,
+		WorkspaceAgentLifecycleShutdownError, WorkspaceAgentLifecycleOff:
+		return true
+	default:
+		return false
+	}
+}
+
+// Starting returns true if the agent has not yet finished running its
+// startup script.
+func (l WorkspaceAgentLifecycle) Starting() bool {
+	switch l {
+	case WorkspaceAgentLifecycleCreated, WorkspaceAgentLifecycleStarting:
+		return true
+	default:
+		return false
+	}
+}
+
 type WorkspaceAgent struct {
 	ID                   uuid.UUID            `json:"id"`
 	CreatedAt            time.Time            `json:"created_at"`
@@ -54,9 +95,56 @@ type WorkspaceAgent struct {
 	Version              string               `json:"version"`
 	Apps                 []WorkspaceApp       `json:"apps"`
 	// DERPLatency is mapped by region name (e.g. "New York City", "Seattle").
-	DERPLatency              map[string]DERPRegion `json:"latency,omitempty"`
-	ConnectionTimeoutSeconds int32                 `json:"connection_timeout_seconds"`
-	TroubleshootingURL       string                `json:"troubleshooting_url"`
+	DERPLatency              map[string]DERPRegion   `json:"latency,omitempty"`
+	ConnectionTimeoutSeconds int32                   `json:"connection_timeout_seconds"`
+	TroubleshootingURL       string                  `json:"troubleshooting_url"`
+	LifecycleState           WorkspaceAgentLifecycle `json:"lifecycle_state"`
+	// StartupScriptTimeoutSeconds is the number of seconds to wait for the
+	// startup script to complete, 0 means disabled.
+	StartupScriptTimeoutSeconds int32  `json:"startup_script_timeout_seconds"`
+	ShutdownScript              string `json:"shutdown_script,omitempty"`
+	// ShutdownScriptTimeoutSeconds is the number of seconds to wait for the
+	// shutdown script to complete, 0 means disabled.
+	ShutdownScriptTimeoutSeconds int32 `json:"shutdown_script_timeout_seconds"`
+	// StartupLogsLength is the total length of the stored startup logs.
+	StartupLogsLength int32 `json:"startup_logs_length"`
+	// StartupLogsOverflowed is true when the startup script produced more
+	// output than coderd stores. Output beyond the limit is discarded.
+	StartupLogsOverflowed bool `json:"startup_logs_overflowed"`
+	// Metadata is the most recent result of each metadata item declared on
+	// the agent by the template.
+	Metadata []WorkspaceAgentMetadataItem `json:"metadata"`
+}
+
+// WorkspaceAgentMetadataDescription describes a metadata item declared
+// on the agent by the template.
+type WorkspaceAgentMetadataDescription struct {
+	DisplayName string `json:"display_name"`
+	Key         string `json:"key"`
+	Script      string `json:"script"`
+	// Interval is the number of seconds to wait between runs of the script.
+	// Zero means the script only runs once when the agent starts.
+	Interval int64 `json:"interval"`
+	// Timeout is the number of seconds the script is allowed to run before
+	// it is killed.
+	Timeout int64 `json:"timeout"`
+}
+
+// WorkspaceAgentMetadataResult is the outcome of a single run of a
+// metadata script.
+type WorkspaceAgentMetadataResult struct {
+	// CollectedAt is the time coderd received the value, or the zero time
+	// if the agent has not reported a value yet.
+	CollectedAt time.Time `json:"collected_at"`
+	// Age is the number of seconds since the value was collected.
+	Age   int64  `json:"age"`
+	Value string `json:"value"`
+	Error string `json:"error"`
+}
+
+type WorkspaceAgentMetadataItem struct {
+	Description WorkspaceAgentMetadataDescription `json:"description"`
+	Result      WorkspaceAgentMetadataResult      `json:"result"`
 }
 
 type WorkspaceAgentResourceMetadata struct {
@@ -125,13 +213,26 @@ type WorkspaceAgentMetadata struct {
 	// GitAuthConfigs stores the number of Git configurations
 	// the Coder deployment has. If this number is >0, we
 	// set up special configuration in the workspace.
-	GitAuthConfigs       int               `json:"git_auth_configs"`
-	VSCodePortProxyURI   string            `json:"vscode_port_proxy_uri"`
-	Apps                 []WorkspaceApp    `json:"apps"`
-	DERPMap              *tailcfg.DERPMap  `json:"derpmap"`
-	EnvironmentVariables map[string]string `json:"environment_variables"`
-	StartupScript        string            `json:"startup_script"`
-	Directory            string            `json:"directory"`
+	GitAuthConfigs        int               `json:"git_auth_configs"`
+	VSCodePortProxyURI    string            `json:"vscode_port_proxy_uri"`
+	Apps                  []WorkspaceApp    `json:"apps"`
+	DERPMap               *tailcfg.DERPMap  `json:"derpmap"`
+	EnvironmentVariables  map[string]string `json:"environment_variables"`
+	StartupScript         string            `json:"startup_script"`
+	StartupScriptTimeout  time.Duration     `json:"startup_script_timeout"`
+	ShutdownScript        string            `json:"shutdown_script"`
+	ShutdownScriptTimeout time.Duration     `json:"shutdown_script_timeout"`
+	Directory             string            `json:"directory"`
+	// Metadata describes the metadata items the agent collects.
+	Metadata []WorkspaceAgentMetadataDescription `json:"metadata"`
+	// SessionRecording enables recording of terminal sessions, which are
+	// uploaded when they end.
+	SessionRecording bool `json:"session_recording"`
+}
+
+// @typescript-ignore PostWorkspaceAgentLifecycleRequest
+type PostWorkspaceAgentLifecycleRequest struct {
+	State WorkspaceAgentLifecycle `json:"state"`
 }
 
 // AuthWorkspaceGoogleInstanceIdentity uses the Google Compute Engine Metadata API to
@@ -498,6 +599,106 @@ func (c *Client) PostWorkspaceAgentVersion(ctx context.Context, version string)
 	return nil
 }
 
+// PostWorkspaceAgentLifecycle reports the lifecycle state of the agent.
+func (c *Client) PostWorkspaceAgentLifecycle(ctx context.Context, req PostWorkspaceAgentLifecycleRequest) error {
+	res, err := c.Request(ctx, http.MethodPost, "/api/v2/workspaceagents/me/report-lifecycle", req)
+	if err != nil {
+		return xerrors.Errorf("agent state post request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusNoContent {
+		return readBodyAsError(res)
+	}
+	return nil
+}
+
+// @typescript-ignore PostWorkspaceAgentMetadataRequest
+type PostWorkspaceAgentMetadataRequest struct {
+	Value string `json:"value"`
+	Error string `json:"error"`
+}
+
+// PostWorkspaceAgentMetadata reports the result of running the metadata
+// script with the given key.
+func (c *Client) PostWorkspaceAgentMetadata(ctx context.Context, key string, req PostWorkspaceAgentMetadataRequest) error {
+	res, err := c.Request(ctx, http.MethodPost, fmt.Sprintf("/api/v2/workspaceagents/me/metadata/%s", url.PathEscape(key)), req)
+	if err != nil {
+		return xerrors.Errorf("agent metadata post request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusNoContent {
+		return readBodyAsError(res)
+	}
+	return nil
+}
+
+// WatchWorkspaceAgentMetadata streams the metadata of a workspace agent.
+// The full set of items is sent initially and each time a value changes.
+// The channel is closed when the context is canceled or the stream ends.
+func (c *Client) WatchWorkspaceAgentMetadata(ctx context.Context, agentID uuid.UUID) (<-chan []WorkspaceAgentMetadataItem, error) {
+	//nolint:bodyclose
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/workspaceagents/%s/watch-metadata", agentID), nil)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, readBodyAsError(res)
+	}
+	nextEvent := ServerSentEventReader(ctx, res.Body)
+
+	metadataChan := make(chan []WorkspaceAgentMetadataItem, 256)
+	go func() {
+		defer close(metadataChan)
+		defer res.Body.Close()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			default:
+				sse, err := nextEvent()
+				if err != nil {
+					return
+				}
+				if sse.Type != ServerSentEventTypeData {
+					continue
+				}
+				b, ok := sse.Data.([]byte)
+				if !ok {
+					return
+				}
+				var items []WorkspaceAgentMetadataItem
+				err = json.Unmarshal(b, &items)
+				if err != nil {
+					return
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case metadataChan <- items:
+				}
+			}
+		}
+	}()
+
+	return metadataChan, nil
+}
+
+// WorkspaceAgentAwaitShutdown blocks until a build that stops or deletes
+// the workspace of the agent has started. The agent should run its
+// shutdown script once this returns without error.
+func (c *Client) WorkspaceAgentAwaitShutdown(ctx context.Context) error {
+	res, err := c.Request(ctx, http.MethodGet, "/api/v2/workspaceagents/me/await-shutdown", nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return readBodyAsError(res)
+	}
+	return nil
+}
+
 // WorkspaceAgentReconnectingPTY spawns a PTY that reconnects using the token provided.
 // It communicates using `agent.ReconnectingPTYRequest` marshaled as JSON.
 // Responses are PTY output that can be rendered.
@@ -544,6 +745,50 @@ func (c *Client) WorkspaceAgentListeningPorts(ctx context.Context, agentID uuid.
 	return listeningPorts, json.NewDecoder(res.Body).Decode(&listeningPorts)
 }
 
+// WorkspaceAgentReconnectingPTYSessions returns the terminal sessions running
+// in the workspace agent. Sessions can be attached to from any client with
+// WorkspaceAgentReconnectingPTY.
+func (c *Client) WorkspaceAgentReconnectingPTYSessions(ctx context.Context, agentID uuid.UUID) (ReconnectingPTYSessionsResponse, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/workspaceagents/%s/pty-sessions", agentID), nil)
+	if err != nil {
+		return ReconnectingPTYSessionsResponse{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return ReconnectingPTYSessionsResponse{}, readBodyAsError(res)
+	}
+	var sessions ReconnectingPTYSessionsResponse
+	return sessions, json.NewDecoder(res.Body).Decode(&sessions)
+}
+
+// UpdateWorkspaceAgentReconnectingPTYSession renames a terminal session.
+func (c *Client) UpdateWorkspaceAgentReconnectingPTYSession(ctx context.Context, agentID uuid.UUID, id string, req UpdateReconnectingPTYSessionRequest) (ReconnectingPTYSession, error) {
+	res, err := c.Request(ctx, http.MethodPatch, fmt.Sprintf("/api/v2/workspaceagents/%s/pty-sessions/%s", agentID, url.PathEscape(id)), req)
+	if err != nil {
+		return ReconnectingPTYSession{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return ReconnectingPTYSession{}, readBodyAsError(res)
+	}
+	var session ReconnectingPTYSession
+	return session, json.NewDecoder(res.Body).Decode(&session)
+}
+
+// DeleteWorkspaceAgentReconnectingPTYSession kills the process of a terminal
+// session and disconnects all clients attached to it.
+func (c *Client) DeleteWorkspaceAgentReconnectingPTYSession(ctx context.Context, agentID uuid.UUID, id string) error {
+	res, err := c.Request(ctx, http.MethodDelete, fmt.Sprintf("/api/v2/workspaceagents/%s/pty-sessions/%s", agentID, url.PathEscape(id)), nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusNoContent {
+		return readBodyAsError(res)
+	}
+	return nil
+}
+
 // Stats records the Agent's network connection statistics for use in
 // user-facing metrics and debugging.
 // Each member value must be written and read with atomic.
@@ -552,6 +797,24 @@ type AgentStats struct {
 	NumConns int64 `json:"num_comms"`
 	RxBytes  int64 `json:"rx_bytes"`
 	TxBytes  int64 `json:"tx_bytes"`
+
+	SessionCountSSH             int64 `json:"session_count_ssh"`
+	SessionCountReconnectingPTY int64 `json:"session_count_reconnecting_pty"`
+	SessionCountVSCode          int64 `json:"session_count_vscode"`
+	SessionCountJetBrains       int64 `json:"session_count_jetbrains"`
+
+	ConnectionCountSSH             int64 `json:"connection_count_ssh"`
+	ConnectionCountReconnectingPTY int64 `json:"connection_count_reconnecting_pty"`
+	ConnectionCountVSCode          int64 `json:"connection_count_vscode"`
+	ConnectionCountJetBrains       int64 `json:"connection_count_jetbrains"`
+
+	// Resource usage is sampled on Linux only, and is zero elsewhere.
+	CPUUsedCores     float64 `json:"cpu_used_cores"`
+	CPUTotalCores    float64 `json:"cpu_total_cores"`
+	MemoryUsedBytes  int64   `json:"memory_used_bytes"`
+	MemoryTotalBytes int64   `json:"memory_total_bytes"`
+	DiskUsedBytes    int64   `json:"disk_used_bytes"`
+	DiskTotalBytes   int64   `json:"disk_total_bytes"`
 }
 
 // AgentReportStats begins a stat streaming connection with the Coder server.
@@ -616,9 +879,23 @@ func (c *Client) AgentReportStats(
 					s := stats()
 
 					resp := AgentStatsReportResponse{
-						NumConns: s.NumConns,
-						RxBytes:  s.RxBytes,
-						TxBytes:  s.TxBytes,
+						NumConns:                       s.NumConns,
+						RxBytes:                        s.RxBytes,
+						TxBytes:                        s.TxBytes,
+						SessionCountSSH:                s.SessionCountSSH,
+						SessionCountReconnectingPTY:    s.SessionCountReconnectingPTY,
+						SessionCountVSCode:             s.SessionCountVSCode,
+						SessionCountJetBrains:          s.SessionCountJetBrains,
+						ConnectionCountSSH:             s.ConnectionCountSSH,
+						ConnectionCountReconnectingPTY: s.ConnectionCountReconnectingPTY,
+						ConnectionCountVSCode:          s.ConnectionCountVSCode,
+						ConnectionCountJetBrains:       s.ConnectionCountJetBrains,
+						CPUUsedCores:                   s.CPUUsedCores,
+						CPUTotalCores:                  s.CPUTotalCores,
+						MemoryUsedBytes:                s.MemoryUsedBytes,
+						MemoryTotalBytes:               s.MemoryTotalBytes,
+						DiskUsedBytes:                  s.DiskUsedBytes,
+						DiskTotalBytes:                 s.DiskTotalBytes,
 					}
 
 					err = wsjson.Write(ctx, conn, resp)
@@ -680,3 +957,98 @@ func (c *Client) WorkspaceAgentGitAuth(ctx context.Context, gitURL string, liste
 	var authResp WorkspaceAgentGitAuthResponse
 	return authResp, json.NewDecoder(res.Body).Decode(&authResp)
 }
+
+// WorkspaceAgentStartupLog is a single line of output from a workspace
+// agent's startup script.
+type WorkspaceAgentStartupLog struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Output    string    `json:"output"`
+}
+
+// @typescript-ignore StartupLog
+type StartupLog struct {
+	CreatedAt time.Time `json:"created_at"`
+	Output    string    `json:"output"`
+}
+
+// @typescript-ignore PatchStartupLogs
+type PatchStartupLogs struct {
+	Logs []StartupLog `json:"logs"`
+	// EOF indicates that the startup script has exited. Followers of the
+	// startup logs stop streaming once it is set.
+	EOF bool `json:"eof"`
+}
+
+// PatchStartupLogs writes log messages from the agent startup script.
+func (c *Client) PatchStartupLogs(ctx context.Context, req PatchStartupLogs) error {
+	res, err := c.Request(ctx, http.MethodPatch, "/api/v2/workspaceagents/me/startup-logs", req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return readBodyAsError(res)
+	}
+	return nil
+}
+
+// WorkspaceAgentStartupLogsAfter streams the startup script logs of a
+// workspace agent that occurred after the log ID provided. The channel is
+// closed once the startup script has exited and all logs have been sent.
+func (c *Client) WorkspaceAgentStartupLogsAfter(ctx context.Context, agentID uuid.UUID, after int64) (<-chan WorkspaceAgentStartupLog, io.Closer, error) {
+	afterQuery := ""
+	if after != 0 {
+		afterQuery = fmt.Sprintf("&after=%d", after)
+	}
+	followURL, err := c.URL.Parse(fmt.Sprintf("/api/v2/workspaceagents/%s/startup-logs?follow%s", agentID, afterQuery))
+	if err != nil {
+		return nil, nil, err
+	}
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, nil, xerrors.Errorf("create cookie jar: %w", err)
+	}
+	jar.SetCookies(followURL, []*http.Cookie{{
+		Name:  SessionTokenKey,
+		Value: c.SessionToken(),
+	}})
+	httpClient := &http.Client{
+		Jar: jar,
+	}
+	conn, res, err := websocket.Dial(ctx, followURL.String(), &websocket.DialOptions{
+		HTTPClient:      httpClient,
+		CompressionMode: websocket.CompressionDisabled,
+	})
+	if err != nil {
+		if res == nil {
+			return nil, nil, err
+		}
+		return nil, nil, readBodyAsError(res)
+	}
+	logs := make(chan WorkspaceAgentStartupLog)
+	decoder := json.NewDecoder(websocket.NetConn(ctx, conn, websocket.MessageText))
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		defer close(logs)
+		defer conn.Close(websocket.StatusGoingAway, "")
+		var log WorkspaceAgentStartupLog
+		for {
+			err = decoder.Decode(&log)
+			if err != nil {
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case logs <- log:
+			}
+		}
+	}()
+	return logs, closeFunc(func() error {
+		_ = conn.Close(websocket.StatusNormalClosure, "")
+		<-closed
+		return nil
+	}), nil
+}
@@ -32,6 +32,9 @@ type Workspace struct {
 	AutostartSchedule   *string        `json:"autostart_schedule,omitempty"`
 	TTLMillis           *int64         `json:"ttl_ms,omitempty"`
 	LastUsedAt          time.Time      `json:"last_used_at"`
+	// DeletingAt is set when the workspace is dormant and will be
+	// automatically deleted at that time unless it is used again.
+	DeletingAt *time.Time `json:"deleting_at,omitempty"`
 }
 
 type WorkspacesRequest struct {
@@ -56,6 +59,11 @@ type CreateWorkspaceBuildRequest struct {
 	// This will overwrite any existing parameters with the same name.
 	// This will not delete old params not included in this list.
 	ParameterValues []CreateParameterRequest `json:"parameter_values,omitempty"`
+	// RichParameterValues are the values of the rich parameters of the
+	// template version. Values not included are carried over from the
+	// previous build, or set to their default value. Values can only be
+	// changed when starting a workspace.
+	RichParameterValues []WorkspaceBuildParameter `json:"rich_parameter_values,omitempty"`
 }
 
 type WorkspaceOptions struct {
@@ -373,6 +381,36 @@ func (c *Client) GetAppHost(ctx context.Context) (GetAppHostResponse, error) {
 	return host, json.NewDecoder(res.Body).Decode(&host)
 }
 
+// WorkspaceAgentStats is the latest stats report of an agent.
+type WorkspaceAgentStats struct {
+	AgentID   uuid.UUID                `json:"agent_id"`
+	AgentName string                   `json:"agent_name"`
+	CreatedAt time.Time                `json:"created_at"`
+	Stats     AgentStatsReportResponse `json:"stats"`
+}
+
+// WorkspaceStats is the latest stats of the agents of a workspace's latest
+// build. Agents that haven't reported stats are omitted.
+type WorkspaceStats struct {
+	Agents []WorkspaceAgentStats `json:"agents"`
+}
+
+// WorkspaceStats returns the latest stats reported by the agents of a
+// workspace. Agents report stats once every stats refresh interval of the
+// deployment.
+func (c *Client) WorkspaceStats(ctx context.Context, id uuid.UUID) (WorkspaceStats, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/workspaces/%s/stats", id), nil)
+	if err != nil {
+		return WorkspaceStats{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return WorkspaceStats{}, readBodyAsError(res)
+	}
+	var stats WorkspaceStats
+	return stats, json.NewDecoder(res.Body).Decode(&stats)
+}
+
 // WorkspaceNotifyChannel is the PostgreSQL NOTIFY
 // channel to listen for updates on. The payload is empty,
 // because the size of a workspace payload can be very large.
@@ -26,6 +26,21 @@ type Table map[string]map[string]Action
 // AuditableResources contains a definitive list of all auditable resources and
 // which fields are auditable.
 var AuditableResources = auditMap(map[any]map[string]Action{
+	&database.APIKey{}: {
+		"id":               ActionTrack,
+		"hashed_secret":    ActionSecret, // We don't want to expose the hash of the secret in diffs.
+		"user_id":          ActionTrack,
+		"last_used":        ActionIgnore, // Changes, but is implicit when a use event is fired.
+		"expires_at":       ActionTrack,
+		"created_at":       ActionIgnore, // Never changes, but is implicit and not helpful in a diff.
+		"updated_at":       ActionIgnore, // Changes, but is implicit and not helpful in a diff.
+		"login_type":       ActionTrack,
+		"lifetime_seconds": ActionTrack,
+		"ip_address":       ActionIgnore, // Changes, but is implicit when a use event is fired.
+		"scope":            ActionTrack,
+		"token_name":       ActionTrack,
+		"allow_list":       ActionTrack,
+	},
 	&database.GitSSHKey{}: {
 		"user_id":     ActionTrack,
 		"created_at":  ActionIgnore, // Never changes, but is implicit and not helpful in a diff.
@@ -60,7 +75,19 @@ var AuditableResources = auditMap(map[any]map[string]Action{
 		"description":            ActionTrack,
 		"icon":                   ActionTrack,
 		"default_ttl":            ActionTrack,
+		"inactivity_ttl":         ActionTrack,
+		"stopped_ttl":            ActionTrack,
+		"autodelete_warning_ttl": ActionTrack,
+		"max_ttl":                ActionTrack,
+		"autostart_days_of_week": ActionTrack,
+		"autostart_start_hour":   ActionTrack,
+		"autostart_end_hour":     ActionTrack,
+		"quiet_hours_schedule":   ActionTrack,
+		"quiet_hours_duration":   ActionTrack,
 		"min_autostart_interval": ActionTrack,
+		"session_recording":      ActionTrack,
+		"auto_start_on_connect":  ActionTrack,
+		"max_port_sharing_level": ActionTrack,
 		"created_by":             ActionTrack,
 		"is_private":             ActionTrack,
 		"group_acl":              ActionTrack,
@@ -78,18 +105,19 @@ var AuditableResources = auditMap(map[any]map[string]Action{
 		"created_by":      ActionTrack,
 	},
 	&database.User{}: {
-		"id":              ActionTrack,
-		"email":           ActionTrack,
-		"username":        ActionTrack,
-		"hashed_password": ActionSecret, // Do not expose a users hashed password.
-		"created_at":      ActionIgnore, // Never changes.
-		"updated_at":      ActionIgnore, // Changes, but is implicit and not helpful in a diff.
-		"status":          ActionTrack,
-		"rbac_roles":      ActionTrack,
-		"login_type":      ActionIgnore,
-		"avatar_url":      ActionIgnore,
-		"last_seen_at":    ActionIgnore,
-		"deleted":         ActionTrack,
+		"id":                    ActionTrack,
+		"email":                 ActionTrack,
+		"username":              ActionTrack,
+		"hashed_password":       ActionSecret, // Do not expose a users hashed password.
+		"created_at":            ActionIgnore, // Never changes.
+		"updated_at":            ActionIgnore, // Changes, but is implicit and not helpful in a diff.
+		"status":                ActionTrack,
+		"rbac_roles":            ActionTrack,
+		"login_type":            ActionIgnore,
+		"avatar_url":            ActionIgnore,
+		"last_seen_at":          ActionIgnore,
+		"deleted":               ActionTrack,
+		"auto_start_on_connect": ActionTrack,
 	},
 	&database.Workspace{}: {
 		"id":                 ActionTrack,
@@ -103,6 +131,7 @@ var AuditableResources = auditMap(map[any]map[string]Action{
 		"autostart_schedule": ActionTrack,
 		"ttl":                ActionTrack,
 		"last_used_at":       ActionIgnore,
+		"deleting_at":        ActionTrack,
 	},
 	&database.Group{}: {
 		"id":              ActionTrack,
@@ -110,6 +139,7 @@ var AuditableResources = auditMap(map[any]map[string]Action{
 		"organization_id": ActionIgnore, // Never changes.
 		"avatar_url":      ActionTrack,
 		"quota_allowance": ActionTrack,
+		"source":          ActionIgnore, // Never changes.
 	},
 	// We don't show any diff for the WorkspaceBuild resource,
 	// save for the template_version_id
@@ -128,6 +158,40 @@ var AuditableResources = auditMap(map[any]map[string]Action{
 		"reason":              ActionIgnore,
 		"daily_cost":          ActionIgnore,
 	},
+	&database.WorkspaceSessionRecording{}: {
+		"id":              ActionIgnore,
+		"created_at":      ActionIgnore,
+		"organization_id": ActionIgnore,
+		"workspace_id":    ActionTrack,
+		"agent_id":        ActionTrack,
+		"owner_id":        ActionTrack,
+		"type":            ActionTrack,
+		"command":         ActionTrack,
+		"started_at":      ActionTrack,
+		"ended_at":        ActionTrack,
+		"file_id":         ActionIgnore,
+	},
+	&database.CustomRole{}: {
+		"id":               ActionIgnore,
+		"name":             ActionTrack,
+		"display_name":     ActionTrack,
+		"organization_id":  ActionIgnore,
+		"site_permissions": ActionTrack,
+		"org_permissions":  ActionTrack,
+		"user_permissions": ActionTrack,
+		"created_at":       ActionIgnore,
+		"updated_at":       ActionIgnore,
+	},
+	&database.Webhook{}: {
+		"id":              ActionTrack,
+		"organization_id": ActionIgnore, // Never changes.
+		"name":            ActionTrack,
+		"url":             ActionTrack,
+		"secret":          ActionSecret, // We don't want to expose the signing secret in diffs.
+		"events":          ActionTrack,
+		"created_at":      ActionIgnore, // Never changes, but is implicit and not helpful in a diff.
+		"updated_at":      ActionIgnore, // Changes, but is implicit and not helpful in a diff.
+	},
 })
 
 // auditMap converts a map of struct pointers to a map of struct names as
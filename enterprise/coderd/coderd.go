@@ -42,12 +42,13 @@ func New(ctx context.Context, options *Options) (*API, error) {
 	if options.Options == nil {
 		options.Options = &coderd.Options{}
 	}
-	if options.Options.Authorizer == nil {
-		options.Options.Authorizer = rbac.NewAuthorizer()
+	agplAPI, err := coderd.New(options.Options)
+	if err != nil {
+		return nil, err
 	}
 	ctx, cancelFunc := context.WithCancel(ctx)
 	api := &API{
-		AGPL:                   coderd.New(options.Options),
+		AGPL:                   agplAPI,
 		Options:                options,
 		cancelEntitlementsLoop: cancelFunc,
 	}
@@ -60,6 +61,7 @@ func New(ctx context.Context, options *Options) (*API, error) {
 		DB:              options.Database,
 		OAuth2Configs:   oauthConfigs,
 		RedirectToLogin: false,
+		TokenUsed:       api.AGPL.AuditTokenUse,
 	})
 
 	api.AGPL.APIHandler.Group(func(r chi.Router) {
@@ -99,6 +101,15 @@ func New(ctx context.Context, options *Options) (*API, error) {
 			r.Get("/", api.provisionerDaemons)
 			r.Get("/serve", api.provisionerDaemonServe)
 		})
+		r.Route("/organizations/{organization}/members/{user}/workspace-quota", func(r chi.Router) {
+			r.Use(
+				apiKeyMiddleware,
+				httpmw.ExtractOrganizationParam(api.Database),
+				httpmw.ExtractUserParam(api.Database, false),
+				httpmw.ExtractOrganizationMemberParam(api.Database),
+			)
+			r.Get("/", api.workspaceQuotaByOrganization)
+		})
 		r.Route("/templates/{template}/acl", func(r chi.Router) {
 			r.Use(
 				api.templateRBACEnabledMW,
@@ -139,6 +150,15 @@ func New(ctx context.Context, options *Options) (*API, error) {
 				r.Get("/{id}", api.scimGetUser)
 				r.Patch("/{id}", api.scimPatchUser)
 			})
+			r.Route("/Groups", func(r chi.Router) {
+				r.Use(api.templateRBACEnabledMW)
+				r.Get("/", api.scimGetGroups)
+				r.Post("/", api.scimPostGroup)
+				r.Get("/{id}", api.scimGetGroup)
+				r.Put("/{id}", api.scimPutGroup)
+				r.Patch("/{id}", api.scimPatchGroup)
+				r.Delete("/{id}", api.scimDeleteGroup)
+			})
 		})
 	}
 
@@ -164,7 +184,6 @@ func New(ctx context.Context, options *Options) (*API, error) {
 		RootCAs:      meshRootCA,
 		ServerName:   options.AccessURL.Hostname(),
 	}
-	var err error
 	api.replicaManager, err = replicasync.New(ctx, options.Logger, options.Database, options.Pubsub, &replicasync.Options{
 		ID:           api.AGPL.ID,
 		RelayAddress: options.DERPServerRelayAddress,
@@ -274,8 +293,11 @@ func (api *API) updateEntitlements(ctx context.Context) error {
 			committer := committer{Database: api.Database}
 			ptr := proto.QuotaCommitter(&committer)
 			api.AGPL.QuotaCommitter.Store(&ptr)
+			syncGroups := api.syncGroups
+			api.AGPL.GroupSync.Store(&syncGroups)
 		} else {
 			api.AGPL.QuotaCommitter.Store(nil)
+			api.AGPL.GroupSync.Store(nil)
 		}
 	}
 
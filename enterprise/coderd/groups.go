@@ -1,6 +1,7 @@
 package coderd
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"net/http"
@@ -8,6 +9,7 @@ import (
 	"github.com/google/uuid"
 	"golang.org/x/xerrors"
 
+	"cdr.dev/slog"
 	"github.com/coder/coder/coderd"
 	"github.com/coder/coder/coderd/audit"
 	"github.com/coder/coder/coderd/database"
@@ -54,6 +56,7 @@ func (api *API) postGroupByOrganization(rw http.ResponseWriter, r *http.Request)
 		OrganizationID: org.ID,
 		AvatarURL:      req.AvatarURL,
 		QuotaAllowance: int32(req.QuotaAllowance),
+		Source:         database.GroupSourceUser,
 	})
 	if database.IsUniqueViolation(err) {
 		httpapi.Write(ctx, rw, http.StatusConflict, codersdk.Response{
@@ -67,7 +70,7 @@ func (api *API) postGroupByOrganization(rw http.ResponseWriter, r *http.Request)
 	}
 	aReq.New = group
 
-	httpapi.Write(ctx, rw, http.StatusCreated, convertGroup(group, nil))
+	httpapi.Write(ctx, rw, http.StatusCreated, convertGroup(group, nil, api.AGPL.CustomRoles))
 }
 
 func (api *API) patchGroup(rw http.ResponseWriter, r *http.Request) {
@@ -223,7 +226,7 @@ func (api *API) patchGroup(rw http.ResponseWriter, r *http.Request) {
 
 	aReq.New = group
 
-	httpapi.Write(ctx, rw, http.StatusOK, convertGroup(group, members))
+	httpapi.Write(ctx, rw, http.StatusOK, convertGroup(group, members, api.AGPL.CustomRoles))
 }
 
 func (api *API) deleteGroup(rw http.ResponseWriter, r *http.Request) {
@@ -281,7 +284,7 @@ func (api *API) group(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	httpapi.Write(ctx, rw, http.StatusOK, convertGroup(group, users))
+	httpapi.Write(ctx, rw, http.StatusOK, convertGroup(group, users, api.AGPL.CustomRoles))
 }
 
 func (api *API) groups(rw http.ResponseWriter, r *http.Request) {
@@ -314,13 +317,103 @@ func (api *API) groups(rw http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		resp = append(resp, convertGroup(group, members))
+		resp = append(resp, convertGroup(group, members, api.AGPL.CustomRoles))
 	}
 
 	httpapi.Write(ctx, rw, http.StatusOK, resp)
 }
 
-func convertGroup(g database.Group, users []database.User) codersdk.Group {
+// syncGroups adds a user to the named groups in each of their organizations,
+// and removes them from the groups created by the sync that aren't named.
+// Memberships of other groups are never removed, so groups created by admins
+// or SCIM can still be managed in Coder. Groups that don't exist are created
+// if the OIDC config allows it, and ignored otherwise.
+func (api *API) syncGroups(ctx context.Context, tx database.Store, userID uuid.UUID, groupNames []string) error {
+	want := make(map[string]struct{}, len(groupNames))
+	for _, name := range groupNames {
+		if name == "" || name == database.AllUsersGroup {
+			continue
+		}
+		want[name] = struct{}{}
+	}
+
+	createMissing := api.OIDCConfig != nil && api.OIDCConfig.CreateMissingGroups
+	organizations, err := tx.GetOrganizationsByUserID(ctx, userID)
+	if err != nil && !xerrors.Is(err, sql.ErrNoRows) {
+		return xerrors.Errorf("get user organizations: %w", err)
+	}
+	userGroups, err := tx.GetUserGroups(ctx, userID)
+	if err != nil {
+		return xerrors.Errorf("get user groups: %w", err)
+	}
+
+	for _, org := range organizations {
+		groups, err := tx.GetGroupsByOrganizationID(ctx, org.ID)
+		if err != nil {
+			return xerrors.Errorf("get organization groups: %w", err)
+		}
+		existing := make(map[string]database.Group, len(groups))
+		for _, group := range groups {
+			existing[group.Name] = group
+		}
+		member := make(map[uuid.UUID]struct{})
+		for _, group := range userGroups {
+			if group.OrganizationID != org.ID {
+				continue
+			}
+			member[group.ID] = struct{}{}
+			if group.Source != database.GroupSourceOIDC {
+				continue
+			}
+			if _, ok := want[group.Name]; ok {
+				continue
+			}
+			err = tx.DeleteGroupMemberFromGroup(ctx, database.DeleteGroupMemberFromGroupParams{
+				UserID:  userID,
+				GroupID: group.ID,
+			})
+			if err != nil {
+				return xerrors.Errorf("remove user from group %q: %w", group.Name, err)
+			}
+		}
+
+		for name := range want {
+			group, ok := existing[name]
+			if !ok {
+				if !createMissing {
+					continue
+				}
+				if err := httpapi.NameValid(name); err != nil {
+					api.Logger.Warn(ctx, "not creating group with invalid name from oidc claim",
+						slog.F("group", name), slog.Error(err))
+					continue
+				}
+				group, err = tx.InsertGroup(ctx, database.InsertGroupParams{
+					ID:             uuid.New(),
+					Name:           name,
+					OrganizationID: org.ID,
+					Source:         database.GroupSourceOIDC,
+				})
+				if err != nil {
+					return xerrors.Errorf("create group %q: %w", name, err)
+				}
+			}
+			if _, ok := member[group.ID]; ok {
+				continue
+			}
+			err = tx.InsertGroupMember(ctx, database.InsertGroupMemberParams{
+				UserID:  userID,
+				GroupID: group.ID,
+			})
+			if err != nil {
+				return xerrors.Errorf("add user to group %q: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func convertGroup(g database.Group, users []database.User, customRoles *rbac.CustomRoles) codersdk.Group {
 	// It's ridiculous to query all the orgs of a user here
 	// especially since as of the writing of this comment there
 	// is only one org. So we pretend everyone is only part of
@@ -335,11 +428,11 @@ func convertGroup(g database.Group, users []database.User) codersdk.Group {
 		OrganizationID: g.OrganizationID,
 		AvatarURL:      g.AvatarURL,
 		QuotaAllowance: int(g.QuotaAllowance),
-		Members:        convertUsers(users, orgs),
+		Members:        convertUsers(users, orgs, customRoles),
 	}
 }
 
-func convertUser(user database.User, organizationIDs []uuid.UUID) codersdk.User {
+func convertUser(user database.User, organizationIDs []uuid.UUID, customRoles *rbac.CustomRoles) codersdk.User {
 	convertedUser := codersdk.User{
 		ID:              user.ID,
 		Email:           user.Email,
@@ -353,18 +446,22 @@ func convertUser(user database.User, organizationIDs []uuid.UUID) codersdk.User
 	}
 
 	for _, roleName := range user.RBACRoles {
-		rbacRole, _ := rbac.RoleByName(roleName)
+		rbacRole, err := customRoles.RoleByName(roleName)
+		if err != nil {
+			// Roles that no longer exist, like deleted custom roles.
+			rbacRole = rbac.Role{Name: roleName, DisplayName: roleName}
+		}
 		convertedUser.Roles = append(convertedUser.Roles, convertRole(rbacRole))
 	}
 
 	return convertedUser
 }
 
-func convertUsers(users []database.User, organizationIDsByUserID map[uuid.UUID][]uuid.UUID) []codersdk.User {
+func convertUsers(users []database.User, organizationIDsByUserID map[uuid.UUID][]uuid.UUID, customRoles *rbac.CustomRoles) []codersdk.User {
 	converted := make([]codersdk.User, 0, len(users))
 	for _, u := range users {
 		userOrganizationIDs := organizationIDsByUserID[u.ID]
-		converted = append(converted, convertUser(u, userOrganizationIDs))
+		converted = append(converted, convertUser(u, userOrganizationIDs, customRoles))
 	}
 	return converted
 }
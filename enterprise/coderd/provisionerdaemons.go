@@ -67,6 +67,16 @@ func (api *API) provisionerDaemons(rw http.ResponseWriter, r *http.Request) {
 	if daemons == nil {
 		daemons = []database.ProvisionerDaemon{}
 	}
+	// Daemons of other organizations are hidden, while daemons that aren't
+	// scoped to an organization serve every organization.
+	orgDaemons := make([]database.ProvisionerDaemon, 0, len(daemons))
+	for _, daemon := range daemons {
+		if daemon.OrganizationID.Valid && daemon.OrganizationID.UUID != org.ID {
+			continue
+		}
+		orgDaemons = append(orgDaemons, daemon)
+	}
+	daemons = orgDaemons
 	daemons, err = coderd.AuthorizeFilter(api.AGPL.HTTPAuth, r, rbac.ActionRead, daemons)
 	if err != nil {
 		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
@@ -121,12 +131,13 @@ func (api *API) provisionerDaemonServe(rw http.ResponseWriter, r *http.Request)
 
 	// Any authenticated user can create provisioner daemons scoped
 	// for jobs that they own, but only authorized users can create
-	// globally scoped provisioners that attach to all jobs.
+	// provisioners that attach to all jobs of the organization.
 	apiKey := httpmw.APIKey(r)
+	org := httpmw.OrganizationParam(r)
 	tags = provisionerdserver.MutateTags(apiKey.UserID, tags)
 
 	if tags[provisionerdserver.TagScope] == provisionerdserver.ScopeOrganization {
-		if !api.AGPL.Authorize(r, rbac.ActionCreate, rbac.ResourceProvisionerDaemon) {
+		if !api.AGPL.Authorize(r, rbac.ActionCreate, rbac.ResourceProvisionerDaemon.InOrg(org.ID)) {
 			httpapi.Write(r.Context(), rw, http.StatusForbidden, codersdk.Response{
 				Message: "You aren't allowed to create provisioner daemons for the organization.",
 			})
@@ -151,6 +162,10 @@ func (api *API) provisionerDaemonServe(rw http.ResponseWriter, r *http.Request)
 		Name:         name,
 		Provisioners: provisioners,
 		Tags:         tags,
+		OrganizationID: uuid.NullUUID{
+			UUID:  org.ID,
+			Valid: true,
+		},
 	})
 	if err != nil {
 		httpapi.Write(r.Context(), rw, http.StatusInternalServerError, codersdk.Response{
@@ -203,11 +218,15 @@ func (api *API) provisionerDaemonServe(rw http.ResponseWriter, r *http.Request)
 		AccessURL:    api.AccessURL,
 		ID:           daemon.ID,
 		Database:     api.Database,
+		FileStore:    api.FileStore,
 		Pubsub:       api.Pubsub,
 		Provisioners: daemon.Provisioners,
 		Telemetry:    api.Telemetry,
 		Logger:       api.Logger.Named(fmt.Sprintf("provisionerd-%s", daemon.Name)),
 		Tags:         rawTags,
+		// Daemons connected through an organization only acquire the jobs
+		// of that organization.
+		OrganizationID: org.ID,
 	})
 	if err != nil {
 		_ = conn.Close(websocket.StatusInternalError, httpapi.WebsocketCloseSprintf("drpc register provisioner daemon: %s", err))
@@ -238,6 +257,9 @@ func convertProvisionerDaemon(daemon database.ProvisionerDaemon) codersdk.Provis
 		Name:      daemon.Name,
 		Tags:      daemon.Tags,
 	}
+	if daemon.OrganizationID.Valid {
+		result.OrganizationID = &daemon.OrganizationID.UUID
+	}
 	for _, provisionerType := range daemon.Provisioners {
 		result.Provisioners = append(result.Provisioners, codersdk.ProvisionerType(provisionerType))
 	}
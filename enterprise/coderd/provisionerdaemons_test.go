@@ -10,6 +10,7 @@ import (
 
 	"github.com/coder/coder/coderd/coderdtest"
 	"github.com/coder/coder/coderd/provisionerdserver"
+	"github.com/coder/coder/coderd/rbac"
 	"github.com/coder/coder/codersdk"
 	"github.com/coder/coder/enterprise/coderd/coderdenttest"
 	"github.com/coder/coder/provisioner/echo"
@@ -64,6 +65,73 @@ func TestProvisionerDaemonServe(t *testing.T) {
 		require.Equal(t, http.StatusForbidden, apiError.StatusCode())
 	})
 
+	t.Run("OrganizationAdmin", func(t *testing.T) {
+		t.Parallel()
+		client := coderdenttest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		coderdenttest.AddLicense(t, client, coderdenttest.LicenseOptions{
+			ExternalProvisionerDaemons: true,
+		})
+		org, err := client.CreateOrganization(context.Background(), codersdk.CreateOrganizationRequest{
+			Name: "other",
+		})
+		require.NoError(t, err)
+		another := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+		anotherUser, err := another.User(context.Background(), codersdk.Me)
+		require.NoError(t, err)
+		_, err = client.AddOrganizationMember(context.Background(), org.ID, anotherUser.ID.String())
+		require.NoError(t, err)
+		_, err = client.UpdateOrganizationMemberRoles(context.Background(), org.ID, anotherUser.ID.String(), codersdk.UpdateRoles{
+			Roles: []string{rbac.RoleOrgAdmin(org.ID)},
+		})
+		require.NoError(t, err)
+
+		// Organization admins may run daemons for their own organization
+		// only.
+		tags := map[string]string{
+			provisionerdserver.TagScope: provisionerdserver.ScopeOrganization,
+		}
+		srv, err := another.ServeProvisionerDaemon(context.Background(), org.ID, []codersdk.ProvisionerType{
+			codersdk.ProvisionerTypeEcho,
+		}, tags)
+		require.NoError(t, err)
+		srv.DRPCConn().Close()
+
+		_, err = another.ServeProvisionerDaemon(context.Background(), user.OrganizationID, []codersdk.ProvisionerType{
+			codersdk.ProvisionerTypeEcho,
+		}, tags)
+		var apiError *codersdk.Error
+		require.ErrorAs(t, err, &apiError)
+		require.Equal(t, http.StatusForbidden, apiError.StatusCode())
+	})
+
+	t.Run("OrganizationJobs", func(t *testing.T) {
+		t.Parallel()
+		client := coderdenttest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		coderdenttest.AddLicense(t, client, coderdenttest.LicenseOptions{
+			ExternalProvisionerDaemons: true,
+		})
+		org, err := client.CreateOrganization(context.Background(), codersdk.CreateOrganizationRequest{
+			Name: "other",
+		})
+		require.NoError(t, err)
+		closer := coderdtest.NewExternalProvisionerDaemon(t, client, org.ID, map[string]string{
+			provisionerdserver.TagScope: provisionerdserver.ScopeOrganization,
+		})
+		defer closer.Close()
+
+		// The daemon only serves the organization it's connected through,
+		// so the older job of the first organization is skipped.
+		pending := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		version := coderdtest.CreateTemplateVersion(t, client, org.ID, nil)
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+
+		pending, err = client.TemplateVersion(context.Background(), pending.ID)
+		require.NoError(t, err)
+		require.Equal(t, codersdk.ProvisionerJobPending, pending.Job.Status)
+	})
+
 	t.Run("UserLocal", func(t *testing.T) {
 		t.Parallel()
 		client := coderdenttest.New(t, nil)
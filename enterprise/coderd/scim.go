@@ -1,16 +1,19 @@
 package coderd
 
 import (
+	"context"
 	"crypto/subtle"
+	"database/sql"
 	"encoding/json"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/imulab/go-scim/pkg/v2/handlerutil"
-	scimjson "github.com/imulab/go-scim/pkg/v2/json"
-	"github.com/imulab/go-scim/pkg/v2/service"
 	"github.com/imulab/go-scim/pkg/v2/spec"
+	"golang.org/x/xerrors"
 
 	agpl "github.com/coder/coder/coderd"
 	"github.com/coder/coder/coderd/database"
@@ -18,6 +21,15 @@ import (
 	"github.com/coder/coder/codersdk"
 )
 
+const (
+	scimSchemaUser         = "urn:ietf:params:scim:schemas:core:2.0:User"
+	scimSchemaGroup        = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	scimSchemaListResponse = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+
+	// scimMaxPageSize is the most resources returned by a list request.
+	scimMaxPageSize = 100
+)
+
 func (api *API) scimEnabledMW(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
 		api.entitlementsMu.RLock()
@@ -39,37 +51,25 @@ func (api *API) scimVerifyAuthHeader(r *http.Request) bool {
 	return len(api.SCIMAPIKey) != 0 && subtle.ConstantTimeCompare(hdr, api.SCIMAPIKey) == 1
 }
 
-// scimGetUsers intentionally always returns no users. This is done to always force
-// Okta to try and create each user individually, this way we don't need to
-// implement fetching users twice.
-//
-//nolint:revive
-func (api *API) scimGetUsers(rw http.ResponseWriter, r *http.Request) {
+// scimAuthorized writes an error and returns false if the request isn't
+// authenticated with the SCIM API key.
+func (api *API) scimAuthorized(rw http.ResponseWriter, r *http.Request) bool {
 	if !api.scimVerifyAuthHeader(r) {
-		_ = handlerutil.WriteError(rw, spec.Error{Status: http.StatusUnauthorized, Type: "invalidAuthorization"})
-		return
+		scimError(rw, &spec.Error{Status: http.StatusUnauthorized, Type: "invalidAuthorization"}, "The SCIM API key is missing or invalid.")
+		return false
 	}
-
-	_ = handlerutil.WriteSearchResultToResponse(rw, &service.QueryResponse{
-		TotalResults: 0,
-		StartIndex:   1,
-		ItemsPerPage: 0,
-		Resources:    []scimjson.Serializable{},
-	})
+	return true
 }
 
-// scimGetUser intentionally always returns an error saying the user wasn't found.
-// This is done to always force Okta to try and create the user, this way we
-// don't need to implement fetching users twice.
-//
-//nolint:revive
-func (api *API) scimGetUser(rw http.ResponseWriter, r *http.Request) {
-	if !api.scimVerifyAuthHeader(r) {
-		_ = handlerutil.WriteError(rw, spec.Error{Status: http.StatusUnauthorized, Type: "invalidAuthorization"})
-		return
-	}
+// scimError writes a SCIM error. The status and type are only read from a
+// wrapped error, so the detail wraps the error.
+func scimError(rw http.ResponseWriter, err *spec.Error, detail string) {
+	_ = handlerutil.WriteError(rw, xerrors.Errorf("%s: %w", detail, err))
+}
 
-	_ = handlerutil.WriteError(rw, spec.ErrNotFound)
+// scimInternalError writes an internal SCIM error.
+func scimInternalError(rw http.ResponseWriter, err error) {
+	scimError(rw, spec.ErrInternal, err.Error())
 }
 
 // We currently use our own struct instead of using the SCIM package. This was
@@ -84,12 +84,7 @@ type SCIMUser struct {
 		GivenName  string `json:"givenName"`
 		FamilyName string `json:"familyName"`
 	} `json:"name"`
-	Emails []struct {
-		Primary bool   `json:"primary"`
-		Value   string `json:"value"`
-		Type    string `json:"type"`
-		Display string `json:"display"`
-	} `json:"emails"`
+	Emails []SCIMEmail   `json:"emails"`
 	Active bool          `json:"active"`
 	Groups []interface{} `json:"groups"`
 	Meta   struct {
@@ -97,11 +92,305 @@ type SCIMUser struct {
 	} `json:"meta"`
 }
 
-// scimPostUser creates a new user, or returns the existing user if it exists.
+// SCIMEmail is an email address of a SCIMUser.
+type SCIMEmail = struct {
+	Primary bool   `json:"primary"`
+	Value   string `json:"value"`
+	Type    string `json:"type"`
+	Display string `json:"display"`
+}
+
+// SCIMListResponse is the envelope of the resources returned by a list
+// request. StartIndex is 1-based.
+type SCIMListResponse[T any] struct {
+	Schemas      []string `json:"schemas"`
+	TotalResults int      `json:"totalResults"`
+	StartIndex   int      `json:"startIndex"`
+	ItemsPerPage int      `json:"itemsPerPage"`
+	Resources    []T      `json:"Resources"`
+}
+
+// SCIMPatchRequest modifies a resource with a list of operations.
+// See https://www.rfc-editor.org/rfc/rfc7644#section-3.5.2.
+type SCIMPatchRequest struct {
+	Schemas    []string             `json:"schemas"`
+	Operations []SCIMPatchOperation `json:"Operations"`
+}
+
+// SCIMPatchOperation adds, removes or replaces the value of the attribute at
+// the path. Without a path, the value is an object of attributes.
+type SCIMPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// scimFilter is a filter on a single attribute, which is how identity
+// providers look up resources. Only the "eq" operator is supported.
+type scimFilter struct {
+	Attribute string
+	Value     string
+}
+
+// parseSCIMFilter parses a filter formatted as <attribute> eq "<value>". An
+// empty filter returns nil.
+func parseSCIMFilter(raw string) (*scimFilter, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+	attribute, rest, ok := strings.Cut(raw, " ")
+	if !ok {
+		return nil, xerrors.Errorf("filter %q must be formatted as <attribute> eq \"<value>\"", raw)
+	}
+	operator, value, ok := strings.Cut(strings.TrimSpace(rest), " ")
+	if !ok || !strings.EqualFold(operator, "eq") {
+		return nil, xerrors.Errorf("filter %q must use the eq operator", raw)
+	}
+	var unquoted string
+	err := json.Unmarshal([]byte(strings.TrimSpace(value)), &unquoted)
+	if err != nil {
+		return nil, xerrors.Errorf("filter %q must compare with a quoted string", raw)
+	}
+	return &scimFilter{
+		Attribute: attribute,
+		Value:     unquoted,
+	}, nil
+}
+
+// scimPage is the page of resources requested with the startIndex and count
+// query parameters.
+type scimPage struct {
+	// StartIndex is the 1-based index of the first resource.
+	StartIndex int
+	Count      int
+}
+
+func parseSCIMPage(r *http.Request) (scimPage, error) {
+	page := scimPage{
+		StartIndex: 1,
+		Count:      scimMaxPageSize,
+	}
+	query := r.URL.Query()
+	if raw := query.Get("startIndex"); raw != "" {
+		startIndex, err := strconv.Atoi(raw)
+		if err != nil {
+			return scimPage{}, xerrors.Errorf("startIndex %q must be an integer", raw)
+		}
+		// Values less than 1 are interpreted as 1.
+		if startIndex > 1 {
+			page.StartIndex = startIndex
+		}
+	}
+	if raw := query.Get("count"); raw != "" {
+		count, err := strconv.Atoi(raw)
+		if err != nil {
+			return scimPage{}, xerrors.Errorf("count %q must be an integer", raw)
+		}
+		// Negative values are interpreted as 0.
+		if count < 0 {
+			count = 0
+		}
+		if count < page.Count {
+			page.Count = count
+		}
+	}
+	return page, nil
+}
+
+// scimPageOf returns the resources of the page from a list of every resource.
+func scimPageOf[T any](page scimPage, resources []T) []T {
+	start := page.StartIndex - 1
+	if start > len(resources) {
+		start = len(resources)
+	}
+	end := start + page.Count
+	if end > len(resources) {
+		end = len(resources)
+	}
+	return resources[start:end]
+}
+
+func writeSCIMList[T any](ctx context.Context, rw http.ResponseWriter, page scimPage, total int, resources []T) {
+	httpapi.Write(ctx, rw, http.StatusOK, SCIMListResponse[T]{
+		Schemas:      []string{scimSchemaListResponse},
+		TotalResults: total,
+		StartIndex:   page.StartIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	})
+}
+
+// scimPatchOp returns the lowercase operation. Some identity providers
+// capitalize them.
+func scimPatchOp(op SCIMPatchOperation) (string, error) {
+	switch lower := strings.ToLower(op.Op); lower {
+	case "add", "remove", "replace":
+		return lower, nil
+	default:
+		return "", xerrors.Errorf("unsupported operation %q", op.Op)
+	}
+}
+
+// scimPatchValues returns the attributes modified by an operation. The path
+// is the attribute of the value, or without a path the value is an object of
+// attributes. Attribute names are lowercase since they're case-insensitive.
+func scimPatchValues(op SCIMPatchOperation) (map[string]json.RawMessage, error) {
+	if op.Path != "" {
+		return map[string]json.RawMessage{
+			strings.ToLower(op.Path): op.Value,
+		}, nil
+	}
+	var values map[string]json.RawMessage
+	err := json.Unmarshal(op.Value, &values)
+	if err != nil {
+		return nil, xerrors.Errorf("value of an operation without a path must be an object: %w", err)
+	}
+	lower := make(map[string]json.RawMessage, len(values))
+	for attribute, value := range values {
+		lower[strings.ToLower(attribute)] = value
+	}
+	return lower, nil
+}
+
+func scimUserFromDB(user database.User) SCIMUser {
+	sUser := SCIMUser{
+		Schemas:  []string{scimSchemaUser},
+		ID:       user.ID.String(),
+		UserName: user.Username,
+		Emails: []SCIMEmail{{
+			Primary: true,
+			Value:   user.Email,
+			Type:    "work",
+		}},
+		Active: user.Status == database.UserStatusActive,
+		Groups: []interface{}{},
+	}
+	sUser.Meta.ResourceType = "User"
+	return sUser
+}
+
+// scimGetUsers returns the users matching the filter. Identity providers use
+// it to look up whether a user exists before creating them.
+func (api *API) scimGetUsers(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if !api.scimAuthorized(rw, r) {
+		return
+	}
+
+	filter, err := parseSCIMFilter(r.URL.Query().Get("filter"))
+	if err != nil {
+		scimError(rw, spec.ErrInvalidFilter, err.Error())
+		return
+	}
+	page, err := parseSCIMPage(r)
+	if err != nil {
+		scimError(rw, spec.ErrInvalidValue, err.Error())
+		return
+	}
+
+	if filter != nil {
+		params := database.GetUserByEmailOrUsernameParams{}
+		switch strings.ToLower(filter.Attribute) {
+		case "username":
+			params.Username = filter.Value
+		case "emails", "emails.value":
+			params.Email = filter.Value
+		case "id":
+			id, err := uuid.Parse(filter.Value)
+			if err != nil {
+				writeSCIMList(ctx, rw, page, 0, []SCIMUser{})
+				return
+			}
+			user, err := api.Database.GetUserByID(ctx, id)
+			if xerrors.Is(err, sql.ErrNoRows) || (err == nil && user.Deleted) {
+				writeSCIMList(ctx, rw, page, 0, []SCIMUser{})
+				return
+			}
+			if err != nil {
+				scimInternalError(rw, err)
+				return
+			}
+			writeSCIMList(ctx, rw, page, 1, scimPageOf(page, []SCIMUser{scimUserFromDB(user)}))
+			return
+		default:
+			scimError(rw, spec.ErrInvalidFilter, "Users can only be filtered by userName, emails or id.")
+			return
+		}
+		user, err := api.Database.GetUserByEmailOrUsername(ctx, params)
+		if xerrors.Is(err, sql.ErrNoRows) {
+			writeSCIMList(ctx, rw, page, 0, []SCIMUser{})
+			return
+		}
+		if err != nil {
+			scimInternalError(rw, err)
+			return
+		}
+		writeSCIMList(ctx, rw, page, 1, scimPageOf(page, []SCIMUser{scimUserFromDB(user)}))
+		return
+	}
+
+	total, err := api.Database.GetFilteredUserCount(ctx, database.GetFilteredUserCountParams{})
+	if err != nil {
+		scimInternalError(rw, err)
+		return
+	}
+	users := []database.User{}
+	if page.Count > 0 {
+		rows, err := api.Database.GetUsers(ctx, database.GetUsersParams{
+			OffsetOpt: int32(page.StartIndex - 1),
+			LimitOpt:  int32(page.Count),
+		})
+		if err != nil && !xerrors.Is(err, sql.ErrNoRows) {
+			scimInternalError(rw, err)
+			return
+		}
+		users = database.ConvertUserRows(rows)
+	}
+	sUsers := make([]SCIMUser, 0, len(users))
+	for _, user := range users {
+		sUsers = append(sUsers, scimUserFromDB(user))
+	}
+	writeSCIMList(ctx, rw, page, int(total), sUsers)
+}
+
+// scimUserParam returns the user in the URL, or writes an error if it
+// doesn't exist.
+func (api *API) scimUserParam(rw http.ResponseWriter, r *http.Request) (database.User, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		scimError(rw, spec.ErrNotFound, "User not found.")
+		return database.User{}, false
+	}
+	user, err := api.Database.GetUserByID(r.Context(), id)
+	if xerrors.Is(err, sql.ErrNoRows) || (err == nil && user.Deleted) {
+		scimError(rw, spec.ErrNotFound, "User not found.")
+		return database.User{}, false
+	}
+	if err != nil {
+		scimInternalError(rw, err)
+		return database.User{}, false
+	}
+	return user, true
+}
+
+func (api *API) scimGetUser(rw http.ResponseWriter, r *http.Request) {
+	if !api.scimAuthorized(rw, r) {
+		return
+	}
+
+	user, ok := api.scimUserParam(rw, r)
+	if !ok {
+		return
+	}
+
+	httpapi.Write(r.Context(), rw, http.StatusOK, scimUserFromDB(user))
+}
+
+// scimPostUser creates a new user in the default organization.
 func (api *API) scimPostUser(rw http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	if !api.scimVerifyAuthHeader(r) {
-		_ = handlerutil.WriteError(rw, spec.Error{Status: http.StatusUnauthorized, Type: "invalidAuthorization"})
+	if !api.scimAuthorized(rw, r) {
 		return
 	}
 
@@ -121,17 +410,28 @@ func (api *API) scimPostUser(rw http.ResponseWriter, r *http.Request) {
 	}
 
 	if email == "" {
-		_ = handlerutil.WriteError(rw, spec.Error{Status: http.StatusBadRequest, Type: "invalidEmail"})
+		scimError(rw, &spec.Error{Status: http.StatusBadRequest, Type: "invalidEmail"}, "A primary email is required.")
+		return
+	}
+
+	org, err := scimOrganization(ctx, api.Database)
+	if err != nil {
+		scimInternalError(rw, err)
 		return
 	}
 
 	user, _, err := api.AGPL.CreateUser(ctx, api.Database, agpl.CreateUserRequest{
 		CreateUserRequest: codersdk.CreateUserRequest{
-			Username: sUser.UserName,
-			Email:    email,
+			Username:       sUser.UserName,
+			Email:          email,
+			OrganizationID: org.ID,
 		},
 		LoginType: database.LoginTypeOIDC,
 	})
+	if database.IsUniqueViolation(err) {
+		scimError(rw, spec.ErrUniqueness, "The userName or email is already in use.")
+		return
+	}
 	if err != nil {
 		_ = handlerutil.WriteError(rw, err)
 		return
@@ -143,52 +443,168 @@ func (api *API) scimPostUser(rw http.ResponseWriter, r *http.Request) {
 	httpapi.Write(ctx, rw, http.StatusOK, sUser)
 }
 
-// scimPatchUser supports suspending and activating users only.
+// scimPatchUser modifies the userName, emails and active attributes of a
+// user. Other attributes aren't stored by Coder, so they're ignored. For
+// compatibility, a body of a user rather than a list of operations only
+// sets whether the user is active.
 func (api *API) scimPatchUser(rw http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	if !api.scimVerifyAuthHeader(r) {
-		_ = handlerutil.WriteError(rw, spec.Error{Status: http.StatusUnauthorized, Type: "invalidAuthorization"})
+	if !api.scimAuthorized(rw, r) {
 		return
 	}
 
-	id := chi.URLParam(r, "id")
-
-	var sUser SCIMUser
-	err := json.NewDecoder(r.Body).Decode(&sUser)
-	if err != nil {
-		_ = handlerutil.WriteError(rw, err)
+	dbUser, ok := api.scimUserParam(rw, r)
+	if !ok {
 		return
 	}
-	sUser.ID = id
 
-	uid, err := uuid.Parse(id)
+	var body json.RawMessage
+	err := json.NewDecoder(r.Body).Decode(&body)
 	if err != nil {
-		_ = handlerutil.WriteError(rw, spec.Error{Status: http.StatusBadRequest, Type: "invalidId"})
+		scimError(rw, spec.ErrInvalidSyntax, err.Error())
 		return
 	}
-
-	dbUser, err := api.Database.GetUserByID(ctx, uid)
+	var req SCIMPatchRequest
+	err = json.Unmarshal(body, &req)
 	if err != nil {
-		_ = handlerutil.WriteError(rw, err)
+		scimError(rw, spec.ErrInvalidSyntax, err.Error())
 		return
 	}
+	if len(req.Operations) == 0 {
+		var sUser SCIMUser
+		err = json.Unmarshal(body, &sUser)
+		if err != nil {
+			scimError(rw, spec.ErrInvalidSyntax, err.Error())
+			return
+		}
+		req.Operations = []SCIMPatchOperation{{
+			Op:    "replace",
+			Path:  "active",
+			Value: json.RawMessage(strconv.FormatBool(sUser.Active)),
+		}}
+	}
 
-	var status database.UserStatus
-	if sUser.Active {
-		status = database.UserStatusActive
-	} else {
-		status = database.UserStatusSuspended
+	var (
+		username = dbUser.Username
+		email    = dbUser.Email
+		active   = dbUser.Status == database.UserStatusActive
+	)
+	for _, op := range req.Operations {
+		kind, err := scimPatchOp(op)
+		if err != nil {
+			scimError(rw, spec.ErrInvalidSyntax, err.Error())
+			return
+		}
+		values, err := scimPatchValues(op)
+		if err != nil {
+			scimError(rw, spec.ErrInvalidValue, err.Error())
+			return
+		}
+		for attribute, value := range values {
+			switch {
+			case attribute == "username":
+				if kind == "remove" {
+					scimError(rw, spec.ErrMutability, "userName is required.")
+					return
+				}
+				err = json.Unmarshal(value, &username)
+			case attribute == "active":
+				if kind == "remove" {
+					scimError(rw, spec.ErrMutability, "active is required.")
+					return
+				}
+				active, err = scimBool(value)
+			case attribute == "emails":
+				if kind == "remove" {
+					scimError(rw, spec.ErrMutability, "emails is required.")
+					return
+				}
+				var emails []SCIMEmail
+				err = json.Unmarshal(value, &emails)
+				if err == nil && len(emails) > 0 {
+					email = emails[0].Value
+					for _, e := range emails {
+						if e.Primary {
+							email = e.Value
+							break
+						}
+					}
+				}
+			case strings.HasPrefix(attribute, "emails[") && strings.HasSuffix(attribute, "].value"),
+				attribute == "emails.value":
+				if kind == "remove" {
+					scimError(rw, spec.ErrMutability, "emails is required.")
+					return
+				}
+				err = json.Unmarshal(value, &email)
+			}
+			if err != nil {
+				scimError(rw, spec.ErrInvalidValue, xerrors.Errorf("invalid value of %q: %w", attribute, err).Error())
+				return
+			}
+		}
 	}
 
-	_, err = api.Database.UpdateUserStatus(r.Context(), database.UpdateUserStatusParams{
-		ID:        dbUser.ID,
-		Status:    status,
-		UpdatedAt: database.Now(),
-	})
-	if err != nil {
-		_ = handlerutil.WriteError(rw, err)
+	if username != dbUser.Username {
+		if err := httpapi.NameValid(username); err != nil {
+			scimError(rw, spec.ErrInvalidValue, xerrors.Errorf("invalid userName %q: %w", username, err).Error())
+			return
+		}
+	}
+	if email == "" {
+		scimError(rw, spec.ErrInvalidValue, "emails must have a value.")
 		return
 	}
 
-	httpapi.Write(ctx, rw, http.StatusOK, sUser)
+	if username != dbUser.Username || email != dbUser.Email {
+		dbUser, err = api.Database.UpdateUserProfile(ctx, database.UpdateUserProfileParams{
+			ID:        dbUser.ID,
+			Email:     email,
+			Username:  username,
+			AvatarURL: dbUser.AvatarURL,
+			UpdatedAt: database.Now(),
+		})
+		if database.IsUniqueViolation(err) {
+			scimError(rw, spec.ErrUniqueness, "The userName or email is already in use.")
+			return
+		}
+		if err != nil {
+			scimInternalError(rw, err)
+			return
+		}
+	}
+
+	if active != (dbUser.Status == database.UserStatusActive) {
+		status := database.UserStatusSuspended
+		if active {
+			status = database.UserStatusActive
+		}
+		dbUser, err = api.Database.UpdateUserStatus(ctx, database.UpdateUserStatusParams{
+			ID:        dbUser.ID,
+			Status:    status,
+			UpdatedAt: database.Now(),
+		})
+		if err != nil {
+			scimInternalError(rw, err)
+			return
+		}
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, scimUserFromDB(dbUser))
+}
+
+// scimBool parses a boolean value. Some identity providers send booleans as
+// strings.
+func scimBool(value json.RawMessage) (bool, error) {
+	var b bool
+	err := json.Unmarshal(value, &b)
+	if err == nil {
+		return b, nil
+	}
+	var s string
+	err = json.Unmarshal(value, &s)
+	if err != nil {
+		return false, xerrors.New("must be a boolean")
+	}
+	return strconv.ParseBool(strings.ToLower(s))
 }
@@ -5,8 +5,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"testing"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -91,7 +93,7 @@ func TestScim(t *testing.T) {
 			res, err := client.Request(ctx, "POST", "/scim/v2/Users", struct{}{})
 			require.NoError(t, err)
 			defer res.Body.Close()
-			assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+			assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
 		})
 
 		t.Run("OK", func(t *testing.T) {
@@ -161,7 +163,7 @@ func TestScim(t *testing.T) {
 			res, err := client.Request(ctx, "PATCH", "/scim/v2/Users/bob", struct{}{})
 			require.NoError(t, err)
 			defer res.Body.Close()
-			assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+			assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
 		})
 
 		t.Run("OK", func(t *testing.T) {
@@ -200,4 +202,262 @@ func TestScim(t *testing.T) {
 			assert.Equal(t, codersdk.UserStatusSuspended, userRes.Users[0].Status)
 		})
 	})
+
+	t.Run("patchUserOperations", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		scimAPIKey := []byte("hi")
+		client := coderdenttest.New(t, &coderdenttest.Options{SCIMAPIKey: scimAPIKey})
+		_ = coderdtest.CreateFirstUser(t, client)
+		coderdenttest.AddLicense(t, client, coderdenttest.LicenseOptions{
+			AccountID: "coolin",
+			SCIM:      true,
+		})
+
+		sUser := makeScimUser(t)
+		res, err := client.Request(ctx, "POST", "/scim/v2/Users", sUser, setScimAuth(scimAPIKey))
+		require.NoError(t, err)
+		defer res.Body.Close()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		err = json.NewDecoder(res.Body).Decode(&sUser)
+		require.NoError(t, err)
+
+		res, err = client.Request(ctx, "PATCH", "/scim/v2/Users/"+sUser.ID, coderd.SCIMPatchRequest{
+			Operations: []coderd.SCIMPatchOperation{{
+				Op:    "replace",
+				Path:  "userName",
+				Value: json.RawMessage(`"renamed"`),
+			}, {
+				Op:    "Replace",
+				Path:  `emails[type eq "work"].value`,
+				Value: json.RawMessage(`"renamed@coder.com"`),
+			}, {
+				Op:    "replace",
+				Value: json.RawMessage(`{"active": "False"}`),
+			}},
+		}, setScimAuth(scimAPIKey))
+		require.NoError(t, err)
+		defer res.Body.Close()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		var patched coderd.SCIMUser
+		err = json.NewDecoder(res.Body).Decode(&patched)
+		require.NoError(t, err)
+		require.Equal(t, "renamed", patched.UserName)
+		require.Equal(t, "renamed@coder.com", patched.Emails[0].Value)
+		require.False(t, patched.Active)
+
+		user, err := client.User(ctx, sUser.ID)
+		require.NoError(t, err)
+		require.Equal(t, "renamed", user.Username)
+		require.Equal(t, "renamed@coder.com", user.Email)
+		require.Equal(t, codersdk.UserStatusSuspended, user.Status)
+
+		// Required attributes can't be removed.
+		res, err = client.Request(ctx, "PATCH", "/scim/v2/Users/"+sUser.ID, coderd.SCIMPatchRequest{
+			Operations: []coderd.SCIMPatchOperation{{
+				Op:   "remove",
+				Path: "userName",
+			}},
+		}, setScimAuth(scimAPIKey))
+		require.NoError(t, err)
+		defer res.Body.Close()
+		require.Equal(t, http.StatusBadRequest, res.StatusCode)
+	})
+
+	t.Run("getUsers", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		scimAPIKey := []byte("hi")
+		client := coderdenttest.New(t, &coderdenttest.Options{SCIMAPIKey: scimAPIKey})
+		_ = coderdtest.CreateFirstUser(t, client)
+		coderdenttest.AddLicense(t, client, coderdenttest.LicenseOptions{
+			AccountID: "coolin",
+			SCIM:      true,
+		})
+
+		sUser := makeScimUser(t)
+		res, err := client.Request(ctx, "POST", "/scim/v2/Users", sUser, setScimAuth(scimAPIKey))
+		require.NoError(t, err)
+		defer res.Body.Close()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		err = json.NewDecoder(res.Body).Decode(&sUser)
+		require.NoError(t, err)
+
+		list := func(t *testing.T, query string) coderd.SCIMListResponse[coderd.SCIMUser] {
+			res, err := client.Request(ctx, "GET", "/scim/v2/Users?"+query, nil, setScimAuth(scimAPIKey))
+			require.NoError(t, err)
+			defer res.Body.Close()
+			require.Equal(t, http.StatusOK, res.StatusCode)
+			var list coderd.SCIMListResponse[coderd.SCIMUser]
+			err = json.NewDecoder(res.Body).Decode(&list)
+			require.NoError(t, err)
+			return list
+		}
+
+		users := list(t, url.Values{"filter": {fmt.Sprintf("userName eq %q", sUser.UserName)}}.Encode())
+		require.Equal(t, 1, users.TotalResults)
+		require.Len(t, users.Resources, 1)
+		require.Equal(t, sUser.ID, users.Resources[0].ID)
+		require.True(t, users.Resources[0].Active)
+
+		users = list(t, url.Values{"filter": {`userName eq "missing"`}}.Encode())
+		require.Equal(t, 0, users.TotalResults)
+		require.Empty(t, users.Resources)
+
+		// The first user and the SCIM user exist.
+		users = list(t, "startIndex=2&count=1")
+		require.Equal(t, 2, users.TotalResults)
+		require.Equal(t, 2, users.StartIndex)
+		require.Equal(t, 1, users.ItemsPerPage)
+		require.Len(t, users.Resources, 1)
+
+		res, err = client.Request(ctx, "GET", "/scim/v2/Users?filter="+url.QueryEscape(`userName co "a"`), nil, setScimAuth(scimAPIKey))
+		require.NoError(t, err)
+		defer res.Body.Close()
+		require.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		res, err = client.Request(ctx, "GET", "/scim/v2/Users/"+sUser.ID, nil, setScimAuth(scimAPIKey))
+		require.NoError(t, err)
+		defer res.Body.Close()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		res, err = client.Request(ctx, "GET", "/scim/v2/Users/"+uuid.NewString(), nil, setScimAuth(scimAPIKey))
+		require.NoError(t, err)
+		defer res.Body.Close()
+		require.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("groups", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		scimAPIKey := []byte("hi")
+		client := coderdenttest.New(t, &coderdenttest.Options{SCIMAPIKey: scimAPIKey})
+		first := coderdtest.CreateFirstUser(t, client)
+		coderdenttest.AddLicense(t, client, coderdenttest.LicenseOptions{
+			AccountID:    "coolin",
+			SCIM:         true,
+			TemplateRBAC: true,
+		})
+		// Groups are always created in the default organization.
+		_, err := client.CreateOrganization(ctx, codersdk.CreateOrganizationRequest{
+			Name: "second",
+		})
+		require.NoError(t, err)
+
+		createUser := func(t *testing.T) coderd.SCIMUser {
+			sUser := makeScimUser(t)
+			res, err := client.Request(ctx, "POST", "/scim/v2/Users", sUser, setScimAuth(scimAPIKey))
+			require.NoError(t, err)
+			defer res.Body.Close()
+			require.Equal(t, http.StatusOK, res.StatusCode)
+			err = json.NewDecoder(res.Body).Decode(&sUser)
+			require.NoError(t, err)
+			return sUser
+		}
+		do := func(t *testing.T, method, path string, body interface{}, status int) coderd.SCIMGroup {
+			res, err := client.Request(ctx, method, path, body, setScimAuth(scimAPIKey))
+			require.NoError(t, err)
+			defer res.Body.Close()
+			require.Equal(t, status, res.StatusCode)
+			var sGroup coderd.SCIMGroup
+			if status < 300 && status != http.StatusNoContent {
+				err = json.NewDecoder(res.Body).Decode(&sGroup)
+				require.NoError(t, err)
+			}
+			return sGroup
+		}
+		memberIDs := func(sGroup coderd.SCIMGroup) []string {
+			ids := make([]string, 0, len(sGroup.Members))
+			for _, member := range sGroup.Members {
+				ids = append(ids, member.Value)
+			}
+			return ids
+		}
+
+		alice := createUser(t)
+		bob := createUser(t)
+
+		sGroup := do(t, "POST", "/scim/v2/Groups", coderd.SCIMGroup{
+			DisplayName: "Engineering Team",
+			Members:     []coderd.SCIMGroupMember{{Value: alice.ID}},
+		}, http.StatusCreated)
+		require.Equal(t, "Engineering Team", sGroup.DisplayName)
+		require.Equal(t, []string{alice.ID}, memberIDs(sGroup))
+		group, err := client.GroupByOrgAndName(ctx, first.OrganizationID, "Engineering Team")
+		require.NoError(t, err)
+		require.Equal(t, sGroup.ID, group.ID.String())
+		do(t, "POST", "/scim/v2/Groups", coderd.SCIMGroup{
+			DisplayName: "Engineering Team",
+		}, http.StatusConflict)
+		do(t, "POST", "/scim/v2/Groups", coderd.SCIMGroup{
+			DisplayName: "Strangers",
+			Members:     []coderd.SCIMGroupMember{{Value: uuid.NewString()}},
+		}, http.StatusBadRequest)
+
+		path := "/scim/v2/Groups/" + sGroup.ID
+		sGroup = do(t, "PATCH", path, coderd.SCIMPatchRequest{
+			Operations: []coderd.SCIMPatchOperation{{
+				Op:    "add",
+				Path:  "members",
+				Value: json.RawMessage(fmt.Sprintf(`[{"value": %q}]`, bob.ID)),
+			}},
+		}, http.StatusOK)
+		require.ElementsMatch(t, []string{alice.ID, bob.ID}, memberIDs(sGroup))
+
+		sGroup = do(t, "PATCH", path, coderd.SCIMPatchRequest{
+			Operations: []coderd.SCIMPatchOperation{{
+				Op:   "remove",
+				Path: fmt.Sprintf(`members[value eq %q]`, alice.ID),
+			}, {
+				Op:    "replace",
+				Value: json.RawMessage(`{"displayName": "Platform"}`),
+			}},
+		}, http.StatusOK)
+		require.Equal(t, "Platform", sGroup.DisplayName)
+		require.Equal(t, []string{bob.ID}, memberIDs(sGroup))
+
+		sGroup = do(t, "PATCH", path, coderd.SCIMPatchRequest{
+			Operations: []coderd.SCIMPatchOperation{{
+				Op:    "replace",
+				Path:  "members",
+				Value: json.RawMessage(fmt.Sprintf(`[{"value": %q}]`, alice.ID)),
+			}},
+		}, http.StatusOK)
+		require.Equal(t, []string{alice.ID}, memberIDs(sGroup))
+
+		sGroup = do(t, "PUT", path, coderd.SCIMGroup{
+			DisplayName: "Platform",
+			Members:     []coderd.SCIMGroupMember{{Value: bob.ID}},
+		}, http.StatusOK)
+		require.Equal(t, []string{bob.ID}, memberIDs(sGroup))
+
+		group, err = client.Group(ctx, uuid.MustParse(sGroup.ID))
+		require.NoError(t, err)
+		require.Equal(t, "Platform", group.Name)
+		require.Len(t, group.Members, 1)
+		require.Equal(t, bob.ID, group.Members[0].ID.String())
+
+		res, err := client.Request(ctx, "GET", "/scim/v2/Groups?excludedAttributes=members&filter="+url.QueryEscape(`displayName eq "platform"`), nil, setScimAuth(scimAPIKey))
+		require.NoError(t, err)
+		defer res.Body.Close()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		var list coderd.SCIMListResponse[coderd.SCIMGroup]
+		err = json.NewDecoder(res.Body).Decode(&list)
+		require.NoError(t, err)
+		require.Equal(t, 1, list.TotalResults)
+		require.Equal(t, sGroup.ID, list.Resources[0].ID)
+		require.Nil(t, list.Resources[0].Members)
+
+		do(t, "DELETE", path, nil, http.StatusNoContent)
+		do(t, "GET", path, nil, http.StatusNotFound)
+	})
 }
@@ -0,0 +1,608 @@
+package coderd
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/imulab/go-scim/pkg/v2/spec"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/httpapi"
+)
+
+// SCIMGroup is a group in the default organization. Members are referenced by
+// user ID.
+type SCIMGroup struct {
+	Schemas     []string          `json:"schemas"`
+	ID          string            `json:"id"`
+	DisplayName string            `json:"displayName"`
+	Members     []SCIMGroupMember `json:"members,omitempty"`
+	Meta        struct {
+		ResourceType string `json:"resourceType"`
+	} `json:"meta"`
+}
+
+type SCIMGroupMember struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+}
+
+// scimOrganization returns the organization SCIM users are added to and
+// groups are in, which is the oldest (default) organization. Once
+// multi-organization support is added, groups should be mapped to
+// organizations.
+func scimOrganization(ctx context.Context, db database.Store) (database.Organization, error) {
+	organizations, err := db.GetOrganizations(ctx)
+	if err != nil {
+		return database.Organization{}, xerrors.Errorf("get organizations: %w", err)
+	}
+	if len(organizations) == 0 {
+		return database.Organization{}, xerrors.New("no organizations exist")
+	}
+	return organizations[0], nil
+}
+
+// scimGroupFromDB converts a group. Members are omitted if they're nil.
+func scimGroupFromDB(group database.Group, members []database.User) SCIMGroup {
+	sGroup := SCIMGroup{
+		Schemas:     []string{scimSchemaGroup},
+		ID:          group.ID.String(),
+		DisplayName: group.Name,
+	}
+	sGroup.Meta.ResourceType = "Group"
+	if members != nil {
+		sGroup.Members = make([]SCIMGroupMember, 0, len(members))
+		for _, member := range members {
+			sGroup.Members = append(sGroup.Members, SCIMGroupMember{
+				Value:   member.ID.String(),
+				Display: member.Username,
+			})
+		}
+	}
+	return sGroup
+}
+
+// scimGroupsFromDB converts groups, fetching their members unless they're
+// excluded by the excludedAttributes query parameter.
+func (api *API) scimGroupsFromDB(r *http.Request, groups []database.Group) ([]SCIMGroup, error) {
+	excludeMembers := false
+	for _, attribute := range strings.Split(r.URL.Query().Get("excludedAttributes"), ",") {
+		if strings.EqualFold(strings.TrimSpace(attribute), "members") {
+			excludeMembers = true
+		}
+	}
+	sGroups := make([]SCIMGroup, 0, len(groups))
+	for _, group := range groups {
+		var members []database.User
+		if !excludeMembers {
+			var err error
+			members, err = api.Database.GetGroupMembers(r.Context(), group.ID)
+			if err != nil && !xerrors.Is(err, sql.ErrNoRows) {
+				return nil, xerrors.Errorf("get group members: %w", err)
+			}
+			if members == nil {
+				members = []database.User{}
+			}
+		}
+		sGroups = append(sGroups, scimGroupFromDB(group, members))
+	}
+	return sGroups, nil
+}
+
+// scimGetGroups returns the groups of the default organization matching the
+// filter.
+func (api *API) scimGetGroups(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if !api.scimAuthorized(rw, r) {
+		return
+	}
+
+	filter, err := parseSCIMFilter(r.URL.Query().Get("filter"))
+	if err != nil {
+		scimError(rw, spec.ErrInvalidFilter, err.Error())
+		return
+	}
+	page, err := parseSCIMPage(r)
+	if err != nil {
+		scimError(rw, spec.ErrInvalidValue, err.Error())
+		return
+	}
+	org, err := scimOrganization(ctx, api.Database)
+	if err != nil {
+		scimInternalError(rw, err)
+		return
+	}
+	groups, err := api.Database.GetGroupsByOrganizationID(ctx, org.ID)
+	if err != nil && !xerrors.Is(err, sql.ErrNoRows) {
+		scimInternalError(rw, err)
+		return
+	}
+
+	if filter != nil {
+		var match func(group database.Group) bool
+		switch strings.ToLower(filter.Attribute) {
+		case "displayname":
+			match = func(group database.Group) bool {
+				return strings.EqualFold(group.Name, filter.Value)
+			}
+		case "id":
+			match = func(group database.Group) bool {
+				return group.ID.String() == filter.Value
+			}
+		default:
+			scimError(rw, spec.ErrInvalidFilter, "Groups can only be filtered by displayName or id.")
+			return
+		}
+		filtered := make([]database.Group, 0, 1)
+		for _, group := range groups {
+			if match(group) {
+				filtered = append(filtered, group)
+			}
+		}
+		groups = filtered
+	}
+
+	sGroups, err := api.scimGroupsFromDB(r, scimPageOf(page, groups))
+	if err != nil {
+		scimInternalError(rw, err)
+		return
+	}
+	writeSCIMList(ctx, rw, page, len(groups), sGroups)
+}
+
+// scimGroupParam returns the group in the URL, or writes an error if it
+// doesn't exist in the default organization.
+func (api *API) scimGroupParam(rw http.ResponseWriter, r *http.Request) (database.Group, bool) {
+	ctx := r.Context()
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		scimError(rw, spec.ErrNotFound, "Group not found.")
+		return database.Group{}, false
+	}
+	org, err := scimOrganization(ctx, api.Database)
+	if err != nil {
+		scimInternalError(rw, err)
+		return database.Group{}, false
+	}
+	group, err := api.Database.GetGroupByID(ctx, id)
+	// The group of every user in an organization can't be managed.
+	if xerrors.Is(err, sql.ErrNoRows) || (err == nil && (group.OrganizationID != org.ID || group.ID == org.ID)) {
+		scimError(rw, spec.ErrNotFound, "Group not found.")
+		return database.Group{}, false
+	}
+	if err != nil {
+		scimInternalError(rw, err)
+		return database.Group{}, false
+	}
+	return group, true
+}
+
+func (api *API) scimGetGroup(rw http.ResponseWriter, r *http.Request) {
+	if !api.scimAuthorized(rw, r) {
+		return
+	}
+
+	group, ok := api.scimGroupParam(rw, r)
+	if !ok {
+		return
+	}
+	sGroups, err := api.scimGroupsFromDB(r, []database.Group{group})
+	if err != nil {
+		scimInternalError(rw, err)
+		return
+	}
+
+	httpapi.Write(r.Context(), rw, http.StatusOK, sGroups[0])
+}
+
+// scimPostGroup creates a group in the default organization.
+func (api *API) scimPostGroup(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if !api.scimAuthorized(rw, r) {
+		return
+	}
+
+	var sGroup SCIMGroup
+	err := json.NewDecoder(r.Body).Decode(&sGroup)
+	if err != nil {
+		scimError(rw, spec.ErrInvalidSyntax, err.Error())
+		return
+	}
+	if !validSCIMGroupName(rw, sGroup.DisplayName) {
+		return
+	}
+	memberIDs, ok := scimMemberIDs(rw, sGroup.Members)
+	if !ok {
+		return
+	}
+	org, err := scimOrganization(ctx, api.Database)
+	if err != nil {
+		scimInternalError(rw, err)
+		return
+	}
+
+	var group database.Group
+	err = api.Database.InTx(func(tx database.Store) error {
+		group, err = tx.InsertGroup(ctx, database.InsertGroupParams{
+			ID:             uuid.New(),
+			Name:           sGroup.DisplayName,
+			OrganizationID: org.ID,
+			Source:         database.GroupSourceSCIM,
+		})
+		if err != nil {
+			return xerrors.Errorf("insert group: %w", err)
+		}
+		return setSCIMGroupMembers(ctx, tx, group, memberIDs)
+	}, nil)
+	if database.IsUniqueViolation(err) {
+		scimError(rw, spec.ErrUniqueness, "A group with the displayName already exists.")
+		return
+	}
+	if !scimWriteTxError(rw, err) {
+		return
+	}
+
+	sGroups, err := api.scimGroupsFromDB(r, []database.Group{group})
+	if err != nil {
+		scimInternalError(rw, err)
+		return
+	}
+	httpapi.Write(ctx, rw, http.StatusCreated, sGroups[0])
+}
+
+// scimPutGroup replaces the displayName and members of a group.
+func (api *API) scimPutGroup(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if !api.scimAuthorized(rw, r) {
+		return
+	}
+
+	group, ok := api.scimGroupParam(rw, r)
+	if !ok {
+		return
+	}
+	var sGroup SCIMGroup
+	err := json.NewDecoder(r.Body).Decode(&sGroup)
+	if err != nil {
+		scimError(rw, spec.ErrInvalidSyntax, err.Error())
+		return
+	}
+	if !validSCIMGroupName(rw, sGroup.DisplayName) {
+		return
+	}
+	memberIDs, ok := scimMemberIDs(rw, sGroup.Members)
+	if !ok {
+		return
+	}
+
+	err = api.Database.InTx(func(tx database.Store) error {
+		group, err = renameSCIMGroup(ctx, tx, group, sGroup.DisplayName)
+		if err != nil {
+			return err
+		}
+		return setSCIMGroupMembers(ctx, tx, group, memberIDs)
+	}, nil)
+	if database.IsUniqueViolation(err) {
+		scimError(rw, spec.ErrUniqueness, "A group with the displayName already exists.")
+		return
+	}
+	if !scimWriteTxError(rw, err) {
+		return
+	}
+
+	sGroups, err := api.scimGroupsFromDB(r, []database.Group{group})
+	if err != nil {
+		scimInternalError(rw, err)
+		return
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, sGroups[0])
+}
+
+// scimPatchGroup adds, removes and replaces the members of a group, and
+// replaces its displayName.
+func (api *API) scimPatchGroup(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if !api.scimAuthorized(rw, r) {
+		return
+	}
+
+	group, ok := api.scimGroupParam(rw, r)
+	if !ok {
+		return
+	}
+	var req SCIMPatchRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		scimError(rw, spec.ErrInvalidSyntax, err.Error())
+		return
+	}
+
+	err = api.Database.InTx(func(tx database.Store) error {
+		current, err := tx.GetGroupMemberIDs(ctx, group.ID)
+		if err != nil && !xerrors.Is(err, sql.ErrNoRows) {
+			return xerrors.Errorf("get group member IDs: %w", err)
+		}
+		members := make(map[uuid.UUID]struct{}, len(current))
+		for _, id := range current {
+			members[id] = struct{}{}
+		}
+		name := group.Name
+
+		for _, op := range req.Operations {
+			kind, err := scimPatchOp(op)
+			if err != nil {
+				return scimPatchError{err: spec.ErrInvalidSyntax, detail: err.Error()}
+			}
+
+			// Members are removed with a filter on their ID in the path.
+			if kind == "remove" && strings.HasPrefix(strings.ToLower(op.Path), "members[") {
+				id, err := scimMemberFilterID(op.Path)
+				if err != nil {
+					return scimPatchError{err: spec.ErrInvalidPath, detail: err.Error()}
+				}
+				delete(members, id)
+				continue
+			}
+
+			values, err := scimPatchValues(op)
+			if err != nil {
+				return scimPatchError{err: spec.ErrInvalidValue, detail: err.Error()}
+			}
+			for attribute, value := range values {
+				switch attribute {
+				case "displayname":
+					if kind == "remove" {
+						return scimPatchError{err: spec.ErrMutability, detail: "displayName is required."}
+					}
+					err = json.Unmarshal(value, &name)
+					if err != nil {
+						return scimPatchError{err: spec.ErrInvalidValue, detail: "displayName must be a string."}
+					}
+				case "members":
+					var sMembers []SCIMGroupMember
+					if len(value) > 0 {
+						err = json.Unmarshal(value, &sMembers)
+						if err != nil {
+							return scimPatchError{err: spec.ErrInvalidValue, detail: "members must be a list of objects with a value."}
+						}
+					}
+					ids, err := parseSCIMMemberIDs(sMembers)
+					if err != nil {
+						return scimPatchError{err: spec.ErrInvalidValue, detail: err.Error()}
+					}
+					if kind == "remove" {
+						// Without a value, every member is removed.
+						if len(value) == 0 {
+							members = map[uuid.UUID]struct{}{}
+						}
+						for _, id := range ids {
+							delete(members, id)
+						}
+						continue
+					}
+					if kind == "replace" {
+						members = map[uuid.UUID]struct{}{}
+					}
+					for _, id := range ids {
+						members[id] = struct{}{}
+					}
+				default:
+					// Attributes Coder doesn't store, such as externalId,
+					// are ignored.
+				}
+			}
+		}
+
+		if name != group.Name {
+			if name == "" || name == database.AllUsersGroup {
+				return scimPatchError{err: spec.ErrInvalidValue, detail: fmt.Sprintf("displayName %q is invalid.", name)}
+			}
+			group, err = renameSCIMGroup(ctx, tx, group, name)
+			if err != nil {
+				return err
+			}
+		}
+		ids := make([]uuid.UUID, 0, len(members))
+		for id := range members {
+			ids = append(ids, id)
+		}
+		return setSCIMGroupMembers(ctx, tx, group, ids)
+	}, nil)
+	var patchErr scimPatchError
+	if xerrors.As(err, &patchErr) {
+		scimError(rw, patchErr.err, patchErr.detail)
+		return
+	}
+	if database.IsUniqueViolation(err) {
+		scimError(rw, spec.ErrUniqueness, "A group with the displayName already exists.")
+		return
+	}
+	if !scimWriteTxError(rw, err) {
+		return
+	}
+
+	sGroups, err := api.scimGroupsFromDB(r, []database.Group{group})
+	if err != nil {
+		scimInternalError(rw, err)
+		return
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, sGroups[0])
+}
+
+func (api *API) scimDeleteGroup(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if !api.scimAuthorized(rw, r) {
+		return
+	}
+
+	group, ok := api.scimGroupParam(rw, r)
+	if !ok {
+		return
+	}
+	err := api.Database.DeleteGroupByID(ctx, group.ID)
+	if err != nil {
+		scimInternalError(rw, err)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// scimPatchError is an error applying a patch operation.
+type scimPatchError struct {
+	err    *spec.Error
+	detail string
+}
+
+func (e scimPatchError) Error() string {
+	return e.detail
+}
+
+// scimMemberError is a member that can't be added to a group.
+type scimMemberError struct {
+	userID uuid.UUID
+}
+
+func (e scimMemberError) Error() string {
+	return fmt.Sprintf("User %q isn't a member of the organization.", e.userID)
+}
+
+// scimWriteTxError writes the error of a transaction and returns false if
+// there is one.
+func scimWriteTxError(rw http.ResponseWriter, err error) bool {
+	var memberErr scimMemberError
+	if xerrors.As(err, &memberErr) {
+		scimError(rw, spec.ErrInvalidValue, memberErr.Error())
+		return false
+	}
+	if err != nil {
+		scimInternalError(rw, err)
+		return false
+	}
+	return true
+}
+
+func validSCIMGroupName(rw http.ResponseWriter, name string) bool {
+	if name == "" {
+		scimError(rw, spec.ErrInvalidValue, "displayName is required.")
+		return false
+	}
+	if name == database.AllUsersGroup {
+		scimError(rw, spec.ErrUniqueness, fmt.Sprintf("The displayName %q is reserved.", database.AllUsersGroup))
+		return false
+	}
+	return true
+}
+
+func scimMemberIDs(rw http.ResponseWriter, members []SCIMGroupMember) ([]uuid.UUID, bool) {
+	ids, err := parseSCIMMemberIDs(members)
+	if err != nil {
+		scimError(rw, spec.ErrInvalidValue, err.Error())
+		return nil, false
+	}
+	return ids, true
+}
+
+func parseSCIMMemberIDs(members []SCIMGroupMember) ([]uuid.UUID, error) {
+	ids := make([]uuid.UUID, 0, len(members))
+	for _, member := range members {
+		id, err := uuid.Parse(member.Value)
+		if err != nil {
+			return nil, xerrors.Errorf("member value %q must be a user ID", member.Value)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// scimMemberFilterID returns the user ID in a path formatted as
+// members[value eq "<id>"].
+func scimMemberFilterID(path string) (uuid.UUID, error) {
+	inner := path[len("members["):]
+	end := strings.Index(inner, "]")
+	if end == -1 {
+		return uuid.Nil, xerrors.Errorf("path %q must be formatted as members[value eq \"<id>\"]", path)
+	}
+	filter, err := parseSCIMFilter(inner[:end])
+	if err != nil || filter == nil || !strings.EqualFold(filter.Attribute, "value") {
+		return uuid.Nil, xerrors.Errorf("path %q must be formatted as members[value eq \"<id>\"]", path)
+	}
+	id, err := uuid.Parse(filter.Value)
+	if err != nil {
+		return uuid.Nil, xerrors.Errorf("member value %q must be a user ID", filter.Value)
+	}
+	return id, nil
+}
+
+func renameSCIMGroup(ctx context.Context, tx database.Store, group database.Group, name string) (database.Group, error) {
+	if name == group.Name {
+		return group, nil
+	}
+	group, err := tx.UpdateGroupByID(ctx, database.UpdateGroupByIDParams{
+		ID:             group.ID,
+		Name:           name,
+		AvatarURL:      group.AvatarURL,
+		QuotaAllowance: group.QuotaAllowance,
+	})
+	if err != nil {
+		return database.Group{}, xerrors.Errorf("update group: %w", err)
+	}
+	return group, nil
+}
+
+// setSCIMGroupMembers sets the members of a group to the users. Users must be
+// members of the group's organization.
+func setSCIMGroupMembers(ctx context.Context, tx database.Store, group database.Group, userIDs []uuid.UUID) error {
+	current, err := tx.GetGroupMemberIDs(ctx, group.ID)
+	if err != nil && !xerrors.Is(err, sql.ErrNoRows) {
+		return xerrors.Errorf("get group member IDs: %w", err)
+	}
+	want := make(map[uuid.UUID]struct{}, len(userIDs))
+	for _, id := range userIDs {
+		want[id] = struct{}{}
+	}
+	has := make(map[uuid.UUID]struct{}, len(current))
+	for _, id := range current {
+		has[id] = struct{}{}
+		if _, ok := want[id]; ok {
+			continue
+		}
+		err = tx.DeleteGroupMemberFromGroup(ctx, database.DeleteGroupMemberFromGroupParams{
+			UserID:  id,
+			GroupID: group.ID,
+		})
+		if err != nil {
+			return xerrors.Errorf("remove group member %q: %w", id, err)
+		}
+	}
+	for id := range want {
+		if _, ok := has[id]; ok {
+			continue
+		}
+		_, err = tx.GetOrganizationMemberByUserID(ctx, database.GetOrganizationMemberByUserIDParams{
+			OrganizationID: group.OrganizationID,
+			UserID:         id,
+		})
+		if xerrors.Is(err, sql.ErrNoRows) {
+			return scimMemberError{userID: id}
+		}
+		if err != nil {
+			return xerrors.Errorf("get organization member %q: %w", id, err)
+		}
+		err = tx.InsertGroupMember(ctx, database.InsertGroupMemberParams{
+			UserID:  id,
+			GroupID: group.ID,
+		})
+		if err != nil {
+			return xerrors.Errorf("insert group member %q: %w", id, err)
+		}
+	}
+	return nil
+}
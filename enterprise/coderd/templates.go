@@ -81,13 +81,13 @@ func (api *API) templateACL(rw http.ResponseWriter, r *http.Request) {
 		}
 
 		groups = append(groups, codersdk.TemplateGroup{
-			Group: convertGroup(group.Group, members),
+			Group: convertGroup(group.Group, members, api.AGPL.CustomRoles),
 			Role:  convertToTemplateRole(group.Actions),
 		})
 	}
 
 	httpapi.Write(ctx, rw, http.StatusOK, codersdk.TemplateACL{
-		Users:  convertTemplateUsers(users, organizationIDsByUserID),
+		Users:  convertTemplateUsers(users, organizationIDsByUserID, api.AGPL.CustomRoles),
 		Groups: groups,
 	})
 }
@@ -219,12 +219,12 @@ func validateTemplateACLPerms(ctx context.Context, db database.Store, perms map[
 	return validErrs
 }
 
-func convertTemplateUsers(tus []database.TemplateUser, orgIDsByUserIDs map[uuid.UUID][]uuid.UUID) []codersdk.TemplateUser {
+func convertTemplateUsers(tus []database.TemplateUser, orgIDsByUserIDs map[uuid.UUID][]uuid.UUID, customRoles *rbac.CustomRoles) []codersdk.TemplateUser {
 	users := make([]codersdk.TemplateUser, 0, len(tus))
 
 	for _, tu := range tus {
 		users = append(users, codersdk.TemplateUser{
-			User: convertUser(tu.User, orgIDsByUserIDs[tu.User.ID]),
+			User: convertUser(tu.User, orgIDsByUserIDs[tu.User.ID], customRoles),
 			Role: convertToTemplateRole(tu.Actions),
 		})
 	}
@@ -0,0 +1,187 @@
+package coderd_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/enterprise/coderd/coderdenttest"
+	"github.com/coder/coder/testutil"
+)
+
+func TestUserOIDC(t *testing.T) {
+	t.Parallel()
+
+	t.Run("GroupSync", func(t *testing.T) {
+		t.Parallel()
+
+		conf := coderdtest.NewOIDCConfig(t, "")
+		config := conf.OIDCConfig()
+		config.AllowSignups = true
+		config.GroupField = "groups"
+		config.GroupMapping = map[string]string{
+			"engineering": "devs",
+		}
+		config.CreateMissingGroups = true
+
+		client := coderdenttest.New(t, &coderdenttest.Options{
+			Options: &coderdtest.Options{
+				OIDCConfig: config,
+			},
+		})
+		admin := coderdtest.CreateFirstUser(t, client)
+		_ = coderdenttest.AddLicense(t, client, coderdenttest.LicenseOptions{
+			TemplateRBAC: true,
+		})
+
+		ctx, _ := testutil.Context(t)
+		ops, err := client.CreateGroup(ctx, admin.OrganizationID, codersdk.CreateGroupRequest{
+			Name: "ops",
+		})
+		require.NoError(t, err)
+
+		resp := oidcCallback(t, client, conf.EncodeClaims(t, jwt.MapClaims{
+			"email":  "colin@coder.com",
+			"groups": []string{"engineering", "ops", "Not A Name"},
+		}))
+		require.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+		userClient := codersdk.New(client.URL)
+		userClient.SetSessionToken(authCookieValue(resp.Cookies()))
+		user, err := userClient.User(ctx, codersdk.Me)
+		require.NoError(t, err)
+
+		devs, err := client.GroupByOrgAndName(ctx, admin.OrganizationID, "devs")
+		require.NoError(t, err)
+		require.Len(t, devs.Members, 1)
+		require.Equal(t, user.ID, devs.Members[0].ID)
+
+		// Users are added to groups created in Coder too.
+		ops, err = client.Group(ctx, ops.ID)
+		require.NoError(t, err)
+		require.Len(t, ops.Members, 1)
+
+		// Invalid names aren't created.
+		_, err = client.GroupByOrgAndName(ctx, admin.OrganizationID, "Not A Name")
+		require.Error(t, err)
+
+		// Groups aren't synced when the claim is missing.
+		resp = oidcCallback(t, client, conf.EncodeClaims(t, jwt.MapClaims{
+			"email": "colin@coder.com",
+		}))
+		require.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+		devs, err = client.Group(ctx, devs.ID)
+		require.NoError(t, err)
+		require.Len(t, devs.Members, 1)
+
+		// Users are only removed from groups created by the sync.
+		resp = oidcCallback(t, client, conf.EncodeClaims(t, jwt.MapClaims{
+			"email":  "colin@coder.com",
+			"groups": []string{},
+		}))
+		require.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+		devs, err = client.Group(ctx, devs.ID)
+		require.NoError(t, err)
+		require.Empty(t, devs.Members)
+		ops, err = client.Group(ctx, ops.ID)
+		require.NoError(t, err)
+		require.Len(t, ops.Members, 1)
+	})
+
+	t.Run("CreateMissingGroups", func(t *testing.T) {
+		t.Parallel()
+
+		conf := coderdtest.NewOIDCConfig(t, "")
+		config := conf.OIDCConfig()
+		config.AllowSignups = true
+		config.GroupField = "groups"
+		config.CreateMissingGroups = true
+
+		client := coderdenttest.New(t, &coderdenttest.Options{
+			Options: &coderdtest.Options{
+				OIDCConfig: config,
+			},
+		})
+		admin := coderdtest.CreateFirstUser(t, client)
+		_ = coderdenttest.AddLicense(t, client, coderdenttest.LicenseOptions{
+			TemplateRBAC: true,
+		})
+
+		resp := oidcCallback(t, client, conf.EncodeClaims(t, jwt.MapClaims{
+			"email":  "colin@coder.com",
+			"groups": []string{"new"},
+		}))
+		require.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+
+		ctx, _ := testutil.Context(t)
+		group, err := client.GroupByOrgAndName(ctx, admin.OrganizationID, "new")
+		require.NoError(t, err)
+		require.Len(t, group.Members, 1)
+		require.Equal(t, "colin@coder.com", group.Members[0].Email)
+	})
+
+	t.Run("Unlicensed", func(t *testing.T) {
+		t.Parallel()
+
+		conf := coderdtest.NewOIDCConfig(t, "")
+		config := conf.OIDCConfig()
+		config.AllowSignups = true
+		config.GroupField = "groups"
+		config.CreateMissingGroups = true
+
+		client := coderdenttest.New(t, &coderdenttest.Options{
+			Options: &coderdtest.Options{
+				OIDCConfig: config,
+			},
+		})
+		admin := coderdtest.CreateFirstUser(t, client)
+
+		// Groups aren't synced without a license, but users can still log in.
+		resp := oidcCallback(t, client, conf.EncodeClaims(t, jwt.MapClaims{
+			"email":  "colin@coder.com",
+			"groups": []string{"new"},
+		}))
+		require.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+
+		_ = coderdenttest.AddLicense(t, client, coderdenttest.LicenseOptions{
+			TemplateRBAC: true,
+		})
+		ctx, _ := testutil.Context(t)
+		_, err := client.GroupByOrgAndName(ctx, admin.OrganizationID, "new")
+		require.Error(t, err)
+	})
+}
+
+func oidcCallback(t *testing.T, client *codersdk.Client, code string) *http.Response {
+	t.Helper()
+	httpClient := *client.HTTPClient
+	httpClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	oauthURL, err := client.URL.Parse(fmt.Sprintf("/api/v2/users/oidc/callback?code=%s&state=somestate", code))
+	require.NoError(t, err)
+	req, err := http.NewRequestWithContext(context.Background(), "GET", oauthURL.String(), nil)
+	require.NoError(t, err)
+	req.AddCookie(&http.Cookie{
+		Name:  codersdk.OAuth2StateKey,
+		Value: "somestate",
+	})
+	res, err := httpClient.Do(req)
+	require.NoError(t, err)
+	_ = res.Body.Close()
+	return res
+}
+
+func authCookieValue(cookies []*http.Cookie) string {
+	for _, cookie := range cookies {
+		if cookie.Name == codersdk.SessionTokenKey {
+			return cookie.Value
+		}
+	}
+	return ""
+}
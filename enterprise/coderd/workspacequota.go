@@ -45,12 +45,20 @@ func (c *committer) CommitQuota(
 	)
 	err = c.Database.InTx(func(s database.Store) error {
 		var err error
-		consumed, err = s.GetQuotaConsumedForUser(ctx, workspace.OwnerID)
+		// Quotas are enforced per organization, so workspaces in other
+		// organizations don't eat into the budget.
+		consumed, err = s.GetQuotaConsumedForUser(ctx, database.GetQuotaConsumedForUserParams{
+			OwnerID:        workspace.OwnerID,
+			OrganizationID: workspace.OrganizationID,
+		})
 		if err != nil {
 			return err
 		}
 
-		budget, err = s.GetQuotaAllowanceForUser(ctx, workspace.OwnerID)
+		budget, err = s.GetQuotaAllowanceForUser(ctx, database.GetQuotaAllowanceForUserParams{
+			UserID:         workspace.OwnerID,
+			OrganizationID: workspace.OrganizationID,
+		})
 		if err != nil {
 			return err
 		}
@@ -99,6 +107,7 @@ func (c *committer) CommitQuota(
 	}, nil
 }
 
+// workspaceQuota returns the quota of a user summed across all organizations.
 func (api *API) workspaceQuota(rw http.ResponseWriter, r *http.Request) {
 	user := httpmw.UserParam(r)
 
@@ -107,6 +116,23 @@ func (api *API) workspaceQuota(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	api.writeWorkspaceQuota(rw, r, user.ID, uuid.Nil)
+}
+
+// workspaceQuotaByOrganization returns the quota of a member of an
+// organization, which is what builds in the organization are checked against.
+func (api *API) workspaceQuotaByOrganization(rw http.ResponseWriter, r *http.Request) {
+	member := httpmw.OrganizationMemberParam(r)
+
+	if !api.AGPL.Authorize(r, rbac.ActionRead, member) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	api.writeWorkspaceQuota(rw, r, member.UserID, member.OrganizationID)
+}
+
+func (api *API) writeWorkspaceQuota(rw http.ResponseWriter, r *http.Request, userID, organizationID uuid.UUID) {
 	api.entitlementsMu.RLock()
 	licensed := api.entitlements.Features[codersdk.FeatureTemplateRBAC].Enabled
 	api.entitlementsMu.RUnlock()
@@ -115,7 +141,10 @@ func (api *API) workspaceQuota(rw http.ResponseWriter, r *http.Request) {
 	var quotaAllowance int64 = -1
 	if licensed {
 		var err error
-		quotaAllowance, err = api.Database.GetQuotaAllowanceForUser(r.Context(), user.ID)
+		quotaAllowance, err = api.Database.GetQuotaAllowanceForUser(r.Context(), database.GetQuotaAllowanceForUserParams{
+			UserID:         userID,
+			OrganizationID: organizationID,
+		})
 		if err != nil {
 			httpapi.Write(r.Context(), rw, http.StatusInternalServerError, codersdk.Response{
 				Message: "Failed to get allowance",
@@ -125,7 +154,10 @@ func (api *API) workspaceQuota(rw http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	quotaConsumed, err := api.Database.GetQuotaConsumedForUser(r.Context(), user.ID)
+	quotaConsumed, err := api.Database.GetQuotaConsumedForUser(r.Context(), database.GetQuotaConsumedForUserParams{
+		OwnerID:        userID,
+		OrganizationID: organizationID,
+	})
 	if err != nil {
 		httpapi.Write(r.Context(), rw, http.StatusInternalServerError, codersdk.Response{
 			Message: "Failed to get consumed",
@@ -141,3 +141,44 @@ func TestWorkspaceQuota(t *testing.T) {
 		require.Equal(t, codersdk.WorkspaceStatusRunning, build.Status)
 	})
 }
+
+func TestWorkspaceQuotaByOrganization(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+	client := coderdenttest.New(t, nil)
+	user := coderdtest.CreateFirstUser(t, client)
+	coderdenttest.AddLicense(t, client, coderdenttest.LicenseOptions{
+		TemplateRBAC: true,
+	})
+	org, err := client.CreateOrganization(ctx, codersdk.CreateOrganizationRequest{
+		Name: "other",
+	})
+	require.NoError(t, err)
+
+	// Each organization grants its own allowance.
+	for orgID, allowance := range map[uuid.UUID]int{
+		user.OrganizationID: 1,
+		org.ID:              4,
+	} {
+		group, err := client.CreateGroup(ctx, orgID, codersdk.CreateGroupRequest{
+			Name:           "quota",
+			QuotaAllowance: allowance,
+		})
+		require.NoError(t, err)
+		_, err = client.PatchGroup(ctx, group.ID, codersdk.PatchGroupRequest{
+			AddUsers: []string{user.UserID.String()},
+		})
+		require.NoError(t, err)
+	}
+
+	verifyQuota(ctx, t, client, 0, 5)
+
+	quota, err := client.WorkspaceQuotaByOrganization(ctx, user.OrganizationID, codersdk.Me)
+	require.NoError(t, err)
+	require.Equal(t, codersdk.WorkspaceQuota{Budget: 1}, quota)
+
+	quota, err = client.WorkspaceQuotaByOrganization(ctx, org.ID, codersdk.Me)
+	require.NoError(t, err)
+	require.Equal(t, codersdk.WorkspaceQuota{Budget: 4}, quota)
+}
@@ -144,14 +144,19 @@ func (e *echo) Provision(stream proto.DRPCProvisioner_ProvisionStream) error {
 		}
 	}
 
-	for index := 0; ; index++ {
-		var extension string
-		if msg.GetPlan() != nil {
-			extension = ".plan.protobuf"
-		} else {
-			extension = ".apply.protobuf"
+	extension := ".provision.plan.protobuf"
+	if msg.GetApply() != nil {
+		extension = ".provision.apply.protobuf"
+		// Prefer responses specific to the workspace transition if any exist.
+		transition := strings.ToLower(config.GetMetadata().GetWorkspaceTransition().String())
+		_, err := e.filesystem.Stat(filepath.Join(config.Directory, "0.provision.apply."+transition+".protobuf"))
+		if err == nil {
+			extension = ".provision.apply." + transition + ".protobuf"
 		}
-		path := filepath.Join(config.Directory, fmt.Sprintf("%d.provision"+extension, index))
+	}
+
+	for index := 0; ; index++ {
+		path := filepath.Join(config.Directory, fmt.Sprintf("%d"+extension, index))
 		_, err := e.filesystem.Stat(path)
 		if err != nil {
 			if index == 0 {
@@ -186,12 +191,19 @@ type Responses struct {
 	Parse          []*proto.Parse_Response
 	ProvisionApply []*proto.Provision_Response
 	ProvisionPlan  []*proto.Provision_Response
+	// ProvisionApplyMap overrides ProvisionApply for specific workspace
+	// transitions.
+	ProvisionApplyMap map[proto.WorkspaceTransition][]*proto.Provision_Response
 }
 
 // Tar returns a tar archive of responses to provisioner operations.
 func Tar(responses *Responses) ([]byte, error) {
 	if responses == nil {
-		responses = &Responses{ParseComplete, ProvisionComplete, ProvisionComplete}
+		responses = &Responses{
+			Parse:          ParseComplete,
+			ProvisionApply: ProvisionComplete,
+			ProvisionPlan:  ProvisionComplete,
+		}
 	}
 	if responses.ProvisionPlan == nil {
 		responses.ProvisionPlan = responses.ProvisionApply
@@ -233,6 +245,25 @@ func Tar(responses *Responses) ([]byte, error) {
 			return nil, err
 		}
 	}
+	for transition, transitionResponses := range responses.ProvisionApplyMap {
+		for index, response := range transitionResponses {
+			data, err := protobuf.Marshal(response)
+			if err != nil {
+				return nil, err
+			}
+			err = writer.WriteHeader(&tar.Header{
+				Name: fmt.Sprintf("%d.provision.apply.%s.protobuf", index, strings.ToLower(transition.String())),
+				Size: int64(len(data)),
+			})
+			if err != nil {
+				return nil, err
+			}
+			_, err = writer.Write(data)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
 	for index, response := range responses.ProvisionPlan {
 		data, err := protobuf.Marshal(response)
 		if err != nil {
@@ -239,7 +239,7 @@ func (e executor) plan(ctx, killCtx context.Context, env, vars []string, logr lo
 	if err != nil {
 		return nil, xerrors.Errorf("terraform plan: %w", err)
 	}
-	resources, err := e.planResources(ctx, killCtx, planfilePath)
+	resources, parameters, err := e.planResources(ctx, killCtx, planfilePath)
 	if err != nil {
 		return nil, err
 	}
@@ -250,24 +250,37 @@ func (e executor) plan(ctx, killCtx context.Context, env, vars []string, logr lo
 	return &proto.Provision_Response{
 		Type: &proto.Provision_Response_Complete{
 			Complete: &proto.Provision_Complete{
-				Resources: resources,
-				Plan:      planFileByt,
+				Resources:  resources,
+				Parameters: parameters,
+				Plan:       planFileByt,
 			},
 		},
 	}, nil
 }
 
-func (e executor) planResources(ctx, killCtx context.Context, planfilePath string) ([]*proto.Resource, error) {
+func (e executor) planResources(ctx, killCtx context.Context, planfilePath string) ([]*proto.Resource, []*proto.RichParameter, error) {
 	plan, err := e.showPlan(ctx, killCtx, planfilePath)
 	if err != nil {
-		return nil, xerrors.Errorf("show terraform plan file: %w", err)
+		return nil, nil, xerrors.Errorf("show terraform plan file: %w", err)
 	}
 
 	rawGraph, err := e.graph(ctx, killCtx)
 	if err != nil {
-		return nil, xerrors.Errorf("graph: %w", err)
+		return nil, nil, xerrors.Errorf("graph: %w", err)
+	}
+	resources, err := ConvertResources(plan.PlannedValues.RootModule, rawGraph)
+	if err != nil {
+		return nil, nil, err
+	}
+	modules := []*tfjson.StateModule{plan.PlannedValues.RootModule}
+	if plan.PriorState != nil && plan.PriorState.Values != nil {
+		modules = append(modules, plan.PriorState.Values.RootModule)
+	}
+	parameters, err := ConvertParameters(modules...)
+	if err != nil {
+		return nil, nil, xerrors.Errorf("convert parameters: %w", err)
 	}
-	return ConvertResources(plan.PlannedValues.RootModule, rawGraph)
+	return resources, parameters, nil
 }
 
 func (e executor) showPlan(ctx, killCtx context.Context, planfilePath string) (*tfjson.Plan, error) {
@@ -2,6 +2,8 @@ package terraform
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -138,7 +140,7 @@ func (s *server) Provision(stream proto.DRPCProvisioner_ProvisionStream) error {
 	}
 	s.logger.Debug(ctx, "ran initialization")
 
-	env, err := provisionEnv(config, request.GetPlan().GetParameterValues())
+	env, err := provisionEnv(config, request.GetPlan().GetParameterValues(), request.GetPlan().GetRichParameterValues())
 	if err != nil {
 		return err
 	}
@@ -204,7 +206,7 @@ func planVars(plan *proto.Provision_Plan) ([]string, error) {
 	return vars, nil
 }
 
-func provisionEnv(config *proto.Provision_Config, params []*proto.ParameterValue) ([]string, error) {
+func provisionEnv(config *proto.Provision_Config, params []*proto.ParameterValue, richParams []*proto.RichParameterValue) ([]string, error) {
 	env := safeEnviron()
 	env = append(env,
 		"CODER_AGENT_URL="+config.Metadata.CoderUrl,
@@ -228,9 +230,19 @@ func provisionEnv(config *proto.Provision_Config, params []*proto.ParameterValue
 			return nil, xerrors.Errorf("unsupported parameter type %q for %q", param.DestinationScheme, param.Name)
 		}
 	}
+	for _, param := range richParams {
+		env = append(env, richParameterEnvironmentVariable(param.Name)+"="+param.Value)
+	}
 	return env, nil
 }
 
+// richParameterEnvironmentVariable returns the environment variable the
+// "coder_parameter" data source reads the value of a parameter from.
+func richParameterEnvironmentVariable(name string) string {
+	hash := sha256.Sum256([]byte(name))
+	return "CODER_PARAMETER_" + hex.EncodeToString(hash[:])
+}
+
 var (
 	// tfEnvSafeToPrint is the set of terraform environment variables that we are quite sure won't contain secrets,
 	// and therefore it's ok to log their values
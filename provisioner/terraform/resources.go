@@ -24,6 +24,19 @@ type agentAttributes struct {
 	StartupScript            string            `mapstructure:"startup_script"`
 	ConnectionTimeoutSeconds int32             `mapstructure:"connection_timeout"`
 	TroubleshootingURL       string            `mapstructure:"troubleshooting_url"`
+	StartupScriptTimeout     int32             `mapstructure:"startup_script_timeout"`
+	ShutdownScript           string            `mapstructure:"shutdown_script"`
+	ShutdownScriptTimeout    int32             `mapstructure:"shutdown_script_timeout"`
+	Metadata                 []agentMetadata   `mapstructure:"metadata"`
+}
+
+// A mapping of attributes on the "metadata" block of a "coder_agent".
+type agentMetadata struct {
+	Key         string `mapstructure:"key"`
+	DisplayName string `mapstructure:"display_name"`
+	Script      string `mapstructure:"script"`
+	Interval    int64  `mapstructure:"interval"`
+	Timeout     int64  `mapstructure:"timeout"`
 }
 
 // A mapping of attributes on the "coder_app" resource.
@@ -66,6 +79,109 @@ type metadataItem struct {
 	IsNull    bool   `mapstructure:"is_null"`
 }
 
+// A mapping of attributes on the "coder_parameter" data source.
+type parameterAttributes struct {
+	Name        string                `mapstructure:"name"`
+	Description string                `mapstructure:"description"`
+	Type        string                `mapstructure:"type"`
+	Mutable     bool                  `mapstructure:"mutable"`
+	Default     string                `mapstructure:"default"`
+	Icon        string                `mapstructure:"icon"`
+	Options     []parameterOption     `mapstructure:"option"`
+	Validation  []parameterValidation `mapstructure:"validation"`
+}
+
+// A mapping of attributes on the "option" block of a "coder_parameter".
+type parameterOption struct {
+	Name        string `mapstructure:"name"`
+	Description string `mapstructure:"description"`
+	Value       string `mapstructure:"value"`
+	Icon        string `mapstructure:"icon"`
+}
+
+// A mapping of attributes on the "validation" block of a "coder_parameter".
+type parameterValidation struct {
+	Regex string `mapstructure:"regex"`
+	Min   *int32 `mapstructure:"min"`
+	Max   *int32 `mapstructure:"max"`
+	Error string `mapstructure:"error"`
+}
+
+// ConvertParameters returns the rich parameters declared by "coder_parameter"
+// data sources in the modules. Data sources are read during planning, so
+// they may be in the prior state or the planned values of a plan. Parameters
+// are returned in the order they are found, and duplicate addresses are
+// ignored.
+func ConvertParameters(modules ...*tfjson.StateModule) ([]*proto.RichParameter, error) {
+	parameters := make([]*proto.RichParameter, 0)
+	seenAddresses := map[string]struct{}{}
+	seenNames := map[string]struct{}{}
+	var findParameters func(mod *tfjson.StateModule) error
+	findParameters = func(mod *tfjson.StateModule) error {
+		for _, resource := range mod.Resources {
+			if resource.Mode != tfjson.DataResourceMode || resource.Type != "coder_parameter" {
+				continue
+			}
+			if _, ok := seenAddresses[resource.Address]; ok {
+				continue
+			}
+			seenAddresses[resource.Address] = struct{}{}
+
+			var attrs parameterAttributes
+			err := mapstructure.Decode(resource.AttributeValues, &attrs)
+			if err != nil {
+				return xerrors.Errorf("decode parameter attributes: %w", err)
+			}
+			if _, ok := seenNames[attrs.Name]; ok {
+				return xerrors.Errorf("duplicate parameter name %q", attrs.Name)
+			}
+			seenNames[attrs.Name] = struct{}{}
+
+			parameter := &proto.RichParameter{
+				Name:         attrs.Name,
+				Description:  attrs.Description,
+				Type:         attrs.Type,
+				Mutable:      attrs.Mutable,
+				DefaultValue: attrs.Default,
+				Icon:         attrs.Icon,
+			}
+			for _, option := range attrs.Options {
+				parameter.Options = append(parameter.Options, &proto.RichParameterOption{
+					Name:        option.Name,
+					Description: option.Description,
+					Value:       option.Value,
+					Icon:        option.Icon,
+				})
+			}
+			if len(attrs.Validation) > 0 {
+				validation := attrs.Validation[0]
+				parameter.ValidationRegex = validation.Regex
+				parameter.ValidationMin = validation.Min
+				parameter.ValidationMax = validation.Max
+				parameter.ValidationError = validation.Error
+			}
+			parameters = append(parameters, parameter)
+		}
+		for _, child := range mod.ChildModules {
+			err := findParameters(child)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, module := range modules {
+		if module == nil {
+			continue
+		}
+		err := findParameters(module)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return parameters, nil
+}
+
 // ConvertResources consumes Terraform state and a GraphViz representation produced by
 // `terraform graph` to produce resources consumable by Coder.
 // nolint:gocyclo
@@ -121,15 +237,27 @@ func ConvertResources(module *tfjson.StateModule, rawGraph string) ([]*proto.Res
 			return nil, xerrors.Errorf("decode agent attributes: %w", err)
 		}
 		agent := &proto.Agent{
-			Name:                     tfResource.Name,
-			Id:                       attrs.ID,
-			Env:                      attrs.Env,
-			StartupScript:            attrs.StartupScript,
-			OperatingSystem:          attrs.OperatingSystem,
-			Architecture:             attrs.Architecture,
-			Directory:                attrs.Directory,
-			ConnectionTimeoutSeconds: attrs.ConnectionTimeoutSeconds,
-			TroubleshootingUrl:       attrs.TroubleshootingURL,
+			Name:                         tfResource.Name,
+			Id:                           attrs.ID,
+			Env:                          attrs.Env,
+			StartupScript:                attrs.StartupScript,
+			OperatingSystem:              attrs.OperatingSystem,
+			Architecture:                 attrs.Architecture,
+			Directory:                    attrs.Directory,
+			ConnectionTimeoutSeconds:     attrs.ConnectionTimeoutSeconds,
+			TroubleshootingUrl:           attrs.TroubleshootingURL,
+			StartupScriptTimeoutSeconds:  attrs.StartupScriptTimeout,
+			ShutdownScript:               attrs.ShutdownScript,
+			ShutdownScriptTimeoutSeconds: attrs.ShutdownScriptTimeout,
+		}
+		for _, item := range attrs.Metadata {
+			agent.Metadata = append(agent.Metadata, &proto.Agent_Metadata{
+				Key:         item.Key,
+				DisplayName: item.DisplayName,
+				Script:      item.Script,
+				Interval:    item.Interval,
+				Timeout:     item.Timeout,
+			})
 		}
 		switch attrs.Auth {
 		case "token":
@@ -438,3 +438,138 @@ func sortResources(resources []*proto.Resource) {
 		})
 	}
 }
+
+func TestAgentMetadata(t *testing.T) {
+	t.Parallel()
+	resources, err := terraform.ConvertResources(&tfjson.StateModule{
+		Resources: []*tfjson.StateResource{{
+			Address: "coder_agent.dev",
+			Type:    "coder_agent",
+			Name:    "dev",
+			Mode:    tfjson.ManagedResourceMode,
+			AttributeValues: map[string]interface{}{
+				"arch": "amd64",
+				"auth": "token",
+				"metadata": []interface{}{
+					map[string]interface{}{
+						"key":          "load",
+						"display_name": "CPU Load",
+						"script":       "cat /proc/loadavg",
+						"interval":     5,
+						"timeout":      1,
+					},
+					map[string]interface{}{
+						"key":          "branch",
+						"display_name": "Git Branch",
+						"script":       "git branch --show-current",
+						"interval":     10,
+						"timeout":      2,
+					},
+				},
+			},
+		}, {
+			Address:   "null_resource.dev",
+			Type:      "null_resource",
+			Name:      "dev",
+			Mode:      tfjson.ManagedResourceMode,
+			DependsOn: []string{"coder_agent.dev"},
+		}},
+		// This is manually created to join the edges.
+	}, `digraph {
+	compound = "true"
+	newrank = "true"
+	subgraph "root" {
+		"[root] coder_agent.dev" [label = "coder_agent.dev", shape = "box"]
+		"[root] null_resource.dev" [label = "null_resource.dev", shape = "box"]
+		"[root] null_resource.dev" -> "[root] coder_agent.dev"
+	}
+}
+`)
+	require.NoError(t, err)
+	require.Len(t, resources, 1)
+	require.Len(t, resources[0].Agents, 1)
+	metadata := resources[0].Agents[0].GetMetadata()
+	require.Len(t, metadata, 2)
+	require.Equal(t, "load", metadata[0].Key)
+	require.Equal(t, "CPU Load", metadata[0].DisplayName)
+	require.Equal(t, "cat /proc/loadavg", metadata[0].Script)
+	require.EqualValues(t, 5, metadata[0].Interval)
+	require.EqualValues(t, 1, metadata[0].Timeout)
+	require.Equal(t, "branch", metadata[1].Key)
+	require.EqualValues(t, 10, metadata[1].Interval)
+}
+
+func TestConvertParameters(t *testing.T) {
+	t.Parallel()
+	region := &tfjson.StateResource{
+		Address: "data.coder_parameter.region",
+		Type:    "coder_parameter",
+		Name:    "region",
+		Mode:    tfjson.DataResourceMode,
+		AttributeValues: map[string]interface{}{
+			"name":        "Region",
+			"description": "Where to deploy the workspace.",
+			"type":        "string",
+			"default":     "us",
+			"icon":        "/emojis/1f30e.png",
+			"option": []interface{}{
+				map[string]interface{}{
+					"name":  "United States",
+					"value": "us",
+					"icon":  "/emojis/1f1fa-1f1f8.png",
+				},
+				map[string]interface{}{
+					"name":  "Europe",
+					"value": "eu",
+				},
+			},
+		},
+	}
+	parameters, err := terraform.ConvertParameters(&tfjson.StateModule{
+		Resources: []*tfjson.StateResource{region, {
+			Address: "null_resource.dev",
+			Type:    "null_resource",
+			Name:    "dev",
+			Mode:    tfjson.ManagedResourceMode,
+		}},
+	}, &tfjson.StateModule{
+		// Data sources may be in the prior state and the planned values.
+		Resources: []*tfjson.StateResource{region},
+		ChildModules: []*tfjson.StateModule{{
+			Resources: []*tfjson.StateResource{{
+				Address: "module.disk.data.coder_parameter.disk_size",
+				Type:    "coder_parameter",
+				Name:    "disk_size",
+				Mode:    tfjson.DataResourceMode,
+				AttributeValues: map[string]interface{}{
+					"name":    "Disk Size",
+					"type":    "number",
+					"mutable": true,
+					"default": "10",
+					"validation": []interface{}{
+						map[string]interface{}{
+							"min": float64(5),
+							"max": float64(50),
+						},
+					},
+				},
+			}},
+		}},
+	})
+	require.NoError(t, err)
+	require.Len(t, parameters, 2)
+
+	require.Equal(t, "Region", parameters[0].Name)
+	require.Equal(t, "string", parameters[0].Type)
+	require.Equal(t, "us", parameters[0].DefaultValue)
+	require.False(t, parameters[0].Mutable)
+	require.Len(t, parameters[0].Options, 2)
+	require.Equal(t, "/emojis/1f1fa-1f1f8.png", parameters[0].Options[0].Icon)
+	require.Equal(t, "eu", parameters[0].Options[1].Value)
+	require.Nil(t, parameters[0].ValidationMin)
+
+	require.Equal(t, "Disk Size", parameters[1].Name)
+	require.True(t, parameters[1].Mutable)
+	require.EqualValues(t, 5, parameters[1].GetValidationMin())
+	require.EqualValues(t, 50, parameters[1].GetValidationMax())
+}
@@ -40,6 +40,7 @@ type DRPCProvisionerDaemonClient interface {
 
 	AcquireJob(ctx context.Context, in *Empty) (*AcquiredJob, error)
 	CommitQuota(ctx context.Context, in *CommitQuotaRequest) (*CommitQuotaResponse, error)
+	CheckAgentsShutdown(ctx context.Context, in *CheckAgentsShutdownRequest) (*CheckAgentsShutdownResponse, error)
 	UpdateJob(ctx context.Context, in *UpdateJobRequest) (*UpdateJobResponse, error)
 	FailJob(ctx context.Context, in *FailedJob) (*Empty, error)
 	CompleteJob(ctx context.Context, in *CompletedJob) (*Empty, error)
@@ -73,6 +74,15 @@ func (c *drpcProvisionerDaemonClient) CommitQuota(ctx context.Context, in *Commi
 	return out, nil
 }
 
+func (c *drpcProvisionerDaemonClient) CheckAgentsShutdown(ctx context.Context, in *CheckAgentsShutdownRequest) (*CheckAgentsShutdownResponse, error) {
+	out := new(CheckAgentsShutdownResponse)
+	err := c.cc.Invoke(ctx, "/provisionerd.ProvisionerDaemon/CheckAgentsShutdown", drpcEncoding_File_provisionerd_proto_provisionerd_proto{}, in, out)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *drpcProvisionerDaemonClient) UpdateJob(ctx context.Context, in *UpdateJobRequest) (*UpdateJobResponse, error) {
 	out := new(UpdateJobResponse)
 	err := c.cc.Invoke(ctx, "/provisionerd.ProvisionerDaemon/UpdateJob", drpcEncoding_File_provisionerd_proto_provisionerd_proto{}, in, out)
@@ -103,6 +113,7 @@ func (c *drpcProvisionerDaemonClient) CompleteJob(ctx context.Context, in *Compl
 type DRPCProvisionerDaemonServer interface {
 	AcquireJob(context.Context, *Empty) (*AcquiredJob, error)
 	CommitQuota(context.Context, *CommitQuotaRequest) (*CommitQuotaResponse, error)
+	CheckAgentsShutdown(context.Context, *CheckAgentsShutdownRequest) (*CheckAgentsShutdownResponse, error)
 	UpdateJob(context.Context, *UpdateJobRequest) (*UpdateJobResponse, error)
 	FailJob(context.Context, *FailedJob) (*Empty, error)
 	CompleteJob(context.Context, *CompletedJob) (*Empty, error)
@@ -118,6 +129,10 @@ func (s *DRPCProvisionerDaemonUnimplementedServer) CommitQuota(context.Context,
 	return nil, drpcerr.WithCode(errors.New("Unimplemented"), drpcerr.Unimplemented)
 }
 
+func (s *DRPCProvisionerDaemonUnimplementedServer) CheckAgentsShutdown(context.Context, *CheckAgentsShutdownRequest) (*CheckAgentsShutdownResponse, error) {
+	return nil, drpcerr.WithCode(errors.New("Unimplemented"), drpcerr.Unimplemented)
+}
+
 func (s *DRPCProvisionerDaemonUnimplementedServer) UpdateJob(context.Context, *UpdateJobRequest) (*UpdateJobResponse, error) {
 	return nil, drpcerr.WithCode(errors.New("Unimplemented"), drpcerr.Unimplemented)
 }
@@ -132,7 +147,7 @@ func (s *DRPCProvisionerDaemonUnimplementedServer) CompleteJob(context.Context,
 
 type DRPCProvisionerDaemonDescription struct{}
 
-func (DRPCProvisionerDaemonDescription) NumMethods() int { return 5 }
+func (DRPCProvisionerDaemonDescription) NumMethods() int { return 6 }
 
 func (DRPCProvisionerDaemonDescription) Method(n int) (string, drpc.Encoding, drpc.Receiver, interface{}, bool) {
 	switch n {
@@ -155,6 +170,15 @@ func (DRPCProvisionerDaemonDescription) Method(n int) (string, drpc.Encoding, dr
 					)
 			}, DRPCProvisionerDaemonServer.CommitQuota, true
 	case 2:
+		return "/provisionerd.ProvisionerDaemon/CheckAgentsShutdown", drpcEncoding_File_provisionerd_proto_provisionerd_proto{},
+			func(srv interface{}, ctx context.Context, in1, in2 interface{}) (drpc.Message, error) {
+				return srv.(DRPCProvisionerDaemonServer).
+					CheckAgentsShutdown(
+						ctx,
+						in1.(*CheckAgentsShutdownRequest),
+					)
+			}, DRPCProvisionerDaemonServer.CheckAgentsShutdown, true
+	case 3:
 		return "/provisionerd.ProvisionerDaemon/UpdateJob", drpcEncoding_File_provisionerd_proto_provisionerd_proto{},
 			func(srv interface{}, ctx context.Context, in1, in2 interface{}) (drpc.Message, error) {
 				return srv.(DRPCProvisionerDaemonServer).
@@ -163,7 +187,7 @@ func (DRPCProvisionerDaemonDescription) Method(n int) (string, drpc.Encoding, dr
 						in1.(*UpdateJobRequest),
 					)
 			}, DRPCProvisionerDaemonServer.UpdateJob, true
-	case 3:
+	case 4:
 		return "/provisionerd.ProvisionerDaemon/FailJob", drpcEncoding_File_provisionerd_proto_provisionerd_proto{},
 			func(srv interface{}, ctx context.Context, in1, in2 interface{}) (drpc.Message, error) {
 				return srv.(DRPCProvisionerDaemonServer).
@@ -172,7 +196,7 @@ func (DRPCProvisionerDaemonDescription) Method(n int) (string, drpc.Encoding, dr
 						in1.(*FailedJob),
 					)
 			}, DRPCProvisionerDaemonServer.FailJob, true
-	case 4:
+	case 5:
 		return "/provisionerd.ProvisionerDaemon/CompleteJob", drpcEncoding_File_provisionerd_proto_provisionerd_proto{},
 			func(srv interface{}, ctx context.Context, in1, in2 interface{}) (drpc.Message, error) {
 				return srv.(DRPCProvisionerDaemonServer).
@@ -222,6 +246,22 @@ func (x *drpcProvisionerDaemon_CommitQuotaStream) SendAndClose(m *CommitQuotaRes
 	return x.CloseSend()
 }
 
+type DRPCProvisionerDaemon_CheckAgentsShutdownStream interface {
+	drpc.Stream
+	SendAndClose(*CheckAgentsShutdownResponse) error
+}
+
+type drpcProvisionerDaemon_CheckAgentsShutdownStream struct {
+	drpc.Stream
+}
+
+func (x *drpcProvisionerDaemon_CheckAgentsShutdownStream) SendAndClose(m *CheckAgentsShutdownResponse) error {
+	if err := x.MsgSend(m, drpcEncoding_File_provisionerd_proto_provisionerd_proto{}); err != nil {
+		return err
+	}
+	return x.CloseSend()
+}
+
 type DRPCProvisionerDaemon_UpdateJobStream interface {
 	drpc.Stream
 	SendAndClose(*UpdateJobResponse) error
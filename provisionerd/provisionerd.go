@@ -326,17 +326,18 @@ func (p *Server) acquireJob(ctx context.Context) {
 		ctx,
 		job,
 		runner.Options{
-			Updater:             p,
-			QuotaCommitter:      p,
-			Logger:              p.opts.Logger,
-			Filesystem:          p.opts.Filesystem,
-			WorkDirectory:       p.opts.WorkDirectory,
-			Provisioner:         provisioner,
-			UpdateInterval:      p.opts.UpdateInterval,
-			ForceCancelInterval: p.opts.ForceCancelInterval,
-			LogDebounceInterval: p.opts.LogBufferInterval,
-			Tracer:              p.tracer,
-			Metrics:             p.opts.Metrics.Runner,
+			Updater:               p,
+			QuotaCommitter:        p,
+			AgentsShutdownChecker: p,
+			Logger:                p.opts.Logger,
+			Filesystem:            p.opts.Filesystem,
+			WorkDirectory:         p.opts.WorkDirectory,
+			Provisioner:           provisioner,
+			UpdateInterval:        p.opts.UpdateInterval,
+			ForceCancelInterval:   p.opts.ForceCancelInterval,
+			LogDebounceInterval:   p.opts.LogBufferInterval,
+			Tracer:                p.tracer,
+			Metrics:               p.opts.Metrics.Runner,
 		},
 	)
 
@@ -380,6 +381,17 @@ func (p *Server) CommitQuota(ctx context.Context, in *proto.CommitQuotaRequest)
 	return out.(*proto.CommitQuotaResponse), nil
 }
 
+func (p *Server) CheckAgentsShutdown(ctx context.Context, in *proto.CheckAgentsShutdownRequest) (*proto.CheckAgentsShutdownResponse, error) {
+	out, err := p.clientDoWithRetries(ctx, func(ctx context.Context, client proto.DRPCProvisionerDaemonClient) (any, error) {
+		return client.CheckAgentsShutdown(ctx, in)
+	})
+	if err != nil {
+		return nil, err
+	}
+	// nolint: forcetypeassert
+	return out.(*proto.CheckAgentsShutdownResponse), nil
+}
+
 func (p *Server) UpdateJob(ctx context.Context, in *proto.UpdateJobRequest) (*proto.UpdateJobResponse, error) {
 	out, err := p.clientDoWithRetries(ctx, func(ctx context.Context, client proto.DRPCProvisionerDaemonClient) (any, error) {
 		return client.UpdateJob(ctx, in)
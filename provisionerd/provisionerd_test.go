@@ -481,6 +481,82 @@ func TestProvisionerd(t *testing.T) {
 		require.NoError(t, closer.Close())
 	})
 
+	t.Run("WorkspaceBuildAwaitsAgentsShutdown", func(t *testing.T) {
+		t.Parallel()
+		var (
+			didComplete   atomic.Bool
+			didAcquireJob atomic.Bool
+			checks        atomic.Int64
+			completeChan  = make(chan struct{})
+			completeOnce  sync.Once
+			logsMutex     sync.Mutex
+			stages        = map[string]bool{}
+		)
+
+		closer := createProvisionerd(t, func(ctx context.Context) (proto.DRPCProvisionerDaemonClient, error) {
+			return createProvisionerDaemonClient(t, provisionerDaemonTestServer{
+				acquireJob: func(ctx context.Context, _ *proto.Empty) (*proto.AcquiredJob, error) {
+					if !didAcquireJob.CAS(false, true) {
+						completeOnce.Do(func() { close(completeChan) })
+						return &proto.AcquiredJob{}, nil
+					}
+
+					return &proto.AcquiredJob{
+						JobId:       "test",
+						Provisioner: "someprovisioner",
+						TemplateSourceArchive: createTar(t, map[string]string{
+							"test.txt": "content",
+						}),
+						Type: &proto.AcquiredJob_WorkspaceBuild_{
+							WorkspaceBuild: &proto.AcquiredJob_WorkspaceBuild{
+								Metadata: &sdkproto.Provision_Metadata{
+									WorkspaceTransition: sdkproto.WorkspaceTransition_STOP,
+								},
+							},
+						},
+					}, nil
+				},
+				checkAgentsShutdown: func(ctx context.Context, req *proto.CheckAgentsShutdownRequest) (*proto.CheckAgentsShutdownResponse, error) {
+					// The agents finish shutting down on the second check.
+					return &proto.CheckAgentsShutdownResponse{
+						Pending:        checks.Inc() == 1,
+						TimeoutSeconds: 60,
+					}, nil
+				},
+				updateJob: func(ctx context.Context, update *proto.UpdateJobRequest) (*proto.UpdateJobResponse, error) {
+					logsMutex.Lock()
+					defer logsMutex.Unlock()
+					for _, log := range update.Logs {
+						stages[log.Stage] = true
+					}
+					return &proto.UpdateJobResponse{}, nil
+				},
+				completeJob: func(ctx context.Context, job *proto.CompletedJob) (*proto.Empty, error) {
+					didComplete.Store(true)
+					return &proto.Empty{}, nil
+				},
+			}), nil
+		}, provisionerd.Provisioners{
+			"someprovisioner": createProvisionerClient(t, provisionerTestServer{
+				provision: func(stream sdkproto.DRPCProvisioner_ProvisionStream) error {
+					// Provisioning only starts once the agents shut down.
+					assert.EqualValues(t, 2, checks.Load())
+					return stream.Send(&sdkproto.Provision_Response{
+						Type: &sdkproto.Provision_Response_Complete{
+							Complete: &sdkproto.Provision_Complete{},
+						},
+					})
+				},
+			}),
+		})
+		require.Condition(t, closedWithin(completeChan, testutil.WaitShort))
+		require.True(t, didComplete.Load())
+		require.NoError(t, closer.Close())
+		logsMutex.Lock()
+		defer logsMutex.Unlock()
+		require.True(t, stages["Waiting for agents to shut down"])
+	})
+
 	t.Run("WorkspaceBuildQuotaExceeded", func(t *testing.T) {
 		t.Parallel()
 		var (
@@ -1131,9 +1207,11 @@ func (p *provisionerTestServer) Provision(stream sdkproto.DRPCProvisioner_Provis
 type provisionerDaemonTestServer struct {
 	acquireJob  func(ctx context.Context, _ *proto.Empty) (*proto.AcquiredJob, error)
 	commitQuota func(ctx context.Context, com *proto.CommitQuotaRequest) (*proto.CommitQuotaResponse, error)
-	updateJob   func(ctx context.Context, update *proto.UpdateJobRequest) (*proto.UpdateJobResponse, error)
-	failJob     func(ctx context.Context, job *proto.FailedJob) (*proto.Empty, error)
-	completeJob func(ctx context.Context, job *proto.CompletedJob) (*proto.Empty, error)
+	// checkAgentsShutdown defaults to reporting no pending agents.
+	checkAgentsShutdown func(ctx context.Context, req *proto.CheckAgentsShutdownRequest) (*proto.CheckAgentsShutdownResponse, error)
+	updateJob           func(ctx context.Context, update *proto.UpdateJobRequest) (*proto.UpdateJobResponse, error)
+	failJob             func(ctx context.Context, job *proto.FailedJob) (*proto.Empty, error)
+	completeJob         func(ctx context.Context, job *proto.CompletedJob) (*proto.Empty, error)
 }
 
 func (p *provisionerDaemonTestServer) AcquireJob(ctx context.Context, empty *proto.Empty) (*proto.AcquiredJob, error) {
@@ -1148,6 +1226,13 @@ func (p *provisionerDaemonTestServer) CommitQuota(ctx context.Context, com *prot
 	return p.commitQuota(ctx, com)
 }
 
+func (p *provisionerDaemonTestServer) CheckAgentsShutdown(ctx context.Context, req *proto.CheckAgentsShutdownRequest) (*proto.CheckAgentsShutdownResponse, error) {
+	if p.checkAgentsShutdown == nil {
+		return &proto.CheckAgentsShutdownResponse{}, nil
+	}
+	return p.checkAgentsShutdown(ctx, req)
+}
+
 func (p *provisionerDaemonTestServer) UpdateJob(ctx context.Context, update *proto.UpdateJobRequest) (*proto.UpdateJobResponse, error) {
 	return p.updateJob(ctx, update)
 }
@@ -31,6 +31,10 @@ import (
 
 const (
 	MissingParameterErrorText = "missing parameter"
+
+	// agentsShutdownPollInterval is how often stop builds check whether
+	// the workspace agents finished their shutdown scripts.
+	agentsShutdownPollInterval = time.Second
 )
 
 var (
@@ -43,6 +47,7 @@ type Runner struct {
 	job                 *proto.AcquiredJob
 	sender              JobUpdater
 	quotaCommitter      QuotaCommitter
+	shutdownChecker     AgentsShutdownChecker
 	logger              slog.Logger
 	filesystem          afero.Fs
 	workDirectory       string
@@ -89,19 +94,25 @@ type JobUpdater interface {
 type QuotaCommitter interface {
 	CommitQuota(ctx context.Context, in *proto.CommitQuotaRequest) (*proto.CommitQuotaResponse, error)
 }
+type AgentsShutdownChecker interface {
+	CheckAgentsShutdown(ctx context.Context, in *proto.CheckAgentsShutdownRequest) (*proto.CheckAgentsShutdownResponse, error)
+}
 
 type Options struct {
-	Updater             JobUpdater
-	QuotaCommitter      QuotaCommitter
-	Logger              slog.Logger
-	Filesystem          afero.Fs
-	WorkDirectory       string
-	Provisioner         sdkproto.DRPCProvisionerClient
-	UpdateInterval      time.Duration
-	ForceCancelInterval time.Duration
-	LogDebounceInterval time.Duration
-	Tracer              trace.Tracer
-	Metrics             Metrics
+	Updater        JobUpdater
+	QuotaCommitter QuotaCommitter
+	// AgentsShutdownChecker is optional. Stop and delete builds wait for
+	// the workspace agents to run their shutdown scripts when it's set.
+	AgentsShutdownChecker AgentsShutdownChecker
+	Logger                slog.Logger
+	Filesystem            afero.Fs
+	WorkDirectory         string
+	Provisioner           sdkproto.DRPCProvisionerClient
+	UpdateInterval        time.Duration
+	ForceCancelInterval   time.Duration
+	LogDebounceInterval   time.Duration
+	Tracer                trace.Tracer
+	Metrics               Metrics
 }
 
 func New(
@@ -121,6 +132,7 @@ func New(
 		job:                 job,
 		sender:              opts.Updater,
 		quotaCommitter:      opts.QuotaCommitter,
+		shutdownChecker:     opts.AgentsShutdownChecker,
 		logger:              opts.Logger.With(slog.F("job_id", job.JobId)),
 		filesystem:          opts.Filesystem,
 		workDirectory:       opts.WorkDirectory,
@@ -566,7 +578,7 @@ func (r *Runner) runTemplateImport(ctx context.Context) (*proto.CompletedJob, *p
 		Stage:     "Detecting persistent resources",
 		CreatedAt: time.Now().UnixMilli(),
 	})
-	startResources, err := r.runTemplateImportProvision(ctx, updateResponse.ParameterValues, &sdkproto.Provision_Metadata{
+	startResources, richParameters, err := r.runTemplateImportProvision(ctx, updateResponse.ParameterValues, nil, &sdkproto.Provision_Metadata{
 		CoderUrl:            r.job.GetTemplateImport().Metadata.CoderUrl,
 		WorkspaceTransition: sdkproto.WorkspaceTransition_START,
 	})
@@ -581,7 +593,7 @@ func (r *Runner) runTemplateImport(ctx context.Context) (*proto.CompletedJob, *p
 		Stage:     "Detecting ephemeral resources",
 		CreatedAt: time.Now().UnixMilli(),
 	})
-	stopResources, err := r.runTemplateImportProvision(ctx, updateResponse.ParameterValues, &sdkproto.Provision_Metadata{
+	stopResources, _, err := r.runTemplateImportProvision(ctx, updateResponse.ParameterValues, nil, &sdkproto.Provision_Metadata{
 		CoderUrl:            r.job.GetTemplateImport().Metadata.CoderUrl,
 		WorkspaceTransition: sdkproto.WorkspaceTransition_STOP,
 	})
@@ -595,6 +607,7 @@ func (r *Runner) runTemplateImport(ctx context.Context) (*proto.CompletedJob, *p
 			TemplateImport: &proto.CompletedJob_TemplateImport{
 				StartResources: startResources,
 				StopResources:  stopResources,
+				RichParameters: richParameters,
 			},
 		},
 	}, nil
@@ -645,8 +658,9 @@ func (r *Runner) runTemplateImportParse(ctx context.Context) ([]*sdkproto.Parame
 
 // Performs a dry-run provision when importing a template.
 // This is used to detect resources that would be provisioned
-// for a workspace in various states.
-func (r *Runner) runTemplateImportProvision(ctx context.Context, values []*sdkproto.ParameterValue, metadata *sdkproto.Provision_Metadata) ([]*sdkproto.Resource, error) {
+// for a workspace in various states. The rich parameters declared
+// by the template are returned alongside the resources.
+func (r *Runner) runTemplateImportProvision(ctx context.Context, values []*sdkproto.ParameterValue, richValues []*sdkproto.RichParameterValue, metadata *sdkproto.Provision_Metadata) ([]*sdkproto.Resource, []*sdkproto.RichParameter, error) {
 	ctx, span := r.startTrace(ctx, tracing.FuncName())
 	defer span.End()
 
@@ -661,7 +675,7 @@ func (r *Runner) runTemplateImportProvision(ctx context.Context, values []*sdkpr
 	// to send the cancel to the provisioner
 	stream, err := r.provisioner.Provision(ctx)
 	if err != nil {
-		return nil, xerrors.Errorf("provision: %w", err)
+		return nil, nil, xerrors.Errorf("provision: %w", err)
 	}
 	defer stream.Close()
 	go func() {
@@ -683,18 +697,19 @@ func (r *Runner) runTemplateImportProvision(ctx context.Context, values []*sdkpr
 					Directory: r.workDirectory,
 					Metadata:  metadata,
 				},
-				ParameterValues: values,
+				ParameterValues:     values,
+				RichParameterValues: richValues,
 			},
 		},
 	})
 	if err != nil {
-		return nil, xerrors.Errorf("start provision: %w", err)
+		return nil, nil, xerrors.Errorf("start provision: %w", err)
 	}
 
 	for {
 		msg, err := stream.Recv()
 		if err != nil {
-			return nil, xerrors.Errorf("recv import provision: %w", err)
+			return nil, nil, xerrors.Errorf("recv import provision: %w", err)
 		}
 		switch msgType := msg.Type.(type) {
 		case *sdkproto.Provision_Response_Log:
@@ -715,18 +730,19 @@ func (r *Runner) runTemplateImportProvision(ctx context.Context, values []*sdkpr
 					slog.F("error", msgType.Complete.Error),
 				)
 
-				return nil, xerrors.New(msgType.Complete.Error)
+				return nil, nil, xerrors.New(msgType.Complete.Error)
 			}
 
 			r.logger.Info(context.Background(), "parse dry-run provision successful",
 				slog.F("resource_count", len(msgType.Complete.Resources)),
 				slog.F("resources", msgType.Complete.Resources),
+				slog.F("rich_parameter_count", len(msgType.Complete.Parameters)),
 				slog.F("state_length", len(msgType.Complete.State)),
 			)
 
-			return msgType.Complete.Resources, nil
+			return msgType.Complete.Resources, msgType.Complete.Parameters, nil
 		default:
-			return nil, xerrors.Errorf("invalid message type %q received from provisioner",
+			return nil, nil, xerrors.Errorf("invalid message type %q received from provisioner",
 				reflect.TypeOf(msg.Type).String())
 		}
 	}
@@ -765,8 +781,9 @@ func (r *Runner) runTemplateDryRun(ctx context.Context) (*proto.CompletedJob, *p
 	}
 
 	// Run the template import provision task since it's already a dry run.
-	resources, err := r.runTemplateImportProvision(ctx,
+	resources, _, err := r.runTemplateImportProvision(ctx,
 		r.job.GetTemplateDryRun().GetParameterValues(),
+		r.job.GetTemplateDryRun().GetRichParameterValues(),
 		metadata,
 	)
 	if err != nil {
@@ -930,6 +947,11 @@ func (r *Runner) runWorkspaceBuild(ctx context.Context) (*proto.CompletedJob, *p
 		applyStage = "Destroying workspace"
 	}
 
+	if r.job.GetWorkspaceBuild().Metadata.WorkspaceTransition != sdkproto.WorkspaceTransition_START {
+		r.awaitAgentsShutdown(ctx)
+		r.flushQueuedLogs(ctx)
+	}
+
 	config := &sdkproto.Provision_Config{
 		Directory: r.workDirectory,
 		Metadata:  r.job.GetWorkspaceBuild().Metadata,
@@ -939,8 +961,9 @@ func (r *Runner) runWorkspaceBuild(ctx context.Context) (*proto.CompletedJob, *p
 	completedPlan, failed := r.buildWorkspace(ctx, "Planning infrastructure", &sdkproto.Provision_Request{
 		Type: &sdkproto.Provision_Request_Plan{
 			Plan: &sdkproto.Provision_Plan{
-				Config:          config,
-				ParameterValues: r.job.GetWorkspaceBuild().ParameterValues,
+				Config:              config,
+				ParameterValues:     r.job.GetWorkspaceBuild().ParameterValues,
+				RichParameterValues: r.job.GetWorkspaceBuild().RichParameterValues,
 			},
 		},
 	})
@@ -987,6 +1010,61 @@ func (r *Runner) runWorkspaceBuild(ctx context.Context) (*proto.CompletedJob, *p
 	}, nil
 }
 
+// awaitAgentsShutdown waits for the agents of the build being replaced to
+// finish running their shutdown scripts, so the scripts can complete
+// before the infrastructure is torn down. Failures are logged and don't
+// fail the build.
+func (r *Runner) awaitAgentsShutdown(ctx context.Context) {
+	if r.shutdownChecker == nil {
+		return
+	}
+
+	const stage = "Waiting for agents to shut down"
+	var deadline time.Time
+	ticker := time.NewTicker(agentsShutdownPollInterval)
+	defer ticker.Stop()
+	for {
+		resp, err := r.shutdownChecker.CheckAgentsShutdown(ctx, &proto.CheckAgentsShutdownRequest{
+			JobId: r.job.JobId,
+		})
+		if err != nil {
+			r.logger.Warn(ctx, "check workspace agents shutdown", slog.Error(err))
+			return
+		}
+		if !resp.Pending {
+			return
+		}
+		if deadline.IsZero() {
+			r.queueLog(ctx, &proto.Log{
+				Source:    proto.LogSource_PROVISIONER_DAEMON,
+				Level:     sdkproto.LogLevel_INFO,
+				Stage:     stage,
+				CreatedAt: time.Now().UnixMilli(),
+			})
+			// Allow some leeway for the agents to report their state.
+			deadline = time.Now().Add(time.Duration(resp.TimeoutSeconds)*time.Second + agentsShutdownPollInterval*5)
+		}
+		if time.Now().After(deadline) {
+			r.queueLog(ctx, &proto.Log{
+				Source:    proto.LogSource_PROVISIONER_DAEMON,
+				Level:     sdkproto.LogLevel_WARN,
+				Stage:     stage,
+				CreatedAt: time.Now().UnixMilli(),
+				Output:    "Timed out waiting for the shutdown scripts of the workspace agents.",
+			})
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.notCanceled.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
 func (r *Runner) failedJobf(format string, args ...interface{}) *proto.FailedJob {
 	return &proto.FailedJob{
 		JobId: r.job.JobId,
@@ -10,7 +10,9 @@ export interface APIKey {
   readonly updated_at: string
   readonly login_type: LoginType
   readonly scope: APIKeyScope
+  readonly token_name: string
   readonly lifetime_seconds: number
+  readonly allow_list: string[]
 }
 
 // From codersdk/licenses.go
@@ -29,6 +31,37 @@ export interface AgentStatsReportResponse {
   readonly num_comms: number
   readonly rx_bytes: number
   readonly tx_bytes: number
+  readonly session_count_ssh: number
+  readonly session_count_reconnecting_pty: number
+  readonly session_count_vscode: number
+  readonly session_count_jetbrains: number
+  readonly connection_count_ssh: number
+  readonly connection_count_reconnecting_pty: number
+  readonly connection_count_vscode: number
+  readonly connection_count_jetbrains: number
+  readonly cpu_used_cores: number
+  readonly cpu_total_cores: number
+  readonly memory_used_bytes: number
+  readonly memory_total_bytes: number
+  readonly disk_used_bytes: number
+  readonly disk_total_bytes: number
+}
+
+// From codersdk/insights.go
+export interface AppInsight {
+  readonly template_id: string
+  readonly template_name: string
+  readonly slug: string
+  readonly users: number
+  readonly usage_hours: number
+  readonly requests: number
+}
+
+// From codersdk/insights.go
+export interface AppInsightsResponse {
+  readonly start_time: string
+  readonly end_time: string
+  readonly apps: AppInsight[]
 }
 
 // From codersdk/roles.go
@@ -138,6 +171,16 @@ export interface ComputedParameter extends Parameter {
   readonly default_source_value: boolean
 }
 
+// From codersdk/roles.go
+export interface CreateCustomRoleRequest {
+  readonly name: string
+  readonly display_name: string
+  readonly organization_id?: string
+  readonly site_permissions: Permission[]
+  readonly org_permissions: Permission[]
+  readonly user_permissions: Permission[]
+}
+
 // From codersdk/users.go
 export interface CreateFirstUserRequest {
   readonly email: string
@@ -162,6 +205,7 @@ export interface CreateGroupRequest {
 // From codersdk/users.go
 export interface CreateOrganizationRequest {
   readonly name: string
+  readonly description?: string
 }
 
 // From codersdk/parameters.go
@@ -182,12 +226,21 @@ export interface CreateTemplateRequest {
   readonly template_version_id: string
   readonly parameter_values?: CreateParameterRequest[]
   readonly default_ttl_ms?: number
+  readonly inactivity_ttl_ms?: number
+  readonly stopped_ttl_ms?: number
+  readonly autodelete_warning_ttl_ms?: number
+  readonly max_ttl_ms?: number
+  readonly autostart_requirement?: TemplateAutostartRequirement
+  readonly quiet_hours?: TemplateQuietHours
+  readonly session_recording?: boolean
+  readonly auto_start_on_connect?: boolean
 }
 
 // From codersdk/templateversions.go
 export interface CreateTemplateVersionDryRunRequest {
   readonly workspace_name: string
   readonly parameter_values: CreateParameterRequest[]
+  readonly rich_parameter_values: WorkspaceBuildParameter[]
 }
 
 // From codersdk/organizations.go
@@ -211,7 +264,11 @@ export interface CreateTestAuditLogRequest {
 
 // From codersdk/apikey.go
 export interface CreateTokenRequest {
+  readonly token_name: string
+  // This is likely an enum in an external package ("time.Duration")
+  readonly lifetime: number
   readonly scope: APIKeyScope
+  readonly allow_list: string[]
 }
 
 // From codersdk/users.go
@@ -222,6 +279,13 @@ export interface CreateUserRequest {
   readonly organization_id: string
 }
 
+// From codersdk/webhooks.go
+export interface CreateWebhookRequest {
+  readonly name: string
+  readonly url: string
+  readonly events: WebhookEventType[]
+}
+
 // From codersdk/workspaces.go
 export interface CreateWorkspaceBuildRequest {
   readonly template_version_id?: string
@@ -230,6 +294,7 @@ export interface CreateWorkspaceBuildRequest {
   readonly state?: string
   readonly orphan?: boolean
   readonly parameter_values?: CreateParameterRequest[]
+  readonly rich_parameter_values?: WorkspaceBuildParameter[]
 }
 
 // From codersdk/organizations.go
@@ -239,6 +304,20 @@ export interface CreateWorkspaceRequest {
   readonly autostart_schedule?: string
   readonly ttl_ms?: number
   readonly parameter_values?: CreateParameterRequest[]
+  readonly rich_parameter_values?: WorkspaceBuildParameter[]
+}
+
+// From codersdk/roles.go
+export interface CustomRole {
+  readonly id: string
+  readonly name: string
+  readonly display_name: string
+  readonly organization_id?: string
+  readonly site_permissions: Permission[]
+  readonly org_permissions: Permission[]
+  readonly user_permissions: Permission[]
+  readonly created_at: string
+  readonly updated_at: string
 }
 
 // From codersdk/templates.go
@@ -305,7 +384,9 @@ export interface DeploymentConfig {
   readonly browser_only: DeploymentConfigField<boolean>
   readonly scim_api_key: DeploymentConfigField<string>
   readonly provisioner: ProvisionerConfig
+  readonly file_storage: FileStorageConfig
   readonly api_rate_limit: DeploymentConfigField<number>
+  readonly max_token_lifetime: DeploymentConfigField<number>
   readonly experimental: DeploymentConfigField<boolean>
 }
 
@@ -340,6 +421,24 @@ export interface Feature {
   readonly actual?: number
 }
 
+// From codersdk/deploymentconfig.go
+export interface FileStorageConfig {
+  readonly backend: DeploymentConfigField<string>
+  readonly local_path: DeploymentConfigField<string>
+  readonly s3: FileStorageS3Config
+}
+
+// From codersdk/deploymentconfig.go
+export interface FileStorageS3Config {
+  readonly endpoint: DeploymentConfigField<string>
+  readonly region: DeploymentConfigField<string>
+  readonly bucket: DeploymentConfigField<string>
+  readonly prefix: DeploymentConfigField<string>
+  readonly access_key_id: DeploymentConfigField<string>
+  readonly secret_access_key: DeploymentConfigField<string>
+  readonly use_path_style: DeploymentConfigField<boolean>
+}
+
 // From codersdk/apikey.go
 export interface GenerateAPIKeyResponse {
   readonly key: string
@@ -449,12 +548,18 @@ export interface OIDCConfig {
   readonly email_domain: DeploymentConfigField<string>
   readonly issuer_url: DeploymentConfigField<string>
   readonly scopes: DeploymentConfigField<string[]>
+  readonly group_field: DeploymentConfigField<string>
+  readonly group_mapping: DeploymentConfigField<string[]>
+  readonly create_missing_groups: DeploymentConfigField<boolean>
+  readonly role_field: DeploymentConfigField<string>
+  readonly role_mapping: DeploymentConfigField<string[]>
 }
 
 // From codersdk/organizations.go
 export interface Organization {
   readonly id: string
   readonly name: string
+  readonly description: string
   readonly created_at: string
   readonly updated_at: string
 }
@@ -468,6 +573,12 @@ export interface OrganizationMember {
   readonly roles: Role[]
 }
 
+// From codersdk/organizationmember.go
+export interface OrganizationMemberWithUser extends OrganizationMember {
+  readonly username: string
+  readonly email: string
+}
+
 // From codersdk/pagination.go
 export interface Pagination {
   readonly after_id?: string
@@ -517,6 +628,13 @@ export interface PatchGroupRequest {
   readonly quota_allowance?: number
 }
 
+// From codersdk/roles.go
+export interface Permission {
+  readonly negate: boolean
+  readonly resource_type: string
+  readonly action: string
+}
+
 // From codersdk/deploymentconfig.go
 export interface PprofConfig {
   readonly enable: DeploymentConfigField<boolean>
@@ -543,6 +661,7 @@ export interface ProvisionerDaemon {
   readonly name: string
   readonly provisioners: ProvisionerType[]
   readonly tags: Record<string, string>
+  readonly organization_id?: string
 }
 
 // From codersdk/provisionerdaemons.go
@@ -574,6 +693,20 @@ export interface PutExtendWorkspaceRequest {
   readonly deadline: string
 }
 
+// From codersdk/agentconn.go
+export interface ReconnectingPTYSession {
+  readonly id: string
+  readonly name: string
+  readonly command: string
+  readonly started_at: string
+  readonly connections: number
+}
+
+// From codersdk/agentconn.go
+export interface ReconnectingPTYSessionsResponse {
+  readonly sessions: ReconnectingPTYSession[]
+}
+
 // From codersdk/replicas.go
 export interface Replica {
   readonly id: string
@@ -640,6 +773,15 @@ export interface Template {
   readonly description: string
   readonly icon: string
   readonly default_ttl_ms: number
+  readonly inactivity_ttl_ms: number
+  readonly stopped_ttl_ms: number
+  readonly autodelete_warning_ttl_ms: number
+  readonly max_ttl_ms: number
+  readonly autostart_requirement: TemplateAutostartRequirement
+  readonly quiet_hours: TemplateQuietHours
+  readonly session_recording: boolean
+  readonly auto_start_on_connect: boolean
+  readonly max_port_sharing_level: WorkspaceAppSharingLevel
   readonly created_by_id: string
   readonly created_by_name: string
 }
@@ -650,6 +792,13 @@ export interface TemplateACL {
   readonly group: TemplateGroup[]
 }
 
+// From codersdk/templates.go
+export interface TemplateAutostartRequirement {
+  readonly days_of_week: string[]
+  readonly start_hour: number
+  readonly end_hour: number
+}
+
 // From codersdk/templates.go
 export interface TemplateBuildTimeStats {
   readonly start_ms?: number
@@ -667,6 +816,33 @@ export interface TemplateGroup extends Group {
   readonly role: TemplateRole
 }
 
+// From codersdk/insights.go
+export interface TemplateInsight {
+  readonly template_id: string
+  readonly template_name: string
+  readonly active_users: number
+  readonly active_hours: number
+  readonly connection_count: number
+  readonly ssh_connection_count: number
+  readonly reconnecting_pty_connection_count: number
+  readonly vscode_connection_count: number
+  readonly jetbrains_connection_count: number
+  readonly cost: number
+}
+
+// From codersdk/insights.go
+export interface TemplateInsightsResponse {
+  readonly start_time: string
+  readonly end_time: string
+  readonly templates: TemplateInsight[]
+}
+
+// From codersdk/templates.go
+export interface TemplateQuietHours {
+  readonly schedule: string
+  readonly duration_ms: number
+}
+
 // From codersdk/templates.go
 export interface TemplateUser extends User {
   readonly role: TemplateRole
@@ -685,6 +861,29 @@ export interface TemplateVersion {
   readonly created_by: User
 }
 
+// From codersdk/richparameters.go
+export interface TemplateVersionParameter {
+  readonly name: string
+  readonly description: string
+  readonly type: string
+  readonly mutable: boolean
+  readonly default_value: string
+  readonly icon: string
+  readonly options: TemplateVersionParameterOption[]
+  readonly validation_regex?: string
+  readonly validation_min?: number
+  readonly validation_max?: number
+  readonly validation_error?: string
+}
+
+// From codersdk/richparameters.go
+export interface TemplateVersionParameterOption {
+  readonly name: string
+  readonly description: string
+  readonly value: string
+  readonly icon: string
+}
+
 // From codersdk/templates.go
 export interface TemplateVersionsByTemplateRequest extends Pagination {
   readonly template_id: string
@@ -702,6 +901,25 @@ export interface UpdateActiveTemplateVersion {
   readonly id: string
 }
 
+// From codersdk/roles.go
+export interface UpdateCustomRoleRequest {
+  readonly display_name?: string
+  readonly site_permissions?: Permission[]
+  readonly org_permissions?: Permission[]
+  readonly user_permissions?: Permission[]
+}
+
+// From codersdk/organizations.go
+export interface UpdateOrganizationRequest {
+  readonly name?: string
+  readonly description?: string
+}
+
+// From codersdk/agentconn.go
+export interface UpdateReconnectingPTYSessionRequest {
+  readonly name: string
+}
+
 // From codersdk/users.go
 export interface UpdateRoles {
   readonly roles: string[]
@@ -720,6 +938,15 @@ export interface UpdateTemplateMeta {
   readonly description?: string
   readonly icon?: string
   readonly default_ttl_ms?: number
+  readonly inactivity_ttl_ms?: number
+  readonly stopped_ttl_ms?: number
+  readonly autodelete_warning_ttl_ms?: number
+  readonly max_ttl_ms?: number
+  readonly autostart_requirement?: TemplateAutostartRequirement
+  readonly quiet_hours?: TemplateQuietHours
+  readonly session_recording?: boolean
+  readonly auto_start_on_connect?: boolean
+  readonly max_port_sharing_level?: WorkspaceAppSharingLevel
 }
 
 // From codersdk/users.go
@@ -728,11 +955,23 @@ export interface UpdateUserPasswordRequest {
   readonly password: string
 }
 
+// From codersdk/users.go
+export interface UpdateUserPreferencesRequest {
+  readonly auto_start_on_connect: boolean
+}
+
 // From codersdk/users.go
 export interface UpdateUserProfileRequest {
   readonly username: string
 }
 
+// From codersdk/webhooks.go
+export interface UpdateWebhookRequest {
+  readonly name?: string
+  readonly url?: string
+  readonly events?: WebhookEventType[]
+}
+
 // From codersdk/workspaces.go
 export interface UpdateWorkspaceAutostartRequest {
   readonly schedule?: string
@@ -753,6 +992,14 @@ export interface UploadResponse {
   readonly hash: string
 }
 
+// From codersdk/portshares.go
+export interface UpsertWorkspaceAgentPortShareRequest {
+  readonly agent_name: string
+  readonly port: number
+  readonly protocol?: WorkspaceAgentPortShareProtocol
+  readonly sharing_level: WorkspaceAppSharingLevel
+}
+
 // From codersdk/users.go
 export interface User {
   readonly id: string
@@ -764,6 +1011,29 @@ export interface User {
   readonly organization_ids: string[]
   readonly roles: Role[]
   readonly avatar_url: string
+  readonly auto_start_on_connect: boolean
+}
+
+// From codersdk/insights.go
+export interface UserInsight {
+  readonly template_id: string
+  readonly template_name: string
+  readonly user_id: string
+  readonly username: string
+  readonly active_hours: number
+  readonly connection_count: number
+  readonly ssh_connection_count: number
+  readonly reconnecting_pty_connection_count: number
+  readonly vscode_connection_count: number
+  readonly jetbrains_connection_count: number
+  readonly cost: number
+}
+
+// From codersdk/insights.go
+export interface UserInsightsResponse {
+  readonly start_time: string
+  readonly end_time: string
+  readonly users: UserInsight[]
 }
 
 // From codersdk/users.go
@@ -783,6 +1053,77 @@ export interface ValidationError {
   readonly detail: string
 }
 
+// From codersdk/webhooks.go
+export interface Webhook {
+  readonly id: string
+  readonly organization_id: string
+  readonly name: string
+  readonly url: string
+  readonly events: WebhookEventType[]
+  readonly secret?: string
+  readonly created_at: string
+  readonly updated_at: string
+}
+
+// From codersdk/webhooks.go
+export interface WebhookDeliveriesRequest {
+  readonly status?: WebhookDeliveryStatus
+  readonly limit?: number
+}
+
+// From codersdk/webhooks.go
+export interface WebhookDelivery {
+  readonly id: string
+  readonly webhook_id: string
+  readonly event_id: string
+  readonly event_type: WebhookEventType
+  readonly status: WebhookDeliveryStatus
+  readonly attempts: number
+  readonly last_status_code: number
+  readonly last_error: string
+  readonly payload: Record<string, string>
+  readonly created_at: string
+  readonly updated_at: string
+}
+
+// From codersdk/webhooks.go
+export interface WebhookEvent {
+  readonly id: string
+  readonly type: WebhookEventType
+  readonly organization_id: string
+  readonly created_at: string
+  readonly data: Record<string, string>
+}
+
+// From codersdk/webhooks.go
+export interface WebhookTemplateVersionData {
+  readonly template_id: string
+  readonly template_name: string
+  readonly template_version_id: string
+  readonly template_version_name: string
+}
+
+// From codersdk/webhooks.go
+export interface WebhookWorkspaceAutostopData {
+  readonly workspace_id: string
+  readonly workspace_name: string
+  readonly workspace_owner_id: string
+  readonly build_id: string
+  readonly deadline: string
+}
+
+// From codersdk/webhooks.go
+export interface WebhookWorkspaceBuildData {
+  readonly workspace_id: string
+  readonly workspace_name: string
+  readonly workspace_owner_id: string
+  readonly template_id: string
+  readonly build_id: string
+  readonly build_number: number
+  readonly transition: WorkspaceTransition
+  readonly error?: string
+}
+
 // From codersdk/workspaces.go
 export interface Workspace {
   readonly id: string
@@ -800,6 +1141,7 @@ export interface Workspace {
   readonly autostart_schedule?: string
   readonly ttl_ms?: number
   readonly last_used_at: string
+  readonly deleting_at?: string
 }
 
 // From codersdk/workspaceagents.go
@@ -824,6 +1166,13 @@ export interface WorkspaceAgent {
   readonly latency?: Record<string, DERPRegion>
   readonly connection_timeout_seconds: number
   readonly troubleshooting_url: string
+  readonly lifecycle_state: WorkspaceAgentLifecycle
+  readonly startup_script_timeout_seconds: number
+  readonly shutdown_script?: string
+  readonly shutdown_script_timeout_seconds: number
+  readonly startup_logs_length: number
+  readonly startup_logs_overflowed: boolean
+  readonly metadata: WorkspaceAgentMetadataItem[]
 }
 
 // From codersdk/workspaceagents.go
@@ -846,6 +1195,46 @@ export interface WorkspaceAgentInstanceMetadata {
   readonly vnc: boolean
 }
 
+// From codersdk/workspaceagents.go
+export interface WorkspaceAgentMetadataDescription {
+  readonly display_name: string
+  readonly key: string
+  readonly script: string
+  readonly interval: number
+  readonly timeout: number
+}
+
+// From codersdk/workspaceagents.go
+export interface WorkspaceAgentMetadataItem {
+  readonly description: WorkspaceAgentMetadataDescription
+  readonly result: WorkspaceAgentMetadataResult
+}
+
+// From codersdk/workspaceagents.go
+export interface WorkspaceAgentMetadataResult {
+  readonly collected_at: string
+  readonly age: number
+  readonly value: string
+  readonly error: string
+}
+
+// From codersdk/portshares.go
+export interface WorkspaceAgentPortShare {
+  readonly workspace_id: string
+  readonly agent_name: string
+  readonly port: number
+  readonly protocol: WorkspaceAgentPortShareProtocol
+  readonly sharing_level: WorkspaceAppSharingLevel
+  readonly created_at: string
+  readonly updated_at: string
+}
+
+// From codersdk/portshares.go
+export interface WorkspaceAgentPortShares {
+  readonly shares: WorkspaceAgentPortShare[]
+  readonly max_sharing_level: WorkspaceAppSharingLevel
+}
+
 // From codersdk/workspaceagents.go
 export interface WorkspaceAgentResourceMetadata {
   readonly memory_total: number
@@ -855,6 +1244,21 @@ export interface WorkspaceAgentResourceMetadata {
   readonly cpu_mhz: number
 }
 
+// From codersdk/workspaceagents.go
+export interface WorkspaceAgentStartupLog {
+  readonly id: number
+  readonly created_at: string
+  readonly output: string
+}
+
+// From codersdk/workspaces.go
+export interface WorkspaceAgentStats {
+  readonly agent_id: string
+  readonly agent_name: string
+  readonly created_at: string
+  readonly stats: AgentStatsReportResponse
+}
+
 // From codersdk/workspaceapps.go
 export interface WorkspaceApp {
   readonly id: string
@@ -890,6 +1294,12 @@ export interface WorkspaceBuild {
   readonly daily_cost: number
 }
 
+// From codersdk/richparameters.go
+export interface WorkspaceBuildParameter {
+  readonly name: string
+  readonly value: string
+}
+
 // From codersdk/workspaces.go
 export interface WorkspaceBuildsRequest extends Pagination {
   readonly WorkspaceID: string
@@ -934,6 +1344,33 @@ export interface WorkspaceResourceMetadata {
   readonly sensitive: boolean
 }
 
+// From codersdk/sessionrecordings.go
+export interface WorkspaceSessionRecording {
+  readonly id: string
+  readonly created_at: string
+  readonly organization_id: string
+  readonly workspace_id: string
+  readonly workspace_name: string
+  readonly agent_id: string
+  readonly owner_id: string
+  readonly owner_name: string
+  readonly type: SessionRecordingType
+  readonly command: string
+  readonly started_at: string
+  readonly ended_at: string
+}
+
+// From codersdk/sessionrecordings.go
+export interface WorkspaceSessionRecordingsRequest extends Pagination {
+  readonly workspace_id?: string
+  readonly owner_id?: string
+}
+
+// From codersdk/workspaces.go
+export interface WorkspaceStats {
+  readonly agents: WorkspaceAgentStats[]
+}
+
 // From codersdk/workspaces.go
 export interface WorkspacesRequest extends Pagination {
   readonly q?: string
@@ -946,17 +1383,31 @@ export interface WorkspacesResponse {
 }
 
 // From codersdk/apikey.go
-export type APIKeyScope = "all" | "application_connect"
+export type APIKeyScope =
+  | "all"
+  | "application_connect"
+  | "read"
+  | "template_push"
+  | "workspace_start_stop"
 
 // From codersdk/audit.go
-export type AuditAction = "create" | "delete" | "start" | "stop" | "write"
+export type AuditAction =
+  | "create"
+  | "delete"
+  | "start"
+  | "stop"
+  | "use"
+  | "write"
 
 // From codersdk/workspacebuilds.go
-export type BuildReason = "autostart" | "autostop" | "initiator"
+export type BuildReason = "autodelete" | "autostart" | "autostop" | "initiator"
 
 // From codersdk/features.go
 export type Entitlement = "entitled" | "grace_period" | "not_entitled"
 
+// From codersdk/insights.go
+export type InsightsReport = "apps" | "templates" | "users"
+
 // From codersdk/agentconn.go
 export type ListeningPortNetwork = "tcp"
 
@@ -1002,24 +1453,56 @@ export type ProvisionerType = "echo" | "terraform"
 // From codersdk/audit.go
 export type ResourceType =
   | "api_key"
+  | "custom_role"
   | "git_ssh_key"
   | "group"
   | "organization"
+  | "organization_member"
   | "template"
   | "template_version"
   | "user"
+  | "webhook"
   | "workspace"
   | "workspace_build"
+  | "workspace_session_recording"
 
 // From codersdk/sse.go
 export type ServerSentEventType = "data" | "error" | "ping"
 
+// From codersdk/sessionrecordings.go
+export type SessionRecordingType = "reconnecting_pty" | "ssh"
+
 // From codersdk/templates.go
 export type TemplateRole = "" | "admin" | "use"
 
 // From codersdk/users.go
 export type UserStatus = "active" | "suspended"
 
+// From codersdk/webhooks.go
+export type WebhookDeliveryStatus = "delivered" | "failed" | "pending"
+
+// From codersdk/webhooks.go
+export type WebhookEventType =
+  | "template_version.promoted"
+  | "workspace.autostop"
+  | "workspace_build.failed"
+  | "workspace_build.succeeded"
+
+// From codersdk/workspaceagents.go
+export type WorkspaceAgentLifecycle =
+  | "created"
+  | "off"
+  | "ready"
+  | "shutdown_error"
+  | "shutdown_timeout"
+  | "shutting_down"
+  | "start_error"
+  | "start_timeout"
+  | "starting"
+
+// From codersdk/portshares.go
+export type WorkspaceAgentPortShareProtocol = "tcp" | "udp"
+
 // From codersdk/workspaceagents.go
 export type WorkspaceAgentStatus =
   | "connected"
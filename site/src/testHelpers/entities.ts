@@ -81,6 +81,7 @@ export const MockUser: TypesGen.User = {
   roles: [MockOwnerRole],
   avatar_url: "https://avatars.githubusercontent.com/u/95932066?s=200&v=4",
   last_seen_at: "",
+  auto_start_on_connect: false,
 }
 
 export const MockUserAdmin: TypesGen.User = {
@@ -93,6 +94,7 @@ export const MockUserAdmin: TypesGen.User = {
   roles: [MockUserAdminRole],
   avatar_url: "",
   last_seen_at: "",
+  auto_start_on_connect: false,
 }
 
 export const MockUser2: TypesGen.User = {
@@ -105,6 +107,7 @@ export const MockUser2: TypesGen.User = {
   roles: [],
   avatar_url: "",
   last_seen_at: "2022-09-14T19:12:21Z",
+  auto_start_on_connect: false,
 }
 
 export const SuspendedMockUser: TypesGen.User = {
@@ -117,6 +120,7 @@ export const SuspendedMockUser: TypesGen.User = {
   roles: [],
   avatar_url: "",
   last_seen_at: "",
+  auto_start_on_connect: false,
 }
 
 export const MockOrganization: TypesGen.Organization = {
@@ -199,6 +203,30 @@ export const MockTemplate: TypesGen.Template = {
   },
   description: "This is a test description.",
   default_ttl_ms: 24 * 60 * 60 * 1000,
+  inactivity_ttl_ms: 0,
+  stopped_ttl_ms: 0,
+  autodelete_warning_ttl_ms: 24 * 60 * 60 * 1000,
+  max_ttl_ms: 0,
+  autostart_requirement: {
+    days_of_week: [
+      "sunday",
+      "monday",
+      "tuesday",
+      "wednesday",
+      "thursday",
+      "friday",
+      "saturday",
+    ],
+    start_hour: 0,
+    end_hour: 24,
+  },
+  quiet_hours: {
+    schedule: "",
+    duration_ms: 0,
+  },
+  session_recording: false,
+  auto_start_on_connect: false,
+  max_port_sharing_level: "owner",
   created_by_id: "test-creator-id",
   created_by_name: "test_creator",
   icon: "/icon/code.svg",
@@ -239,6 +267,12 @@ export const MockWorkspaceAgent: TypesGen.WorkspaceAgent = {
   },
   connection_timeout_seconds: 120,
   troubleshooting_url: "https://coder.com/troubleshoot",
+  lifecycle_state: "ready",
+  startup_script_timeout_seconds: 0,
+  shutdown_script_timeout_seconds: 0,
+  startup_logs_length: 0,
+  startup_logs_overflowed: false,
+  metadata: [],
 }
 
 export const MockWorkspaceAgentDisconnected: TypesGen.WorkspaceAgent = {
@@ -80,6 +80,7 @@ export const getDisplayWorkspaceBuildInitiatedBy = (
       return build.initiator_name
     case "autostart":
     case "autostop":
+    case "autodelete":
       return "Coder"
   }
 }